@@ -1,20 +1,21 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 
 	awsSDK "github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/cloudformation"
-	"github.com/aws/aws-sdk-go/service/ec2"
-	"github.com/aws/aws-sdk-go/service/route53"
-	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/giantswarm/microerror"
 	"github.com/spf13/cobra"
 
 	"github.com/giantswarm/ci-cleaner/pkg/cleaner/aws"
+	"github.com/giantswarm/ci-cleaner/pkg/config"
 	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/recording"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
 )
 
 var (
@@ -29,54 +30,113 @@ var (
 	accessKeyID     string
 	secretAccessKey string
 	region          string
+
+	recordDir string
+	replayDir string
 )
 
 func init() {
 	AwsCmd.Flags().StringVar(&accessKeyID, "access-key-id", "", "Access key ID.")
 	AwsCmd.Flags().StringVar(&secretAccessKey, "secret-access-key", "", "Secret access key.")
 	AwsCmd.Flags().StringVar(&region, "region", "", "Region.")
+	AwsCmd.Flags().StringVar(&recordDir, "record", "", "Directory the responses of the AWS API read calls are recorded in, sanitized, for replaying the run with --replay.")
+	AwsCmd.Flags().StringVar(&replayDir, "replay", "", "Directory of a run recorded with --record to replay. Nothing is deleted and nothing but the report is written.")
 }
 
 // runAws runs the AWS related cleaner jobs, prints error output
 // and exits with a non-zero exit case when errors occur.
 func runAws(cmd *cobra.Command, args []string) {
-	awsCfg := &awsSDK.Config{
-		Credentials: credentials.NewStaticCredentials(accessKeyID, secretAccessKey, ""),
-		Region:      awsSDK.String(region),
-	}
-	s, err := session.NewSession(awsCfg)
-	if err != nil {
-		fmt.Printf("Problem setting up a new AWS session: %#v\n", err)
+	if recordDir != "" && replayDir != "" {
+		fmt.Println("--record and --replay must not be given together")
 		os.Exit(1)
 	}
-	cfClient := cloudformation.New(s)
-	ec2Client := ec2.New(s)
-	route53Client := route53.New(s)
-	s3Client := s3.New(s)
-
-	c := &aws.Config{
-		CFClient:      cfClient,
-		EC2Client:     ec2Client,
-		Logger:        logger,
-		Route53Client: route53Client,
-		S3Client:      s3Client,
-	}
 
-	a, err := aws.New(c)
+	profile, err := loadProfile()
 	if err != nil {
-		fmt.Printf("Problem creating the AWS cleaner: %#v\n", err)
+		fmt.Printf("Problem loading the profile: %#v\n", err)
 		os.Exit(1)
 	}
+	for name, value := range map[string]string{
+		"access-key-id":     profile.AWS.AccessKeyID,
+		"region":            profile.AWS.Region,
+		"secret-access-key": profile.AWS.SecretAccessKey,
+	} {
+		err = setFromProfile(cmd, name, value)
+		if err != nil {
+			fmt.Printf("Problem applying the profile: %#v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	profile.AWS.AccessKeyID = accessKeyID
+	profile.AWS.Region = region
+	profile.AWS.SecretAccessKey = secretAccessKey
 
-	err = a.Clean()
+	err = sweep(context.Background(), "aws", profile, run.Scope{}, nil)
 	if err != nil {
 		// Print our collected errors
-		if errors, ok := err.(*errorcollection.ErrorCollection); ok {
+		if errors, ok := microerror.Cause(err).(*errorcollection.ErrorCollection); ok {
 			fmt.Println("\nErrors:")
 			fmt.Println(errors.Dump())
+		} else {
+			fmt.Printf("Problem running the AWS cleaner: %#v\n", err)
 		}
 
 		os.Exit(1)
 	}
 
 }
+
+func newAWSCleaner(profile config.Profile, r *runner) (*aws.Cleaner, error) {
+	awsCfg := &awsSDK.Config{
+		Credentials: credentials.NewStaticCredentials(profile.AWS.AccessKeyID, profile.AWS.SecretAccessKey, ""),
+		Region:      awsSDK.String(profile.AWS.Region),
+	}
+	s, err := session.NewSession(awsCfg)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	if recordDir != "" {
+		err = recording.Record(&s.Handlers, recordDir)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+	if replayDir != "" {
+		recording.Replay(&s.Handlers, replayDir, r.replayShift)
+	}
+
+	c := aws.ConfigFromSession(s)
+	c.Logger = logger
+	c.Run = r.run
+
+	c.AcceleratorGracePeriod = profile.AWS.AcceleratorGracePeriod.Duration
+	c.AccountRetention = profile.AWS.AccountRetention.Duration
+	c.AMIRetention = profile.AWS.AMIRetention.Duration
+	c.ChartRepositories = profile.AWS.Charts.Repositories
+	c.ChartRetention = profile.AWS.Charts.Retention.Duration
+	c.ChartSuffixes = profile.AWS.Charts.Suffixes
+	c.Tables = tablesFromProfile(profile.AWS.TableData)
+	c.TableDataRetention = profile.AWS.TableData.Retention.Duration
+	c.GracePeriod = profile.GracePeriod.Duration
+	c.MaxVolumesPerRun = profile.AWS.MaxVolumesPerRun
+	c.LogGroupRetentionDays = profile.AWS.LogGroupRetentionDays
+	c.Prefixes = profile.Prefixes
+	c.Queries = profile.AWS.Queries
+	c.CIPrincipals = profile.AWS.CIPrincipals
+
+	c.CloseAccounts = profile.AWS.CloseAccounts
+	c.DeleteCloudHSMClusters = profile.AWS.DeleteCloudHSMClusters
+	c.DeprovisionBYOIPCIDRs = profile.AWS.DeprovisionBYOIPCIDRs
+	c.DeleteTableData = profile.AWS.TableData.Delete
+	c.DisableMacie = profile.AWS.DisableMacie
+	c.TerminateProtectedEMRClusters = profile.AWS.TerminateProtectedEMRClusters
+
+	a, err := aws.New(c)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return a, nil
+}
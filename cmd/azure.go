@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/Azure/azure-sdk-for-go/services/dns/mgmt/2017-10-01/dns"
+	"github.com/Azure/azure-sdk-for-go/services/keyvault/mgmt/2018-02-14/keyvault"
 	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-12-01/network"
 	"github.com/Azure/azure-sdk-for-go/services/preview/monitor/mgmt/2018-03-01/insights"
 	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2018-02-01/resources"
@@ -15,6 +16,8 @@ import (
 	"github.com/spf13/cobra"
 
 	pkgazure "github.com/giantswarm/ci-cleaner/pkg/cleaner/azure"
+	"github.com/giantswarm/ci-cleaner/pkg/config"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
 )
 
 var (
@@ -25,6 +28,11 @@ var (
 	}
 )
 
+const (
+	defaultAzureInstallations = "ghost,godsmack"
+	defaultAzureLocation      = "westeurope"
+)
+
 var (
 	azureClientID       string
 	azureClientSecret   string
@@ -37,61 +45,143 @@ var (
 func init() {
 	AzureCmd.Flags().StringVar(&azureClientID, "client-id", "", "Client ID.")
 	AzureCmd.Flags().StringVar(&azureClientSecret, "client-secret", "", "Client secret.")
-	AzureCmd.Flags().StringVar(&azureInstallations, "installations", "ghost,godsmack", "Comma separated list of installation names to cleanup.")
-	AzureCmd.Flags().StringVar(&azureLocation, "location", "westeurope", "Location.")
+	AzureCmd.Flags().StringVar(&azureInstallations, "installations", defaultAzureInstallations, "Comma separated list of installation names to cleanup.")
+	AzureCmd.Flags().StringVar(&azureLocation, "location", defaultAzureLocation, "Location.")
 	AzureCmd.Flags().StringVar(&azureSubscriptionID, "subscription-id", "", "Subscription ID.")
 	AzureCmd.Flags().StringVar(&azureTenantID, "tenant-id", "", "Tenant ID.")
 }
 
 func runAzure(cmd *cobra.Command, args []string) error {
-	var err error
+	profile, err := loadProfile()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	{
+		for name, value := range map[string]string{
+			"client-id":       profile.Azure.ClientID,
+			"client-secret":   profile.Azure.ClientSecret,
+			"location":        profile.Azure.Location,
+			"subscription-id": profile.Azure.SubscriptionID,
+			"tenant-id":       profile.Azure.TenantID,
+		} {
+			err = setFromProfile(cmd, name, value)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+
+		err = setListFromProfile(cmd, "installations", profile.Azure.Installations)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	profile.Azure.ClientID = azureClientID
+	profile.Azure.ClientSecret = azureClientSecret
+	profile.Azure.Installations = strings.Split(azureInstallations, ",")
+	profile.Azure.Location = azureLocation
+	profile.Azure.SubscriptionID = azureSubscriptionID
+	profile.Azure.TenantID = azureTenantID
+
+	err = sweep(context.Background(), "azure", profile, run.Scope{}, nil)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func newAzureCleaner(profile config.Profile, r *runner) (*pkgazure.Cleaner, error) {
+	installations := profile.Azure.Installations
+	if len(installations) == 0 {
+		installations = strings.Split(defaultAzureInstallations, ",")
+	}
+	location := profile.Azure.Location
+	if location == "" {
+		location = defaultAzureLocation
+	}
+	subscriptionID := profile.Azure.SubscriptionID
 
 	var servicePrincipalToken *adal.ServicePrincipalToken
+	var keyVaultToken *adal.ServicePrincipalToken
+	var cosmosToken *adal.ServicePrincipalToken
+	var graphToken *adal.ServicePrincipalToken
 	{
 		env, err := azure.EnvironmentFromName(azure.PublicCloud.Name)
 		if err != nil {
-			return microerror.Mask(err)
+			return nil, microerror.Mask(err)
 		}
 
-		oauthConfig, err := adal.NewOAuthConfig(env.ActiveDirectoryEndpoint, azureTenantID)
+		oauthConfig, err := adal.NewOAuthConfig(env.ActiveDirectoryEndpoint, profile.Azure.TenantID)
 		if err != nil {
-			return microerror.Mask(err)
+			return nil, microerror.Mask(err)
 		}
 
-		servicePrincipalToken, err = adal.NewServicePrincipalToken(*oauthConfig, azureClientID, azureClientSecret, env.ServiceManagementEndpoint)
+		servicePrincipalToken, err = adal.NewServicePrincipalToken(*oauthConfig, profile.Azure.ClientID, profile.Azure.ClientSecret, env.ServiceManagementEndpoint)
 		if err != nil {
-			return microerror.Mask(err)
+			return nil, microerror.Mask(err)
 		}
-	}
 
-	var azureCleaner *pkgazure.Cleaner
-	{
-		c := pkgazure.CleanerConfig{
-			Logger: logger,
-
-			ActivityLogsClient:                     newActivityLogsClient(azureSubscriptionID, servicePrincipalToken),
-			DNSRecordSetsClient:                    newDNSRecordSetsClient(azureSubscriptionID, servicePrincipalToken),
-			GroupsClient:                           newGroupsClient(azureSubscriptionID, servicePrincipalToken),
-			VirtualNetworkPeeringsClient:           newVirtualNetworkPeeringsClient(azureSubscriptionID, servicePrincipalToken),
-			VirtualNetworkGatewayConnectionsClient: newVirtualNetworkGatewayConnectionsClient(azureSubscriptionID, servicePrincipalToken),
-			VirtualNetworksClient:                  newVirtualNetworksClient(azureSubscriptionID, servicePrincipalToken),
-
-			Installations: strings.Split(azureInstallations, ","),
-			AzureLocation: azureLocation,
+		keyVaultToken, err = adal.NewServicePrincipalToken(*oauthConfig, profile.Azure.ClientID, profile.Azure.ClientSecret, env.ResourceIdentifiers.KeyVault)
+		if err != nil {
+			return nil, microerror.Mask(err)
 		}
 
-		azureCleaner, err = pkgazure.NewCleaner(c)
+		cosmosToken, err = adal.NewServicePrincipalToken(*oauthConfig, profile.Azure.ClientID, profile.Azure.ClientSecret, pkgazure.CosmosDBResource)
 		if err != nil {
-			return microerror.Mask(err)
+			return nil, microerror.Mask(err)
+		}
+
+		graphToken, err = adal.NewServicePrincipalToken(*oauthConfig, profile.Azure.ClientID, profile.Azure.ClientSecret, pkgazure.GraphResource)
+		if err != nil {
+			return nil, microerror.Mask(err)
 		}
 	}
 
-	err = azureCleaner.Clean(context.Background())
+	c := pkgazure.CleanerConfig{
+		Logger: logger,
+		Run:    r.run,
+
+		ActivityLogsClient:                     newActivityLogsClient(subscriptionID, servicePrincipalToken),
+		ARMClient:                              newARMClient(subscriptionID, servicePrincipalToken),
+		CosmosClient:                           newCosmosClient(cosmosToken),
+		DNSRecordSetsClient:                    newDNSRecordSetsClient(subscriptionID, servicePrincipalToken),
+		GraphClient:                            newGraphClient(graphToken),
+		GroupsClient:                           newGroupsClient(subscriptionID, servicePrincipalToken),
+		KeyVaultClient:                         newARMClient(subscriptionID, keyVaultToken),
+		RegistryClient:                         newRegistryClient(profile.Azure.TenantID, servicePrincipalToken),
+		VaultsClient:                           newVaultsClient(subscriptionID, servicePrincipalToken),
+		VirtualNetworkPeeringsClient:           newVirtualNetworkPeeringsClient(subscriptionID, servicePrincipalToken),
+		VirtualNetworkGatewayConnectionsClient: newVirtualNetworkGatewayConnectionsClient(subscriptionID, servicePrincipalToken),
+		VirtualNetworksClient:                  newVirtualNetworksClient(subscriptionID, servicePrincipalToken),
+
+		Installations:     installations,
+		AzureLocation:     location,
+		CertificateVaults: profile.Azure.CertificateVaults,
+		ChartRepositories: profile.Azure.Charts.Repositories,
+		ChartRetention:    profile.Azure.Charts.Retention.Duration,
+		ChartSuffixes:     profile.Azure.Charts.Suffixes,
+		CIPrincipals:      profile.Azure.CIPrincipals,
+		GracePeriod:       profile.GracePeriod.Duration,
+		Prefixes:          profile.Prefixes,
+		PurgeHSMs:         profile.Azure.PurgeHSMs,
+
+		Tables:             tablesFromProfile(profile.Azure.TableData),
+		TableDataRetention: profile.Azure.TableData.Retention.Duration,
+
+		DeleteApplications:        profile.Azure.DeleteApplications,
+		DeleteTableData:           profile.Azure.TableData.Delete,
+		RecordRoleAssignmentDrift: profile.Azure.RecordRoleAssignmentDrift,
+
+		DeleteOrphanedRoleAssignments: profile.Azure.DeleteOrphanedRoleAssignments,
+	}
+
+	azureCleaner, err := pkgazure.NewCleaner(c)
 	if err != nil {
-		return microerror.Mask(err)
+		return nil, microerror.Mask(err)
 	}
 
-	return nil
+	return azureCleaner, nil
 }
 
 func newActivityLogsClient(azureSubscriptionID string, servicePrincipalToken *adal.ServicePrincipalToken) *insights.ActivityLogsClient {
@@ -101,6 +191,19 @@ func newActivityLogsClient(azureSubscriptionID string, servicePrincipalToken *ad
 	return &c
 }
 
+func newARMClient(azureSubscriptionID string, servicePrincipalToken *adal.ServicePrincipalToken) *pkgazure.ARMClient {
+	c := pkgazure.NewARMClient(azureSubscriptionID)
+	c.Authorizer = autorest.NewBearerAuthorizer(servicePrincipalToken)
+
+	return &c
+}
+
+func newCosmosClient(servicePrincipalToken *adal.ServicePrincipalToken) *pkgazure.CosmosClient {
+	c := pkgazure.NewCosmosClient(servicePrincipalToken)
+
+	return &c
+}
+
 func newDNSRecordSetsClient(azureSubscriptionID string, servicePrincipalToken *adal.ServicePrincipalToken) *dns.RecordSetsClient {
 	c := dns.NewRecordSetsClient(azureSubscriptionID)
 	c.Authorizer = autorest.NewBearerAuthorizer(servicePrincipalToken)
@@ -108,6 +211,13 @@ func newDNSRecordSetsClient(azureSubscriptionID string, servicePrincipalToken *a
 	return &c
 }
 
+func newGraphClient(servicePrincipalToken *adal.ServicePrincipalToken) *pkgazure.GraphClient {
+	c := pkgazure.NewGraphClient()
+	c.Authorizer = autorest.NewBearerAuthorizer(servicePrincipalToken)
+
+	return &c
+}
+
 func newGroupsClient(azureSubscriptionID string, servicePrincipalToken *adal.ServicePrincipalToken) *resources.GroupsClient {
 	c := resources.NewGroupsClient(azureSubscriptionID)
 	c.Authorizer = autorest.NewBearerAuthorizer(servicePrincipalToken)
@@ -115,6 +225,19 @@ func newGroupsClient(azureSubscriptionID string, servicePrincipalToken *adal.Ser
 	return &c
 }
 
+func newRegistryClient(tenantID string, servicePrincipalToken *adal.ServicePrincipalToken) *pkgazure.RegistryClient {
+	c := pkgazure.NewRegistryClient(tenantID, servicePrincipalToken)
+
+	return &c
+}
+
+func newVaultsClient(azureSubscriptionID string, servicePrincipalToken *adal.ServicePrincipalToken) *keyvault.VaultsClient {
+	c := keyvault.NewVaultsClient(azureSubscriptionID)
+	c.Authorizer = autorest.NewBearerAuthorizer(servicePrincipalToken)
+
+	return &c
+}
+
 func newVirtualNetworkPeeringsClient(azureSubscriptionID string, servicePrincipalToken *adal.ServicePrincipalToken) *network.VirtualNetworkPeeringsClient {
 	c := network.NewVirtualNetworkPeeringsClient(azureSubscriptionID)
 	c.Authorizer = autorest.NewBearerAuthorizer(servicePrincipalToken)
@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/spf13/cobra"
+
+	"github.com/giantswarm/ci-cleaner/pkg/cleaner/aws"
+	pkgazure "github.com/giantswarm/ci-cleaner/pkg/cleaner/azure"
+	"github.com/giantswarm/ci-cleaner/pkg/config"
+	"github.com/giantswarm/ci-cleaner/pkg/protection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+	"github.com/giantswarm/ci-cleaner/pkg/server"
+	"github.com/giantswarm/ci-cleaner/pkg/state"
+)
+
+const (
+	// sweepQueueSize is the number of sweeps which may be waiting for a
+	// running sweep to finish.
+	sweepQueueSize = 16
+)
+
+var (
+	DaemonCmd = &cobra.Command{
+		Use:   "daemon",
+		Short: "Run scheduled and on-demand cleanups.",
+		Long: `Run scheduled and on-demand cleanups.
+
+Provider credentials are read from the profile. Sweeps scoped to a single
+cluster can be triggered with authenticated POST requests to /trigger.`,
+		RunE: runDaemon,
+	}
+)
+
+var (
+	daemonInterval      time.Duration
+	daemonListenAddress string
+	daemonOIDCAudience  string
+	daemonOIDCIssuer    string
+	daemonProviders     string
+	daemonSlackSecret   string
+	daemonTriggerToken  string
+
+	daemonOIDCRepositories string
+	daemonOIDCSubjects     string
+)
+
+func init() {
+	DaemonCmd.Flags().DurationVar(&daemonInterval, "interval", 0, "Interval of scheduled sweeps of all providers. Only triggered sweeps run when zero.")
+	DaemonCmd.Flags().StringVar(&daemonListenAddress, "listen-address", ":8000", "Address the HTTP API listens on.")
+	DaemonCmd.Flags().StringVar(&daemonOIDCAudience, "oidc-audience", "ci-cleaner", "Audience OIDC tokens have to be issued for.")
+	DaemonCmd.Flags().StringVar(&daemonOIDCIssuer, "oidc-issuer", "", "URL of the OIDC issuer whose ID tokens are accepted by /trigger.")
+	DaemonCmd.Flags().StringVar(&daemonOIDCRepositories, "oidc-repositories", "", "Comma separated list of repositories whose OIDC tokens are accepted, as told by their repository claim.")
+	DaemonCmd.Flags().StringVar(&daemonOIDCSubjects, "oidc-subjects", "", "Comma separated list of subjects whose OIDC tokens are accepted. A trailing * matches any suffix.")
+	DaemonCmd.Flags().StringVar(&daemonProviders, "providers", "aws", "Comma separated list of providers to sweep.")
+	DaemonCmd.Flags().StringVar(&daemonSlackSecret, "slack-signing-secret", "", "Signing secret of the Slack app sending the /cleaner slash-command. The command is disabled when empty.")
+	DaemonCmd.Flags().StringVar(&daemonTriggerToken, "trigger-token", "", "Bearer token accepted by /trigger.")
+}
+
+type sweepRequest struct {
+	provider string
+	scope    run.Scope
+}
+
+func runDaemon(cmd *cobra.Command, args []string) error {
+	profile, err := loadProfile()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	providers := strings.Split(daemonProviders, ",")
+
+	var authenticators server.Authenticators
+	{
+		if daemonTriggerToken != "" {
+			authenticators = append(authenticators, server.TokenAuthenticator{Token: daemonTriggerToken})
+		}
+
+		if daemonOIDCIssuer != "" {
+			c := server.OIDCAuthenticatorConfig{
+				Issuer:   daemonOIDCIssuer,
+				Audience: daemonOIDCAudience,
+
+				Repositories: splitList(daemonOIDCRepositories),
+				Subjects:     splitList(daemonOIDCSubjects),
+			}
+
+			a, err := server.NewOIDCAuthenticator(c)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+			authenticators = append(authenticators, a)
+		}
+
+		if len(authenticators) == 0 {
+			return microerror.Maskf(invalidFlagError, "--trigger-token or --oidc-issuer must not be empty")
+		}
+	}
+
+	// The state is shared between all sweeps and the API, so that e.g.
+	// protections take effect immediately.
+	stateStore, err := newStateStore()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	var newProtection *protection.Protection
+	{
+		c := protection.Config{
+			State: stateStore,
+		}
+
+		newProtection, err = protection.New(c)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	queue := make(chan sweepRequest, sweepQueueSize)
+
+	var s *server.Server
+	{
+		c := server.Config{
+			Authenticator: authenticators,
+			Logger:        logger,
+			Trigger: func(r server.TriggerRequest) error {
+				req := sweepRequest{
+					provider: r.Provider,
+					scope: run.Scope{
+						Cleaners:  r.Cleaners,
+						ClusterID: r.ClusterID,
+					},
+				}
+
+				select {
+				case queue <- req:
+					return nil
+				default:
+					return microerror.Maskf(queueFullError, "%d sweeps waiting", sweepQueueSize)
+				}
+			},
+
+			Metrics:   runMetrics,
+			Providers: providers,
+			Cleaners:  cleanerNames(providers),
+
+			Protection:         newProtection,
+			Persist:            stateStore.Flush,
+			SlackSigningSecret: daemonSlackSecret,
+		}
+
+		s, err = server.New(c)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	go sweepQueue(profile, stateStore, queue)
+
+	if daemonInterval != 0 {
+		go func() {
+			for {
+				for _, p := range providers {
+					queue <- sweepRequest{provider: p}
+				}
+
+				time.Sleep(daemonInterval)
+			}
+		}()
+	}
+
+	logger.Log("level", "info", "message", fmt.Sprintf("listening on %s", daemonListenAddress))
+
+	err = http.ListenAndServe(daemonListenAddress, s.Handler())
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// cleanerNames returns the names of the cleaners of each of the given
+// providers. The external systems have no cleaners triggered sweeps can be
+// restricted to.
+func cleanerNames(providers []string) map[string][]string {
+	names := map[string][]string{}
+	for _, p := range providers {
+		switch p {
+		case "aws":
+			names[p] = (&aws.Cleaner{}).Names()
+		case "azure":
+			names[p] = pkgazure.Cleaner{}.Names()
+		}
+	}
+
+	return names
+}
+
+// splitList splits the given comma separated list, ignoring empty elements.
+func splitList(s string) []string {
+	var l []string
+	for _, e := range strings.Split(s, ",") {
+		e = strings.TrimSpace(e)
+		if e != "" {
+			l = append(l, e)
+		}
+	}
+
+	return l
+}
+
+// sweepQueue runs the requested sweeps one after another, as they share the
+// state and audit files.
+func sweepQueue(profile config.Profile, stateStore *state.Store, queue <-chan sweepRequest) {
+	for req := range queue {
+		logger.Log("level", "info", "message", fmt.Sprintf("running %s sweep", req.provider), "clusterID", req.scope.ClusterID)
+
+		err := sweep(context.Background(), req.provider, profile, req.scope, stateStore)
+		if err != nil {
+			logger.Log("level", "error", "message", fmt.Sprintf("failed to run %s sweep", req.provider), "stack", fmt.Sprintf("%#v", err))
+		}
+	}
+}
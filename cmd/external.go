@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/giantswarm/microerror"
+	"github.com/spf13/cobra"
+
+	"github.com/giantswarm/ci-cleaner/pkg/cleaner/external"
+	"github.com/giantswarm/ci-cleaner/pkg/config"
+	"github.com/giantswarm/ci-cleaner/pkg/plugin"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+var (
+	ExternalCmd = &cobra.Command{
+		Use:   "external",
+		Short: "Cleanup leftover CI artifacts in external systems.",
+		Long: `Cleanup leftover CI artifacts in external systems.
+
+The external systems to clean are configured in the profile.`,
+		RunE: runExternal,
+	}
+)
+
+func runExternal(cmd *cobra.Command, args []string) error {
+	profile, err := loadProfile()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = sweep(context.Background(), "external", profile, run.Scope{}, nil)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// externalCleaner runs the cleaners for external systems configured in the
+// profile, including the cleaners loaded from the plugins of the profile.
+// Plugins only run here, so that they do not run once per provider.
+type externalCleaner struct {
+	cleaners []plugin.Cleaner
+	run      *run.Run
+}
+
+func newExternalCleaner(profile config.Profile, r *runner) (*externalCleaner, error) {
+	var cleaners []plugin.Cleaner
+
+	if profile.External.Quay.Namespace != "" {
+		c := external.QuayConfig{
+			Logger: logger,
+
+			Namespace: profile.External.Quay.Namespace,
+			Token:     profile.External.Quay.Token,
+			URL:       profile.External.Quay.URL,
+
+			Retention: profile.External.Quay.Retention.Duration,
+			Prefixes:  profile.External.Quay.Prefixes,
+		}
+
+		q, err := external.NewQuay(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		cleaners = append(cleaners, q)
+	}
+
+	for _, path := range profile.Plugins {
+		pluginConfig := plugin.Config{
+			Logger: logger,
+
+			GracePeriod: profile.GracePeriod.Duration,
+			Prefixes:    profile.Prefixes,
+		}
+
+		p, err := plugin.Open(path, pluginConfig)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		cleaners = append(cleaners, p)
+	}
+
+	e := &externalCleaner{
+		cleaners: cleaners,
+		run:      r.run,
+	}
+
+	return e, nil
+}
+
+func (e *externalCleaner) Clean(ctx context.Context) error {
+	return plugin.Clean(ctx, logger, e.run, e.cleaners)
+}
@@ -0,0 +1,11 @@
+//go:build faultinjection
+// +build faultinjection
+
+package cmd
+
+// init registers --fault-rate, which is only available in test builds made
+// with `go build -tags faultinjection`, so that production runs cannot inject
+// faults by accident.
+func init() {
+	RootCmd.PersistentFlags().Float64Var(&faultRate, "fault-rate", 0, "Share of delete calls between 0 and 1 which fail with an injected error instead of deleting anything, for testing partial failures.")
+}
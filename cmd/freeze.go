@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/freeze"
+	"github.com/giantswarm/ci-cleaner/pkg/report"
+)
+
+// activeFreeze returns the freeze window of the calendar at the given URL the
+// run falls into. Runs which cannot read the calendar are frozen as well, so
+// that a broken calendar never lets a run delete during a freeze.
+func activeFreeze(ctx context.Context, url string) (report.Freeze, bool) {
+	var calendar *freeze.Calendar
+	{
+		c := freeze.Config{
+			URL: url,
+		}
+
+		var err error
+		calendar, err = freeze.New(c)
+		if err != nil {
+			return unavailableFreeze(ctx, err), true
+		}
+	}
+
+	w, ok, err := calendar.Active(ctx, time.Now())
+	if err != nil {
+		return unavailableFreeze(ctx, err), true
+	}
+	if !ok {
+		return report.Freeze{}, false
+	}
+
+	logger.LogCtx(ctx, "level", "info", "message", fmt.Sprintf("running in report-only mode during freeze %#q until %s", w.Summary, w.End.UTC().Format(time.RFC3339)))
+
+	f := report.Freeze{
+		Summary: w.Summary,
+		Start:   w.Start,
+		End:     w.End,
+	}
+
+	return f, true
+}
+
+func unavailableFreeze(ctx context.Context, err error) report.Freeze {
+	logger.LogCtx(ctx, "level", "error", "message", "failed reading freeze calendar, running in report-only mode", "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
+
+	return report.Freeze{Error: err.Error()}
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/spf13/cobra"
+
+	"github.com/giantswarm/ci-cleaner/pkg/config"
+	"github.com/giantswarm/ci-cleaner/pkg/tabledata"
+)
+
+var (
+	configPath      string
+	profileName     string
+	profileSchedule bool
+)
+
+func init() {
+	RootCmd.PersistentFlags().StringVar(&configPath, "config", "", "Path of the configuration file holding the profiles.")
+	RootCmd.PersistentFlags().StringVar(&profileName, "profile", "", "Name of the profile in the configuration file to use.")
+	RootCmd.PersistentFlags().BoolVar(&profileSchedule, "profile-schedule", false, "Select the profile by the schedules of the configuration file instead of --profile.")
+}
+
+// loadProfile reads the profile selected with --profile, or the one the
+// schedules of the configuration file select for now with
+// --profile-schedule. An empty profile is returned when no profile is
+// selected.
+func loadProfile() (config.Profile, error) {
+	if profileName == "" && !profileSchedule {
+		return config.Profile{}, nil
+	}
+	if profileName != "" && profileSchedule {
+		return config.Profile{}, microerror.Maskf(invalidFlagError, "--profile and --profile-schedule must not be given together")
+	}
+	if configPath == "" {
+		return config.Profile{}, microerror.Maskf(invalidFlagError, "--config must not be empty when --profile or --profile-schedule is given")
+	}
+
+	c, err := config.Read(configPath)
+	if err != nil {
+		return config.Profile{}, microerror.Mask(err)
+	}
+
+	name := profileName
+	if profileSchedule {
+		name, err = c.ScheduledProfile(time.Now())
+		if err != nil {
+			return config.Profile{}, microerror.Mask(err)
+		}
+
+		logger.Log("level", "info", "message", fmt.Sprintf("schedule selected profile %#q", name))
+	}
+
+	p, err := c.Profile(name)
+	if err != nil {
+		return config.Profile{}, microerror.Mask(err)
+	}
+
+	return p, nil
+}
+
+// setFromProfile sets the value of the given flag to the value of the
+// profile, unless the flag was set explicitly on the command line. Command
+// line flags always take precedence over profile values.
+func setFromProfile(cmd *cobra.Command, name string, value string) error {
+	if value == "" || cmd.Flags().Changed(name) {
+		return nil
+	}
+
+	err := cmd.Flags().Set(name, value)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// setListFromProfile is like setFromProfile for comma separated list flags.
+func setListFromProfile(cmd *cobra.Command, name string, values []string) error {
+	return setFromProfile(cmd, name, strings.Join(values, ","))
+}
+
+// tablesFromProfile returns the shared tables of the given table data
+// settings of a profile.
+func tablesFromProfile(c config.TableData) []tabledata.Table {
+	var tables []tabledata.Table
+	for _, t := range c.Tables {
+		tables = append(tables, tabledata.Table{
+			Name:               t.Name,
+			KeyAttribute:       t.KeyAttribute,
+			KeyPrefixes:        t.KeyPrefixes,
+			TimestampAttribute: t.TimestampAttribute,
+		})
+	}
+
+	return tables
+}
@@ -5,6 +5,8 @@ import (
 
 	"github.com/giantswarm/micrologger"
 	"github.com/spf13/cobra"
+
+	"github.com/giantswarm/ci-cleaner/pkg/logctx"
 )
 
 var (
@@ -21,16 +23,35 @@ var (
 func init() {
 	var err error
 
+	var microLogger micrologger.Logger
 	{
-		c := micrologger.Config{}
+		c := micrologger.Config{
+			Caller: logctx.Caller,
+		}
 
-		logger, err = micrologger.New(c)
+		microLogger, err = micrologger.New(c)
 		if err != nil {
 			panic(fmt.Sprintf("Error creating micrologger instance: %#v", err))
 		}
 	}
 
+	// The cleaners may log concurrently, so entries are serialized and
+	// attributed to their run and cleaner by the context they are logged
+	// with.
+	{
+		c := logctx.Config{
+			Logger: microLogger,
+		}
+
+		logger, err = logctx.New(c)
+		if err != nil {
+			panic(fmt.Sprintf("Error creating logctx instance: %#v", err))
+		}
+	}
+
 	RootCmd.AddCommand(AwsCmd)
 	RootCmd.AddCommand(AzureCmd)
+	RootCmd.AddCommand(DaemonCmd)
+	RootCmd.AddCommand(ExternalCmd)
 	RootCmd.AddCommand(VersionCmd)
 }
@@ -0,0 +1,483 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	awsSDK "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/audit"
+	"github.com/giantswarm/ci-cleaner/pkg/config"
+	"github.com/giantswarm/ci-cleaner/pkg/inventory"
+	"github.com/giantswarm/ci-cleaner/pkg/metrics"
+	"github.com/giantswarm/ci-cleaner/pkg/notify"
+	"github.com/giantswarm/ci-cleaner/pkg/protection"
+	"github.com/giantswarm/ci-cleaner/pkg/recording"
+	"github.com/giantswarm/ci-cleaner/pkg/report"
+	"github.com/giantswarm/ci-cleaner/pkg/retention"
+	"github.com/giantswarm/ci-cleaner/pkg/rollout"
+	"github.com/giantswarm/ci-cleaner/pkg/rule"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+	"github.com/giantswarm/ci-cleaner/pkg/slo"
+	"github.com/giantswarm/ci-cleaner/pkg/state"
+)
+
+var (
+	auditPath   string
+	metricsPath string
+	reportDir   string
+	statePath   string
+)
+
+// faultRate is the share of delete calls which fail with an injected error.
+// It can only be set with --fault-rate in builds with the faultinjection
+// build tag, see fault.go.
+var faultRate float64
+
+// runMetrics counts the outcome of all runs of the process, so that the
+// daemon serves the counters of all its sweeps.
+var runMetrics = metrics.New()
+
+func init() {
+	RootCmd.PersistentFlags().StringVar(&auditPath, "audit-file", "", "Path of the audit log file. Audit records are discarded when empty.")
+	RootCmd.PersistentFlags().StringVar(&metricsPath, "metrics-file", "", "Path of the file the metrics are written to after each run, e.g. for the textfile collector of the node exporter. No metrics are written when empty.")
+	RootCmd.PersistentFlags().StringVar(&reportDir, "report-dir", "", "Directory the run report is written to. No report is written when empty.")
+	RootCmd.PersistentFlags().StringVar(&statePath, "state-file", "", "Path of the file persisting state between runs. State is kept in memory only when empty.")
+}
+
+// runner wires the components shared by the cleaners of all providers for a
+// single run.
+type runner struct {
+	audit     *audit.Log
+	inventory *inventory.Exporter
+	notifier  *notify.Notifier
+	report    *report.Report
+	retention *retention.Retention
+	rollout   *rollout.Rollout
+	run       *run.Run
+	slo       *slo.SLO
+	state     *state.Store
+
+	provider string
+	region   string
+	replay   bool
+	scoped   bool
+
+	// replayShift is the time passed since the replayed run started, see
+	// recording.Replay.
+	replayShift time.Duration
+}
+
+// newRunner creates the components of a single run. The state is read from
+// --state-file unless a state store shared between runs is given.
+func newRunner(provider string, profile config.Profile, scope run.Scope, stateStore *state.Store) (*runner, error) {
+	var err error
+
+	var auditLog *audit.Log
+	{
+		c := audit.Config{
+			Path: auditPath,
+		}
+
+		auditLog, err = audit.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	// Replays decide against the state of the replayed run instead of the
+	// current one.
+	var replayShift time.Duration
+	if replayDir != "" {
+		stateStore, replayShift, err = newReplayStateStore()
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	if stateStore == nil {
+		stateStore, err = newStateStore()
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var newProtection *protection.Protection
+	{
+		c := protection.Config{
+			State: stateStore,
+		}
+
+		newProtection, err = protection.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var newRollout *rollout.Rollout
+	{
+		c := rollout.Config{
+			Audit:  auditLog,
+			Logger: logger,
+			State:  stateStore,
+
+			Provider:  provider,
+			Cleaners:  profile.Canary.Cleaners,
+			Runs:      profile.Canary.Runs,
+			Tolerance: profile.Canary.Tolerance,
+		}
+
+		newRollout, err = rollout.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	region := profile.AWS.Region
+	if provider == "azure" {
+		region = profile.Azure.Location
+	}
+
+	var exporter *inventory.Exporter
+	{
+		var uploaders []inventory.Uploader
+		if profile.Inventory.S3Location != "" {
+			awsCfg := &awsSDK.Config{
+				Credentials: credentials.NewStaticCredentials(profile.AWS.AccessKeyID, profile.AWS.SecretAccessKey, ""),
+				Region:      awsSDK.String(profile.AWS.Region),
+			}
+			s, err := session.NewSession(awsCfg)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			u, err := inventory.NewS3Uploader(s3.New(s), profile.Inventory.S3Location)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+			uploaders = append(uploaders, u)
+		}
+		if profile.Inventory.BlobContainerURL != "" {
+			uploaders = append(uploaders, &inventory.BlobUploader{ContainerURL: profile.Inventory.BlobContainerURL})
+		}
+
+		c := inventory.Config{
+			Logger:    logger,
+			Uploaders: uploaders,
+
+			Region: region,
+		}
+
+		exporter, err = inventory.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var notifier *notify.Notifier
+	{
+		var sinks []notify.Sink
+		if profile.Notify.SlackWebhookURL != "" {
+			sinks = append(sinks, notify.SlackSink{URL: profile.Notify.SlackWebhookURL})
+		}
+		if profile.Notify.WebhookURL != "" {
+			sinks = append(sinks, notify.WebhookSink{URL: profile.Notify.WebhookURL})
+		}
+
+		c := notify.Config{
+			Logger: logger,
+			Sinks:  sinks,
+			State:  stateStore,
+
+			MaxResources: profile.Notify.MaxResources,
+		}
+
+		notifier, err = notify.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var newRetention *retention.Retention
+	{
+		c := retention.Config{
+			Audit:  auditLog,
+			Logger: logger,
+			State:  stateStore,
+
+			ReportDir: reportDir,
+
+			AuditRetention:  profile.Retention.Audit.Duration,
+			ReportRetention: profile.Retention.Reports.Duration,
+			StateRetention:  profile.Retention.State.Duration,
+		}
+
+		newRetention, err = retention.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var newRules *rule.Engine
+	if len(profile.Rules) != 0 {
+		rules := map[string]rule.Rules{}
+		for cleaner, r := range profile.Rules {
+			rules[cleaner] = rule.Rules{Skip: r.Skip, Delete: r.Delete}
+		}
+
+		c := rule.Config{
+			Rules: rules,
+		}
+
+		newRules, err = rule.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var newSLO *slo.SLO
+	{
+		thresholds := map[string]time.Duration{}
+		for t, d := range profile.TimeToClean.Types {
+			thresholds[t] = d.Duration
+		}
+
+		c := slo.Config{
+			Audit: auditLog,
+
+			Threshold:  profile.TimeToClean.SLO.Duration,
+			Thresholds: thresholds,
+			Window:     profile.TimeToClean.Window.Duration,
+		}
+
+		newSLO, err = slo.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	newReport := report.New(provider)
+
+	var frozen bool
+	if profile.Freeze.CalendarURL != "" {
+		var f report.Freeze
+		f, frozen = activeFreeze(context.Background(), profile.Freeze.CalendarURL)
+		if frozen {
+			newReport.SetFreeze(f)
+		}
+	}
+
+	var newRun *run.Run
+	{
+		reportOnly, err := newRollout.ReportOnly()
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		newReport.ReportOnly = reportOnly
+
+		c := run.Config{
+			Logger:     logger,
+			Protection: newProtection,
+			Report:     newReport,
+			Rules:      newRules,
+			State:      stateStore,
+
+			Escalation: run.Escalation{
+				DiagnoseAfter: profile.Escalation.DiagnoseAfter,
+				ManualAfter:   profile.Escalation.ManualAfter,
+				MaxRetries:    profile.Escalation.MaxRetries,
+				RetryDelay:    profile.Escalation.RetryDelay.Duration,
+			},
+
+			DryRun:         replayDir != "" || frozen,
+			FaultRate:      faultRate,
+			MaxMonthlyCost: profile.MaxMonthlyCostPerRun,
+			ReportOnly:     reportOnly,
+			Scope:          scope,
+			Skip:           profile.SkipCleaners,
+		}
+
+		newRun, err = run.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	r := &runner{
+		audit:     auditLog,
+		inventory: exporter,
+		notifier:  notifier,
+		report:    newReport,
+		retention: newRetention,
+		rollout:   newRollout,
+		run:       newRun,
+		slo:       newSLO,
+		state:     stateStore,
+
+		provider: provider,
+		region:   region,
+		replay:   replayDir != "",
+		scoped:   !scope.IsZero(),
+
+		replayShift: replayShift,
+	}
+
+	return r, nil
+}
+
+func newStateStore() (*state.Store, error) {
+	c := state.Config{
+		Path: statePath,
+	}
+
+	s, err := state.New(c)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return s, nil
+}
+
+// newReplayStateStore returns a state kept in memory holding the entries
+// recorded with the replayed run, shifted by the time passed since it
+// started, which is returned as well. No state is returned for recordings
+// without manifest, which are replayed against the state given with
+// --state-file and the current time.
+func newReplayStateStore() (*state.Store, time.Duration, error) {
+	m, err := recording.ReadManifest(replayDir)
+	if recording.IsNotRecorded(err) {
+		logger.Log("level", "warning", "message", "replaying recording without manifest against the current state and time")
+		return nil, 0, nil
+	} else if err != nil {
+		return nil, 0, microerror.Mask(err)
+	}
+
+	shift := time.Since(m.Started)
+
+	s, err := state.New(state.Config{})
+	if err != nil {
+		return nil, 0, microerror.Mask(err)
+	}
+
+	for k, seen := range m.Seen {
+		err = s.Put(k, seen.Add(shift))
+		if err != nil {
+			return nil, 0, microerror.Mask(err)
+		}
+	}
+
+	return s, shift, nil
+}
+
+// writeManifest records when the run started and when it found resources
+// for the first time along with the responses recorded in --record.
+func (r *runner) writeManifest() error {
+	m := recording.Manifest{
+		Started: r.report.Started,
+		Seen:    map[string]time.Time{},
+	}
+
+	for _, k := range r.state.Keys(run.SeenKeyPrefix) {
+		var seen time.Time
+		_, err := r.state.Get(k, &seen)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		m.Seen[k] = seen
+	}
+
+	err := recording.WriteManifest(recordDir, m)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// finish records the outcome of the run. It is called regardless of whether
+// the cleaners failed, as the report is most interesting for failed runs.
+func (r *runner) finish() error {
+	var err error
+
+	// Scoped runs only see a subset of the candidates, which must not be
+	// mistaken for a change of the candidate sets.
+	if !r.scoped && !r.replay {
+		err = r.rollout.Observe(r.report.Candidates(), r.report.Completed)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	// The deletions of scoped runs are real and count towards the
+	// time-to-clean as well.
+	if !r.replay {
+		err = r.slo.Record(r.report)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		stats, err := r.slo.Compute(r.provider, time.Now())
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		r.report.SetTimeToClean(stats)
+	}
+
+	if reportDir != "" {
+		path, err := r.report.Write(reportDir)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		logger.Log("level", "info", "message", "wrote run report", "path", path)
+	}
+
+	// Replayed runs only explain the decisions of a recorded run and must
+	// not change any state or be mistaken for a real run.
+	if r.replay {
+		return nil
+	}
+
+	runMetrics.Observe(r.report, r.region)
+
+	// Failing notifications and exports must not prevent the state from being
+	// persisted.
+	err = r.notifier.Notify(context.Background(), r.report)
+	if err != nil {
+		logger.Log("level", "warning", "message", "failed to send notifications", "stack", fmt.Sprintf("%#v", err))
+	}
+	err = r.inventory.Export(context.Background(), r.report)
+	if err != nil {
+		logger.Log("level", "warning", "message", "failed to export inventory", "stack", fmt.Sprintf("%#v", err))
+	}
+	if metricsPath != "" {
+		err = runMetrics.WriteFile(metricsPath)
+		if err != nil {
+			logger.Log("level", "warning", "message", "failed to write metrics", "stack", fmt.Sprintf("%#v", err))
+		}
+	}
+
+	if recordDir != "" {
+		err = r.writeManifest()
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	err = r.retention.Prune()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = r.state.Flush()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
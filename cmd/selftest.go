@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/cleaner/aws"
+	pkgazure "github.com/giantswarm/ci-cleaner/pkg/cleaner/azure"
+	"github.com/giantswarm/ci-cleaner/pkg/config"
+	"github.com/giantswarm/ci-cleaner/pkg/selftest"
+)
+
+// selfTest checks the canary resources of previous runs and creates new ones
+// with the probes of the given cleaner. The results are added to the report
+// of the run.
+func selfTest(ctx context.Context, profile config.Profile, r *runner, c cleaner) error {
+	var probes []selftest.Probe
+	switch c := c.(type) {
+	case *aws.Cleaner:
+		probes = c.SelfTestProbes(profile.AWS.Region)
+	case *pkgazure.Cleaner:
+		probes = c.SelfTestProbes()
+	}
+
+	var s *selftest.SelfTest
+	{
+		c := selftest.Config{
+			Logger: logger,
+			State:  r.state,
+
+			Probes: probes,
+
+			Deadline: profile.SelfTest.Deadline.Duration,
+		}
+
+		var err error
+		s, err = selftest.New(c)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	results, err := s.Run(ctx)
+	r.report.SetSelfTest(results)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/config"
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/logctx"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+	"github.com/giantswarm/ci-cleaner/pkg/state"
+)
+
+// cleaner is implemented by the cleaners of all providers.
+type cleaner interface {
+	Clean(ctx context.Context) error
+}
+
+// sweep runs the cleaner of the given provider within the given scope and
+// records the outcome of the run. See newRunner for stateStore.
+func sweep(ctx context.Context, provider string, profile config.Profile, scope run.Scope, stateStore *state.Store) error {
+	r, err := newRunner(provider, profile, scope, stateStore)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	// Entries logged by the cleaners tell the run and provider they belong
+	// to, as sweeps of the daemon and cleaners may interleave.
+	ctx = logctx.WithProvider(ctx, provider)
+	ctx = logctx.WithRun(ctx, r.report.ID())
+
+	var c cleaner
+	switch provider {
+	case "aws":
+		c, err = newAWSCleaner(profile, r)
+	case "azure":
+		c, err = newAzureCleaner(profile, r)
+	case "external":
+		c, err = newExternalCleaner(profile, r)
+	default:
+		return microerror.Maskf(invalidFlagError, "unknown provider %#q", provider)
+	}
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	errors := &errorcollection.ErrorCollection{}
+	{
+		// Replayed runs must not create any resources and scoped runs
+		// do not see the canary resources.
+		if profile.SelfTest.Enabled && !r.replay && !r.scoped {
+			err := selfTest(ctx, profile, r, c)
+			if err != nil {
+				errors.Append(err)
+			}
+		}
+
+		err := c.Clean(ctx)
+		if err != nil {
+			errors.Append(err)
+		}
+	}
+
+	err = r.finish()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if errors.HasErrors() {
+		return microerror.Mask(errors)
+	}
+
+	return nil
+}
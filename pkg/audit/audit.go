@@ -0,0 +1,182 @@
+// Package audit implements an append-only log of the decisions taken by the
+// ci-cleaner, stored as JSON lines.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/giantswarm/microerror"
+)
+
+type Config struct {
+	// Path is the file records are appended to. Records are discarded when
+	// Path is empty.
+	Path string
+}
+
+// Record is a single entry of the audit log.
+type Record struct {
+	Time     time.Time `json:"time"`
+	Action   string    `json:"action"`
+	Cleaner  string    `json:"cleaner,omitempty"`
+	Resource string    `json:"resource,omitempty"`
+	Message  string    `json:"message,omitempty"`
+
+	// Provider, Type and CreatedAt describe deleted resources, so that it
+	// can be told how long they lived.
+	Provider  string     `json:"provider,omitempty"`
+	Type      string     `json:"type,omitempty"`
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+}
+
+// Log is an audit log safe for concurrent use.
+type Log struct {
+	mutex sync.Mutex
+	path  string
+}
+
+func New(config Config) (*Log, error) {
+	l := &Log{
+		path: config.Path,
+	}
+
+	return l, nil
+}
+
+// Record appends r to the audit log. The current time is used when r does not
+// define one.
+func (l *Log) Record(r Record) error {
+	if l.path == "" {
+		return nil
+	}
+	if r.Time.IsZero() {
+		r.Time = time.Now().UTC()
+	}
+
+	b, err := json.Marshal(r)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(b, '\n'))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// Records returns all records of the audit log in the order they were
+// recorded.
+func (l *Log) Records() ([]Record, error) {
+	if l.path == "" {
+		return nil, nil
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.records()
+}
+
+// DeleteBefore removes all records recorded before t and returns the number
+// of removed records. The log file is replaced atomically.
+func (l *Log) DeleteBefore(t time.Time) (int, error) {
+	if l.path == "" {
+		return 0, nil
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	records, err := l.records()
+	if err != nil {
+		return 0, microerror.Mask(err)
+	}
+
+	var kept []byte
+	var n int
+	for _, r := range records {
+		if r.Time.Before(t) {
+			n++
+			continue
+		}
+
+		b, err := json.Marshal(r)
+		if err != nil {
+			return 0, microerror.Mask(err)
+		}
+		kept = append(kept, append(b, '\n')...)
+	}
+
+	if n == 0 {
+		return 0, nil
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(l.path), filepath.Base(l.path)+".tmp")
+	if err != nil {
+		return 0, microerror.Mask(err)
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(kept)
+	if err != nil {
+		f.Close()
+		return 0, microerror.Mask(err)
+	}
+	err = f.Close()
+	if err != nil {
+		return 0, microerror.Mask(err)
+	}
+
+	err = os.Rename(f.Name(), l.path)
+	if err != nil {
+		return 0, microerror.Mask(err)
+	}
+
+	return n, nil
+}
+
+// records reads all records. The caller has to hold the mutex.
+func (l *Log) records() ([]Record, error) {
+	f, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	defer f.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r Record
+		err := json.Unmarshal(scanner.Bytes(), &r)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		records = append(records, r)
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return records, nil
+}
@@ -0,0 +1,50 @@
+package audit
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ci-cleaner-audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	l, err := New(Config{Path: filepath.Join(dir, "audit.log")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := l.Records()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 0 {
+		t.Fatalf("expected no records, got %d", len(records))
+	}
+
+	for _, cleaner := range []string{"stacks", "buckets"} {
+		err = l.Record(Record{Action: "promoted", Cleaner: cleaner})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	records, err = l.Records()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	if records[1].Cleaner != "buckets" {
+		t.Errorf("expected cleaner %q, got %q", "buckets", records[1].Cleaner)
+	}
+	if records[0].Time.IsZero() {
+		t.Errorf("expected record time to be set")
+	}
+}
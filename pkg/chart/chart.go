@@ -0,0 +1,58 @@
+// Package chart knows about the versions CI pushes ephemeral Helm charts to
+// OCI registries with, e.g. `1.2.3-pr42` for pull requests or
+// `1.2.3-e2e-a1b2c` for e2e tests, so that the cleaners of all registries
+// tell them apart from released versions the same way.
+package chart
+
+import (
+	"strings"
+)
+
+var (
+	// DefaultSuffixes mark ephemeral chart versions, unless configured
+	// otherwise.
+	DefaultSuffixes = []string{
+		"-pr",
+		"-e2e",
+	}
+)
+
+// Ephemeral checks if the given chart version has one of the given suffixes,
+// which may be followed by a number or further separated identifiers, e.g.
+// `-pr` matches `1.2.3-pr`, `1.2.3-pr42` and `1.2.3-pr.42`, but not
+// `1.2.3-preview`.
+func Ephemeral(version string, suffixes []string) bool {
+	for _, s := range suffixes {
+		rest := version
+		for {
+			i := strings.Index(rest, s)
+			if i < 0 {
+				break
+			}
+
+			rest = rest[i+len(s):]
+			if rest == "" || strings.ContainsAny(rest[:1], "0123456789-.") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// AllEphemeral checks if the artifact with the given tags is only tagged with
+// ephemeral chart versions. Untagged artifacts are not considered ephemeral,
+// as they cannot be told apart from released charts.
+func AllEphemeral(tags []string, suffixes []string) bool {
+	if len(tags) == 0 {
+		return false
+	}
+
+	for _, t := range tags {
+		if !Ephemeral(t, suffixes) {
+			return false
+		}
+	}
+
+	return true
+}
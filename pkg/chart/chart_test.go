@@ -0,0 +1,92 @@
+package chart
+
+import (
+	"testing"
+)
+
+func TestEphemeral(t *testing.T) {
+	tcs := []struct {
+		version     string
+		expected    bool
+		description string
+	}{
+		{
+			description: "pull request version",
+			version:     "1.2.3-pr42",
+			expected:    true,
+		},
+		{
+			description: "pull request version with separated number",
+			version:     "1.2.3-pr.42",
+			expected:    true,
+		},
+		{
+			description: "bare pull request suffix",
+			version:     "1.2.3-pr",
+			expected:    true,
+		},
+		{
+			description: "e2e version",
+			version:     "1.2.3-e2e-a1b2c",
+			expected:    true,
+		},
+		{
+			description: "preview version",
+			version:     "1.2.3-preview",
+			expected:    false,
+		},
+		{
+			description: "preview version of pull request",
+			version:     "1.2.3-preview-pr42",
+			expected:    true,
+		},
+		{
+			description: "released version",
+			version:     "1.2.3",
+			expected:    false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := Ephemeral(tc.version, DefaultSuffixes)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q is ephemeral, want %t, got %t", tc.version, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestAllEphemeral(t *testing.T) {
+	tcs := []struct {
+		tags        []string
+		expected    bool
+		description string
+	}{
+		{
+			description: "artifact with ephemeral versions only",
+			tags:        []string{"1.2.3-pr42", "1.2.3-e2e-a1b2c"},
+			expected:    true,
+		},
+		{
+			description: "artifact released after testing",
+			tags:        []string{"1.2.3-pr42", "1.2.3"},
+			expected:    false,
+		},
+		{
+			description: "untagged artifact",
+			expected:    false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := AllEphemeral(tc.tags, DefaultSuffixes)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %v are ephemeral, want %t, got %t", tc.tags, tc.expected, actual)
+			}
+		})
+	}
+}
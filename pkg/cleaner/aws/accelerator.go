@@ -0,0 +1,220 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// acceleratedInstanceType matches the instance types of the GPU, Inferentia,
+// Trainium, Gaudi, FPGA and video transcoding families.
+var acceleratedInstanceType = regexp.MustCompile(`\A(p|g|inf|trn|dl|f|vt)\d`)
+
+// cleanAcceleratorInstances terminates CI instances with GPUs or other
+// accelerators, including instances with Elastic GPUs or Elastic Inference
+// accelerators attached. Our ML e2e tests occasionally leave such instances
+// behind, which is why they have a stricter grace period than other
+// resources. Stopped instances are terminated as well, as their volumes are
+// usually large and still billed.
+func (a *Cleaner) cleanAcceleratorInstances(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	// Instances are not filtered by their Name tag, as instances Kubernetes
+	// provisioned are only told apart by their cluster tags.
+	var nextToken *string
+	err := paginate(ctx, &nextToken, func() (*string, error) {
+		i := &ec2.DescribeInstancesInput{
+			Filters: []*ec2.Filter{
+				{
+					Name: aws.String("instance-state-name"),
+					Values: []*string{
+						aws.String(ec2.InstanceStateNamePending),
+						aws.String(ec2.InstanceStateNameRunning),
+						aws.String(ec2.InstanceStateNameStopping),
+						aws.String(ec2.InstanceStateNameStopped),
+					},
+				},
+			},
+			NextToken: nextToken,
+		}
+
+		o, err := a.ec2Client.DescribeInstances(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, reservation := range o.Reservations {
+			for _, instance := range reservation.Instances {
+				if !a.acceleratorInstanceShouldBeDeleted(instance) {
+					continue
+				}
+
+				err := a.deleteAcceleratorInstance(ctx, instance)
+				if err != nil {
+					errors.Append(microerror.Mask(err))
+					a.logger.Log("level", "error", "message", fmt.Sprintf("failed terminating instance %#q", *instance.InstanceId), "stack", fmt.Sprintf("%#v", err))
+				}
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) deleteAcceleratorInstance(ctx context.Context, instance *ec2.Instance) error {
+	protected, err := a.hasTerminationProtection(instance.InstanceId)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if protected {
+		a.logger.Log("level", "warning", "message", fmt.Sprintf("found that instance %#q should be terminated, but skipping it as termination protection is enabled", *instance.InstanceId))
+		return nil
+	}
+
+	volumeSize, err := a.volumeSize(instance)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	a.logger.Log("level", "info", "message", fmt.Sprintf("found that instance %#q should be terminated", *instance.InstanceId))
+
+	res := run.Resource{
+		ID:        *instance.InstanceId,
+		Type:      "AWS::EC2::Instance",
+		Tags:      ec2Tags(instance.Tags),
+		CreatedAt: aws.TimeValue(instance.LaunchTime),
+		Cost:      acceleratorCost(instance, volumeSize),
+	}
+	if instance.Placement != nil && instance.Placement.AvailabilityZone != nil {
+		az := *instance.Placement.AvailabilityZone
+		res.Region = az[:len(az)-1]
+	}
+
+	err = a.run.DeleteResource(ctx, cleanerAcceleratorInstances, res, func() error {
+		return a.terminateInstance(instance.InstanceId)
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (a *Cleaner) terminateInstance(id *string) error {
+	i := &ec2.TerminateInstancesInput{
+		InstanceIds: []*string{id},
+	}
+
+	_, err := a.ec2Client.TerminateInstances(i)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// volumeSize returns the size of the EBS volumes attached to the given
+// instance in GiB.
+func (a *Cleaner) volumeSize(instance *ec2.Instance) (int64, error) {
+	var ids []*string
+	for _, m := range instance.BlockDeviceMappings {
+		if m.Ebs != nil && m.Ebs.VolumeId != nil {
+			ids = append(ids, m.Ebs.VolumeId)
+		}
+	}
+
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	i := &ec2.DescribeVolumesInput{
+		VolumeIds: ids,
+	}
+
+	o, err := a.ec2Client.DescribeVolumes(i)
+	if err != nil {
+		return 0, microerror.Mask(err)
+	}
+
+	var size int64
+	for _, v := range o.Volumes {
+		size += aws.Int64Value(v.Size)
+	}
+
+	return size, nil
+}
+
+func (a *Cleaner) acceleratorInstanceShouldBeDeleted(instance *ec2.Instance) bool {
+	if instance.InstanceId == nil {
+		return false
+	}
+
+	if !isAcceleratorInstance(instance) {
+		return false
+	}
+
+	// do not delete instances that are already being terminated.
+	if instance.State != nil {
+		switch aws.StringValue(instance.State.Name) {
+		case ec2.InstanceStateNameShuttingDown, ec2.InstanceStateNameTerminated:
+			return false
+		}
+	}
+
+	// do not delete recent instances.
+	if isRecent(instance.LaunchTime, a.acceleratorGracePeriod) {
+		return false
+	}
+
+	return a.isCITagged(ec2Tags(instance.Tags))
+}
+
+func isAcceleratorInstance(instance *ec2.Instance) bool {
+	if len(instance.ElasticGpuAssociations) != 0 || len(instance.ElasticInferenceAcceleratorAssociations) != 0 {
+		return true
+	}
+
+	return acceleratedInstanceType.MatchString(aws.StringValue(instance.InstanceType))
+}
+
+// acceleratorCost describes what the given instance is billed for, so that
+// reports call out how expensive leaving it behind is.
+func acceleratorCost(instance *ec2.Instance, volumeSize int64) string {
+	var state string
+	if instance.State != nil {
+		state = aws.StringValue(instance.State.Name)
+	}
+
+	parts := []string{
+		fmt.Sprintf("%s %s instance", state, aws.StringValue(instance.InstanceType)),
+	}
+	if n := len(instance.ElasticGpuAssociations); n != 0 {
+		parts = append(parts, fmt.Sprintf("%d Elastic GPU(s)", n))
+	}
+	if n := len(instance.ElasticInferenceAcceleratorAssociations); n != 0 {
+		parts = append(parts, fmt.Sprintf("%d Elastic Inference accelerator(s)", n))
+	}
+	if volumeSize != 0 {
+		parts = append(parts, fmt.Sprintf("%d GiB EBS", volumeSize))
+	}
+
+	return strings.Join(parts, ", ")
+}
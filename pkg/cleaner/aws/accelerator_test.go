@@ -0,0 +1,106 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func TestAcceleratorInstanceShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		instance    *ec2.Instance
+		expected    bool
+		description string
+	}{
+		{
+			description: "old ci gpu instance should be deleted",
+			instance:    newInstance("ci-wip-a1b2c-worker", "p3.2xlarge", ec2.InstanceStateNameRunning, time.Now().Add(-time.Hour)),
+			expected:    true,
+		},
+		{
+			description: "stopped ci inferentia instance should be deleted",
+			instance:    newInstance("e2e-a1b2c-worker", "inf1.xlarge", ec2.InstanceStateNameStopped, time.Now().Add(-time.Hour)),
+			expected:    true,
+		},
+		{
+			description: "recent ci gpu instance should not be deleted",
+			instance:    newInstance("ci-wip-a1b2c-worker", "g4dn.xlarge", ec2.InstanceStateNameRunning, time.Now().Add(-10*time.Minute)),
+			expected:    false,
+		},
+		{
+			description: "old ci instance without accelerator should not be deleted",
+			instance:    newInstance("ci-wip-a1b2c-worker", "m5.xlarge", ec2.InstanceStateNameRunning, time.Now().Add(-time.Hour)),
+			expected:    false,
+		},
+		{
+			description: "old ci instance with elastic inference accelerator should be deleted",
+			instance: func() *ec2.Instance {
+				i := newInstance("ci-wip-a1b2c-worker", "m5.xlarge", ec2.InstanceStateNameRunning, time.Now().Add(-time.Hour))
+				i.ElasticInferenceAcceleratorAssociations = []*ec2.ElasticInferenceAcceleratorAssociation{{}}
+				return i
+			}(),
+			expected: true,
+		},
+		{
+			description: "old gpu instance kubernetes provisioned for a ci cluster should be deleted",
+			instance: func() *ec2.Instance {
+				i := newInstance("karpenter-gpu", "g5.xlarge", ec2.InstanceStateNameRunning, time.Now().Add(-time.Hour))
+				i.Tags = append(i.Tags, &ec2.Tag{Key: aws.String("kubernetes.io/cluster/ci-wip-a1b2c"), Value: aws.String("owned")})
+				return i
+			}(),
+			expected: true,
+		},
+		{
+			description: "terminating ci gpu instance should not be deleted",
+			instance:    newInstance("ci-wip-a1b2c-worker", "p3.2xlarge", ec2.InstanceStateNameShuttingDown, time.Now().Add(-time.Hour)),
+			expected:    false,
+		},
+		{
+			description: "old general gpu instance should not be deleted",
+			instance:    newInstance("ml-training", "p3.2xlarge", ec2.InstanceStateNameRunning, time.Now().Add(-time.Hour)),
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		acceleratorGracePeriod: defaultAcceleratorGracePeriod,
+		prefixes:               defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := a.acceleratorInstanceShouldBeDeleted(tc.instance)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.instance.InstanceId, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestAcceleratorCost(t *testing.T) {
+	instance := newInstance("ci-wip-a1b2c-worker", "g4dn.xlarge", ec2.InstanceStateNameStopped, time.Now())
+	instance.ElasticGpuAssociations = []*ec2.ElasticGpuAssociation{{}}
+
+	expected := "stopped g4dn.xlarge instance, 1 Elastic GPU(s), 512 GiB EBS"
+	actual := acceleratorCost(instance, 512)
+	if actual != expected {
+		t.Errorf("want %q, got %q", expected, actual)
+	}
+}
+
+func newInstance(name, instanceType, state string, launchTime time.Time) *ec2.Instance {
+	return &ec2.Instance{
+		InstanceId:   aws.String("i-0123456789abcdef0"),
+		InstanceType: aws.String(instanceType),
+		LaunchTime:   aws.Time(launchTime),
+		State: &ec2.InstanceState{
+			Name: aws.String(state),
+		},
+		Tags: []*ec2.Tag{
+			{Key: aws.String("Name"), Value: aws.String(name)},
+		},
+	}
+}
@@ -0,0 +1,85 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanAddresses releases CI Elastic IPs left behind once the NAT gateways
+// and instances they were associated with are gone. They count against the
+// quota of the account and are billed while unassociated. Elastic IPs do not
+// tell when they were allocated or disassociated, which is why the grace
+// period starts when a run finds them unassociated for the first time.
+func (a *Cleaner) cleanAddresses(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	o, err := a.ec2Client.DescribeAddresses(&ec2.DescribeAddressesInput{})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	for _, address := range o.Addresses {
+		if !a.isCIAddress(address) {
+			continue
+		}
+
+		seen, err := a.run.FirstSeen(cleanerAddresses, *address.AllocationId)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			continue
+		}
+
+		// do not release recently disassociated addresses.
+		if time.Since(seen) < a.gracePeriod {
+			continue
+		}
+
+		a.logger.Log("level", "info", "message", fmt.Sprintf("found that elastic ip %#q should be released", *address.AllocationId))
+
+		res := run.Resource{
+			ID:     *address.AllocationId,
+			Type:   "AWS::EC2::EIP",
+			Tags:   ec2Tags(address.Tags),
+			Reason: run.ReasonUnused,
+		}
+		err = a.run.DeleteResource(ctx, cleanerAddresses, res, func() error {
+			_, err := a.ec2Client.ReleaseAddress(&ec2.ReleaseAddressInput{AllocationId: address.AllocationId})
+			if err != nil && !isAWSError(err, "InvalidAllocationID.NotFound") {
+				return microerror.Mask(err)
+			}
+
+			return nil
+		})
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			a.logger.Log("level", "error", "message", fmt.Sprintf("failed releasing elastic ip %#q", *address.AllocationId), "stack", fmt.Sprintf("%#v", err))
+		}
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// isCIAddress returns whether the given Elastic IP belongs to CI and is not
+// associated with anything anymore.
+func (a *Cleaner) isCIAddress(address *ec2.Address) bool {
+	if address.AllocationId == nil {
+		return false
+	}
+
+	if address.AssociationId != nil || address.NetworkInterfaceId != nil || address.InstanceId != nil {
+		return false
+	}
+
+	return a.isCITagged(ec2Tags(address.Tags))
+}
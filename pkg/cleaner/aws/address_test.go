@@ -0,0 +1,56 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func TestIsCIAddress(t *testing.T) {
+	tcs := []struct {
+		address     *ec2.Address
+		expected    bool
+		description string
+	}{
+		{
+			description: "unassociated ci address should be released",
+			address:     newAddress("ci-wip-a1b2c-nat", nil),
+			expected:    true,
+		},
+		{
+			description: "associated ci address should not be released",
+			address:     newAddress("ci-wip-a1b2c-nat", aws.String("eipassoc-0123456789abcdef0")),
+			expected:    false,
+		},
+		{
+			description: "unassociated general address should not be released",
+			address:     newAddress("vpn-gateway", nil),
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		prefixes: defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := a.isCIAddress(tc.address)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be released, want %t, got %t", *tc.address.AllocationId, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func newAddress(name string, associationID *string) *ec2.Address {
+	return &ec2.Address{
+		AllocationId:  aws.String("eipalloc-0123456789abcdef0"),
+		AssociationId: associationID,
+		Tags: []*ec2.Tag{
+			{Key: aws.String("Name"), Value: aws.String(name)},
+		},
+	}
+}
@@ -0,0 +1,261 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanAutoScalingGroups deletes the Auto Scaling groups of CI clusters,
+// which otherwise keep replacing the instances the instances cleaner
+// terminates. Their capacity is set to zero first, so that they stop
+// launching instances right away, and they are deleted forcefully together
+// with their instances and launch templates, which is tracked by later runs.
+// Launch configurations cannot be deleted while in use, so CI launch
+// configurations no group uses anymore are deleted afterwards.
+func (a *Cleaner) cleanAutoScalingGroups(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	// inUse holds the launch configurations of all groups, including the
+	// ones being deleted.
+	inUse := map[string]bool{}
+
+	i := &autoscaling.DescribeAutoScalingGroupsInput{}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.autoScalingClient.DescribeAutoScalingGroups(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, group := range o.AutoScalingGroups {
+			if group.LaunchConfigurationName != nil {
+				inUse[*group.LaunchConfigurationName] = true
+			}
+
+			if !a.autoScalingGroupShouldBeDeleted(group) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that auto scaling group %#q should be deleted", *group.AutoScalingGroupName))
+
+			res := run.Resource{
+				ID:        *group.AutoScalingGroupName,
+				Type:      "AWS::AutoScaling::AutoScalingGroup",
+				Tags:      autoScalingTags(group.Tags),
+				CreatedAt: aws.TimeValue(group.CreatedTime),
+			}
+			group := group
+			start := func() (string, error) {
+				return a.deleteAutoScalingGroup(group)
+			}
+			err := a.run.DeleteResourceAsync(ctx, cleanerAutoScalingGroups, res, start, a.pollAutoScalingGroup)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting auto scaling group %#q", *group.AutoScalingGroupName), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	err = a.cleanLaunchConfigurations(ctx, inUse)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+	}
+
+	err = a.run.PollPending(ctx, cleanerAutoScalingGroups, "AWS::AutoScaling::AutoScalingGroup", a.pollAutoScalingGroup)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// deleteAutoScalingGroup scales the given group down to zero and deletes it
+// together with its instances and launch templates. The name of the group is
+// returned to poll the deletion.
+func (a *Cleaner) deleteAutoScalingGroup(group *autoscaling.Group) (string, error) {
+	i := &autoscaling.UpdateAutoScalingGroupInput{
+		AutoScalingGroupName: group.AutoScalingGroupName,
+		DesiredCapacity:      aws.Int64(0),
+		MaxSize:              aws.Int64(0),
+		MinSize:              aws.Int64(0),
+	}
+	_, err := a.autoScalingClient.UpdateAutoScalingGroup(i)
+	if err != nil && !isAWSError(err, autoscaling.ErrCodeScalingActivityInProgressFault) {
+		return "", microerror.Mask(err)
+	}
+
+	// EC2 does not keep launch templates in use from being deleted. The
+	// group is deleted right after and does not launch instances anymore.
+	for _, id := range autoScalingLaunchTemplates(group) {
+		_, err := a.ec2Client.DeleteLaunchTemplate(&ec2.DeleteLaunchTemplateInput{LaunchTemplateId: aws.String(id)})
+		if err != nil && !isAWSError(err, "InvalidLaunchTemplateId.NotFound") {
+			return "", microerror.Mask(err)
+		}
+	}
+
+	_, err = a.autoScalingClient.DeleteAutoScalingGroup(&autoscaling.DeleteAutoScalingGroupInput{
+		AutoScalingGroupName: group.AutoScalingGroupName,
+		ForceDelete:          aws.Bool(true),
+	})
+	if err != nil && !isAWSError(err, autoscaling.ErrCodeScalingActivityInProgressFault) && !isAWSError(err, autoscaling.ErrCodeResourceInUseFault) {
+		return "", microerror.Mask(err)
+	}
+
+	return *group.AutoScalingGroupName, nil
+}
+
+// pollAutoScalingGroup continues deleting the Auto Scaling group with the
+// given name until it is gone.
+func (a *Cleaner) pollAutoScalingGroup(ctx context.Context, name string) (bool, error) {
+	o, err := a.autoScalingClient.DescribeAutoScalingGroups(&autoscaling.DescribeAutoScalingGroupsInput{AutoScalingGroupNames: []*string{aws.String(name)}})
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	if len(o.AutoScalingGroups) == 0 {
+		return true, nil
+	}
+
+	group := o.AutoScalingGroups[0]
+	if group.Status != nil {
+		// the deletion is in progress.
+		return false, nil
+	}
+
+	_, err = a.deleteAutoScalingGroup(group)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	return false, nil
+}
+
+// cleanLaunchConfigurations deletes the CI launch configurations which are
+// not in use by any Auto Scaling group.
+func (a *Cleaner) cleanLaunchConfigurations(ctx context.Context, inUse map[string]bool) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &autoscaling.DescribeLaunchConfigurationsInput{}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.autoScalingClient.DescribeLaunchConfigurations(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, c := range o.LaunchConfigurations {
+			if !a.launchConfigurationShouldBeDeleted(c, inUse) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that launch configuration %#q should be deleted", *c.LaunchConfigurationName))
+
+			res := run.Resource{
+				ID:        *c.LaunchConfigurationName,
+				Type:      "AWS::AutoScaling::LaunchConfiguration",
+				CreatedAt: aws.TimeValue(c.CreatedTime),
+				Reason:    run.ReasonUnused,
+			}
+			c := c
+			err := a.run.DeleteResource(ctx, cleanerAutoScalingGroups, res, func() error {
+				_, err := a.autoScalingClient.DeleteLaunchConfiguration(&autoscaling.DeleteLaunchConfigurationInput{LaunchConfigurationName: c.LaunchConfigurationName})
+				return err
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting launch configuration %#q", *c.LaunchConfigurationName), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) autoScalingGroupShouldBeDeleted(group *autoscaling.Group) bool {
+	if group.AutoScalingGroupName == nil {
+		return false
+	}
+
+	if !a.hasCIPrefix(*group.AutoScalingGroupName) && !a.isCITagged(autoScalingTags(group.Tags)) {
+		return false
+	}
+
+	// do not delete recent groups.
+	if isRecent(group.CreatedTime, a.gracePeriod) {
+		return false
+	}
+
+	return true
+}
+
+func (a *Cleaner) launchConfigurationShouldBeDeleted(c *autoscaling.LaunchConfiguration, inUse map[string]bool) bool {
+	if c.LaunchConfigurationName == nil || !a.hasCIPrefix(*c.LaunchConfigurationName) {
+		return false
+	}
+
+	if inUse[*c.LaunchConfigurationName] {
+		return false
+	}
+
+	// do not delete recent launch configurations, their group may be about
+	// to be created.
+	if isRecent(c.CreatedTime, a.gracePeriod) {
+		return false
+	}
+
+	return true
+}
+
+// autoScalingLaunchTemplates returns the IDs of the launch templates the
+// given group launches instances from.
+func autoScalingLaunchTemplates(group *autoscaling.Group) []string {
+	var ids []string
+
+	if group.LaunchTemplate != nil && group.LaunchTemplate.LaunchTemplateId != nil {
+		ids = append(ids, *group.LaunchTemplate.LaunchTemplateId)
+	}
+
+	p := group.MixedInstancesPolicy
+	if p != nil && p.LaunchTemplate != nil && p.LaunchTemplate.LaunchTemplateSpecification != nil && p.LaunchTemplate.LaunchTemplateSpecification.LaunchTemplateId != nil {
+		ids = append(ids, *p.LaunchTemplate.LaunchTemplateSpecification.LaunchTemplateId)
+	}
+
+	return ids
+}
+
+func autoScalingTags(autoScalingTags []*autoscaling.TagDescription) map[string]string {
+	if len(autoScalingTags) == 0 {
+		return nil
+	}
+
+	tags := map[string]string{}
+	for _, t := range autoScalingTags {
+		tags[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+	}
+
+	return tags
+}
@@ -0,0 +1,141 @@
+package aws
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+)
+
+func TestAutoScalingGroupShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		name        string
+		tags        []*autoscaling.TagDescription
+		created     time.Time
+		expected    bool
+		description string
+	}{
+		{
+			description: "old group of ci cluster should be deleted",
+			name:        "ci-wip-a1b2c-workers",
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    true,
+		},
+		{
+			description: "old group tagged with ci cluster should be deleted",
+			name:        "workers-a1b2c",
+			tags:        []*autoscaling.TagDescription{{Key: aws.String(clusterTag), Value: aws.String("ci-wip-a1b2c")}},
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    true,
+		},
+		{
+			description: "recent group of ci cluster should not be deleted",
+			name:        "ci-wip-a1b2c-workers",
+			created:     time.Now().Add(-time.Hour),
+			expected:    false,
+		},
+		{
+			description: "old general group should not be deleted",
+			name:        "gauss-workers",
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			group := &autoscaling.Group{
+				AutoScalingGroupName: aws.String(tc.name),
+				CreatedTime:          aws.Time(tc.created),
+				Tags:                 tc.tags,
+			}
+
+			actual := a.autoScalingGroupShouldBeDeleted(group)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", tc.name, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestLaunchConfigurationShouldBeDeleted(t *testing.T) {
+	inUse := map[string]bool{"ci-wip-d4e5f-workers": true}
+
+	tcs := []struct {
+		name        string
+		created     time.Time
+		expected    bool
+		description string
+	}{
+		{
+			description: "old unused ci launch configuration should be deleted",
+			name:        "ci-wip-a1b2c-workers",
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    true,
+		},
+		{
+			description: "old ci launch configuration in use should not be deleted",
+			name:        "ci-wip-d4e5f-workers",
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    false,
+		},
+		{
+			description: "recent unused ci launch configuration should not be deleted",
+			name:        "ci-wip-a1b2c-workers",
+			created:     time.Now().Add(-time.Hour),
+			expected:    false,
+		},
+		{
+			description: "old unused general launch configuration should not be deleted",
+			name:        "gauss-workers",
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			c := &autoscaling.LaunchConfiguration{
+				CreatedTime:             aws.Time(tc.created),
+				LaunchConfigurationName: aws.String(tc.name),
+			}
+
+			actual := a.launchConfigurationShouldBeDeleted(c, inUse)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", tc.name, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestAutoScalingLaunchTemplates(t *testing.T) {
+	group := &autoscaling.Group{
+		LaunchTemplate: &autoscaling.LaunchTemplateSpecification{LaunchTemplateId: aws.String("lt-1")},
+		MixedInstancesPolicy: &autoscaling.MixedInstancesPolicy{
+			LaunchTemplate: &autoscaling.LaunchTemplate{
+				LaunchTemplateSpecification: &autoscaling.LaunchTemplateSpecification{LaunchTemplateId: aws.String("lt-2")},
+			},
+		},
+	}
+
+	expected := []string{"lt-1", "lt-2"}
+	actual := autoScalingLaunchTemplates(group)
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("want %v, got %v", expected, actual)
+	}
+}
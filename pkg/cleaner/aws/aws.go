@@ -1,95 +1,529 @@
 package aws
 
 import (
+	"context"
 	"fmt"
-	"reflect"
 	"regexp"
-	"runtime"
 	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/cloudformation"
 	"github.com/aws/aws-sdk-go/service/ec2"
-	"github.com/aws/aws-sdk-go/service/route53"
 	"github.com/aws/aws-sdk-go/service/s3"
-	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
+
+	"github.com/giantswarm/ci-cleaner/pkg/chart"
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/logctx"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+	"github.com/giantswarm/ci-cleaner/pkg/tabledata"
 )
 
 type Config struct {
-	EC2Client     EC2Client
-	CFClient      CFClient
-	Logger        micrologger.Logger
-	Route53Client Route53Client
-	S3Client      S3Client
+	// AcceleratorGracePeriod is the maximum time CI instances with GPUs or
+	// other accelerators are allowed to remain up. Defaults to 30 minutes.
+	AcceleratorGracePeriod time.Duration
+	// GracePeriod is the maximum time CI resources are allowed to remain up.
+	// Defaults to 90 minutes.
+	GracePeriod time.Duration
+	// Prefixes are the name prefixes identifying CI resources. Defaults to
+	// the prefixes used by our CI pipelines.
+	Prefixes []string
+	// AMIRetention is how long CI AMIs are kept before they are
+	// deregistered.
+	AMIRetention time.Duration
+	// AccountRetention is how long member accounts created by
+	// account-vending tests are kept before they are closed. Defaults to 24
+	// hours.
+	AccountRetention time.Duration
+	// ChartRepositories are the names of the ECR repositories ephemeral
+	// chart versions are deleted from. The chart cleaner does nothing when
+	// empty.
+	ChartRepositories []string
+	// ChartRetention is how long ephemeral chart versions are kept after
+	// they were pushed. Defaults to 7 days.
+	ChartRetention time.Duration
+	// ChartSuffixes are the suffixes marking ephemeral chart versions.
+	// Defaults to chart.DefaultSuffixes.
+	ChartSuffixes []string
+	// Tables are the shared DynamoDB tables the items of test runs are
+	// deleted from. The table data cleaner does nothing when empty.
+	Tables []tabledata.Table
+	// TableDataRetention is how long the items of a test run are kept after
+	// the last of them was written. Defaults to 7 days.
+	TableDataRetention time.Duration
+	// MaxVolumesPerRun limits the number of unattached volumes deleted per
+	// run, so that wrongly tagged volumes cannot all be wiped at once.
+	MaxVolumesPerRun int
+	// LogGroupRetentionDays is set as retention on CI log groups which are
+	// kept for now and have no retention policy. No retention is set when
+	// zero.
+	LogGroupRetentionDays int64
+	// CloseAccounts enables closing CI member accounts of the organization,
+	// which are only reported otherwise. Closed accounts cannot be reused
+	// and only count against the quota of the organization until AWS
+	// removes them after 90 days.
+	CloseAccounts bool
+	// DeleteTableData enables deleting the items of test runs from shared
+	// tables, which are only reported otherwise.
+	DeleteTableData bool
+	// DeleteCloudHSMClusters enables deleting CI CloudHSM clusters, which
+	// are only reported otherwise.
+	DeleteCloudHSMClusters bool
+	// DeprovisionBYOIPCIDRs enables withdrawing and deprovisioning the BYOIP
+	// CIDRs of CI IPv6 pools, which are only reported otherwise. A
+	// deprovisioned CIDR has to be provisioned again, which takes days,
+	// before it can be used again.
+	DeprovisionBYOIPCIDRs bool
+	// DisableMacie enables disabling Macie in accounts where it is not
+	// managed by an organization. Macie sessions cannot be tagged, so this is
+	// meant for accounts used by CI only.
+	DisableMacie bool
+	// TerminateProtectedEMRClusters enables terminating CI EMR clusters
+	// with termination protection, which are only reported otherwise.
+	TerminateProtectedEMRClusters bool
+	// Queries maps cleaner names to the names or ARNs of Resource Explorer
+	// views, which then replace the in-code matching of the cleaner. Only
+	// the stacks, buckets, secrets and soft-deleted-secrets cleaners support
+	// views.
+	Queries map[string]string
+	// CIPrincipals are the ARNs of the IAM roles and users CI runs as. The
+	// resources they created according to CloudTrail are treated as CI
+	// resources regardless of their name.
+	CIPrincipals []string
+
+	EC2Client              EC2Client
+	ACMClient              ACMClient
+	AutoScalingClient      AutoScalingClient
+	BatchClient            BatchClient
+	CFClient               CFClient
+	CloudHSMClient         CloudHSMClient
+	CloudTrailClient       CloudTrailClient
+	CloudWatchLogsClient   CloudWatchLogsClient
+	DynamoDBClient         DynamoDBClient
+	ECRClient              ECRClient
+	EFSClient              EFSClient
+	EKSClient              EKSClient
+	ELBClient              ELBClient
+	ELBV2Client            ELBV2Client
+	EMRClient              EMRClient
+	FirehoseClient         FirehoseClient
+	GrafanaClient          GrafanaClient
+	GuardDutyClient        GuardDutyClient
+	IAMClient              IAMClient
+	ImageBuilderClient     ImageBuilderClient
+	InspectorClient        InspectorClient
+	KafkaClient            KafkaClient
+	KinesisClient          KinesisClient
+	KMSClient              KMSClient
+	Logger                 micrologger.Logger
+	LambdaClient           LambdaClient
+	LicenseManagerClient   LicenseManagerClient
+	MacieClient            MacieClient
+	NetworkFirewallClient  NetworkFirewallClient
+	OrganizationsClient    OrganizationsClient
+	PrometheusClient       PrometheusClient
+	RDSClient              RDSClient
+	ResourceExplorerClient ResourceExplorerClient
+	Run                    *run.Run
+	Route53Client          Route53Client
+	Route53ResolverClient  Route53ResolverClient
+	S3Client               S3Client
+	SageMakerClient        SageMakerClient
+	SecretsManagerClient   SecretsManagerClient
+	ServiceDiscoveryClient ServiceDiscoveryClient
+	ServiceQuotasClient    ServiceQuotasClient
+	SNSClient              SNSClient
+	SQSClient              SQSClient
+	SSMClient              SSMClient
+	SyntheticsClient       SyntheticsClient
 }
 
 type Cleaner struct {
-	ec2Client     EC2Client
-	cfClient      CFClient
-	logger        micrologger.Logger
-	route53Client Route53Client
-	s3Client      S3Client
+	acceleratorGracePeriod time.Duration
+	accountRetention       time.Duration
+	amiRetention           time.Duration
+	chartRepositories      []string
+	chartRetention         time.Duration
+	chartSuffixes          []string
+	tables                 []tabledata.Table
+	tableDataRetention     time.Duration
+	gracePeriod            time.Duration
+	maxVolumesPerRun       int
+	logGroupRetentionDays  int64
+	prefixes               []string
+	queries                map[string]string
+	ciPrincipals           []string
+
+	closeAccounts                 bool
+	deleteCloudHSMClusters        bool
+	deprovisionBYOIPCIDRs         bool
+	deleteTableData               bool
+	disableMacie                  bool
+	terminateProtectedEMRClusters bool
+
+	// queryMatches holds the ARNs matched by the views of the cleaners with
+	// configured queries.
+	queryMatches map[string]map[string]bool
+	// createdByCI holds the names and IDs of the resources created by the
+	// configured CI principals.
+	createdByCI map[string]bool
+
+	ec2Client              EC2Client
+	acmClient              ACMClient
+	autoScalingClient      AutoScalingClient
+	batchClient            BatchClient
+	cfClient               CFClient
+	cloudHSMClient         CloudHSMClient
+	cloudTrailClient       CloudTrailClient
+	cloudWatchLogsClient   CloudWatchLogsClient
+	dynamoDBClient         DynamoDBClient
+	ecrClient              ECRClient
+	efsClient              EFSClient
+	eksClient              EKSClient
+	elbClient              ELBClient
+	elbv2Client            ELBV2Client
+	emrClient              EMRClient
+	firehoseClient         FirehoseClient
+	grafanaClient          GrafanaClient
+	guardDutyClient        GuardDutyClient
+	iamClient              IAMClient
+	imageBuilderClient     ImageBuilderClient
+	inspectorClient        InspectorClient
+	kafkaClient            KafkaClient
+	kinesisClient          KinesisClient
+	kmsClient              KMSClient
+	logger                 micrologger.Logger
+	lambdaClient           LambdaClient
+	licenseManagerClient   LicenseManagerClient
+	macieClient            MacieClient
+	networkFirewallClient  NetworkFirewallClient
+	organizationsClient    OrganizationsClient
+	prometheusClient       PrometheusClient
+	rdsClient              RDSClient
+	resourceExplorerClient ResourceExplorerClient
+	run                    *run.Run
+	route53Client          Route53Client
+	route53ResolverClient  Route53ResolverClient
+	s3Client               S3Client
+	sageMakerClient        SageMakerClient
+	secretsManagerClient   SecretsManagerClient
+	serviceDiscoveryClient ServiceDiscoveryClient
+	serviceQuotasClient    ServiceQuotasClient
+	snsClient              SNSClient
+	sqsClient              SQSClient
+	ssmClient              SSMClient
+	syntheticsClient       SyntheticsClient
 }
 
 func New(config *Config) (*Cleaner, error) {
+	if config.ACMClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ACMClient must not be empty", config)
+	}
+	if config.AutoScalingClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.AutoScalingClient must not be empty", config)
+	}
+	if config.BatchClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.BatchClient must not be empty", config)
+	}
 	if config.CFClient == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.CFClient must not be empty", config)
 	}
+	if config.CloudHSMClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.CloudHSMClient must not be empty", config)
+	}
+	if config.CloudTrailClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.CloudTrailClient must not be empty", config)
+	}
+	if config.CloudWatchLogsClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.CloudWatchLogsClient must not be empty", config)
+	}
+	if config.DynamoDBClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.DynamoDBClient must not be empty", config)
+	}
 	if config.EC2Client == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.ec2lient must not be empty", config)
 	}
+	if config.ECRClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ECRClient must not be empty", config)
+	}
+	if config.EFSClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.EFSClient must not be empty", config)
+	}
+	if config.EKSClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.EKSClient must not be empty", config)
+	}
+	if config.ELBClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ELBClient must not be empty", config)
+	}
+	if config.ELBV2Client == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ELBV2Client must not be empty", config)
+	}
+	if config.EMRClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.EMRClient must not be empty", config)
+	}
+	if config.FirehoseClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.FirehoseClient must not be empty", config)
+	}
+	if config.GrafanaClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.GrafanaClient must not be empty", config)
+	}
+	if config.GuardDutyClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.GuardDutyClient must not be empty", config)
+	}
+	if config.IAMClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.IAMClient must not be empty", config)
+	}
+	if config.ImageBuilderClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ImageBuilderClient must not be empty", config)
+	}
+	if config.InspectorClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.InspectorClient must not be empty", config)
+	}
+	if config.KafkaClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.KafkaClient must not be empty", config)
+	}
+	if config.KinesisClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.KinesisClient must not be empty", config)
+	}
+	if config.KMSClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.KMSClient must not be empty", config)
+	}
+	if config.LambdaClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.LambdaClient must not be empty", config)
+	}
+	if config.LicenseManagerClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.LicenseManagerClient must not be empty", config)
+	}
 	if config.Logger == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
 	}
+	if config.MacieClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.MacieClient must not be empty", config)
+	}
+	if config.NetworkFirewallClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.NetworkFirewallClient must not be empty", config)
+	}
+	if config.OrganizationsClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.OrganizationsClient must not be empty", config)
+	}
+	if config.PrometheusClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.PrometheusClient must not be empty", config)
+	}
+	if config.RDSClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.RDSClient must not be empty", config)
+	}
+	if config.Run == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Run must not be empty", config)
+	}
 	if config.Route53Client == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.Route53Client must not be empty", config)
 	}
+	if config.Route53ResolverClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Route53ResolverClient must not be empty", config)
+	}
 	if config.S3Client == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.S3Client must not be empty", config)
 	}
+	if config.SageMakerClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.SageMakerClient must not be empty", config)
+	}
+	if config.SecretsManagerClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.SecretsManagerClient must not be empty", config)
+	}
+	if config.ServiceDiscoveryClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ServiceDiscoveryClient must not be empty", config)
+	}
+	if config.ServiceQuotasClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ServiceQuotasClient must not be empty", config)
+	}
+	if config.SNSClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.SNSClient must not be empty", config)
+	}
+	if config.SQSClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.SQSClient must not be empty", config)
+	}
+	if config.SSMClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.SSMClient must not be empty", config)
+	}
+	if config.SyntheticsClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.SyntheticsClient must not be empty", config)
+	}
+
+	if len(config.Queries) != 0 && config.ResourceExplorerClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ResourceExplorerClient must not be empty when %T.Queries is given", config, config)
+	}
+	for c := range config.Queries {
+		if !queryCleaners[c] {
+			return nil, microerror.Maskf(invalidConfigError, "%T.Queries must not configure cleaner %#q, which does not support views", config, c)
+		}
+	}
+
+	for i, t := range config.Tables {
+		if t.Name == "" {
+			return nil, microerror.Maskf(invalidConfigError, "%T.Tables[%d].Name must not be empty", config, i)
+		}
+		if len(t.KeyPrefixes) == 0 {
+			return nil, microerror.Maskf(invalidConfigError, "%T.Tables[%d].KeyPrefixes must not be empty", config, i)
+		}
+	}
+
+	if config.AcceleratorGracePeriod == 0 {
+		config.AcceleratorGracePeriod = defaultAcceleratorGracePeriod
+	}
+	if config.AccountRetention == 0 {
+		config.AccountRetention = defaultAccountRetention
+	}
+	if config.AMIRetention == 0 {
+		config.AMIRetention = defaultAMIRetention
+	}
+	if config.ChartRetention == 0 {
+		config.ChartRetention = defaultChartRetention
+	}
+	if len(config.ChartSuffixes) == 0 {
+		config.ChartSuffixes = chart.DefaultSuffixes
+	}
+	if config.GracePeriod == 0 {
+		config.GracePeriod = defaultGracePeriod
+	}
+	if config.MaxVolumesPerRun == 0 {
+		config.MaxVolumesPerRun = defaultMaxVolumesPerRun
+	}
+	if len(config.Prefixes) == 0 {
+		config.Prefixes = defaultPrefixes
+	}
+	if config.TableDataRetention == 0 {
+		config.TableDataRetention = defaultTableDataRetention
+	}
 
 	cleaner := &Cleaner{
-		ec2Client:     config.EC2Client,
-		cfClient:      config.CFClient,
-		logger:        config.Logger,
-		route53Client: config.Route53Client,
-		s3Client:      config.S3Client,
+		acceleratorGracePeriod: config.AcceleratorGracePeriod,
+		accountRetention:       config.AccountRetention,
+		amiRetention:           config.AMIRetention,
+		chartRepositories:      config.ChartRepositories,
+		chartRetention:         config.ChartRetention,
+		chartSuffixes:          config.ChartSuffixes,
+		tables:                 config.Tables,
+		tableDataRetention:     config.TableDataRetention,
+		gracePeriod:            config.GracePeriod,
+		maxVolumesPerRun:       config.MaxVolumesPerRun,
+		logGroupRetentionDays:  config.LogGroupRetentionDays,
+		prefixes:               config.Prefixes,
+		queries:                config.Queries,
+		ciPrincipals:           config.CIPrincipals,
+
+		closeAccounts:                 config.CloseAccounts,
+		deleteCloudHSMClusters:        config.DeleteCloudHSMClusters,
+		deprovisionBYOIPCIDRs:         config.DeprovisionBYOIPCIDRs,
+		deleteTableData:               config.DeleteTableData,
+		disableMacie:                  config.DisableMacie,
+		terminateProtectedEMRClusters: config.TerminateProtectedEMRClusters,
+
+		queryMatches: map[string]map[string]bool{},
+
+		ec2Client:              config.EC2Client,
+		acmClient:              config.ACMClient,
+		autoScalingClient:      config.AutoScalingClient,
+		batchClient:            config.BatchClient,
+		cfClient:               config.CFClient,
+		cloudHSMClient:         config.CloudHSMClient,
+		cloudTrailClient:       config.CloudTrailClient,
+		cloudWatchLogsClient:   config.CloudWatchLogsClient,
+		dynamoDBClient:         config.DynamoDBClient,
+		ecrClient:              config.ECRClient,
+		efsClient:              config.EFSClient,
+		eksClient:              config.EKSClient,
+		elbClient:              config.ELBClient,
+		elbv2Client:            config.ELBV2Client,
+		emrClient:              config.EMRClient,
+		firehoseClient:         config.FirehoseClient,
+		grafanaClient:          config.GrafanaClient,
+		guardDutyClient:        config.GuardDutyClient,
+		iamClient:              config.IAMClient,
+		imageBuilderClient:     config.ImageBuilderClient,
+		inspectorClient:        config.InspectorClient,
+		kafkaClient:            config.KafkaClient,
+		kinesisClient:          config.KinesisClient,
+		logger:                 config.Logger,
+		kmsClient:              config.KMSClient,
+		lambdaClient:           config.LambdaClient,
+		licenseManagerClient:   config.LicenseManagerClient,
+		macieClient:            config.MacieClient,
+		networkFirewallClient:  config.NetworkFirewallClient,
+		organizationsClient:    config.OrganizationsClient,
+		prometheusClient:       config.PrometheusClient,
+		rdsClient:              config.RDSClient,
+		resourceExplorerClient: config.ResourceExplorerClient,
+		run:                    config.Run,
+		route53Client:          config.Route53Client,
+		route53ResolverClient:  config.Route53ResolverClient,
+		s3Client:               config.S3Client,
+		sageMakerClient:        config.SageMakerClient,
+		secretsManagerClient:   config.SecretsManagerClient,
+		serviceDiscoveryClient: config.ServiceDiscoveryClient,
+		serviceQuotasClient:    config.ServiceQuotasClient,
+		snsClient:              config.SNSClient,
+		sqsClient:              config.SQSClient,
+		ssmClient:              config.SSMClient,
+		syntheticsClient:       config.SyntheticsClient,
 	}
 
+	config.Run.RegisterDiagnoser(cleanerStacks, cleaner.diagnoseStack)
+
 	return cleaner, nil
 }
 
-// getFunctionName returns the name of the function passed as argument.
-func getFunctionName(i interface{}) string {
-	return runtime.FuncForPC(reflect.ValueOf(i).Pointer()).Name()
+// Names returns the names of the cleaners in the order they run.
+func (a *Cleaner) Names() []string {
+	var names []string
+	for _, c := range a.cleaners() {
+		names = append(names, c.name)
+	}
+
+	return names
 }
 
 // Clean calls our cleaner functions and logs errors if they happen.
 // We don't return errors as we want all cleaners to be called.
-func (a *Cleaner) Clean() error {
-	type cleanerFn func() error
+func (a *Cleaner) Clean(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
 
-	cleaners := []cleanerFn{
-		a.cleanStacks,
-		a.cleanBuckets,
-		// NOTE this can be enable when needed for further cleanups.
-		// a.cleanHostedZones,
+	err := a.loadCreatedByCI(ctx)
+	if err != nil {
+		a.logger.Log("level", "error", "message", "looking up resources created by CI principals", "stack", fmt.Sprintf("%#v", err))
+		errors.Append(err)
 	}
 
-	errors := &errorcollection.ErrorCollection{}
+	for _, c := range a.cleaners() {
+		if !a.run.Enabled(c.name) {
+			continue
+		}
+
+		ctx := logctx.WithCleaner(ctx, c.name)
 
-	for _, f := range cleaners {
-		a.logger.Log("level", "info", "message", fmt.Sprintf("running cleaner %s", getFunctionName(f)))
-		err := f()
+		a.logger.LogCtx(ctx, "level", "info", "message", fmt.Sprintf("running cleaner %s", c.name))
+
+		err := a.loadQueries(ctx, c.name)
+		if err != nil {
+			a.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("running queries of cleaner %s", c.name), "stack", fmt.Sprintf("%#v", err))
+			errors.Append(err)
+			continue
+		}
+
+		err = c.fn(ctx)
 		if err != nil {
-			a.logger.Log("level", "error", "message", fmt.Sprintf("running cleaner %s", getFunctionName(f)), "stack", fmt.Sprintf("%#v", err))
+			a.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("running cleaner %s", c.name), "stack", fmt.Sprintf("%#v", err))
 			errors.Append(err)
+			continue
 		}
+
+		a.run.Complete(c.name)
 	}
 
+	a.reportQuotas(ctx)
+
 	if errors.HasErrors() {
 		return errors
 	}
@@ -97,46 +531,166 @@ func (a *Cleaner) Clean() error {
 	return nil
 }
 
-func (a *Cleaner) cleanStacks() error {
+// namedCleaner is a single cleaner of the provider.
+type namedCleaner struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// cleaners returns all cleaners in the order they run. Resources depending on
+// others are deleted first, e.g. instances before their VPCs.
+func (a *Cleaner) cleaners() []namedCleaner {
+	cleaners := []namedCleaner{
+		{name: cleanerStacks, fn: a.cleanStacks},
+		{name: cleanerStackSets, fn: a.cleanStackSets},
+		{name: cleanerBuckets, fn: a.cleanBuckets},
+		{name: cleanerSecrets, fn: a.cleanSecrets},
+		{name: cleanerSoftDeletedSecrets, fn: a.cleanSoftDeletedSecrets},
+		{name: cleanerSSMParameters, fn: a.cleanSSMParameters},
+		{name: cleanerAutoScalingGroups, fn: a.cleanAutoScalingGroups},
+		{name: cleanerSpotRequests, fn: a.cleanSpotRequests},
+		{name: cleanerAcceleratorInstances, fn: a.cleanAcceleratorInstances},
+		{name: cleanerInstances, fn: a.cleanInstances},
+		{name: cleanerVolumes, fn: a.cleanVolumes},
+		{name: cleanerLicenseConfigurations, fn: a.cleanLicenseConfigurations},
+		{name: cleanerImageBuilder, fn: a.cleanImageBuilder},
+		{name: cleanerImages, fn: a.cleanImages},
+		{name: cleanerClientVPNEndpoints, fn: a.cleanClientVPNEndpoints},
+		{name: cleanerVPNConnections, fn: a.cleanVPNConnections},
+		{name: cleanerResolver, fn: a.cleanResolver},
+		{name: cleanerCloudMap, fn: a.cleanCloudMap},
+		{name: cleanerHostedZones, fn: a.cleanHostedZones},
+		{name: cleanerDelegationRecords, fn: a.cleanDelegationRecords},
+		{name: cleanerMSK, fn: a.cleanMSK},
+		{name: cleanerEMR, fn: a.cleanEMR},
+		{name: cleanerSageMaker, fn: a.cleanSageMaker},
+		{name: cleanerBatch, fn: a.cleanBatch},
+		{name: cleanerCloudHSM, fn: a.cleanCloudHSMClusters},
+		{name: cleanerLambdaFunctions, fn: a.cleanLambdaFunctions},
+		{name: cleanerTopics, fn: a.cleanTopics},
+		{name: cleanerQueues, fn: a.cleanQueues},
+		{name: cleanerRDS, fn: a.cleanRDS},
+		{name: cleanerDynamoDBTables, fn: a.cleanDynamoDBTables},
+		{name: cleanerTableData, fn: a.cleanTableData},
+		{name: cleanerECRRepositories, fn: a.cleanECRRepositories},
+		{name: cleanerCharts, fn: a.cleanCharts},
+		{name: cleanerEKSClusters, fn: a.cleanEKSClusters},
+		{name: cleanerFileSystems, fn: a.cleanFileSystems},
+		{name: cleanerLoadBalancers, fn: a.cleanLoadBalancers},
+		{name: cleanerCertificates, fn: a.cleanCertificates},
+		{name: cleanerNATGateways, fn: a.cleanNATGateways},
+		{name: cleanerNetworkFirewalls, fn: a.cleanNetworkFirewalls},
+		{name: cleanerAddresses, fn: a.cleanAddresses},
+		{name: cleanerKeyPairs, fn: a.cleanKeyPairs},
+		{name: cleanerTransitGateways, fn: a.cleanTransitGateways},
+		{name: cleanerVPCPeeringConnections, fn: a.cleanVPCPeeringConnections},
+		{name: cleanerTrafficMirroring, fn: a.cleanTrafficMirroring},
+		{name: cleanerFlowLogs, fn: a.cleanFlowLogs},
+		{name: cleanerRouteTables, fn: a.cleanRouteTables},
+		{name: cleanerInternetGateways, fn: a.cleanInternetGateways},
+		{name: cleanerNetworkInterfaces, fn: a.cleanNetworkInterfaces},
+		{name: cleanerSecurityGroups, fn: a.cleanSecurityGroups},
+		{name: cleanerVPCs, fn: a.cleanVPCs},
+		{name: cleanerIPv6Pools, fn: a.cleanIPv6Pools},
+		{name: cleanerRoles, fn: a.cleanRoles},
+		{name: cleanerOIDCProviders, fn: a.cleanOIDCProviders},
+		{name: cleanerUsers, fn: a.cleanUsers},
+		{name: cleanerKMSKeys, fn: a.cleanKMSKeys},
+		{name: cleanerSubscriptionFilters, fn: a.cleanSubscriptionFilters},
+		{name: cleanerLogGroups, fn: a.cleanLogGroups},
+		{name: cleanerDetectors, fn: a.cleanDetectors},
+		{name: cleanerCanaries, fn: a.cleanCanaries},
+		{name: cleanerPrometheusWorkspaces, fn: a.cleanPrometheusWorkspaces},
+		{name: cleanerGrafanaWorkspaces, fn: a.cleanGrafanaWorkspaces},
+		{name: cleanerOrganization, fn: a.cleanOrganization},
+	}
+
+	return cleaners
+}
+
+// cleanStacks deletes the CI stacks which are older than the grace period.
+// Stacks which are already being deleted are left alone.
+func (a *Cleaner) cleanStacks(ctx context.Context) error {
 	errors := &errorcollection.ErrorCollection{}
 
-	input := &cloudformation.DescribeStacksInput{}
-	output, err := a.cfClient.DescribeStacks(input)
+	var stacks []*cloudformation.Stack
+	var nextToken *string
+	err := paginate(ctx, &nextToken, func() (*string, error) {
+		input := &cloudformation.DescribeStacksInput{
+			NextToken: nextToken,
+		}
+		output, err := a.cfClient.DescribeStacks(input)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		stacks = append(stacks, output.Stacks...)
+
+		return output.NextToken, nil
+	})
 	if err != nil {
 		errors.Append(microerror.Mask(err))
 		return errors
 	}
 
-	for _, stack := range output.Stacks {
-		if !stackShouldBeDeleted(stack) {
+	for _, stack := range stacks {
+		if !a.stackShouldBeDeleted(stack) {
 			continue
 		}
 
 		a.logger.Log("level", "info", "message", fmt.Sprintf("found that stack %#q should be deleted", *stack.StackName))
 
-		if isTenantStack(stack) {
-			a.logger.Log("level", "debug", "message", fmt.Sprintf("disabling termination protection for EC2 instance belonging to the stack %#q", *stack.StackName))
-			err = a.disableMasterTerminationProtection(*stack.StackName)
-			if err != nil {
-				errors.Append(microerror.Mask(err))
-				// do not return on error, try to continue deleting.
-				a.logger.Log("level", "error", "message", fmt.Sprintf("failed disabling termination protection for EC2 instance belonging to the stack %#q: %#v. Skipping deletion.", *stack.StackName, err))
-				continue
-			}
+		res := run.Resource{
+			ID:        *stack.StackName,
+			Type:      "AWS::CloudFormation::Stack",
+			Tags:      stackTags(stack),
+			CreatedAt: aws.TimeValue(stack.CreationTime),
+		}
+		if aws.BoolValue(stack.EnableTerminationProtection) {
+			res.Note = "termination protection is overridden"
+		}
+		err := a.run.DeleteResource(ctx, cleanerStacks, res, func() error {
+			return a.deleteStack(ctx, stack)
+		})
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			// do not return on error, try to continue deleting.
+			a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting stack %#q: %s", *stack.StackName, err.Error()), "stack", fmt.Sprintf("%#v", err))
+			a.logger.Log("level", "debug", "message", fmt.Sprintf("stack details: %#v", stack))
+		}
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) deleteStack(ctx context.Context, stack *cloudformation.Stack) error {
+	if isTenantStack(stack) {
+		a.logger.Log("level", "debug", "message", fmt.Sprintf("disabling termination protection for EC2 instance belonging to the stack %#q", *stack.StackName))
+		err := a.disableMasterTerminationProtection(*stack.StackName)
+		if err != nil {
+			return microerror.Mask(err)
 		}
+	}
 
-		a.logger.Log("level", "debug", "message", fmt.Sprintf("disabling termination protection for stack %#q", *stack.StackName))
+	disable := func() error {
 		enableTerminationProtection := false
 		updateTerminationProtection := &cloudformation.UpdateTerminationProtectionInput{
 			EnableTerminationProtection: &enableTerminationProtection,
 			StackName:                   stack.StackName,
 		}
-		_, err = a.cfClient.UpdateTerminationProtection(updateTerminationProtection)
+		_, err := a.cfClient.UpdateTerminationProtection(updateTerminationProtection)
 		if err != nil {
-			errors.Append(microerror.Mask(err))
-			// do not return on error, try to continue deleting.
-			a.logger.Log("level", "error", "message", fmt.Sprintf("failed disabling termination protection for %#q: %#v. Skipping deletion.", *stack.StackName, err))
-			continue
+			return microerror.Mask(err)
+		}
+
+		return nil
+	}
+	del := func() error {
+		if aws.StringValue(stack.StackStatus) == cloudformation.StackStatusDeleteFailed {
+			return a.deleteFailedStack(ctx, stack)
 		}
 
 		deleteStackInput := &cloudformation.DeleteStackInput{
@@ -144,22 +698,70 @@ func (a *Cleaner) cleanStacks() error {
 		}
 		_, err := a.cfClient.DeleteStack(deleteStackInput)
 		if err != nil {
-			errors.Append(microerror.Mask(err))
-			// do not return on error, try to continue deleting.
-			a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting stack %#q: %s", *stack.StackName, err.Error()), "stack", fmt.Sprintf("%#v", err))
-			a.logger.Log("level", "debug", "message", fmt.Sprintf("stack details: %#v", stack))
-		} else {
-			a.logger.Log("level", "info", "message", fmt.Sprintf("deleted stack %#q", *stack.StackName))
+			return microerror.Mask(err)
 		}
-	}
 
-	if errors.HasErrors() {
-		return errors
+		return nil
+	}
+	err := a.deleteProtected("stack", *stack.StackName, aws.BoolValue(stack.EnableTerminationProtection), disable, del)
+	if err != nil {
+		return microerror.Mask(err)
 	}
+
 	return nil
 }
 
-func (a *Cleaner) cleanBuckets() error {
+func stackTags(stack *cloudformation.Stack) map[string]string {
+	if len(stack.Tags) == 0 {
+		return nil
+	}
+
+	tags := map[string]string{}
+	for _, t := range stack.Tags {
+		tags[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+	}
+
+	return tags
+}
+
+func ec2Tags(ec2Tags []*ec2.Tag) map[string]string {
+	if len(ec2Tags) == 0 {
+		return nil
+	}
+
+	tags := map[string]string{}
+	for _, t := range ec2Tags {
+		tags[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+	}
+
+	return tags
+}
+
+// diagnoseStack returns the reasons CloudFormation gave for failing to delete
+// resources of the given stack, which usually name the dependencies
+// preventing the deletion.
+func (a *Cleaner) diagnoseStack(ctx context.Context, name string) ([]string, error) {
+	input := &cloudformation.DescribeStackEventsInput{
+		StackName: aws.String(name),
+	}
+	output, err := a.cfClient.DescribeStackEvents(input)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var diagnostics []string
+	for _, e := range output.StackEvents {
+		if aws.StringValue(e.ResourceStatus) != cloudformation.ResourceStatusDeleteFailed {
+			continue
+		}
+
+		diagnostics = append(diagnostics, fmt.Sprintf("%s (%s): %s", aws.StringValue(e.LogicalResourceId), aws.StringValue(e.ResourceType), aws.StringValue(e.ResourceStatusReason)))
+	}
+
+	return diagnostics, nil
+}
+
+func (a *Cleaner) cleanBuckets(ctx context.Context) error {
 	errors := &errorcollection.ErrorCollection{}
 
 	input := &s3.ListBucketsInput{}
@@ -170,16 +772,24 @@ func (a *Cleaner) cleanBuckets() error {
 	}
 
 	for _, bucket := range output.Buckets {
-		if !bucketShouldBeDeleted(bucket) {
+		tags := func() map[string]string {
+			return a.bucketTags(bucket.Name)
+		}
+		if !a.bucketShouldBeDeleted(bucket, tags) {
 			continue
 		}
 		a.logger.Log("level", "debug", "message", fmt.Sprintf("found that bucket %#q should be deleted", *bucket.Name))
-		err := a.deleteBucket(bucket.Name)
+		res := run.Resource{
+			ID:        *bucket.Name,
+			Type:      "AWS::S3::Bucket",
+			CreatedAt: aws.TimeValue(bucket.CreationDate),
+		}
+		err := a.run.DeleteResource(ctx, cleanerBuckets, res, func() error {
+			return a.deleteBucket(bucket.Name)
+		})
 		if err != nil {
 			errors.Append(microerror.Mask(err))
 			a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting bucket %#q: %#v", *bucket.Name, err), "stack", fmt.Sprintf("%#v", err))
-		} else {
-			a.logger.Log("level", "info", "message", fmt.Sprintf("deleted bucket %#q", *bucket.Name))
 		}
 	}
 
@@ -189,51 +799,17 @@ func (a *Cleaner) cleanBuckets() error {
 	return nil
 }
 
-func (a *Cleaner) cleanHostedZones() error {
-	var marker *string
-	for {
-		in := &route53.ListHostedZonesInput{
-			Marker: marker,
-		}
-
-		out, err := a.route53Client.ListHostedZones(in)
-		if err != nil {
-			return microerror.Mask(err)
-		}
-
-		for _, hz := range out.HostedZones {
-			if hz.Name == nil || hz.Id == nil {
-				continue
-			}
-
-			fmt.Printf("\n")
-			fmt.Printf("%#v\n", *hz.Id)
-			fmt.Printf("%#v\n", *hz.Name)
-			fmt.Printf("%#v\n", hz)
-			fmt.Printf("\n")
-		}
-
-		if out.IsTruncated == nil || !*out.IsTruncated {
-			break
-		} else {
-			marker = out.Marker
-		}
+func (a *Cleaner) stackShouldBeDeleted(stack *cloudformation.Stack) bool {
+	matched, ok := a.queried(cleanerStacks, aws.StringValue(stack.StackId))
+	if !ok {
+		matched = a.hasCIPrefix(*stack.StackName)
 	}
-
-	return nil
-}
-
-func stackShouldBeDeleted(stack *cloudformation.Stack) bool {
-	if stack.CreationTime == nil {
-		// bad formed stack, should be deleted
-		return true
+	if !matched {
+		return false
 	}
 
-	now := time.Now().UTC()
-	timeDiff := now.Sub(*stack.CreationTime)
-
-	// do not delete recent stacks.
-	if timeDiff < gracePeriod {
+	// do not delete recent stacks, nor stacks whose age is unknown.
+	if isRecent(stack.CreationTime, a.gracePeriod) {
 		return false
 	}
 
@@ -242,14 +818,19 @@ func stackShouldBeDeleted(stack *cloudformation.Stack) bool {
 		return false
 	}
 
-	prefixes := []string{
-		"cluster-ci-",
-		"host-peer-ci-",
-		"e2e-",
-		"ci-",
+	return true
+}
+
+// hasCIPrefix checks if the given resource name starts with one of the name
+// prefixes used by CI, or if the resource was created by one of the
+// configured CI principals.
+func (a *Cleaner) hasCIPrefix(name string) bool {
+	if a.createdByCI[name] {
+		return true
 	}
-	for _, prefix := range prefixes {
-		if strings.HasPrefix(*stack.StackName, prefix) {
+
+	for _, prefix := range a.prefixes {
+		if strings.HasPrefix(name, prefix) {
 			return true
 		}
 	}
@@ -257,6 +838,56 @@ func stackShouldBeDeleted(stack *cloudformation.Stack) bool {
 	return false
 }
 
+// isCITagged checks if the given tags mark a resource as created by CI,
+// either by its name or by the standard Giant Swarm tags of the installation
+// or cluster it belongs to, including the tags Kubernetes provisioned
+// resources carry.
+func (a *Cleaner) isCITagged(tags map[string]string) bool {
+	for _, k := range []string{"Name", clusterTag, installationTag} {
+		if v := tags[k]; v != "" && a.hasCIPrefix(v) {
+			return true
+		}
+	}
+
+	return a.kubernetesCluster(tags) != ""
+}
+
+// kubernetesCluster returns the name of the CI cluster whose Kubernetes
+// provisioned the resource with the given tags, if any.
+func (a *Cleaner) kubernetesCluster(tags map[string]string) string {
+	for k := range tags {
+		if !strings.HasPrefix(k, kubernetesClusterTagPrefix) {
+			continue
+		}
+
+		cluster := strings.TrimPrefix(k, kubernetesClusterTagPrefix)
+		if a.hasCIPrefix(cluster) {
+			return cluster
+		}
+	}
+
+	return ""
+}
+
+// isRecent checks if the given creation time lies within the given age.
+// Resources whose creation time is unknown count as recent, so that they are
+// never deleted because an API left it out.
+func isRecent(createdAt *time.Time, age time.Duration) bool {
+	return createdAt == nil || time.Since(*createdAt) < age
+}
+
+// parseTimestamp parses the timestamps of APIs returning them as ISO 8601
+// strings, like creation times. Missing or malformed timestamps are returned
+// as nil.
+func parseTimestamp(s *string) *time.Time {
+	t, err := time.Parse(time.RFC3339, aws.StringValue(s))
+	if err != nil {
+		return nil
+	}
+
+	return &t
+}
+
 func isTenantStack(stack *cloudformation.Stack) bool {
 	outputs := stack.Outputs
 	for _, o := range outputs {
@@ -268,20 +899,30 @@ func isTenantStack(stack *cloudformation.Stack) bool {
 	return false
 }
 
-func bucketShouldBeDeleted(bucket *s3.Bucket) bool {
-	if bucket.CreationDate == nil {
-		// bad formed bucket, should be deleted
-		return true
+// bucketShouldBeDeleted checks if the given bucket is a CI bucket older than
+// the grace period. CI buckets are identified by name or, for buckets with
+// other names, by their tags. Fetching the tags of a bucket is a request of
+// its own, which is why they are only fetched when needed.
+func (a *Cleaner) bucketShouldBeDeleted(bucket *s3.Bucket, tags func() map[string]string) bool {
+	matched, queried := a.queried(cleanerBuckets, "arn:aws:s3:::"+*bucket.Name)
+	if !queried {
+		matched = hasCIBucketName(*bucket.Name)
+	}
+	if queried && !matched {
+		return false
 	}
 
-	now := time.Now().UTC()
-	timeDiff := now.Sub(*bucket.CreationDate)
-
-	// do not delete recent buckets.
-	if timeDiff < gracePeriod {
+	// do not delete recent buckets, nor buckets whose age is unknown.
+	if isRecent(bucket.CreationDate, a.gracePeriod) {
 		return false
 	}
 
+	return matched || a.isCITagged(tags())
+}
+
+// hasCIBucketName checks if the given bucket name is one of the names CI
+// gives its buckets.
+func hasCIBucketName(name string) bool {
 	patterns := []string{
 		`\Aci-last-.*`,
 		`\Aci-prev-.*`,
@@ -294,7 +935,7 @@ func bucketShouldBeDeleted(bucket *s3.Bucket) bool {
 		`.*-g8s-ci-.*`,
 	}
 	for _, pattern := range patterns {
-		matches, _ := regexp.MatchString(pattern, *bucket.Name)
+		matches, _ := regexp.MatchString(pattern, name)
 		if matches {
 			return true
 		}
@@ -303,47 +944,80 @@ func bucketShouldBeDeleted(bucket *s3.Bucket) bool {
 	return false
 }
 
+// bucketTags returns the tags of the given bucket. Buckets without tags or
+// whose tags cannot be read, e.g. because they are located in another
+// region, are considered untagged.
+func (a *Cleaner) bucketTags(name *string) map[string]string {
+	o, err := a.s3Client.GetBucketTagging(&s3.GetBucketTaggingInput{Bucket: name})
+	if err != nil {
+		a.logger.Log("level", "debug", "message", fmt.Sprintf("not reading tags of bucket %#q: %s", *name, err.Error()))
+		return nil
+	}
+
+	tags := map[string]string{}
+	for _, t := range o.TagSet {
+		tags[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+	}
+
+	return tags
+}
+
+// deleteBucket deletes all object versions and delete markers of the given
+// bucket in batches before deleting the bucket itself, as buckets can only be
+// deleted when they are empty. Unversioned buckets list their objects as
+// versions as well.
 func (a *Cleaner) deleteBucket(name *string) error {
-	var repeat bool
+	var keyMarker, versionIDMarker *string
 	for {
-		i := &s3.ListObjectsV2Input{
-			Bucket: name,
+		i := &s3.ListObjectVersionsInput{
+			Bucket:          name,
+			KeyMarker:       keyMarker,
+			VersionIdMarker: versionIDMarker,
 		}
-		o, err := a.s3Client.ListObjectsV2(i)
+		o, err := a.s3Client.ListObjectVersions(i)
 		if err != nil {
 			return microerror.Mask(err)
 		}
-		if o.IsTruncated != nil && *o.IsTruncated {
-			repeat = true
-		}
-		if len(o.Contents) == 0 {
-			break
-		}
 
-		//batch up the objects for deletion
 		var objects []*s3.ObjectIdentifier
-		for _, o := range o.Contents {
+		for _, v := range o.Versions {
 			objects = append(objects, &s3.ObjectIdentifier{
-				Key: o.Key,
+				Key:       v.Key,
+				VersionId: v.VersionId,
 			})
 		}
-		di := &s3.DeleteObjectsInput{
-			Bucket: name,
-			Delete: &s3.Delete{
-				Objects: objects,
-				Quiet:   aws.Bool(true),
-			},
+		for _, m := range o.DeleteMarkers {
+			objects = append(objects, &s3.ObjectIdentifier{
+				Key:       m.Key,
+				VersionId: m.VersionId,
+			})
 		}
-		//delete the batch
-		_, err = a.s3Client.DeleteObjects(di)
-		if err != nil {
-			return microerror.Mask(err)
+
+		if len(objects) != 0 {
+			di := &s3.DeleteObjectsInput{
+				Bucket: name,
+				Delete: &s3.Delete{
+					Objects: objects,
+					Quiet:   aws.Bool(true),
+				},
+			}
+			do, err := a.s3Client.DeleteObjects(di)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+			if len(do.Errors) != 0 {
+				e := do.Errors[0]
+				return microerror.Maskf(executionFailedError, "deleting %d objects of bucket %#q failed, e.g. %#q: %s", len(do.Errors), *name, aws.StringValue(e.Key), aws.StringValue(e.Message))
+			}
 		}
 
-		if !repeat {
+		if !aws.BoolValue(o.IsTruncated) {
 			break
 		}
+		keyMarker = o.NextKeyMarker
+		versionIDMarker = o.NextVersionIdMarker
 	}
+
 	deleteBucketInput := &s3.DeleteBucketInput{
 		Bucket: name,
 	}
@@ -385,7 +1059,7 @@ func (a *Cleaner) disableMasterTerminationProtection(stackName string) error {
 	for _, reservation := range o.Reservations {
 
 		if len(reservation.Instances) != 1 {
-			return microerror.Newf("Expected one master instance, got %d", len(reservation.Instances))
+			return microerror.Maskf(executionFailedError, "expected one master instance, got %d", len(reservation.Instances))
 		}
 
 		for _, instance := range reservation.Instances {
@@ -5,8 +5,13 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/cloudformation"
 	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/giantswarm/micrologger/microloggertest"
+
+	"github.com/giantswarm/ci-cleaner/pkg/report"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
 )
 
 func TestStackShouldBeDeleted(t *testing.T) {
@@ -16,12 +21,20 @@ func TestStackShouldBeDeleted(t *testing.T) {
 		description string
 	}{
 		{
-			description: "stack without creation time should be deleted",
+			description: "stack without creation time should not be deleted",
 			stack: &cloudformation.Stack{
 				StackName:   aws.String("blblalal"),
 				StackStatus: aws.String("FOO_STATUS"),
 			},
-			expected: true,
+			expected: false,
+		},
+		{
+			description: "ci stack without creation time should not be deleted",
+			stack: &cloudformation.Stack{
+				StackName:   aws.String("cluster-ci-blabla"),
+				StackStatus: aws.String("CREATE_COMPLETE"),
+			},
+			expected: false,
 		},
 		{
 			description: "recent host peer stack should not be deleted",
@@ -104,11 +117,25 @@ func TestStackShouldBeDeleted(t *testing.T) {
 			},
 			expected: false,
 		},
+		{
+			description: "stack that is already deleted",
+			stack: &cloudformation.Stack{
+				StackName:    aws.String("cluster-ci-blabla"),
+				CreationTime: aws.Time(time.Now().Add(-2 * time.Hour)),
+				StackStatus:  aws.String("DELETE_COMPLETE"),
+			},
+			expected: false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
 	}
 
 	for _, tc := range tcs {
 		t.Run(tc.description, func(t *testing.T) {
-			actual := stackShouldBeDeleted(tc.stack)
+			actual := a.stackShouldBeDeleted(tc.stack)
 
 			if actual != tc.expected {
 				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.stack.StackName, tc.expected, actual)
@@ -120,15 +147,23 @@ func TestStackShouldBeDeleted(t *testing.T) {
 func TestBucketShouldBeDeleted(t *testing.T) {
 	tcs := []struct {
 		bucket      *s3.Bucket
+		tags        map[string]string
 		expected    bool
 		description string
 	}{
 		{
-			description: "bucket without creation time should be deleted",
+			description: "bucket without creation time should not be deleted",
 			bucket: &s3.Bucket{
 				Name: aws.String("blblalal"),
 			},
-			expected: true,
+			expected: false,
+		},
+		{
+			description: "ci wip bucket without creation time should not be deleted",
+			bucket: &s3.Bucket{
+				Name: aws.String("270935918670-g8s-ci-wip-50a83-d4f51"),
+			},
+			expected: false,
 		},
 		{
 			description: "recent ci wip bucket should not be deleted",
@@ -258,11 +293,63 @@ func TestBucketShouldBeDeleted(t *testing.T) {
 			},
 			expected: true,
 		},
+		{
+			description: "old bucket tagged for ci should be deleted",
+			bucket: &s3.Bucket{
+				Name:         aws.String("access-logs-a1b2c"),
+				CreationDate: aws.Time(time.Now().Add(-2 * time.Hour)),
+			},
+			tags:     map[string]string{clusterTag: "ci-wip-a1b2c"},
+			expected: true,
+		},
+		{
+			description: "old bucket of ci installation should be deleted",
+			bucket: &s3.Bucket{
+				Name:         aws.String("access-logs-a1b2c"),
+				CreationDate: aws.Time(time.Now().Add(-2 * time.Hour)),
+			},
+			tags:     map[string]string{installationTag: "e2e-gauss"},
+			expected: true,
+		},
+		{
+			description: "old bucket tagged by kubernetes of ci cluster should be deleted",
+			bucket: &s3.Bucket{
+				Name:         aws.String("access-logs-a1b2c"),
+				CreationDate: aws.Time(time.Now().Add(-2 * time.Hour)),
+			},
+			tags:     map[string]string{kubernetesClusterTagPrefix + "ci-wip-a1b2c": "owned"},
+			expected: true,
+		},
+		{
+			description: "old bucket of general installation should not be deleted",
+			bucket: &s3.Bucket{
+				Name:         aws.String("access-logs-a1b2c"),
+				CreationDate: aws.Time(time.Now().Add(-2 * time.Hour)),
+			},
+			tags:     map[string]string{installationTag: "gauss"},
+			expected: false,
+		},
+		{
+			description: "old untagged bucket should not be deleted",
+			bucket: &s3.Bucket{
+				Name:         aws.String("access-logs-a1b2c"),
+				CreationDate: aws.Time(time.Now().Add(-2 * time.Hour)),
+			},
+			expected: false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
 	}
 
 	for _, tc := range tcs {
 		t.Run(tc.description, func(t *testing.T) {
-			actual := bucketShouldBeDeleted(tc.bucket)
+			tags := func() map[string]string {
+				return tc.tags
+			}
+			actual := a.bucketShouldBeDeleted(tc.bucket, tags)
 
 			if actual != tc.expected {
 				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.bucket.Name, tc.expected, actual)
@@ -270,3 +357,42 @@ func TestBucketShouldBeDeleted(t *testing.T) {
 		})
 	}
 }
+
+func TestNames(t *testing.T) {
+	a := &Cleaner{}
+
+	names := a.Names()
+	if len(names) == 0 {
+		t.Fatal("expected cleaners")
+	}
+	if names[0] != cleanerStacks {
+		t.Errorf("expected cleaner %q to run first, got %q", cleanerStacks, names[0])
+	}
+
+	seen := map[string]bool{}
+	for _, n := range names {
+		if seen[n] {
+			t.Errorf("cleaner %q is listed twice", n)
+		}
+		seen[n] = true
+	}
+}
+
+func TestConfigFromSession(t *testing.T) {
+	r, err := run.New(run.Config{
+		Logger: microloggertest.New(),
+		Report: report.New("aws"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := ConfigFromSession(session.Must(session.NewSession(&aws.Config{Region: aws.String("eu-central-1")})))
+	c.Logger = microloggertest.New()
+	c.Run = r
+
+	_, err = New(c)
+	if err != nil {
+		t.Errorf("expected config from session to hold all clients, got %#v", err)
+	}
+}
@@ -0,0 +1,276 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/batch"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanBatch deletes CI Batch job queues and compute environments. Both have
+// to be disabled before they can be deleted, and compute environments only
+// once no job queue uses them anymore, which is tracked by later runs.
+// RUNNABLE jobs of CI job queues are terminated first, as they are usually
+// stuck waiting for compute environments which are gone already.
+func (a *Cleaner) cleanBatch(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	var queues []*batch.JobQueueDetail
+	{
+		i := &batch.DescribeJobQueuesInput{}
+		err := paginate(ctx, &i.NextToken, func() (*string, error) {
+			o, err := a.batchClient.DescribeJobQueues(i)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			queues = append(queues, o.JobQueues...)
+
+			return o.NextToken, nil
+		})
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			return errors
+		}
+	}
+
+	// used holds the names and ARNs of the compute environments job queues
+	// still use.
+	used := map[string]bool{}
+	for _, queue := range queues {
+		if aws.StringValue(queue.Status) == batch.JQStatusDeleted {
+			continue
+		}
+		for _, o := range queue.ComputeEnvironmentOrder {
+			used[aws.StringValue(o.ComputeEnvironment)] = true
+		}
+
+		seen, err := a.run.FirstSeen(cleanerBatch, *queue.JobQueueArn)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			continue
+		}
+
+		if !a.batchResourceShouldBeDeleted(aws.StringValue(queue.JobQueueName), aws.StringValueMap(queue.Tags), aws.StringValue(queue.Status), seen) {
+			continue
+		}
+
+		a.logger.Log("level", "info", "message", fmt.Sprintf("found that batch job queue %#q should be deleted", *queue.JobQueueName))
+
+		res := run.Resource{
+			ID:   *queue.JobQueueArn,
+			Type: "AWS::Batch::JobQueue",
+			Tags: aws.StringValueMap(queue.Tags),
+		}
+		queue := queue
+		start := func() (string, error) {
+			return a.deleteJobQueue(ctx, queue)
+		}
+		err = a.run.DeleteResourceAsync(ctx, cleanerBatch, res, start, a.pollJobQueue)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting batch job queue %#q", *queue.JobQueueName), "stack", fmt.Sprintf("%#v", err))
+		}
+	}
+
+	i := &batch.DescribeComputeEnvironmentsInput{}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.batchClient.DescribeComputeEnvironments(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, env := range o.ComputeEnvironments {
+			// do not delete compute environments job queues still use.
+			if used[aws.StringValue(env.ComputeEnvironmentArn)] || used[aws.StringValue(env.ComputeEnvironmentName)] {
+				continue
+			}
+
+			seen, err := a.run.FirstSeen(cleanerBatch, *env.ComputeEnvironmentArn)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				continue
+			}
+
+			if !a.batchResourceShouldBeDeleted(aws.StringValue(env.ComputeEnvironmentName), aws.StringValueMap(env.Tags), aws.StringValue(env.Status), seen) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that batch compute environment %#q should be deleted", *env.ComputeEnvironmentName))
+
+			res := run.Resource{
+				ID:   *env.ComputeEnvironmentArn,
+				Type: "AWS::Batch::ComputeEnvironment",
+				Tags: aws.StringValueMap(env.Tags),
+			}
+			env := env
+			start := func() (string, error) {
+				return a.deleteComputeEnvironment(env)
+			}
+			err = a.run.DeleteResourceAsync(ctx, cleanerBatch, res, start, a.pollComputeEnvironment)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting batch compute environment %#q", *env.ComputeEnvironmentName), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	err = a.run.PollPending(ctx, cleanerBatch, "AWS::Batch::JobQueue", a.pollJobQueue)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+	}
+
+	err = a.run.PollPending(ctx, cleanerBatch, "AWS::Batch::ComputeEnvironment", a.pollComputeEnvironment)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// deleteJobQueue terminates the RUNNABLE jobs of the given job queue and
+// disables it, or deletes it once it is disabled. The ARN of the job queue is
+// returned to poll the deletion.
+func (a *Cleaner) deleteJobQueue(ctx context.Context, queue *batch.JobQueueDetail) (string, error) {
+	switch aws.StringValue(queue.Status) {
+	case batch.JQStatusCreating, batch.JQStatusUpdating, batch.JQStatusDeleting:
+		return *queue.JobQueueArn, nil
+	}
+
+	i := &batch.ListJobsInput{
+		JobQueue:  queue.JobQueueArn,
+		JobStatus: aws.String(batch.JobStatusRunnable),
+	}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.batchClient.ListJobs(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, job := range o.JobSummaryList {
+			_, err := a.batchClient.TerminateJob(&batch.TerminateJobInput{JobId: job.JobId, Reason: aws.String("job queue is deleted by ci-cleaner")})
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	if aws.StringValue(queue.State) == batch.JQStateEnabled {
+		_, err := a.batchClient.UpdateJobQueue(&batch.UpdateJobQueueInput{JobQueue: queue.JobQueueArn, State: aws.String(batch.JQStateDisabled)})
+		if err != nil {
+			return "", microerror.Mask(err)
+		}
+
+		return *queue.JobQueueArn, nil
+	}
+
+	_, err = a.batchClient.DeleteJobQueue(&batch.DeleteJobQueueInput{JobQueue: queue.JobQueueArn})
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return *queue.JobQueueArn, nil
+}
+
+func (a *Cleaner) pollJobQueue(ctx context.Context, arn string) (bool, error) {
+	o, err := a.batchClient.DescribeJobQueues(&batch.DescribeJobQueuesInput{JobQueues: []*string{aws.String(arn)}})
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	if len(o.JobQueues) == 0 || aws.StringValue(o.JobQueues[0].Status) == batch.JQStatusDeleted {
+		return true, nil
+	}
+
+	_, err = a.deleteJobQueue(ctx, o.JobQueues[0])
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	return false, nil
+}
+
+// deleteComputeEnvironment disables the given compute environment, or
+// deletes it once it is disabled. The ARN of the compute environment is
+// returned to poll the deletion.
+func (a *Cleaner) deleteComputeEnvironment(env *batch.ComputeEnvironmentDetail) (string, error) {
+	switch aws.StringValue(env.Status) {
+	case batch.CEStatusCreating, batch.CEStatusUpdating, batch.CEStatusDeleting:
+		return *env.ComputeEnvironmentArn, nil
+	}
+
+	if aws.StringValue(env.State) == batch.CEStateEnabled {
+		_, err := a.batchClient.UpdateComputeEnvironment(&batch.UpdateComputeEnvironmentInput{ComputeEnvironment: env.ComputeEnvironmentArn, State: aws.String(batch.CEStateDisabled)})
+		if err != nil {
+			return "", microerror.Mask(err)
+		}
+
+		return *env.ComputeEnvironmentArn, nil
+	}
+
+	_, err := a.batchClient.DeleteComputeEnvironment(&batch.DeleteComputeEnvironmentInput{ComputeEnvironment: env.ComputeEnvironmentArn})
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return *env.ComputeEnvironmentArn, nil
+}
+
+func (a *Cleaner) pollComputeEnvironment(ctx context.Context, arn string) (bool, error) {
+	o, err := a.batchClient.DescribeComputeEnvironments(&batch.DescribeComputeEnvironmentsInput{ComputeEnvironments: []*string{aws.String(arn)}})
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	if len(o.ComputeEnvironments) == 0 || aws.StringValue(o.ComputeEnvironments[0].Status) == batch.CEStatusDeleted {
+		return true, nil
+	}
+
+	_, err = a.deleteComputeEnvironment(o.ComputeEnvironments[0])
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	return false, nil
+}
+
+// batchResourceShouldBeDeleted decides about job queues and compute
+// environments alike, given their name, tags, status and when they were
+// first found.
+func (a *Cleaner) batchResourceShouldBeDeleted(name string, tags map[string]string, status string, seen time.Time) bool {
+	if !a.hasCIPrefix(name) && !a.isCITagged(tags) {
+		return false
+	}
+
+	// do not delete resources that are already being deleted.
+	if status == batch.JQStatusDeleting || status == batch.JQStatusDeleted {
+		return false
+	}
+
+	// do not delete recent resources.
+	if time.Since(seen) < a.gracePeriod {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,71 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/batch"
+)
+
+func TestBatchResourceShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		name        string
+		tags        map[string]string
+		status      string
+		seen        time.Time
+		expected    bool
+		description string
+	}{
+		{
+			description: "old ci job queue should be deleted",
+			name:        "ci-wip-a1b2c-jobs",
+			status:      batch.JQStatusValid,
+			seen:        time.Now().Add(-2 * time.Hour),
+			expected:    true,
+		},
+		{
+			description: "old compute environment of ci cluster should be deleted",
+			name:        "spot",
+			tags:        map[string]string{clusterTag: "ci-a1b2c"},
+			status:      batch.CEStatusInvalid,
+			seen:        time.Now().Add(-2 * time.Hour),
+			expected:    true,
+		},
+		{
+			description: "recently found ci job queue should not be deleted",
+			name:        "ci-wip-a1b2c-jobs",
+			status:      batch.JQStatusValid,
+			seen:        time.Now().Add(-time.Hour),
+			expected:    false,
+		},
+		{
+			description: "deleting ci compute environment should not be deleted",
+			name:        "ci-wip-a1b2c-spot",
+			status:      batch.CEStatusDeleting,
+			seen:        time.Now().Add(-2 * time.Hour),
+			expected:    false,
+		},
+		{
+			description: "old general job queue should not be deleted",
+			name:        "nightly",
+			status:      batch.JQStatusValid,
+			seen:        time.Now().Add(-2 * time.Hour),
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := a.batchResourceShouldBeDeleted(tc.name, tc.tags, tc.status, tc.seen)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", tc.name, tc.expected, actual)
+			}
+		})
+	}
+}
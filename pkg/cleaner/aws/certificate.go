@@ -0,0 +1,116 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/acm"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/domain"
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanCertificates deletes the ACM wildcard certificates requested for CI
+// clusters, like `*.ci-wip-a1b2c.k8s.gigantic.io`, which no load balancer or
+// CloudFront distribution uses. Issued certificates are deleted once they
+// outlived their cluster, which is considered gone once its API hostname does
+// not resolve anymore. Certificates which never got issued, e.g. because they
+// are stuck in PENDING_VALIDATION, or which expired cannot serve any cluster
+// and are deleted regardless.
+func (a *Cleaner) cleanCertificates(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &acm.ListCertificatesInput{
+		CertificateStatuses: aws.StringSlice(acm.CertificateStatus_Values()),
+		Includes: &acm.Filters{
+			KeyTypes: aws.StringSlice(acm.KeyAlgorithm_Values()),
+		},
+	}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.acmClient.ListCertificates(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, c := range o.CertificateSummaryList {
+			cluster, ok := a.certificateShouldBeDeleted(c)
+			if !ok {
+				continue
+			}
+
+			res := run.Resource{
+				ID:        *c.CertificateArn,
+				Type:      "AWS::CertificateManager::Certificate",
+				CreatedAt: aws.TimeValue(c.CreatedAt),
+			}
+
+			if aws.StringValue(c.Status) == acm.CertificateStatusIssued {
+				resolves, err := domain.APIResolves(cluster)
+				if err != nil {
+					a.logger.Log("level", "warning", "message", fmt.Sprintf("failed resolving API hostname of cluster %#q", cluster), "stack", fmt.Sprintf("%#v", err))
+					continue
+				}
+				if resolves {
+					continue
+				}
+
+				a.logger.Log("level", "info", "message", fmt.Sprintf("found that certificate %#q of deleted cluster %#q should be deleted", *c.CertificateArn, cluster))
+			} else {
+				a.logger.Log("level", "info", "message", fmt.Sprintf("found that %s certificate %#q of cluster %#q should be deleted", aws.StringValue(c.Status), *c.CertificateArn, cluster))
+				res.Reason = run.ReasonUnused
+			}
+
+			c := c
+			err := a.run.DeleteResource(ctx, cleanerCertificates, res, func() error {
+				_, err := a.acmClient.DeleteCertificate(&acm.DeleteCertificateInput{CertificateArn: c.CertificateArn})
+				if isAWSError(err, acm.ErrCodeResourceNotFoundException) {
+					return nil
+				}
+				return err
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting certificate %#q", *c.CertificateArn), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// certificateShouldBeDeleted checks if the given certificate is an unused
+// wildcard certificate of a CI cluster and returns the domain of the cluster.
+func (a *Cleaner) certificateShouldBeDeleted(c *acm.CertificateSummary) (string, bool) {
+	if c.CertificateArn == nil {
+		return "", false
+	}
+
+	cluster, ok := domain.WildcardCluster(aws.StringValue(c.DomainName), a.prefixes)
+	if !ok {
+		return "", false
+	}
+
+	// do not delete certificates load balancers or distributions still use.
+	if aws.BoolValue(c.InUse) {
+		return "", false
+	}
+
+	// do not delete recent certificates.
+	if isRecent(c.CreatedAt, a.gracePeriod) {
+		return "", false
+	}
+
+	return cluster, true
+}
@@ -0,0 +1,87 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/acm"
+)
+
+func TestCertificateShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		domainName  string
+		status      string
+		inUse       bool
+		createdAt   time.Time
+		expected    bool
+		description string
+	}{
+		{
+			description: "old unused wildcard certificate of ci cluster should be deleted",
+			domainName:  "*.ci-wip-a1b2c.k8s.gigantic.io",
+			createdAt:   time.Now().Add(-2 * time.Hour),
+			expected:    true,
+		},
+		{
+			description: "old wildcard certificate of ci cluster pending validation should be deleted",
+			domainName:  "*.ci-wip-a1b2c.k8s.gigantic.io",
+			status:      acm.CertificateStatusPendingValidation,
+			createdAt:   time.Now().Add(-2 * time.Hour),
+			expected:    true,
+		},
+		{
+			description: "recent wildcard certificate of ci cluster pending validation should not be deleted",
+			domainName:  "*.ci-wip-a1b2c.k8s.gigantic.io",
+			status:      acm.CertificateStatusPendingValidation,
+			createdAt:   time.Now().Add(-time.Hour),
+			expected:    false,
+		},
+		{
+			description: "recent wildcard certificate of ci cluster should not be deleted",
+			domainName:  "*.ci-wip-a1b2c.k8s.gigantic.io",
+			createdAt:   time.Now().Add(-time.Hour),
+			expected:    false,
+		},
+		{
+			description: "old wildcard certificate of ci cluster in use should not be deleted",
+			domainName:  "*.ci-wip-a1b2c.k8s.gigantic.io",
+			inUse:       true,
+			createdAt:   time.Now().Add(-2 * time.Hour),
+			expected:    false,
+		},
+		{
+			description: "old wildcard certificate of installation should not be deleted",
+			domainName:  "*.gauss.eu-central-1.aws.gigantic.io",
+			createdAt:   time.Now().Add(-2 * time.Hour),
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			c := &acm.CertificateSummary{
+				CertificateArn: aws.String("arn:aws:acm:eu-central-1:123456789012:certificate/a1b2c"),
+				CreatedAt:      aws.Time(tc.createdAt),
+				DomainName:     aws.String(tc.domainName),
+				InUse:          aws.Bool(tc.inUse),
+				Status:         aws.String(tc.status),
+			}
+
+			if tc.status == "" {
+				c.Status = aws.String(acm.CertificateStatusIssued)
+			}
+
+			_, actual := a.certificateShouldBeDeleted(c)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", tc.domainName, tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecr"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/chart"
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanCharts deletes the ephemeral Helm chart versions CI pushes to the
+// configured ECR repositories, like `1.2.3-pr42`, once they are older than
+// the chart retention. Artifacts which are also tagged with a released
+// version are kept.
+func (a *Cleaner) cleanCharts(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	for _, repository := range a.chartRepositories {
+		err := a.cleanRepositoryCharts(ctx, repository)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			a.logger.Log("level", "error", "message", fmt.Sprintf("failed cleaning charts of ecr repository %#q", repository), "stack", fmt.Sprintf("%#v", err))
+		}
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) cleanRepositoryCharts(ctx context.Context, repository string) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &ecr.DescribeImagesInput{
+		Filter: &ecr.DescribeImagesFilter{
+			TagStatus: aws.String(ecr.TagStatusTagged),
+		},
+		RepositoryName: aws.String(repository),
+	}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.ecrClient.DescribeImages(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, image := range o.ImageDetails {
+			if !a.chartShouldBeDeleted(image) {
+				continue
+			}
+
+			tags := aws.StringValueSlice(image.ImageTags)
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that chart versions %s in ecr repository %#q should be deleted", strings.Join(tags, ", "), repository))
+
+			res := run.Resource{
+				ID:        repository + "@" + *image.ImageDigest,
+				Type:      "AWS::ECR::Image",
+				CreatedAt: aws.TimeValue(image.ImagePushedAt),
+				Note:      strings.Join(tags, ", "),
+			}
+			digest := image.ImageDigest
+			err := a.run.DeleteResource(ctx, cleanerCharts, res, func() error {
+				i := &ecr.BatchDeleteImageInput{
+					ImageIds:       []*ecr.ImageIdentifier{{ImageDigest: digest}},
+					RepositoryName: aws.String(repository),
+				}
+
+				o, err := a.ecrClient.BatchDeleteImage(i)
+				if err != nil {
+					return microerror.Mask(err)
+				}
+
+				for _, f := range o.Failures {
+					if aws.StringValue(f.FailureCode) == ecr.ImageFailureCodeImageNotFound {
+						continue
+					}
+					return microerror.Maskf(executionFailedError, "%s: %s", aws.StringValue(f.FailureCode), aws.StringValue(f.FailureReason))
+				}
+
+				return nil
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting chart %#q", res.ID), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) chartShouldBeDeleted(image *ecr.ImageDetail) bool {
+	if image.ImageDigest == nil {
+		return false
+	}
+
+	if !chart.AllEphemeral(aws.StringValueSlice(image.ImageTags), a.chartSuffixes) {
+		return false
+	}
+
+	// do not delete charts within their retention.
+	if isRecent(image.ImagePushedAt, a.chartRetention) {
+		return false
+	}
+
+	return true
+}
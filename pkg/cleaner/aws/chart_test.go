@@ -0,0 +1,66 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecr"
+
+	"github.com/giantswarm/ci-cleaner/pkg/chart"
+)
+
+func TestChartShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		tags        []string
+		pushed      time.Time
+		expected    bool
+		description string
+	}{
+		{
+			description: "old pull request chart should be deleted",
+			tags:        []string{"1.2.3-pr42"},
+			pushed:      time.Now().Add(-8 * 24 * time.Hour),
+			expected:    true,
+		},
+		{
+			description: "recent pull request chart should not be deleted",
+			tags:        []string{"1.2.3-pr42"},
+			pushed:      time.Now().Add(-24 * time.Hour),
+			expected:    false,
+		},
+		{
+			description: "old pull request chart which was released should not be deleted",
+			tags:        []string{"1.2.3-pr42", "1.2.3"},
+			pushed:      time.Now().Add(-8 * 24 * time.Hour),
+			expected:    false,
+		},
+		{
+			description: "old released chart should not be deleted",
+			tags:        []string{"1.2.3"},
+			pushed:      time.Now().Add(-8 * 24 * time.Hour),
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		chartRetention: defaultChartRetention,
+		chartSuffixes:  chart.DefaultSuffixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			image := &ecr.ImageDetail{
+				ImageDigest:   aws.String("sha256:0123456789abcdef"),
+				ImagePushedAt: aws.Time(tc.pushed),
+				ImageTags:     aws.StringSlice(tc.tags),
+			}
+
+			actual := a.chartShouldBeDeleted(image)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %v should be deleted, want %t, got %t", tc.tags, tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudhsmv2"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanCloudHSMClusters finds CloudHSM clusters tagged by CI. Their HSMs are
+// billed hourly at a rate which makes a single leaked cluster more expensive
+// than anything else we run, which is why they are always reported as
+// expensive, but only deleted when configured explicitly. Deleting the HSMs
+// and the cluster afterwards is tracked by later runs.
+func (a *Cleaner) cleanCloudHSMClusters(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &cloudhsmv2.DescribeClustersInput{}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.cloudHSMClient.DescribeClusters(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, cluster := range o.Clusters {
+			if !a.cloudHSMClusterShouldBeDeleted(cluster) {
+				continue
+			}
+
+			res := run.Resource{
+				ID:          *cluster.ClusterId,
+				Type:        "AWS::CloudHSM::Cluster",
+				Tags:        cloudHSMTags(cluster.TagList),
+				CreatedAt:   aws.TimeValue(cluster.CreateTimestamp),
+				Cost:        fmt.Sprintf("CloudHSM cluster with %d %s HSMs, billed hourly until deleted", len(cluster.Hsms), aws.StringValue(cluster.HsmType)),
+				MonthlyCost: float64(len(cluster.Hsms)) * cloudHSMHourlyCost * hoursPerMonth,
+			}
+
+			if !a.deleteCloudHSMClusters {
+				a.logger.Log("level", "error", "message", fmt.Sprintf("found CI CloudHSM cluster %#q, which is billed hourly and has to be deleted manually", *cluster.ClusterId))
+				a.run.Report(ctx, cleanerCloudHSM, res)
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that cloudhsm cluster %#q should be deleted", *cluster.ClusterId))
+
+			cluster := cluster
+			start := func() (string, error) {
+				return a.deleteCloudHSMCluster(cluster)
+			}
+			err := a.run.DeleteResourceAsync(ctx, cleanerCloudHSM, res, start, a.pollCloudHSMCluster)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting cloudhsm cluster %#q", *cluster.ClusterId), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	err = a.run.PollPending(ctx, cleanerCloudHSM, "AWS::CloudHSM::Cluster", a.pollCloudHSMCluster)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// deleteCloudHSMCluster starts deleting the HSMs of the given cluster, or the
+// cluster itself once it has no HSMs left. The ID of the cluster is returned
+// to poll the deletion.
+func (a *Cleaner) deleteCloudHSMCluster(cluster *cloudhsmv2.Cluster) (string, error) {
+	if aws.StringValue(cluster.State) == cloudhsmv2.ClusterStateDeleteInProgress {
+		return *cluster.ClusterId, nil
+	}
+
+	var deleting bool
+	for _, hsm := range cluster.Hsms {
+		switch aws.StringValue(hsm.State) {
+		case cloudhsmv2.HsmStateDeleted:
+			continue
+		case cloudhsmv2.HsmStateDeleteInProgress:
+			deleting = true
+			continue
+		}
+
+		_, err := a.cloudHSMClient.DeleteHsm(&cloudhsmv2.DeleteHsmInput{ClusterId: cluster.ClusterId, HsmId: hsm.HsmId})
+		if err != nil {
+			return "", microerror.Mask(err)
+		}
+		deleting = true
+	}
+
+	if !deleting {
+		_, err := a.cloudHSMClient.DeleteCluster(&cloudhsmv2.DeleteClusterInput{ClusterId: cluster.ClusterId})
+		if err != nil {
+			return "", microerror.Mask(err)
+		}
+	}
+
+	return *cluster.ClusterId, nil
+}
+
+// pollCloudHSMCluster continues deleting the cluster with the given ID once
+// its HSMs are gone.
+func (a *Cleaner) pollCloudHSMCluster(ctx context.Context, id string) (bool, error) {
+	i := &cloudhsmv2.DescribeClustersInput{
+		Filters: map[string][]*string{
+			"clusterIds": {aws.String(id)},
+		},
+	}
+
+	o, err := a.cloudHSMClient.DescribeClusters(i)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	if len(o.Clusters) == 0 {
+		return true, nil
+	}
+
+	switch aws.StringValue(o.Clusters[0].State) {
+	case cloudhsmv2.ClusterStateDeleted:
+		return true, nil
+	case cloudhsmv2.ClusterStateDeleteInProgress:
+		return false, nil
+	}
+
+	_, err = a.deleteCloudHSMCluster(o.Clusters[0])
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	return false, nil
+}
+
+func (a *Cleaner) cloudHSMClusterShouldBeDeleted(cluster *cloudhsmv2.Cluster) bool {
+	if cluster.ClusterId == nil {
+		return false
+	}
+
+	if !a.isCITagged(cloudHSMTags(cluster.TagList)) {
+		return false
+	}
+
+	if aws.StringValue(cluster.State) == cloudhsmv2.ClusterStateDeleted {
+		return false
+	}
+
+	// do not delete recent clusters.
+	if isRecent(cluster.CreateTimestamp, a.gracePeriod) {
+		return false
+	}
+
+	return true
+}
+
+func cloudHSMTags(cloudHSMTags []*cloudhsmv2.Tag) map[string]string {
+	if len(cloudHSMTags) == 0 {
+		return nil
+	}
+
+	tags := map[string]string{}
+	for _, t := range cloudHSMTags {
+		tags[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+	}
+
+	return tags
+}
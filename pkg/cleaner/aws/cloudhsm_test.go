@@ -0,0 +1,69 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudhsmv2"
+)
+
+func TestCloudHSMClusterShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		cluster     *cloudhsmv2.Cluster
+		expected    bool
+		description string
+	}{
+		{
+			description: "old ci cluster should be deleted",
+			cluster:     newCloudHSMCluster(clusterTag, "ci-a1b2c", cloudhsmv2.ClusterStateActive, time.Now().Add(-2*time.Hour)),
+			expected:    true,
+		},
+		{
+			description: "deleting ci cluster should be polled",
+			cluster:     newCloudHSMCluster("Name", "e2e-a1b2c-hsm", cloudhsmv2.ClusterStateDeleteInProgress, time.Now().Add(-2*time.Hour)),
+			expected:    true,
+		},
+		{
+			description: "deleted ci cluster should not be deleted",
+			cluster:     newCloudHSMCluster(clusterTag, "ci-a1b2c", cloudhsmv2.ClusterStateDeleted, time.Now().Add(-2*time.Hour)),
+			expected:    false,
+		},
+		{
+			description: "recent ci cluster should not be deleted",
+			cluster:     newCloudHSMCluster(clusterTag, "ci-a1b2c", cloudhsmv2.ClusterStateActive, time.Now().Add(-time.Hour)),
+			expected:    false,
+		},
+		{
+			description: "old general cluster should not be deleted",
+			cluster:     newCloudHSMCluster("Name", "payments", cloudhsmv2.ClusterStateActive, time.Now().Add(-2*time.Hour)),
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := a.cloudHSMClusterShouldBeDeleted(tc.cluster)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.cluster.ClusterId, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func newCloudHSMCluster(tagKey, tagValue, state string, createTimestamp time.Time) *cloudhsmv2.Cluster {
+	return &cloudhsmv2.Cluster{
+		ClusterId:       aws.String("cluster-abcdefghijk"),
+		CreateTimestamp: aws.Time(createTimestamp),
+		State:           aws.String(state),
+		TagList: []*cloudhsmv2.Tag{
+			{Key: aws.String(tagKey), Value: aws.String(tagValue)},
+		},
+	}
+}
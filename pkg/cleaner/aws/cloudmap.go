@@ -0,0 +1,238 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/servicediscovery"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+const (
+	// serviceDiscoveryPrincipal is the service principal of the private
+	// hosted zones Cloud Map manages for DNS namespaces.
+	serviceDiscoveryPrincipal = "servicediscovery.amazonaws.com"
+)
+
+// cleanCloudMap deletes the Cloud Map namespaces service discovery tests
+// leak, after deregistering all instances and deleting all services of the
+// namespace. Deleting a namespace is an operation tracked by later runs.
+// Private hosted zones of DNS namespaces which are left behind once their
+// namespace is gone are deleted afterwards.
+func (a *Cleaner) cleanCloudMap(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	// zones are the IDs of the hosted zones of all existing namespaces.
+	zones := map[string]bool{}
+
+	var nextToken *string
+	err := paginate(ctx, &nextToken, func() (*string, error) {
+		o, err := a.serviceDiscoveryClient.ListNamespaces(&servicediscovery.ListNamespacesInput{NextToken: nextToken})
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, ns := range o.Namespaces {
+			if ns.Properties != nil && ns.Properties.DnsProperties != nil && ns.Properties.DnsProperties.HostedZoneId != nil {
+				zones[*ns.Properties.DnsProperties.HostedZoneId] = true
+			}
+
+			if !a.namespaceShouldBeDeleted(ns) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that cloud map namespace %#q should be deleted", *ns.Name))
+
+			res := run.Resource{
+				ID:        *ns.Id,
+				Type:      "AWS::ServiceDiscovery::Namespace",
+				CreatedAt: aws.TimeValue(ns.CreateDate),
+			}
+			start := func() (string, error) {
+				return a.deleteNamespace(ctx, ns.Id)
+			}
+			err := a.run.DeleteResourceAsync(ctx, cleanerCloudMap, res, start, a.pollServiceDiscoveryOperation)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting cloud map namespace %#q", *ns.Name), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	var marker *string
+	err = paginate(ctx, &marker, func() (*string, error) {
+		o, err := a.route53Client.ListHostedZones(&route53.ListHostedZonesInput{Marker: marker})
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, zone := range o.HostedZones {
+			if !a.namespaceZoneShouldBeDeleted(zone, zones) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that hosted zone %#q of a deleted cloud map namespace should be deleted", *zone.Name))
+
+			res := run.Resource{
+				ID:   *zone.Id,
+				Type: "AWS::Route53::HostedZone",
+			}
+			err := a.run.DeleteResource(ctx, cleanerCloudMap, res, func() error {
+				return a.deleteHostedZone(zone)
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting hosted zone %#q", *zone.Name), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		if !aws.BoolValue(o.IsTruncated) {
+			return nil, nil
+		}
+		return o.NextMarker, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	err = a.run.PollPending(ctx, cleanerCloudMap, "AWS::ServiceDiscovery::Namespace", a.pollServiceDiscoveryOperation)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// deleteNamespace deregisters all instances and deletes all services of the
+// given namespace and starts deleting the namespace. The ID of the operation
+// deleting the namespace is returned.
+func (a *Cleaner) deleteNamespace(ctx context.Context, id *string) (string, error) {
+	var nextToken *string
+	err := paginate(ctx, &nextToken, func() (*string, error) {
+		i := &servicediscovery.ListServicesInput{
+			Filters: []*servicediscovery.ServiceFilter{
+				{
+					Name:   aws.String(servicediscovery.ServiceFilterNameNamespaceId),
+					Values: []*string{id},
+				},
+			},
+			NextToken: nextToken,
+		}
+
+		o, err := a.serviceDiscoveryClient.ListServices(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, s := range o.Services {
+			err := a.deleteService(ctx, s.Id)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	o, err := a.serviceDiscoveryClient.DeleteNamespace(&servicediscovery.DeleteNamespaceInput{Id: id})
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return aws.StringValue(o.OperationId), nil
+}
+
+// deleteService deregisters all instances of the given service and deletes
+// it. Deregistering is asynchronous, so deleting the service fails until all
+// instances are gone and is retried by the escalation.
+func (a *Cleaner) deleteService(ctx context.Context, id *string) error {
+	var nextToken *string
+	err := paginate(ctx, &nextToken, func() (*string, error) {
+		o, err := a.serviceDiscoveryClient.ListInstances(&servicediscovery.ListInstancesInput{ServiceId: id, NextToken: nextToken})
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, instance := range o.Instances {
+			_, err := a.serviceDiscoveryClient.DeregisterInstance(&servicediscovery.DeregisterInstanceInput{ServiceId: id, InstanceId: instance.Id})
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	_, err = a.serviceDiscoveryClient.DeleteService(&servicediscovery.DeleteServiceInput{Id: id})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (a *Cleaner) pollServiceDiscoveryOperation(ctx context.Context, id string) (bool, error) {
+	o, err := a.serviceDiscoveryClient.GetOperation(&servicediscovery.GetOperationInput{OperationId: aws.String(id)})
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	switch aws.StringValue(o.Operation.Status) {
+	case servicediscovery.OperationStatusSuccess:
+		return true, nil
+	case servicediscovery.OperationStatusFail:
+		return false, microerror.Maskf(executionFailedError, "%s: %s", aws.StringValue(o.Operation.ErrorCode), aws.StringValue(o.Operation.ErrorMessage))
+	default:
+		return false, nil
+	}
+}
+
+func (a *Cleaner) namespaceShouldBeDeleted(ns *servicediscovery.NamespaceSummary) bool {
+	if ns.Id == nil || ns.Name == nil || !a.hasCIPrefix(*ns.Name) {
+		return false
+	}
+
+	// do not delete recent namespaces.
+	if isRecent(ns.CreateDate, a.gracePeriod) {
+		return false
+	}
+
+	return true
+}
+
+// namespaceZoneShouldBeDeleted checks if the given hosted zone was created by
+// Cloud Map for a CI namespace which does not exist anymore. zones are the
+// IDs of the hosted zones of all existing namespaces.
+func (a *Cleaner) namespaceZoneShouldBeDeleted(zone *route53.HostedZone, zones map[string]bool) bool {
+	if zone.Id == nil || zone.Name == nil || !a.hasCIPrefix(*zone.Name) {
+		return false
+	}
+
+	if zone.LinkedService == nil || aws.StringValue(zone.LinkedService.ServicePrincipal) != serviceDiscoveryPrincipal {
+		return false
+	}
+
+	return !zones[strings.TrimPrefix(*zone.Id, "/hostedzone/")]
+}
@@ -0,0 +1,119 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/servicediscovery"
+)
+
+func TestNamespaceShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		namespace   *servicediscovery.NamespaceSummary
+		expected    bool
+		description string
+	}{
+		{
+			description: "old ci namespace should be deleted",
+			namespace:   newNamespace("ci-wip-a1b2c.local", time.Now().Add(-2*time.Hour)),
+			expected:    true,
+		},
+		{
+			description: "recent ci namespace should not be deleted",
+			namespace:   newNamespace("e2e-a1b2c.local", time.Now().Add(-10*time.Minute)),
+			expected:    false,
+		},
+		{
+			description: "old general namespace should not be deleted",
+			namespace:   newNamespace("mesh.local", time.Now().Add(-2*time.Hour)),
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := a.namespaceShouldBeDeleted(tc.namespace)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.namespace.Name, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestNamespaceZoneShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		zone        *route53.HostedZone
+		expected    bool
+		description string
+	}{
+		{
+			description: "ci zone of deleted namespace should be deleted",
+			zone:        newHostedZone("Z0001", "ci-wip-a1b2c.local.", serviceDiscoveryPrincipal),
+			expected:    true,
+		},
+		{
+			description: "ci zone of existing namespace should not be deleted",
+			zone:        newHostedZone("Z0002", "ci-wip-d3e4f.local.", serviceDiscoveryPrincipal),
+			expected:    false,
+		},
+		{
+			description: "ci zone not managed by cloud map should not be deleted",
+			zone:        newHostedZone("Z0003", "ci-wip-a1b2c.local.", ""),
+			expected:    false,
+		},
+		{
+			description: "general zone of deleted namespace should not be deleted",
+			zone:        newHostedZone("Z0004", "mesh.local.", serviceDiscoveryPrincipal),
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	zones := map[string]bool{
+		"Z0002": true,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := a.namespaceZoneShouldBeDeleted(tc.zone, zones)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.zone.Name, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func newNamespace(name string, createDate time.Time) *servicediscovery.NamespaceSummary {
+	return &servicediscovery.NamespaceSummary{
+		CreateDate: aws.Time(createDate),
+		Id:         aws.String("ns-0123456789abcdef"),
+		Name:       aws.String(name),
+	}
+}
+
+func newHostedZone(id, name, principal string) *route53.HostedZone {
+	zone := &route53.HostedZone{
+		Id:   aws.String("/hostedzone/" + id),
+		Name: aws.String(name),
+	}
+	if principal != "" {
+		zone.LinkedService = &route53.LinkedService{
+			ServicePrincipal: aws.String(principal),
+		}
+	}
+
+	return zone
+}
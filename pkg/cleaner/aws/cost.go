@@ -0,0 +1,45 @@
+package aws
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// hoursPerMonth is the number of hours AWS bills per month for resources
+// billed hourly.
+const hoursPerMonth = 730
+
+// The hourly and monthly list prices in USD the monthly cost of resources is
+// estimated with, see run.Resource.MonthlyCost. They are the us-east-1 prices
+// and only meant to put a rough figure on what a run deletes, regional price
+// differences do not matter for that.
+const (
+	cloudHSMHourlyCost                 = 1.45
+	eksClusterHourlyCost               = 0.10
+	kmsKeyMonthlyCost                  = 1.00
+	natGatewayHourlyCost               = 0.045
+	networkFirewallHourlyCost          = 0.395
+	resolverENIHourlyCost              = 0.125
+	secretMonthlyCost                  = 0.40
+	ssmAdvancedParameterMonthlyCost    = 0.05
+	trafficMirrorHourlyCost            = 0.015
+	transitGatewayAttachmentHourlyCost = 0.05
+)
+
+// volumeGiBMonthlyCost is the list price in USD per GiB-month of the EBS
+// volume types.
+var volumeGiBMonthlyCost = map[string]float64{
+	ec2.VolumeTypeGp2:      0.10,
+	ec2.VolumeTypeGp3:      0.08,
+	ec2.VolumeTypeIo1:      0.125,
+	ec2.VolumeTypeIo2:      0.125,
+	ec2.VolumeTypeSc1:      0.015,
+	ec2.VolumeTypeSt1:      0.045,
+	ec2.VolumeTypeStandard: 0.05,
+}
+
+// volumeMonthlyCost estimates the monthly storage cost of the given volume.
+// It is zero for unknown volume types.
+func volumeMonthlyCost(volume *ec2.Volume) float64 {
+	return float64(aws.Int64Value(volume.Size)) * volumeGiBMonthlyCost[aws.StringValue(volume.VolumeType)]
+}
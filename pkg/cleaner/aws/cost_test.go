@@ -0,0 +1,51 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func TestVolumeMonthlyCost(t *testing.T) {
+	tcs := []struct {
+		size        int64
+		volumeType  string
+		expected    float64
+		description string
+	}{
+		{
+			description: "gp3 volume",
+			size:        100,
+			volumeType:  ec2.VolumeTypeGp3,
+			expected:    8,
+		},
+		{
+			description: "sc1 volume",
+			size:        1000,
+			volumeType:  ec2.VolumeTypeSc1,
+			expected:    15,
+		},
+		{
+			description: "unknown volume type",
+			size:        100,
+			volumeType:  "gp9",
+			expected:    0,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			volume := &ec2.Volume{
+				Size:       aws.Int64(tc.size),
+				VolumeType: aws.String(tc.volumeType),
+			}
+
+			actual := volumeMonthlyCost(volume)
+
+			if actual != tc.expected {
+				t.Errorf("want %.2f, got %.2f", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/domain"
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanDelegationRecords deletes the NS records in our root zones delegating
+// to hosted zones of CI clusters which do not exist anymore, as well as the
+// AAAA records dual-stack e2e tests create for their clusters next to them. A
+// record is considered stale once the API hostname of the cluster does not
+// resolve anymore.
+func (a *Cleaner) cleanDelegationRecords(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	var zones []*route53.HostedZone
+	{
+		i := &route53.ListHostedZonesInput{}
+		err := paginate(ctx, &i.Marker, func() (*string, error) {
+			o, err := a.route53Client.ListHostedZones(i)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			zones = append(zones, o.HostedZones...)
+
+			if !aws.BoolValue(o.IsTruncated) {
+				return nil, nil
+			}
+			return o.NextMarker, nil
+		})
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			return errors
+		}
+	}
+
+	// existing holds the names of all hosted zones, so that delegations to
+	// zones which still exist are never deleted.
+	existing := map[string]bool{}
+	for _, zone := range zones {
+		existing[aws.StringValue(zone.Name)] = true
+	}
+
+	for _, zone := range zones {
+		if !a.isRootZone(zone) {
+			continue
+		}
+
+		err := a.cleanZoneDelegationRecords(ctx, zone, existing)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			a.logger.Log("level", "error", "message", fmt.Sprintf("failed cleaning delegation records of hosted zone %#q", *zone.Name), "stack", fmt.Sprintf("%#v", err))
+		}
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) cleanZoneDelegationRecords(ctx context.Context, zone *route53.HostedZone, existing map[string]bool) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &route53.ListResourceRecordSetsInput{
+		HostedZoneId: zone.Id,
+	}
+	for {
+		o, err := a.route53Client.ListResourceRecordSets(i)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			return errors
+		}
+
+		for _, r := range o.ResourceRecordSets {
+			if existing[aws.StringValue(r.Name)] {
+				continue
+			}
+
+			seen, err := a.run.FirstSeen(cleanerDelegationRecords, delegationRecordID(zone, r))
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				continue
+			}
+
+			if !a.delegationRecordShouldBeDeleted(zone, r, seen) {
+				continue
+			}
+
+			resolves, err := domain.APIResolves(*r.Name)
+			if err != nil {
+				a.logger.Log("level", "warning", "message", fmt.Sprintf("failed resolving API hostname of delegation %#q", *r.Name), "stack", fmt.Sprintf("%#v", err))
+				continue
+			}
+			if resolves {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that delegation record %#q should be deleted", *r.Name))
+
+			res := run.Resource{
+				ID:     delegationRecordID(zone, r),
+				Type:   "AWS::Route53::RecordSet",
+				Reason: run.ReasonDNSStale,
+			}
+			r := r
+			err = a.run.DeleteResource(ctx, cleanerDelegationRecords, res, func() error {
+				return a.deleteRecordSet(zone.Id, r)
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting delegation record %#q", *r.Name), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		if !aws.BoolValue(o.IsTruncated) {
+			break
+		}
+		i.StartRecordIdentifier = o.NextRecordIdentifier
+		i.StartRecordName = o.NextRecordName
+		i.StartRecordType = o.NextRecordType
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) deleteRecordSet(zoneID *string, r *route53.ResourceRecordSet) error {
+	i := &route53.ChangeResourceRecordSetsInput{
+		ChangeBatch: &route53.ChangeBatch{
+			Changes: []*route53.Change{
+				{
+					Action:            aws.String(route53.ChangeActionDelete),
+					ResourceRecordSet: r,
+				},
+			},
+		},
+		HostedZoneId: zoneID,
+	}
+
+	_, err := a.route53Client.ChangeResourceRecordSets(i)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// isRootZone checks if the given hosted zone is one of our public zones CI
+// clusters delegate their own zones from.
+func (a *Cleaner) isRootZone(zone *route53.HostedZone) bool {
+	name := aws.StringValue(zone.Name)
+	if name != ciZoneDomain && !strings.HasSuffix(name, "."+ciZoneDomain) {
+		return false
+	}
+
+	if a.hasCIPrefix(name) || zone.LinkedService != nil {
+		return false
+	}
+
+	return zone.Config == nil || !aws.BoolValue(zone.Config.PrivateZone)
+}
+
+func (a *Cleaner) delegationRecordShouldBeDeleted(zone *route53.HostedZone, r *route53.ResourceRecordSet, seen time.Time) bool {
+	switch aws.StringValue(r.Type) {
+	case route53.RRTypeNs, route53.RRTypeAaaa:
+	default:
+		return false
+	}
+	if isZoneApexRecord(zone, r) {
+		return false
+	}
+
+	if !a.hasCIPrefix(aws.StringValue(r.Name)) {
+		return false
+	}
+
+	// do not delete recent delegations.
+	if time.Since(seen) < a.gracePeriod {
+		return false
+	}
+
+	return true
+}
+
+// delegationRecordID identifies the given record of our root zone. NS records
+// are identified by their name only, as they were before AAAA records were
+// cleaned up as well.
+func delegationRecordID(zone *route53.HostedZone, r *route53.ResourceRecordSet) string {
+	id := *zone.Id + "/" + *r.Name
+	if aws.StringValue(r.Type) != route53.RRTypeNs {
+		id += "/" + aws.StringValue(r.Type)
+	}
+
+	return id
+}
@@ -0,0 +1,124 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+func TestDelegationRecordShouldBeDeleted(t *testing.T) {
+	zone := &route53.HostedZone{Id: aws.String("/hostedzone/Z1"), Name: aws.String("k8s.gigantic.io.")}
+
+	tcs := []struct {
+		name        string
+		recordType  string
+		seen        time.Time
+		expected    bool
+		description string
+	}{
+		{
+			description: "old delegation to ci cluster zone should be deleted",
+			name:        "ci-wip-a1b2c.k8s.gigantic.io.",
+			recordType:  route53.RRTypeNs,
+			seen:        time.Now().Add(-2 * time.Hour),
+			expected:    true,
+		},
+		{
+			description: "recently found delegation to ci cluster zone should not be deleted",
+			name:        "ci-wip-a1b2c.k8s.gigantic.io.",
+			recordType:  route53.RRTypeNs,
+			seen:        time.Now().Add(-time.Hour),
+			expected:    false,
+		},
+		{
+			description: "old ci a record should not be deleted",
+			name:        "ci-wip-a1b2c.k8s.gigantic.io.",
+			recordType:  route53.RRTypeA,
+			seen:        time.Now().Add(-2 * time.Hour),
+			expected:    false,
+		},
+		{
+			description: "old ci aaaa record of dual-stack cluster should be deleted",
+			name:        "ci-wip-a1b2c.k8s.gigantic.io.",
+			recordType:  route53.RRTypeAaaa,
+			seen:        time.Now().Add(-2 * time.Hour),
+			expected:    true,
+		},
+		{
+			description: "old delegation to installation zone should not be deleted",
+			name:        "gauss.k8s.gigantic.io.",
+			recordType:  route53.RRTypeNs,
+			seen:        time.Now().Add(-2 * time.Hour),
+			expected:    false,
+		},
+		{
+			description: "ns record of the root zone should not be deleted",
+			name:        "k8s.gigantic.io.",
+			recordType:  route53.RRTypeNs,
+			seen:        time.Now().Add(-2 * time.Hour),
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			r := &route53.ResourceRecordSet{Name: aws.String(tc.name), Type: aws.String(tc.recordType)}
+
+			actual := a.delegationRecordShouldBeDeleted(zone, r, tc.seen)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", tc.name, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestIsRootZone(t *testing.T) {
+	tcs := []struct {
+		zone        *route53.HostedZone
+		expected    bool
+		description string
+	}{
+		{
+			description: "public gigantic.io zone is a root zone",
+			zone:        &route53.HostedZone{Name: aws.String("k8s.gigantic.io.")},
+			expected:    true,
+		},
+		{
+			description: "private gigantic.io zone is not a root zone",
+			zone:        &route53.HostedZone{Name: aws.String("k8s.gigantic.io."), Config: &route53.HostedZoneConfig{PrivateZone: aws.Bool(true)}},
+			expected:    false,
+		},
+		{
+			description: "ci cluster zone is not a root zone",
+			zone:        &route53.HostedZone{Name: aws.String("ci-wip-a1b2c.k8s.gigantic.io.")},
+			expected:    false,
+		},
+		{
+			description: "zone of another domain is not a root zone",
+			zone:        &route53.HostedZone{Name: aws.String("example.com.")},
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		prefixes: defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := a.isRootZone(tc.zone)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q is a root zone, want %t, got %t", *tc.zone.Name, tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/guardduty"
+	"github.com/aws/aws-sdk-go/service/inspector"
+	"github.com/aws/aws-sdk-go/service/macie2"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanDetectors deletes the GuardDuty detectors, Inspector Classic
+// assessment targets and Macie sessions security e2e tests enable per run.
+// Detectors and sessions managed by an organization administrator are left
+// alone, as member accounts cannot and must not disable them.
+func (a *Cleaner) cleanDetectors(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	err := a.cleanGuardDutyDetectors(ctx)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+	}
+
+	err = a.cleanInspectorAssessmentTargets(ctx)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+	}
+
+	err = a.cleanMacieSession(ctx)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) cleanGuardDutyDetectors(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &guardduty.ListDetectorsInput{}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.guardDutyClient.ListDetectors(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, id := range o.DetectorIds {
+			detector, err := a.guardDutyClient.GetDetector(&guardduty.GetDetectorInput{DetectorId: id})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				continue
+			}
+
+			if !a.guardDutyDetectorShouldBeDeleted(detector) {
+				continue
+			}
+
+			managed, err := a.isGuardDutyOrganizationManaged(id)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				continue
+			}
+			if managed {
+				a.logger.Log("level", "debug", "message", fmt.Sprintf("not deleting guardduty detector %#q as it is managed by an organization", *id))
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that guardduty detector %#q should be deleted", *id))
+
+			createdAt, _ := time.Parse(time.RFC3339, aws.StringValue(detector.CreatedAt))
+			res := run.Resource{
+				ID:        *id,
+				Type:      "AWS::GuardDuty::Detector",
+				Tags:      aws.StringValueMap(detector.Tags),
+				CreatedAt: createdAt,
+			}
+			err = a.run.DeleteResource(ctx, cleanerDetectors, res, func() error {
+				_, err := a.guardDutyClient.DeleteDetector(&guardduty.DeleteDetectorInput{DetectorId: id})
+				if err != nil {
+					return microerror.Mask(err)
+				}
+
+				return nil
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting guardduty detector %#q", *id), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) isGuardDutyOrganizationManaged(id *string) (bool, error) {
+	o, err := a.guardDutyClient.GetAdministratorAccount(&guardduty.GetAdministratorAccountInput{DetectorId: id})
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	return o.Administrator != nil && o.Administrator.AccountId != nil, nil
+}
+
+func (a *Cleaner) guardDutyDetectorShouldBeDeleted(detector *guardduty.GetDetectorOutput) bool {
+	if !a.isCITagged(aws.StringValueMap(detector.Tags)) {
+		return false
+	}
+
+	// do not delete recent detectors. Detectors with an unknown creation
+	// time are never recent.
+	createdAt, err := time.Parse(time.RFC3339, aws.StringValue(detector.CreatedAt))
+	if err == nil && time.Since(createdAt) < a.gracePeriod {
+		return false
+	}
+
+	return true
+}
+
+// cleanInspectorAssessmentTargets deletes CI assessment targets of Inspector
+// Classic. Assessment targets cannot be tagged, which is why they are matched
+// by name.
+func (a *Cleaner) cleanInspectorAssessmentTargets(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &inspector.ListAssessmentTargetsInput{}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.inspectorClient.ListAssessmentTargets(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		if len(o.AssessmentTargetArns) != 0 {
+			d, err := a.inspectorClient.DescribeAssessmentTargets(&inspector.DescribeAssessmentTargetsInput{AssessmentTargetArns: o.AssessmentTargetArns})
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			for _, target := range d.AssessmentTargets {
+				if !a.inspectorAssessmentTargetShouldBeDeleted(target) {
+					continue
+				}
+
+				a.logger.Log("level", "info", "message", fmt.Sprintf("found that inspector assessment target %#q should be deleted", *target.Name))
+
+				res := run.Resource{
+					ID:        *target.Arn,
+					Type:      "AWS::Inspector::AssessmentTarget",
+					CreatedAt: aws.TimeValue(target.CreatedAt),
+				}
+				err := a.run.DeleteResource(ctx, cleanerDetectors, res, func() error {
+					_, err := a.inspectorClient.DeleteAssessmentTarget(&inspector.DeleteAssessmentTargetInput{AssessmentTargetArn: target.Arn})
+					if err != nil {
+						return microerror.Mask(err)
+					}
+
+					return nil
+				})
+				if err != nil {
+					errors.Append(microerror.Mask(err))
+					a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting inspector assessment target %#q", *target.Name), "stack", fmt.Sprintf("%#v", err))
+				}
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) inspectorAssessmentTargetShouldBeDeleted(target *inspector.AssessmentTarget) bool {
+	if target.Arn == nil || !a.hasCIPrefix(aws.StringValue(target.Name)) {
+		return false
+	}
+
+	// do not delete recent assessment targets.
+	if isRecent(target.CreatedAt, a.gracePeriod) {
+		return false
+	}
+
+	return true
+}
+
+// cleanMacieSession disables Macie, if configured. Macie sessions cannot be
+// tagged, so they are only disabled in accounts explicitly configured to be
+// used by CI only, and never when an organization manages Macie.
+func (a *Cleaner) cleanMacieSession(ctx context.Context) error {
+	if !a.disableMacie {
+		return nil
+	}
+
+	session, err := a.macieClient.GetMacieSession(&macie2.GetMacieSessionInput{})
+	// Macie answers with access denied when it is not enabled.
+	if isAWSError(err, macie2.ErrCodeAccessDeniedException) {
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	// do not disable recent sessions.
+	if isRecent(session.CreatedAt, a.gracePeriod) {
+		return nil
+	}
+
+	o, err := a.macieClient.GetAdministratorAccount(&macie2.GetAdministratorAccountInput{})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	if o.Administrator != nil && o.Administrator.AccountId != nil {
+		a.logger.Log("level", "debug", "message", fmt.Sprintf("not disabling macie as it is managed by account %#q", *o.Administrator.AccountId))
+		return nil
+	}
+
+	a.logger.Log("level", "info", "message", "found that macie should be disabled")
+
+	res := run.Resource{
+		ID:        "macie-session",
+		Type:      "AWS::Macie::Session",
+		CreatedAt: aws.TimeValue(session.CreatedAt),
+	}
+	err = a.run.DeleteResource(ctx, cleanerDetectors, res, func() error {
+		_, err := a.macieClient.DisableMacie(&macie2.DisableMacieInput{})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
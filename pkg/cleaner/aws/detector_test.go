@@ -0,0 +1,115 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/guardduty"
+	"github.com/aws/aws-sdk-go/service/inspector"
+)
+
+func TestGuardDutyDetectorShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		detector    *guardduty.GetDetectorOutput
+		expected    bool
+		description string
+	}{
+		{
+			description: "old detector of ci cluster should be deleted",
+			detector:    newGuardDutyDetector("ci-a1b2c", time.Now().Add(-2*time.Hour).Format(time.RFC3339)),
+			expected:    true,
+		},
+		{
+			description: "detector of ci cluster with unknown creation time should be deleted",
+			detector:    newGuardDutyDetector("ci-a1b2c", ""),
+			expected:    true,
+		},
+		{
+			description: "recent detector of ci cluster should not be deleted",
+			detector:    newGuardDutyDetector("ci-a1b2c", time.Now().Add(-time.Hour).Format(time.RFC3339)),
+			expected:    false,
+		},
+		{
+			description: "old general detector should not be deleted",
+			detector:    newGuardDutyDetector("gauss", time.Now().Add(-2*time.Hour).Format(time.RFC3339)),
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := a.guardDutyDetectorShouldBeDeleted(tc.detector)
+
+			if actual != tc.expected {
+				t.Errorf("checking if detector should be deleted, want %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestInspectorAssessmentTargetShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		target      *inspector.AssessmentTarget
+		expected    bool
+		description string
+	}{
+		{
+			description: "old ci assessment target should be deleted",
+			target:      newInspectorAssessmentTarget("e2e-a1b2c-nodes", time.Now().Add(-2*time.Hour)),
+			expected:    true,
+		},
+		{
+			description: "recent ci assessment target should not be deleted",
+			target:      newInspectorAssessmentTarget("e2e-a1b2c-nodes", time.Now().Add(-time.Hour)),
+			expected:    false,
+		},
+		{
+			description: "old general assessment target should not be deleted",
+			target:      newInspectorAssessmentTarget("bastions", time.Now().Add(-2*time.Hour)),
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := a.inspectorAssessmentTargetShouldBeDeleted(tc.target)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.target.Name, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func newGuardDutyDetector(cluster string, createdAt string) *guardduty.GetDetectorOutput {
+	d := &guardduty.GetDetectorOutput{
+		Status: aws.String(guardduty.DetectorStatusEnabled),
+		Tags: map[string]*string{
+			clusterTag: aws.String(cluster),
+		},
+	}
+	if createdAt != "" {
+		d.CreatedAt = aws.String(createdAt)
+	}
+
+	return d
+}
+
+func newInspectorAssessmentTarget(name string, createdAt time.Time) *inspector.AssessmentTarget {
+	return &inspector.AssessmentTarget{
+		Arn:       aws.String("arn:aws:inspector:eu-west-1:123456789012:target/0-a1b2c3d4"),
+		CreatedAt: aws.Time(createdAt),
+		Name:      aws.String(name),
+	}
+}
@@ -0,0 +1,168 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// terraformLockInfo is the part of the lock info terraform writes into the
+// `Info` attribute of the lock item of a state we care about.
+type terraformLockInfo struct {
+	Created time.Time `json:"Created"`
+}
+
+// cleanDynamoDBTables deletes the per-run DynamoDB tables e2e terraform runs
+// lock their state with. Tables holding a lock taken within the grace period
+// belong to a run which is still applying or destroying and are kept.
+func (a *Cleaner) cleanDynamoDBTables(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &dynamodb.ListTablesInput{}
+	err := paginate(ctx, &i.ExclusiveStartTableName, func() (*string, error) {
+		o, err := a.dynamoDBClient.ListTables(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, name := range o.TableNames {
+			if !a.hasCIPrefix(aws.StringValue(name)) {
+				continue
+			}
+
+			err := a.cleanDynamoDBTable(ctx, name)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting dynamodb table %#q", *name), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.LastEvaluatedTableName, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) cleanDynamoDBTable(ctx context.Context, name *string) error {
+	o, err := a.dynamoDBClient.DescribeTable(&dynamodb.DescribeTableInput{TableName: name})
+	if isAWSError(err, dynamodb.ErrCodeResourceNotFoundException) {
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	table := o.Table
+	if !a.dynamoDBTableShouldBeDeleted(table) {
+		return nil
+	}
+
+	locked, err := a.hasActiveTerraformLock(name)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	if locked {
+		a.logger.Log("level", "debug", "message", fmt.Sprintf("not deleting dynamodb table %#q as it holds an active lock", *name))
+		return nil
+	}
+
+	a.logger.Log("level", "info", "message", fmt.Sprintf("found that dynamodb table %#q should be deleted", *name))
+
+	res := run.Resource{
+		ID:        *name,
+		Type:      "AWS::DynamoDB::Table",
+		CreatedAt: aws.TimeValue(table.CreationDateTime),
+	}
+	err = a.run.DeleteResource(ctx, cleanerDynamoDBTables, res, func() error {
+		_, err := a.dynamoDBClient.DeleteTable(&dynamodb.DeleteTableInput{TableName: name})
+		if isAWSError(err, dynamodb.ErrCodeResourceNotFoundException) {
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// hasActiveTerraformLock checks if the table with the given name holds a
+// terraform lock taken within the grace period. Lock tables are tiny, so
+// scanning them is cheap.
+func (a *Cleaner) hasActiveTerraformLock(name *string) (bool, error) {
+	i := &dynamodb.ScanInput{
+		ExpressionAttributeNames: map[string]*string{"#info": aws.String("Info")},
+		ProjectionExpression:     aws.String("#info"),
+		TableName:                name,
+	}
+	for {
+		o, err := a.dynamoDBClient.Scan(i)
+		if err != nil {
+			return false, microerror.Mask(err)
+		}
+
+		for _, item := range o.Items {
+			if a.isActiveTerraformLock(item) {
+				return true, nil
+			}
+		}
+
+		if len(o.LastEvaluatedKey) == 0 {
+			break
+		}
+		i.ExclusiveStartKey = o.LastEvaluatedKey
+	}
+
+	return false, nil
+}
+
+// isActiveTerraformLock checks if the given item is a terraform lock taken
+// within the grace period. Items of other shapes, like the digests terraform
+// stores next to the locks, are no locks. Locks whose creation time cannot be
+// read are considered active to be on the safe side.
+func (a *Cleaner) isActiveTerraformLock(item map[string]*dynamodb.AttributeValue) bool {
+	v, ok := item["Info"]
+	if !ok || v.S == nil {
+		return false
+	}
+
+	var info terraformLockInfo
+	err := json.Unmarshal([]byte(*v.S), &info)
+	if err != nil {
+		return true
+	}
+
+	return time.Since(info.Created) < a.gracePeriod
+}
+
+func (a *Cleaner) dynamoDBTableShouldBeDeleted(table *dynamodb.TableDescription) bool {
+	if table.TableName == nil || !a.hasCIPrefix(*table.TableName) {
+		return false
+	}
+
+	if aws.StringValue(table.TableStatus) == dynamodb.TableStatusDeleting {
+		return false
+	}
+
+	// do not delete recent tables.
+	if isRecent(table.CreationDateTime, a.gracePeriod) {
+		return false
+	}
+
+	return true
+}
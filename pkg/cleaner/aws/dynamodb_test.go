@@ -0,0 +1,118 @@
+package aws
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestDynamoDBTableShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		name        string
+		status      string
+		created     time.Time
+		expected    bool
+		description string
+	}{
+		{
+			description: "old e2e lock table should be deleted",
+			name:        "e2e-a1b2c-tf-lock",
+			status:      dynamodb.TableStatusActive,
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    true,
+		},
+		{
+			description: "recent e2e lock table should not be deleted",
+			name:        "e2e-a1b2c-tf-lock",
+			status:      dynamodb.TableStatusActive,
+			created:     time.Now().Add(-time.Hour),
+			expected:    false,
+		},
+		{
+			description: "deleting e2e lock table should not be deleted again",
+			name:        "e2e-a1b2c-tf-lock",
+			status:      dynamodb.TableStatusDeleting,
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    false,
+		},
+		{
+			description: "old general table should not be deleted",
+			name:        "terraform-lock",
+			status:      dynamodb.TableStatusActive,
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			table := &dynamodb.TableDescription{
+				CreationDateTime: aws.Time(tc.created),
+				TableName:        aws.String(tc.name),
+				TableStatus:      aws.String(tc.status),
+			}
+
+			actual := a.dynamoDBTableShouldBeDeleted(table)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", tc.name, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestIsActiveTerraformLock(t *testing.T) {
+	lock := func(created time.Time) map[string]*dynamodb.AttributeValue {
+		info := fmt.Sprintf(`{"ID":"a1b2c","Operation":"OperationTypeApply","Created":%q}`, created.Format(time.RFC3339Nano))
+		return map[string]*dynamodb.AttributeValue{"Info": {S: aws.String(info)}}
+	}
+
+	tcs := []struct {
+		item        map[string]*dynamodb.AttributeValue
+		expected    bool
+		description string
+	}{
+		{
+			description: "recent lock is active",
+			item:        lock(time.Now().Add(-time.Minute)),
+			expected:    true,
+		},
+		{
+			description: "old lock is stale",
+			item:        lock(time.Now().Add(-2 * time.Hour)),
+			expected:    false,
+		},
+		{
+			description: "digest is no lock",
+			item:        map[string]*dynamodb.AttributeValue{},
+			expected:    false,
+		},
+		{
+			description: "malformed lock is active",
+			item:        map[string]*dynamodb.AttributeValue{"Info": {S: aws.String("{")}},
+			expected:    true,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := a.isActiveTerraformLock(tc.item)
+
+			if actual != tc.expected {
+				t.Errorf("want %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecr"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanECRRepositories deletes the per-run ECR repositories CI jobs push
+// their throwaway images to. Repositories are deleted forcefully, so that
+// the images they still hold do not block deleting them.
+func (a *Cleaner) cleanECRRepositories(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &ecr.DescribeRepositoriesInput{}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.ecrClient.DescribeRepositories(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, repository := range o.Repositories {
+			if !a.ecrRepositoryShouldBeDeleted(repository) {
+				continue
+			}
+
+			err := a.deleteECRRepository(ctx, repository)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting ecr repository %#q", *repository.RepositoryName), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) deleteECRRepository(ctx context.Context, repository *ecr.Repository) error {
+	images, err := a.countECRImages(ctx, repository)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	a.logger.Log("level", "info", "message", fmt.Sprintf("found that ecr repository %#q with %d images should be deleted", *repository.RepositoryName, images))
+
+	res := run.Resource{
+		ID:        *repository.RepositoryName,
+		Type:      "AWS::ECR::Repository",
+		CreatedAt: aws.TimeValue(repository.CreatedAt),
+		Note:      fmt.Sprintf("%d images", images),
+	}
+	err = a.run.DeleteResource(ctx, cleanerECRRepositories, res, func() error {
+		i := &ecr.DeleteRepositoryInput{
+			Force:          aws.Bool(true),
+			RegistryId:     repository.RegistryId,
+			RepositoryName: repository.RepositoryName,
+		}
+
+		_, err := a.ecrClient.DeleteRepository(i)
+		if err != nil && !isAWSError(err, ecr.ErrCodeRepositoryNotFoundException) {
+			return microerror.Mask(err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// countECRImages returns the number of images in the given repository.
+// Images tagged several times are counted once.
+func (a *Cleaner) countECRImages(ctx context.Context, repository *ecr.Repository) (int, error) {
+	digests := map[string]bool{}
+
+	i := &ecr.ListImagesInput{
+		RegistryId:     repository.RegistryId,
+		RepositoryName: repository.RepositoryName,
+	}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.ecrClient.ListImages(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, id := range o.ImageIds {
+			digests[aws.StringValue(id.ImageDigest)] = true
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		return 0, microerror.Mask(err)
+	}
+
+	return len(digests), nil
+}
+
+func (a *Cleaner) ecrRepositoryShouldBeDeleted(repository *ecr.Repository) bool {
+	if repository.RepositoryName == nil || !a.hasCIPrefix(*repository.RepositoryName) {
+		return false
+	}
+
+	// do not delete recent repositories.
+	if isRecent(repository.CreatedAt, a.gracePeriod) {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,57 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecr"
+)
+
+func TestECRRepositoryShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		name        string
+		created     time.Time
+		expected    bool
+		description string
+	}{
+		{
+			description: "old ci repository should be deleted",
+			name:        "ci-wip-a1b2c/app",
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    true,
+		},
+		{
+			description: "recent ci repository should not be deleted",
+			name:        "e2e-a1b2c",
+			created:     time.Now().Add(-time.Hour),
+			expected:    false,
+		},
+		{
+			description: "old general repository should not be deleted",
+			name:        "giantswarm/app-operator",
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			repository := &ecr.Repository{
+				CreatedAt:      aws.Time(tc.created),
+				RepositoryName: aws.String(tc.name),
+			}
+
+			actual := a.ecrRepositoryShouldBeDeleted(repository)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", tc.name, tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/efs"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanFileSystems deletes the EFS file systems created by the CSI driver
+// tests of CI clusters. Their mount targets keep network interfaces in the
+// subnets of the cluster, which blocks deleting its VPC. The mount targets
+// are deleted first, and the file system once they are gone, which is
+// tracked by later runs.
+func (a *Cleaner) cleanFileSystems(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &efs.DescribeFileSystemsInput{}
+	err := paginate(ctx, &i.Marker, func() (*string, error) {
+		o, err := a.efsClient.DescribeFileSystems(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, fs := range o.FileSystems {
+			if !a.fileSystemShouldBeDeleted(fs) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that file system %#q should be deleted", *fs.FileSystemId))
+
+			res := run.Resource{
+				ID:        *fs.FileSystemId,
+				Type:      "AWS::EFS::FileSystem",
+				Tags:      efsTags(fs.Tags),
+				CreatedAt: aws.TimeValue(fs.CreationTime),
+			}
+			if n := aws.Int64Value(fs.NumberOfMountTargets); n > 0 {
+				res.Note = fmt.Sprintf("%d mount targets are deleted along with the file system", n)
+			}
+
+			fs := fs
+			start := func() (string, error) {
+				if aws.StringValue(fs.LifeCycleState) == efs.LifeCycleStateDeleting {
+					return *fs.FileSystemId, nil
+				}
+				return a.deleteFileSystem(ctx, *fs.FileSystemId)
+			}
+			err := a.run.DeleteResourceAsync(ctx, cleanerFileSystems, res, start, a.pollFileSystem)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting file system %#q", *fs.FileSystemId), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextMarker, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	err = a.run.PollPending(ctx, cleanerFileSystems, "AWS::EFS::FileSystem", a.pollFileSystem)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// deleteFileSystem starts deleting the mount targets of the file system with
+// the given ID, or the file system itself once it has no mount targets left.
+// The ID of the file system is returned to poll the deletion.
+func (a *Cleaner) deleteFileSystem(ctx context.Context, id string) (string, error) {
+	var deleting bool
+
+	i := &efs.DescribeMountTargetsInput{FileSystemId: aws.String(id)}
+	err := paginate(ctx, &i.Marker, func() (*string, error) {
+		o, err := a.efsClient.DescribeMountTargets(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, mt := range o.MountTargets {
+			switch aws.StringValue(mt.LifeCycleState) {
+			case efs.LifeCycleStateDeleted:
+				continue
+			case efs.LifeCycleStateDeleting:
+				deleting = true
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("deleting mount target %#q of file system %#q", *mt.MountTargetId, id))
+
+			_, err := a.efsClient.DeleteMountTarget(&efs.DeleteMountTargetInput{MountTargetId: mt.MountTargetId})
+			if isAWSError(err, efs.ErrCodeMountTargetNotFound) {
+				continue
+			} else if err != nil {
+				return nil, microerror.Mask(err)
+			}
+			deleting = true
+		}
+
+		return o.NextMarker, nil
+	})
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	// the file system cannot be deleted while mount targets are still
+	// being deleted.
+	if deleting {
+		return id, nil
+	}
+
+	_, err = a.efsClient.DeleteFileSystem(&efs.DeleteFileSystemInput{FileSystemId: aws.String(id)})
+	if isAWSError(err, efs.ErrCodeFileSystemNotFound) {
+		return "", nil
+	} else if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return id, nil
+}
+
+// pollFileSystem continues deleting the file system with the given ID once
+// its mount targets are gone.
+func (a *Cleaner) pollFileSystem(ctx context.Context, id string) (bool, error) {
+	o, err := a.efsClient.DescribeFileSystems(&efs.DescribeFileSystemsInput{FileSystemId: aws.String(id)})
+	if isAWSError(err, efs.ErrCodeFileSystemNotFound) {
+		return true, nil
+	} else if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	if len(o.FileSystems) == 0 {
+		return true, nil
+	}
+
+	switch aws.StringValue(o.FileSystems[0].LifeCycleState) {
+	case efs.LifeCycleStateDeleted:
+		return true, nil
+	case efs.LifeCycleStateDeleting:
+		return false, nil
+	}
+
+	handle, err := a.deleteFileSystem(ctx, id)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	return handle == "", nil
+}
+
+func (a *Cleaner) fileSystemShouldBeDeleted(fs *efs.FileSystemDescription) bool {
+	if fs.FileSystemId == nil {
+		return false
+	}
+
+	if !a.isCITagged(efsTags(fs.Tags)) && !a.hasCIPrefix(aws.StringValue(fs.Name)) {
+		return false
+	}
+
+	if aws.StringValue(fs.LifeCycleState) == efs.LifeCycleStateDeleted {
+		return false
+	}
+
+	// do not delete recent file systems.
+	if isRecent(fs.CreationTime, a.gracePeriod) {
+		return false
+	}
+
+	return true
+}
+
+func efsTags(efsTags []*efs.Tag) map[string]string {
+	if len(efsTags) == 0 {
+		return nil
+	}
+
+	tags := map[string]string{}
+	for _, t := range efsTags {
+		tags[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+	}
+
+	return tags
+}
@@ -0,0 +1,82 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/efs"
+)
+
+func TestFileSystemShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		fs          *efs.FileSystemDescription
+		expected    bool
+		description string
+	}{
+		{
+			description: "old ci file system should be deleted",
+			fs:          newFileSystem(clusterTag, "ci-a1b2c", efs.LifeCycleStateAvailable, time.Now().Add(-2*time.Hour)),
+			expected:    true,
+		},
+		{
+			description: "old file system of ci csi driver test should be deleted",
+			fs:          newFileSystem("Name", "e2e-a1b2c-efs", efs.LifeCycleStateAvailable, time.Now().Add(-2*time.Hour)),
+			expected:    true,
+		},
+		{
+			description: "deleting ci file system should be polled",
+			fs:          newFileSystem(clusterTag, "ci-a1b2c", efs.LifeCycleStateDeleting, time.Now().Add(-2*time.Hour)),
+			expected:    true,
+		},
+		{
+			description: "deleted ci file system should not be deleted",
+			fs:          newFileSystem(clusterTag, "ci-a1b2c", efs.LifeCycleStateDeleted, time.Now().Add(-2*time.Hour)),
+			expected:    false,
+		},
+		{
+			description: "recent ci file system should not be deleted",
+			fs:          newFileSystem(clusterTag, "ci-a1b2c", efs.LifeCycleStateAvailable, time.Now().Add(-time.Hour)),
+			expected:    false,
+		},
+		{
+			description: "old general file system should not be deleted",
+			fs:          newFileSystem("Name", "shared-home", efs.LifeCycleStateAvailable, time.Now().Add(-2*time.Hour)),
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := a.fileSystemShouldBeDeleted(tc.fs)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.fs.FileSystemId, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func newFileSystem(tagKey, tagValue, state string, creationTime time.Time) *efs.FileSystemDescription {
+	fs := &efs.FileSystemDescription{
+		CreationTime:   aws.Time(creationTime),
+		FileSystemId:   aws.String("fs-a1b2c3d4"),
+		LifeCycleState: aws.String(state),
+		Tags: []*efs.Tag{
+			{
+				Key:   aws.String(tagKey),
+				Value: aws.String(tagValue),
+			},
+		},
+	}
+	if tagKey == "Name" {
+		fs.Name = aws.String(tagValue)
+	}
+
+	return fs
+}
@@ -0,0 +1,219 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanEKSClusters deletes the managed EKS clusters CAPI based CI runs leave
+// behind. EKS refuses to delete clusters which still have node groups or
+// Fargate profiles, and deletes Fargate profiles one at a time, so the
+// deletion takes several steps which are tracked by later runs.
+func (a *Cleaner) cleanEKSClusters(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &eks.ListClustersInput{}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.eksClient.ListClusters(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, name := range o.Clusters {
+			err := a.cleanEKSCluster(ctx, name)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting eks cluster %#q", *name), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	err = a.run.PollPending(ctx, cleanerEKSClusters, "AWS::EKS::Cluster", a.pollEKSCluster)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) cleanEKSCluster(ctx context.Context, name *string) error {
+	o, err := a.eksClient.DescribeCluster(&eks.DescribeClusterInput{Name: name})
+	if isAWSError(err, eks.ErrCodeResourceNotFoundException) {
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	cluster := o.Cluster
+	if !a.eksClusterShouldBeDeleted(cluster) {
+		return nil
+	}
+
+	a.logger.Log("level", "info", "message", fmt.Sprintf("found that eks cluster %#q should be deleted", *cluster.Name))
+
+	res := run.Resource{
+		ID:          *cluster.Name,
+		Type:        "AWS::EKS::Cluster",
+		Tags:        aws.StringValueMap(cluster.Tags),
+		CreatedAt:   aws.TimeValue(cluster.CreatedAt),
+		Cost:        "EKS control plane, billed hourly",
+		MonthlyCost: eksClusterHourlyCost * hoursPerMonth,
+	}
+	start := func() (string, error) {
+		return a.deleteEKSCluster(ctx, cluster.Name)
+	}
+	err = a.run.DeleteResourceAsync(ctx, cleanerEKSClusters, res, start, a.pollEKSCluster)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// deleteEKSCluster takes the next step of deleting the cluster with the given
+// name. Node groups and Fargate profiles are deleted first, the cluster once
+// they are gone. The name of the cluster is returned to poll the deletion.
+func (a *Cleaner) deleteEKSCluster(ctx context.Context, name *string) (string, error) {
+	nodegroups, err := a.deleteEKSNodegroups(ctx, name)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	profiles, err := a.deleteEKSFargateProfile(ctx, name)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	if nodegroups+profiles > 0 {
+		a.logger.Log("level", "debug", "message", fmt.Sprintf("waiting for %d node groups and %d fargate profiles of eks cluster %#q to be deleted", nodegroups, profiles, *name))
+		return *name, nil
+	}
+
+	_, err = a.eksClient.DeleteCluster(&eks.DeleteClusterInput{Name: name})
+	// ignore clusters which are gone or being deleted already.
+	if err != nil && !isAWSError(err, eks.ErrCodeResourceNotFoundException) && !isAWSError(err, eks.ErrCodeResourceInUseException) {
+		return "", microerror.Mask(err)
+	}
+
+	return *name, nil
+}
+
+// pollEKSCluster continues deleting the EKS cluster with the given name until
+// it is gone.
+func (a *Cleaner) pollEKSCluster(ctx context.Context, name string) (bool, error) {
+	o, err := a.eksClient.DescribeCluster(&eks.DescribeClusterInput{Name: aws.String(name)})
+	if isAWSError(err, eks.ErrCodeResourceNotFoundException) {
+		return true, nil
+	} else if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	if aws.StringValue(o.Cluster.Status) == eks.ClusterStatusDeleting {
+		return false, nil
+	}
+
+	_, err = a.deleteEKSCluster(ctx, o.Cluster.Name)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	return false, nil
+}
+
+// deleteEKSNodegroups deletes all node groups of the given cluster and
+// returns how many are left.
+func (a *Cleaner) deleteEKSNodegroups(ctx context.Context, cluster *string) (int, error) {
+	var left int
+
+	i := &eks.ListNodegroupsInput{ClusterName: cluster}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.eksClient.ListNodegroups(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, n := range o.Nodegroups {
+			left++
+
+			_, err := a.eksClient.DeleteNodegroup(&eks.DeleteNodegroupInput{ClusterName: cluster, NodegroupName: n})
+			// ignore node groups which are gone or being deleted already.
+			if err != nil && !isAWSError(err, eks.ErrCodeResourceNotFoundException) && !isAWSError(err, eks.ErrCodeResourceInUseException) {
+				return nil, microerror.Mask(err)
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		return 0, microerror.Mask(err)
+	}
+
+	return left, nil
+}
+
+// deleteEKSFargateProfile deletes a Fargate profile of the given cluster and
+// returns how many are left. EKS deletes only one profile of a cluster at a
+// time, so the others are deleted by later steps.
+func (a *Cleaner) deleteEKSFargateProfile(ctx context.Context, cluster *string) (int, error) {
+	var profiles []*string
+
+	i := &eks.ListFargateProfilesInput{ClusterName: cluster}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.eksClient.ListFargateProfiles(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		profiles = append(profiles, o.FargateProfileNames...)
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		return 0, microerror.Mask(err)
+	}
+
+	if len(profiles) == 0 {
+		return 0, nil
+	}
+
+	_, err = a.eksClient.DeleteFargateProfile(&eks.DeleteFargateProfileInput{ClusterName: cluster, FargateProfileName: profiles[0]})
+	// ignore profiles which are gone or wait for another one being deleted.
+	if err != nil && !isAWSError(err, eks.ErrCodeResourceNotFoundException) && !isAWSError(err, eks.ErrCodeResourceInUseException) {
+		return 0, microerror.Mask(err)
+	}
+
+	return len(profiles), nil
+}
+
+func (a *Cleaner) eksClusterShouldBeDeleted(cluster *eks.Cluster) bool {
+	if cluster.Name == nil {
+		return false
+	}
+
+	if !a.hasCIPrefix(*cluster.Name) && !a.isCITagged(aws.StringValueMap(cluster.Tags)) {
+		return false
+	}
+
+	// do not delete recent clusters.
+	if isRecent(cluster.CreatedAt, a.gracePeriod) {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,66 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/eks"
+)
+
+func TestEKSClusterShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		name        string
+		tags        map[string]*string
+		created     time.Time
+		expected    bool
+		description string
+	}{
+		{
+			description: "old ci cluster should be deleted",
+			name:        "ci-wip-a1b2c",
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    true,
+		},
+		{
+			description: "old cluster tagged with ci cluster should be deleted",
+			name:        "capa-a1b2c",
+			tags:        map[string]*string{clusterTag: aws.String("ci-wip-a1b2c")},
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    true,
+		},
+		{
+			description: "recent ci cluster should not be deleted",
+			name:        "ci-wip-a1b2c",
+			created:     time.Now().Add(-time.Hour),
+			expected:    false,
+		},
+		{
+			description: "old general cluster should not be deleted",
+			name:        "gauss",
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			cluster := &eks.Cluster{
+				CreatedAt: aws.Time(tc.created),
+				Name:      aws.String(tc.name),
+				Tags:      tc.tags,
+			}
+
+			actual := a.eksClusterShouldBeDeleted(cluster)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", tc.name, tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,298 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/emr"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+const (
+	// emrSecurityGroupLookback is how long after their creation the security
+	// groups of terminated CI clusters are cleaned up.
+	emrSecurityGroupLookback = 7 * 24 * time.Hour
+)
+
+// cleanEMR terminates CI EMR clusters running longer than the grace period.
+// Clusters with termination protection are only reported, unless overriding
+// it is configured. Terminating a cluster is tracked by later runs. Once
+// terminated, the security groups EMR created for the clusters are deleted,
+// unless other clusters still use them.
+func (a *Cleaner) cleanEMR(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	active := []*string{
+		aws.String(emr.ClusterStateStarting),
+		aws.String(emr.ClusterStateBootstrapping),
+		aws.String(emr.ClusterStateRunning),
+		aws.String(emr.ClusterStateWaiting),
+		aws.String(emr.ClusterStateTerminating),
+	}
+
+	// inUse are the IDs of the security groups of clusters which are not
+	// terminated.
+	inUse := map[string]bool{}
+
+	clusters, err := a.listEMRClusters(ctx, &emr.ListClustersInput{ClusterStates: active})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	for _, cluster := range clusters {
+		for _, id := range emrSecurityGroups(cluster) {
+			inUse[id] = true
+		}
+
+		if !a.emrClusterShouldBeTerminated(cluster) {
+			continue
+		}
+
+		res := run.Resource{
+			ID:        *cluster.Id,
+			Type:      "AWS::EMR::Cluster",
+			Tags:      emrTags(cluster.Tags),
+			CreatedAt: aws.TimeValue(cluster.Status.Timeline.CreationDateTime),
+		}
+
+		if aws.BoolValue(cluster.TerminationProtected) && !a.terminateProtectedEMRClusters {
+			a.logger.Log("level", "warning", "message", fmt.Sprintf("found that emr cluster %#q should be terminated, but skipping it as termination protection is enabled", *cluster.Id))
+			a.run.Report(ctx, cleanerEMR, res)
+			continue
+		}
+
+		a.logger.Log("level", "info", "message", fmt.Sprintf("found that emr cluster %#q should be terminated", *cluster.Id))
+
+		cluster := cluster
+		start := func() (string, error) {
+			return a.terminateEMRCluster(cluster)
+		}
+		err := a.run.DeleteResourceAsync(ctx, cleanerEMR, res, start, a.pollEMRCluster)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			a.logger.Log("level", "error", "message", fmt.Sprintf("failed terminating emr cluster %#q", *cluster.Id), "stack", fmt.Sprintf("%#v", err))
+		}
+	}
+
+	i := &emr.ListClustersInput{
+		ClusterStates: []*string{
+			aws.String(emr.ClusterStateTerminated),
+			aws.String(emr.ClusterStateTerminatedWithErrors),
+		},
+		CreatedAfter: aws.Time(time.Now().Add(-emrSecurityGroupLookback)),
+	}
+	terminated, err := a.listEMRClusters(ctx, i)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	var ids []*string
+	for _, cluster := range terminated {
+		if !a.isCIEMRCluster(cluster) {
+			continue
+		}
+
+		for _, id := range emrSecurityGroups(cluster) {
+			if !inUse[id] {
+				inUse[id] = true
+				ids = append(ids, aws.String(id))
+			}
+		}
+	}
+
+	if len(ids) != 0 {
+		err := a.deleteEMRSecurityGroups(ctx, ids)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+		}
+	}
+
+	err = a.run.PollPending(ctx, cleanerEMR, "AWS::EMR::Cluster", a.pollEMRCluster)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// listEMRClusters lists the clusters matching the given input including their
+// details.
+func (a *Cleaner) listEMRClusters(ctx context.Context, i *emr.ListClustersInput) ([]*emr.Cluster, error) {
+	var clusters []*emr.Cluster
+	err := paginate(ctx, &i.Marker, func() (*string, error) {
+		o, err := a.emrClient.ListClusters(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, s := range o.Clusters {
+			o, err := a.emrClient.DescribeCluster(&emr.DescribeClusterInput{ClusterId: s.Id})
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			clusters = append(clusters, o.Cluster)
+		}
+
+		return o.Marker, nil
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return clusters, nil
+}
+
+func (a *Cleaner) terminateEMRCluster(cluster *emr.Cluster) (string, error) {
+	if aws.BoolValue(cluster.TerminationProtected) {
+		i := &emr.SetTerminationProtectionInput{
+			JobFlowIds:           []*string{cluster.Id},
+			TerminationProtected: aws.Bool(false),
+		}
+
+		_, err := a.emrClient.SetTerminationProtection(i)
+		if err != nil {
+			return "", microerror.Mask(err)
+		}
+	}
+
+	_, err := a.emrClient.TerminateJobFlows(&emr.TerminateJobFlowsInput{JobFlowIds: []*string{cluster.Id}})
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return *cluster.Id, nil
+}
+
+func (a *Cleaner) pollEMRCluster(ctx context.Context, id string) (bool, error) {
+	o, err := a.emrClient.DescribeCluster(&emr.DescribeClusterInput{ClusterId: aws.String(id)})
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	switch aws.StringValue(o.Cluster.Status.State) {
+	case emr.ClusterStateTerminated, emr.ClusterStateTerminatedWithErrors:
+		return true, nil
+	case emr.ClusterStateTerminating:
+		return false, nil
+	default:
+		return false, microerror.Maskf(executionFailedError, "emr cluster %#q is in state %#q", id, aws.StringValue(o.Cluster.Status.State))
+	}
+}
+
+// deleteEMRSecurityGroups deletes the security groups with the given IDs.
+// The master and slave groups EMR creates reference each other, which is why
+// these references are revoked first.
+func (a *Cleaner) deleteEMRSecurityGroups(ctx context.Context, ids []*string) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &ec2.DescribeSecurityGroupsInput{
+		Filters: []*ec2.Filter{
+			{
+				Name:   aws.String("group-id"),
+				Values: ids,
+			},
+		},
+	}
+
+	o, err := a.ec2Client.DescribeSecurityGroups(i)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, group := range o.SecurityGroups {
+		a.logger.Log("level", "info", "message", fmt.Sprintf("found that security group %#q of terminated emr cluster should be deleted", *group.GroupId))
+
+		res := run.Resource{
+			ID:   *group.GroupId,
+			Type: "AWS::EC2::SecurityGroup",
+			Tags: ec2Tags(group.Tags),
+		}
+		group := group
+		err := a.run.DeleteResource(ctx, cleanerEMR, res, func() error {
+			return a.deleteSecurityGroup(group, o.SecurityGroups)
+		})
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting security group %#q", *group.GroupId), "stack", fmt.Sprintf("%#v", err))
+		}
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) emrClusterShouldBeTerminated(cluster *emr.Cluster) bool {
+	if !a.isCIEMRCluster(cluster) {
+		return false
+	}
+
+	if cluster.Status == nil || cluster.Status.Timeline == nil {
+		return false
+	}
+
+	// do not terminate clusters that are already being terminated.
+	if aws.StringValue(cluster.Status.State) == emr.ClusterStateTerminating {
+		return false
+	}
+
+	// do not terminate recent clusters.
+	created := cluster.Status.Timeline.CreationDateTime
+	if isRecent(created, a.gracePeriod) {
+		return false
+	}
+
+	return true
+}
+
+func (a *Cleaner) isCIEMRCluster(cluster *emr.Cluster) bool {
+	if cluster.Id == nil || cluster.Name == nil {
+		return false
+	}
+
+	return a.hasCIPrefix(*cluster.Name) || a.isCITagged(emrTags(cluster.Tags))
+}
+
+// emrSecurityGroups returns the IDs of the security groups EMR created for
+// the given cluster.
+func emrSecurityGroups(cluster *emr.Cluster) []string {
+	attributes := cluster.Ec2InstanceAttributes
+	if attributes == nil {
+		return nil
+	}
+
+	var ids []string
+	for _, id := range []*string{attributes.EmrManagedMasterSecurityGroup, attributes.EmrManagedSlaveSecurityGroup, attributes.ServiceAccessSecurityGroup} {
+		if id != nil {
+			ids = append(ids, *id)
+		}
+	}
+
+	return ids
+}
+
+func emrTags(emrTags []*emr.Tag) map[string]string {
+	if len(emrTags) == 0 {
+		return nil
+	}
+
+	tags := map[string]string{}
+	for _, t := range emrTags {
+		tags[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+	}
+
+	return tags
+}
@@ -0,0 +1,75 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/emr"
+)
+
+func TestEMRClusterShouldBeTerminated(t *testing.T) {
+	tcs := []struct {
+		cluster     *emr.Cluster
+		expected    bool
+		description string
+	}{
+		{
+			description: "old ci cluster should be terminated",
+			cluster:     newEMRCluster("ci-wip-a1b2c-spark", emr.ClusterStateWaiting, time.Now().Add(-2*time.Hour)),
+			expected:    true,
+		},
+		{
+			description: "old cluster with ci cluster tag should be terminated",
+			cluster: func() *emr.Cluster {
+				c := newEMRCluster("spark", emr.ClusterStateRunning, time.Now().Add(-2*time.Hour))
+				c.Tags = []*emr.Tag{{Key: aws.String(clusterTag), Value: aws.String("ci-a1b2c")}}
+				return c
+			}(),
+			expected: true,
+		},
+		{
+			description: "recent ci cluster should not be terminated",
+			cluster:     newEMRCluster("ci-wip-a1b2c-spark", emr.ClusterStateBootstrapping, time.Now().Add(-time.Hour)),
+			expected:    false,
+		},
+		{
+			description: "terminating ci cluster should not be terminated",
+			cluster:     newEMRCluster("ci-wip-a1b2c-spark", emr.ClusterStateTerminating, time.Now().Add(-2*time.Hour)),
+			expected:    false,
+		},
+		{
+			description: "old general cluster should not be terminated",
+			cluster:     newEMRCluster("analytics", emr.ClusterStateWaiting, time.Now().Add(-2*time.Hour)),
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := a.emrClusterShouldBeTerminated(tc.cluster)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be terminated, want %t, got %t", *tc.cluster.Name, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func newEMRCluster(name, state string, creationTime time.Time) *emr.Cluster {
+	return &emr.Cluster{
+		Id:   aws.String("j-0123456789ABC"),
+		Name: aws.String(name),
+		Status: &emr.ClusterStatus{
+			State: aws.String(state),
+			Timeline: &emr.ClusterTimeline{
+				CreationDateTime: aws.Time(creationTime),
+			},
+		},
+	}
+}
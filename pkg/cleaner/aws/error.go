@@ -1,6 +1,7 @@
 package aws
 
 import (
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/giantswarm/microerror"
 )
 
@@ -21,3 +22,21 @@ var notFoundError = &microerror.Error{
 func IsNotFound(err error) bool {
 	return microerror.Cause(err) == notFoundError
 }
+
+var executionFailedError = &microerror.Error{
+	Kind: "executionFailedError",
+}
+
+// IsExecutionFailed asserts executionFailedError.
+func IsExecutionFailed(err error) bool {
+	return microerror.Cause(err) == executionFailedError
+}
+
+// isAWSError asserts errors returned by the AWS API with the given code.
+func isAWSError(err error, code string) bool {
+	if aerr, ok := microerror.Cause(err).(awserr.Error); ok {
+		return aerr.Code() == code
+	}
+
+	return false
+}
@@ -0,0 +1,105 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanFlowLogs deletes the VPC flow logs CI clusters create. Once their log
+// group or bucket is deleted, they keep failing to deliver logs.
+func (a *Cleaner) cleanFlowLogs(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &ec2.DescribeFlowLogsInput{}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.ec2Client.DescribeFlowLogs(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, flowLog := range o.FlowLogs {
+			if !a.flowLogShouldBeDeleted(flowLog) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that flow log %#q of %#q should be deleted", *flowLog.FlowLogId, aws.StringValue(flowLog.ResourceId)))
+
+			res := run.Resource{
+				ID:        *flowLog.FlowLogId,
+				Type:      "AWS::EC2::FlowLog",
+				Tags:      ec2Tags(flowLog.Tags),
+				CreatedAt: aws.TimeValue(flowLog.CreationTime),
+			}
+			id := flowLog.FlowLogId
+			err := a.run.DeleteResource(ctx, cleanerFlowLogs, res, func() error {
+				o, err := a.ec2Client.DeleteFlowLogs(&ec2.DeleteFlowLogsInput{FlowLogIds: []*string{id}})
+				if err != nil {
+					return microerror.Mask(err)
+				}
+				for _, item := range o.Unsuccessful {
+					if item.Error != nil && aws.StringValue(item.Error.Code) != "InvalidFlowLogId.NotFound" {
+						return microerror.Maskf(executionFailedError, "%s: %s", aws.StringValue(item.Error.Code), aws.StringValue(item.Error.Message))
+					}
+				}
+
+				return nil
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting flow log %#q", *flowLog.FlowLogId), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// isCIFlowLog checks if the given flow log is tagged as CI resource or
+// delivers to a CI log group or bucket, like `/aws/vpc/ci-wip-a1b2c`.
+func (a *Cleaner) isCIFlowLog(flowLog *ec2.FlowLog) bool {
+	if a.isCITagged(ec2Tags(flowLog.Tags)) {
+		return true
+	}
+
+	if flowLog.LogGroupName != nil && a.isCILogGroup(*flowLog.LogGroupName) {
+		return true
+	}
+
+	d, err := arn.Parse(aws.StringValue(flowLog.LogDestination))
+	if err != nil {
+		return false
+	}
+
+	return a.isCILogGroup(strings.Replace(d.Resource, ":", "/", -1))
+}
+
+func (a *Cleaner) flowLogShouldBeDeleted(flowLog *ec2.FlowLog) bool {
+	if flowLog.FlowLogId == nil || !a.isCIFlowLog(flowLog) {
+		return false
+	}
+
+	// do not delete recent flow logs.
+	if isRecent(flowLog.CreationTime, a.gracePeriod) {
+		return false
+	}
+
+	return true
+}
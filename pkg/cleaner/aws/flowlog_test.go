@@ -0,0 +1,75 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func TestFlowLogShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		flowLog     *ec2.FlowLog
+		expected    bool
+		description string
+	}{
+		{
+			description: "old flow log into ci log group should be deleted",
+			flowLog:     newFlowLog("/aws/vpc/ci-wip-a1b2c", "", nil, time.Now().Add(-2*time.Hour)),
+			expected:    true,
+		},
+		{
+			description: "old flow log into ci bucket should be deleted",
+			flowLog:     newFlowLog("", "arn:aws:s3:::e2e-a1b2c-flow-logs/vpc", nil, time.Now().Add(-2*time.Hour)),
+			expected:    true,
+		},
+		{
+			description: "old flow log tagged with ci cluster should be deleted",
+			flowLog:     newFlowLog("vpc-flow-logs", "", []*ec2.Tag{{Key: aws.String(clusterTag), Value: aws.String("ci-wip-a1b2c")}}, time.Now().Add(-2*time.Hour)),
+			expected:    true,
+		},
+		{
+			description: "recent flow log into ci log group should not be deleted",
+			flowLog:     newFlowLog("/aws/vpc/ci-wip-a1b2c", "", nil, time.Now().Add(-time.Hour)),
+			expected:    false,
+		},
+		{
+			description: "old general flow log should not be deleted",
+			flowLog:     newFlowLog("vpc-flow-logs", "", nil, time.Now().Add(-2*time.Hour)),
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := a.flowLogShouldBeDeleted(tc.flowLog)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.flowLog.FlowLogId, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func newFlowLog(group string, destination string, tags []*ec2.Tag, created time.Time) *ec2.FlowLog {
+	flowLog := &ec2.FlowLog{
+		CreationTime: aws.Time(created),
+		FlowLogId:    aws.String("fl-0123456789abcdef0"),
+		ResourceId:   aws.String("vpc-0123456789abcdef0"),
+		Tags:         tags,
+	}
+	if group != "" {
+		flowLog.LogGroupName = aws.String(group)
+	}
+	if destination != "" {
+		flowLog.LogDestination = aws.String(destination)
+	}
+
+	return flowLog
+}
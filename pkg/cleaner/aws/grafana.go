@@ -0,0 +1,87 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/managedgrafana"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanGrafanaWorkspaces deletes the Amazon Managed Grafana workspaces
+// observability e2e tests create per run.
+func (a *Cleaner) cleanGrafanaWorkspaces(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &managedgrafana.ListWorkspacesInput{}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.grafanaClient.ListWorkspaces(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, workspace := range o.Workspaces {
+			if !a.grafanaWorkspaceShouldBeDeleted(workspace) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that grafana workspace %#q should be deleted", *workspace.Id))
+
+			res := run.Resource{
+				ID:        *workspace.Id,
+				Type:      "AWS::Grafana::Workspace",
+				Tags:      aws.StringValueMap(workspace.Tags),
+				CreatedAt: aws.TimeValue(workspace.Created),
+			}
+			err := a.run.DeleteResource(ctx, cleanerGrafanaWorkspaces, res, func() error {
+				_, err := a.grafanaClient.DeleteWorkspace(&managedgrafana.DeleteWorkspaceInput{WorkspaceId: workspace.Id})
+				if err != nil {
+					return microerror.Mask(err)
+				}
+
+				return nil
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting grafana workspace %#q", *workspace.Id), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) grafanaWorkspaceShouldBeDeleted(workspace *managedgrafana.WorkspaceSummary) bool {
+	if workspace.Id == nil {
+		return false
+	}
+
+	if !a.hasCIPrefix(aws.StringValue(workspace.Name)) && !a.isCITagged(aws.StringValueMap(workspace.Tags)) {
+		return false
+	}
+
+	// do not delete workspaces that are already being deleted.
+	if aws.StringValue(workspace.Status) == managedgrafana.WorkspaceStatusDeleting {
+		return false
+	}
+
+	// do not delete recent workspaces.
+	if isRecent(workspace.Created, a.gracePeriod) {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,63 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/managedgrafana"
+)
+
+func TestGrafanaWorkspaceShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		workspace   *managedgrafana.WorkspaceSummary
+		expected    bool
+		description string
+	}{
+		{
+			description: "old workspace of ci cluster should be deleted",
+			workspace:   newGrafanaWorkspace("dashboards", "ci-a1b2c", managedgrafana.WorkspaceStatusActive, time.Now().Add(-2*time.Hour)),
+			expected:    true,
+		},
+		{
+			description: "recent workspace of ci cluster should not be deleted",
+			workspace:   newGrafanaWorkspace("dashboards", "ci-a1b2c", managedgrafana.WorkspaceStatusCreating, time.Now().Add(-time.Hour)),
+			expected:    false,
+		},
+		{
+			description: "deleting workspace of ci cluster should not be deleted",
+			workspace:   newGrafanaWorkspace("dashboards", "ci-a1b2c", managedgrafana.WorkspaceStatusDeleting, time.Now().Add(-2*time.Hour)),
+			expected:    false,
+		},
+		{
+			description: "old general workspace should not be deleted",
+			workspace:   newGrafanaWorkspace("dashboards", "gauss", managedgrafana.WorkspaceStatusActive, time.Now().Add(-2*time.Hour)),
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := a.grafanaWorkspaceShouldBeDeleted(tc.workspace)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.workspace.Name, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func newGrafanaWorkspace(name, cluster, status string, created time.Time) *managedgrafana.WorkspaceSummary {
+	return &managedgrafana.WorkspaceSummary{
+		Created: aws.Time(created),
+		Id:      aws.String("g-0123456789"),
+		Name:    aws.String(name),
+		Status:  aws.String(status),
+		Tags:    map[string]*string{clusterTag: aws.String(cluster)},
+	}
+}
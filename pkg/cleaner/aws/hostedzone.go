@@ -0,0 +1,162 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanHostedZones deletes the hosted zones CI clusters create for
+// themselves, like `ci-wip-a1b2c.k8s.gigantic.io`, together with all their
+// records. Hosted zones Cloud Map manages are left to the Cloud Map cleaner.
+func (a *Cleaner) cleanHostedZones(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &route53.ListHostedZonesInput{}
+	err := paginate(ctx, &i.Marker, func() (*string, error) {
+		o, err := a.route53Client.ListHostedZones(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, zone := range o.HostedZones {
+			if zone.Id == nil || zone.Name == nil {
+				continue
+			}
+
+			seen, err := a.run.FirstSeen(cleanerHostedZones, *zone.Id)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				continue
+			}
+
+			if !a.hostedZoneShouldBeDeleted(zone, seen) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that hosted zone %#q should be deleted", *zone.Name))
+
+			res := run.Resource{
+				ID:   *zone.Id,
+				Type: "AWS::Route53::HostedZone",
+			}
+			zone := zone
+			err = a.run.DeleteResource(ctx, cleanerHostedZones, res, func() error {
+				return a.deleteHostedZone(zone)
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting hosted zone %#q", *zone.Name), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		if !aws.BoolValue(o.IsTruncated) {
+			return nil, nil
+		}
+		return o.NextMarker, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// deleteHostedZone deletes all records of the given hosted zone except its
+// own SOA and NS records, which cannot be deleted, and the zone itself. NS
+// records delegating subdomains are deleted, as they block deleting the zone
+// just like any other record.
+func (a *Cleaner) deleteHostedZone(zone *route53.HostedZone) error {
+	var changes []*route53.Change
+
+	i := &route53.ListResourceRecordSetsInput{
+		HostedZoneId: zone.Id,
+	}
+	for {
+		o, err := a.route53Client.ListResourceRecordSets(i)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		for _, r := range o.ResourceRecordSets {
+			if isZoneApexRecord(zone, r) {
+				continue
+			}
+
+			changes = append(changes, &route53.Change{
+				Action:            aws.String(route53.ChangeActionDelete),
+				ResourceRecordSet: r,
+			})
+		}
+
+		if !aws.BoolValue(o.IsTruncated) {
+			break
+		}
+		i.StartRecordIdentifier = o.NextRecordIdentifier
+		i.StartRecordName = o.NextRecordName
+		i.StartRecordType = o.NextRecordType
+	}
+
+	if len(changes) != 0 {
+		i := &route53.ChangeResourceRecordSetsInput{
+			ChangeBatch: &route53.ChangeBatch{
+				Changes: changes,
+			},
+			HostedZoneId: zone.Id,
+		}
+
+		_, err := a.route53Client.ChangeResourceRecordSets(i)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	_, err := a.route53Client.DeleteHostedZone(&route53.DeleteHostedZoneInput{Id: zone.Id})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (a *Cleaner) hostedZoneShouldBeDeleted(zone *route53.HostedZone, seen time.Time) bool {
+	name := aws.StringValue(zone.Name)
+	if !a.hasCIPrefix(name) || !strings.HasSuffix(name, "."+ciZoneDomain) {
+		return false
+	}
+
+	// do not delete hosted zones managed by other services.
+	if zone.LinkedService != nil {
+		return false
+	}
+
+	// do not delete recent hosted zones.
+	if time.Since(seen) < a.gracePeriod {
+		return false
+	}
+
+	return true
+}
+
+// isZoneApexRecord checks if the given record is the SOA or NS record of the
+// given hosted zone itself.
+func isZoneApexRecord(zone *route53.HostedZone, r *route53.ResourceRecordSet) bool {
+	switch aws.StringValue(r.Type) {
+	case route53.RRTypeSoa, route53.RRTypeNs:
+		return aws.StringValue(r.Name) == aws.StringValue(zone.Name)
+	}
+
+	return false
+}
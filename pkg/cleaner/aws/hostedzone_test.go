@@ -0,0 +1,116 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+func TestHostedZoneShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		zone        *route53.HostedZone
+		seen        time.Time
+		expected    bool
+		description string
+	}{
+		{
+			description: "old ci cluster zone should be deleted",
+			zone:        &route53.HostedZone{Id: aws.String("/hostedzone/Z1"), Name: aws.String("ci-wip-a1b2c.k8s.gigantic.io.")},
+			seen:        time.Now().Add(-2 * time.Hour),
+			expected:    true,
+		},
+		{
+			description: "recently found ci cluster zone should not be deleted",
+			zone:        &route53.HostedZone{Id: aws.String("/hostedzone/Z1"), Name: aws.String("ci-wip-a1b2c.k8s.gigantic.io.")},
+			seen:        time.Now().Add(-time.Hour),
+			expected:    false,
+		},
+		{
+			description: "old ci zone of another domain should not be deleted",
+			zone:        &route53.HostedZone{Id: aws.String("/hostedzone/Z1"), Name: aws.String("ci-wip-a1b2c.example.com.")},
+			seen:        time.Now().Add(-2 * time.Hour),
+			expected:    false,
+		},
+		{
+			description: "old ci zone managed by cloud map should not be deleted",
+			zone: &route53.HostedZone{
+				Id:            aws.String("/hostedzone/Z1"),
+				Name:          aws.String("ci-wip-a1b2c.gigantic.io."),
+				LinkedService: &route53.LinkedService{ServicePrincipal: aws.String(serviceDiscoveryPrincipal)},
+			},
+			seen:     time.Now().Add(-2 * time.Hour),
+			expected: false,
+		},
+		{
+			description: "old installation zone should not be deleted",
+			zone:        &route53.HostedZone{Id: aws.String("/hostedzone/Z1"), Name: aws.String("gauss.eu-central-1.aws.gigantic.io.")},
+			seen:        time.Now().Add(-2 * time.Hour),
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := a.hostedZoneShouldBeDeleted(tc.zone, tc.seen)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.zone.Name, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestIsZoneApexRecord(t *testing.T) {
+	zone := &route53.HostedZone{Name: aws.String("ci-wip-a1b2c.k8s.gigantic.io.")}
+
+	tcs := []struct {
+		name        string
+		recordType  string
+		expected    bool
+		description string
+	}{
+		{
+			description: "ns record of the zone is an apex record",
+			name:        "ci-wip-a1b2c.k8s.gigantic.io.",
+			recordType:  route53.RRTypeNs,
+			expected:    true,
+		},
+		{
+			description: "soa record of the zone is an apex record",
+			name:        "ci-wip-a1b2c.k8s.gigantic.io.",
+			recordType:  route53.RRTypeSoa,
+			expected:    true,
+		},
+		{
+			description: "ns record delegating a subdomain is not an apex record",
+			name:        "api.ci-wip-a1b2c.k8s.gigantic.io.",
+			recordType:  route53.RRTypeNs,
+			expected:    false,
+		},
+		{
+			description: "a record of the zone is not an apex record",
+			name:        "ci-wip-a1b2c.k8s.gigantic.io.",
+			recordType:  route53.RRTypeA,
+			expected:    false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			r := &route53.ResourceRecordSet{Name: aws.String(tc.name), Type: aws.String(tc.recordType)}
+
+			actual := isZoneApexRecord(zone, r)
+
+			if actual != tc.expected {
+				t.Errorf("want %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// clusterRoleName matches the names of the IAM roles and instance profiles
+// every cluster creates, prefixed by the cluster ID.
+var clusterRoleName = regexp.MustCompile(`-(EC2-K8S|IAMManager)-Role\z`)
+
+// cleanRoles deletes the IAM roles and instance profiles of CI clusters,
+// which are left behind when deleting the stacks of the cluster fails. Roles
+// are removed from their instance profiles and their policies are detached
+// or deleted first.
+func (a *Cleaner) cleanRoles(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	{
+		i := &iam.ListRolesInput{}
+		err := paginate(ctx, &i.Marker, func() (*string, error) {
+			o, err := a.iamClient.ListRoles(i)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			for _, role := range o.Roles {
+				if !a.iamResourceShouldBeDeleted(aws.StringValue(role.RoleName), role.CreateDate) {
+					continue
+				}
+
+				a.logger.Log("level", "info", "message", fmt.Sprintf("found that iam role %#q should be deleted", *role.RoleName))
+
+				res := run.Resource{
+					ID:        *role.RoleName,
+					Type:      "AWS::IAM::Role",
+					Tags:      iamTags(role.Tags),
+					CreatedAt: aws.TimeValue(role.CreateDate),
+				}
+				err := a.run.DeleteResource(ctx, cleanerRoles, res, func() error {
+					return a.deleteRole(*role.RoleName)
+				})
+				if err != nil {
+					errors.Append(microerror.Mask(err))
+					a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting iam role %#q", *role.RoleName), "stack", fmt.Sprintf("%#v", err))
+				}
+			}
+
+			if !aws.BoolValue(o.IsTruncated) {
+				return nil, nil
+			}
+			return o.Marker, nil
+		})
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			return errors
+		}
+	}
+
+	{
+		i := &iam.ListInstanceProfilesInput{}
+		err := paginate(ctx, &i.Marker, func() (*string, error) {
+			o, err := a.iamClient.ListInstanceProfiles(i)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			for _, profile := range o.InstanceProfiles {
+				if !a.iamResourceShouldBeDeleted(aws.StringValue(profile.InstanceProfileName), profile.CreateDate) {
+					continue
+				}
+
+				a.logger.Log("level", "info", "message", fmt.Sprintf("found that iam instance profile %#q should be deleted", *profile.InstanceProfileName))
+
+				res := run.Resource{
+					ID:        *profile.InstanceProfileName,
+					Type:      "AWS::IAM::InstanceProfile",
+					Tags:      iamTags(profile.Tags),
+					CreatedAt: aws.TimeValue(profile.CreateDate),
+				}
+				err := a.run.DeleteResource(ctx, cleanerRoles, res, func() error {
+					return a.deleteInstanceProfile(profile)
+				})
+				if err != nil {
+					errors.Append(microerror.Mask(err))
+					a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting iam instance profile %#q", *profile.InstanceProfileName), "stack", fmt.Sprintf("%#v", err))
+				}
+			}
+
+			if !aws.BoolValue(o.IsTruncated) {
+				return nil, nil
+			}
+			return o.Marker, nil
+		})
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			return errors
+		}
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// deleteRole deletes the IAM role with the given name, after removing it from
+// its instance profiles, detaching its managed policies and deleting its
+// inline policies.
+func (a *Cleaner) deleteRole(name string) error {
+	{
+		o, err := a.iamClient.ListInstanceProfilesForRole(&iam.ListInstanceProfilesForRoleInput{RoleName: aws.String(name)})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		for _, p := range o.InstanceProfiles {
+			_, err := a.iamClient.RemoveRoleFromInstanceProfile(&iam.RemoveRoleFromInstanceProfileInput{InstanceProfileName: p.InstanceProfileName, RoleName: aws.String(name)})
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+	}
+
+	{
+		o, err := a.iamClient.ListAttachedRolePolicies(&iam.ListAttachedRolePoliciesInput{RoleName: aws.String(name)})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		for _, p := range o.AttachedPolicies {
+			_, err := a.iamClient.DetachRolePolicy(&iam.DetachRolePolicyInput{PolicyArn: p.PolicyArn, RoleName: aws.String(name)})
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+	}
+
+	{
+		o, err := a.iamClient.ListRolePolicies(&iam.ListRolePoliciesInput{RoleName: aws.String(name)})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		for _, p := range o.PolicyNames {
+			_, err := a.iamClient.DeleteRolePolicy(&iam.DeleteRolePolicyInput{PolicyName: p, RoleName: aws.String(name)})
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+	}
+
+	_, err := a.iamClient.DeleteRole(&iam.DeleteRoleInput{RoleName: aws.String(name)})
+	if err != nil && !isAWSError(err, iam.ErrCodeNoSuchEntityException) {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// deleteInstanceProfile deletes the given instance profile, after removing
+// the roles it still holds.
+func (a *Cleaner) deleteInstanceProfile(profile *iam.InstanceProfile) error {
+	for _, role := range profile.Roles {
+		_, err := a.iamClient.RemoveRoleFromInstanceProfile(&iam.RemoveRoleFromInstanceProfileInput{InstanceProfileName: profile.InstanceProfileName, RoleName: role.RoleName})
+		if err != nil && !isAWSError(err, iam.ErrCodeNoSuchEntityException) {
+			return microerror.Mask(err)
+		}
+	}
+
+	_, err := a.iamClient.DeleteInstanceProfile(&iam.DeleteInstanceProfileInput{InstanceProfileName: profile.InstanceProfileName})
+	if err != nil && !isAWSError(err, iam.ErrCodeNoSuchEntityException) {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// iamResourceShouldBeDeleted decides about roles and instance profiles
+// alike, given their name and creation time.
+func (a *Cleaner) iamResourceShouldBeDeleted(name string, createDate *time.Time) bool {
+	if !a.hasCIPrefix(name) || !clusterRoleName.MatchString(name) {
+		return false
+	}
+
+	// do not delete recent roles and instance profiles.
+	if isRecent(createDate, a.gracePeriod) {
+		return false
+	}
+
+	return true
+}
+
+func iamTags(iamTags []*iam.Tag) map[string]string {
+	if len(iamTags) == 0 {
+		return nil
+	}
+
+	tags := map[string]string{}
+	for _, t := range iamTags {
+		tags[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+	}
+
+	return tags
+}
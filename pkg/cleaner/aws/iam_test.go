@@ -0,0 +1,62 @@
+package aws
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIAMResourceShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		name        string
+		createDate  time.Time
+		expected    bool
+		description string
+	}{
+		{
+			description: "old worker role of ci cluster should be deleted",
+			name:        "ci-wip-a1b2c-EC2-K8S-Role",
+			createDate:  time.Now().Add(-2 * time.Hour),
+			expected:    true,
+		},
+		{
+			description: "old iam manager role of ci cluster should be deleted",
+			name:        "e2e-a1b2c-IAMManager-Role",
+			createDate:  time.Now().Add(-2 * time.Hour),
+			expected:    true,
+		},
+		{
+			description: "recent worker role of ci cluster should not be deleted",
+			name:        "ci-wip-a1b2c-EC2-K8S-Role",
+			createDate:  time.Now().Add(-time.Hour),
+			expected:    false,
+		},
+		{
+			description: "old other role of ci cluster should not be deleted",
+			name:        "ci-wip-a1b2c-Route53Manager-Role",
+			createDate:  time.Now().Add(-2 * time.Hour),
+			expected:    false,
+		},
+		{
+			description: "old worker role of general cluster should not be deleted",
+			name:        "gauss-EC2-K8S-Role",
+			createDate:  time.Now().Add(-2 * time.Hour),
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			createDate := tc.createDate
+			actual := a.iamResourceShouldBeDeleted(tc.name, &createDate)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", tc.name, tc.expected, actual)
+			}
+		})
+	}
+}
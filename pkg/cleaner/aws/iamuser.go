@@ -0,0 +1,209 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanUsers deletes the temporary IAM users some e2e suites create, after
+// removing everything which blocks deleting them. Access keys of other users
+// which are older than the grace period are logged as warnings, as long
+// lived keys in CI accounts usually are leaked test credentials.
+func (a *Cleaner) cleanUsers(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &iam.ListUsersInput{}
+	err := paginate(ctx, &i.Marker, func() (*string, error) {
+		o, err := a.iamClient.ListUsers(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, user := range o.Users {
+			if !a.hasCIPrefix(aws.StringValue(user.UserName)) {
+				err := a.warnStaleAccessKeys(user)
+				if err != nil {
+					errors.Append(microerror.Mask(err))
+				}
+				continue
+			}
+
+			if !a.userShouldBeDeleted(user) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that iam user %#q should be deleted", *user.UserName))
+
+			res := run.Resource{
+				ID:        *user.UserName,
+				Type:      "AWS::IAM::User",
+				Tags:      iamTags(user.Tags),
+				CreatedAt: aws.TimeValue(user.CreateDate),
+			}
+			err := a.run.DeleteResource(ctx, cleanerUsers, res, func() error {
+				return a.deleteUser(user.UserName)
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting iam user %#q", *user.UserName), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		if !aws.BoolValue(o.IsTruncated) {
+			return nil, nil
+		}
+		return o.Marker, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// deleteUser deletes the IAM user with the given name, after deleting its
+// access keys, MFA devices, login profile and inline policies, detaching its
+// managed policies and removing it from its groups.
+func (a *Cleaner) deleteUser(name *string) error {
+	{
+		o, err := a.iamClient.ListAccessKeys(&iam.ListAccessKeysInput{UserName: name})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		for _, k := range o.AccessKeyMetadata {
+			_, err := a.iamClient.DeleteAccessKey(&iam.DeleteAccessKeyInput{AccessKeyId: k.AccessKeyId, UserName: name})
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+	}
+
+	{
+		o, err := a.iamClient.ListMFADevices(&iam.ListMFADevicesInput{UserName: name})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		for _, d := range o.MFADevices {
+			_, err := a.iamClient.DeactivateMFADevice(&iam.DeactivateMFADeviceInput{SerialNumber: d.SerialNumber, UserName: name})
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			// hardware MFA devices are not deleted, only virtual ones.
+			_, err = a.iamClient.DeleteVirtualMFADevice(&iam.DeleteVirtualMFADeviceInput{SerialNumber: d.SerialNumber})
+			if err != nil && !isAWSError(err, iam.ErrCodeNoSuchEntityException) {
+				return microerror.Mask(err)
+			}
+		}
+	}
+
+	{
+		_, err := a.iamClient.DeleteLoginProfile(&iam.DeleteLoginProfileInput{UserName: name})
+		if err != nil && !isAWSError(err, iam.ErrCodeNoSuchEntityException) {
+			return microerror.Mask(err)
+		}
+	}
+
+	{
+		o, err := a.iamClient.ListAttachedUserPolicies(&iam.ListAttachedUserPoliciesInput{UserName: name})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		for _, p := range o.AttachedPolicies {
+			_, err := a.iamClient.DetachUserPolicy(&iam.DetachUserPolicyInput{PolicyArn: p.PolicyArn, UserName: name})
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+	}
+
+	{
+		o, err := a.iamClient.ListUserPolicies(&iam.ListUserPoliciesInput{UserName: name})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		for _, p := range o.PolicyNames {
+			_, err := a.iamClient.DeleteUserPolicy(&iam.DeleteUserPolicyInput{PolicyName: p, UserName: name})
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+	}
+
+	{
+		o, err := a.iamClient.ListGroupsForUser(&iam.ListGroupsForUserInput{UserName: name})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		for _, g := range o.Groups {
+			_, err := a.iamClient.RemoveUserFromGroup(&iam.RemoveUserFromGroupInput{GroupName: g.GroupName, UserName: name})
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+	}
+
+	_, err := a.iamClient.DeleteUser(&iam.DeleteUserInput{UserName: name})
+	if err != nil && !isAWSError(err, iam.ErrCodeNoSuchEntityException) {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// warnStaleAccessKeys logs the active access keys of the given user which are
+// older than the grace period.
+func (a *Cleaner) warnStaleAccessKeys(user *iam.User) error {
+	o, err := a.iamClient.ListAccessKeys(&iam.ListAccessKeysInput{UserName: user.UserName})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, k := range o.AccessKeyMetadata {
+		if !a.accessKeyIsStale(k) {
+			continue
+		}
+
+		a.logger.Log("level", "warning", "message", fmt.Sprintf("access key %#q of iam user %#q was created %s ago", aws.StringValue(k.AccessKeyId), *user.UserName, time.Since(*k.CreateDate).Round(time.Hour)))
+	}
+
+	return nil
+}
+
+func (a *Cleaner) accessKeyIsStale(k *iam.AccessKeyMetadata) bool {
+	if aws.StringValue(k.Status) != iam.StatusTypeActive {
+		return false
+	}
+
+	return !isRecent(k.CreateDate, a.gracePeriod)
+}
+
+func (a *Cleaner) userShouldBeDeleted(user *iam.User) bool {
+	if user.UserName == nil || !a.hasCIPrefix(*user.UserName) {
+		return false
+	}
+
+	// do not delete recent users.
+	if isRecent(user.CreateDate, a.gracePeriod) {
+		return false
+	}
+
+	return true
+}
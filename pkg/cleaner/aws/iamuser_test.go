@@ -0,0 +1,86 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+)
+
+func TestUserShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		user        *iam.User
+		expected    bool
+		description string
+	}{
+		{
+			description: "old ci user should be deleted",
+			user:        &iam.User{UserName: aws.String("e2e-a1b2c-uploader"), CreateDate: aws.Time(time.Now().Add(-2 * time.Hour))},
+			expected:    true,
+		},
+		{
+			description: "recent ci user should not be deleted",
+			user:        &iam.User{UserName: aws.String("e2e-a1b2c-uploader"), CreateDate: aws.Time(time.Now().Add(-time.Hour))},
+			expected:    false,
+		},
+		{
+			description: "old general user should not be deleted",
+			user:        &iam.User{UserName: aws.String("jenkins"), CreateDate: aws.Time(time.Now().Add(-2 * time.Hour))},
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := a.userShouldBeDeleted(tc.user)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.user.UserName, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestAccessKeyIsStale(t *testing.T) {
+	tcs := []struct {
+		key         *iam.AccessKeyMetadata
+		expected    bool
+		description string
+	}{
+		{
+			description: "old active key is stale",
+			key:         &iam.AccessKeyMetadata{Status: aws.String(iam.StatusTypeActive), CreateDate: aws.Time(time.Now().Add(-2 * time.Hour))},
+			expected:    true,
+		},
+		{
+			description: "old inactive key is not stale",
+			key:         &iam.AccessKeyMetadata{Status: aws.String(iam.StatusTypeInactive), CreateDate: aws.Time(time.Now().Add(-2 * time.Hour))},
+			expected:    false,
+		},
+		{
+			description: "recent active key is not stale",
+			key:         &iam.AccessKeyMetadata{Status: aws.String(iam.StatusTypeActive), CreateDate: aws.Time(time.Now().Add(-time.Hour))},
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := a.accessKeyIsStale(tc.key)
+
+			if actual != tc.expected {
+				t.Errorf("want %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanImages deregisters the AMIs image building CI jobs leave behind once
+// they are older than the AMI retention, and deletes their backing snapshots
+// afterwards, as snapshots cannot be deleted while an AMI uses them. CI
+// tagged snapshots left behind when deleting them failed after deregistering
+// their AMI are deleted as well.
+func (a *Cleaner) cleanImages(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &ec2.DescribeImagesInput{
+		Owners: []*string{aws.String("self")},
+	}
+	o, err := a.ec2Client.DescribeImages(i)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	// inUse are the IDs of the snapshots backing the AMIs which are kept.
+	inUse := map[string]bool{}
+
+	for _, image := range o.Images {
+		if !a.imageShouldBeDeleted(image) {
+			for _, id := range imageSnapshots(image) {
+				inUse[id] = true
+			}
+			continue
+		}
+
+		a.logger.Log("level", "info", "message", fmt.Sprintf("found that image %#q should be deregistered", *image.ImageId))
+
+		res := run.Resource{
+			ID:        *image.ImageId,
+			Type:      "AWS::EC2::Image",
+			Tags:      ec2Tags(image.Tags),
+			CreatedAt: aws.TimeValue(parseTimestamp(image.CreationDate)),
+		}
+		image := image
+		err := a.run.DeleteResource(ctx, cleanerImages, res, func() error {
+			return a.deleteImage(image)
+		})
+		if err != nil {
+			for _, id := range imageSnapshots(image) {
+				inUse[id] = true
+			}
+			errors.Append(microerror.Mask(err))
+			a.logger.Log("level", "error", "message", fmt.Sprintf("failed deregistering image %#q", *image.ImageId), "stack", fmt.Sprintf("%#v", err))
+		}
+	}
+
+	err = a.cleanImageSnapshots(ctx, inUse)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// deleteImage deregisters the given AMI and deletes its snapshots afterwards.
+// Deregistering is skipped when retrying after deleting a snapshot failed.
+func (a *Cleaner) deleteImage(image *ec2.Image) error {
+	o, err := a.ec2Client.DescribeImages(&ec2.DescribeImagesInput{
+		Filters: []*ec2.Filter{
+			{
+				Name:   aws.String("image-id"),
+				Values: []*string{image.ImageId},
+			},
+		},
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if len(o.Images) != 0 {
+		_, err := a.ec2Client.DeregisterImage(&ec2.DeregisterImageInput{ImageId: image.ImageId})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	for _, id := range imageSnapshots(image) {
+		_, err := a.ec2Client.DeleteSnapshot(&ec2.DeleteSnapshotInput{SnapshotId: aws.String(id)})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+// cleanImageSnapshots deletes CI tagged snapshots older than the AMI
+// retention which no kept AMI uses. inUse are the IDs of the snapshots
+// backing kept AMIs.
+func (a *Cleaner) cleanImageSnapshots(ctx context.Context, inUse map[string]bool) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &ec2.DescribeSnapshotsInput{
+		OwnerIds: []*string{aws.String("self")},
+	}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.ec2Client.DescribeSnapshots(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, snapshot := range o.Snapshots {
+			if !a.snapshotShouldBeDeleted(snapshot, inUse) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that snapshot %#q should be deleted", *snapshot.SnapshotId))
+
+			res := run.Resource{
+				ID:        *snapshot.SnapshotId,
+				Type:      "AWS::EC2::Snapshot",
+				Tags:      ec2Tags(snapshot.Tags),
+				CreatedAt: aws.TimeValue(snapshot.StartTime),
+			}
+			err := a.run.DeleteResource(ctx, cleanerImages, res, func() error {
+				_, err := a.ec2Client.DeleteSnapshot(&ec2.DeleteSnapshotInput{SnapshotId: snapshot.SnapshotId})
+				if err != nil {
+					return microerror.Mask(err)
+				}
+
+				return nil
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting snapshot %#q", *snapshot.SnapshotId), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) imageShouldBeDeleted(image *ec2.Image) bool {
+	if image.ImageId == nil {
+		return false
+	}
+
+	if !a.hasCIPrefix(aws.StringValue(image.Name)) && !a.isCITagged(ec2Tags(image.Tags)) {
+		return false
+	}
+
+	// do not delete images which are still in use by CI, nor images whose age
+	// is unknown.
+	if isRecent(parseTimestamp(image.CreationDate), a.amiRetention) {
+		return false
+	}
+
+	return true
+}
+
+func (a *Cleaner) snapshotShouldBeDeleted(snapshot *ec2.Snapshot, inUse map[string]bool) bool {
+	if snapshot.SnapshotId == nil || inUse[*snapshot.SnapshotId] {
+		return false
+	}
+
+	if !a.isCITagged(ec2Tags(snapshot.Tags)) {
+		return false
+	}
+
+	if isRecent(snapshot.StartTime, a.amiRetention) {
+		return false
+	}
+
+	return true
+}
+
+// imageSnapshots returns the IDs of the EBS snapshots backing the given AMI.
+func imageSnapshots(image *ec2.Image) []string {
+	var ids []string
+	for _, m := range image.BlockDeviceMappings {
+		if m.Ebs != nil && m.Ebs.SnapshotId != nil {
+			ids = append(ids, *m.Ebs.SnapshotId)
+		}
+	}
+
+	return ids
+}
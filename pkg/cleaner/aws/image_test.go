@@ -0,0 +1,123 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func TestImageShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		image       *ec2.Image
+		expected    bool
+		description string
+	}{
+		{
+			description: "old ci image should be deleted",
+			image:       newImage("ci-wip-a1b2c-node", time.Now().Add(-8*24*time.Hour)),
+			expected:    true,
+		},
+		{
+			description: "recent ci image should not be deleted",
+			image:       newImage("ci-wip-a1b2c-node", time.Now().Add(-2*24*time.Hour)),
+			expected:    false,
+		},
+		{
+			description: "ci image with malformed creation date should not be deleted",
+			image: &ec2.Image{
+				CreationDate: aws.String("yesterday"),
+				ImageId:      aws.String("ami-0123456789abcdef0"),
+				Name:         aws.String("ci-wip-a1b2c-node"),
+			},
+			expected: false,
+		},
+		{
+			description: "old general image should not be deleted",
+			image:       newImage("flatcar-stable", time.Now().Add(-8*24*time.Hour)),
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		amiRetention: defaultAMIRetention,
+		prefixes:     defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := a.imageShouldBeDeleted(tc.image)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.image.Name, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestSnapshotShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		snapshot    *ec2.Snapshot
+		expected    bool
+		description string
+	}{
+		{
+			description: "old unused ci snapshot should be deleted",
+			snapshot:    newSnapshot("snap-0001", "ci-wip-a1b2c-node", time.Now().Add(-8*24*time.Hour)),
+			expected:    true,
+		},
+		{
+			description: "old ci snapshot used by kept image should not be deleted",
+			snapshot:    newSnapshot("snap-0002", "ci-wip-a1b2c-node", time.Now().Add(-8*24*time.Hour)),
+			expected:    false,
+		},
+		{
+			description: "recent unused ci snapshot should not be deleted",
+			snapshot:    newSnapshot("snap-0001", "ci-wip-a1b2c-node", time.Now().Add(-2*24*time.Hour)),
+			expected:    false,
+		},
+		{
+			description: "old unused general snapshot should not be deleted",
+			snapshot:    newSnapshot("snap-0001", "backup", time.Now().Add(-8*24*time.Hour)),
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		amiRetention: defaultAMIRetention,
+		prefixes:     defaultPrefixes,
+	}
+
+	inUse := map[string]bool{
+		"snap-0002": true,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := a.snapshotShouldBeDeleted(tc.snapshot, inUse)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.snapshot.SnapshotId, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func newImage(name string, creationDate time.Time) *ec2.Image {
+	return &ec2.Image{
+		CreationDate: aws.String(creationDate.Format(time.RFC3339)),
+		ImageId:      aws.String("ami-0123456789abcdef0"),
+		Name:         aws.String(name),
+	}
+}
+
+func newSnapshot(id, name string, startTime time.Time) *ec2.Snapshot {
+	return &ec2.Snapshot{
+		SnapshotId: aws.String(id),
+		StartTime:  aws.Time(startTime),
+		Tags: []*ec2.Tag{
+			{Key: aws.String("Name"), Value: aws.String(name)},
+		},
+	}
+}
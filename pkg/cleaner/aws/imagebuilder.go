@@ -0,0 +1,392 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/imagebuilder"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanImageBuilder deletes the EC2 Image Builder pipelines, image recipes,
+// infrastructure configurations and distribution configurations Image
+// Builder e2e tests create. The images they produced are deleted once they
+// are older than the AMI retention, together with their AMIs and snapshots
+// in this region. Pipelines are deleted first, as they reference everything
+// else, and images before recipes, as recipes cannot be deleted while images
+// use them.
+func (a *Cleaner) cleanImageBuilder(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	err := a.cleanImagePipelines(ctx)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+	}
+
+	kept, err := a.cleanImageBuilderImages(ctx)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+	}
+
+	err = a.cleanImageRecipes(ctx, kept)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+	}
+
+	err = a.cleanInfrastructureConfigurations(ctx)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+	}
+
+	err = a.cleanDistributionConfigurations(ctx)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) cleanImagePipelines(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &imagebuilder.ListImagePipelinesInput{}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.imageBuilderClient.ListImagePipelines(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, pipeline := range o.ImagePipelineList {
+			if !a.imageBuilderResourceShouldBeDeleted(aws.StringValue(pipeline.Name), pipeline.Tags, pipeline.DateCreated, a.gracePeriod) {
+				continue
+			}
+
+			res := run.Resource{
+				ID:        *pipeline.Arn,
+				Type:      "AWS::ImageBuilder::ImagePipeline",
+				Tags:      aws.StringValueMap(pipeline.Tags),
+				CreatedAt: aws.TimeValue(parseTimestamp(pipeline.DateCreated)),
+			}
+			err := a.deleteImageBuilderResource(ctx, res, func() error {
+				_, err := a.imageBuilderClient.DeleteImagePipeline(&imagebuilder.DeleteImagePipelineInput{ImagePipelineArn: pipeline.Arn})
+				return err
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// cleanImageBuilderImages deletes the CI image build versions older than the
+// AMI retention. The names of the kept images are returned, so that their
+// recipes are kept as well.
+func (a *Cleaner) cleanImageBuilderImages(ctx context.Context) (map[string]bool, error) {
+	errors := &errorcollection.ErrorCollection{}
+	kept := map[string]bool{}
+
+	i := &imagebuilder.ListImagesInput{
+		Owner: aws.String(imagebuilder.OwnershipSelf),
+	}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.imageBuilderClient.ListImages(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, version := range o.ImageVersionList {
+			if !a.hasCIPrefix(aws.StringValue(version.Name)) {
+				continue
+			}
+
+			j := &imagebuilder.ListImageBuildVersionsInput{
+				ImageVersionArn: version.Arn,
+			}
+			err := paginate(ctx, &j.NextToken, func() (*string, error) {
+				p, err := a.imageBuilderClient.ListImageBuildVersions(j)
+				if err != nil {
+					return nil, microerror.Mask(err)
+				}
+
+				for _, image := range p.ImageSummaryList {
+					if !a.imageBuilderResourceShouldBeDeleted(aws.StringValue(image.Name), image.Tags, image.DateCreated, a.amiRetention) {
+						kept[aws.StringValue(image.Name)] = true
+						continue
+					}
+
+					res := run.Resource{
+						ID:        *image.Arn,
+						Type:      "AWS::ImageBuilder::Image",
+						Tags:      aws.StringValueMap(image.Tags),
+						CreatedAt: aws.TimeValue(parseTimestamp(image.DateCreated)),
+					}
+					image := image
+					err := a.deleteImageBuilderResource(ctx, res, func() error {
+						return a.deleteImageBuilderImage(image)
+					})
+					if err != nil {
+						errors.Append(microerror.Mask(err))
+						kept[aws.StringValue(image.Name)] = true
+					}
+				}
+
+				return p.NextToken, nil
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				kept[aws.StringValue(version.Name)] = true
+				break
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return nil, errors
+	}
+
+	if errors.HasErrors() {
+		return kept, errors
+	}
+	return kept, nil
+}
+
+// deleteImageBuilderImage deregisters the AMIs the given image build version
+// produced in this region, deletes their snapshots and the image build
+// version itself.
+func (a *Cleaner) deleteImageBuilderImage(image *imagebuilder.ImageSummary) error {
+	if image.OutputResources != nil {
+		var ids []*string
+		for _, ami := range image.OutputResources.Amis {
+			ids = append(ids, ami.Image)
+		}
+
+		if len(ids) != 0 {
+			i := &ec2.DescribeImagesInput{
+				Filters: []*ec2.Filter{
+					{
+						Name:   aws.String("image-id"),
+						Values: ids,
+					},
+				},
+			}
+
+			o, err := a.ec2Client.DescribeImages(i)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			for _, ami := range o.Images {
+				err := a.deleteImage(ami)
+				if err != nil {
+					return microerror.Mask(err)
+				}
+			}
+		}
+	}
+
+	_, err := a.imageBuilderClient.DeleteImage(&imagebuilder.DeleteImageInput{ImageBuildVersionArn: image.Arn})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// cleanImageRecipes deletes CI image recipes, except for the ones of kept
+// images.
+func (a *Cleaner) cleanImageRecipes(ctx context.Context, kept map[string]bool) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &imagebuilder.ListImageRecipesInput{
+		Owner: aws.String(imagebuilder.OwnershipSelf),
+	}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.imageBuilderClient.ListImageRecipes(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, recipe := range o.ImageRecipeSummaryList {
+			if kept[aws.StringValue(recipe.Name)] {
+				continue
+			}
+			if !a.imageBuilderResourceShouldBeDeleted(aws.StringValue(recipe.Name), recipe.Tags, recipe.DateCreated, a.gracePeriod) {
+				continue
+			}
+
+			res := run.Resource{
+				ID:        *recipe.Arn,
+				Type:      "AWS::ImageBuilder::ImageRecipe",
+				Tags:      aws.StringValueMap(recipe.Tags),
+				CreatedAt: aws.TimeValue(parseTimestamp(recipe.DateCreated)),
+			}
+			err := a.deleteImageBuilderResource(ctx, res, func() error {
+				_, err := a.imageBuilderClient.DeleteImageRecipe(&imagebuilder.DeleteImageRecipeInput{ImageRecipeArn: recipe.Arn})
+				return err
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) cleanInfrastructureConfigurations(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &imagebuilder.ListInfrastructureConfigurationsInput{}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.imageBuilderClient.ListInfrastructureConfigurations(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, c := range o.InfrastructureConfigurationSummaryList {
+			if !a.imageBuilderResourceShouldBeDeleted(aws.StringValue(c.Name), c.Tags, c.DateCreated, a.gracePeriod) {
+				continue
+			}
+
+			res := run.Resource{
+				ID:        *c.Arn,
+				Type:      "AWS::ImageBuilder::InfrastructureConfiguration",
+				Tags:      aws.StringValueMap(c.Tags),
+				CreatedAt: aws.TimeValue(parseTimestamp(c.DateCreated)),
+			}
+			arn := c.Arn
+			err := a.deleteImageBuilderResource(ctx, res, func() error {
+				_, err := a.imageBuilderClient.DeleteInfrastructureConfiguration(&imagebuilder.DeleteInfrastructureConfigurationInput{InfrastructureConfigurationArn: arn})
+				return err
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) cleanDistributionConfigurations(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &imagebuilder.ListDistributionConfigurationsInput{}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.imageBuilderClient.ListDistributionConfigurations(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, c := range o.DistributionConfigurationSummaryList {
+			if !a.imageBuilderResourceShouldBeDeleted(aws.StringValue(c.Name), c.Tags, c.DateCreated, a.gracePeriod) {
+				continue
+			}
+
+			res := run.Resource{
+				ID:        *c.Arn,
+				Type:      "AWS::ImageBuilder::DistributionConfiguration",
+				Tags:      aws.StringValueMap(c.Tags),
+				CreatedAt: aws.TimeValue(parseTimestamp(c.DateCreated)),
+			}
+			arn := c.Arn
+			err := a.deleteImageBuilderResource(ctx, res, func() error {
+				_, err := a.imageBuilderClient.DeleteDistributionConfiguration(&imagebuilder.DeleteDistributionConfigurationInput{DistributionConfigurationArn: arn})
+				return err
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// deleteImageBuilderResource deletes the given Image Builder resource using
+// fn and logs the outcome.
+func (a *Cleaner) deleteImageBuilderResource(ctx context.Context, res run.Resource, fn func() error) error {
+	a.logger.Log("level", "info", "message", fmt.Sprintf("found that image builder resource %#q should be deleted", res.ID))
+
+	err := a.run.DeleteResource(ctx, cleanerImageBuilder, res, func() error {
+		err := fn()
+		if isAWSError(err, imagebuilder.ErrCodeResourceNotFoundException) {
+			return nil
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting image builder resource %#q", res.ID), "stack", fmt.Sprintf("%#v", err))
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// imageBuilderResourceShouldBeDeleted decides about all Image Builder
+// resources alike, given their name, tags, creation time and the age at
+// which they should be deleted.
+func (a *Cleaner) imageBuilderResourceShouldBeDeleted(name string, tags map[string]*string, dateCreated *string, age time.Duration) bool {
+	if !a.hasCIPrefix(name) && !a.isCITagged(aws.StringValueMap(tags)) {
+		return false
+	}
+
+	// do not delete recent resources, nor resources whose age is unknown.
+	if isRecent(parseTimestamp(dateCreated), age) {
+		return false
+	}
+
+	return true
+}
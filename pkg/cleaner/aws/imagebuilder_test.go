@@ -0,0 +1,80 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestImageBuilderResourceShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		name        string
+		tags        map[string]*string
+		dateCreated time.Time
+		age         time.Duration
+		expected    bool
+		description string
+	}{
+		{
+			description: "old ci pipeline should be deleted",
+			name:        "ci-wip-a1b2c-node",
+			dateCreated: time.Now().Add(-2 * time.Hour),
+			age:         defaultGracePeriod,
+			expected:    true,
+		},
+		{
+			description: "old recipe of ci cluster should be deleted",
+			name:        "node",
+			tags:        map[string]*string{clusterTag: aws.String("ci-a1b2c")},
+			dateCreated: time.Now().Add(-2 * time.Hour),
+			age:         defaultGracePeriod,
+			expected:    true,
+		},
+		{
+			description: "recent ci pipeline should not be deleted",
+			name:        "ci-wip-a1b2c-node",
+			dateCreated: time.Now().Add(-time.Hour),
+			age:         defaultGracePeriod,
+			expected:    false,
+		},
+		{
+			description: "ci image within ami retention should not be deleted",
+			name:        "ci-wip-a1b2c-node",
+			dateCreated: time.Now().Add(-48 * time.Hour),
+			age:         defaultAMIRetention,
+			expected:    false,
+		},
+		{
+			description: "ci pipeline without creation time should not be deleted",
+			name:        "ci-wip-a1b2c-node",
+			age:         defaultGracePeriod,
+			expected:    false,
+		},
+		{
+			description: "old general pipeline should not be deleted",
+			name:        "flatcar",
+			dateCreated: time.Now().Add(-2 * time.Hour),
+			age:         defaultGracePeriod,
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		prefixes: defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			var dateCreated *string
+			if !tc.dateCreated.IsZero() {
+				dateCreated = aws.String(tc.dateCreated.Format(time.RFC3339))
+			}
+			actual := a.imageBuilderResourceShouldBeDeleted(tc.name, tc.tags, dateCreated, tc.age)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", tc.name, tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanInstances terminates CI instances which outlived their CloudFormation
+// stack, e.g. because deleting the stack failed halfway. Instances with
+// termination protection enabled are logged and left alone, as somebody
+// enabled it on purpose. Instances with accelerators are left to
+// cleanAcceleratorInstances.
+func (a *Cleaner) cleanInstances(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	var nextToken *string
+	err := paginate(ctx, &nextToken, func() (*string, error) {
+		i := &ec2.DescribeInstancesInput{
+			Filters: []*ec2.Filter{
+				{
+					Name: aws.String("instance-state-name"),
+					Values: []*string{
+						aws.String(ec2.InstanceStateNamePending),
+						aws.String(ec2.InstanceStateNameRunning),
+						aws.String(ec2.InstanceStateNameStopping),
+						aws.String(ec2.InstanceStateNameStopped),
+					},
+				},
+			},
+			NextToken: nextToken,
+		}
+
+		o, err := a.ec2Client.DescribeInstances(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, reservation := range o.Reservations {
+			for _, instance := range reservation.Instances {
+				if !a.instanceShouldBeDeleted(instance) {
+					continue
+				}
+
+				err := a.deleteInstance(ctx, instance)
+				if err != nil {
+					errors.Append(microerror.Mask(err))
+					a.logger.Log("level", "error", "message", fmt.Sprintf("failed terminating instance %#q", *instance.InstanceId), "stack", fmt.Sprintf("%#v", err))
+				}
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) deleteInstance(ctx context.Context, instance *ec2.Instance) error {
+	protected, err := a.hasTerminationProtection(instance.InstanceId)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if protected {
+		a.logger.Log("level", "warning", "message", fmt.Sprintf("found that instance %#q should be terminated, but skipping it as termination protection is enabled", *instance.InstanceId))
+		return nil
+	}
+
+	a.logger.Log("level", "info", "message", fmt.Sprintf("found that instance %#q should be terminated", *instance.InstanceId))
+
+	res := run.Resource{
+		ID:        *instance.InstanceId,
+		Type:      "AWS::EC2::Instance",
+		Tags:      ec2Tags(instance.Tags),
+		CreatedAt: aws.TimeValue(instance.LaunchTime),
+	}
+	if instance.Placement != nil && instance.Placement.AvailabilityZone != nil {
+		az := *instance.Placement.AvailabilityZone
+		res.Region = az[:len(az)-1]
+	}
+
+	err = a.run.DeleteResource(ctx, cleanerInstances, res, func() error {
+		i := &ec2.TerminateInstancesInput{
+			InstanceIds: []*string{instance.InstanceId},
+		}
+
+		_, err := a.ec2Client.TerminateInstances(i)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (a *Cleaner) hasTerminationProtection(id *string) (bool, error) {
+	i := &ec2.DescribeInstanceAttributeInput{
+		Attribute:  aws.String(ec2.InstanceAttributeNameDisableApiTermination),
+		InstanceId: id,
+	}
+
+	o, err := a.ec2Client.DescribeInstanceAttribute(i)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	return o.DisableApiTermination != nil && aws.BoolValue(o.DisableApiTermination.Value), nil
+}
+
+func (a *Cleaner) instanceShouldBeDeleted(instance *ec2.Instance) bool {
+	if instance.InstanceId == nil {
+		return false
+	}
+
+	// instances with accelerators are terminated by their own cleaner.
+	if isAcceleratorInstance(instance) {
+		return false
+	}
+
+	// do not delete instances that are already being terminated.
+	if instance.State != nil {
+		switch aws.StringValue(instance.State.Name) {
+		case ec2.InstanceStateNameShuttingDown, ec2.InstanceStateNameTerminated:
+			return false
+		}
+	}
+
+	// do not delete recent instances.
+	if isRecent(instance.LaunchTime, a.gracePeriod) {
+		return false
+	}
+
+	return a.isCITagged(ec2Tags(instance.Tags))
+}
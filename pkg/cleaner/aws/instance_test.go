@@ -0,0 +1,67 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func TestInstanceShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		instance    *ec2.Instance
+		expected    bool
+		description string
+	}{
+		{
+			description: "old ci instance should be deleted",
+			instance:    newInstance("ci-wip-a1b2c-worker", "m5.xlarge", ec2.InstanceStateNameRunning, time.Now().Add(-2*time.Hour)),
+			expected:    true,
+		},
+		{
+			description: "old instance with ci cluster tag should be deleted",
+			instance: func() *ec2.Instance {
+				i := newInstance("worker", "m5.xlarge", ec2.InstanceStateNameStopped, time.Now().Add(-2*time.Hour))
+				i.Tags = append(i.Tags, &ec2.Tag{Key: aws.String(clusterTag), Value: aws.String("ci-a1b2c")})
+				return i
+			}(),
+			expected: true,
+		},
+		{
+			description: "recent ci instance should not be deleted",
+			instance:    newInstance("ci-wip-a1b2c-worker", "m5.xlarge", ec2.InstanceStateNameRunning, time.Now().Add(-time.Hour)),
+			expected:    false,
+		},
+		{
+			description: "old ci gpu instance should be left to the accelerator cleaner",
+			instance:    newInstance("ci-wip-a1b2c-worker", "p3.2xlarge", ec2.InstanceStateNameRunning, time.Now().Add(-2*time.Hour)),
+			expected:    false,
+		},
+		{
+			description: "terminating ci instance should not be deleted",
+			instance:    newInstance("ci-wip-a1b2c-worker", "m5.xlarge", ec2.InstanceStateNameShuttingDown, time.Now().Add(-2*time.Hour)),
+			expected:    false,
+		},
+		{
+			description: "old general instance should not be deleted",
+			instance:    newInstance("bastion", "m5.xlarge", ec2.InstanceStateNameRunning, time.Now().Add(-2*time.Hour)),
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := a.instanceShouldBeDeleted(tc.instance)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.instance.InstanceId, tc.expected, actual)
+			}
+		})
+	}
+}
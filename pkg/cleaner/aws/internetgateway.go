@@ -0,0 +1,102 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanInternetGateways detaches CI internet gateways from their VPCs and
+// deletes them. They stay attached to half-deleted VPCs when deleting a
+// CloudFormation stack fails midway, keeping the VPC from being deleted.
+func (a *Cleaner) cleanInternetGateways(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &ec2.DescribeInternetGatewaysInput{}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.ec2Client.DescribeInternetGateways(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, igw := range o.InternetGateways {
+			if !a.isCIInternetGateway(igw) {
+				continue
+			}
+
+			seen, err := a.run.FirstSeen(cleanerInternetGateways, *igw.InternetGatewayId)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				continue
+			}
+
+			// do not delete recent internet gateways.
+			if time.Since(seen) < a.gracePeriod {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that internet gateway %#q should be deleted", *igw.InternetGatewayId))
+
+			res := run.Resource{
+				ID:   *igw.InternetGatewayId,
+				Type: kindInternetGateway,
+				Tags: ec2Tags(igw.Tags),
+			}
+			igw := igw
+			err = a.run.DeleteResource(ctx, cleanerInternetGateways, res, func() error {
+				return a.deleteInternetGateway(igw)
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting internet gateway %#q", *igw.InternetGatewayId), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// deleteInternetGateway detaches the given internet gateway from all its VPCs
+// and deletes it.
+func (a *Cleaner) deleteInternetGateway(igw *ec2.InternetGateway) error {
+	for _, attachment := range igw.Attachments {
+		i := &ec2.DetachInternetGatewayInput{
+			InternetGatewayId: igw.InternetGatewayId,
+			VpcId:             attachment.VpcId,
+		}
+		_, err := a.ec2Client.DetachInternetGateway(i)
+		if err != nil && !isAWSError(err, "Gateway.NotAttached") {
+			return microerror.Mask(err)
+		}
+	}
+
+	_, err := a.ec2Client.DeleteInternetGateway(&ec2.DeleteInternetGatewayInput{InternetGatewayId: igw.InternetGatewayId})
+	if err != nil && !isAWSError(err, "InvalidInternetGatewayID.NotFound") {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (a *Cleaner) isCIInternetGateway(igw *ec2.InternetGateway) bool {
+	if igw.InternetGatewayId == nil {
+		return false
+	}
+
+	return a.isCITagged(ec2Tags(igw.Tags))
+}
@@ -0,0 +1,51 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func TestIsCIInternetGateway(t *testing.T) {
+	tcs := []struct {
+		tags        []*ec2.Tag
+		expected    bool
+		description string
+	}{
+		{
+			description: "internet gateway named after ci cluster should be deleted",
+			tags:        []*ec2.Tag{{Key: aws.String("Name"), Value: aws.String("ci-wip-a1b2c-igw")}},
+			expected:    true,
+		},
+		{
+			description: "internet gateway tagged with ci cluster should be deleted",
+			tags:        []*ec2.Tag{{Key: aws.String(clusterTag), Value: aws.String("ci-wip-a1b2c")}},
+			expected:    true,
+		},
+		{
+			description: "general internet gateway should not be deleted",
+			tags:        []*ec2.Tag{{Key: aws.String("Name"), Value: aws.String("installation-igw")}},
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		prefixes: defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			igw := &ec2.InternetGateway{
+				InternetGatewayId: aws.String("igw-0123456789abcdef0"),
+				Tags:              tc.tags,
+			}
+
+			actual := a.isCIInternetGateway(igw)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *igw.InternetGatewayId, tc.expected, actual)
+			}
+		})
+	}
+}
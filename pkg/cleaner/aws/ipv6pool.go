@@ -0,0 +1,164 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanIPv6Pools withdraws and deprovisions the BYOIP CIDRs of the IPv6 pools
+// our dual-stack e2e tests provision, which removes the pools along with
+// them. CIDRs cannot be deprovisioned while VPCs are still assigned blocks of
+// them, which is why this runs after the VPCs are torn down. The CIDRs are only
+// reported unless deprovisioning them is enabled, as they cannot be used again
+// before provisioning them anew.
+func (a *Cleaner) cleanIPv6Pools(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	var byoipCIDRs map[string]*ec2.ByoipCidr
+	var pools []*ec2.Ipv6Pool
+	{
+		i := &ec2.DescribeIpv6PoolsInput{}
+		err := paginate(ctx, &i.NextToken, func() (*string, error) {
+			o, err := a.ec2Client.DescribeIpv6Pools(i)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			for _, pool := range o.Ipv6Pools {
+				if !a.isCIIPv6Pool(pool) {
+					continue
+				}
+				pools = append(pools, pool)
+			}
+
+			return o.NextToken, nil
+		})
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			return errors
+		}
+	}
+
+	// BYOIP CIDRs are only listed when there are CI pools, as most
+	// accounts do not have any.
+	if len(pools) != 0 {
+		var err error
+		byoipCIDRs, err = a.byoipCIDRs(ctx)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			return errors
+		}
+	}
+
+	for _, pool := range pools {
+		seen, err := a.run.FirstSeen(cleanerIPv6Pools, *pool.PoolId)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			continue
+		}
+
+		// do not delete recent pools.
+		if time.Since(seen) < a.gracePeriod {
+			continue
+		}
+
+		for _, block := range pool.PoolCidrBlocks {
+			cidr, ok := byoipCIDRs[aws.StringValue(block.Cidr)]
+			if !ok {
+				continue
+			}
+
+			res := run.Resource{
+				ID:   *pool.PoolId + "/" + *cidr.Cidr,
+				Type: "AWS::EC2::ByoipCidr",
+				Tags: ec2Tags(pool.Tags),
+			}
+
+			if !a.deprovisionBYOIPCIDRs {
+				a.logger.Log("level", "warning", "message", fmt.Sprintf("found BYOIP CIDR %#q of IPv6 pool %#q, which has to be deprovisioned manually", *cidr.Cidr, *pool.PoolId))
+				a.run.Report(ctx, cleanerIPv6Pools, res)
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that BYOIP CIDR %#q of IPv6 pool %#q should be deprovisioned", *cidr.Cidr, *pool.PoolId))
+			err = a.run.DeleteResource(ctx, cleanerIPv6Pools, res, func() error {
+				return a.deprovisionBYOIPCIDR(cidr)
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deprovisioning BYOIP CIDR %#q", *cidr.Cidr), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// byoipCIDRs returns all BYOIP CIDRs of the region by CIDR.
+func (a *Cleaner) byoipCIDRs(ctx context.Context) (map[string]*ec2.ByoipCidr, error) {
+	cidrs := map[string]*ec2.ByoipCidr{}
+
+	i := &ec2.DescribeByoipCidrsInput{
+		MaxResults: aws.Int64(100),
+	}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.ec2Client.DescribeByoipCidrs(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, c := range o.ByoipCidrs {
+			if c.Cidr != nil {
+				cidrs[*c.Cidr] = c
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return cidrs, nil
+}
+
+// deprovisionBYOIPCIDR stops advertising the given CIDR and deprovisions it.
+// Deprovisioning fails until the withdrawal finished, in which case a later
+// attempt succeeds.
+func (a *Cleaner) deprovisionBYOIPCIDR(cidr *ec2.ByoipCidr) error {
+	switch aws.StringValue(cidr.State) {
+	case ec2.ByoipCidrStateDeprovisioned, ec2.ByoipCidrStatePendingDeprovision:
+		return nil
+	case ec2.ByoipCidrStateAdvertised:
+		_, err := a.ec2Client.WithdrawByoipCidr(&ec2.WithdrawByoipCidrInput{Cidr: cidr.Cidr})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	_, err := a.ec2Client.DeprovisionByoipCidr(&ec2.DeprovisionByoipCidrInput{Cidr: cidr.Cidr})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (a *Cleaner) isCIIPv6Pool(pool *ec2.Ipv6Pool) bool {
+	if pool.PoolId == nil {
+		return false
+	}
+
+	return a.isCITagged(ec2Tags(pool.Tags))
+}
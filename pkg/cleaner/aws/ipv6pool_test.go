@@ -0,0 +1,206 @@
+package aws
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/giantswarm/micrologger/microloggertest"
+
+	"github.com/giantswarm/ci-cleaner/pkg/report"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+	"github.com/giantswarm/ci-cleaner/pkg/state"
+)
+
+type byoipEC2ClientMock struct {
+	EC2Client
+
+	pools []*ec2.Ipv6Pool
+	cidrs []*ec2.ByoipCidr
+	calls []string
+}
+
+func (c *byoipEC2ClientMock) DescribeIpv6Pools(*ec2.DescribeIpv6PoolsInput) (*ec2.DescribeIpv6PoolsOutput, error) {
+	return &ec2.DescribeIpv6PoolsOutput{Ipv6Pools: c.pools}, nil
+}
+
+func (c *byoipEC2ClientMock) DescribeByoipCidrs(*ec2.DescribeByoipCidrsInput) (*ec2.DescribeByoipCidrsOutput, error) {
+	return &ec2.DescribeByoipCidrsOutput{ByoipCidrs: c.cidrs}, nil
+}
+
+func (c *byoipEC2ClientMock) DeprovisionByoipCidr(*ec2.DeprovisionByoipCidrInput) (*ec2.DeprovisionByoipCidrOutput, error) {
+	c.calls = append(c.calls, "deprovision")
+	return &ec2.DeprovisionByoipCidrOutput{}, nil
+}
+
+func (c *byoipEC2ClientMock) WithdrawByoipCidr(*ec2.WithdrawByoipCidrInput) (*ec2.WithdrawByoipCidrOutput, error) {
+	c.calls = append(c.calls, "withdraw")
+	return &ec2.WithdrawByoipCidrOutput{}, nil
+}
+
+func TestIsCIIPv6Pool(t *testing.T) {
+	tcs := []struct {
+		tags        []*ec2.Tag
+		expected    bool
+		description string
+	}{
+		{
+			description: "ipv6 pool tagged with ci cluster should be deleted",
+			tags:        []*ec2.Tag{{Key: aws.String(clusterTag), Value: aws.String("ci-wip-a1b2c")}},
+			expected:    true,
+		},
+		{
+			description: "general ipv6 pool should not be deleted",
+			tags:        []*ec2.Tag{{Key: aws.String("Name"), Value: aws.String("office")}},
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		prefixes: defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			pool := &ec2.Ipv6Pool{
+				PoolId: aws.String("ipv6pool-ec2-0123456789abcdef0"),
+				Tags:   tc.tags,
+			}
+
+			actual := a.isCIIPv6Pool(pool)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *pool.PoolId, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestDeprovisionBYOIPCIDR(t *testing.T) {
+	tcs := []struct {
+		state       string
+		expected    []string
+		description string
+	}{
+		{
+			description: "advertised cidr is withdrawn first",
+			state:       ec2.ByoipCidrStateAdvertised,
+			expected:    []string{"withdraw", "deprovision"},
+		},
+		{
+			description: "provisioned cidr is deprovisioned",
+			state:       ec2.ByoipCidrStateProvisioned,
+			expected:    []string{"deprovision"},
+		},
+		{
+			description: "cidr being deprovisioned is left alone",
+			state:       ec2.ByoipCidrStatePendingDeprovision,
+			expected:    nil,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			client := &byoipEC2ClientMock{}
+			a := &Cleaner{
+				ec2Client: client,
+			}
+
+			err := a.deprovisionBYOIPCIDR(&ec2.ByoipCidr{Cidr: aws.String("2001:db8::/48"), State: aws.String(tc.state)})
+			if err != nil {
+				t.Fatalf("expected nil, got %#v", err)
+			}
+
+			if len(client.calls) != len(tc.expected) {
+				t.Fatalf("want calls %v, got %v", tc.expected, client.calls)
+			}
+			for i := range tc.expected {
+				if client.calls[i] != tc.expected[i] {
+					t.Errorf("want calls %v, got %v", tc.expected, client.calls)
+				}
+			}
+		})
+	}
+}
+
+func TestCleanIPv6Pools(t *testing.T) {
+	tcs := []struct {
+		deprovision   bool
+		expectedCalls []string
+		expected      report.Action
+		description   string
+	}{
+		{
+			description:   "cidr is only reported by default",
+			deprovision:   false,
+			expectedCalls: nil,
+			expected:      report.ActionReported,
+		},
+		{
+			description:   "cidr is deprovisioned when enabled",
+			deprovision:   true,
+			expectedCalls: []string{"withdraw", "deprovision"},
+			expected:      report.ActionDeleted,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			client := &byoipEC2ClientMock{
+				pools: []*ec2.Ipv6Pool{
+					{
+						PoolId:         aws.String("ipv6pool-ec2-0123456789abcdef0"),
+						PoolCidrBlocks: []*ec2.PoolCidrBlock{{Cidr: aws.String("2001:db8::/48")}},
+						Tags:           []*ec2.Tag{{Key: aws.String(clusterTag), Value: aws.String("ci-wip-a1b2c")}},
+					},
+				},
+				cidrs: []*ec2.ByoipCidr{
+					{Cidr: aws.String("2001:db8::/48"), State: aws.String(ec2.ByoipCidrStateAdvertised)},
+				},
+			}
+
+			stateStore, err := state.New(state.Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = stateStore.Put(run.SeenKeyPrefix+cleanerIPv6Pools+"/ipv6pool-ec2-0123456789abcdef0", time.Now().Add(-2*time.Hour))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rep := report.New("aws")
+			r, err := run.New(run.Config{
+				Logger: microloggertest.New(),
+				Report: rep,
+				State:  stateStore,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			a := &Cleaner{
+				ec2Client:             client,
+				gracePeriod:           defaultGracePeriod,
+				logger:                microloggertest.New(),
+				prefixes:              defaultPrefixes,
+				run:                   r,
+				deprovisionBYOIPCIDRs: tc.deprovision,
+			}
+
+			err = a.cleanIPv6Pools(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(client.calls) != len(tc.expectedCalls) {
+				t.Fatalf("want calls %v, got %v", tc.expectedCalls, client.calls)
+			}
+			items := rep.ByCleaner()[cleanerIPv6Pools]
+			if len(items) != 1 || items[0].Action != tc.expected {
+				t.Errorf("want one %s item, got %v", tc.expected, items)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanKeyPairs deletes the CI key pairs which no instance references
+// anymore. Every e2e run creates its own key pair, which is left behind
+// whenever the run does not get to clean up after itself.
+func (a *Cleaner) cleanKeyPairs(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	inUse, err := a.keyPairsInUse(ctx)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	o, err := a.ec2Client.DescribeKeyPairs(&ec2.DescribeKeyPairsInput{})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	for _, keyPair := range o.KeyPairs {
+		if !a.keyPairShouldBeDeleted(keyPair, inUse) {
+			continue
+		}
+
+		a.logger.Log("level", "info", "message", fmt.Sprintf("found that key pair %#q should be deleted", *keyPair.KeyName))
+
+		res := run.Resource{
+			ID:        *keyPair.KeyName,
+			Type:      "AWS::EC2::KeyPair",
+			Tags:      ec2Tags(keyPair.Tags),
+			CreatedAt: aws.TimeValue(keyPair.CreateTime),
+			Reason:    run.ReasonUnused,
+		}
+		keyPair := keyPair
+		err := a.run.DeleteResource(ctx, cleanerKeyPairs, res, func() error {
+			_, err := a.ec2Client.DeleteKeyPair(&ec2.DeleteKeyPairInput{KeyPairId: keyPair.KeyPairId})
+			if err != nil && !isAWSError(err, "InvalidKeyPair.NotFound") {
+				return microerror.Mask(err)
+			}
+
+			return nil
+		})
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting key pair %#q", *keyPair.KeyName), "stack", fmt.Sprintf("%#v", err))
+		}
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// keyPairsInUse returns the names of the key pairs referenced by instances
+// which are not terminated. Stopped instances count as well, as they cannot
+// be accessed anymore without their key pair once started again.
+func (a *Cleaner) keyPairsInUse(ctx context.Context) (map[string]bool, error) {
+	inUse := map[string]bool{}
+
+	i := &ec2.DescribeInstancesInput{}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.ec2Client.DescribeInstances(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, r := range o.Reservations {
+			for _, instance := range r.Instances {
+				if instance.State != nil && aws.StringValue(instance.State.Name) == ec2.InstanceStateNameTerminated {
+					continue
+				}
+				if instance.KeyName != nil {
+					inUse[*instance.KeyName] = true
+				}
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return inUse, nil
+}
+
+func (a *Cleaner) keyPairShouldBeDeleted(keyPair *ec2.KeyPairInfo, inUse map[string]bool) bool {
+	if keyPair.KeyName == nil || !a.hasCIPrefix(*keyPair.KeyName) {
+		return false
+	}
+
+	// do not delete key pairs of instances.
+	if inUse[*keyPair.KeyName] {
+		return false
+	}
+
+	// do not delete recent key pairs.
+	if isRecent(keyPair.CreateTime, a.gracePeriod) {
+		return false
+	}
+
+	return true
+}
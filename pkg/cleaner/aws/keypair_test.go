@@ -0,0 +1,65 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func TestKeyPairShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		name        string
+		created     time.Time
+		expected    bool
+		description string
+	}{
+		{
+			description: "old unused ci key pair should be deleted",
+			name:        "ci-wip-a1b2c",
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    true,
+		},
+		{
+			description: "old ci key pair of instance should not be deleted",
+			name:        "ci-wip-d3e4f",
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    false,
+		},
+		{
+			description: "recent unused ci key pair should not be deleted",
+			name:        "e2e-a1b2c",
+			created:     time.Now().Add(-time.Hour),
+			expected:    false,
+		},
+		{
+			description: "old unused general key pair should not be deleted",
+			name:        "bastion",
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	inUse := map[string]bool{"ci-wip-d3e4f": true}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			keyPair := &ec2.KeyPairInfo{
+				CreateTime: aws.Time(tc.created),
+				KeyName:    aws.String(tc.name),
+			}
+
+			actual := a.keyPairShouldBeDeleted(keyPair, inUse)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", tc.name, tc.expected, actual)
+			}
+		})
+	}
+}
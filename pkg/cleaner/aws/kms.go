@@ -0,0 +1,177 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+const (
+	kmsAliasPrefix = "alias/"
+	// kmsPendingWindowInDays is the minimum waiting period before KMS
+	// deletes a key scheduled for deletion.
+	kmsPendingWindowInDays = 7
+)
+
+// cleanKMSKeys deletes the aliases of the KMS keys CI clusters create, like
+// `alias/ci-wip-a1b2c`, after disabling their keys and scheduling them for
+// deletion with the minimum waiting period. KMS keys cannot be deleted right
+// away and are billed until they are gone.
+func (a *Cleaner) cleanKMSKeys(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	// scheduled holds the IDs of the keys scheduled for deletion in this
+	// run, as a key may have several aliases.
+	scheduled := map[string]bool{}
+
+	i := &kms.ListAliasesInput{}
+	err := paginate(ctx, &i.Marker, func() (*string, error) {
+		o, err := a.kmsClient.ListAliases(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, alias := range o.Aliases {
+			if !a.kmsAliasShouldBeDeleted(alias) {
+				continue
+			}
+
+			if alias.TargetKeyId != nil && !scheduled[*alias.TargetKeyId] {
+				err := a.scheduleKMSKeyDeletion(ctx, alias.TargetKeyId)
+				if err != nil {
+					errors.Append(microerror.Mask(err))
+					a.logger.Log("level", "error", "message", fmt.Sprintf("failed scheduling deletion of kms key %#q", *alias.TargetKeyId), "stack", fmt.Sprintf("%#v", err))
+					continue
+				}
+				scheduled[*alias.TargetKeyId] = true
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that kms alias %#q should be deleted", *alias.AliasName))
+
+			res := run.Resource{
+				ID:        *alias.AliasArn,
+				Type:      "AWS::KMS::Alias",
+				CreatedAt: aws.TimeValue(alias.CreationDate),
+			}
+			alias := alias
+			err := a.run.DeleteResource(ctx, cleanerKMSKeys, res, func() error {
+				_, err := a.kmsClient.DeleteAlias(&kms.DeleteAliasInput{AliasName: alias.AliasName})
+				return err
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting kms alias %#q", *alias.AliasName), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		if !aws.BoolValue(o.Truncated) {
+			return nil, nil
+		}
+		return o.NextMarker, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// scheduleKMSKeyDeletion disables the KMS key with the given ID and schedules
+// its deletion, unless it is managed by AWS or already pending deletion.
+func (a *Cleaner) scheduleKMSKeyDeletion(ctx context.Context, id *string) error {
+	o, err := a.kmsClient.DescribeKey(&kms.DescribeKeyInput{KeyId: id})
+	if isAWSError(err, kms.ErrCodeNotFoundException) {
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	key := o.KeyMetadata
+	if !kmsKeyShouldBeDeleted(key) {
+		return nil
+	}
+
+	a.logger.Log("level", "info", "message", fmt.Sprintf("found that kms key %#q should be scheduled for deletion", *key.KeyId))
+
+	res := run.Resource{
+		ID:          *key.Arn,
+		Type:        "AWS::KMS::Key",
+		CreatedAt:   aws.TimeValue(key.CreationDate),
+		Cost:        "customer managed KMS key, billed monthly until deleted",
+		MonthlyCost: kmsKeyMonthlyCost,
+		Reason:      run.ReasonUnused,
+	}
+	err = a.run.DeleteResource(ctx, cleanerKMSKeys, res, func() error {
+		if aws.StringValue(key.KeyState) == kms.KeyStateEnabled {
+			_, err := a.kmsClient.DisableKey(&kms.DisableKeyInput{KeyId: key.KeyId})
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+
+		i := &kms.ScheduleKeyDeletionInput{
+			KeyId:               key.KeyId,
+			PendingWindowInDays: aws.Int64(kmsPendingWindowInDays),
+		}
+
+		_, err := a.kmsClient.ScheduleKeyDeletion(i)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (a *Cleaner) kmsAliasShouldBeDeleted(alias *kms.AliasListEntry) bool {
+	if alias.AliasArn == nil || alias.AliasName == nil {
+		return false
+	}
+
+	if !a.hasCIPrefix(strings.TrimPrefix(*alias.AliasName, kmsAliasPrefix)) {
+		return false
+	}
+
+	// do not delete recent aliases.
+	if isRecent(alias.CreationDate, a.gracePeriod) {
+		return false
+	}
+
+	return true
+}
+
+// kmsKeyShouldBeDeleted checks if the given key of a CI alias can be
+// scheduled for deletion.
+func kmsKeyShouldBeDeleted(key *kms.KeyMetadata) bool {
+	if key == nil || key.KeyId == nil || key.Arn == nil {
+		return false
+	}
+
+	if aws.StringValue(key.KeyManager) != kms.KeyManagerTypeCustomer {
+		return false
+	}
+
+	// do not schedule keys which are already being deleted.
+	switch aws.StringValue(key.KeyState) {
+	case kms.KeyStatePendingDeletion, kms.KeyStatePendingReplicaDeletion:
+		return false
+	}
+
+	return true
+}
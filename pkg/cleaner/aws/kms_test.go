@@ -0,0 +1,109 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/kms"
+)
+
+func TestKMSAliasShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		name        string
+		created     time.Time
+		expected    bool
+		description string
+	}{
+		{
+			description: "old ci alias should be deleted",
+			name:        "alias/ci-wip-a1b2c",
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    true,
+		},
+		{
+			description: "recent ci alias should not be deleted",
+			name:        "alias/ci-wip-a1b2c",
+			created:     time.Now().Add(-time.Hour),
+			expected:    false,
+		},
+		{
+			description: "old aws managed alias should not be deleted",
+			name:        "alias/aws/ebs",
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			alias := &kms.AliasListEntry{
+				AliasArn:     aws.String("arn:aws:kms:eu-central-1:123456789012:" + tc.name),
+				AliasName:    aws.String(tc.name),
+				CreationDate: aws.Time(tc.created),
+			}
+
+			actual := a.kmsAliasShouldBeDeleted(alias)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", tc.name, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestKMSKeyShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		keyManager  string
+		keyState    string
+		expected    bool
+		description string
+	}{
+		{
+			description: "enabled customer managed key should be deleted",
+			keyManager:  kms.KeyManagerTypeCustomer,
+			keyState:    kms.KeyStateEnabled,
+			expected:    true,
+		},
+		{
+			description: "disabled customer managed key should be deleted",
+			keyManager:  kms.KeyManagerTypeCustomer,
+			keyState:    kms.KeyStateDisabled,
+			expected:    true,
+		},
+		{
+			description: "customer managed key pending deletion should not be deleted",
+			keyManager:  kms.KeyManagerTypeCustomer,
+			keyState:    kms.KeyStatePendingDeletion,
+			expected:    false,
+		},
+		{
+			description: "aws managed key should not be deleted",
+			keyManager:  kms.KeyManagerTypeAws,
+			keyState:    kms.KeyStateEnabled,
+			expected:    false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			key := &kms.KeyMetadata{
+				Arn:        aws.String("arn:aws:kms:eu-central-1:123456789012:key/a1b2c"),
+				KeyId:      aws.String("a1b2c"),
+				KeyManager: aws.String(tc.keyManager),
+				KeyState:   aws.String(tc.keyState),
+			}
+
+			actual := kmsKeyShouldBeDeleted(key)
+
+			if actual != tc.expected {
+				t.Errorf("want %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/lambda"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// lambdaTimeLayout is the layout of the modification times of Lambda
+// functions, like `2019-08-14T22:13:12.000+0000`.
+const lambdaTimeLayout = "2006-01-02T15:04:05.000-0700"
+
+// cleanLambdaFunctions deletes the helper Lambda functions the e2e terraform
+// suites install. The event source mappings of a function are not deleted
+// along with it and keep polling their queues and streams, so they are
+// deleted first.
+func (a *Cleaner) cleanLambdaFunctions(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &lambda.ListFunctionsInput{}
+	err := paginate(ctx, &i.Marker, func() (*string, error) {
+		o, err := a.lambdaClient.ListFunctions(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, function := range o.Functions {
+			if !a.lambdaFunctionShouldBeDeleted(function) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that lambda function %#q should be deleted", *function.FunctionName))
+
+			res := run.Resource{
+				ID:        *function.FunctionName,
+				Type:      "AWS::Lambda::Function",
+				CreatedAt: lambdaModificationTime(function),
+			}
+			function := function
+			err := a.run.DeleteResource(ctx, cleanerLambdaFunctions, res, func() error {
+				return a.deleteLambdaFunction(ctx, function)
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting lambda function %#q", *function.FunctionName), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextMarker, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// deleteLambdaFunction deletes the event source mappings of the given
+// function and the function itself.
+func (a *Cleaner) deleteLambdaFunction(ctx context.Context, function *lambda.FunctionConfiguration) error {
+	i := &lambda.ListEventSourceMappingsInput{
+		FunctionName: function.FunctionName,
+	}
+	err := paginate(ctx, &i.Marker, func() (*string, error) {
+		o, err := a.lambdaClient.ListEventSourceMappings(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, m := range o.EventSourceMappings {
+			a.logger.Log("level", "debug", "message", fmt.Sprintf("deleting event source mapping %#q of lambda function %#q", aws.StringValue(m.UUID), *function.FunctionName))
+
+			_, err := a.lambdaClient.DeleteEventSourceMapping(&lambda.DeleteEventSourceMappingInput{UUID: m.UUID})
+			if err != nil && !isAWSError(err, lambda.ErrCodeResourceNotFoundException) {
+				return nil, microerror.Mask(err)
+			}
+		}
+
+		return o.NextMarker, nil
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	_, err = a.lambdaClient.DeleteFunction(&lambda.DeleteFunctionInput{FunctionName: function.FunctionName})
+	if isAWSError(err, lambda.ErrCodeResourceNotFoundException) {
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (a *Cleaner) lambdaFunctionShouldBeDeleted(function *lambda.FunctionConfiguration) bool {
+	if function.FunctionName == nil || !a.hasCIPrefix(*function.FunctionName) {
+		return false
+	}
+
+	// do not delete recently deployed functions. Lambda does not expose the
+	// creation time, the last modification is at least as recent.
+	if time.Since(lambdaModificationTime(function)) < a.gracePeriod {
+		return false
+	}
+
+	return true
+}
+
+func lambdaModificationTime(function *lambda.FunctionConfiguration) time.Time {
+	t, err := time.Parse(lambdaTimeLayout, aws.StringValue(function.LastModified))
+	if err != nil {
+		return time.Time{}
+	}
+
+	return t
+}
@@ -0,0 +1,70 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/lambda"
+)
+
+func TestLambdaFunctionShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		name        string
+		modified    time.Time
+		expected    bool
+		description string
+	}{
+		{
+			description: "old e2e helper function should be deleted",
+			name:        "e2e-a1b2c-helper",
+			modified:    time.Now().Add(-2 * time.Hour),
+			expected:    true,
+		},
+		{
+			description: "recent e2e helper function should not be deleted",
+			name:        "e2e-a1b2c-helper",
+			modified:    time.Now().Add(-time.Hour),
+			expected:    false,
+		},
+		{
+			description: "old general function should not be deleted",
+			name:        "nightly-report",
+			modified:    time.Now().Add(-2 * time.Hour),
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			function := &lambda.FunctionConfiguration{
+				FunctionName: aws.String(tc.name),
+				LastModified: aws.String(tc.modified.Format(lambdaTimeLayout)),
+			}
+
+			actual := a.lambdaFunctionShouldBeDeleted(function)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", tc.name, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestLambdaModificationTime(t *testing.T) {
+	function := &lambda.FunctionConfiguration{
+		LastModified: aws.String("2019-08-14T22:13:12.000+0000"),
+	}
+
+	expected := time.Date(2019, 8, 14, 22, 13, 12, 0, time.UTC)
+	actual := lambdaModificationTime(function)
+
+	if !actual.Equal(expected) {
+		t.Errorf("want %s, got %s", expected, actual)
+	}
+}
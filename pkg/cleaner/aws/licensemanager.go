@@ -0,0 +1,162 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/licensemanager"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanLicenseConfigurations deletes self-managed License Manager license
+// configurations our licensing tests leave behind once no instance uses them
+// anymore. Configurations still associated with AMIs block their
+// deregistration, which is why such associations are removed before the
+// configuration is deleted, and why this runs before the images cleaner.
+func (a *Cleaner) cleanLicenseConfigurations(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &licensemanager.ListLicenseConfigurationsInput{}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.licenseManagerClient.ListLicenseConfigurations(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, c := range o.LicenseConfigurations {
+			seen, err := a.run.FirstSeen(cleanerLicenseConfigurations, *c.LicenseConfigurationArn)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				continue
+			}
+
+			if !a.licenseConfigurationShouldBeDeleted(aws.StringValue(c.Name), seen) {
+				continue
+			}
+
+			associations, err := a.licenseConfigurationAssociations(ctx, c.LicenseConfigurationArn)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed listing associations of license configuration %#q", *c.Name), "stack", fmt.Sprintf("%#v", err))
+				continue
+			}
+
+			// do not delete license configurations instances still use.
+			if isLicenseConfigurationInUse(associations) {
+				a.logger.Log("level", "debug", "message", fmt.Sprintf("license configuration %#q is still associated with instances", *c.Name))
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that license configuration %#q should be deleted", *c.Name))
+
+			res := run.Resource{
+				ID:   *c.LicenseConfigurationArn,
+				Type: "AWS::LicenseManager::LicenseConfiguration",
+			}
+			c := c
+			err = a.run.DeleteResource(ctx, cleanerLicenseConfigurations, res, func() error {
+				return a.deleteLicenseConfiguration(c, associations)
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting license configuration %#q", *c.Name), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) licenseConfigurationAssociations(ctx context.Context, arn *string) ([]*licensemanager.LicenseConfigurationAssociation, error) {
+	var associations []*licensemanager.LicenseConfigurationAssociation
+
+	i := &licensemanager.ListAssociationsForLicenseConfigurationInput{
+		LicenseConfigurationArn: arn,
+	}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.licenseManagerClient.ListAssociationsForLicenseConfiguration(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		associations = append(associations, o.LicenseConfigurationAssociations...)
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return associations, nil
+}
+
+// deleteLicenseConfiguration removes the given license configuration from the
+// AMIs it is still associated with and deletes it.
+func (a *Cleaner) deleteLicenseConfiguration(c *licensemanager.LicenseConfiguration, associations []*licensemanager.LicenseConfigurationAssociation) error {
+	for _, association := range associations {
+		i := &licensemanager.UpdateLicenseSpecificationsForResourceInput{
+			RemoveLicenseSpecifications: []*licensemanager.LicenseSpecification{
+				{
+					AmiAssociationScope:     association.AmiAssociationScope,
+					LicenseConfigurationArn: c.LicenseConfigurationArn,
+				},
+			},
+			ResourceArn: association.ResourceArn,
+		}
+
+		_, err := a.licenseManagerClient.UpdateLicenseSpecificationsForResource(i)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	i := &licensemanager.DeleteLicenseConfigurationInput{
+		LicenseConfigurationArn: c.LicenseConfigurationArn,
+	}
+
+	_, err := a.licenseManagerClient.DeleteLicenseConfiguration(i)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (a *Cleaner) licenseConfigurationShouldBeDeleted(name string, seen time.Time) bool {
+	if !a.hasCIPrefix(name) {
+		return false
+	}
+
+	// do not delete recent license configurations.
+	if time.Since(seen) < a.gracePeriod {
+		return false
+	}
+
+	return true
+}
+
+// isLicenseConfigurationInUse returns whether any of the given associations
+// is one of an instance or a dedicated host, as opposed to one of an AMI.
+func isLicenseConfigurationInUse(associations []*licensemanager.LicenseConfigurationAssociation) bool {
+	for _, association := range associations {
+		if aws.StringValue(association.ResourceType) != licensemanager.ResourceTypeEc2Ami {
+			return true
+		}
+	}
+
+	return false
+}
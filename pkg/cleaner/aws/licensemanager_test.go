@@ -0,0 +1,90 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/licensemanager"
+)
+
+func TestLicenseConfigurationShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		name        string
+		seen        time.Time
+		expected    bool
+		description string
+	}{
+		{
+			description: "old ci license configuration should be deleted",
+			name:        "ci-wip-a1b2c-windows-server",
+			seen:        time.Now().Add(-2 * time.Hour),
+			expected:    true,
+		},
+		{
+			description: "recently found ci license configuration should not be deleted",
+			name:        "ci-wip-a1b2c-windows-server",
+			seen:        time.Now().Add(-time.Hour),
+			expected:    false,
+		},
+		{
+			description: "old general license configuration should not be deleted",
+			name:        "windows-server",
+			seen:        time.Now().Add(-2 * time.Hour),
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := a.licenseConfigurationShouldBeDeleted(tc.name, tc.seen)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", tc.name, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestIsLicenseConfigurationInUse(t *testing.T) {
+	tcs := []struct {
+		resourceTypes []string
+		expected      bool
+		description   string
+	}{
+		{
+			description: "unassociated license configuration is not in use",
+			expected:    false,
+		},
+		{
+			description:   "license configuration associated with amis only is not in use",
+			resourceTypes: []string{licensemanager.ResourceTypeEc2Ami, licensemanager.ResourceTypeEc2Ami},
+			expected:      false,
+		},
+		{
+			description:   "license configuration associated with an instance is in use",
+			resourceTypes: []string{licensemanager.ResourceTypeEc2Ami, licensemanager.ResourceTypeEc2Instance},
+			expected:      true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			var associations []*licensemanager.LicenseConfigurationAssociation
+			for _, r := range tc.resourceTypes {
+				associations = append(associations, &licensemanager.LicenseConfigurationAssociation{ResourceType: aws.String(r)})
+			}
+
+			actual := isLicenseConfigurationInUse(associations)
+
+			if actual != tc.expected {
+				t.Errorf("want %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,272 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+const (
+	// maxLoadBalancerTags is the maximum number of load balancers whose tags
+	// can be described at once.
+	maxLoadBalancerTags = 20
+)
+
+// cleanLoadBalancers deletes the Classic, Application and Network Load
+// Balancers Kubernetes Services of type LoadBalancer create in CI clusters,
+// which survive deleting the cluster. They are matched by the tag Kubernetes
+// puts on them, which names the cluster they belonged to.
+func (a *Cleaner) cleanLoadBalancers(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	err := a.cleanClassicLoadBalancers(ctx)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+	}
+
+	err = a.cleanV2LoadBalancers(ctx)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) cleanClassicLoadBalancers(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &elb.DescribeLoadBalancersInput{
+		PageSize: aws.Int64(maxLoadBalancerTags),
+	}
+	err := paginate(ctx, &i.Marker, func() (*string, error) {
+		o, err := a.elbClient.DescribeLoadBalancers(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		var names []*string
+		for _, lb := range o.LoadBalancerDescriptions {
+			names = append(names, lb.LoadBalancerName)
+		}
+
+		tags := map[string]map[string]string{}
+		if len(names) != 0 {
+			t, err := a.elbClient.DescribeTags(&elb.DescribeTagsInput{LoadBalancerNames: names})
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			for _, d := range t.TagDescriptions {
+				m := map[string]string{}
+				for _, tag := range d.Tags {
+					m[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+				}
+				tags[aws.StringValue(d.LoadBalancerName)] = m
+			}
+		}
+
+		for _, lb := range o.LoadBalancerDescriptions {
+			name := aws.StringValue(lb.LoadBalancerName)
+
+			cluster, ok := a.loadBalancerShouldBeDeleted(lb.CreatedTime, tags[name])
+			if !ok {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that load balancer %#q of cluster %#q should be deleted", name, cluster))
+
+			res := run.Resource{
+				ID:        name,
+				Type:      "AWS::ElasticLoadBalancing::LoadBalancer",
+				Tags:      tags[name],
+				CreatedAt: aws.TimeValue(lb.CreatedTime),
+			}
+			err := a.run.DeleteResource(ctx, cleanerLoadBalancers, res, func() error {
+				_, err := a.elbClient.DeleteLoadBalancer(&elb.DeleteLoadBalancerInput{LoadBalancerName: lb.LoadBalancerName})
+				if err != nil {
+					return microerror.Mask(err)
+				}
+
+				return nil
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting load balancer %#q", name), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextMarker, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) cleanV2LoadBalancers(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &elbv2.DescribeLoadBalancersInput{
+		PageSize: aws.Int64(maxLoadBalancerTags),
+	}
+	err := paginate(ctx, &i.Marker, func() (*string, error) {
+		o, err := a.elbv2Client.DescribeLoadBalancers(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		var arns []*string
+		for _, lb := range o.LoadBalancers {
+			arns = append(arns, lb.LoadBalancerArn)
+		}
+
+		tags := map[string]map[string]string{}
+		if len(arns) != 0 {
+			t, err := a.elbv2Client.DescribeTags(&elbv2.DescribeTagsInput{ResourceArns: arns})
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			for _, d := range t.TagDescriptions {
+				m := map[string]string{}
+				for _, tag := range d.Tags {
+					m[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+				}
+				tags[aws.StringValue(d.ResourceArn)] = m
+			}
+		}
+
+		for _, lb := range o.LoadBalancers {
+			arn := aws.StringValue(lb.LoadBalancerArn)
+
+			cluster, ok := a.loadBalancerShouldBeDeleted(lb.CreatedTime, tags[arn])
+			if !ok {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that %s load balancer %#q of cluster %#q should be deleted", aws.StringValue(lb.Type), aws.StringValue(lb.LoadBalancerName), cluster))
+
+			res := run.Resource{
+				ID:        arn,
+				Type:      "AWS::ElasticLoadBalancingV2::LoadBalancer",
+				Tags:      tags[arn],
+				CreatedAt: aws.TimeValue(lb.CreatedTime),
+			}
+			err := a.run.DeleteResource(ctx, cleanerLoadBalancers, res, func() error {
+				return a.deleteV2LoadBalancer(ctx, lb.LoadBalancerArn)
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting load balancer %#q", aws.StringValue(lb.LoadBalancerName)), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextMarker, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// deleteV2LoadBalancer deletes the listeners of the load balancer with the
+// given ARN, the load balancer itself and the target groups it forwarded to,
+// which are not deleted along with it.
+func (a *Cleaner) deleteV2LoadBalancer(ctx context.Context, arn *string) error {
+	var targetGroups []*string
+	{
+		i := &elbv2.DescribeTargetGroupsInput{
+			LoadBalancerArn: arn,
+		}
+		err := paginate(ctx, &i.Marker, func() (*string, error) {
+			o, err := a.elbv2Client.DescribeTargetGroups(i)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			for _, g := range o.TargetGroups {
+				targetGroups = append(targetGroups, g.TargetGroupArn)
+			}
+
+			return o.NextMarker, nil
+		})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	{
+		i := &elbv2.DescribeListenersInput{
+			LoadBalancerArn: arn,
+		}
+		err := paginate(ctx, &i.Marker, func() (*string, error) {
+			o, err := a.elbv2Client.DescribeListeners(i)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			for _, l := range o.Listeners {
+				_, err := a.elbv2Client.DeleteListener(&elbv2.DeleteListenerInput{ListenerArn: l.ListenerArn})
+				if err != nil {
+					return nil, microerror.Mask(err)
+				}
+			}
+
+			return o.NextMarker, nil
+		})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	_, err := a.elbv2Client.DeleteLoadBalancer(&elbv2.DeleteLoadBalancerInput{LoadBalancerArn: arn})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, g := range targetGroups {
+		_, err := a.elbv2Client.DeleteTargetGroup(&elbv2.DeleteTargetGroupInput{TargetGroupArn: g})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+// loadBalancerShouldBeDeleted returns the CI cluster the load balancer with
+// the given creation time and tags belonged to, if it should be deleted.
+func (a *Cleaner) loadBalancerShouldBeDeleted(createdTime *time.Time, tags map[string]string) (string, bool) {
+	cluster := a.kubernetesCluster(tags)
+	if cluster == "" {
+		return "", false
+	}
+
+	// do not delete recent load balancers.
+	if isRecent(createdTime, a.gracePeriod) {
+		return "", false
+	}
+
+	return cluster, true
+}
@@ -0,0 +1,62 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestLoadBalancerShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		createdTime     time.Time
+		tags            map[string]string
+		expectedCluster string
+		expected        bool
+		description     string
+	}{
+		{
+			description:     "old load balancer of ci cluster should be deleted",
+			createdTime:     time.Now().Add(-2 * time.Hour),
+			tags:            map[string]string{"kubernetes.io/cluster/ci-a1b2c": "owned", "kubernetes.io/service-name": "default/nginx"},
+			expectedCluster: "ci-a1b2c",
+			expected:        true,
+		},
+		{
+			description: "recent load balancer of ci cluster should not be deleted",
+			createdTime: time.Now().Add(-time.Hour),
+			tags:        map[string]string{"kubernetes.io/cluster/ci-a1b2c": "owned"},
+			expected:    false,
+		},
+		{
+			description: "old load balancer of general cluster should not be deleted",
+			createdTime: time.Now().Add(-2 * time.Hour),
+			tags:        map[string]string{"kubernetes.io/cluster/gauss": "owned"},
+			expected:    false,
+		},
+		{
+			description: "old load balancer without cluster should not be deleted",
+			createdTime: time.Now().Add(-2 * time.Hour),
+			tags:        map[string]string{"Name": "ci-a1b2c"},
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			cluster, actual := a.loadBalancerShouldBeDeleted(aws.Time(tc.createdTime), tc.tags)
+
+			if actual != tc.expected {
+				t.Errorf("checking if load balancer should be deleted, want %t, got %t", tc.expected, actual)
+			}
+			if cluster != tc.expectedCluster {
+				t.Errorf("want cluster %q, got %q", tc.expectedCluster, cluster)
+			}
+		})
+	}
+}
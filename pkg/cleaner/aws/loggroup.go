@@ -0,0 +1,135 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanLogGroups deletes the CloudWatch log groups of CI clusters and e2e
+// Lambda functions, like `/aws/eks/ci-wip-a1b2c/cluster` or
+// `/aws/lambda/e2e-a1b2c-helper`, which are created without retention policy.
+// When a log group retention is configured, it is set on the CI log groups
+// which are kept for now and do not have a retention policy yet.
+func (a *Cleaner) cleanLogGroups(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &cloudwatchlogs.DescribeLogGroupsInput{}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.cloudWatchLogsClient.DescribeLogGroups(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, group := range o.LogGroups {
+			if group.LogGroupName == nil || !a.isCILogGroup(*group.LogGroupName) {
+				continue
+			}
+
+			if !a.logGroupShouldBeDeleted(group) {
+				err := a.ensureLogGroupRetention(ctx, group)
+				if err != nil {
+					errors.Append(microerror.Mask(err))
+					a.logger.Log("level", "error", "message", fmt.Sprintf("failed setting retention of log group %#q", *group.LogGroupName), "stack", fmt.Sprintf("%#v", err))
+				}
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that log group %#q should be deleted", *group.LogGroupName))
+
+			res := run.Resource{
+				ID:        *group.LogGroupName,
+				Type:      "AWS::Logs::LogGroup",
+				CreatedAt: logGroupCreationTime(group),
+			}
+			group := group
+			err := a.run.DeleteResource(ctx, cleanerLogGroups, res, func() error {
+				_, err := a.cloudWatchLogsClient.DeleteLogGroup(&cloudwatchlogs.DeleteLogGroupInput{LogGroupName: group.LogGroupName})
+				if isAWSError(err, cloudwatchlogs.ErrCodeResourceNotFoundException) {
+					return nil
+				}
+				return err
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting log group %#q", *group.LogGroupName), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// ensureLogGroupRetention sets the configured retention on the given log
+// group, unless it has a retention policy already.
+func (a *Cleaner) ensureLogGroupRetention(ctx context.Context, group *cloudwatchlogs.LogGroup) error {
+	if a.logGroupRetentionDays == 0 || group.RetentionInDays != nil {
+		return nil
+	}
+
+	a.logger.Log("level", "info", "message", fmt.Sprintf("setting retention of log group %#q to %d days", *group.LogGroupName, a.logGroupRetentionDays))
+
+	i := &cloudwatchlogs.PutRetentionPolicyInput{
+		LogGroupName:    group.LogGroupName,
+		RetentionInDays: aws.Int64(a.logGroupRetentionDays),
+	}
+
+	_, err := a.cloudWatchLogsClient.PutRetentionPolicy(i)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// isCILogGroup checks if any segment of the given log group name, e.g. the
+// cluster of `/aws/eks/ci-wip-a1b2c/cluster`, identifies a CI resource.
+func (a *Cleaner) isCILogGroup(name string) bool {
+	for _, s := range strings.Split(name, "/") {
+		if s != "" && a.hasCIPrefix(s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (a *Cleaner) logGroupShouldBeDeleted(group *cloudwatchlogs.LogGroup) bool {
+	if group.LogGroupName == nil || !a.isCILogGroup(*group.LogGroupName) {
+		return false
+	}
+
+	// do not delete recent log groups.
+	if time.Since(logGroupCreationTime(group)) < a.gracePeriod {
+		return false
+	}
+
+	return true
+}
+
+func logGroupCreationTime(group *cloudwatchlogs.LogGroup) time.Time {
+	return logCreationTime(group.CreationTime)
+}
+
+// logCreationTime converts the creation time in milliseconds CloudWatch Logs
+// returns.
+func logCreationTime(ms *int64) time.Time {
+	return time.Unix(0, aws.Int64Value(ms)*int64(time.Millisecond))
+}
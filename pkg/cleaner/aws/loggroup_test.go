@@ -0,0 +1,63 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+)
+
+func TestLogGroupShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		name        string
+		created     time.Time
+		expected    bool
+		description string
+	}{
+		{
+			description: "old log group of ci eks cluster should be deleted",
+			name:        "/aws/eks/ci-wip-a1b2c/cluster",
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    true,
+		},
+		{
+			description: "old log group of e2e lambda function should be deleted",
+			name:        "/aws/lambda/e2e-a1b2c-helper",
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    true,
+		},
+		{
+			description: "recent log group of ci eks cluster should not be deleted",
+			name:        "/aws/eks/ci-wip-a1b2c/cluster",
+			created:     time.Now().Add(-time.Hour),
+			expected:    false,
+		},
+		{
+			description: "old log group of general lambda function should not be deleted",
+			name:        "/aws/lambda/nightly-report",
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			group := &cloudwatchlogs.LogGroup{
+				CreationTime: aws.Int64(tc.created.UnixNano() / int64(time.Millisecond)),
+				LogGroupName: aws.String(tc.name),
+			}
+
+			actual := a.logGroupShouldBeDeleted(group)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", tc.name, tc.expected, actual)
+			}
+		})
+	}
+}
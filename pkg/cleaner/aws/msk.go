@@ -0,0 +1,238 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/kafka"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanMSK deletes the MSK clusters streaming e2e tests leak, after
+// disassociating their SCRAM secrets, which would otherwise block deleting
+// the secrets. Deleting a cluster takes long and is tracked by later runs.
+// CI configurations, including all their revisions, are deleted once no
+// cluster uses them anymore. MSK is one of the most expensive resources we
+// ever leaked.
+func (a *Cleaner) cleanMSK(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	// configurations are the ARNs of the configurations used by existing
+	// clusters.
+	configurations := map[string]bool{}
+
+	var nextToken *string
+	err := paginate(ctx, &nextToken, func() (*string, error) {
+		o, err := a.kafkaClient.ListClustersV2(&kafka.ListClustersV2Input{NextToken: nextToken})
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, cluster := range o.ClusterInfoList {
+			if cluster.Provisioned != nil && cluster.Provisioned.CurrentBrokerSoftwareInfo != nil && cluster.Provisioned.CurrentBrokerSoftwareInfo.ConfigurationArn != nil {
+				configurations[*cluster.Provisioned.CurrentBrokerSoftwareInfo.ConfigurationArn] = true
+			}
+
+			if !a.mskClusterShouldBeDeleted(cluster) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that msk cluster %#q should be deleted", *cluster.ClusterName))
+
+			res := run.Resource{
+				ID:        *cluster.ClusterArn,
+				Type:      "AWS::MSK::Cluster",
+				Tags:      aws.StringValueMap(cluster.Tags),
+				CreatedAt: aws.TimeValue(cluster.CreationTime),
+				Cost:      mskCost(cluster),
+			}
+			cluster := cluster
+			start := func() (string, error) {
+				return a.deleteMSKCluster(ctx, cluster)
+			}
+			err := a.run.DeleteResourceAsync(ctx, cleanerMSK, res, start, a.pollMSKCluster)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting msk cluster %#q", *cluster.ClusterName), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	nextToken = nil
+	err = paginate(ctx, &nextToken, func() (*string, error) {
+		o, err := a.kafkaClient.ListConfigurations(&kafka.ListConfigurationsInput{NextToken: nextToken})
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, configuration := range o.Configurations {
+			if !a.mskConfigurationShouldBeDeleted(configuration, configurations) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that msk configuration %#q should be deleted", *configuration.Name))
+
+			res := run.Resource{
+				ID:        *configuration.Arn,
+				Type:      "AWS::MSK::Configuration",
+				CreatedAt: aws.TimeValue(configuration.CreationTime),
+			}
+			err := a.run.DeleteResource(ctx, cleanerMSK, res, func() error {
+				_, err := a.kafkaClient.DeleteConfiguration(&kafka.DeleteConfigurationInput{Arn: configuration.Arn})
+				if err != nil {
+					return microerror.Mask(err)
+				}
+
+				return nil
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting msk configuration %#q", *configuration.Name), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	err = a.run.PollPending(ctx, cleanerMSK, "AWS::MSK::Cluster", a.pollMSKCluster)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// deleteMSKCluster disassociates the SCRAM secrets of the given cluster and
+// starts deleting it. The ARN of the cluster is returned to poll the
+// deletion.
+func (a *Cleaner) deleteMSKCluster(ctx context.Context, cluster *kafka.Cluster) (string, error) {
+	if hasSCRAMAuthentication(cluster) {
+		var secrets []*string
+		var nextToken *string
+		err := paginate(ctx, &nextToken, func() (*string, error) {
+			o, err := a.kafkaClient.ListScramSecrets(&kafka.ListScramSecretsInput{ClusterArn: cluster.ClusterArn, NextToken: nextToken})
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			secrets = append(secrets, o.SecretArnList...)
+
+			return o.NextToken, nil
+		})
+		if err != nil {
+			return "", microerror.Mask(err)
+		}
+
+		if len(secrets) != 0 {
+			i := &kafka.BatchDisassociateScramSecretInput{
+				ClusterArn:    cluster.ClusterArn,
+				SecretArnList: secrets,
+			}
+
+			_, err := a.kafkaClient.BatchDisassociateScramSecret(i)
+			if err != nil {
+				return "", microerror.Mask(err)
+			}
+		}
+	}
+
+	_, err := a.kafkaClient.DeleteCluster(&kafka.DeleteClusterInput{ClusterArn: cluster.ClusterArn})
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return *cluster.ClusterArn, nil
+}
+
+func (a *Cleaner) pollMSKCluster(ctx context.Context, arn string) (bool, error) {
+	o, err := a.kafkaClient.DescribeClusterV2(&kafka.DescribeClusterV2Input{ClusterArn: aws.String(arn)})
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	state := aws.StringValue(o.ClusterInfo.State)
+	if state != kafka.ClusterStateDeleting {
+		return false, microerror.Maskf(executionFailedError, "msk cluster %#q is in state %#q", arn, state)
+	}
+
+	return false, nil
+}
+
+func (a *Cleaner) mskClusterShouldBeDeleted(cluster *kafka.Cluster) bool {
+	if cluster.ClusterArn == nil || cluster.ClusterName == nil {
+		return false
+	}
+
+	if !a.hasCIPrefix(*cluster.ClusterName) && !a.isCITagged(aws.StringValueMap(cluster.Tags)) {
+		return false
+	}
+
+	// do not delete clusters that are already being deleted.
+	if aws.StringValue(cluster.State) == kafka.ClusterStateDeleting {
+		return false
+	}
+
+	// do not delete recent clusters.
+	if isRecent(cluster.CreationTime, a.gracePeriod) {
+		return false
+	}
+
+	return true
+}
+
+// mskConfigurationShouldBeDeleted checks if the given configuration is a CI
+// configuration none of the existing clusters uses. configurations are the
+// ARNs of the configurations used by existing clusters.
+func (a *Cleaner) mskConfigurationShouldBeDeleted(configuration *kafka.Configuration, configurations map[string]bool) bool {
+	if configuration.Arn == nil || configuration.Name == nil || !a.hasCIPrefix(*configuration.Name) {
+		return false
+	}
+
+	if aws.StringValue(configuration.State) == kafka.ConfigurationStateDeleting {
+		return false
+	}
+
+	// do not delete recent configurations, which might be about to be used
+	// by a cluster.
+	if isRecent(configuration.CreationTime, a.gracePeriod) {
+		return false
+	}
+
+	return !configurations[*configuration.Arn]
+}
+
+func hasSCRAMAuthentication(cluster *kafka.Cluster) bool {
+	p := cluster.Provisioned
+	if p == nil || p.ClientAuthentication == nil || p.ClientAuthentication.Sasl == nil || p.ClientAuthentication.Sasl.Scram == nil {
+		return false
+	}
+
+	return aws.BoolValue(p.ClientAuthentication.Sasl.Scram.Enabled)
+}
+
+// mskCost describes what the given cluster is billed for, so that reports
+// call out how expensive leaving it behind is.
+func mskCost(cluster *kafka.Cluster) string {
+	if cluster.Provisioned == nil || cluster.Provisioned.BrokerNodeGroupInfo == nil {
+		return "serverless MSK cluster"
+	}
+
+	return fmt.Sprintf("MSK cluster with %d %s brokers", aws.Int64Value(cluster.Provisioned.NumberOfBrokerNodes), aws.StringValue(cluster.Provisioned.BrokerNodeGroupInfo.InstanceType))
+}
@@ -0,0 +1,128 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/kafka"
+)
+
+func TestMSKClusterShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		cluster     *kafka.Cluster
+		expected    bool
+		description string
+	}{
+		{
+			description: "old ci cluster should be deleted",
+			cluster:     newMSKCluster("ci-wip-a1b2c-kafka", kafka.ClusterStateActive, time.Now().Add(-2*time.Hour)),
+			expected:    true,
+		},
+		{
+			description: "old failed cluster with ci cluster tag should be deleted",
+			cluster: func() *kafka.Cluster {
+				c := newMSKCluster("kafka", kafka.ClusterStateFailed, time.Now().Add(-2*time.Hour))
+				c.Tags = map[string]*string{clusterTag: aws.String("e2e-a1b2c")}
+				return c
+			}(),
+			expected: true,
+		},
+		{
+			description: "recent ci cluster should not be deleted",
+			cluster:     newMSKCluster("ci-wip-a1b2c-kafka", kafka.ClusterStateCreating, time.Now().Add(-time.Hour)),
+			expected:    false,
+		},
+		{
+			description: "deleting ci cluster should not be deleted",
+			cluster:     newMSKCluster("ci-wip-a1b2c-kafka", kafka.ClusterStateDeleting, time.Now().Add(-2*time.Hour)),
+			expected:    false,
+		},
+		{
+			description: "old general cluster should not be deleted",
+			cluster:     newMSKCluster("events", kafka.ClusterStateActive, time.Now().Add(-2*time.Hour)),
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := a.mskClusterShouldBeDeleted(tc.cluster)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.cluster.ClusterName, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestMSKConfigurationShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		configuration *kafka.Configuration
+		expected      bool
+		description   string
+	}{
+		{
+			description:   "old unused ci configuration should be deleted",
+			configuration: newMSKConfiguration("arn:aws:kafka:eu-central-1:123456789012:configuration/ci-wip-a1b2c/1", "ci-wip-a1b2c", time.Now().Add(-2*time.Hour)),
+			expected:      true,
+		},
+		{
+			description:   "old used ci configuration should not be deleted",
+			configuration: newMSKConfiguration("arn:aws:kafka:eu-central-1:123456789012:configuration/ci-wip-d3e4f/1", "ci-wip-d3e4f", time.Now().Add(-2*time.Hour)),
+			expected:      false,
+		},
+		{
+			description:   "recent unused ci configuration should not be deleted",
+			configuration: newMSKConfiguration("arn:aws:kafka:eu-central-1:123456789012:configuration/ci-wip-a1b2c/1", "ci-wip-a1b2c", time.Now().Add(-time.Hour)),
+			expected:      false,
+		},
+		{
+			description:   "old unused general configuration should not be deleted",
+			configuration: newMSKConfiguration("arn:aws:kafka:eu-central-1:123456789012:configuration/events/1", "events", time.Now().Add(-2*time.Hour)),
+			expected:      false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	configurations := map[string]bool{
+		"arn:aws:kafka:eu-central-1:123456789012:configuration/ci-wip-d3e4f/1": true,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := a.mskConfigurationShouldBeDeleted(tc.configuration, configurations)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.configuration.Name, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func newMSKCluster(name, state string, creationTime time.Time) *kafka.Cluster {
+	return &kafka.Cluster{
+		ClusterArn:   aws.String("arn:aws:kafka:eu-central-1:123456789012:cluster/" + name + "/1"),
+		ClusterName:  aws.String(name),
+		CreationTime: aws.Time(creationTime),
+		State:        aws.String(state),
+	}
+}
+
+func newMSKConfiguration(arn, name string, creationTime time.Time) *kafka.Configuration {
+	return &kafka.Configuration{
+		Arn:          aws.String(arn),
+		CreationTime: aws.Time(creationTime),
+		Name:         aws.String(name),
+		State:        aws.String(kafka.ConfigurationStateActive),
+	}
+}
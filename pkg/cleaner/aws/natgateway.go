@@ -0,0 +1,146 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanNATGateways deletes CI NAT gateways, which are the most expensive
+// leftover of broken CI runs, as they are billed hourly on top of the Elastic
+// IPs they hold. The Elastic IPs can only be released once the NAT gateway
+// reached the deleted state, which is tracked by later runs, as NAT gateways
+// being deleted are not listed anymore.
+func (a *Cleaner) cleanNATGateways(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &ec2.DescribeNatGatewaysInput{
+		Filter: []*ec2.Filter{
+			{
+				Name: aws.String("state"),
+				Values: []*string{
+					aws.String(ec2.NatGatewayStatePending),
+					aws.String(ec2.NatGatewayStateAvailable),
+					aws.String(ec2.NatGatewayStateFailed),
+				},
+			},
+		},
+	}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.ec2Client.DescribeNatGateways(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, natGateway := range o.NatGateways {
+			if !a.natGatewayShouldBeDeleted(natGateway) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that nat gateway %#q should be deleted", *natGateway.NatGatewayId))
+
+			res := run.Resource{
+				ID:          *natGateway.NatGatewayId,
+				Type:        "AWS::EC2::NatGateway",
+				Tags:        ec2Tags(natGateway.Tags),
+				CreatedAt:   aws.TimeValue(natGateway.CreateTime),
+				Cost:        fmt.Sprintf("NAT gateway with %d Elastic IP(s), billed hourly until deleted", len(natGateway.NatGatewayAddresses)),
+				MonthlyCost: natGatewayHourlyCost * hoursPerMonth,
+			}
+			id := natGateway.NatGatewayId
+			start := func() (string, error) {
+				_, err := a.ec2Client.DeleteNatGateway(&ec2.DeleteNatGatewayInput{NatGatewayId: id})
+				if err != nil {
+					return "", microerror.Mask(err)
+				}
+
+				return *id, nil
+			}
+			err := a.run.DeleteResourceAsync(ctx, cleanerNATGateways, res, start, a.pollNATGateway)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting nat gateway %#q", *natGateway.NatGatewayId), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	err = a.run.PollPending(ctx, cleanerNATGateways, "AWS::EC2::NatGateway", a.pollNATGateway)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// pollNATGateway releases the Elastic IPs of the NAT gateway with the given
+// ID once it is deleted.
+func (a *Cleaner) pollNATGateway(ctx context.Context, id string) (bool, error) {
+	o, err := a.ec2Client.DescribeNatGateways(&ec2.DescribeNatGatewaysInput{NatGatewayIds: []*string{aws.String(id)}})
+	if isAWSError(err, "NatGatewayNotFound") {
+		return true, nil
+	} else if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	if len(o.NatGateways) == 0 {
+		return true, nil
+	}
+
+	natGateway := o.NatGateways[0]
+	if aws.StringValue(natGateway.State) != ec2.NatGatewayStateDeleted {
+		return false, nil
+	}
+
+	for _, address := range natGateway.NatGatewayAddresses {
+		if address.AllocationId == nil {
+			continue
+		}
+
+		a.logger.Log("level", "info", "message", fmt.Sprintf("releasing elastic ip %#q of deleted nat gateway %#q", *address.AllocationId, id))
+
+		_, err := a.ec2Client.ReleaseAddress(&ec2.ReleaseAddressInput{AllocationId: address.AllocationId})
+		if err != nil && !isAWSError(err, "InvalidAllocationID.NotFound") {
+			return false, microerror.Mask(err)
+		}
+	}
+
+	return true, nil
+}
+
+func (a *Cleaner) natGatewayShouldBeDeleted(natGateway *ec2.NatGateway) bool {
+	if natGateway.NatGatewayId == nil {
+		return false
+	}
+
+	if !a.isCITagged(ec2Tags(natGateway.Tags)) {
+		return false
+	}
+
+	// do not delete NAT gateways that are already being deleted.
+	switch aws.StringValue(natGateway.State) {
+	case ec2.NatGatewayStateDeleting, ec2.NatGatewayStateDeleted:
+		return false
+	}
+
+	// do not delete recent NAT gateways.
+	if isRecent(natGateway.CreateTime, a.gracePeriod) {
+		return false
+	}
+
+	return true
+}
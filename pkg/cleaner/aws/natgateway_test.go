@@ -0,0 +1,170 @@
+package aws
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/giantswarm/micrologger/microloggertest"
+
+	"github.com/giantswarm/ci-cleaner/pkg/report"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+	"github.com/giantswarm/ci-cleaner/pkg/state"
+)
+
+type natGatewayEC2ClientMock struct {
+	EC2Client
+
+	natGateway *ec2.NatGateway
+	released   []string
+}
+
+func (c *natGatewayEC2ClientMock) DescribeNatGateways(i *ec2.DescribeNatGatewaysInput) (*ec2.DescribeNatGatewaysOutput, error) {
+	for _, f := range i.Filter {
+		if aws.StringValue(f.Name) != "state" {
+			continue
+		}
+
+		for _, v := range f.Values {
+			if aws.StringValue(v) == aws.StringValue(c.natGateway.State) {
+				return &ec2.DescribeNatGatewaysOutput{NatGateways: []*ec2.NatGateway{c.natGateway}}, nil
+			}
+		}
+
+		return &ec2.DescribeNatGatewaysOutput{}, nil
+	}
+
+	return &ec2.DescribeNatGatewaysOutput{NatGateways: []*ec2.NatGateway{c.natGateway}}, nil
+}
+
+func (c *natGatewayEC2ClientMock) DeleteNatGateway(*ec2.DeleteNatGatewayInput) (*ec2.DeleteNatGatewayOutput, error) {
+	c.natGateway.State = aws.String(ec2.NatGatewayStateDeleting)
+	return &ec2.DeleteNatGatewayOutput{}, nil
+}
+
+func (c *natGatewayEC2ClientMock) ReleaseAddress(i *ec2.ReleaseAddressInput) (*ec2.ReleaseAddressOutput, error) {
+	c.released = append(c.released, aws.StringValue(i.AllocationId))
+	return &ec2.ReleaseAddressOutput{}, nil
+}
+
+func TestNATGatewayShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		natGateway  *ec2.NatGateway
+		expected    bool
+		description string
+	}{
+		{
+			description: "old ci nat gateway should be deleted",
+			natGateway:  newNATGateway("ci-wip-a1b2c-nat", ec2.NatGatewayStateAvailable, time.Now().Add(-2*time.Hour)),
+			expected:    true,
+		},
+		{
+			description: "failed ci nat gateway should be deleted",
+			natGateway:  newNATGateway("e2e-a1b2c-nat", ec2.NatGatewayStateFailed, time.Now().Add(-2*time.Hour)),
+			expected:    true,
+		},
+		{
+			description: "recent ci nat gateway should not be deleted",
+			natGateway:  newNATGateway("ci-wip-a1b2c-nat", ec2.NatGatewayStateAvailable, time.Now().Add(-time.Hour)),
+			expected:    false,
+		},
+		{
+			description: "deleting ci nat gateway should not be deleted",
+			natGateway:  newNATGateway("ci-wip-a1b2c-nat", ec2.NatGatewayStateDeleting, time.Now().Add(-2*time.Hour)),
+			expected:    false,
+		},
+		{
+			description: "old general nat gateway should not be deleted",
+			natGateway:  newNATGateway("gauss-nat", ec2.NatGatewayStateAvailable, time.Now().Add(-2*time.Hour)),
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := a.natGatewayShouldBeDeleted(tc.natGateway)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.natGateway.NatGatewayId, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func newNATGateway(name, state string, createTime time.Time) *ec2.NatGateway {
+	return &ec2.NatGateway{
+		CreateTime:   aws.Time(createTime),
+		NatGatewayId: aws.String("nat-0123456789abcdef0"),
+		State:        aws.String(state),
+		Tags: []*ec2.Tag{
+			{Key: aws.String("Name"), Value: aws.String(name)},
+		},
+	}
+}
+
+func TestCleanNATGatewaysReleasesAddresses(t *testing.T) {
+	natGateway := newNATGateway("ci-wip-a1b2c-nat", ec2.NatGatewayStateAvailable, time.Now().Add(-2*time.Hour))
+	natGateway.NatGatewayAddresses = []*ec2.NatGatewayAddress{
+		{AllocationId: aws.String("eipalloc-0123456789abcdef0")},
+	}
+	client := &natGatewayEC2ClientMock{natGateway: natGateway}
+
+	stateStore, err := state.New(state.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clean := func(started time.Time) *report.Report {
+		rep := &report.Report{Started: started}
+		r, err := run.New(run.Config{
+			Logger: microloggertest.New(),
+			Report: rep,
+			State:  stateStore,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		a := &Cleaner{
+			ec2Client:   client,
+			logger:      microloggertest.New(),
+			run:         r,
+			gracePeriod: defaultGracePeriod,
+			prefixes:    defaultPrefixes,
+		}
+
+		err = a.cleanNATGateways(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return rep
+	}
+
+	rep := clean(time.Unix(0, 0))
+	if len(rep.Items) != 1 || rep.Items[0].Action != report.ActionDeleting {
+		t.Fatalf("expected nat gateway to be reported as deleting, got %v", rep.Items)
+	}
+	if len(client.released) != 0 {
+		t.Fatalf("expected no elastic ip to be released before the nat gateway is deleted, got %v", client.released)
+	}
+
+	// The deleted NAT gateway is not listed anymore, but its deletion is
+	// still tracked.
+	natGateway.State = aws.String(ec2.NatGatewayStateDeleted)
+
+	rep = clean(time.Unix(1, 0))
+	if len(rep.Items) != 1 || rep.Items[0].Action != report.ActionDeleted {
+		t.Errorf("expected nat gateway to be reported as deleted, got %v", rep.Items)
+	}
+	if len(client.released) != 1 || client.released[0] != "eipalloc-0123456789abcdef0" {
+		t.Errorf("expected elastic ip of deleted nat gateway to be released, got %v", client.released)
+	}
+}
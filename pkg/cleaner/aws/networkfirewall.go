@@ -0,0 +1,353 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/networkfirewall"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanNetworkFirewalls deletes the Network Firewall firewalls, firewall
+// policies and rule groups egress-control e2e tests create. Firewalls are
+// billed per endpoint hour and block deleting their VPC and subnets. Policies
+// can only be deleted once no firewall uses them anymore and rule groups once
+// no policy references them anymore, which is why leftovers of a single test
+// usually take a few runs to be gone.
+func (a *Cleaner) cleanNetworkFirewalls(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	err := a.cleanFirewalls(ctx)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+	}
+
+	err = a.cleanFirewallPolicies(ctx)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+	}
+
+	err = a.cleanFirewallRuleGroups(ctx)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) cleanFirewalls(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &networkfirewall.ListFirewallsInput{}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.networkFirewallClient.ListFirewalls(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, m := range o.Firewalls {
+			d, err := a.networkFirewallClient.DescribeFirewall(&networkfirewall.DescribeFirewallInput{FirewallArn: m.FirewallArn})
+			if isAWSError(err, networkfirewall.ErrCodeResourceNotFoundException) {
+				continue
+			} else if err != nil {
+				errors.Append(microerror.Mask(err))
+				continue
+			}
+
+			firewall := d.Firewall
+			tags := networkFirewallTags(firewall.Tags)
+
+			var status string
+			if d.FirewallStatus != nil {
+				status = aws.StringValue(d.FirewallStatus.Status)
+			}
+
+			seen, err := a.run.FirstSeen(cleanerNetworkFirewalls, *firewall.FirewallArn)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				continue
+			}
+
+			if !a.networkFirewallResourceShouldBeDeleted(aws.StringValue(firewall.FirewallName), tags, status, seen) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that network firewall %#q should be deleted", *firewall.FirewallName))
+
+			res := run.Resource{
+				ID:          *firewall.FirewallArn,
+				Type:        "AWS::NetworkFirewall::Firewall",
+				Tags:        tags,
+				Cost:        fmt.Sprintf("Network Firewall with %d endpoint(s), billed hourly until deleted", len(firewall.SubnetMappings)),
+				MonthlyCost: float64(len(firewall.SubnetMappings)) * networkFirewallHourlyCost * hoursPerMonth,
+			}
+			start := func() (string, error) {
+				return a.deleteFirewall(firewall, d.UpdateToken)
+			}
+			err = a.run.DeleteResourceAsync(ctx, cleanerNetworkFirewalls, res, start, a.pollFirewall)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting network firewall %#q", *firewall.FirewallName), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	err = a.run.PollPending(ctx, cleanerNetworkFirewalls, "AWS::NetworkFirewall::Firewall", a.pollFirewall)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// deleteFirewall starts deleting the given firewall, after disabling its
+// delete protection. The ARN of the firewall is returned to poll the
+// deletion.
+func (a *Cleaner) deleteFirewall(firewall *networkfirewall.Firewall, updateToken *string) (string, error) {
+	disable := func() error {
+		i := &networkfirewall.UpdateFirewallDeleteProtectionInput{
+			DeleteProtection: aws.Bool(false),
+			FirewallArn:      firewall.FirewallArn,
+			UpdateToken:      updateToken,
+		}
+
+		_, err := a.networkFirewallClient.UpdateFirewallDeleteProtection(i)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		return nil
+	}
+	del := func() error {
+		_, err := a.networkFirewallClient.DeleteFirewall(&networkfirewall.DeleteFirewallInput{FirewallArn: firewall.FirewallArn})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		return nil
+	}
+
+	err := a.deleteProtected("network firewall", aws.StringValue(firewall.FirewallName), aws.BoolValue(firewall.DeleteProtection), disable, del)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return *firewall.FirewallArn, nil
+}
+
+func (a *Cleaner) pollFirewall(ctx context.Context, arn string) (bool, error) {
+	o, err := a.networkFirewallClient.DescribeFirewall(&networkfirewall.DescribeFirewallInput{FirewallArn: aws.String(arn)})
+	if isAWSError(err, networkfirewall.ErrCodeResourceNotFoundException) {
+		return true, nil
+	} else if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	if o.FirewallStatus != nil && aws.StringValue(o.FirewallStatus.Status) == networkfirewall.FirewallStatusValueDeleting {
+		return false, nil
+	}
+
+	// the deletion did not stick, e.g. as delete protection was enabled
+	// again, so it is started once more.
+	_, err = a.deleteFirewall(o.Firewall, o.UpdateToken)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	return false, nil
+}
+
+func (a *Cleaner) cleanFirewallPolicies(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &networkfirewall.ListFirewallPoliciesInput{}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.networkFirewallClient.ListFirewallPolicies(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, m := range o.FirewallPolicies {
+			d, err := a.networkFirewallClient.DescribeFirewallPolicy(&networkfirewall.DescribeFirewallPolicyInput{FirewallPolicyArn: m.Arn})
+			if isAWSError(err, networkfirewall.ErrCodeResourceNotFoundException) {
+				continue
+			} else if err != nil {
+				errors.Append(microerror.Mask(err))
+				continue
+			}
+
+			policy := d.FirewallPolicyResponse
+			tags := networkFirewallTags(policy.Tags)
+
+			// do not delete policies firewalls still use.
+			if aws.Int64Value(policy.NumberOfAssociations) != 0 {
+				continue
+			}
+
+			seen, err := a.run.FirstSeen(cleanerNetworkFirewalls, *policy.FirewallPolicyArn)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				continue
+			}
+
+			if !a.networkFirewallResourceShouldBeDeleted(aws.StringValue(policy.FirewallPolicyName), tags, aws.StringValue(policy.FirewallPolicyStatus), seen) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that firewall policy %#q should be deleted", *policy.FirewallPolicyName))
+
+			res := run.Resource{
+				ID:   *policy.FirewallPolicyArn,
+				Type: "AWS::NetworkFirewall::FirewallPolicy",
+				Tags: tags,
+			}
+			err = a.run.DeleteResource(ctx, cleanerNetworkFirewalls, res, func() error {
+				_, err := a.networkFirewallClient.DeleteFirewallPolicy(&networkfirewall.DeleteFirewallPolicyInput{FirewallPolicyArn: policy.FirewallPolicyArn})
+				if err != nil {
+					return microerror.Mask(err)
+				}
+
+				return nil
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting firewall policy %#q", *policy.FirewallPolicyName), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) cleanFirewallRuleGroups(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &networkfirewall.ListRuleGroupsInput{
+		Scope: aws.String(networkfirewall.ResourceManagedStatusAccount),
+	}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.networkFirewallClient.ListRuleGroups(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, m := range o.RuleGroups {
+			d, err := a.networkFirewallClient.DescribeRuleGroup(&networkfirewall.DescribeRuleGroupInput{RuleGroupArn: m.Arn})
+			if isAWSError(err, networkfirewall.ErrCodeResourceNotFoundException) {
+				continue
+			} else if err != nil {
+				errors.Append(microerror.Mask(err))
+				continue
+			}
+
+			group := d.RuleGroupResponse
+			tags := networkFirewallTags(group.Tags)
+
+			// do not delete rule groups policies still reference.
+			if aws.Int64Value(group.NumberOfAssociations) != 0 {
+				continue
+			}
+
+			seen, err := a.run.FirstSeen(cleanerNetworkFirewalls, *group.RuleGroupArn)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				continue
+			}
+
+			if !a.networkFirewallResourceShouldBeDeleted(aws.StringValue(group.RuleGroupName), tags, aws.StringValue(group.RuleGroupStatus), seen) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that firewall rule group %#q should be deleted", *group.RuleGroupName))
+
+			res := run.Resource{
+				ID:   *group.RuleGroupArn,
+				Type: "AWS::NetworkFirewall::RuleGroup",
+				Tags: tags,
+			}
+			err = a.run.DeleteResource(ctx, cleanerNetworkFirewalls, res, func() error {
+				_, err := a.networkFirewallClient.DeleteRuleGroup(&networkfirewall.DeleteRuleGroupInput{RuleGroupArn: group.RuleGroupArn})
+				if err != nil {
+					return microerror.Mask(err)
+				}
+
+				return nil
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting firewall rule group %#q", *group.RuleGroupName), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// networkFirewallResourceShouldBeDeleted decides about firewalls, firewall
+// policies and rule groups alike, given their name, tags, status and when
+// they were first found.
+func (a *Cleaner) networkFirewallResourceShouldBeDeleted(name string, tags map[string]string, status string, seen time.Time) bool {
+	if !a.hasCIPrefix(name) && !a.isCITagged(tags) {
+		return false
+	}
+
+	// do not delete resources that are already being deleted.
+	if status == networkfirewall.FirewallStatusValueDeleting || status == networkfirewall.ResourceStatusDeleting {
+		return false
+	}
+
+	// do not delete recent resources.
+	if time.Since(seen) < a.gracePeriod {
+		return false
+	}
+
+	return true
+}
+
+func networkFirewallTags(networkFirewallTags []*networkfirewall.Tag) map[string]string {
+	if len(networkFirewallTags) == 0 {
+		return nil
+	}
+
+	tags := map[string]string{}
+	for _, t := range networkFirewallTags {
+		tags[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+	}
+
+	return tags
+}
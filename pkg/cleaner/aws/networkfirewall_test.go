@@ -0,0 +1,71 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/networkfirewall"
+)
+
+func TestNetworkFirewallResourceShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		name        string
+		tags        map[string]string
+		status      string
+		seen        time.Time
+		expected    bool
+		description string
+	}{
+		{
+			description: "old ci firewall should be deleted",
+			name:        "ci-wip-a1b2c-egress",
+			status:      networkfirewall.FirewallStatusValueReady,
+			seen:        time.Now().Add(-2 * time.Hour),
+			expected:    true,
+		},
+		{
+			description: "old policy tagged by ci cluster should be deleted",
+			name:        "egress",
+			tags:        map[string]string{clusterTag: "ci-a1b2c"},
+			status:      networkfirewall.ResourceStatusActive,
+			seen:        time.Now().Add(-2 * time.Hour),
+			expected:    true,
+		},
+		{
+			description: "recently found ci firewall should not be deleted",
+			name:        "ci-wip-a1b2c-egress",
+			status:      networkfirewall.FirewallStatusValueReady,
+			seen:        time.Now().Add(-time.Hour),
+			expected:    false,
+		},
+		{
+			description: "deleting ci rule group should not be deleted",
+			name:        "ci-wip-a1b2c-egress",
+			status:      networkfirewall.ResourceStatusDeleting,
+			seen:        time.Now().Add(-2 * time.Hour),
+			expected:    false,
+		},
+		{
+			description: "old general firewall should not be deleted",
+			name:        "egress",
+			status:      networkfirewall.FirewallStatusValueReady,
+			seen:        time.Now().Add(-2 * time.Hour),
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := a.networkFirewallResourceShouldBeDeleted(tc.name, tc.tags, tc.status, tc.seen)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", tc.name, tc.expected, actual)
+			}
+		})
+	}
+}
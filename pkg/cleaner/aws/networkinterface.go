@@ -0,0 +1,198 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanNetworkInterfaces deletes the network interfaces Lambda functions, EFS
+// mount targets and load balancers leave behind in CI VPCs, which keep their
+// subnets and security groups from being deleted. Secondary network
+// interfaces still attached to instances are detached first, and deleted once
+// they are available, which is tracked by later runs.
+func (a *Cleaner) cleanNetworkInterfaces(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	vpcs, err := a.ciVPCs(ctx)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	if len(vpcs) == 0 {
+		return nil
+	}
+
+	i := &ec2.DescribeNetworkInterfacesInput{}
+	err = paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.ec2Client.DescribeNetworkInterfaces(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, n := range o.NetworkInterfaces {
+			if !a.networkInterfaceShouldBeDeleted(n, vpcs) {
+				continue
+			}
+
+			seen, err := a.run.FirstSeen(cleanerNetworkInterfaces, *n.NetworkInterfaceId)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				continue
+			}
+
+			// do not delete recent network interfaces.
+			if time.Since(seen) < a.gracePeriod {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that network interface %#q of type %#q should be deleted", *n.NetworkInterfaceId, aws.StringValue(n.InterfaceType)))
+
+			res := run.Resource{
+				ID:     *n.NetworkInterfaceId,
+				Type:   kindNetworkInterface,
+				Tags:   ec2Tags(n.TagSet),
+				Reason: run.ReasonUnused,
+			}
+			if n.Attachment != nil {
+				res.Note = fmt.Sprintf("detached from instance %s before deletion", aws.StringValue(n.Attachment.InstanceId))
+			}
+
+			n := n
+			start := func() (string, error) {
+				return a.deleteNetworkInterface(n)
+			}
+			err = a.run.DeleteResourceAsync(ctx, cleanerNetworkInterfaces, res, start, a.pollNetworkInterface)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting network interface %#q", *n.NetworkInterfaceId), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	err = a.run.PollPending(ctx, cleanerNetworkInterfaces, kindNetworkInterface, a.pollNetworkInterface)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// ciVPCs returns the IDs of the VPCs tagged by CI.
+func (a *Cleaner) ciVPCs(ctx context.Context) (map[string]bool, error) {
+	vpcs := map[string]bool{}
+
+	i := &ec2.DescribeVpcsInput{}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.ec2Client.DescribeVpcs(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, vpc := range o.Vpcs {
+			if vpc.VpcId == nil || aws.BoolValue(vpc.IsDefault) || !a.isCITagged(ec2Tags(vpc.Tags)) {
+				continue
+			}
+			vpcs[*vpc.VpcId] = true
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return vpcs, nil
+}
+
+// deleteNetworkInterface deletes the given network interface, or detaches it
+// from its instance when it is still attached. The ID of a detached network
+// interface is returned to delete it once it is available.
+func (a *Cleaner) deleteNetworkInterface(n *ec2.NetworkInterface) (string, error) {
+	if n.Attachment != nil && aws.StringValue(n.Status) != ec2.NetworkInterfaceStatusAvailable {
+		if aws.StringValue(n.Attachment.Status) != ec2.AttachmentStatusDetaching {
+			i := &ec2.DetachNetworkInterfaceInput{
+				AttachmentId: n.Attachment.AttachmentId,
+				Force:        aws.Bool(true),
+			}
+			_, err := a.ec2Client.DetachNetworkInterface(i)
+			if err != nil && !isAWSError(err, "InvalidAttachmentID.NotFound") {
+				return "", microerror.Mask(err)
+			}
+		}
+
+		return *n.NetworkInterfaceId, nil
+	}
+
+	_, err := a.ec2Client.DeleteNetworkInterface(&ec2.DeleteNetworkInterfaceInput{NetworkInterfaceId: n.NetworkInterfaceId})
+	if err != nil && !isAWSError(err, "InvalidNetworkInterfaceID.NotFound") {
+		return "", microerror.Mask(err)
+	}
+
+	return "", nil
+}
+
+// pollNetworkInterface deletes the network interface with the given ID once
+// it is detached.
+func (a *Cleaner) pollNetworkInterface(ctx context.Context, id string) (bool, error) {
+	o, err := a.ec2Client.DescribeNetworkInterfaces(&ec2.DescribeNetworkInterfacesInput{NetworkInterfaceIds: aws.StringSlice([]string{id})})
+	if isAWSError(err, "InvalidNetworkInterfaceID.NotFound") {
+		return true, nil
+	} else if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	if len(o.NetworkInterfaces) == 0 {
+		return true, nil
+	}
+
+	handle, err := a.deleteNetworkInterface(o.NetworkInterfaces[0])
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	return handle == "", nil
+}
+
+// networkInterfaceShouldBeDeleted checks if the given network interface is
+// inside one of the given CI VPCs and either available or a secondary network
+// interface which can be detached from its instance. Network interfaces
+// managed by AWS services can only be deleted once they are available.
+func (a *Cleaner) networkInterfaceShouldBeDeleted(n *ec2.NetworkInterface, vpcs map[string]bool) bool {
+	if n.NetworkInterfaceId == nil || !vpcs[aws.StringValue(n.VpcId)] {
+		return false
+	}
+
+	if aws.StringValue(n.Status) == ec2.NetworkInterfaceStatusAvailable {
+		return true
+	}
+
+	// network interfaces of NAT gateways and endpoints are deleted along
+	// with them.
+	switch aws.StringValue(n.InterfaceType) {
+	case ec2.NetworkInterfaceTypeNatGateway, ec2.NetworkInterfaceTypeVpcEndpoint:
+		return false
+	}
+
+	if aws.BoolValue(n.RequesterManaged) || n.Attachment == nil || n.Attachment.InstanceId == nil {
+		return false
+	}
+
+	// primary network interfaces cannot be detached from their instance.
+	return aws.Int64Value(n.Attachment.DeviceIndex) != 0
+}
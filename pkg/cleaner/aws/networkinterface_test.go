@@ -0,0 +1,94 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func TestNetworkInterfaceShouldBeDeleted(t *testing.T) {
+	vpcs := map[string]bool{"vpc-ci": true}
+
+	tcs := []struct {
+		n           *ec2.NetworkInterface
+		expected    bool
+		description string
+	}{
+		{
+			description: "available network interface of lambda function in ci vpc should be deleted",
+			n: &ec2.NetworkInterface{
+				InterfaceType:    aws.String(ec2.NetworkInterfaceTypeLambda),
+				RequesterManaged: aws.Bool(true),
+				Status:           aws.String(ec2.NetworkInterfaceStatusAvailable),
+				VpcId:            aws.String("vpc-ci"),
+			},
+			expected: true,
+		},
+		{
+			description: "secondary network interface attached to instance in ci vpc should be deleted",
+			n: &ec2.NetworkInterface{
+				Attachment: &ec2.NetworkInterfaceAttachment{
+					DeviceIndex: aws.Int64(1),
+					InstanceId:  aws.String("i-a1b2c3d4"),
+				},
+				InterfaceType: aws.String(ec2.NetworkInterfaceTypeInterface),
+				Status:        aws.String(ec2.NetworkInterfaceStatusInUse),
+				VpcId:         aws.String("vpc-ci"),
+			},
+			expected: true,
+		},
+		{
+			description: "primary network interface of instance in ci vpc should not be deleted",
+			n: &ec2.NetworkInterface{
+				Attachment: &ec2.NetworkInterfaceAttachment{
+					DeviceIndex: aws.Int64(0),
+					InstanceId:  aws.String("i-a1b2c3d4"),
+				},
+				InterfaceType: aws.String(ec2.NetworkInterfaceTypeInterface),
+				Status:        aws.String(ec2.NetworkInterfaceStatusInUse),
+				VpcId:         aws.String("vpc-ci"),
+			},
+			expected: false,
+		},
+		{
+			description: "network interface of load balancer in use in ci vpc should not be deleted",
+			n: &ec2.NetworkInterface{
+				Attachment: &ec2.NetworkInterfaceAttachment{
+					DeviceIndex: aws.Int64(1),
+				},
+				InterfaceType:    aws.String(ec2.NetworkInterfaceTypeNetworkLoadBalancer),
+				RequesterManaged: aws.Bool(true),
+				Status:           aws.String(ec2.NetworkInterfaceStatusInUse),
+				VpcId:            aws.String("vpc-ci"),
+			},
+			expected: false,
+		},
+		{
+			description: "available network interface in other vpc should not be deleted",
+			n: &ec2.NetworkInterface{
+				InterfaceType: aws.String(ec2.NetworkInterfaceTypeInterface),
+				Status:        aws.String(ec2.NetworkInterfaceStatusAvailable),
+				VpcId:         aws.String("vpc-shared"),
+			},
+			expected: false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			tc.n.NetworkInterfaceId = aws.String("eni-a1b2c3d4")
+
+			actual := a.networkInterfaceShouldBeDeleted(tc.n, vpcs)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.n.NetworkInterfaceId, tc.expected, actual)
+			}
+		})
+	}
+}
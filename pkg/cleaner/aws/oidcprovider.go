@@ -0,0 +1,210 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+const (
+	// capaClusterTagPrefix prefixes the tag CAPA puts on the resources it
+	// creates, followed by the cluster name.
+	capaClusterTagPrefix = "sigs.k8s.io/cluster-api-provider-aws/cluster/"
+	// eksctlClusterTag is the tag eksctl puts on the resources it creates,
+	// holding the cluster name.
+	eksctlClusterTag = "alpha.eksctl.io/cluster-name"
+
+	// oidcDiscoveryPath is the path of the discovery document every OIDC
+	// issuer serves as long as it exists.
+	oidcDiscoveryPath = "/.well-known/openid-configuration"
+)
+
+// discoveryClient fetches the discovery documents of OIDC issuers.
+var discoveryClient = &http.Client{Timeout: 30 * time.Second}
+
+// cleanOIDCProviders deletes the IAM OIDC providers IRSA-enabled CI clusters
+// register, which are never removed together with their cluster. Clusters are
+// considered gone once their issuer does not serve its discovery document
+// anymore, e.g. because its hostname does not resolve or EKS deleted the
+// issuer along with the cluster.
+func (a *Cleaner) cleanOIDCProviders(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	o, err := a.iamClient.ListOpenIDConnectProviders(&iam.ListOpenIDConnectProvidersInput{})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	for _, p := range o.OpenIDConnectProviderList {
+		if p.Arn == nil {
+			continue
+		}
+
+		provider, err := a.iamClient.GetOpenIDConnectProvider(&iam.GetOpenIDConnectProviderInput{OpenIDConnectProviderArn: p.Arn})
+		if isAWSError(err, iam.ErrCodeNoSuchEntityException) {
+			continue
+		} else if err != nil {
+			errors.Append(microerror.Mask(err))
+			continue
+		}
+
+		cluster, ok := a.oidcProviderShouldBeDeleted(provider)
+		if !ok {
+			continue
+		}
+
+		exists, err := issuerExists(ctx, discoveryClient, aws.StringValue(provider.Url))
+		if err != nil {
+			a.logger.Log("level", "warning", "message", fmt.Sprintf("failed looking up issuer of OIDC provider %#q", *p.Arn), "stack", fmt.Sprintf("%#v", err))
+			continue
+		}
+		if exists {
+			continue
+		}
+
+		a.logger.Log("level", "info", "message", fmt.Sprintf("found that OIDC provider %#q of deleted cluster %#q should be deleted", *p.Arn, cluster))
+
+		res := run.Resource{
+			ID:        *p.Arn,
+			Type:      "AWS::IAM::OIDCProvider",
+			Tags:      iamTags(provider.Tags),
+			CreatedAt: aws.TimeValue(provider.CreateDate),
+		}
+		arn := p.Arn
+		err = a.run.DeleteResource(ctx, cleanerOIDCProviders, res, func() error {
+			_, err := a.iamClient.DeleteOpenIDConnectProvider(&iam.DeleteOpenIDConnectProviderInput{OpenIDConnectProviderArn: arn})
+			if isAWSError(err, iam.ErrCodeNoSuchEntityException) {
+				return nil
+			} else if err != nil {
+				return microerror.Mask(err)
+			}
+
+			return nil
+		})
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting OIDC provider %#q", *p.Arn), "stack", fmt.Sprintf("%#v", err))
+		}
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// oidcProviderShouldBeDeleted checks if the given OIDC provider belongs to a
+// CI cluster and is older than the grace period, and returns the cluster.
+// Whether the cluster still exists is up to the caller.
+func (a *Cleaner) oidcProviderShouldBeDeleted(provider *iam.GetOpenIDConnectProviderOutput) (string, bool) {
+	cluster := a.oidcProviderCluster(aws.StringValue(provider.Url), iamTags(provider.Tags))
+	if cluster == "" {
+		return "", false
+	}
+
+	// do not delete recent providers.
+	if isRecent(provider.CreateDate, a.gracePeriod) {
+		return "", false
+	}
+
+	return cluster, true
+}
+
+// oidcProviderCluster returns the name of the CI cluster the OIDC provider
+// with the given issuer URL and tags was registered for. EKS issuers, like
+// `oidc.eks.eu-west-1.amazonaws.com/id/<id>`, do not tell their cluster, but
+// CAPA and eksctl tag the providers they register. Self-hosted issuers, like
+// `irsa.ci-wip-a1b2c.k8s.gigantic.io` or S3 buckets named after the cluster,
+// tell it by a segment of their URL.
+func (a *Cleaner) oidcProviderCluster(issuer string, tags map[string]string) string {
+	for k, v := range tags {
+		if strings.HasPrefix(k, capaClusterTagPrefix) {
+			if cluster := strings.TrimPrefix(k, capaClusterTagPrefix); a.hasCIPrefix(cluster) {
+				return cluster
+			}
+		}
+		if k == eksctlClusterTag && a.hasCIPrefix(v) {
+			return v
+		}
+	}
+	if v := tags[clusterTag]; v != "" && a.hasCIPrefix(v) {
+		return v
+	}
+
+	issuer = strings.TrimPrefix(issuer, "https://")
+	for _, s := range strings.FieldsFunc(issuer, func(r rune) bool { return r == '.' || r == '/' }) {
+		if a.hasCIPrefix(s) {
+			return s
+		}
+	}
+
+	return ""
+}
+
+// issuerExists checks whether the OIDC issuer with the given URL still serves
+// its discovery document. Issuers whose hostname does not resolve anymore, or
+// which respond as not found or forbidden, like S3 does for deleted objects,
+// are considered gone. Any other failure returns an error, so that providers
+// are not deleted because of a temporary outage.
+func issuerExists(ctx context.Context, client *http.Client, issuer string) (bool, error) {
+	if !strings.Contains(issuer, "://") {
+		issuer = "https://" + issuer
+	}
+
+	u, err := url.Parse(strings.TrimSuffix(issuer, "/") + oidcDiscoveryPath)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	resp, err := client.Do(req.WithContext(ctx))
+	if isHostNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, microerror.Mask(err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound, http.StatusForbidden, http.StatusGone:
+		return false, nil
+	default:
+		return false, microerror.Maskf(executionFailedError, "discovery document of issuer %#q responded with status %d", issuer, resp.StatusCode)
+	}
+}
+
+// isHostNotFound checks if the given error is caused by a hostname which does
+// not resolve.
+func isHostNotFound(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if uerr, ok := err.(*url.Error); ok {
+		err = uerr.Err
+	}
+	if oerr, ok := err.(*net.OpError); ok {
+		err = oerr.Err
+	}
+
+	dnsErr, ok := err.(*net.DNSError)
+	return ok && dnsErr.IsNotFound
+}
@@ -0,0 +1,147 @@
+package aws
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+)
+
+func TestOIDCProviderShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		url         string
+		tags        map[string]string
+		created     time.Time
+		expected    bool
+		description string
+	}{
+		{
+			description: "old provider of self-hosted ci issuer should be deleted",
+			url:         "irsa.ci-wip-a1b2c.k8s.gigantic.io",
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    true,
+		},
+		{
+			description: "old provider of ci issuer bucket should be deleted",
+			url:         "ci-wip-a1b2c-oidc.s3.eu-west-1.amazonaws.com",
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    true,
+		},
+		{
+			description: "old provider of eks issuer tagged by capa for ci cluster should be deleted",
+			url:         "oidc.eks.eu-west-1.amazonaws.com/id/A1B2C3D4E5F6",
+			tags:        map[string]string{"sigs.k8s.io/cluster-api-provider-aws/cluster/ci-wip-a1b2c": "owned"},
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    true,
+		},
+		{
+			description: "old provider of eks issuer tagged by eksctl for ci cluster should be deleted",
+			url:         "oidc.eks.eu-west-1.amazonaws.com/id/A1B2C3D4E5F6",
+			tags:        map[string]string{"alpha.eksctl.io/cluster-name": "e2e-a1b2c"},
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    true,
+		},
+		{
+			description: "recent provider of self-hosted ci issuer should not be deleted",
+			url:         "irsa.ci-wip-a1b2c.k8s.gigantic.io",
+			created:     time.Now().Add(-time.Hour),
+			expected:    false,
+		},
+		{
+			description: "old provider of untagged eks issuer should not be deleted",
+			url:         "oidc.eks.eu-west-1.amazonaws.com/id/A1B2C3D4E5F6",
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    false,
+		},
+		{
+			description: "old provider of general issuer should not be deleted",
+			url:         "token.actions.githubusercontent.com",
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			provider := &iam.GetOpenIDConnectProviderOutput{
+				CreateDate: aws.Time(tc.created),
+				Url:        aws.String(tc.url),
+			}
+			for k, v := range tc.tags {
+				provider.Tags = append(provider.Tags, &iam.Tag{Key: aws.String(k), Value: aws.String(v)})
+			}
+
+			_, actual := a.oidcProviderShouldBeDeleted(provider)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", tc.url, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestIssuerExists(t *testing.T) {
+	tcs := []struct {
+		status      int
+		expected    bool
+		expectError bool
+		description string
+	}{
+		{
+			description: "issuer serving its discovery document exists",
+			status:      http.StatusOK,
+			expected:    true,
+		},
+		{
+			description: "issuer without discovery document is gone",
+			status:      http.StatusNotFound,
+			expected:    false,
+		},
+		{
+			description: "bucket denying access to discovery document is gone",
+			status:      http.StatusForbidden,
+			expected:    false,
+		},
+		{
+			description: "failing issuer returns an error",
+			status:      http.StatusServiceUnavailable,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != oidcDiscoveryPath {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				w.WriteHeader(tc.status)
+			}))
+			defer ts.Close()
+
+			actual, err := issuerExists(context.Background(), ts.Client(), ts.URL)
+			if tc.expectError {
+				if err == nil {
+					t.Fatalf("want error, got nil")
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("want nil, got %#v", err)
+			}
+
+			if actual != tc.expected {
+				t.Errorf("checking if issuer exists, want %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
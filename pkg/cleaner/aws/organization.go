@@ -0,0 +1,333 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/organizations"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanOrganization cleans up what our account-vending e2e tests leave
+// behind in the organization: CI service control policies, the CI member
+// accounts themselves and the instances of CI stack sets in the accounts
+// being closed. CI stack sets themselves are left to cleanStackSets. It only has
+// an effect when running against the management account of an organization.
+func (a *Cleaner) cleanOrganization(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	var accounts []*organizations.Account
+	{
+		i := &organizations.ListAccountsInput{}
+		err := paginate(ctx, &i.NextToken, func() (*string, error) {
+			o, err := a.organizationsClient.ListAccounts(i)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			accounts = append(accounts, o.Accounts...)
+
+			return o.NextToken, nil
+		})
+		if isAWSError(err, organizations.ErrCodeAWSOrganizationsNotInUseException) || isAWSError(err, organizations.ErrCodeAccessDeniedException) {
+			a.logger.Log("level", "debug", "message", "not cleaning organization, as the account is not the management account of an organization")
+			return nil
+		} else if err != nil {
+			errors.Append(microerror.Mask(err))
+			return errors
+		}
+	}
+
+	err := a.cleanServiceControlPolicies(ctx)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+	}
+
+	// closing holds the IDs of the CI member accounts which are being closed,
+	// either by a previous run or by this one.
+	closing := map[string]bool{}
+	for _, account := range accounts {
+		if a.isCIAccount(account) && aws.StringValue(account.Status) == organizations.AccountStatusPendingClosure {
+			closing[*account.Id] = true
+		}
+	}
+
+	for _, account := range accounts {
+		if !a.accountShouldBeClosed(account) {
+			continue
+		}
+
+		res := run.Resource{
+			ID:        *account.Id,
+			Type:      "AWS::Organizations::Account",
+			CreatedAt: aws.TimeValue(account.JoinedTimestamp),
+		}
+
+		if !a.closeAccounts {
+			a.logger.Log("level", "warning", "message", fmt.Sprintf("found CI member account %#q (%s), which has to be closed manually", *account.Id, aws.StringValue(account.Name)))
+			a.run.Report(ctx, cleanerOrganization, res)
+			continue
+		}
+
+		a.logger.Log("level", "info", "message", fmt.Sprintf("found that member account %#q (%s) should be closed", *account.Id, aws.StringValue(account.Name)))
+
+		id := account.Id
+		err := a.run.DeleteResource(ctx, cleanerOrganization, res, func() error {
+			_, err := a.organizationsClient.CloseAccount(&organizations.CloseAccountInput{AccountId: id})
+			if err != nil {
+				return microerror.Mask(err)
+			}
+			closing[*id] = true
+
+			return nil
+		})
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			a.logger.Log("level", "error", "message", fmt.Sprintf("failed closing member account %#q", *account.Id), "stack", fmt.Sprintf("%#v", err))
+		}
+	}
+
+	err = a.cleanOrganizationStackSets(ctx, closing)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// cleanOrganizationStackSets deletes the stack instances of CI stack sets in
+// the given CI accounts which are being closed, so that the stack sets can be
+// deleted before their accounts are gone for good. Their stacks are retained,
+// as they cannot be deleted from closed accounts. Instances are only deleted
+// once their account was found being closed for the grace period.
+func (a *Cleaner) cleanOrganizationStackSets(ctx context.Context, closing map[string]bool) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	stackSets, err := a.activeStackSets(ctx)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	for _, stackSet := range stackSets {
+		if !a.hasCIPrefix(*stackSet.StackSetName) {
+			continue
+		}
+
+		instances, err := a.stackInstances(ctx, *stackSet.StackSetName)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			continue
+		}
+
+		var stale []*cloudformation.StackInstanceSummary
+		for _, instance := range staleStackInstances(instances, closing) {
+			seen, err := a.run.FirstSeen(cleanerOrganization, *stackSet.StackSetName+"/"+*instance.Account)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				continue
+			}
+
+			// do not delete instances in accounts which were closed recently.
+			if time.Since(seen) < a.gracePeriod {
+				continue
+			}
+
+			stale = append(stale, instance)
+		}
+		if len(stale) == 0 {
+			continue
+		}
+
+		err = a.deleteStaleStackInstances(ctx, stackSet, stale)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting stale instances of stack set %#q", *stackSet.StackSetName), "stack", fmt.Sprintf("%#v", err))
+		}
+	}
+
+	err = a.run.PollPending(ctx, cleanerOrganization, "AWS::CloudFormation::StackInstance", a.pollStackSetOperation)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// deleteStaleStackInstances deletes the given stale instances of the given
+// stack set in a single operation, which is tracked by later runs.
+func (a *Cleaner) deleteStaleStackInstances(ctx context.Context, stackSet *cloudformation.StackSetSummary, stale []*cloudformation.StackInstanceSummary) error {
+	accounts, _, _ := stackInstanceTargets(stale)
+
+	a.logger.Log("level", "info", "message", fmt.Sprintf("found that instances of stack set %#q in accounts %s which are being closed should be deleted", *stackSet.StackSetName, strings.Join(accounts, ", ")))
+
+	res := run.Resource{
+		ID:   *stackSet.StackSetName + "/" + strings.Join(accounts, ","),
+		Type: "AWS::CloudFormation::StackInstance",
+	}
+	start := func() (string, error) {
+		return a.startStackInstancesDeletion(stackSet, stale, true)
+	}
+	err := a.run.DeleteResourceAsync(ctx, cleanerOrganization, res, start, a.pollStackSetOperation)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// cleanServiceControlPolicies detaches the service control policies
+// account-vending tests create from all their targets and deletes them.
+func (a *Cleaner) cleanServiceControlPolicies(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &organizations.ListPoliciesInput{
+		Filter: aws.String(organizations.PolicyTypeServiceControlPolicy),
+	}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.organizationsClient.ListPolicies(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, policy := range o.Policies {
+			if !a.isCIServiceControlPolicy(policy) {
+				continue
+			}
+
+			seen, err := a.run.FirstSeen(cleanerOrganization, *policy.Id)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				continue
+			}
+
+			// do not delete recent policies.
+			if time.Since(seen) < a.gracePeriod {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that service control policy %#q should be deleted", *policy.Name))
+
+			res := run.Resource{
+				ID:   *policy.Id,
+				Type: "AWS::Organizations::Policy",
+			}
+			id := policy.Id
+			err = a.run.DeleteResource(ctx, cleanerOrganization, res, func() error {
+				return a.deleteServiceControlPolicy(ctx, id)
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting service control policy %#q", *policy.Name), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// deleteServiceControlPolicy detaches the policy with the given ID from all
+// roots, organizational units and accounts and deletes it.
+func (a *Cleaner) deleteServiceControlPolicy(ctx context.Context, id *string) error {
+	i := &organizations.ListTargetsForPolicyInput{
+		PolicyId: id,
+	}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.organizationsClient.ListTargetsForPolicy(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, target := range o.Targets {
+			_, err := a.organizationsClient.DetachPolicy(&organizations.DetachPolicyInput{PolicyId: id, TargetId: target.TargetId})
+			if err != nil && !isAWSError(err, organizations.ErrCodePolicyNotAttachedException) {
+				return nil, microerror.Mask(err)
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	_, err = a.organizationsClient.DeletePolicy(&organizations.DeletePolicyInput{PolicyId: id})
+	if err != nil && !isAWSError(err, organizations.ErrCodePolicyNotFoundException) {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// accountShouldBeClosed checks if the given member account was created by
+// account-vending tests and is past its retention.
+func (a *Cleaner) accountShouldBeClosed(account *organizations.Account) bool {
+	if !a.isCIAccount(account) {
+		return false
+	}
+	if aws.StringValue(account.Status) != organizations.AccountStatusActive {
+		return false
+	}
+
+	// do not close recent accounts.
+	if isRecent(account.JoinedTimestamp, a.accountRetention) {
+		return false
+	}
+
+	return true
+}
+
+// isCIAccount checks if the given member account was created by
+// account-vending tests. Invited accounts are never considered CI accounts,
+// whatever their name, as they were not created by CI.
+func (a *Cleaner) isCIAccount(account *organizations.Account) bool {
+	if account.Id == nil || !a.hasCIPrefix(aws.StringValue(account.Name)) {
+		return false
+	}
+
+	return aws.StringValue(account.JoinedMethod) == organizations.AccountJoinedMethodCreated
+}
+
+func (a *Cleaner) isCIServiceControlPolicy(policy *organizations.PolicySummary) bool {
+	if policy.Id == nil || policy.Name == nil || aws.BoolValue(policy.AwsManaged) {
+		return false
+	}
+
+	return a.hasCIPrefix(*policy.Name)
+}
+
+// staleStackInstances returns the given stack instances which belong to the
+// given accounts which are being closed.
+func staleStackInstances(instances []*cloudformation.StackInstanceSummary, closing map[string]bool) []*cloudformation.StackInstanceSummary {
+	var stale []*cloudformation.StackInstanceSummary
+	for _, instance := range instances {
+		if instance.Account == nil || !closing[*instance.Account] {
+			continue
+		}
+		stale = append(stale, instance)
+	}
+
+	return stale
+}
@@ -0,0 +1,114 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/organizations"
+)
+
+func TestAccountShouldBeClosed(t *testing.T) {
+	tcs := []struct {
+		name         string
+		joinedMethod string
+		status       string
+		joined       time.Time
+		expected     bool
+		description  string
+	}{
+		{
+			description:  "old created ci account should be closed",
+			name:         "ci-vending-a1b2c",
+			joinedMethod: organizations.AccountJoinedMethodCreated,
+			status:       organizations.AccountStatusActive,
+			joined:       time.Now().Add(-25 * time.Hour),
+			expected:     true,
+		},
+		{
+			description:  "recent created ci account should not be closed",
+			name:         "ci-vending-a1b2c",
+			joinedMethod: organizations.AccountJoinedMethodCreated,
+			status:       organizations.AccountStatusActive,
+			joined:       time.Now().Add(-2 * time.Hour),
+			expected:     false,
+		},
+		{
+			description:  "old invited ci account should not be closed",
+			name:         "ci-vending-a1b2c",
+			joinedMethod: organizations.AccountJoinedMethodInvited,
+			status:       organizations.AccountStatusActive,
+			joined:       time.Now().Add(-25 * time.Hour),
+			expected:     false,
+		},
+		{
+			description:  "old created ci account pending closure should not be closed",
+			name:         "ci-vending-a1b2c",
+			joinedMethod: organizations.AccountJoinedMethodCreated,
+			status:       organizations.AccountStatusPendingClosure,
+			joined:       time.Now().Add(-25 * time.Hour),
+			expected:     false,
+		},
+		{
+			description:  "old created general account should not be closed",
+			name:         "giantswarm-staging",
+			joinedMethod: organizations.AccountJoinedMethodCreated,
+			status:       organizations.AccountStatusActive,
+			joined:       time.Now().Add(-25 * time.Hour),
+			expected:     false,
+		},
+	}
+
+	a := &Cleaner{
+		accountRetention: defaultAccountRetention,
+		prefixes:         defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			account := &organizations.Account{
+				Id:              aws.String("123456789012"),
+				JoinedMethod:    aws.String(tc.joinedMethod),
+				JoinedTimestamp: aws.Time(tc.joined),
+				Name:            aws.String(tc.name),
+				Status:          aws.String(tc.status),
+			}
+
+			actual := a.accountShouldBeClosed(account)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be closed, want %t, got %t", tc.name, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestStaleStackInstances(t *testing.T) {
+	instances := []*cloudformation.StackInstanceSummary{
+		{Account: aws.String("111111111111"), Region: aws.String("eu-west-1")},
+		{Account: aws.String("222222222222"), Region: aws.String("eu-west-1"), OrganizationalUnitId: aws.String("ou-a1b2-c3d4e5f6")},
+		{Account: aws.String("222222222222"), Region: aws.String("eu-central-1"), OrganizationalUnitId: aws.String("ou-a1b2-c3d4e5f6")},
+		{Account: aws.String("333333333333"), Region: aws.String("eu-west-1")},
+	}
+	closing := map[string]bool{
+		"222222222222": true,
+		"333333333333": true,
+	}
+
+	stale := staleStackInstances(instances, closing)
+	if len(stale) != 3 {
+		t.Fatalf("want 3 stale instances, got %d", len(stale))
+	}
+
+	accounts, regions, units := stackInstanceTargets(stale)
+	if len(accounts) != 2 || accounts[0] != "222222222222" || accounts[1] != "333333333333" {
+		t.Errorf("want accounts of closed members, got %v", accounts)
+	}
+	if len(regions) != 2 || regions[0] != "eu-central-1" || regions[1] != "eu-west-1" {
+		t.Errorf("want regions of stale instances, got %v", regions)
+	}
+	if len(units) != 1 || units[0] != "ou-a1b2-c3d4e5f6" {
+		t.Errorf("want organizational units of stale instances, got %v", units)
+	}
+}
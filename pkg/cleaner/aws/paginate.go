@@ -0,0 +1,75 @@
+package aws
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// maxThrottledRetries is the number of times a page is requested again
+	// when the AWS API keeps throttling requests after the retries of the
+	// SDK.
+	maxThrottledRetries = 5
+)
+
+// throttledBackoff is the time waited before requesting a throttled page
+// again. It doubles with every retry.
+var throttledBackoff = 2 * time.Second
+
+// paginate calls page for every page of a paginated AWS API until it returns
+// no next token. The next token returned by page is set to token, which
+// points to the token field of the input page reuses, like
+// `&i.NextToken`. Pages the AWS API throttles are requested again after
+// backing off, and paginating stops as soon as ctx is canceled.
+//
+//	i := &ec2.DescribeVolumesInput{}
+//	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+//		o, err := a.ec2Client.DescribeVolumes(i)
+//		if err != nil {
+//			return nil, microerror.Mask(err)
+//		}
+//		...
+//		return o.NextToken, nil
+//	})
+func paginate(ctx context.Context, token **string, page func() (*string, error)) error {
+	for {
+		next, err := throttled(ctx, page)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		if next == nil || *next == "" {
+			return nil
+		}
+		*token = next
+	}
+}
+
+// throttled calls page until the AWS API does not throttle it anymore, at
+// most maxThrottledRetries times.
+func throttled(ctx context.Context, page func() (*string, error)) (*string, error) {
+	backoff := throttledBackoff
+	for retry := 0; ; retry++ {
+		if err := ctx.Err(); err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		next, err := page()
+		if err == nil {
+			return next, nil
+		}
+		if retry == maxThrottledRetries || !request.IsErrorThrottle(microerror.Cause(err)) {
+			return nil, microerror.Mask(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, microerror.Mask(ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
@@ -0,0 +1,73 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+)
+
+func TestPaginate(t *testing.T) {
+	throttledBackoff = time.Millisecond
+
+	pages := map[string]*string{
+		"":  aws.String("a"),
+		"a": aws.String("b"),
+		"b": nil,
+	}
+
+	var token *string
+	var seen []string
+	var throttledOnce bool
+	err := paginate(context.Background(), &token, func() (*string, error) {
+		current := aws.StringValue(token)
+		if current == "a" && !throttledOnce {
+			throttledOnce = true
+			return nil, awserr.New("Throttling", "rate exceeded", nil)
+		}
+
+		seen = append(seen, current)
+		return pages[current], nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(seen) != 3 || seen[0] != "" || seen[1] != "a" || seen[2] != "b" {
+		t.Errorf("expected every page to be requested once, got %v", seen)
+	}
+}
+
+func TestPaginateError(t *testing.T) {
+	throttledBackoff = time.Millisecond
+
+	var token *string
+	var calls int
+	err := paginate(context.Background(), &token, func() (*string, error) {
+		calls++
+		return nil, errors.New("access denied")
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if calls != 1 {
+		t.Errorf("expected errors other than throttling not to be retried, got %d calls", calls)
+	}
+}
+
+func TestPaginateCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var token *string
+	err := paginate(ctx, &token, func() (*string, error) {
+		t.Errorf("expected no page to be requested")
+		return nil, nil
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
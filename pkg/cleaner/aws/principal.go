@@ -0,0 +1,225 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudtrail"
+	"github.com/giantswarm/microerror"
+)
+
+// principalLookback is how far back CloudTrail is searched for resources
+// created by CI principals.
+const principalLookback = 7 * 24 * time.Hour
+
+// createEvents maps the names of the CloudTrail events creating resources to
+// the types of the resources they create. Events also list the resources
+// they merely used, like the subnet and image of an instance, which must not
+// be mistaken for resources created by CI.
+var createEvents = map[string][]string{
+	"CreateAutoScalingGroup":      {"AWS::AutoScaling::AutoScalingGroup"},
+	"CreateLaunchConfiguration":   {"AWS::AutoScaling::LaunchConfiguration"},
+	"CreateStack":                 {"AWS::CloudFormation::Stack"},
+	"CreateStackSet":              {"AWS::CloudFormation::StackSet"},
+	"CreateTable":                 {"AWS::DynamoDB::Table"},
+	"CopyImage":                   {"AWS::EC2::Ami"},
+	"CreateImage":                 {"AWS::EC2::Ami"},
+	"RegisterImage":               {"AWS::EC2::Ami"},
+	"RunInstances":                {"AWS::EC2::Instance"},
+	"CreateKeyPair":               {"AWS::EC2::KeyPair"},
+	"ImportKeyPair":               {"AWS::EC2::KeyPair"},
+	"CreateSecurityGroup":         {"AWS::EC2::SecurityGroup"},
+	"CopySnapshot":                {"AWS::EC2::Snapshot"},
+	"CreateSnapshot":              {"AWS::EC2::Snapshot"},
+	"CreateSubnet":                {"AWS::EC2::Subnet"},
+	"CreateVolume":                {"AWS::EC2::Volume"},
+	"CreateVpc":                   {"AWS::EC2::VPC"},
+	"CreateRepository":            {"AWS::ECR::Repository"},
+	"CreateFileSystem":            {"AWS::EFS::FileSystem"},
+	"CreateCluster":               {"AWS::EKS::Cluster", "AWS::MSK::Cluster"},
+	"CreateClusterV2":             {"AWS::MSK::Cluster"},
+	"CreateOpenIDConnectProvider": {"AWS::IAM::OIDCProvider"},
+	"CreateRole":                  {"AWS::IAM::Role"},
+	"CreateUser":                  {"AWS::IAM::User"},
+	"CreateKey":                   {"AWS::KMS::Key"},
+	"CreateFunction20150331":      {"AWS::Lambda::Function"},
+	"CreateLogGroup":              {"AWS::Logs::LogGroup"},
+	"CreateDBCluster":             {"AWS::RDS::DBCluster"},
+	"CreateDBInstance":            {"AWS::RDS::DBInstance"},
+	"CreateHostedZone":            {"AWS::Route53::HostedZone"},
+	"CreateBucket":                {"AWS::S3::Bucket"},
+	"CreateSecret":                {"AWS::SecretsManager::Secret"},
+	"CreateTopic":                 {"AWS::SNS::Topic"},
+	"CreateQueue":                 {"AWS::SQS::Queue"},
+	"PutParameter":                {"AWS::SSM::Parameter"},
+}
+
+// cloudTrailEvent is the part of a CloudTrail event we care about.
+type cloudTrailEvent struct {
+	ErrorCode         string `json:"errorCode"`
+	RequestParameters struct {
+		Overwrite bool `json:"overwrite"`
+	} `json:"requestParameters"`
+	UserIdentity struct {
+		ARN            string `json:"arn"`
+		SessionContext struct {
+			SessionIssuer struct {
+				ARN string `json:"arn"`
+			} `json:"sessionIssuer"`
+		} `json:"sessionContext"`
+	} `json:"userIdentity"`
+}
+
+// loadCreatedByCI looks up the names and IDs of the resources the configured
+// CI principals created according to CloudTrail, so that they are treated as
+// CI resources regardless of their name. Create events of the last 7 days are
+// considered, modifying a resource does not make it a CI resource.
+func (a *Cleaner) loadCreatedByCI(ctx context.Context) error {
+	if len(a.ciPrincipals) == 0 {
+		return nil
+	}
+
+	principals := map[string]bool{}
+	for _, p := range a.ciPrincipals {
+		principals[p] = true
+	}
+
+	createdByCI := map[string]bool{}
+
+	i := &cloudtrail.LookupEventsInput{
+		LookupAttributes: []*cloudtrail.LookupAttribute{
+			{
+				AttributeKey:   aws.String(cloudtrail.LookupAttributeKeyReadOnly),
+				AttributeValue: aws.String("false"),
+			},
+		},
+		StartTime: aws.Time(time.Now().Add(-principalLookback)),
+	}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.cloudTrailClient.LookupEvents(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, e := range o.Events {
+			if !isCIPrincipalEvent(e, principals) {
+				continue
+			}
+
+			for _, name := range createdResources(e) {
+				createdByCI[name] = true
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	a.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("found %d resources created by CI principals", len(createdByCI)))
+
+	a.createdByCI = createdByCI
+
+	return nil
+}
+
+// isCIPrincipalEvent checks if the given CloudTrail event was caused by one
+// of the given principals, either directly or by a session of an assumed
+// role.
+func isCIPrincipalEvent(e *cloudtrail.Event, principals map[string]bool) bool {
+	if e.CloudTrailEvent == nil {
+		return false
+	}
+
+	var identity cloudTrailEvent
+	err := json.Unmarshal([]byte(*e.CloudTrailEvent), &identity)
+	if err != nil {
+		return false
+	}
+
+	for _, arn := range []string{identity.UserIdentity.ARN, identity.UserIdentity.SessionContext.SessionIssuer.ARN} {
+		if arn != "" && principals[arn] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// createdResources returns the names of the resources the given CloudTrail
+// event created. Failed events did not create anything and parameters which
+// are overwritten existed before.
+func createdResources(e *cloudtrail.Event) []string {
+	types, ok := createEvents[aws.StringValue(e.EventName)]
+	if !ok || e.CloudTrailEvent == nil {
+		return nil
+	}
+
+	var event cloudTrailEvent
+	err := json.Unmarshal([]byte(*e.CloudTrailEvent), &event)
+	if err != nil || event.ErrorCode != "" || event.RequestParameters.Overwrite {
+		return nil
+	}
+
+	var names []string
+	for _, r := range e.Resources {
+		if r.ResourceName == nil {
+			continue
+		}
+		for _, t := range types {
+			if aws.StringValue(r.ResourceType) == t {
+				names = append(names, resourceName(*r.ResourceName))
+				break
+			}
+		}
+	}
+
+	return names
+}
+
+// resourceName returns the name or ID of a resource CloudTrail refers to by
+// the given name, which often is an ARN, like
+// `arn:aws:cloudformation:eu-west-1:123456789012:stack/ci-wip-a1b2c/<id>`.
+func resourceName(name string) string {
+	if !strings.HasPrefix(name, "arn:") {
+		return name
+	}
+
+	parts := strings.SplitN(name, ":", 6)
+	if len(parts) != 6 {
+		return name
+	}
+	service, resource := parts[2], parts[5]
+
+	switch {
+	case service == "cloudformation":
+		// Stacks are `stack/<name>/<id>` and stack sets `stackset/<name>:<id>`.
+		segments := strings.SplitN(resource, "/", 3)
+		if len(segments) < 2 {
+			return name
+		}
+		return strings.SplitN(segments[1], ":", 2)[0]
+	case service == "secretsmanager":
+		// Secret ARNs end with a random suffix, like `secret:<name>-AbCdEf`.
+		resource = strings.TrimPrefix(resource, "secret:")
+		if i := strings.LastIndex(resource, "-"); i >= 0 && len(resource)-i == 7 {
+			resource = resource[:i]
+		}
+		return resource
+	case service == "logs":
+		return strings.TrimSuffix(strings.TrimPrefix(resource, "log-group:"), ":*")
+	case strings.Contains(resource, "/"):
+		// Resources with path, like IAM roles, are named by the last segment.
+		return resource[strings.LastIndex(resource, "/")+1:]
+	case strings.Contains(resource, ":"):
+		// Resources like Lambda functions are `function:<name>[:<qualifier>]`.
+		return strings.SplitN(resource, ":", 3)[1]
+	}
+
+	return resource
+}
@@ -0,0 +1,211 @@
+package aws
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudtrail"
+	"github.com/giantswarm/micrologger/microloggertest"
+)
+
+const ciRoleEvent = `{"userIdentity":{"type":"AssumedRole","arn":"arn:aws:sts::123456789012:assumed-role/ci/session","sessionContext":{"sessionIssuer":{"arn":"arn:aws:iam::123456789012:role/ci"}}}}`
+
+type cloudTrailClientMock struct {
+	events []*cloudtrail.Event
+}
+
+func (c *cloudTrailClientMock) LookupEvents(*cloudtrail.LookupEventsInput) (*cloudtrail.LookupEventsOutput, error) {
+	return &cloudtrail.LookupEventsOutput{Events: c.events}, nil
+}
+
+func newCloudTrailEvent(name string, event string, resources ...*cloudtrail.Resource) *cloudtrail.Event {
+	return &cloudtrail.Event{
+		CloudTrailEvent: aws.String(event),
+		EventName:       aws.String(name),
+		Resources:       resources,
+	}
+}
+
+func newCloudTrailResource(resourceType string, name string) *cloudtrail.Resource {
+	return &cloudtrail.Resource{
+		ResourceName: aws.String(name),
+		ResourceType: aws.String(resourceType),
+	}
+}
+
+func TestIsCIPrincipalEvent(t *testing.T) {
+	principals := map[string]bool{
+		"arn:aws:iam::123456789012:role/ci":     true,
+		"arn:aws:iam::123456789012:user/ci-bot": true,
+	}
+
+	tcs := []struct {
+		event       string
+		expected    bool
+		description string
+	}{
+		{
+			description: "event of assumed ci role",
+			event:       `{"userIdentity":{"type":"AssumedRole","arn":"arn:aws:sts::123456789012:assumed-role/ci/session","sessionContext":{"sessionIssuer":{"arn":"arn:aws:iam::123456789012:role/ci"}}}}`,
+			expected:    true,
+		},
+		{
+			description: "event of ci user",
+			event:       `{"userIdentity":{"type":"IAMUser","arn":"arn:aws:iam::123456789012:user/ci-bot"}}`,
+			expected:    true,
+		},
+		{
+			description: "event of other assumed role",
+			event:       `{"userIdentity":{"type":"AssumedRole","arn":"arn:aws:sts::123456789012:assumed-role/admin/session","sessionContext":{"sessionIssuer":{"arn":"arn:aws:iam::123456789012:role/admin"}}}}`,
+			expected:    false,
+		},
+		{
+			description: "malformed event",
+			event:       `{`,
+			expected:    false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			e := &cloudtrail.Event{CloudTrailEvent: aws.String(tc.event)}
+
+			actual := isCIPrincipalEvent(e, principals)
+
+			if actual != tc.expected {
+				t.Errorf("want %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestHasCIPrefixCreatedByCI(t *testing.T) {
+	a := &Cleaner{
+		createdByCI: map[string]bool{"nightly-vpc": true},
+		prefixes:    defaultPrefixes,
+	}
+
+	if !a.hasCIPrefix("nightly-vpc") {
+		t.Errorf("want resource created by ci principal to be a ci resource")
+	}
+	if a.hasCIPrefix("nightly-subnet") {
+		t.Errorf("want other resource not to be a ci resource")
+	}
+}
+
+func TestCreatedResources(t *testing.T) {
+	tcs := []struct {
+		event       *cloudtrail.Event
+		expected    []string
+		description string
+	}{
+		{
+			description: "instance is created, its subnet and image are only used",
+			event: newCloudTrailEvent("RunInstances", ciRoleEvent,
+				newCloudTrailResource("AWS::EC2::Instance", "i-0123456789abcdef0"),
+				newCloudTrailResource("AWS::EC2::Subnet", "subnet-0123456789abcdef0"),
+				newCloudTrailResource("AWS::EC2::Ami", "ami-0123456789abcdef0"),
+			),
+			expected: []string{"i-0123456789abcdef0"},
+		},
+		{
+			description: "stack is named by its arn",
+			event: newCloudTrailEvent("CreateStack", ciRoleEvent,
+				newCloudTrailResource("AWS::CloudFormation::Stack", "arn:aws:cloudformation:eu-west-1:123456789012:stack/nightly-stack/8f0a5c70-0000-11ea-8d71-362b9e155667"),
+			),
+			expected: []string{"nightly-stack"},
+		},
+		{
+			description: "modified instance is not created",
+			event: newCloudTrailEvent("ModifyInstanceAttribute", ciRoleEvent,
+				newCloudTrailResource("AWS::EC2::Instance", "i-0123456789abcdef0"),
+			),
+			expected: nil,
+		},
+		{
+			description: "failed event does not create anything",
+			event: newCloudTrailEvent("CreateBucket", `{"errorCode":"BucketAlreadyExists"}`,
+				newCloudTrailResource("AWS::S3::Bucket", "nightly-bucket"),
+			),
+			expected: nil,
+		},
+		{
+			description: "overwritten parameter is not created",
+			event: newCloudTrailEvent("PutParameter", `{"requestParameters":{"name":"/nightly/token","overwrite":true}}`,
+				newCloudTrailResource("AWS::SSM::Parameter", "/nightly/token"),
+			),
+			expected: nil,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := createdResources(tc.event)
+
+			if len(actual) != len(tc.expected) {
+				t.Fatalf("want %v, got %v", tc.expected, actual)
+			}
+			for i := range tc.expected {
+				if actual[i] != tc.expected[i] {
+					t.Errorf("want %v, got %v", tc.expected, actual)
+				}
+			}
+		})
+	}
+}
+
+func TestResourceName(t *testing.T) {
+	tcs := []struct {
+		name     string
+		expected string
+	}{
+		{name: "nightly-bucket", expected: "nightly-bucket"},
+		{name: "arn:aws:s3:::nightly-bucket", expected: "nightly-bucket"},
+		{name: "arn:aws:cloudformation:eu-west-1:123456789012:stack/nightly-stack/8f0a5c70-0000-11ea-8d71-362b9e155667", expected: "nightly-stack"},
+		{name: "arn:aws:cloudformation:eu-west-1:123456789012:stackset/nightly-stackset:8f0a5c70-0000-11ea-8d71-362b9e155667", expected: "nightly-stackset"},
+		{name: "arn:aws:iam::123456789012:role/path/nightly-role", expected: "nightly-role"},
+		{name: "arn:aws:ec2:eu-west-1:123456789012:instance/i-0123456789abcdef0", expected: "i-0123456789abcdef0"},
+		{name: "arn:aws:lambda:eu-west-1:123456789012:function:nightly-function", expected: "nightly-function"},
+		{name: "arn:aws:logs:eu-west-1:123456789012:log-group:/aws/lambda/nightly-function:*", expected: "/aws/lambda/nightly-function"},
+		{name: "arn:aws:secretsmanager:eu-west-1:123456789012:secret:nightly-secret-AbCdEf", expected: "nightly-secret"},
+		{name: "arn:aws:sns:eu-west-1:123456789012:nightly-topic", expected: "nightly-topic"},
+	}
+
+	for _, tc := range tcs {
+		actual := resourceName(tc.name)
+		if actual != tc.expected {
+			t.Errorf("resourceName(%q): want %q, got %q", tc.name, tc.expected, actual)
+		}
+	}
+}
+
+func TestLoadCreatedByCI(t *testing.T) {
+	a := &Cleaner{
+		cloudTrailClient: &cloudTrailClientMock{
+			events: []*cloudtrail.Event{
+				newCloudTrailEvent("CreateStack", ciRoleEvent,
+					newCloudTrailResource("AWS::CloudFormation::Stack", "arn:aws:cloudformation:eu-west-1:123456789012:stack/nightly-stack/8f0a5c70-0000-11ea-8d71-362b9e155667"),
+				),
+				newCloudTrailEvent("UpdateStack", ciRoleEvent,
+					newCloudTrailResource("AWS::CloudFormation::Stack", "arn:aws:cloudformation:eu-west-1:123456789012:stack/office-vpn/8f0a5c70-0000-11ea-8d71-362b9e155667"),
+				),
+			},
+		},
+		ciPrincipals: []string{"arn:aws:iam::123456789012:role/ci"},
+		logger:       microloggertest.New(),
+		prefixes:     defaultPrefixes,
+	}
+
+	err := a.loadCreatedByCI(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !a.hasCIPrefix("nightly-stack") {
+		t.Errorf("want stack created by ci principal to be a ci resource")
+	}
+	if a.hasCIPrefix("office-vpn") {
+		t.Errorf("want stack modified by ci principal not to be a ci resource")
+	}
+}
@@ -0,0 +1,120 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/prometheusservice"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanPrometheusWorkspaces deletes the Amazon Managed Service for Prometheus
+// workspaces observability e2e tests create per run, after deleting their
+// rule groups namespaces and alertmanager definition.
+func (a *Cleaner) cleanPrometheusWorkspaces(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &prometheusservice.ListWorkspacesInput{}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.prometheusClient.ListWorkspaces(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, workspace := range o.Workspaces {
+			if !a.prometheusWorkspaceShouldBeDeleted(workspace) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that prometheus workspace %#q should be deleted", *workspace.WorkspaceId))
+
+			res := run.Resource{
+				ID:        *workspace.Arn,
+				Type:      "AWS::APS::Workspace",
+				Tags:      aws.StringValueMap(workspace.Tags),
+				CreatedAt: aws.TimeValue(workspace.CreatedAt),
+			}
+			err := a.run.DeleteResource(ctx, cleanerPrometheusWorkspaces, res, func() error {
+				return a.deletePrometheusWorkspace(ctx, workspace.WorkspaceId)
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting prometheus workspace %#q", *workspace.WorkspaceId), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) deletePrometheusWorkspace(ctx context.Context, id *string) error {
+	i := &prometheusservice.ListRuleGroupsNamespacesInput{
+		WorkspaceId: id,
+	}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.prometheusClient.ListRuleGroupsNamespaces(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, namespace := range o.RuleGroupsNamespaces {
+			_, err := a.prometheusClient.DeleteRuleGroupsNamespace(&prometheusservice.DeleteRuleGroupsNamespaceInput{WorkspaceId: id, Name: namespace.Name})
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	// workspaces without alertmanager definition report it as not found.
+	_, err = a.prometheusClient.DeleteAlertManagerDefinition(&prometheusservice.DeleteAlertManagerDefinitionInput{WorkspaceId: id})
+	if err != nil && !isAWSError(err, prometheusservice.ErrCodeResourceNotFoundException) {
+		return microerror.Mask(err)
+	}
+
+	_, err = a.prometheusClient.DeleteWorkspace(&prometheusservice.DeleteWorkspaceInput{WorkspaceId: id})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (a *Cleaner) prometheusWorkspaceShouldBeDeleted(workspace *prometheusservice.WorkspaceSummary) bool {
+	if workspace.Arn == nil || workspace.WorkspaceId == nil {
+		return false
+	}
+
+	if !a.hasCIPrefix(aws.StringValue(workspace.Alias)) && !a.isCITagged(aws.StringValueMap(workspace.Tags)) {
+		return false
+	}
+
+	// do not delete workspaces that are already being deleted.
+	if workspace.Status != nil && aws.StringValue(workspace.Status.StatusCode) == prometheusservice.WorkspaceStatusCodeDeleting {
+		return false
+	}
+
+	// do not delete recent workspaces.
+	if isRecent(workspace.CreatedAt, a.gracePeriod) {
+		return false
+	}
+
+	return true
+}
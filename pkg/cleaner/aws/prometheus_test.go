@@ -0,0 +1,75 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/prometheusservice"
+)
+
+func TestPrometheusWorkspaceShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		workspace   *prometheusservice.WorkspaceSummary
+		expected    bool
+		description string
+	}{
+		{
+			description: "old workspace of ci cluster should be deleted",
+			workspace:   newPrometheusWorkspace("metrics", "ci-a1b2c", prometheusservice.WorkspaceStatusCodeActive, time.Now().Add(-2*time.Hour)),
+			expected:    true,
+		},
+		{
+			description: "old ci named workspace should be deleted",
+			workspace:   newPrometheusWorkspace("e2e-a1b2c-metrics", "", prometheusservice.WorkspaceStatusCodeCreationFailed, time.Now().Add(-2*time.Hour)),
+			expected:    true,
+		},
+		{
+			description: "recent workspace of ci cluster should not be deleted",
+			workspace:   newPrometheusWorkspace("metrics", "ci-a1b2c", prometheusservice.WorkspaceStatusCodeActive, time.Now().Add(-time.Hour)),
+			expected:    false,
+		},
+		{
+			description: "deleting workspace of ci cluster should not be deleted",
+			workspace:   newPrometheusWorkspace("metrics", "ci-a1b2c", prometheusservice.WorkspaceStatusCodeDeleting, time.Now().Add(-2*time.Hour)),
+			expected:    false,
+		},
+		{
+			description: "old general workspace should not be deleted",
+			workspace:   newPrometheusWorkspace("metrics", "gauss", prometheusservice.WorkspaceStatusCodeActive, time.Now().Add(-2*time.Hour)),
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := a.prometheusWorkspaceShouldBeDeleted(tc.workspace)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.workspace.Alias, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func newPrometheusWorkspace(alias, cluster, status string, createdAt time.Time) *prometheusservice.WorkspaceSummary {
+	w := &prometheusservice.WorkspaceSummary{
+		Alias:     aws.String(alias),
+		Arn:       aws.String("arn:aws:aps:eu-central-1:123456789012:workspace/ws-0123"),
+		CreatedAt: aws.Time(createdAt),
+		Status: &prometheusservice.WorkspaceStatus{
+			StatusCode: aws.String(status),
+		},
+		WorkspaceId: aws.String("ws-0123"),
+	}
+	if cluster != "" {
+		w.Tags = map[string]*string{clusterTag: aws.String(cluster)}
+	}
+
+	return w
+}
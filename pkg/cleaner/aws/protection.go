@@ -0,0 +1,63 @@
+package aws
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/giantswarm/microerror"
+)
+
+// deleteProtected calls del to delete a CI resource which is possibly
+// protected from deletion, like a stack with termination protection or a DB
+// instance with deletion protection. The protection is disabled with disable
+// before deleting resources known to be protected. Resources whose deletion
+// fails as they turn out to be protected nevertheless, e.g. as protection
+// was enabled after they were listed, are retried once after disabling the
+// protection. Overriding protection is logged loudly.
+func (a *Cleaner) deleteProtected(kind string, id string, protected bool, disable func() error, del func() error) error {
+	if protected {
+		a.logProtectionOverride(kind, id)
+
+		err := disable()
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	err := del()
+	if err == nil {
+		return nil
+	} else if protected || !isProtectionError(err) {
+		return microerror.Mask(err)
+	}
+
+	a.logProtectionOverride(kind, id)
+
+	err = disable()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = del()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (a *Cleaner) logProtectionOverride(kind string, id string) {
+	a.logger.Log("level", "warning", "message", fmt.Sprintf("overriding protection of %s %#q to delete it", kind, id))
+}
+
+// isProtectionError checks if the given error was returned as the resource
+// to be deleted is protected, e.g. "Cannot delete protected DB Instance" or
+// "cannot be deleted while TerminationProtection is enabled".
+func isProtectionError(err error) bool {
+	if aerr, ok := microerror.Cause(err).(awserr.Error); ok {
+		return strings.Contains(strings.ToLower(aerr.Message()), "protect")
+	}
+
+	return false
+}
@@ -0,0 +1,76 @@
+package aws
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/giantswarm/micrologger/microloggertest"
+)
+
+func TestDeleteProtected(t *testing.T) {
+	protectedErr := awserr.New("InvalidParameterCombination", "Cannot delete protected DB Instance, please disable deletion protection and try again.", nil)
+
+	tcs := []struct {
+		protected   bool
+		errors      []error
+		expected    []string
+		expectedErr bool
+		description string
+	}{
+		{
+			description: "known protection is disabled before deleting",
+			protected:   true,
+			expected:    []string{"disable", "delete"},
+		},
+		{
+			description: "unprotected resource is deleted right away",
+			expected:    []string{"delete"},
+		},
+		{
+			description: "protection found when deleting is disabled and deletion retried",
+			errors:      []error{protectedErr},
+			expected:    []string{"delete", "disable", "delete"},
+		},
+		{
+			description: "other errors are not retried",
+			errors:      []error{errors.New("in use")},
+			expected:    []string{"delete"},
+			expectedErr: true,
+		},
+	}
+
+	a := &Cleaner{
+		logger: microloggertest.New(),
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			var calls []string
+			disable := func() error {
+				calls = append(calls, "disable")
+				return nil
+			}
+			errs := tc.errors
+			del := func() error {
+				calls = append(calls, "delete")
+				if len(errs) == 0 {
+					return nil
+				}
+				err := errs[0]
+				errs = errs[1:]
+				return err
+			}
+
+			err := a.deleteProtected("db instance", "ci-wip-a1b2c", tc.protected, disable, del)
+
+			if (err != nil) != tc.expectedErr {
+				t.Errorf("want error %t, got %#v", tc.expectedErr, err)
+			}
+			if !reflect.DeepEqual(calls, tc.expected) {
+				t.Errorf("want calls %v, got %v", tc.expected, calls)
+			}
+		})
+	}
+}
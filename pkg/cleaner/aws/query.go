@@ -0,0 +1,115 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/resourceexplorer2"
+	"github.com/giantswarm/microerror"
+)
+
+// queryCleaners are the cleaners which can be driven by Resource Explorer
+// views, as they consult queried instead of their in-code matching.
+var queryCleaners = map[string]bool{
+	cleanerBuckets:            true,
+	cleanerSecrets:            true,
+	cleanerSoftDeletedSecrets: true,
+	cleanerStacks:             true,
+}
+
+// loadQueries runs the Resource Explorer views configured for the given
+// cleaner and remembers the ARNs of the resources they return. The views
+// replace the in-code matching of the cleaner, so that the platform team can
+// maintain the matching rules in AWS without redeploying the cleaner.
+func (a *Cleaner) loadQueries(ctx context.Context, cleaner string) error {
+	view, ok := a.queries[cleaner]
+	if !ok {
+		return nil
+	}
+
+	viewARN, err := a.viewARN(view)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	matches := map[string]bool{}
+
+	var nextToken *string
+	err = paginate(ctx, &nextToken, func() (*string, error) {
+		i := &resourceexplorer2.SearchInput{
+			NextToken:   nextToken,
+			QueryString: aws.String(""),
+			ViewArn:     aws.String(viewARN),
+		}
+
+		o, err := a.resourceExplorerClient.Search(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, r := range o.Resources {
+			matches[aws.StringValue(r.Arn)] = true
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	a.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("view %#q matched %d resources for cleaner %#q", view, len(matches), cleaner))
+
+	a.queryMatches[cleaner] = matches
+
+	return nil
+}
+
+// queried returns whether the resource with the given ARN was matched by the
+// view configured for the given cleaner. ok is false when the cleaner has no
+// view configured and has to apply its in-code matching.
+func (a *Cleaner) queried(cleaner string, arn string) (matched bool, ok bool) {
+	matches, ok := a.queryMatches[cleaner]
+	if !ok {
+		return false, false
+	}
+
+	return matches[arn], true
+}
+
+// viewARN returns the ARN of the view with the given name. ARNs are returned
+// as they are.
+func (a *Cleaner) viewARN(view string) (string, error) {
+	if strings.HasPrefix(view, "arn:") {
+		return view, nil
+	}
+
+	var nextToken *string
+	for {
+		i := &resourceexplorer2.ListViewsInput{
+			NextToken: nextToken,
+		}
+
+		o, err := a.resourceExplorerClient.ListViews(i)
+		if err != nil {
+			return "", microerror.Mask(err)
+		}
+
+		for _, arn := range o.Views {
+			// View ARNs look like
+			// arn:aws:resource-explorer-2:<region>:<account>:view/<name>/<id>.
+			parts := strings.Split(aws.StringValue(arn), "/")
+			if len(parts) == 3 && parts[1] == view {
+				return aws.StringValue(arn), nil
+			}
+		}
+
+		if o.NextToken == nil {
+			break
+		}
+		nextToken = o.NextToken
+	}
+
+	return "", microerror.Maskf(notFoundError, "resource explorer view %#q", view)
+}
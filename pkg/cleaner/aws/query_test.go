@@ -0,0 +1,126 @@
+package aws
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/resourceexplorer2"
+	"github.com/giantswarm/micrologger/microloggertest"
+
+	"github.com/giantswarm/ci-cleaner/pkg/report"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+type resourceExplorerClientMock struct{}
+
+func (c *resourceExplorerClientMock) ListViews(*resourceexplorer2.ListViewsInput) (*resourceexplorer2.ListViewsOutput, error) {
+	o := &resourceexplorer2.ListViewsOutput{
+		Views: []*string{
+			aws.String("arn:aws:resource-explorer-2:eu-central-1:123456789012:view/default/1"),
+			aws.String("arn:aws:resource-explorer-2:eu-central-1:123456789012:view/ci-stacks/2"),
+		},
+	}
+
+	return o, nil
+}
+
+func (c *resourceExplorerClientMock) Search(i *resourceexplorer2.SearchInput) (*resourceexplorer2.SearchOutput, error) {
+	if aws.StringValue(i.ViewArn) != "arn:aws:resource-explorer-2:eu-central-1:123456789012:view/ci-stacks/2" {
+		return &resourceexplorer2.SearchOutput{}, nil
+	}
+
+	if i.NextToken == nil {
+		o := &resourceexplorer2.SearchOutput{
+			NextToken: aws.String("2"),
+			Resources: []*resourceexplorer2.Resource{{Arn: aws.String("arn:aws:cloudformation:eu-central-1:123456789012:stack/my-e2e/1")}},
+		}
+		return o, nil
+	}
+
+	return &resourceexplorer2.SearchOutput{}, nil
+}
+
+func TestQueries(t *testing.T) {
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+		queries:     map[string]string{cleanerStacks: "ci-stacks"},
+
+		queryMatches: map[string]map[string]bool{},
+
+		logger:                 microloggertest.New(),
+		resourceExplorerClient: &resourceExplorerClientMock{},
+	}
+
+	err := a.loadQueries(context.Background(), cleanerStacks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	old := time.Now().Add(-24 * time.Hour)
+	testCases := []struct {
+		name     string
+		stack    *cloudformation.Stack
+		expected bool
+	}{
+		{
+			name: "case 0: stack matched by the view is deleted",
+			stack: &cloudformation.Stack{
+				CreationTime: &old,
+				StackId:      aws.String("arn:aws:cloudformation:eu-central-1:123456789012:stack/my-e2e/1"),
+				StackName:    aws.String("my-e2e"),
+				StackStatus:  aws.String("CREATE_COMPLETE"),
+			},
+			expected: true,
+		},
+		{
+			name: "case 1: CI named stack not matched by the view is kept",
+			stack: &cloudformation.Stack{
+				CreationTime: &old,
+				StackId:      aws.String("arn:aws:cloudformation:eu-central-1:123456789012:stack/cluster-ci-a/1"),
+				StackName:    aws.String("cluster-ci-a"),
+				StackStatus:  aws.String("CREATE_COMPLETE"),
+			},
+			expected: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if result := a.stackShouldBeDeleted(tc.stack); result != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestQueriesOfUnsupportedCleaners(t *testing.T) {
+	r, err := run.New(run.Config{
+		Logger: microloggertest.New(),
+		Report: report.New("aws"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := ConfigFromSession(session.Must(session.NewSession(&aws.Config{Region: aws.String("eu-central-1")})))
+	c.Logger = microloggertest.New()
+	c.Run = r
+	c.ResourceExplorerClient = &resourceExplorerClientMock{}
+
+	c.Queries = map[string]string{cleanerStacks: "ci-stacks"}
+	_, err = New(c)
+	if err != nil {
+		t.Fatalf("expected view of cleaner %q to be accepted, got %#v", cleanerStacks, err)
+	}
+
+	c.Queries = map[string]string{cleanerVPCs: "ci-vpcs"}
+	_, err = New(c)
+	if !IsInvalidConfig(err) {
+		t.Errorf("expected view of cleaner %q to be rejected, got %#v", cleanerVPCs, err)
+	}
+}
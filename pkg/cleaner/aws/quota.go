@@ -0,0 +1,286 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/servicequotas"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/report"
+)
+
+const (
+	// quotaWarningUtilization is the utilization from which quotas are
+	// logged as warnings, so that alerts fire before CI gets blocked.
+	quotaWarningUtilization = 0.8
+
+	// elasticIPsAttribute is the account attribute holding the maximum
+	// number of Elastic IP addresses per region.
+	elasticIPsAttribute = "vpc-max-elastic-ips"
+)
+
+// reportQuotas adds the utilization of the quotas leaked CI resources
+// usually exhaust first to the report, like the number of VPCs per region,
+// and logs the ones close to their limit. Failures are only logged, as
+// looking up quotas must not fail the cleanup.
+func (a *Cleaner) reportQuotas(ctx context.Context) {
+	fns := []func(ctx context.Context) (report.Quota, error){
+		a.vpcQuota,
+		a.internetGatewayQuota,
+		a.natGatewayQuota,
+		a.elasticIPQuota,
+		a.roleQuota,
+	}
+
+	var quotas []report.Quota
+	for _, fn := range fns {
+		q, err := fn(ctx)
+		if err != nil {
+			a.logger.Log("level", "warning", "message", "failed looking up quota utilization", "stack", fmt.Sprintf("%#v", err))
+			continue
+		}
+
+		level := "info"
+		if q.Utilization() >= quotaWarningUtilization {
+			level = "warning"
+		}
+		a.logger.Log("level", level, "message", fmt.Sprintf("quota %#q is %.0f%% utilized, %d of %.0f", q.Name, q.Utilization()*100, q.Usage, q.Limit))
+
+		quotas = append(quotas, q)
+	}
+
+	a.run.SetQuotas(quotas)
+}
+
+func (a *Cleaner) vpcQuota(ctx context.Context) (report.Quota, error) {
+	limit, err := a.serviceQuotaLimit("vpc", "L-F678F1CE")
+	if err != nil {
+		return report.Quota{}, microerror.Mask(err)
+	}
+
+	var usage int
+	i := &ec2.DescribeVpcsInput{}
+	err = paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.ec2Client.DescribeVpcs(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		usage += len(o.Vpcs)
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		return report.Quota{}, microerror.Mask(err)
+	}
+
+	return report.Quota{Name: "vpcs-per-region", Usage: usage, Limit: limit}, nil
+}
+
+func (a *Cleaner) internetGatewayQuota(ctx context.Context) (report.Quota, error) {
+	limit, err := a.serviceQuotaLimit("vpc", "L-A4707A72")
+	if err != nil {
+		return report.Quota{}, microerror.Mask(err)
+	}
+
+	var usage int
+	i := &ec2.DescribeInternetGatewaysInput{}
+	err = paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.ec2Client.DescribeInternetGateways(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		usage += len(o.InternetGateways)
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		return report.Quota{}, microerror.Mask(err)
+	}
+
+	return report.Quota{Name: "internet-gateways-per-region", Usage: usage, Limit: limit}, nil
+}
+
+// natGatewayQuota returns the utilization of the NAT gateways per
+// availability zone in the zone with the most NAT gateways.
+func (a *Cleaner) natGatewayQuota(ctx context.Context) (report.Quota, error) {
+	limit, err := a.serviceQuotaLimit("vpc", "L-FE5A380F")
+	if err != nil {
+		return report.Quota{}, microerror.Mask(err)
+	}
+
+	var natGateways []*ec2.NatGateway
+	{
+		i := &ec2.DescribeNatGatewaysInput{
+			Filter: []*ec2.Filter{
+				{
+					Name:   aws.String("state"),
+					Values: aws.StringSlice([]string{ec2.NatGatewayStatePending, ec2.NatGatewayStateAvailable}),
+				},
+			},
+		}
+		err = paginate(ctx, &i.NextToken, func() (*string, error) {
+			o, err := a.ec2Client.DescribeNatGateways(i)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			natGateways = append(natGateways, o.NatGateways...)
+
+			return o.NextToken, nil
+		})
+		if err != nil {
+			return report.Quota{}, microerror.Mask(err)
+		}
+	}
+
+	// zones maps the subnets of the NAT gateways to their availability
+	// zone, as NAT gateways only tell their subnet.
+	zones := map[string]string{}
+	if len(natGateways) != 0 {
+		var subnetIDs []*string
+		for _, n := range natGateways {
+			subnetIDs = append(subnetIDs, n.SubnetId)
+		}
+
+		i := &ec2.DescribeSubnetsInput{
+			Filters: []*ec2.Filter{
+				{
+					Name:   aws.String("subnet-id"),
+					Values: subnetIDs,
+				},
+			},
+		}
+		err = paginate(ctx, &i.NextToken, func() (*string, error) {
+			o, err := a.ec2Client.DescribeSubnets(i)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			for _, s := range o.Subnets {
+				zones[aws.StringValue(s.SubnetId)] = aws.StringValue(s.AvailabilityZone)
+			}
+
+			return o.NextToken, nil
+		})
+		if err != nil {
+			return report.Quota{}, microerror.Mask(err)
+		}
+	}
+
+	return report.Quota{Name: "nat-gateways-per-availability-zone", Usage: maxPerZone(natGateways, zones), Limit: limit}, nil
+}
+
+func (a *Cleaner) elasticIPQuota(ctx context.Context) (report.Quota, error) {
+	i := &ec2.DescribeAccountAttributesInput{
+		AttributeNames: aws.StringSlice([]string{elasticIPsAttribute}),
+	}
+	o, err := a.ec2Client.DescribeAccountAttributes(i)
+	if err != nil {
+		return report.Quota{}, microerror.Mask(err)
+	}
+
+	limit, err := accountAttributeLimit(o, elasticIPsAttribute)
+	if err != nil {
+		return report.Quota{}, microerror.Mask(err)
+	}
+
+	addresses, err := a.ec2Client.DescribeAddresses(&ec2.DescribeAddressesInput{})
+	if err != nil {
+		return report.Quota{}, microerror.Mask(err)
+	}
+
+	return report.Quota{Name: "elastic-ips-per-region", Usage: len(addresses.Addresses), Limit: limit}, nil
+}
+
+// roleQuota returns the utilization of the IAM roles of the account, which
+// IAM tells along with their quota.
+func (a *Cleaner) roleQuota(ctx context.Context) (report.Quota, error) {
+	o, err := a.iamClient.GetAccountSummary(&iam.GetAccountSummaryInput{})
+	if err != nil {
+		return report.Quota{}, microerror.Mask(err)
+	}
+
+	q := report.Quota{
+		Name:  "iam-roles",
+		Usage: int(aws.Int64Value(o.SummaryMap["Roles"])),
+		Limit: float64(aws.Int64Value(o.SummaryMap["RolesQuota"])),
+	}
+
+	return q, nil
+}
+
+// serviceQuotaLimit returns the value of the given quota of Service Quotas.
+// Quotas which were never increased are only known by their default value.
+func (a *Cleaner) serviceQuotaLimit(serviceCode, quotaCode string) (float64, error) {
+	var quota *servicequotas.ServiceQuota
+	{
+		o, err := a.serviceQuotasClient.GetServiceQuota(&servicequotas.GetServiceQuotaInput{
+			QuotaCode:   aws.String(quotaCode),
+			ServiceCode: aws.String(serviceCode),
+		})
+		if isAWSError(err, servicequotas.ErrCodeNoSuchResourceException) {
+			o, err := a.serviceQuotasClient.GetAWSDefaultServiceQuota(&servicequotas.GetAWSDefaultServiceQuotaInput{
+				QuotaCode:   aws.String(quotaCode),
+				ServiceCode: aws.String(serviceCode),
+			})
+			if err != nil {
+				return 0, microerror.Mask(err)
+			}
+			quota = o.Quota
+		} else if err != nil {
+			return 0, microerror.Mask(err)
+		} else {
+			quota = o.Quota
+		}
+	}
+
+	if quota == nil || quota.Value == nil {
+		return 0, microerror.Maskf(notFoundError, "quota %#q of service %#q", quotaCode, serviceCode)
+	}
+
+	return *quota.Value, nil
+}
+
+// accountAttributeLimit returns the numeric value of the given account
+// attribute.
+func accountAttributeLimit(o *ec2.DescribeAccountAttributesOutput, name string) (float64, error) {
+	for _, attribute := range o.AccountAttributes {
+		if aws.StringValue(attribute.AttributeName) != name || len(attribute.AttributeValues) == 0 {
+			continue
+		}
+
+		limit, err := strconv.ParseFloat(aws.StringValue(attribute.AttributeValues[0].AttributeValue), 64)
+		if err != nil {
+			return 0, microerror.Mask(err)
+		}
+
+		return limit, nil
+	}
+
+	return 0, microerror.Maskf(notFoundError, "account attribute %#q", name)
+}
+
+// maxPerZone returns the number of NAT gateways in the availability zone
+// with the most NAT gateways. zones maps subnets to their zone.
+func maxPerZone(natGateways []*ec2.NatGateway, zones map[string]string) int {
+	perZone := map[string]int{}
+	for _, n := range natGateways {
+		perZone[zones[aws.StringValue(n.SubnetId)]]++
+	}
+
+	var max int
+	for _, count := range perZone {
+		if count > max {
+			max = count
+		}
+	}
+
+	return max
+}
@@ -0,0 +1,120 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/servicequotas"
+)
+
+type serviceQuotasClientMock struct {
+	applied  map[string]float64
+	defaults map[string]float64
+}
+
+func (c *serviceQuotasClientMock) GetAWSDefaultServiceQuota(i *servicequotas.GetAWSDefaultServiceQuotaInput) (*servicequotas.GetAWSDefaultServiceQuotaOutput, error) {
+	v, ok := c.defaults[*i.QuotaCode]
+	if !ok {
+		return nil, awserr.New(servicequotas.ErrCodeNoSuchResourceException, "no such quota", nil)
+	}
+
+	return &servicequotas.GetAWSDefaultServiceQuotaOutput{Quota: &servicequotas.ServiceQuota{Value: aws.Float64(v)}}, nil
+}
+
+func (c *serviceQuotasClientMock) GetServiceQuota(i *servicequotas.GetServiceQuotaInput) (*servicequotas.GetServiceQuotaOutput, error) {
+	v, ok := c.applied[*i.QuotaCode]
+	if !ok {
+		return nil, awserr.New(servicequotas.ErrCodeNoSuchResourceException, "no such quota", nil)
+	}
+
+	return &servicequotas.GetServiceQuotaOutput{Quota: &servicequotas.ServiceQuota{Value: aws.Float64(v)}}, nil
+}
+
+func TestServiceQuotaLimit(t *testing.T) {
+	a := &Cleaner{
+		serviceQuotasClient: &serviceQuotasClientMock{
+			applied:  map[string]float64{"L-F678F1CE": 20},
+			defaults: map[string]float64{"L-F678F1CE": 5, "L-A4707A72": 5},
+		},
+	}
+
+	tcs := []struct {
+		quotaCode   string
+		expected    float64
+		description string
+	}{
+		{
+			description: "increased quota",
+			quotaCode:   "L-F678F1CE",
+			expected:    20,
+		},
+		{
+			description: "quota which was never increased",
+			quotaCode:   "L-A4707A72",
+			expected:    5,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual, err := a.serviceQuotaLimit("vpc", tc.quotaCode)
+			if err != nil {
+				t.Fatalf("expected nil, got %#v", err)
+			}
+
+			if actual != tc.expected {
+				t.Errorf("want %g, got %g", tc.expected, actual)
+			}
+		})
+	}
+
+	_, err := a.serviceQuotaLimit("vpc", "L-unknown")
+	if err == nil {
+		t.Errorf("expected error for unknown quota")
+	}
+}
+
+func TestAccountAttributeLimit(t *testing.T) {
+	o := &ec2.DescribeAccountAttributesOutput{
+		AccountAttributes: []*ec2.AccountAttribute{
+			{
+				AttributeName:   aws.String(elasticIPsAttribute),
+				AttributeValues: []*ec2.AccountAttributeValue{{AttributeValue: aws.String("5")}},
+			},
+		},
+	}
+
+	actual, err := accountAttributeLimit(o, elasticIPsAttribute)
+	if err != nil {
+		t.Fatalf("expected nil, got %#v", err)
+	}
+	if actual != 5 {
+		t.Errorf("want 5, got %g", actual)
+	}
+
+	_, err = accountAttributeLimit(o, "max-instances")
+	if !IsNotFound(err) {
+		t.Errorf("expected not found error, got %#v", err)
+	}
+}
+
+func TestMaxPerZone(t *testing.T) {
+	natGateways := []*ec2.NatGateway{
+		{NatGatewayId: aws.String("nat-1"), SubnetId: aws.String("subnet-a")},
+		{NatGatewayId: aws.String("nat-2"), SubnetId: aws.String("subnet-b")},
+		{NatGatewayId: aws.String("nat-3"), SubnetId: aws.String("subnet-c")},
+	}
+	zones := map[string]string{
+		"subnet-a": "eu-central-1a",
+		"subnet-b": "eu-central-1b",
+		"subnet-c": "eu-central-1a",
+	}
+
+	actual := maxPerZone(natGateways, zones)
+
+	if actual != 2 {
+		t.Errorf("want 2, got %d", actual)
+	}
+}
@@ -0,0 +1,392 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanRDS deletes the RDS DB instances and Aurora DB clusters integration
+// tests create, without final snapshot and after removing their deletion
+// protection, followed by the CI DB subnet groups nothing uses anymore. DB
+// clusters can only be deleted once their instances are gone, which is
+// tracked by later runs.
+func (a *Cleaner) cleanRDS(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	// inUse holds the names of the DB subnet groups of the DB instances and
+	// clusters which exist.
+	inUse := map[string]bool{}
+
+	{
+		i := &rds.DescribeDBInstancesInput{}
+		err := paginate(ctx, &i.Marker, func() (*string, error) {
+			o, err := a.rdsClient.DescribeDBInstances(i)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			for _, instance := range o.DBInstances {
+				if instance.DBSubnetGroup != nil {
+					inUse[aws.StringValue(instance.DBSubnetGroup.DBSubnetGroupName)] = true
+				}
+
+				if !a.dbInstanceShouldBeDeleted(instance) {
+					continue
+				}
+
+				err := a.deleteDBInstance(ctx, instance)
+				if err != nil {
+					errors.Append(microerror.Mask(err))
+					a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting db instance %#q", *instance.DBInstanceIdentifier), "stack", fmt.Sprintf("%#v", err))
+				}
+			}
+
+			return o.Marker, nil
+		})
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			return errors
+		}
+	}
+
+	{
+		i := &rds.DescribeDBClustersInput{}
+		err := paginate(ctx, &i.Marker, func() (*string, error) {
+			o, err := a.rdsClient.DescribeDBClusters(i)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			for _, cluster := range o.DBClusters {
+				if cluster.DBSubnetGroup != nil {
+					inUse[*cluster.DBSubnetGroup] = true
+				}
+
+				if !a.dbClusterShouldBeDeleted(cluster) {
+					continue
+				}
+
+				err := a.deleteDBCluster(ctx, cluster)
+				if err != nil {
+					errors.Append(microerror.Mask(err))
+					a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting db cluster %#q", *cluster.DBClusterIdentifier), "stack", fmt.Sprintf("%#v", err))
+				}
+			}
+
+			return o.Marker, nil
+		})
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			return errors
+		}
+	}
+
+	{
+		i := &rds.DescribeDBSubnetGroupsInput{}
+		err := paginate(ctx, &i.Marker, func() (*string, error) {
+			o, err := a.rdsClient.DescribeDBSubnetGroups(i)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			for _, group := range o.DBSubnetGroups {
+				if group.DBSubnetGroupName == nil || inUse[*group.DBSubnetGroupName] || !a.hasCIPrefix(*group.DBSubnetGroupName) {
+					continue
+				}
+
+				err := a.deleteDBSubnetGroup(ctx, group)
+				if err != nil {
+					errors.Append(microerror.Mask(err))
+					a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting db subnet group %#q", *group.DBSubnetGroupName), "stack", fmt.Sprintf("%#v", err))
+				}
+			}
+
+			return o.Marker, nil
+		})
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			return errors
+		}
+	}
+
+	{
+		err := a.run.PollPending(ctx, cleanerRDS, "AWS::RDS::DBInstance", a.pollDBInstance)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+		}
+
+		err = a.run.PollPending(ctx, cleanerRDS, "AWS::RDS::DBCluster", a.pollDBCluster)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+		}
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) deleteDBInstance(ctx context.Context, instance *rds.DBInstance) error {
+	a.logger.Log("level", "info", "message", fmt.Sprintf("found that db instance %#q should be deleted", *instance.DBInstanceIdentifier))
+
+	res := run.Resource{
+		ID:   *instance.DBInstanceIdentifier,
+		Type: "AWS::RDS::DBInstance",
+		Tags: rdsTags(instance.TagList),
+		Cost: fmt.Sprintf("%s DB instance, billed hourly until deleted", aws.StringValue(instance.DBInstanceClass)),
+	}
+	if instance.InstanceCreateTime != nil {
+		res.CreatedAt = *instance.InstanceCreateTime
+	}
+	if aws.BoolValue(instance.DeletionProtection) {
+		res.Note = "deletion protection is overridden"
+	}
+	start := func() (string, error) {
+		disable := func() error {
+			i := &rds.ModifyDBInstanceInput{
+				ApplyImmediately:     aws.Bool(true),
+				DBInstanceIdentifier: instance.DBInstanceIdentifier,
+				DeletionProtection:   aws.Bool(false),
+			}
+			_, err := a.rdsClient.ModifyDBInstance(i)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			return nil
+		}
+
+		var deleted bool
+		del := func() error {
+			i := &rds.DeleteDBInstanceInput{
+				DBInstanceIdentifier: instance.DBInstanceIdentifier,
+				SkipFinalSnapshot:    aws.Bool(true),
+			}
+			// the automated backups of Aurora instances belong to their cluster.
+			if instance.DBClusterIdentifier == nil {
+				i.DeleteAutomatedBackups = aws.Bool(true)
+			}
+			_, err := a.rdsClient.DeleteDBInstance(i)
+			if isAWSError(err, rds.ErrCodeDBInstanceNotFoundFault) {
+				deleted = true
+				return nil
+			} else if err != nil {
+				return microerror.Mask(err)
+			}
+
+			return nil
+		}
+
+		err := a.deleteProtected("db instance", *instance.DBInstanceIdentifier, aws.BoolValue(instance.DeletionProtection), disable, del)
+		if err != nil {
+			return "", microerror.Mask(err)
+		}
+		if deleted {
+			return "", nil
+		}
+
+		return *instance.DBInstanceIdentifier, nil
+	}
+	err := a.run.DeleteResourceAsync(ctx, cleanerRDS, res, start, a.pollDBInstance)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (a *Cleaner) pollDBInstance(ctx context.Context, id string) (bool, error) {
+	_, err := a.rdsClient.DescribeDBInstances(&rds.DescribeDBInstancesInput{DBInstanceIdentifier: aws.String(id)})
+	if isAWSError(err, rds.ErrCodeDBInstanceNotFoundFault) {
+		return true, nil
+	} else if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	return false, nil
+}
+
+func (a *Cleaner) deleteDBCluster(ctx context.Context, cluster *rds.DBCluster) error {
+	a.logger.Log("level", "info", "message", fmt.Sprintf("found that db cluster %#q should be deleted", *cluster.DBClusterIdentifier))
+
+	res := run.Resource{
+		ID:   *cluster.DBClusterIdentifier,
+		Type: "AWS::RDS::DBCluster",
+		Tags: rdsTags(cluster.TagList),
+	}
+	if cluster.ClusterCreateTime != nil {
+		res.CreatedAt = *cluster.ClusterCreateTime
+	}
+	if aws.BoolValue(cluster.DeletionProtection) {
+		res.Note = "deletion protection is overridden"
+	}
+	start := func() (string, error) {
+		disable := func() error {
+			i := &rds.ModifyDBClusterInput{
+				ApplyImmediately:    aws.Bool(true),
+				DBClusterIdentifier: cluster.DBClusterIdentifier,
+				DeletionProtection:  aws.Bool(false),
+			}
+			_, err := a.rdsClient.ModifyDBCluster(i)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			return nil
+		}
+
+		var deleted bool
+		del := func() error {
+			i := &rds.DeleteDBClusterInput{
+				DBClusterIdentifier:    cluster.DBClusterIdentifier,
+				DeleteAutomatedBackups: aws.Bool(true),
+				SkipFinalSnapshot:      aws.Bool(true),
+			}
+			_, err := a.rdsClient.DeleteDBCluster(i)
+			if isAWSError(err, rds.ErrCodeDBClusterNotFoundFault) {
+				deleted = true
+				return nil
+			} else if err != nil {
+				return microerror.Mask(err)
+			}
+
+			return nil
+		}
+
+		err := a.deleteProtected("db cluster", *cluster.DBClusterIdentifier, aws.BoolValue(cluster.DeletionProtection), disable, del)
+		if err != nil {
+			return "", microerror.Mask(err)
+		}
+		if deleted {
+			return "", nil
+		}
+
+		return *cluster.DBClusterIdentifier, nil
+	}
+	err := a.run.DeleteResourceAsync(ctx, cleanerRDS, res, start, a.pollDBCluster)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (a *Cleaner) pollDBCluster(ctx context.Context, id string) (bool, error) {
+	_, err := a.rdsClient.DescribeDBClusters(&rds.DescribeDBClustersInput{DBClusterIdentifier: aws.String(id)})
+	if isAWSError(err, rds.ErrCodeDBClusterNotFoundFault) {
+		return true, nil
+	} else if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	return false, nil
+}
+
+// deleteDBSubnetGroup deletes the given DB subnet group once it was first
+// found unused more than the grace period ago, as DB subnet groups do not
+// tell when they were created.
+func (a *Cleaner) deleteDBSubnetGroup(ctx context.Context, group *rds.DBSubnetGroup) error {
+	seen, err := a.run.FirstSeen(cleanerRDS, *group.DBSubnetGroupName)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	// do not delete recent db subnet groups.
+	if time.Since(seen) < a.gracePeriod {
+		return nil
+	}
+
+	a.logger.Log("level", "info", "message", fmt.Sprintf("found that db subnet group %#q should be deleted", *group.DBSubnetGroupName))
+
+	res := run.Resource{
+		ID:     *group.DBSubnetGroupName,
+		Type:   "AWS::RDS::DBSubnetGroup",
+		Reason: run.ReasonUnused,
+	}
+	err = a.run.DeleteResource(ctx, cleanerRDS, res, func() error {
+		_, err := a.rdsClient.DeleteDBSubnetGroup(&rds.DeleteDBSubnetGroupInput{DBSubnetGroupName: group.DBSubnetGroupName})
+		if err != nil && !isAWSError(err, rds.ErrCodeDBSubnetGroupNotFoundFault) {
+			return microerror.Mask(err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (a *Cleaner) dbInstanceShouldBeDeleted(instance *rds.DBInstance) bool {
+	if instance.DBInstanceIdentifier == nil {
+		return false
+	}
+
+	if !a.hasCIPrefix(*instance.DBInstanceIdentifier) && !a.isCITagged(rdsTags(instance.TagList)) {
+		return false
+	}
+
+	// do not delete db instances which are being deleted already.
+	if aws.StringValue(instance.DBInstanceStatus) == "deleting" {
+		return false
+	}
+
+	// do not delete recent db instances. Instances which are being created
+	// do not tell when they were created yet.
+	if isRecent(instance.InstanceCreateTime, a.gracePeriod) {
+		return false
+	}
+
+	return true
+}
+
+func (a *Cleaner) dbClusterShouldBeDeleted(cluster *rds.DBCluster) bool {
+	if cluster.DBClusterIdentifier == nil {
+		return false
+	}
+
+	if !a.hasCIPrefix(*cluster.DBClusterIdentifier) && !a.isCITagged(rdsTags(cluster.TagList)) {
+		return false
+	}
+
+	// do not delete db clusters before their instances are gone.
+	if len(cluster.DBClusterMembers) > 0 {
+		return false
+	}
+
+	// do not delete db clusters which are being deleted already.
+	if aws.StringValue(cluster.Status) == "deleting" {
+		return false
+	}
+
+	// do not delete recent db clusters. Clusters which are being created do
+	// not tell when they were created yet.
+	if isRecent(cluster.ClusterCreateTime, a.gracePeriod) {
+		return false
+	}
+
+	return true
+}
+
+func rdsTags(rdsTags []*rds.Tag) map[string]string {
+	if len(rdsTags) == 0 {
+		return nil
+	}
+
+	tags := map[string]string{}
+	for _, t := range rdsTags {
+		tags[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+	}
+
+	return tags
+}
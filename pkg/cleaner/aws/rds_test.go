@@ -0,0 +1,151 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/rds"
+)
+
+func TestDBInstanceShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		name        string
+		status      string
+		created     time.Time
+		expected    bool
+		description string
+	}{
+		{
+			description: "old ci db instance should be deleted",
+			name:        "e2e-a1b2c-postgres",
+			status:      "available",
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    true,
+		},
+		{
+			description: "old ci db instance being deleted should not be deleted",
+			name:        "e2e-a1b2c-postgres",
+			status:      "deleting",
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    false,
+		},
+		{
+			description: "recent ci db instance should not be deleted",
+			name:        "e2e-a1b2c-postgres",
+			status:      "available",
+			created:     time.Now().Add(-time.Hour),
+			expected:    false,
+		},
+		{
+			description: "ci db instance being created should not be deleted",
+			name:        "e2e-a1b2c-postgres",
+			status:      "creating",
+			expected:    false,
+		},
+		{
+			description: "old general db instance should not be deleted",
+			name:        "grafana",
+			status:      "available",
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			instance := &rds.DBInstance{
+				DBInstanceIdentifier: aws.String(tc.name),
+				DBInstanceStatus:     aws.String(tc.status),
+			}
+			if !tc.created.IsZero() {
+				instance.InstanceCreateTime = aws.Time(tc.created)
+			}
+
+			actual := a.dbInstanceShouldBeDeleted(instance)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", tc.name, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestDBClusterShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		name        string
+		members     []*rds.DBClusterMember
+		tags        []*rds.Tag
+		created     time.Time
+		expected    bool
+		description string
+	}{
+		{
+			description: "old ci db cluster without instances should be deleted",
+			name:        "e2e-a1b2c-aurora",
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    true,
+		},
+		{
+			description: "old db cluster tagged with ci cluster should be deleted",
+			name:        "aurora",
+			tags:        []*rds.Tag{{Key: aws.String(clusterTag), Value: aws.String("ci-wip-a1b2c")}},
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    true,
+		},
+		{
+			description: "old ci db cluster with instances should not be deleted",
+			name:        "e2e-a1b2c-aurora",
+			members:     []*rds.DBClusterMember{{DBInstanceIdentifier: aws.String("e2e-a1b2c-aurora-1")}},
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    false,
+		},
+		{
+			description: "recent ci db cluster without instances should not be deleted",
+			name:        "e2e-a1b2c-aurora",
+			created:     time.Now().Add(-time.Hour),
+			expected:    false,
+		},
+		{
+			description: "ci db cluster being created should not be deleted",
+			name:        "e2e-a1b2c-aurora",
+			expected:    false,
+		},
+		{
+			description: "old general db cluster without instances should not be deleted",
+			name:        "aurora",
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			cluster := &rds.DBCluster{
+				DBClusterIdentifier: aws.String(tc.name),
+				DBClusterMembers:    tc.members,
+				Status:              aws.String("available"),
+				TagList:             tc.tags,
+			}
+			if !tc.created.IsZero() {
+				cluster.ClusterCreateTime = aws.Time(tc.created)
+			}
+
+			actual := a.dbClusterShouldBeDeleted(cluster)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", tc.name, tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53resolver"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanResolver deletes the Route53 Resolver rules and endpoints created by
+// hybrid DNS tests. Rules are disassociated from their VPCs and deleted
+// first, as outbound endpoints cannot be deleted while rules forward to them.
+// Endpoints are billed hourly per ENI.
+func (a *Cleaner) cleanResolver(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	var nextToken *string
+	err := paginate(ctx, &nextToken, func() (*string, error) {
+		o, err := a.route53ResolverClient.ListResolverRules(&route53resolver.ListResolverRulesInput{NextToken: nextToken})
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, rule := range o.ResolverRules {
+			if !a.resolverResourceShouldBeDeleted(rule.Name, rule.CreationTime, rule.Status) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that resolver rule %#q should be deleted", *rule.Id))
+
+			res := run.Resource{
+				ID:        *rule.Id,
+				Type:      "AWS::Route53Resolver::ResolverRule",
+				CreatedAt: aws.TimeValue(parseTimestamp(rule.CreationTime)),
+			}
+			err := a.run.DeleteResource(ctx, cleanerResolver, res, func() error {
+				return a.deleteResolverRule(rule.Id)
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting resolver rule %#q", *rule.Id), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	nextToken = nil
+	err = paginate(ctx, &nextToken, func() (*string, error) {
+		o, err := a.route53ResolverClient.ListResolverEndpoints(&route53resolver.ListResolverEndpointsInput{NextToken: nextToken})
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, endpoint := range o.ResolverEndpoints {
+			if !a.resolverResourceShouldBeDeleted(endpoint.Name, endpoint.CreationTime, endpoint.Status) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that resolver endpoint %#q should be deleted", *endpoint.Id))
+
+			res := run.Resource{
+				ID:          *endpoint.Id,
+				Type:        "AWS::Route53Resolver::ResolverEndpoint",
+				CreatedAt:   aws.TimeValue(parseTimestamp(endpoint.CreationTime)),
+				Cost:        fmt.Sprintf("%s resolver endpoint with %d ENI(s)", aws.StringValue(endpoint.Direction), aws.Int64Value(endpoint.IpAddressCount)),
+				MonthlyCost: float64(aws.Int64Value(endpoint.IpAddressCount)) * resolverENIHourlyCost * hoursPerMonth,
+			}
+			err := a.run.DeleteResource(ctx, cleanerResolver, res, func() error {
+				_, err := a.route53ResolverClient.DeleteResolverEndpoint(&route53resolver.DeleteResolverEndpointInput{ResolverEndpointId: endpoint.Id})
+				return err
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting resolver endpoint %#q", *endpoint.Id), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) deleteResolverRule(id *string) error {
+	i := &route53resolver.ListResolverRuleAssociationsInput{
+		Filters: []*route53resolver.Filter{
+			{
+				Name:   aws.String("ResolverRuleId"),
+				Values: []*string{id},
+			},
+		},
+	}
+
+	o, err := a.route53ResolverClient.ListResolverRuleAssociations(i)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, association := range o.ResolverRuleAssociations {
+		if aws.StringValue(association.Status) == route53resolver.ResolverRuleAssociationStatusDeleting {
+			continue
+		}
+
+		i := &route53resolver.DisassociateResolverRuleInput{
+			ResolverRuleId: id,
+			VPCId:          association.VPCId,
+		}
+
+		_, err := a.route53ResolverClient.DisassociateResolverRule(i)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	_, err = a.route53ResolverClient.DeleteResolverRule(&route53resolver.DeleteResolverRuleInput{ResolverRuleId: id})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (a *Cleaner) resolverResourceShouldBeDeleted(name *string, creationTime *string, status *string) bool {
+	if name == nil || !a.hasCIPrefix(*name) {
+		return false
+	}
+
+	// do not delete resources that are already being deleted.
+	if aws.StringValue(status) == "DELETING" {
+		return false
+	}
+
+	// do not delete recent resources.
+	if isRecent(parseTimestamp(creationTime), a.gracePeriod) {
+		return false
+	}
+
+	return true
+}
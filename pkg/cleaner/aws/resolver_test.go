@@ -0,0 +1,77 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestResolverResourceShouldBeDeleted(t *testing.T) {
+	old := aws.String(time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339))
+	recent := aws.String(time.Now().UTC().Format(time.RFC3339))
+
+	tcs := []struct {
+		name         *string
+		creationTime *string
+		status       *string
+		expected     bool
+		description  string
+	}{
+		{
+			description:  "old ci rule should be deleted",
+			name:         aws.String("ci-wip-a1b2c-forward"),
+			creationTime: old,
+			status:       aws.String("COMPLETE"),
+			expected:     true,
+		},
+		{
+			description:  "recent ci rule should not be deleted",
+			name:         aws.String("ci-wip-a1b2c-forward"),
+			creationTime: recent,
+			status:       aws.String("COMPLETE"),
+			expected:     false,
+		},
+		{
+			description:  "ci endpoint being deleted should not be deleted",
+			name:         aws.String("e2e-a1b2c-inbound"),
+			creationTime: old,
+			status:       aws.String("DELETING"),
+			expected:     false,
+		},
+		{
+			description: "ci rule without creation time should not be deleted",
+			name:        aws.String("ci-wip-a1b2c-forward"),
+			status:      aws.String("COMPLETE"),
+			expected:    false,
+		},
+		{
+			description:  "ci rule with malformed creation time should not be deleted",
+			name:         aws.String("ci-wip-a1b2c-forward"),
+			creationTime: aws.String("yesterday"),
+			status:       aws.String("COMPLETE"),
+			expected:     false,
+		},
+		{
+			description:  "unnamed rule should not be deleted",
+			creationTime: old,
+			status:       aws.String("COMPLETE"),
+			expected:     false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := a.resolverResourceShouldBeDeleted(tc.name, tc.creationTime, tc.status)
+
+			if actual != tc.expected {
+				t.Errorf("want %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanRouteTables deletes the custom CI route tables left in half-deleted
+// VPCs, after disassociating them from their subnets and gateways. The main
+// route table of a VPC is deleted along with the VPC.
+func (a *Cleaner) cleanRouteTables(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &ec2.DescribeRouteTablesInput{}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.ec2Client.DescribeRouteTables(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, t := range o.RouteTables {
+			if !a.isCIRouteTable(t) {
+				continue
+			}
+
+			seen, err := a.run.FirstSeen(cleanerRouteTables, *t.RouteTableId)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				continue
+			}
+
+			// do not delete recent route tables.
+			if time.Since(seen) < a.gracePeriod {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that route table %#q should be deleted", *t.RouteTableId))
+
+			res := run.Resource{
+				ID:   *t.RouteTableId,
+				Type: kindRouteTable,
+				Tags: ec2Tags(t.Tags),
+			}
+			t := t
+			err = a.run.DeleteResource(ctx, cleanerRouteTables, res, func() error {
+				return a.deleteRouteTable(t)
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting route table %#q", *t.RouteTableId), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// deleteRouteTable disassociates the given route table from its subnets and
+// gateways and deletes it.
+func (a *Cleaner) deleteRouteTable(t *ec2.RouteTable) error {
+	for _, association := range t.Associations {
+		i := &ec2.DisassociateRouteTableInput{
+			AssociationId: association.RouteTableAssociationId,
+		}
+		_, err := a.ec2Client.DisassociateRouteTable(i)
+		if err != nil && !isAWSError(err, "InvalidAssociationID.NotFound") {
+			return microerror.Mask(err)
+		}
+	}
+
+	_, err := a.ec2Client.DeleteRouteTable(&ec2.DeleteRouteTableInput{RouteTableId: t.RouteTableId})
+	if err != nil && !isAWSError(err, "InvalidRouteTableID.NotFound") {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// isCIRouteTable returns whether the given route table belongs to CI and is
+// not the main route table of its VPC, which cannot be deleted on its own.
+func (a *Cleaner) isCIRouteTable(t *ec2.RouteTable) bool {
+	if t.RouteTableId == nil || isMainRouteTable(t) {
+		return false
+	}
+
+	return a.isCITagged(ec2Tags(t.Tags))
+}
@@ -0,0 +1,59 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func TestIsCIRouteTable(t *testing.T) {
+	tcs := []struct {
+		tags        []*ec2.Tag
+		main        bool
+		expected    bool
+		description string
+	}{
+		{
+			description: "custom route table of ci cluster should be deleted",
+			tags:        []*ec2.Tag{{Key: aws.String(clusterTag), Value: aws.String("ci-wip-a1b2c")}},
+			expected:    true,
+		},
+		{
+			description: "main route table of ci cluster should not be deleted",
+			tags:        []*ec2.Tag{{Key: aws.String(clusterTag), Value: aws.String("ci-wip-a1b2c")}},
+			main:        true,
+			expected:    false,
+		},
+		{
+			description: "general custom route table should not be deleted",
+			tags:        []*ec2.Tag{{Key: aws.String("Name"), Value: aws.String("installation-private")}},
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		prefixes: defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			rt := &ec2.RouteTable{
+				Associations: []*ec2.RouteTableAssociation{
+					{
+						Main:                    aws.Bool(tc.main),
+						RouteTableAssociationId: aws.String("rtbassoc-0123456789abcdef0"),
+					},
+				},
+				RouteTableId: aws.String("rtb-0123456789abcdef0"),
+				Tags:         tc.tags,
+			}
+
+			actual := a.isCIRouteTable(rt)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *rt.RouteTableId, tc.expected, actual)
+			}
+		})
+	}
+}
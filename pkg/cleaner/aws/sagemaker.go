@@ -0,0 +1,323 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sagemaker"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanSageMaker deletes the SageMaker endpoints, endpoint configs, models
+// and notebook instances ML platform tests leak. Endpoints are billed per
+// hour and deleted first. Notebook instances have to be stopped before they
+// can be deleted, which is tracked by later runs.
+func (a *Cleaner) cleanSageMaker(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	err := a.cleanSageMakerEndpoints(ctx)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+	}
+
+	err = a.cleanSageMakerEndpointConfigs(ctx)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+	}
+
+	err = a.cleanSageMakerModels(ctx)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+	}
+
+	err = a.cleanSageMakerNotebookInstances(ctx)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) cleanSageMakerEndpoints(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &sagemaker.ListEndpointsInput{}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.sageMakerClient.ListEndpoints(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, endpoint := range o.Endpoints {
+			if aws.StringValue(endpoint.EndpointStatus) == sagemaker.EndpointStatusDeleting {
+				continue
+			}
+			if !a.sageMakerResourceShouldBeDeleted(endpoint.EndpointName, endpoint.CreationTime) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that sagemaker endpoint %#q should be deleted", *endpoint.EndpointName))
+
+			res := run.Resource{
+				ID:        *endpoint.EndpointArn,
+				Type:      "AWS::SageMaker::Endpoint",
+				CreatedAt: aws.TimeValue(endpoint.CreationTime),
+				Cost:      "SageMaker endpoint, billed hourly until deleted",
+			}
+			err := a.run.DeleteResource(ctx, cleanerSageMaker, res, func() error {
+				_, err := a.sageMakerClient.DeleteEndpoint(&sagemaker.DeleteEndpointInput{EndpointName: endpoint.EndpointName})
+				if err != nil {
+					return microerror.Mask(err)
+				}
+
+				return nil
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting sagemaker endpoint %#q", *endpoint.EndpointName), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) cleanSageMakerEndpointConfigs(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &sagemaker.ListEndpointConfigsInput{}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.sageMakerClient.ListEndpointConfigs(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, config := range o.EndpointConfigs {
+			if !a.sageMakerResourceShouldBeDeleted(config.EndpointConfigName, config.CreationTime) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that sagemaker endpoint config %#q should be deleted", *config.EndpointConfigName))
+
+			res := run.Resource{
+				ID:        *config.EndpointConfigArn,
+				Type:      "AWS::SageMaker::EndpointConfig",
+				CreatedAt: aws.TimeValue(config.CreationTime),
+			}
+			err := a.run.DeleteResource(ctx, cleanerSageMaker, res, func() error {
+				_, err := a.sageMakerClient.DeleteEndpointConfig(&sagemaker.DeleteEndpointConfigInput{EndpointConfigName: config.EndpointConfigName})
+				if err != nil {
+					return microerror.Mask(err)
+				}
+
+				return nil
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting sagemaker endpoint config %#q", *config.EndpointConfigName), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) cleanSageMakerModels(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &sagemaker.ListModelsInput{}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.sageMakerClient.ListModels(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, model := range o.Models {
+			if !a.sageMakerResourceShouldBeDeleted(model.ModelName, model.CreationTime) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that sagemaker model %#q should be deleted", *model.ModelName))
+
+			res := run.Resource{
+				ID:        *model.ModelArn,
+				Type:      "AWS::SageMaker::Model",
+				CreatedAt: aws.TimeValue(model.CreationTime),
+			}
+			err := a.run.DeleteResource(ctx, cleanerSageMaker, res, func() error {
+				_, err := a.sageMakerClient.DeleteModel(&sagemaker.DeleteModelInput{ModelName: model.ModelName})
+				if err != nil {
+					return microerror.Mask(err)
+				}
+
+				return nil
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting sagemaker model %#q", *model.ModelName), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) cleanSageMakerNotebookInstances(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &sagemaker.ListNotebookInstancesInput{}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.sageMakerClient.ListNotebookInstances(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, notebook := range o.NotebookInstances {
+			if !a.notebookInstanceShouldBeDeleted(notebook) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that sagemaker notebook instance %#q should be deleted", *notebook.NotebookInstanceName))
+
+			res := run.Resource{
+				ID:        *notebook.NotebookInstanceArn,
+				Type:      "AWS::SageMaker::NotebookInstance",
+				CreatedAt: aws.TimeValue(notebook.CreationTime),
+				Cost:      fmt.Sprintf("%s %s notebook instance", aws.StringValue(notebook.NotebookInstanceStatus), aws.StringValue(notebook.InstanceType)),
+			}
+			notebook := notebook
+			start := func() (string, error) {
+				return a.deleteNotebookInstance(notebook.NotebookInstanceName, aws.StringValue(notebook.NotebookInstanceStatus))
+			}
+			err := a.run.DeleteResourceAsync(ctx, cleanerSageMaker, res, start, a.pollNotebookInstance)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting sagemaker notebook instance %#q", *notebook.NotebookInstanceName), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	err = a.run.PollPending(ctx, cleanerSageMaker, "AWS::SageMaker::NotebookInstance", a.pollNotebookInstance)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// deleteNotebookInstance deletes the given notebook instance, or stops it
+// first when it is in service. The name of the notebook instance is returned
+// to poll the deletion.
+func (a *Cleaner) deleteNotebookInstance(name *string, status string) (string, error) {
+	if status == sagemaker.NotebookInstanceStatusInService {
+		_, err := a.sageMakerClient.StopNotebookInstance(&sagemaker.StopNotebookInstanceInput{NotebookInstanceName: name})
+		if err != nil {
+			return "", microerror.Mask(err)
+		}
+
+		return *name, nil
+	}
+
+	_, err := a.sageMakerClient.DeleteNotebookInstance(&sagemaker.DeleteNotebookInstanceInput{NotebookInstanceName: name})
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return *name, nil
+}
+
+// pollNotebookInstance deletes the notebook instance with the given name
+// once it is stopped.
+func (a *Cleaner) pollNotebookInstance(ctx context.Context, name string) (bool, error) {
+	o, err := a.sageMakerClient.DescribeNotebookInstance(&sagemaker.DescribeNotebookInstanceInput{NotebookInstanceName: aws.String(name)})
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	switch status := aws.StringValue(o.NotebookInstanceStatus); status {
+	case sagemaker.NotebookInstanceStatusStopped, sagemaker.NotebookInstanceStatusFailed:
+		_, err := a.sageMakerClient.DeleteNotebookInstance(&sagemaker.DeleteNotebookInstanceInput{NotebookInstanceName: aws.String(name)})
+		if err != nil {
+			return false, microerror.Mask(err)
+		}
+
+		return false, nil
+	case sagemaker.NotebookInstanceStatusStopping, sagemaker.NotebookInstanceStatusDeleting:
+		return false, nil
+	default:
+		return false, microerror.Maskf(executionFailedError, "notebook instance %#q is in status %#q", name, status)
+	}
+}
+
+func (a *Cleaner) notebookInstanceShouldBeDeleted(notebook *sagemaker.NotebookInstanceSummary) bool {
+	if notebook.NotebookInstanceArn == nil {
+		return false
+	}
+
+	// only notebook instances which can be stopped or deleted right away are
+	// considered, while pending ones are considered in later runs.
+	switch aws.StringValue(notebook.NotebookInstanceStatus) {
+	case sagemaker.NotebookInstanceStatusInService, sagemaker.NotebookInstanceStatusStopped, sagemaker.NotebookInstanceStatusFailed:
+	default:
+		return false
+	}
+
+	return a.sageMakerResourceShouldBeDeleted(notebook.NotebookInstanceName, notebook.CreationTime)
+}
+
+func (a *Cleaner) sageMakerResourceShouldBeDeleted(name *string, creationTime *time.Time) bool {
+	if name == nil || !a.hasCIPrefix(*name) {
+		return false
+	}
+
+	// do not delete recent resources.
+	if isRecent(creationTime, a.gracePeriod) {
+		return false
+	}
+
+	return true
+}
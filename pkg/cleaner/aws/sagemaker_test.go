@@ -0,0 +1,67 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sagemaker"
+)
+
+func TestNotebookInstanceShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		notebook    *sagemaker.NotebookInstanceSummary
+		expected    bool
+		description string
+	}{
+		{
+			description: "old ci notebook instance in service should be deleted",
+			notebook:    newNotebookInstance("ci-wip-a1b2c-notebook", sagemaker.NotebookInstanceStatusInService, time.Now().Add(-2*time.Hour)),
+			expected:    true,
+		},
+		{
+			description: "old stopped ci notebook instance should be deleted",
+			notebook:    newNotebookInstance("e2e-a1b2c-notebook", sagemaker.NotebookInstanceStatusStopped, time.Now().Add(-2*time.Hour)),
+			expected:    true,
+		},
+		{
+			description: "old stopping ci notebook instance should not be deleted yet",
+			notebook:    newNotebookInstance("ci-wip-a1b2c-notebook", sagemaker.NotebookInstanceStatusStopping, time.Now().Add(-2*time.Hour)),
+			expected:    false,
+		},
+		{
+			description: "recent ci notebook instance should not be deleted",
+			notebook:    newNotebookInstance("ci-wip-a1b2c-notebook", sagemaker.NotebookInstanceStatusInService, time.Now().Add(-time.Hour)),
+			expected:    false,
+		},
+		{
+			description: "old general notebook instance should not be deleted",
+			notebook:    newNotebookInstance("research", sagemaker.NotebookInstanceStatusInService, time.Now().Add(-2*time.Hour)),
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := a.notebookInstanceShouldBeDeleted(tc.notebook)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.notebook.NotebookInstanceName, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func newNotebookInstance(name, status string, creationTime time.Time) *sagemaker.NotebookInstanceSummary {
+	return &sagemaker.NotebookInstanceSummary{
+		CreationTime:           aws.Time(creationTime),
+		NotebookInstanceArn:    aws.String("arn:aws:sagemaker:eu-central-1:123456789012:notebook-instance/" + name),
+		NotebookInstanceName:   aws.String(name),
+		NotebookInstanceStatus: aws.String(status),
+	}
+}
@@ -0,0 +1,113 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanSecrets deletes the per-cluster secrets CI clusters write, like
+// `/ci-wip-a1b2c/kubeconfig`, which are billed per secret and month. They are
+// deleted without recovery window, so that they neither keep being billed nor
+// block a rerun of the same pipeline. Secrets already scheduled for deletion
+// are left to cleanSoftDeletedSecrets.
+func (a *Cleaner) cleanSecrets(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &secretsmanager.ListSecretsInput{}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.secretsManagerClient.ListSecrets(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, secret := range o.SecretList {
+			if !a.secretShouldBeDeleted(secret) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that secret %#q should be deleted", *secret.Name))
+
+			res := run.Resource{
+				ID:          *secret.Name,
+				Type:        "AWS::SecretsManager::Secret",
+				Tags:        secretTags(secret.Tags),
+				CreatedAt:   aws.TimeValue(secret.CreatedDate),
+				MonthlyCost: secretMonthlyCost,
+			}
+			arn := secret.ARN
+			err := a.run.DeleteResource(ctx, cleanerSecrets, res, func() error {
+				i := &secretsmanager.DeleteSecretInput{
+					ForceDeleteWithoutRecovery: aws.Bool(true),
+					SecretId:                   arn,
+				}
+
+				_, err := a.secretsManagerClient.DeleteSecret(i)
+				if isAWSError(err, secretsmanager.ErrCodeResourceNotFoundException) {
+					return nil
+				} else if err != nil {
+					return microerror.Mask(err)
+				}
+
+				return nil
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting secret %#q", *secret.Name), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) secretShouldBeDeleted(secret *secretsmanager.SecretListEntry) bool {
+	if secret.Name == nil || secret.ARN == nil {
+		return false
+	}
+
+	// secrets scheduled for deletion are handled by cleanSoftDeletedSecrets.
+	if secret.DeletedDate != nil {
+		return false
+	}
+
+	// do not delete recent secrets.
+	if isRecent(secret.CreatedDate, a.gracePeriod) {
+		return false
+	}
+
+	if matched, ok := a.queried(cleanerSecrets, *secret.ARN); ok {
+		return matched
+	}
+
+	return a.hasCIPrefix(strings.TrimPrefix(*secret.Name, "/")) || a.isCITagged(secretTags(secret.Tags))
+}
+
+func secretTags(secretTags []*secretsmanager.Tag) map[string]string {
+	if len(secretTags) == 0 {
+		return nil
+	}
+
+	tags := map[string]string{}
+	for _, t := range secretTags {
+		tags[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+	}
+
+	return tags
+}
@@ -0,0 +1,82 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+)
+
+func TestSecretShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		secret      *secretsmanager.SecretListEntry
+		expected    bool
+		description string
+	}{
+		{
+			description: "old ci secret with path name should be deleted",
+			secret: &secretsmanager.SecretListEntry{
+				ARN:         aws.String("arn:aws:secretsmanager:eu-central-1:123456789012:secret:/ci-wip-a1b2c/kubeconfig-abcdef"),
+				Name:        aws.String("/ci-wip-a1b2c/kubeconfig"),
+				CreatedDate: aws.Time(time.Now().Add(-2 * time.Hour)),
+			},
+			expected: true,
+		},
+		{
+			description: "old secret of a ci cluster by tag should be deleted",
+			secret: &secretsmanager.SecretListEntry{
+				ARN:         aws.String("arn:aws:secretsmanager:eu-central-1:123456789012:secret:kubeconfig-abcdef"),
+				Name:        aws.String("kubeconfig"),
+				CreatedDate: aws.Time(time.Now().Add(-2 * time.Hour)),
+				Tags: []*secretsmanager.Tag{
+					{Key: aws.String(clusterTag), Value: aws.String("ci-wip-a1b2c")},
+				},
+			},
+			expected: true,
+		},
+		{
+			description: "recent ci secret should not be deleted",
+			secret: &secretsmanager.SecretListEntry{
+				ARN:         aws.String("arn:aws:secretsmanager:eu-central-1:123456789012:secret:/ci-wip-a1b2c/kubeconfig-abcdef"),
+				Name:        aws.String("/ci-wip-a1b2c/kubeconfig"),
+				CreatedDate: aws.Time(time.Now().Add(-time.Hour)),
+			},
+			expected: false,
+		},
+		{
+			description: "old soft-deleted ci secret should not be deleted",
+			secret: &secretsmanager.SecretListEntry{
+				ARN:         aws.String("arn:aws:secretsmanager:eu-central-1:123456789012:secret:/ci-wip-a1b2c/kubeconfig-abcdef"),
+				Name:        aws.String("/ci-wip-a1b2c/kubeconfig"),
+				CreatedDate: aws.Time(time.Now().Add(-2 * time.Hour)),
+				DeletedDate: aws.Time(time.Now()),
+			},
+			expected: false,
+		},
+		{
+			description: "old general secret should not be deleted",
+			secret: &secretsmanager.SecretListEntry{
+				ARN:         aws.String("arn:aws:secretsmanager:eu-central-1:123456789012:secret:/gauss/kubeconfig-abcdef"),
+				Name:        aws.String("/gauss/kubeconfig"),
+				CreatedDate: aws.Time(time.Now().Add(-2 * time.Hour)),
+			},
+			expected: false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := a.secretShouldBeDeleted(tc.secret)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.secret.Name, tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanSecurityGroups deletes the security groups CI VPC teardowns leave
+// behind. CI security groups often reference each other, so the rules of the
+// other CI security groups referencing a group are revoked before deleting
+// it, which resolves circular dependencies.
+func (a *Cleaner) cleanSecurityGroups(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	var groups []*ec2.SecurityGroup
+	{
+		i := &ec2.DescribeSecurityGroupsInput{}
+		err := paginate(ctx, &i.NextToken, func() (*string, error) {
+			o, err := a.ec2Client.DescribeSecurityGroups(i)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			for _, group := range o.SecurityGroups {
+				if a.isCISecurityGroup(group) {
+					groups = append(groups, group)
+				}
+			}
+
+			return o.NextToken, nil
+		})
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			return errors
+		}
+	}
+
+	for _, group := range groups {
+		seen, err := a.run.FirstSeen(cleanerSecurityGroups, *group.GroupId)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			continue
+		}
+
+		// do not delete recent security groups.
+		if time.Since(seen) < a.gracePeriod {
+			continue
+		}
+
+		a.logger.Log("level", "info", "message", fmt.Sprintf("found that security group %#q should be deleted", *group.GroupId))
+
+		res := run.Resource{
+			ID:   *group.GroupId,
+			Type: "AWS::EC2::SecurityGroup",
+			Tags: ec2Tags(group.Tags),
+		}
+		group := group
+		err = a.run.DeleteResource(ctx, cleanerSecurityGroups, res, func() error {
+			return a.deleteSecurityGroup(group, groups)
+		})
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting security group %#q", *group.GroupId), "stack", fmt.Sprintf("%#v", err))
+		}
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// deleteSecurityGroup deletes the given security group after revoking the
+// rules of the related security groups which reference it, as these would
+// block deleting it.
+func (a *Cleaner) deleteSecurityGroup(group *ec2.SecurityGroup, related []*ec2.SecurityGroup) error {
+	for _, r := range related {
+		if aws.StringValue(r.GroupId) == aws.StringValue(group.GroupId) {
+			continue
+		}
+
+		ingress := referencingPermissions(r.IpPermissions, *group.GroupId)
+		if len(ingress) != 0 {
+			i := &ec2.RevokeSecurityGroupIngressInput{
+				GroupId:       r.GroupId,
+				IpPermissions: ingress,
+			}
+
+			_, err := a.ec2Client.RevokeSecurityGroupIngress(i)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+
+		egress := referencingPermissions(r.IpPermissionsEgress, *group.GroupId)
+		if len(egress) != 0 {
+			i := &ec2.RevokeSecurityGroupEgressInput{
+				GroupId:       r.GroupId,
+				IpPermissions: egress,
+			}
+
+			_, err := a.ec2Client.RevokeSecurityGroupEgress(i)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+	}
+
+	_, err := a.ec2Client.DeleteSecurityGroup(&ec2.DeleteSecurityGroupInput{GroupId: group.GroupId})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// referencingPermissions returns the parts of the given permissions which
+// reference the security group with the given ID.
+func referencingPermissions(permissions []*ec2.IpPermission, groupID string) []*ec2.IpPermission {
+	var referencing []*ec2.IpPermission
+	for _, p := range permissions {
+		var pairs []*ec2.UserIdGroupPair
+		for _, pair := range p.UserIdGroupPairs {
+			if aws.StringValue(pair.GroupId) == groupID {
+				pairs = append(pairs, pair)
+			}
+		}
+
+		if len(pairs) == 0 {
+			continue
+		}
+
+		referencing = append(referencing, &ec2.IpPermission{
+			FromPort:         p.FromPort,
+			IpProtocol:       p.IpProtocol,
+			ToPort:           p.ToPort,
+			UserIdGroupPairs: pairs,
+		})
+	}
+
+	return referencing
+}
+
+func (a *Cleaner) isCISecurityGroup(group *ec2.SecurityGroup) bool {
+	if group.GroupId == nil {
+		return false
+	}
+
+	// default security groups are deleted along with their VPC.
+	if aws.StringValue(group.GroupName) == "default" {
+		return false
+	}
+
+	return a.hasCIPrefix(aws.StringValue(group.GroupName)) || a.isCITagged(ec2Tags(group.Tags))
+}
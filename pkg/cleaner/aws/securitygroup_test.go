@@ -0,0 +1,89 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func TestIsCISecurityGroup(t *testing.T) {
+	tcs := []struct {
+		group       *ec2.SecurityGroup
+		expected    bool
+		description string
+	}{
+		{
+			description: "ci named security group should be deleted",
+			group:       newSecurityGroup("ci-wip-a1b2c-worker", nil),
+			expected:    true,
+		},
+		{
+			description: "security group tagged by ci cluster should be deleted",
+			group:       newSecurityGroup("worker", []*ec2.Tag{{Key: aws.String(clusterTag), Value: aws.String("ci-a1b2c")}}),
+			expected:    true,
+		},
+		{
+			description: "default security group of ci vpc should not be deleted",
+			group:       newSecurityGroup("default", []*ec2.Tag{{Key: aws.String(clusterTag), Value: aws.String("ci-a1b2c")}}),
+			expected:    false,
+		},
+		{
+			description: "general security group should not be deleted",
+			group:       newSecurityGroup("bastion", nil),
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		prefixes: defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := a.isCISecurityGroup(tc.group)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.group.GroupName, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestReferencingPermissions(t *testing.T) {
+	permissions := []*ec2.IpPermission{
+		{
+			IpProtocol: aws.String("tcp"),
+			FromPort:   aws.Int64(0),
+			ToPort:     aws.Int64(65535),
+			UserIdGroupPairs: []*ec2.UserIdGroupPair{
+				{GroupId: aws.String("sg-master")},
+				{GroupId: aws.String("sg-slave")},
+			},
+		},
+		{
+			IpProtocol: aws.String("tcp"),
+			FromPort:   aws.Int64(22),
+			ToPort:     aws.Int64(22),
+			IpRanges: []*ec2.IpRange{
+				{CidrIp: aws.String("0.0.0.0/0")},
+			},
+		},
+	}
+
+	actual := referencingPermissions(permissions, "sg-master")
+	if len(actual) != 1 {
+		t.Fatalf("want 1 permission, got %d", len(actual))
+	}
+	if len(actual[0].UserIdGroupPairs) != 1 || *actual[0].UserIdGroupPairs[0].GroupId != "sg-master" {
+		t.Errorf("want only the pair referencing %q, got %v", "sg-master", actual[0].UserIdGroupPairs)
+	}
+}
+
+func newSecurityGroup(name string, tags []*ec2.Tag) *ec2.SecurityGroup {
+	return &ec2.SecurityGroup{
+		GroupId:   aws.String("sg-0123456789abcdef0"),
+		GroupName: aws.String(name),
+		Tags:      tags,
+	}
+}
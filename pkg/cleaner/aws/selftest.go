@@ -0,0 +1,182 @@
+package aws
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/selftest"
+)
+
+// SelfTestProbes returns the probes of the self-test, which create canary
+// resources the cleaners are expected to delete: a bucket holding a tiny
+// object in the given region and an NS record in our root zone delegating to
+// a nameserver which does not exist.
+func (a *Cleaner) SelfTestProbes(region string) []selftest.Probe {
+	probes := []selftest.Probe{
+		&bucketProbe{cleaner: a, region: region},
+		&delegationProbe{cleaner: a},
+	}
+
+	return probes
+}
+
+type bucketProbe struct {
+	cleaner *Cleaner
+	region  string
+}
+
+func (p *bucketProbe) Name() string {
+	return "s3-bucket"
+}
+
+func (p *bucketProbe) Create(ctx context.Context, name string) (string, error) {
+	i := &s3.CreateBucketInput{
+		Bucket: aws.String(name),
+	}
+	// us-east-1 is the default location and must not be given explicitly.
+	if p.region != "" && p.region != "us-east-1" {
+		i.CreateBucketConfiguration = &s3.CreateBucketConfiguration{
+			LocationConstraint: aws.String(p.region),
+		}
+	}
+
+	_, err := p.cleaner.s3Client.CreateBucket(i)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	// the object makes sure buckets are emptied before they are deleted.
+	_, err = p.cleaner.s3Client.PutObject(&s3.PutObjectInput{
+		Body:   strings.NewReader("canary"),
+		Bucket: aws.String(name),
+		Key:    aws.String("canary"),
+	})
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return name, nil
+}
+
+func (p *bucketProbe) Exists(ctx context.Context, id string) (bool, error) {
+	_, err := p.cleaner.s3Client.HeadBucket(&s3.HeadBucketInput{Bucket: aws.String(id)})
+	if isAWSError(err, "NotFound") || isAWSError(err, s3.ErrCodeNoSuchBucket) {
+		return false, nil
+	} else if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	return true, nil
+}
+
+type delegationProbe struct {
+	cleaner *Cleaner
+}
+
+func (p *delegationProbe) Name() string {
+	return "delegation-record"
+}
+
+func (p *delegationProbe) Create(ctx context.Context, name string) (string, error) {
+	zone, err := p.rootZone(ctx)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	r := &route53.ResourceRecordSet{
+		Name: aws.String(name + "." + aws.StringValue(zone.Name)),
+		ResourceRecords: []*route53.ResourceRecord{
+			{Value: aws.String("ns1.canary.invalid.")},
+		},
+		TTL:  aws.Int64(300),
+		Type: aws.String(route53.RRTypeNs),
+	}
+	i := &route53.ChangeResourceRecordSetsInput{
+		ChangeBatch: &route53.ChangeBatch{
+			Changes: []*route53.Change{
+				{
+					Action:            aws.String(route53.ChangeActionUpsert),
+					ResourceRecordSet: r,
+				},
+			},
+		},
+		HostedZoneId: zone.Id,
+	}
+
+	_, err = p.cleaner.route53Client.ChangeResourceRecordSets(i)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return *zone.Id + "/" + *r.Name, nil
+}
+
+// Exists checks for the record of the given ID, which is made of the hosted
+// zone ID and the record name like the IDs the delegation cleaner reports.
+func (p *delegationProbe) Exists(ctx context.Context, id string) (bool, error) {
+	i := strings.LastIndex(id, "/")
+	if i == -1 {
+		return false, microerror.Maskf(executionFailedError, "invalid delegation record ID %#q", id)
+	}
+	zoneID, name := id[:i], id[i+1:]
+
+	o, err := p.cleaner.route53Client.ListResourceRecordSets(&route53.ListResourceRecordSetsInput{
+		HostedZoneId:    aws.String(zoneID),
+		MaxItems:        aws.String("1"),
+		StartRecordName: aws.String(name),
+		StartRecordType: aws.String(route53.RRTypeNs),
+	})
+	if isAWSError(err, route53.ErrCodeNoSuchHostedZone) {
+		return false, nil
+	} else if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	for _, r := range o.ResourceRecordSets {
+		if aws.StringValue(r.Name) == name && aws.StringValue(r.Type) == route53.RRTypeNs {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// rootZone returns the first of our root zones the delegation cleaner looks
+// at.
+func (p *delegationProbe) rootZone(ctx context.Context) (*route53.HostedZone, error) {
+	var zone *route53.HostedZone
+
+	i := &route53.ListHostedZonesInput{}
+	err := paginate(ctx, &i.Marker, func() (*string, error) {
+		o, err := p.cleaner.route53Client.ListHostedZones(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, z := range o.HostedZones {
+			if p.cleaner.isRootZone(z) {
+				zone = z
+				return nil, nil
+			}
+		}
+
+		if !aws.BoolValue(o.IsTruncated) {
+			return nil, nil
+		}
+		return o.NextMarker, nil
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	if zone == nil {
+		return nil, microerror.Maskf(notFoundError, "no root zone under %#q found", ciZoneDomain)
+	}
+
+	return zone, nil
+}
@@ -0,0 +1,38 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/giantswarm/ci-cleaner/pkg/selftest"
+)
+
+// TestSelfTestCanariesShouldBeDeleted makes sure the canary resources of the
+// self-test are picked up by the cleaners, as the self-test would fail
+// otherwise.
+func TestSelfTestCanariesShouldBeDeleted(t *testing.T) {
+	name := selftest.NamePrefix + "a1b2c3d4"
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	bucket := &s3.Bucket{
+		Name:         aws.String(name),
+		CreationDate: aws.Time(time.Now().Add(-2 * time.Hour)),
+	}
+	if !a.bucketShouldBeDeleted(bucket, func() map[string]string { return nil }) {
+		t.Errorf("checking if bucket %q should be deleted, want %t, got %t", name, true, false)
+	}
+
+	zone := &route53.HostedZone{Id: aws.String("/hostedzone/Z1"), Name: aws.String("k8s.gigantic.io.")}
+	r := &route53.ResourceRecordSet{Name: aws.String(name + ".k8s.gigantic.io."), Type: aws.String(route53.RRTypeNs)}
+	if !a.delegationRecordShouldBeDeleted(zone, r, time.Now().Add(-2*time.Hour)) {
+		t.Errorf("checking if delegation record %q should be deleted, want %t, got %t", *r.Name, true, false)
+	}
+}
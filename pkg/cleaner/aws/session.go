@@ -0,0 +1,101 @@
+package aws
+
+import (
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/service/acm"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/batch"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudhsmv2"
+	"github.com/aws/aws-sdk-go/service/cloudtrail"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ecr"
+	"github.com/aws/aws-sdk-go/service/efs"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/emr"
+	"github.com/aws/aws-sdk-go/service/firehose"
+	"github.com/aws/aws-sdk-go/service/guardduty"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/imagebuilder"
+	"github.com/aws/aws-sdk-go/service/inspector"
+	"github.com/aws/aws-sdk-go/service/kafka"
+	"github.com/aws/aws-sdk-go/service/kinesis"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/lambda"
+	"github.com/aws/aws-sdk-go/service/licensemanager"
+	"github.com/aws/aws-sdk-go/service/macie2"
+	"github.com/aws/aws-sdk-go/service/managedgrafana"
+	"github.com/aws/aws-sdk-go/service/networkfirewall"
+	"github.com/aws/aws-sdk-go/service/organizations"
+	"github.com/aws/aws-sdk-go/service/prometheusservice"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go/service/resourceexplorer2"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/route53resolver"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/sagemaker"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/servicediscovery"
+	"github.com/aws/aws-sdk-go/service/servicequotas"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/synthetics"
+)
+
+// ConfigFromSession returns a config holding clients of all services the
+// cleaners use, created from the given session. Tools embedding the cleaners
+// only have to add the logger, the run and their policies.
+func ConfigFromSession(p client.ConfigProvider) *Config {
+	c := &Config{
+		ACMClient:              acm.New(p),
+		AutoScalingClient:      autoscaling.New(p),
+		BatchClient:            batch.New(p),
+		CFClient:               cloudformation.New(p),
+		CloudHSMClient:         cloudhsmv2.New(p),
+		CloudTrailClient:       cloudtrail.New(p),
+		CloudWatchLogsClient:   cloudwatchlogs.New(p),
+		DynamoDBClient:         dynamodb.New(p),
+		EC2Client:              ec2.New(p),
+		ECRClient:              ecr.New(p),
+		EFSClient:              efs.New(p),
+		EKSClient:              eks.New(p),
+		ELBClient:              elb.New(p),
+		ELBV2Client:            elbv2.New(p),
+		EMRClient:              emr.New(p),
+		FirehoseClient:         firehose.New(p),
+		GrafanaClient:          managedgrafana.New(p),
+		GuardDutyClient:        guardduty.New(p),
+		IAMClient:              iam.New(p),
+		ImageBuilderClient:     imagebuilder.New(p),
+		InspectorClient:        inspector.New(p),
+		KafkaClient:            kafka.New(p),
+		KinesisClient:          kinesis.New(p),
+		KMSClient:              kms.New(p),
+		LambdaClient:           lambda.New(p),
+		LicenseManagerClient:   licensemanager.New(p),
+		MacieClient:            macie2.New(p),
+		NetworkFirewallClient:  networkfirewall.New(p),
+		OrganizationsClient:    organizations.New(p),
+		PrometheusClient:       prometheusservice.New(p),
+		RDSClient:              rds.New(p),
+		ResourceExplorerClient: resourceexplorer2.New(p),
+		Route53Client:          route53.New(p),
+		Route53ResolverClient:  route53resolver.New(p),
+		S3Client:               s3.New(p),
+		SageMakerClient:        sagemaker.New(p),
+		SecretsManagerClient:   secretsmanager.New(p),
+		ServiceDiscoveryClient: servicediscovery.New(p),
+		ServiceQuotasClient:    servicequotas.New(p),
+		SNSClient:              sns.New(p),
+		SQSClient:              sqs.New(p),
+		SSMClient:              ssm.New(p),
+		SyntheticsClient:       synthetics.New(p),
+	}
+
+	return c
+}
@@ -0,0 +1,126 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// pendingConfirmation is the ARN SNS returns for subscriptions which are not
+// confirmed yet. They cannot be unsubscribed and expire on their own.
+const pendingConfirmation = "PendingConfirmation"
+
+// cleanTopics deletes the per-run SNS topics event-driven e2e tests create,
+// after removing their subscriptions, as they count against the topic quota
+// of the account.
+func (a *Cleaner) cleanTopics(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &sns.ListTopicsInput{}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.snsClient.ListTopics(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, topic := range o.Topics {
+			name, ok := topicName(topic)
+			if !ok || !a.hasCIPrefix(name) {
+				continue
+			}
+
+			seen, err := a.run.FirstSeen(cleanerTopics, *topic.TopicArn)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				continue
+			}
+
+			// do not delete recent topics.
+			if time.Since(seen) < a.gracePeriod {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that sns topic %#q should be deleted", name))
+
+			res := run.Resource{
+				ID:   *topic.TopicArn,
+				Type: "AWS::SNS::Topic",
+			}
+			topic := topic
+			err = a.run.DeleteResource(ctx, cleanerTopics, res, func() error {
+				return a.deleteTopic(ctx, topic)
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting sns topic %#q", name), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// deleteTopic removes the confirmed subscriptions of the given topic and
+// deletes it.
+func (a *Cleaner) deleteTopic(ctx context.Context, topic *sns.Topic) error {
+	i := &sns.ListSubscriptionsByTopicInput{TopicArn: topic.TopicArn}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.snsClient.ListSubscriptionsByTopic(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, s := range o.Subscriptions {
+			if aws.StringValue(s.SubscriptionArn) == pendingConfirmation {
+				continue
+			}
+
+			_, err := a.snsClient.Unsubscribe(&sns.UnsubscribeInput{SubscriptionArn: s.SubscriptionArn})
+			if err != nil && !isAWSError(err, sns.ErrCodeNotFoundException) {
+				return nil, microerror.Mask(err)
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if isAWSError(err, sns.ErrCodeNotFoundException) {
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	_, err = a.snsClient.DeleteTopic(&sns.DeleteTopicInput{TopicArn: topic.TopicArn})
+	if err != nil && !isAWSError(err, sns.ErrCodeNotFoundException) {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// topicName returns the name of the given topic, which is the last part of
+// its ARN.
+func topicName(topic *sns.Topic) (string, bool) {
+	a, err := arn.Parse(aws.StringValue(topic.TopicArn))
+	if err != nil {
+		return "", false
+	}
+
+	return a.Resource, true
+}
@@ -0,0 +1,45 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
+)
+
+func TestTopicName(t *testing.T) {
+	tcs := []struct {
+		arn         string
+		expected    string
+		ok          bool
+		description string
+	}{
+		{
+			description: "name of standard topic",
+			arn:         "arn:aws:sns:eu-west-1:123456789012:e2e-a1b2c-events",
+			expected:    "e2e-a1b2c-events",
+			ok:          true,
+		},
+		{
+			description: "name of fifo topic",
+			arn:         "arn:aws:sns:eu-west-1:123456789012:e2e-a1b2c-events.fifo",
+			expected:    "e2e-a1b2c-events.fifo",
+			ok:          true,
+		},
+		{
+			description: "malformed arn",
+			arn:         "e2e-a1b2c-events",
+			ok:          false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual, ok := topicName(&sns.Topic{TopicArn: aws.String(tc.arn)})
+
+			if ok != tc.ok || actual != tc.expected {
+				t.Errorf("want %q (%t), got %q (%t)", tc.expected, tc.ok, actual, ok)
+			}
+		})
+	}
+}
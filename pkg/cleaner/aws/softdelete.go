@@ -0,0 +1,113 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanSoftDeletedSecrets removes CI secrets which are scheduled for deletion.
+// Such secrets are invisible for the usual listing but still block name reuse,
+// so a rerun of the same CI pipeline fails to create them again. Secrets
+// scheduled for deletion cannot be deleted immediately, which is why they are
+// restored first and then deleted without recovery window.
+func (a *Cleaner) cleanSoftDeletedSecrets(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	var nextToken *string
+	err := paginate(ctx, &nextToken, func() (*string, error) {
+		i := &secretsmanager.ListSecretsInput{
+			IncludePlannedDeletion: aws.Bool(true),
+			NextToken:              nextToken,
+		}
+
+		o, err := a.secretsManagerClient.ListSecrets(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, secret := range o.SecretList {
+			if !a.softDeletedSecretShouldBeDeleted(secret) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that soft-deleted secret %#q should be purged", *secret.Name))
+
+			res := run.Resource{
+				ID:        *secret.Name,
+				Type:      "AWS::SecretsManager::Secret",
+				Tags:      secretTags(secret.Tags),
+				CreatedAt: aws.TimeValue(secret.CreatedDate),
+			}
+			err := a.run.DeleteResource(ctx, cleanerSoftDeletedSecrets, res, func() error {
+				return a.purgeSecret(secret.ARN)
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed purging soft-deleted secret %#q", *secret.Name), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) purgeSecret(arn *string) error {
+	{
+		i := &secretsmanager.RestoreSecretInput{
+			SecretId: arn,
+		}
+
+		_, err := a.secretsManagerClient.RestoreSecret(i)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	{
+		i := &secretsmanager.DeleteSecretInput{
+			ForceDeleteWithoutRecovery: aws.Bool(true),
+			SecretId:                   arn,
+		}
+
+		_, err := a.secretsManagerClient.DeleteSecret(i)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+func (a *Cleaner) softDeletedSecretShouldBeDeleted(secret *secretsmanager.SecretListEntry) bool {
+	if secret.Name == nil || secret.ARN == nil {
+		return false
+	}
+
+	// Only secrets scheduled for deletion are handled here.
+	if secret.DeletedDate == nil {
+		return false
+	}
+
+	if matched, ok := a.queried(cleanerSoftDeletedSecrets, *secret.ARN); ok {
+		return matched
+	}
+
+	return a.hasCIPrefix(strings.TrimPrefix(*secret.Name, "/"))
+}
@@ -0,0 +1,68 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+)
+
+func TestSoftDeletedSecretShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		secret      *secretsmanager.SecretListEntry
+		expected    bool
+		description string
+	}{
+		{
+			description: "active ci secret should not be purged",
+			secret: &secretsmanager.SecretListEntry{
+				ARN:  aws.String("arn:aws:secretsmanager:eu-central-1:123456789012:secret:ci-wip-a1b2c-abcdef"),
+				Name: aws.String("ci-wip-a1b2c"),
+			},
+			expected: false,
+		},
+		{
+			description: "soft-deleted ci secret should be purged",
+			secret: &secretsmanager.SecretListEntry{
+				ARN:         aws.String("arn:aws:secretsmanager:eu-central-1:123456789012:secret:ci-wip-a1b2c-abcdef"),
+				Name:        aws.String("ci-wip-a1b2c"),
+				DeletedDate: aws.Time(time.Now()),
+			},
+			expected: true,
+		},
+		{
+			description: "soft-deleted ci secret with path name should be purged",
+			secret: &secretsmanager.SecretListEntry{
+				ARN:         aws.String("arn:aws:secretsmanager:eu-central-1:123456789012:secret:/e2e-a1b2c/token-abcdef"),
+				Name:        aws.String("/e2e-a1b2c/token"),
+				DeletedDate: aws.Time(time.Now()),
+			},
+			expected: true,
+		},
+		{
+			description: "soft-deleted general secret should not be purged",
+			secret: &secretsmanager.SecretListEntry{
+				ARN:         aws.String("arn:aws:secretsmanager:eu-central-1:123456789012:secret:production-abcdef"),
+				Name:        aws.String("production"),
+				DeletedDate: aws.Time(time.Now()),
+			},
+			expected: false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := a.softDeletedSecretShouldBeDeleted(tc.secret)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be purged, want %t, got %t", *tc.secret.Name, tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanSpotRequests cancels the spot fleet requests and persistent spot
+// instance requests CI scale tests leave open, which keep launching instances
+// after they were terminated. Their instances are terminated along with
+// them, which is why this runs before cleanInstances.
+func (a *Cleaner) cleanSpotRequests(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	err := a.cleanSpotFleetRequests(ctx)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+	}
+
+	err = a.cleanSpotInstanceRequests(ctx)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// cleanSpotFleetRequests cancels CI spot fleet requests, which terminates
+// their instances in the same call.
+func (a *Cleaner) cleanSpotFleetRequests(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &ec2.DescribeSpotFleetRequestsInput{}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.ec2Client.DescribeSpotFleetRequests(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, request := range o.SpotFleetRequestConfigs {
+			if !a.spotFleetRequestShouldBeDeleted(request) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that spot fleet request %#q should be cancelled", *request.SpotFleetRequestId))
+
+			res := run.Resource{
+				ID:        *request.SpotFleetRequestId,
+				Type:      "AWS::EC2::SpotFleet",
+				Tags:      ec2Tags(request.Tags),
+				CreatedAt: aws.TimeValue(request.CreateTime),
+			}
+			id := request.SpotFleetRequestId
+			err := a.run.DeleteResource(ctx, cleanerSpotRequests, res, func() error {
+				i := &ec2.CancelSpotFleetRequestsInput{
+					SpotFleetRequestIds: []*string{id},
+					TerminateInstances:  aws.Bool(true),
+				}
+
+				o, err := a.ec2Client.CancelSpotFleetRequests(i)
+				if err != nil {
+					return microerror.Mask(err)
+				}
+
+				for _, e := range o.UnsuccessfulFleetRequests {
+					if e.Error != nil {
+						return microerror.Maskf(executionFailedError, "%s: %s", aws.StringValue(e.Error.Code), aws.StringValue(e.Error.Message))
+					}
+				}
+
+				return nil
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed cancelling spot fleet request %#q", *request.SpotFleetRequestId), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// cleanSpotInstanceRequests cancels persistent CI spot instance requests and
+// terminates their instance afterwards, as cancelling leaves the instance
+// running and terminating it first makes the request launch another one.
+func (a *Cleaner) cleanSpotInstanceRequests(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &ec2.DescribeSpotInstanceRequestsInput{
+		Filters: []*ec2.Filter{
+			{
+				Name:   aws.String("type"),
+				Values: aws.StringSlice([]string{ec2.SpotInstanceTypePersistent}),
+			},
+			{
+				Name:   aws.String("state"),
+				Values: aws.StringSlice([]string{ec2.SpotInstanceStateOpen, ec2.SpotInstanceStateActive, ec2.SpotInstanceStateDisabled}),
+			},
+		},
+	}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.ec2Client.DescribeSpotInstanceRequests(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, request := range o.SpotInstanceRequests {
+			if !a.spotInstanceRequestShouldBeDeleted(request) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that spot instance request %#q should be cancelled", *request.SpotInstanceRequestId))
+
+			res := run.Resource{
+				ID:        *request.SpotInstanceRequestId,
+				Type:      "AWS::EC2::SpotInstanceRequest",
+				Tags:      ec2Tags(request.Tags),
+				CreatedAt: aws.TimeValue(request.CreateTime),
+			}
+			request := request
+			err := a.run.DeleteResource(ctx, cleanerSpotRequests, res, func() error {
+				return a.cancelSpotInstanceRequest(request)
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed cancelling spot instance request %#q", *request.SpotInstanceRequestId), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) cancelSpotInstanceRequest(request *ec2.SpotInstanceRequest) error {
+	{
+		i := &ec2.CancelSpotInstanceRequestsInput{
+			SpotInstanceRequestIds: []*string{request.SpotInstanceRequestId},
+		}
+
+		_, err := a.ec2Client.CancelSpotInstanceRequests(i)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	if request.InstanceId != nil {
+		a.logger.Log("level", "info", "message", fmt.Sprintf("terminating instance %#q of spot instance request %#q", *request.InstanceId, *request.SpotInstanceRequestId))
+
+		i := &ec2.TerminateInstancesInput{
+			InstanceIds: []*string{request.InstanceId},
+		}
+
+		_, err := a.ec2Client.TerminateInstances(i)
+		if isAWSError(err, "InvalidInstanceID.NotFound") {
+			return nil
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+func (a *Cleaner) spotFleetRequestShouldBeDeleted(request *ec2.SpotFleetRequestConfig) bool {
+	if request.SpotFleetRequestId == nil {
+		return false
+	}
+
+	// cancelled and failed requests do not launch instances anymore.
+	switch aws.StringValue(request.SpotFleetRequestState) {
+	case ec2.BatchStateSubmitted, ec2.BatchStateActive, ec2.BatchStateModifying:
+	default:
+		return false
+	}
+
+	// do not delete recent requests.
+	if isRecent(request.CreateTime, a.gracePeriod) {
+		return false
+	}
+
+	return a.isCITagged(ec2Tags(request.Tags))
+}
+
+func (a *Cleaner) spotInstanceRequestShouldBeDeleted(request *ec2.SpotInstanceRequest) bool {
+	if request.SpotInstanceRequestId == nil {
+		return false
+	}
+
+	// one-time requests do not launch instances again.
+	if aws.StringValue(request.Type) != ec2.SpotInstanceTypePersistent {
+		return false
+	}
+
+	switch aws.StringValue(request.State) {
+	case ec2.SpotInstanceStateOpen, ec2.SpotInstanceStateActive, ec2.SpotInstanceStateDisabled:
+	default:
+		return false
+	}
+
+	// do not delete recent requests.
+	if isRecent(request.CreateTime, a.gracePeriod) {
+		return false
+	}
+
+	return a.isCITagged(ec2Tags(request.Tags))
+}
@@ -0,0 +1,149 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func TestSpotFleetRequestShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		name        string
+		state       string
+		created     time.Time
+		expected    bool
+		description string
+	}{
+		{
+			description: "old active ci spot fleet request should be deleted",
+			name:        "ci-wip-a1b2c-scale",
+			state:       ec2.BatchStateActive,
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    true,
+		},
+		{
+			description: "recent active ci spot fleet request should not be deleted",
+			name:        "ci-wip-a1b2c-scale",
+			state:       ec2.BatchStateActive,
+			created:     time.Now().Add(-time.Hour),
+			expected:    false,
+		},
+		{
+			description: "old cancelled ci spot fleet request should not be deleted",
+			name:        "ci-wip-a1b2c-scale",
+			state:       ec2.BatchStateCancelled,
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    false,
+		},
+		{
+			description: "old active general spot fleet request should not be deleted",
+			name:        "gauss-scale",
+			state:       ec2.BatchStateActive,
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			request := &ec2.SpotFleetRequestConfig{
+				CreateTime:            aws.Time(tc.created),
+				SpotFleetRequestId:    aws.String("sfr-01234567-89ab-cdef-0123-456789abcdef"),
+				SpotFleetRequestState: aws.String(tc.state),
+				Tags: []*ec2.Tag{
+					{Key: aws.String("Name"), Value: aws.String(tc.name)},
+				},
+			}
+
+			actual := a.spotFleetRequestShouldBeDeleted(request)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", tc.name, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestSpotInstanceRequestShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		name        string
+		requestType string
+		state       string
+		created     time.Time
+		expected    bool
+		description string
+	}{
+		{
+			description: "old persistent ci spot instance request should be deleted",
+			name:        "ci-wip-a1b2c-scale",
+			requestType: ec2.SpotInstanceTypePersistent,
+			state:       ec2.SpotInstanceStateActive,
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    true,
+		},
+		{
+			description: "old disabled persistent ci spot instance request should be deleted",
+			name:        "ci-wip-a1b2c-scale",
+			requestType: ec2.SpotInstanceTypePersistent,
+			state:       ec2.SpotInstanceStateDisabled,
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    true,
+		},
+		{
+			description: "old one-time ci spot instance request should not be deleted",
+			name:        "ci-wip-a1b2c-scale",
+			requestType: ec2.SpotInstanceTypeOneTime,
+			state:       ec2.SpotInstanceStateActive,
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    false,
+		},
+		{
+			description: "recent persistent ci spot instance request should not be deleted",
+			name:        "ci-wip-a1b2c-scale",
+			requestType: ec2.SpotInstanceTypePersistent,
+			state:       ec2.SpotInstanceStateActive,
+			created:     time.Now().Add(-time.Hour),
+			expected:    false,
+		},
+		{
+			description: "old persistent general spot instance request should not be deleted",
+			name:        "gauss-scale",
+			requestType: ec2.SpotInstanceTypePersistent,
+			state:       ec2.SpotInstanceStateActive,
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			request := &ec2.SpotInstanceRequest{
+				CreateTime:            aws.Time(tc.created),
+				SpotInstanceRequestId: aws.String("sir-0123abcd"),
+				State:                 aws.String(tc.state),
+				Tags: []*ec2.Tag{
+					{Key: aws.String("Name"), Value: aws.String(tc.name)},
+				},
+				Type: aws.String(tc.requestType),
+			}
+
+			actual := a.spotInstanceRequestShouldBeDeleted(request)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", tc.name, tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanQueues deletes the per-run SQS queues event-driven e2e tests create,
+// as they count against the queue quota of the account.
+func (a *Cleaner) cleanQueues(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &sqs.ListQueuesInput{
+		// without MaxResults, SQS returns the first 1000 queues only.
+		MaxResults: aws.Int64(1000),
+	}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.sqsClient.ListQueues(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, url := range o.QueueUrls {
+			name := path.Base(aws.StringValue(url))
+			if !a.hasCIPrefix(name) {
+				continue
+			}
+
+			created, err := a.queueCreationTime(url)
+			if isAWSError(err, sqs.ErrCodeQueueDoesNotExist) {
+				continue
+			} else if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed getting attributes of sqs queue %#q", name), "stack", fmt.Sprintf("%#v", err))
+				continue
+			}
+
+			if !a.queueShouldBeDeleted(name, created) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that sqs queue %#q should be deleted", name))
+
+			res := run.Resource{
+				ID:        name,
+				Type:      "AWS::SQS::Queue",
+				CreatedAt: created,
+			}
+			url := url
+			err = a.run.DeleteResource(ctx, cleanerQueues, res, func() error {
+				_, err := a.sqsClient.DeleteQueue(&sqs.DeleteQueueInput{QueueUrl: url})
+				if err != nil && !isAWSError(err, sqs.ErrCodeQueueDoesNotExist) {
+					return microerror.Mask(err)
+				}
+
+				return nil
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting sqs queue %#q", name), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// queueCreationTime returns when the queue with the given URL was created.
+func (a *Cleaner) queueCreationTime(url *string) (time.Time, error) {
+	i := &sqs.GetQueueAttributesInput{
+		AttributeNames: []*string{aws.String(sqs.QueueAttributeNameCreatedTimestamp)},
+		QueueUrl:       url,
+	}
+	o, err := a.sqsClient.GetQueueAttributes(i)
+	if err != nil {
+		return time.Time{}, microerror.Mask(err)
+	}
+
+	s, err := strconv.ParseInt(aws.StringValue(o.Attributes[sqs.QueueAttributeNameCreatedTimestamp]), 10, 64)
+	if err != nil {
+		return time.Time{}, microerror.Mask(err)
+	}
+
+	return time.Unix(s, 0), nil
+}
+
+func (a *Cleaner) queueShouldBeDeleted(name string, created time.Time) bool {
+	if !a.hasCIPrefix(name) {
+		return false
+	}
+
+	// do not delete recent queues.
+	if time.Since(created) < a.gracePeriod {
+		return false
+	}
+
+	return true
+}
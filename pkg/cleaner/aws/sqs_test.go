@@ -0,0 +1,49 @@
+package aws
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQueueShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		name        string
+		created     time.Time
+		expected    bool
+		description string
+	}{
+		{
+			description: "old ci queue should be deleted",
+			name:        "e2e-a1b2c-events",
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    true,
+		},
+		{
+			description: "recent ci queue should not be deleted",
+			name:        "e2e-a1b2c-events",
+			created:     time.Now().Add(-time.Hour),
+			expected:    false,
+		},
+		{
+			description: "old general queue should not be deleted",
+			name:        "aws-node-termination-handler",
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := a.queueShouldBeDeleted(tc.name, tc.created)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", tc.name, tc.expected, actual)
+			}
+		})
+	}
+}
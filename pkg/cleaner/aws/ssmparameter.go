@@ -0,0 +1,174 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// ssmDeleteParametersLimit is the maximum number of parameters a single
+// DeleteParameters call accepts.
+const ssmDeleteParametersLimit = 10
+
+// cleanSSMParameters deletes the Parameter Store hierarchies CI clusters
+// write, like `/ci-wip-a1b2c/...`, as a whole. Parameters do not tell when
+// they were created, which is why a hierarchy is only deleted once none of
+// its parameters was modified within the grace period.
+func (a *Cleaner) cleanSSMParameters(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	var parameters []*ssm.ParameterMetadata
+	{
+		i := &ssm.DescribeParametersInput{}
+		err := paginate(ctx, &i.NextToken, func() (*string, error) {
+			o, err := a.ssmClient.DescribeParameters(i)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			parameters = append(parameters, o.Parameters...)
+
+			return o.NextToken, nil
+		})
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			return errors
+		}
+	}
+
+	hierarchies := a.staleSSMParameterHierarchies(parameters)
+
+	var roots []string
+	for root := range hierarchies {
+		roots = append(roots, root)
+	}
+	sort.Strings(roots)
+
+	for _, root := range roots {
+		params := hierarchies[root]
+
+		a.logger.Log("level", "info", "message", fmt.Sprintf("found that SSM parameter hierarchy %#q with %d parameters should be deleted", root, len(params)))
+
+		res := run.Resource{
+			ID:        root,
+			Type:      "AWS::SSM::Parameter",
+			CreatedAt: ssmParametersModifiedAt(params),
+			Note:      fmt.Sprintf("%d parameters", len(params)),
+		}
+		for _, p := range params {
+			if aws.StringValue(p.Tier) == ssm.ParameterTierAdvanced {
+				res.MonthlyCost += ssmAdvancedParameterMonthlyCost
+			}
+		}
+		err := a.run.DeleteResource(ctx, cleanerSSMParameters, res, func() error {
+			return a.deleteSSMParameters(params)
+		})
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting SSM parameter hierarchy %#q", root), "stack", fmt.Sprintf("%#v", err))
+		}
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// deleteSSMParameters deletes the given parameters in batches. Parameters
+// which do not exist anymore are reported as invalid by AWS and ignored.
+func (a *Cleaner) deleteSSMParameters(params []*ssm.ParameterMetadata) error {
+	var names []*string
+	for _, p := range params {
+		names = append(names, p.Name)
+	}
+
+	for len(names) > 0 {
+		n := ssmDeleteParametersLimit
+		if len(names) < n {
+			n = len(names)
+		}
+
+		i := &ssm.DeleteParametersInput{
+			Names: names[:n],
+		}
+
+		_, err := a.ssmClient.DeleteParameters(i)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		names = names[n:]
+	}
+
+	return nil
+}
+
+// staleSSMParameterHierarchies groups the given parameters by the root of
+// their hierarchy and returns the CI hierarchies none of whose parameters
+// was modified within the grace period.
+func (a *Cleaner) staleSSMParameterHierarchies(parameters []*ssm.ParameterMetadata) map[string][]*ssm.ParameterMetadata {
+	hierarchies := map[string][]*ssm.ParameterMetadata{}
+	recent := map[string]bool{}
+
+	for _, p := range parameters {
+		if p.Name == nil {
+			continue
+		}
+
+		root, segment := ssmParameterRoot(*p.Name)
+		if !a.hasCIPrefix(segment) {
+			continue
+		}
+
+		// do not delete hierarchies which are still written to.
+		if isRecent(p.LastModifiedDate, a.gracePeriod) {
+			recent[root] = true
+		}
+
+		hierarchies[root] = append(hierarchies[root], p)
+	}
+
+	for root := range recent {
+		delete(hierarchies, root)
+	}
+
+	return hierarchies
+}
+
+// ssmParameterRoot returns the root of the hierarchy of the parameter with
+// the given name along with its first path segment, e.g. `/ci-wip-a1b2c` and
+// `ci-wip-a1b2c` for `/ci-wip-a1b2c/kubeconfig`. Parameters outside of any
+// hierarchy are their own root.
+func ssmParameterRoot(name string) (string, string) {
+	segment := strings.SplitN(strings.TrimPrefix(name, "/"), "/", 2)[0]
+
+	if strings.HasPrefix(name, "/") {
+		return "/" + segment, segment
+	}
+
+	return segment, segment
+}
+
+// ssmParametersModifiedAt returns the earliest modification of the given
+// parameters, which is the closest to the creation of their hierarchy.
+func ssmParametersModifiedAt(params []*ssm.ParameterMetadata) time.Time {
+	var t time.Time
+	for _, p := range params {
+		m := aws.TimeValue(p.LastModifiedDate)
+		if t.IsZero() || m.Before(t) {
+			t = m
+		}
+	}
+
+	return t
+}
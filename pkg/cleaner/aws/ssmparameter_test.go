@@ -0,0 +1,80 @@
+package aws
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+func TestStaleSSMParameterHierarchies(t *testing.T) {
+	old := time.Now().Add(-2 * time.Hour)
+	recent := time.Now().Add(-time.Hour)
+
+	tcs := []struct {
+		parameters  map[string]time.Time
+		expected    []string
+		description string
+	}{
+		{
+			description: "old ci hierarchy should be deleted",
+			parameters: map[string]time.Time{
+				"/ci-wip-a1b2c/kubeconfig":   old,
+				"/ci-wip-a1b2c/release/name": old,
+			},
+			expected: []string{"/ci-wip-a1b2c"},
+		},
+		{
+			description: "ci hierarchy with a recently modified parameter should not be deleted",
+			parameters: map[string]time.Time{
+				"/ci-wip-a1b2c/kubeconfig":   old,
+				"/ci-wip-a1b2c/release/name": recent,
+			},
+			expected: nil,
+		},
+		{
+			description: "old ci parameter outside of a hierarchy should be deleted",
+			parameters: map[string]time.Time{
+				"e2e-a1b2c-token": old,
+			},
+			expected: []string{"e2e-a1b2c-token"},
+		},
+		{
+			description: "old general hierarchy with ci parameters should not be deleted",
+			parameters: map[string]time.Time{
+				"/gauss/ci-wip-a1b2c": old,
+			},
+			expected: nil,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			var parameters []*ssm.ParameterMetadata
+			for name, modified := range tc.parameters {
+				parameters = append(parameters, &ssm.ParameterMetadata{
+					Name:             aws.String(name),
+					LastModifiedDate: aws.Time(modified),
+				})
+			}
+
+			var actual []string
+			for root := range a.staleSSMParameterHierarchies(parameters) {
+				actual = append(actual, root)
+			}
+			sort.Strings(actual)
+
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("want %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
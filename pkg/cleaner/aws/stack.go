@@ -0,0 +1,153 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/giantswarm/microerror"
+)
+
+// deleteFailedStack retries deleting a stack stuck in DELETE_FAILED. The
+// resources CloudFormation failed to delete, usually non-empty buckets or
+// security groups still referenced by other groups, are cleaned the way
+// their resource cleaners do it. Resources which cannot be cleaned are
+// retained as a last resort, so that the stack goes away and the resource
+// cleaners pick them up on their own.
+func (a *Cleaner) deleteFailedStack(ctx context.Context, stack *cloudformation.Stack) error {
+	var summaries []*cloudformation.StackResourceSummary
+	{
+		i := &cloudformation.ListStackResourcesInput{
+			StackName: stack.StackName,
+		}
+		err := paginate(ctx, &i.NextToken, func() (*string, error) {
+			o, err := a.cfClient.ListStackResources(i)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			summaries = append(summaries, o.StackResourceSummaries...)
+
+			return o.NextToken, nil
+		})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	var retain []string
+	for _, r := range stackBlockers(summaries) {
+		err := a.cleanStackBlocker(ctx, r)
+		if err != nil {
+			a.logger.Log("level", "warning", "message", fmt.Sprintf("failed cleaning resource %#q of type %#q blocking deletion of stack %#q, retaining it", aws.StringValue(r.PhysicalResourceId), aws.StringValue(r.ResourceType), *stack.StackName), "stack", fmt.Sprintf("%#v", err))
+			retain = append(retain, *r.LogicalResourceId)
+			continue
+		}
+
+		a.logger.Log("level", "info", "message", fmt.Sprintf("cleaned resource %#q of type %#q blocking deletion of stack %#q", aws.StringValue(r.PhysicalResourceId), aws.StringValue(r.ResourceType), *stack.StackName))
+	}
+
+	i := &cloudformation.DeleteStackInput{
+		StackName: stack.StackName,
+	}
+	if len(retain) != 0 {
+		i.RetainResources = aws.StringSlice(retain)
+	}
+	_, err := a.cfClient.DeleteStack(i)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// cleanStackBlocker deletes the given stack resource CloudFormation failed to
+// delete. Resources of types no resource cleaner knows how to unblock are
+// returned as error.
+func (a *Cleaner) cleanStackBlocker(ctx context.Context, r *cloudformation.StackResourceSummary) error {
+	id := r.PhysicalResourceId
+	if aws.StringValue(id) == "" {
+		return microerror.Maskf(executionFailedError, "resource %#q was not created", aws.StringValue(r.LogicalResourceId))
+	}
+
+	switch aws.StringValue(r.ResourceType) {
+	case "AWS::S3::Bucket":
+		err := a.deleteBucket(id)
+		if err != nil && !isAWSError(err, s3.ErrCodeNoSuchBucket) {
+			return microerror.Mask(err)
+		}
+
+		return nil
+
+	case "AWS::EC2::SecurityGroup":
+		return a.cleanStackSecurityGroup(ctx, *id)
+	}
+
+	return microerror.Maskf(executionFailedError, "cleaning resources of type %#q is not supported", aws.StringValue(r.ResourceType))
+}
+
+// cleanStackSecurityGroup deletes the security group of the given ID after
+// revoking the rules of the other security groups of its VPC referencing it.
+func (a *Cleaner) cleanStackSecurityGroup(ctx context.Context, id string) error {
+	o, err := a.ec2Client.DescribeSecurityGroups(&ec2.DescribeSecurityGroupsInput{GroupIds: aws.StringSlice([]string{id})})
+	if isAWSError(err, "InvalidGroup.NotFound") {
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+	if len(o.SecurityGroups) == 0 {
+		return nil
+	}
+	group := o.SecurityGroups[0]
+
+	var related []*ec2.SecurityGroup
+	{
+		i := &ec2.DescribeSecurityGroupsInput{
+			Filters: []*ec2.Filter{
+				{
+					Name:   aws.String("vpc-id"),
+					Values: []*string{group.VpcId},
+				},
+			},
+		}
+		err := paginate(ctx, &i.NextToken, func() (*string, error) {
+			o, err := a.ec2Client.DescribeSecurityGroups(i)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			related = append(related, o.SecurityGroups...)
+
+			return o.NextToken, nil
+		})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	err = a.deleteSecurityGroup(group, related)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// stackBlockers returns the resources of a stack CloudFormation failed to
+// delete.
+func stackBlockers(summaries []*cloudformation.StackResourceSummary) []*cloudformation.StackResourceSummary {
+	var blockers []*cloudformation.StackResourceSummary
+	for _, r := range summaries {
+		if r.LogicalResourceId == nil {
+			continue
+		}
+		if aws.StringValue(r.ResourceStatus) == cloudformation.ResourceStatusDeleteFailed {
+			blockers = append(blockers, r)
+		}
+	}
+
+	return blockers
+}
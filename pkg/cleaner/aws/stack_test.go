@@ -0,0 +1,71 @@
+package aws
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/giantswarm/micrologger/microloggertest"
+)
+
+type cfClientMock struct {
+	CFClient
+
+	summaries []*cloudformation.StackResourceSummary
+	deleted   []*cloudformation.DeleteStackInput
+}
+
+func (c *cfClientMock) ListStackResources(*cloudformation.ListStackResourcesInput) (*cloudformation.ListStackResourcesOutput, error) {
+	return &cloudformation.ListStackResourcesOutput{StackResourceSummaries: c.summaries}, nil
+}
+
+func (c *cfClientMock) DeleteStack(i *cloudformation.DeleteStackInput) (*cloudformation.DeleteStackOutput, error) {
+	c.deleted = append(c.deleted, i)
+	return &cloudformation.DeleteStackOutput{}, nil
+}
+
+func TestDeleteFailedStack(t *testing.T) {
+	cf := &cfClientMock{
+		summaries: []*cloudformation.StackResourceSummary{
+			{
+				LogicalResourceId:  aws.String("VPC"),
+				PhysicalResourceId: aws.String("vpc-1"),
+				ResourceStatus:     aws.String(cloudformation.ResourceStatusDeleteFailed),
+				ResourceType:       aws.String("AWS::EC2::VPC"),
+			},
+			{
+				LogicalResourceId:  aws.String("Subnet"),
+				PhysicalResourceId: aws.String("subnet-1"),
+				ResourceStatus:     aws.String(cloudformation.ResourceStatusDeleteComplete),
+				ResourceType:       aws.String("AWS::EC2::Subnet"),
+			},
+		},
+	}
+
+	a := &Cleaner{
+		cfClient: cf,
+		logger:   microloggertest.New(),
+	}
+
+	stack := &cloudformation.Stack{
+		StackName:   aws.String("cluster-ci-a1b2c"),
+		StackStatus: aws.String(cloudformation.StackStatusDeleteFailed),
+	}
+	err := a.deleteFailedStack(context.Background(), stack)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cf.deleted) != 1 {
+		t.Fatalf("want stack deletion to be retried once, got %d", len(cf.deleted))
+	}
+
+	// the vpc cannot be cleaned, so it is retained as a last resort.
+	expected := []string{"VPC"}
+	actual := aws.StringValueSlice(cf.deleted[0].RetainResources)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("want retained resources %v, got %v", expected, actual)
+	}
+}
@@ -0,0 +1,249 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanStackSets deletes the CloudFormation stack sets CI creates. Stack sets
+// can only be deleted once they have no instances left, so their instances
+// in all accounts and regions are deleted first in a single operation, which
+// is tracked by later runs, and the stack set itself is deleted by the run
+// finding it without instances.
+func (a *Cleaner) cleanStackSets(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	stackSets, err := a.activeStackSets(ctx)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	for _, stackSet := range stackSets {
+		if !a.hasCIPrefix(*stackSet.StackSetName) {
+			continue
+		}
+
+		seen, err := a.run.FirstSeen(cleanerStackSets, *stackSet.StackSetName)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			continue
+		}
+
+		// do not delete recent stack sets.
+		if time.Since(seen) < a.gracePeriod {
+			continue
+		}
+
+		err = a.deleteStackSet(ctx, stackSet)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting stack set %#q", *stackSet.StackSetName), "stack", fmt.Sprintf("%#v", err))
+		}
+	}
+
+	err = a.run.PollPending(ctx, cleanerStackSets, "AWS::CloudFormation::StackInstance", a.pollStackSetOperation)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) deleteStackSet(ctx context.Context, stackSet *cloudformation.StackSetSummary) error {
+	instances, err := a.stackInstances(ctx, *stackSet.StackSetName)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if len(instances) != 0 {
+		a.logger.Log("level", "info", "message", fmt.Sprintf("found that %d instances of stack set %#q should be deleted", len(instances), *stackSet.StackSetName))
+
+		res := run.Resource{
+			ID:   *stackSet.StackSetName + "/instances",
+			Type: "AWS::CloudFormation::StackInstance",
+		}
+		start := func() (string, error) {
+			return a.startStackInstancesDeletion(stackSet, instances, false)
+		}
+		err := a.run.DeleteResourceAsync(ctx, cleanerStackSets, res, start, a.pollStackSetOperation)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		return nil
+	}
+
+	a.logger.Log("level", "info", "message", fmt.Sprintf("found that stack set %#q should be deleted", *stackSet.StackSetName))
+
+	res := run.Resource{
+		ID:   *stackSet.StackSetName,
+		Type: "AWS::CloudFormation::StackSet",
+	}
+	err = a.run.DeleteResource(ctx, cleanerStackSets, res, func() error {
+		_, err := a.cfClient.DeleteStackSet(&cloudformation.DeleteStackSetInput{StackSetName: stackSet.StackSetName})
+		if isAWSError(err, cloudformation.ErrCodeStackSetNotFoundException) {
+			return nil
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// activeStackSets lists all stack sets which are not deleted.
+func (a *Cleaner) activeStackSets(ctx context.Context) ([]*cloudformation.StackSetSummary, error) {
+	var stackSets []*cloudformation.StackSetSummary
+
+	i := &cloudformation.ListStackSetsInput{
+		Status: aws.String(cloudformation.StackSetStatusActive),
+	}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.cfClient.ListStackSets(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, s := range o.Summaries {
+			if s.StackSetName != nil {
+				stackSets = append(stackSets, s)
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return stackSets, nil
+}
+
+// stackInstances lists all instances of the stack set with the given name.
+func (a *Cleaner) stackInstances(ctx context.Context, name string) ([]*cloudformation.StackInstanceSummary, error) {
+	var instances []*cloudformation.StackInstanceSummary
+
+	i := &cloudformation.ListStackInstancesInput{
+		StackSetName: aws.String(name),
+	}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.cfClient.ListStackInstances(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		instances = append(instances, o.Summaries...)
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return instances, nil
+}
+
+// startStackInstancesDeletion starts deleting the given instances of the
+// given stack set and returns the handle of the operation for
+// pollStackSetOperation. retain keeps the stacks of the instances, which is
+// required for accounts which are not accessible anymore.
+func (a *Cleaner) startStackInstancesDeletion(stackSet *cloudformation.StackSetSummary, instances []*cloudformation.StackInstanceSummary, retain bool) (string, error) {
+	accounts, regions, units := stackInstanceTargets(instances)
+
+	i := &cloudformation.DeleteStackInstancesInput{
+		Regions:      aws.StringSlice(regions),
+		RetainStacks: aws.Bool(retain),
+		StackSetName: stackSet.StackSetName,
+	}
+	// stack sets managed by the organization address their instances by
+	// organizational unit.
+	if aws.StringValue(stackSet.PermissionModel) == cloudformation.PermissionModelsServiceManaged {
+		i.DeploymentTargets = &cloudformation.DeploymentTargets{
+			AccountFilterType:     aws.String(cloudformation.AccountFilterTypeIntersection),
+			Accounts:              aws.StringSlice(accounts),
+			OrganizationalUnitIds: aws.StringSlice(units),
+		}
+	} else {
+		i.Accounts = aws.StringSlice(accounts)
+	}
+
+	o, err := a.cfClient.DeleteStackInstances(i)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return *stackSet.StackSetName + "/" + aws.StringValue(o.OperationId), nil
+}
+
+// pollStackSetOperation polls the stack set operation with the given handle,
+// which looks like `<stack set name>/<operation ID>`.
+func (a *Cleaner) pollStackSetOperation(ctx context.Context, handle string) (bool, error) {
+	parts := strings.SplitN(handle, "/", 2)
+	if len(parts) != 2 {
+		return false, microerror.Maskf(executionFailedError, "invalid stack set operation %#q", handle)
+	}
+
+	i := &cloudformation.DescribeStackSetOperationInput{
+		OperationId:  aws.String(parts[1]),
+		StackSetName: aws.String(parts[0]),
+	}
+	o, err := a.cfClient.DescribeStackSetOperation(i)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	switch aws.StringValue(o.StackSetOperation.Status) {
+	case cloudformation.StackSetOperationStatusSucceeded:
+		return true, nil
+	case cloudformation.StackSetOperationStatusFailed, cloudformation.StackSetOperationStatusStopped:
+		return false, microerror.Maskf(executionFailedError, "stack set operation %#q %s", parts[1], strings.ToLower(aws.StringValue(o.StackSetOperation.Status)))
+	default:
+		return false, nil
+	}
+}
+
+// stackInstanceTargets returns the sorted distinct accounts, regions and
+// organizational units of the given stack instances.
+func stackInstanceTargets(instances []*cloudformation.StackInstanceSummary) ([]string, []string, []string) {
+	accounts := map[string]bool{}
+	regions := map[string]bool{}
+	units := map[string]bool{}
+	for _, instance := range instances {
+		accounts[aws.StringValue(instance.Account)] = true
+		regions[aws.StringValue(instance.Region)] = true
+		if instance.OrganizationalUnitId != nil {
+			units[*instance.OrganizationalUnitId] = true
+		}
+	}
+
+	return sortedKeys(accounts), sortedKeys(regions), sortedKeys(units)
+}
+
+func sortedKeys(m map[string]bool) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,151 @@
+package aws
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/giantswarm/micrologger/microloggertest"
+)
+
+type stackSetCFClientMock struct {
+	CFClient
+
+	status  string
+	deleted []*cloudformation.DeleteStackInstancesInput
+}
+
+func (c *stackSetCFClientMock) DeleteStackInstances(i *cloudformation.DeleteStackInstancesInput) (*cloudformation.DeleteStackInstancesOutput, error) {
+	c.deleted = append(c.deleted, i)
+	return &cloudformation.DeleteStackInstancesOutput{OperationId: aws.String("op-1")}, nil
+}
+
+func (c *stackSetCFClientMock) DescribeStackSetOperation(i *cloudformation.DescribeStackSetOperationInput) (*cloudformation.DescribeStackSetOperationOutput, error) {
+	o := &cloudformation.DescribeStackSetOperationOutput{
+		StackSetOperation: &cloudformation.StackSetOperation{
+			OperationId: i.OperationId,
+			Status:      aws.String(c.status),
+		},
+	}
+	return o, nil
+}
+
+func TestStartStackInstancesDeletion(t *testing.T) {
+	instances := []*cloudformation.StackInstanceSummary{
+		{Account: aws.String("222222222222"), Region: aws.String("eu-west-1"), OrganizationalUnitId: aws.String("ou-a1b2-c3d4e5f6")},
+		{Account: aws.String("111111111111"), Region: aws.String("eu-central-1"), OrganizationalUnitId: aws.String("ou-a1b2-c3d4e5f6")},
+	}
+
+	tcs := []struct {
+		permissionModel  string
+		expectedAccounts []string
+		expectedTargets  *cloudformation.DeploymentTargets
+		description      string
+	}{
+		{
+			description:      "self-managed stack set addresses instances by account",
+			permissionModel:  cloudformation.PermissionModelsSelfManaged,
+			expectedAccounts: []string{"111111111111", "222222222222"},
+		},
+		{
+			description:      "service-managed stack set addresses instances by organizational unit",
+			permissionModel:  cloudformation.PermissionModelsServiceManaged,
+			expectedAccounts: []string{},
+			expectedTargets: &cloudformation.DeploymentTargets{
+				AccountFilterType:     aws.String(cloudformation.AccountFilterTypeIntersection),
+				Accounts:              aws.StringSlice([]string{"111111111111", "222222222222"}),
+				OrganizationalUnitIds: aws.StringSlice([]string{"ou-a1b2-c3d4e5f6"}),
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			cf := &stackSetCFClientMock{}
+			a := &Cleaner{
+				cfClient: cf,
+				logger:   microloggertest.New(),
+			}
+
+			stackSet := &cloudformation.StackSetSummary{
+				PermissionModel: aws.String(tc.permissionModel),
+				StackSetName:    aws.String("ci-vending-a1b2c"),
+			}
+			handle, err := a.startStackInstancesDeletion(stackSet, instances, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if handle != "ci-vending-a1b2c/op-1" {
+				t.Errorf("want handle %q, got %q", "ci-vending-a1b2c/op-1", handle)
+			}
+			if len(cf.deleted) != 1 {
+				t.Fatalf("want 1 deletion, got %d", len(cf.deleted))
+			}
+
+			i := cf.deleted[0]
+			if !reflect.DeepEqual(aws.StringValueSlice(i.Regions), []string{"eu-central-1", "eu-west-1"}) {
+				t.Errorf("want regions of all instances, got %v", aws.StringValueSlice(i.Regions))
+			}
+			if aws.BoolValue(i.RetainStacks) {
+				t.Errorf("want stacks to be deleted, got retained")
+			}
+			if !reflect.DeepEqual(aws.StringValueSlice(i.Accounts), tc.expectedAccounts) {
+				t.Errorf("want accounts %v, got %v", tc.expectedAccounts, aws.StringValueSlice(i.Accounts))
+			}
+			if !reflect.DeepEqual(i.DeploymentTargets, tc.expectedTargets) {
+				t.Errorf("want deployment targets %v, got %v", tc.expectedTargets, i.DeploymentTargets)
+			}
+		})
+	}
+}
+
+func TestPollStackSetOperation(t *testing.T) {
+	tcs := []struct {
+		status      string
+		expected    bool
+		expectError bool
+		description string
+	}{
+		{
+			description: "succeeded operation is done",
+			status:      cloudformation.StackSetOperationStatusSucceeded,
+			expected:    true,
+		},
+		{
+			description: "running operation is not done",
+			status:      cloudformation.StackSetOperationStatusRunning,
+			expected:    false,
+		},
+		{
+			description: "failed operation returns an error",
+			status:      cloudformation.StackSetOperationStatusFailed,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			a := &Cleaner{
+				cfClient: &stackSetCFClientMock{status: tc.status},
+				logger:   microloggertest.New(),
+			}
+
+			actual, err := a.pollStackSetOperation(context.Background(), "ci-vending-a1b2c/op-1")
+			if tc.expectError {
+				if err == nil {
+					t.Fatalf("want error, got nil")
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("want nil, got %#v", err)
+			}
+
+			if actual != tc.expected {
+				t.Errorf("checking if operation is done, want %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
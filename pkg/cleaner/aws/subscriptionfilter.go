@@ -0,0 +1,265 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go/service/firehose"
+	"github.com/aws/aws-sdk-go/service/kinesis"
+	"github.com/aws/aws-sdk-go/service/lambda"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanSubscriptionFilters deletes the CloudWatch Logs subscription filters
+// log shipping tests leave behind once their Kinesis stream, Firehose
+// delivery stream or Lambda function is deleted, as CloudWatch Logs keeps
+// failing to deliver to them. Afterwards the CI log destinations, which
+// receive the logs of other accounts, are deleted.
+func (a *Cleaner) cleanSubscriptionFilters(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &cloudwatchlogs.DescribeLogGroupsInput{}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.cloudWatchLogsClient.DescribeLogGroups(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, group := range o.LogGroups {
+			err := a.cleanLogGroupSubscriptionFilters(ctx, group)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed cleaning subscription filters of log group %#q", aws.StringValue(group.LogGroupName)), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	err = a.cleanLogDestinations(ctx)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) cleanLogGroupSubscriptionFilters(ctx context.Context, group *cloudwatchlogs.LogGroup) error {
+	i := &cloudwatchlogs.DescribeSubscriptionFiltersInput{LogGroupName: group.LogGroupName}
+	o, err := a.cloudWatchLogsClient.DescribeSubscriptionFilters(i)
+	if isAWSError(err, cloudwatchlogs.ErrCodeResourceNotFoundException) {
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, filter := range o.SubscriptionFilters {
+		if !a.isCISubscriptionFilter(filter) {
+			continue
+		}
+
+		exists, err := a.logDestinationExists(aws.StringValue(filter.DestinationArn), aws.StringValue(group.Arn))
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		if !a.subscriptionFilterShouldBeDeleted(filter, exists) {
+			continue
+		}
+
+		a.logger.Log("level", "info", "message", fmt.Sprintf("found that subscription filter %#q of log group %#q should be deleted, as its destination %#q does not exist anymore", *filter.FilterName, *filter.LogGroupName, *filter.DestinationArn))
+
+		res := run.Resource{
+			ID:        *filter.LogGroupName + "/" + *filter.FilterName,
+			Type:      "AWS::Logs::SubscriptionFilter",
+			CreatedAt: logCreationTime(filter.CreationTime),
+			Reason:    run.ReasonDangling,
+		}
+		filter := filter
+		err = a.run.DeleteResource(ctx, cleanerSubscriptionFilters, res, func() error {
+			i := &cloudwatchlogs.DeleteSubscriptionFilterInput{
+				FilterName:   filter.FilterName,
+				LogGroupName: filter.LogGroupName,
+			}
+			_, err := a.cloudWatchLogsClient.DeleteSubscriptionFilter(i)
+			if err != nil && !isAWSError(err, cloudwatchlogs.ErrCodeResourceNotFoundException) {
+				return microerror.Mask(err)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+// cleanLogDestinations deletes the CI log destinations cross-account log
+// shipping tests create for other accounts to subscribe to.
+func (a *Cleaner) cleanLogDestinations(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &cloudwatchlogs.DescribeDestinationsInput{}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.cloudWatchLogsClient.DescribeDestinations(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, destination := range o.Destinations {
+			if !a.logDestinationShouldBeDeleted(destination) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that log destination %#q should be deleted", *destination.DestinationName))
+
+			res := run.Resource{
+				ID:        aws.StringValue(destination.Arn),
+				Type:      "AWS::Logs::Destination",
+				CreatedAt: logCreationTime(destination.CreationTime),
+			}
+			destination := destination
+			err := a.run.DeleteResource(ctx, cleanerSubscriptionFilters, res, func() error {
+				_, err := a.cloudWatchLogsClient.DeleteDestination(&cloudwatchlogs.DeleteDestinationInput{DestinationName: destination.DestinationName})
+				if err != nil && !isAWSError(err, cloudwatchlogs.ErrCodeResourceNotFoundException) {
+					return microerror.Mask(err)
+				}
+
+				return nil
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting log destination %#q", *destination.DestinationName), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// logDestinationExists checks if the destination of a subscription filter of
+// the log group with the given ARN still exists. Destinations which cannot
+// be looked up, like log destinations of other accounts, are assumed to
+// exist.
+func (a *Cleaner) logDestinationExists(destinationARN string, groupARN string) (bool, error) {
+	d, err := arn.Parse(destinationARN)
+	if err != nil {
+		return true, nil
+	}
+
+	var notFound string
+	switch {
+	case d.Service == kinesis.ServiceName && strings.HasPrefix(d.Resource, "stream/"):
+		_, err = a.kinesisClient.DescribeStreamSummary(&kinesis.DescribeStreamSummaryInput{StreamARN: aws.String(destinationARN)})
+		notFound = kinesis.ErrCodeResourceNotFoundException
+	case d.Service == firehose.ServiceName && strings.HasPrefix(d.Resource, "deliverystream/"):
+		_, err = a.firehoseClient.DescribeDeliveryStream(&firehose.DescribeDeliveryStreamInput{DeliveryStreamName: aws.String(strings.TrimPrefix(d.Resource, "deliverystream/"))})
+		notFound = firehose.ErrCodeResourceNotFoundException
+	case d.Service == lambda.ServiceName && strings.HasPrefix(d.Resource, "function:"):
+		_, err = a.lambdaClient.GetFunction(&lambda.GetFunctionInput{FunctionName: aws.String(destinationARN)})
+		notFound = lambda.ErrCodeResourceNotFoundException
+	case d.Service == "logs" && strings.HasPrefix(d.Resource, "destination:"):
+		g, err := arn.Parse(groupARN)
+		if err != nil || g.AccountID != d.AccountID {
+			return true, nil
+		}
+
+		name := strings.TrimPrefix(d.Resource, "destination:")
+		o, err := a.cloudWatchLogsClient.DescribeDestinations(&cloudwatchlogs.DescribeDestinationsInput{DestinationNamePrefix: aws.String(name)})
+		if err != nil {
+			return false, microerror.Mask(err)
+		}
+		for _, destination := range o.Destinations {
+			if aws.StringValue(destination.DestinationName) == name {
+				return true, nil
+			}
+		}
+
+		return false, nil
+	default:
+		return true, nil
+	}
+
+	if isAWSError(err, notFound) {
+		return false, nil
+	} else if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	return true, nil
+}
+
+// isCISubscriptionFilter checks if the given subscription filter, its log
+// group or its destination identifies a CI resource.
+func (a *Cleaner) isCISubscriptionFilter(filter *cloudwatchlogs.SubscriptionFilter) bool {
+	if filter.FilterName == nil || filter.LogGroupName == nil {
+		return false
+	}
+
+	if a.hasCIPrefix(*filter.FilterName) || a.isCILogGroup(*filter.LogGroupName) {
+		return true
+	}
+
+	d, err := arn.Parse(aws.StringValue(filter.DestinationArn))
+	if err != nil {
+		return false
+	}
+
+	return a.isCILogGroup(strings.Replace(d.Resource, ":", "/", -1))
+}
+
+func (a *Cleaner) subscriptionFilterShouldBeDeleted(filter *cloudwatchlogs.SubscriptionFilter, destinationExists bool) bool {
+	if !a.isCISubscriptionFilter(filter) {
+		return false
+	}
+
+	// do not delete filters which still deliver somewhere.
+	if destinationExists {
+		return false
+	}
+
+	// do not delete recent filters, their destination may still be created.
+	if time.Since(logCreationTime(filter.CreationTime)) < a.gracePeriod {
+		return false
+	}
+
+	return true
+}
+
+func (a *Cleaner) logDestinationShouldBeDeleted(destination *cloudwatchlogs.Destination) bool {
+	if destination.DestinationName == nil || !a.hasCIPrefix(*destination.DestinationName) {
+		return false
+	}
+
+	// do not delete recent destinations.
+	if time.Since(logCreationTime(destination.CreationTime)) < a.gracePeriod {
+		return false
+	}
+
+	return true
+}
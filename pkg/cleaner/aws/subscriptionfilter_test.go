@@ -0,0 +1,133 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+)
+
+func TestSubscriptionFilterShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		group             string
+		filter            string
+		destination       string
+		created           time.Time
+		destinationExists bool
+		expected          bool
+		description       string
+	}{
+		{
+			description: "old filter of ci log group with deleted destination should be deleted",
+			group:       "/aws/eks/ci-wip-a1b2c/cluster",
+			filter:      "shipping",
+			destination: "arn:aws:kinesis:eu-west-1:123456789012:stream/logs",
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    true,
+		},
+		{
+			description: "old filter of general log group with deleted ci destination should be deleted",
+			group:       "/aws/lambda/nightly-report",
+			filter:      "shipping",
+			destination: "arn:aws:lambda:eu-west-1:123456789012:function:e2e-a1b2c-shipper",
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    true,
+		},
+		{
+			description:       "old filter of ci log group with existing destination should not be deleted",
+			group:             "/aws/eks/ci-wip-a1b2c/cluster",
+			filter:            "shipping",
+			destination:       "arn:aws:kinesis:eu-west-1:123456789012:stream/logs",
+			created:           time.Now().Add(-2 * time.Hour),
+			destinationExists: true,
+			expected:          false,
+		},
+		{
+			description: "recent filter of ci log group with deleted destination should not be deleted",
+			group:       "/aws/eks/ci-wip-a1b2c/cluster",
+			filter:      "shipping",
+			destination: "arn:aws:kinesis:eu-west-1:123456789012:stream/logs",
+			created:     time.Now().Add(-time.Hour),
+			expected:    false,
+		},
+		{
+			description: "old filter of general log group with deleted general destination should not be deleted",
+			group:       "/aws/lambda/nightly-report",
+			filter:      "shipping",
+			destination: "arn:aws:firehose:eu-west-1:123456789012:deliverystream/logs",
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			filter := &cloudwatchlogs.SubscriptionFilter{
+				CreationTime:   aws.Int64(tc.created.UnixNano() / int64(time.Millisecond)),
+				DestinationArn: aws.String(tc.destination),
+				FilterName:     aws.String(tc.filter),
+				LogGroupName:   aws.String(tc.group),
+			}
+
+			actual := a.subscriptionFilterShouldBeDeleted(filter, tc.destinationExists)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", tc.group+"/"+tc.filter, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestLogDestinationShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		name        string
+		created     time.Time
+		expected    bool
+		description string
+	}{
+		{
+			description: "old ci destination should be deleted",
+			name:        "e2e-a1b2c-logs",
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    true,
+		},
+		{
+			description: "recent ci destination should not be deleted",
+			name:        "e2e-a1b2c-logs",
+			created:     time.Now().Add(-time.Hour),
+			expected:    false,
+		},
+		{
+			description: "old general destination should not be deleted",
+			name:        "central-logs",
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			destination := &cloudwatchlogs.Destination{
+				CreationTime:    aws.Int64(tc.created.UnixNano() / int64(time.Millisecond)),
+				DestinationName: aws.String(tc.name),
+			}
+
+			actual := a.logDestinationShouldBeDeleted(destination)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", tc.name, tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/lambda"
+	"github.com/aws/aws-sdk-go/service/synthetics"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+const (
+	// syntheticsRolePrefix and syntheticsPolicyPrefix are the name prefixes
+	// of the execution roles and policies Synthetics creates for canaries.
+	syntheticsRolePrefix   = "CloudWatchSyntheticsRole-"
+	syntheticsPolicyPrefix = "CloudWatchSyntheticsPolicy-"
+)
+
+// cleanCanaries deletes the CloudWatch Synthetics canaries created per CI
+// cluster. Running canaries have to be stopped before they can be deleted,
+// which is tracked by later runs. Deleting a canary leaves the Lambda layers
+// and the execution role Synthetics created for it behind, which are deleted
+// along with it.
+func (a *Cleaner) cleanCanaries(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &synthetics.DescribeCanariesInput{}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.syntheticsClient.DescribeCanaries(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, canary := range o.Canaries {
+			if !a.canaryShouldBeDeleted(canary) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that canary %#q should be deleted", *canary.Name))
+
+			res := run.Resource{
+				ID:   *canary.Name,
+				Type: "AWS::Synthetics::Canary",
+				Tags: aws.StringValueMap(canary.Tags),
+			}
+			if canary.Timeline != nil {
+				res.CreatedAt = aws.TimeValue(canary.Timeline.Created)
+			}
+
+			canary := canary
+			start := func() (string, error) {
+				return a.deleteCanary(ctx, canary)
+			}
+			err := a.run.DeleteResourceAsync(ctx, cleanerCanaries, res, start, a.pollCanary)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting canary %#q", *canary.Name), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	err = a.run.PollPending(ctx, cleanerCanaries, "AWS::Synthetics::Canary", a.pollCanary)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// deleteCanary stops the given canary, or deletes it and its leftovers once
+// it is stopped. The name of the canary is returned to poll the deletion.
+func (a *Cleaner) deleteCanary(ctx context.Context, canary *synthetics.Canary) (string, error) {
+	switch canaryState(canary) {
+	case synthetics.CanaryStateStarting, synthetics.CanaryStateRunning:
+		_, err := a.syntheticsClient.StopCanary(&synthetics.StopCanaryInput{Name: canary.Name})
+		if err != nil {
+			return "", microerror.Mask(err)
+		}
+
+		return *canary.Name, nil
+	case synthetics.CanaryStateStopping, synthetics.CanaryStateDeleting:
+		return *canary.Name, nil
+	}
+
+	i := &synthetics.DeleteCanaryInput{
+		DeleteLambda: aws.Bool(true),
+		Name:         canary.Name,
+	}
+	_, err := a.syntheticsClient.DeleteCanary(i)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	err = a.deleteCanaryLayers(ctx, canary)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	err = a.deleteCanaryRole(canary)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return *canary.Name, nil
+}
+
+// pollCanary continues deleting the canary with the given name once it is
+// stopped.
+func (a *Cleaner) pollCanary(ctx context.Context, name string) (bool, error) {
+	o, err := a.syntheticsClient.GetCanary(&synthetics.GetCanaryInput{Name: aws.String(name)})
+	if isAWSError(err, synthetics.ErrCodeResourceNotFoundException) {
+		return true, nil
+	} else if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	_, err = a.deleteCanary(ctx, o.Canary)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	return false, nil
+}
+
+// deleteCanaryLayers deletes all versions of the Lambda layer Synthetics
+// created for the given canary.
+func (a *Cleaner) deleteCanaryLayers(ctx context.Context, canary *synthetics.Canary) error {
+	i := &lambda.ListLayerVersionsInput{
+		LayerName: aws.String(fmt.Sprintf("cwsyn-%s-%s", aws.StringValue(canary.Name), aws.StringValue(canary.Id))),
+	}
+	err := paginate(ctx, &i.Marker, func() (*string, error) {
+		o, err := a.lambdaClient.ListLayerVersions(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, v := range o.LayerVersions {
+			_, err := a.lambdaClient.DeleteLayerVersion(&lambda.DeleteLayerVersionInput{LayerName: i.LayerName, VersionNumber: v.Version})
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+		}
+
+		return o.NextMarker, nil
+	})
+	if isAWSError(err, lambda.ErrCodeResourceNotFoundException) {
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// deleteCanaryRole deletes the execution role of the given canary together
+// with the policy Synthetics created for it, but only when Synthetics created
+// the role for the canary.
+func (a *Cleaner) deleteCanaryRole(canary *synthetics.Canary) error {
+	name, ok := canaryRoleName(canary)
+	if !ok {
+		return nil
+	}
+
+	o, err := a.iamClient.ListAttachedRolePolicies(&iam.ListAttachedRolePoliciesInput{RoleName: aws.String(name)})
+	if isAWSError(err, iam.ErrCodeNoSuchEntityException) {
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = a.deleteRole(name)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, p := range o.AttachedPolicies {
+		if !strings.HasPrefix(aws.StringValue(p.PolicyName), syntheticsPolicyPrefix) {
+			continue
+		}
+
+		_, err := a.iamClient.DeletePolicy(&iam.DeletePolicyInput{PolicyArn: p.PolicyArn})
+		if err != nil && !isAWSError(err, iam.ErrCodeNoSuchEntityException) {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+// canaryRoleName returns the name of the execution role of the given canary
+// if Synthetics created it for the canary.
+func canaryRoleName(canary *synthetics.Canary) (string, bool) {
+	a, err := arn.Parse(aws.StringValue(canary.ExecutionRoleArn))
+	if err != nil {
+		return "", false
+	}
+
+	name := a.Resource[strings.LastIndex(a.Resource, "/")+1:]
+	if !strings.HasPrefix(name, syntheticsRolePrefix+aws.StringValue(canary.Name)+"-") {
+		return "", false
+	}
+
+	return name, true
+}
+
+func (a *Cleaner) canaryShouldBeDeleted(canary *synthetics.Canary) bool {
+	if canary.Name == nil {
+		return false
+	}
+
+	if !a.hasCIPrefix(*canary.Name) && !a.isCITagged(aws.StringValueMap(canary.Tags)) {
+		return false
+	}
+
+	// do not delete recent canaries.
+	var created *time.Time
+	if canary.Timeline != nil {
+		created = canary.Timeline.Created
+	}
+	if isRecent(created, a.gracePeriod) {
+		return false
+	}
+
+	return true
+}
+
+func canaryState(canary *synthetics.Canary) string {
+	if canary.Status == nil {
+		return ""
+	}
+
+	return aws.StringValue(canary.Status.State)
+}
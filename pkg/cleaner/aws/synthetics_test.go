@@ -0,0 +1,108 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/synthetics"
+)
+
+func TestCanaryShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		canary      *synthetics.Canary
+		expected    bool
+		description string
+	}{
+		{
+			description: "old ci canary should be deleted",
+			canary:      newCanary("ci-a1b2c-api", nil, time.Now().Add(-2*time.Hour)),
+			expected:    true,
+		},
+		{
+			description: "old canary of ci cluster should be deleted",
+			canary:      newCanary("api", map[string]*string{clusterTag: aws.String("ci-a1b2c")}, time.Now().Add(-2*time.Hour)),
+			expected:    true,
+		},
+		{
+			description: "recent ci canary should not be deleted",
+			canary:      newCanary("ci-a1b2c-api", nil, time.Now().Add(-time.Hour)),
+			expected:    false,
+		},
+		{
+			description: "old general canary should not be deleted",
+			canary:      newCanary("happa", nil, time.Now().Add(-2*time.Hour)),
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := a.canaryShouldBeDeleted(tc.canary)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.canary.Name, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestCanaryRoleName(t *testing.T) {
+	tcs := []struct {
+		roleARN      string
+		expectedName string
+		expectedOK   bool
+		description  string
+	}{
+		{
+			description:  "role created by synthetics",
+			roleARN:      "arn:aws:iam::123456789012:role/service-role/CloudWatchSyntheticsRole-ci-a1b2c-api-1a2b",
+			expectedName: "CloudWatchSyntheticsRole-ci-a1b2c-api-1a2b",
+			expectedOK:   true,
+		},
+		{
+			description: "role created by synthetics for another canary",
+			roleARN:     "arn:aws:iam::123456789012:role/service-role/CloudWatchSyntheticsRole-happa-1a2b",
+			expectedOK:  false,
+		},
+		{
+			description: "shared role",
+			roleARN:     "arn:aws:iam::123456789012:role/ci-canaries",
+			expectedOK:  false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			canary := newCanary("ci-a1b2c-api", nil, time.Now())
+			canary.ExecutionRoleArn = aws.String(tc.roleARN)
+
+			name, ok := canaryRoleName(canary)
+			if ok != tc.expectedOK {
+				t.Fatalf("want %t, got %t", tc.expectedOK, ok)
+			}
+			if name != tc.expectedName {
+				t.Errorf("want %q, got %q", tc.expectedName, name)
+			}
+		})
+	}
+}
+
+func newCanary(name string, tags map[string]*string, created time.Time) *synthetics.Canary {
+	return &synthetics.Canary{
+		Id:   aws.String("0123abcd-0123-abcd-0123-0123456789ab"),
+		Name: aws.String(name),
+		Status: &synthetics.CanaryStatus{
+			State: aws.String(synthetics.CanaryStateRunning),
+		},
+		Tags: tags,
+		Timeline: &synthetics.CanaryTimeline{
+			Created: aws.Time(created),
+		},
+	}
+}
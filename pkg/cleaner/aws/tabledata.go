@@ -0,0 +1,227 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+	"github.com/giantswarm/ci-cleaner/pkg/tabledata"
+)
+
+// tableDataRun is the data a single test run left in a shared DynamoDB
+// table along with the primary keys of its items.
+type tableDataRun struct {
+	tabledata.Run
+
+	keys []map[string]*dynamodb.AttributeValue
+}
+
+// cleanTableData deletes the items tests write into the configured shared
+// DynamoDB tables, keyed by the ID of their run, once the run is past the
+// table data retention. Unless deleting table data is enabled, the runs are
+// only reported.
+func (a *Cleaner) cleanTableData(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	for _, t := range a.tables {
+		err := a.cleanTable(ctx, t)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			a.logger.Log("level", "error", "message", fmt.Sprintf("failed cleaning data of dynamodb table %#q", t.Name), "stack", fmt.Sprintf("%#v", err))
+		}
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) cleanTable(ctx context.Context, t tabledata.Table) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	o, err := a.dynamoDBClient.DescribeTable(&dynamodb.DescribeTableInput{TableName: aws.String(t.Name)})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	// schema holds the names of the primary key attributes, partition key
+	// first.
+	var schema []string
+	for _, k := range o.Table.KeySchema {
+		if aws.StringValue(k.KeyType) == dynamodb.KeyTypeHash {
+			schema = append([]string{aws.StringValue(k.AttributeName)}, schema...)
+		} else {
+			schema = append(schema, aws.StringValue(k.AttributeName))
+		}
+	}
+	if len(schema) == 0 {
+		return microerror.Maskf(executionFailedError, "dynamodb table %#q has no key schema", t.Name)
+	}
+	if t.KeyAttribute == "" {
+		t.KeyAttribute = schema[0]
+	}
+
+	runs := map[string]*tableDataRun{}
+	{
+		i := tableDataScanInput(t, schema)
+		for {
+			o, err := a.dynamoDBClient.Scan(i)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			addTableDataItems(runs, t, schema, o.Items)
+
+			if len(o.LastEvaluatedKey) == 0 {
+				break
+			}
+			i.ExclusiveStartKey = o.LastEvaluatedKey
+		}
+	}
+
+	var keys []string
+	for k := range runs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		r := runs[k]
+
+		id := t.Name + "/" + r.Key
+		seen, err := a.run.FirstSeen(cleanerTableData, id)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			continue
+		}
+		if !r.Expired(a.tableDataRetention, seen) {
+			continue
+		}
+
+		res := run.Resource{
+			ID:        id,
+			Type:      "AWS::DynamoDB::Item",
+			CreatedAt: r.LastWrite,
+			Note:      fmt.Sprintf("%d items", r.Items),
+		}
+
+		if !a.deleteTableData {
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found %d items of run %#q in dynamodb table %#q past their retention, which are only reported as deleting table data is disabled", r.Items, r.Key, t.Name))
+			a.run.Report(ctx, cleanerTableData, res)
+			continue
+		}
+
+		a.logger.Log("level", "info", "message", fmt.Sprintf("found that %d items of run %#q in dynamodb table %#q should be deleted", r.Items, r.Key, t.Name))
+
+		name := t.Name
+		itemKeys := r.keys
+		err = a.run.DeleteResource(ctx, cleanerTableData, res, func() error {
+			for _, key := range itemKeys {
+				_, err := a.dynamoDBClient.DeleteItem(&dynamodb.DeleteItemInput{TableName: aws.String(name), Key: key})
+				if err != nil {
+					return microerror.Mask(err)
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting items of run %#q in dynamodb table %#q", r.Key, t.Name), "stack", fmt.Sprintf("%#v", err))
+		}
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// tableDataScanInput returns the scan of the given table for the items whose
+// key attribute has one of its key prefixes, projected to the primary key,
+// the key attribute and the timestamp attribute. Attribute names are passed
+// as placeholders, so that reserved words like `name` can be used.
+func tableDataScanInput(t tabledata.Table, schema []string) *dynamodb.ScanInput {
+	names := map[string]*string{}
+	placeholders := map[string]string{}
+	var projection []string
+	for _, attr := range append(append([]string{}, schema...), t.KeyAttribute, t.TimestampAttribute) {
+		if attr == "" || placeholders[attr] != "" {
+			continue
+		}
+
+		p := fmt.Sprintf("#a%d", len(placeholders))
+		placeholders[attr] = p
+		names[p] = aws.String(attr)
+		projection = append(projection, p)
+	}
+
+	values := map[string]*dynamodb.AttributeValue{}
+	var conditions []string
+	for i, prefix := range t.KeyPrefixes {
+		v := fmt.Sprintf(":p%d", i)
+		values[v] = &dynamodb.AttributeValue{S: aws.String(prefix)}
+		conditions = append(conditions, fmt.Sprintf("begins_with(%s, %s)", placeholders[t.KeyAttribute], v))
+	}
+
+	i := &dynamodb.ScanInput{
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+		FilterExpression:          aws.String(strings.Join(conditions, " OR ")),
+		ProjectionExpression:      aws.String(strings.Join(projection, ", ")),
+		TableName:                 aws.String(t.Name),
+	}
+
+	return i
+}
+
+// addTableDataItems groups the given items by the run ID in their key
+// attribute into the given runs. Items whose run ID does not have one of the
+// key prefixes of the table are skipped, even though the scan filters them
+// already.
+func addTableDataItems(runs map[string]*tableDataRun, t tabledata.Table, schema []string, items []map[string]*dynamodb.AttributeValue) {
+	for _, item := range items {
+		v, ok := item[t.KeyAttribute]
+		if !ok || v.S == nil || !t.HasKeyPrefix(*v.S) {
+			continue
+		}
+
+		key := map[string]*dynamodb.AttributeValue{}
+		for _, attr := range schema {
+			key[attr] = item[attr]
+		}
+
+		r, ok := runs[*v.S]
+		if !ok {
+			r = &tableDataRun{Run: tabledata.Run{Key: *v.S}}
+			runs[*v.S] = r
+		}
+		r.keys = append(r.keys, key)
+		r.Add(tableDataTimestamp(item[t.TimestampAttribute]))
+	}
+}
+
+// tableDataTimestamp returns when an item was written according to the given
+// value of its timestamp attribute, which is zero when it does not tell.
+func tableDataTimestamp(v *dynamodb.AttributeValue) time.Time {
+	if v == nil {
+		return time.Time{}
+	}
+
+	s := aws.StringValue(v.S)
+	if v.N != nil {
+		s = *v.N
+	}
+
+	t, _ := tabledata.ParseTimestamp(s)
+	return t
+}
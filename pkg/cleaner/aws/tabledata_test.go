@@ -0,0 +1,102 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+
+	"github.com/giantswarm/ci-cleaner/pkg/tabledata"
+)
+
+func TestTableDataScanInput(t *testing.T) {
+	table := tabledata.Table{
+		Name:               "e2e-results",
+		KeyAttribute:       "runId",
+		KeyPrefixes:        []string{"ci-run-", "e2e-"},
+		TimestampAttribute: "createdAt",
+	}
+
+	i := tableDataScanInput(table, []string{"runId", "name"})
+
+	if e, a := "#a0, #a1, #a2", aws.StringValue(i.ProjectionExpression); e != a {
+		t.Errorf("want projection %q, got %q", e, a)
+	}
+	if e, a := "begins_with(#a0, :p0) OR begins_with(#a0, :p1)", aws.StringValue(i.FilterExpression); e != a {
+		t.Errorf("want filter %q, got %q", e, a)
+	}
+	if e, a := "createdAt", aws.StringValue(i.ExpressionAttributeNames["#a2"]); e != a {
+		t.Errorf("want timestamp attribute %q, got %q", e, a)
+	}
+}
+
+func TestAddTableDataItems(t *testing.T) {
+	old := time.Now().Add(-8 * 24 * time.Hour).UTC().Format(time.RFC3339)
+	recent := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+
+	table := tabledata.Table{
+		Name:               "e2e-results",
+		KeyAttribute:       "runId",
+		KeyPrefixes:        []string{"ci-run-"},
+		TimestampAttribute: "createdAt",
+	}
+	item := func(run string, name string, created string) map[string]*dynamodb.AttributeValue {
+		return map[string]*dynamodb.AttributeValue{
+			"runId":     {S: aws.String(run)},
+			"name":      {S: aws.String(name)},
+			"createdAt": {S: aws.String(created)},
+		}
+	}
+
+	runs := map[string]*tableDataRun{}
+	addTableDataItems(runs, table, []string{"runId", "name"}, []map[string]*dynamodb.AttributeValue{
+		item("ci-run-1", "a", old),
+		item("ci-run-1", "b", old),
+		item("ci-run-2", "a", old),
+		item("ci-run-2", "b", recent),
+		item("release-1", "a", old),
+	})
+
+	tcs := []struct {
+		run         string
+		items       int
+		expected    bool
+		description string
+	}{
+		{
+			description: "run with old items only should be deleted",
+			run:         "ci-run-1",
+			items:       2,
+			expected:    true,
+		},
+		{
+			description: "run with a recent item should not be deleted",
+			run:         "ci-run-2",
+			items:       2,
+			expected:    false,
+		},
+	}
+
+	if len(runs) != len(tcs) {
+		t.Fatalf("want %d runs, got %d", len(tcs), len(runs))
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			r, ok := runs[tc.run]
+			if !ok {
+				t.Fatalf("run %q not found", tc.run)
+			}
+			if r.Items != tc.items || len(r.keys) != tc.items {
+				t.Errorf("want %d items of run %q, got %d", tc.items, tc.run, r.Items)
+			}
+
+			actual := r.Expired(defaultTableDataRetention, time.Now())
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", tc.run, tc.expected, actual)
+			}
+		})
+	}
+}
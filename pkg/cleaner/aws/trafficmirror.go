@@ -0,0 +1,208 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanTrafficMirroring deletes the traffic mirror sessions CI clusters
+// create, which are billed hourly per mirrored network interface, followed by
+// the CI traffic mirror targets and filters no session uses anymore.
+func (a *Cleaner) cleanTrafficMirroring(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	var sessions []*ec2.TrafficMirrorSession
+	{
+		i := &ec2.DescribeTrafficMirrorSessionsInput{}
+		err := paginate(ctx, &i.NextToken, func() (*string, error) {
+			o, err := a.ec2Client.DescribeTrafficMirrorSessions(i)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			sessions = append(sessions, o.TrafficMirrorSessions...)
+
+			return o.NextToken, nil
+		})
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			return errors
+		}
+	}
+
+	// inUse holds the IDs of the targets and filters of the sessions which
+	// are kept.
+	inUse := map[string]bool{}
+	for _, session := range sessions {
+		deleted, err := a.deleteTrafficMirrorSession(ctx, session)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting traffic mirror session %#q", *session.TrafficMirrorSessionId), "stack", fmt.Sprintf("%#v", err))
+		}
+		if !deleted {
+			inUse[aws.StringValue(session.TrafficMirrorTargetId)] = true
+			inUse[aws.StringValue(session.TrafficMirrorFilterId)] = true
+		}
+	}
+
+	{
+		i := &ec2.DescribeTrafficMirrorTargetsInput{}
+		err := paginate(ctx, &i.NextToken, func() (*string, error) {
+			o, err := a.ec2Client.DescribeTrafficMirrorTargets(i)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			for _, target := range o.TrafficMirrorTargets {
+				if target.TrafficMirrorTargetId == nil || inUse[*target.TrafficMirrorTargetId] || !a.isCITagged(ec2Tags(target.Tags)) {
+					continue
+				}
+
+				res := run.Resource{
+					ID:     *target.TrafficMirrorTargetId,
+					Type:   "AWS::EC2::TrafficMirrorTarget",
+					Tags:   ec2Tags(target.Tags),
+					Reason: run.ReasonUnused,
+				}
+				id := target.TrafficMirrorTargetId
+				err := a.deleteTrafficMirrorResource(ctx, res, func() error {
+					_, err := a.ec2Client.DeleteTrafficMirrorTarget(&ec2.DeleteTrafficMirrorTargetInput{TrafficMirrorTargetId: id})
+					if err != nil && !isAWSError(err, "InvalidTrafficMirrorTargetId.NotFound") {
+						return microerror.Mask(err)
+					}
+
+					return nil
+				})
+				if err != nil {
+					errors.Append(microerror.Mask(err))
+					a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting traffic mirror target %#q", *id), "stack", fmt.Sprintf("%#v", err))
+				}
+			}
+
+			return o.NextToken, nil
+		})
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			return errors
+		}
+	}
+
+	{
+		i := &ec2.DescribeTrafficMirrorFiltersInput{}
+		err := paginate(ctx, &i.NextToken, func() (*string, error) {
+			o, err := a.ec2Client.DescribeTrafficMirrorFilters(i)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			for _, filter := range o.TrafficMirrorFilters {
+				if filter.TrafficMirrorFilterId == nil || inUse[*filter.TrafficMirrorFilterId] || !a.isCITagged(ec2Tags(filter.Tags)) {
+					continue
+				}
+
+				res := run.Resource{
+					ID:     *filter.TrafficMirrorFilterId,
+					Type:   "AWS::EC2::TrafficMirrorFilter",
+					Tags:   ec2Tags(filter.Tags),
+					Reason: run.ReasonUnused,
+				}
+				id := filter.TrafficMirrorFilterId
+				err := a.deleteTrafficMirrorResource(ctx, res, func() error {
+					_, err := a.ec2Client.DeleteTrafficMirrorFilter(&ec2.DeleteTrafficMirrorFilterInput{TrafficMirrorFilterId: id})
+					if err != nil && !isAWSError(err, "InvalidTrafficMirrorFilterId.NotFound") {
+						return microerror.Mask(err)
+					}
+
+					return nil
+				})
+				if err != nil {
+					errors.Append(microerror.Mask(err))
+					a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting traffic mirror filter %#q", *id), "stack", fmt.Sprintf("%#v", err))
+				}
+			}
+
+			return o.NextToken, nil
+		})
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			return errors
+		}
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// deleteTrafficMirrorSession deletes the given session if it belongs to CI
+// and was first found more than the grace period ago. It returns whether the
+// session is gone.
+func (a *Cleaner) deleteTrafficMirrorSession(ctx context.Context, session *ec2.TrafficMirrorSession) (bool, error) {
+	if !a.isCITrafficMirrorSession(session) {
+		return false, nil
+	}
+
+	res := run.Resource{
+		ID:          *session.TrafficMirrorSessionId,
+		Type:        "AWS::EC2::TrafficMirrorSession",
+		Tags:        ec2Tags(session.Tags),
+		Cost:        fmt.Sprintf("traffic mirror session of network interface %s, billed hourly until deleted", aws.StringValue(session.NetworkInterfaceId)),
+		MonthlyCost: trafficMirrorHourlyCost * hoursPerMonth,
+	}
+	deleted := false
+	err := a.deleteTrafficMirrorResource(ctx, res, func() error {
+		_, err := a.ec2Client.DeleteTrafficMirrorSession(&ec2.DeleteTrafficMirrorSessionInput{TrafficMirrorSessionId: session.TrafficMirrorSessionId})
+		if err != nil && !isAWSError(err, "InvalidTrafficMirrorSessionId.NotFound") {
+			return microerror.Mask(err)
+		}
+
+		deleted = true
+
+		return nil
+	})
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	return deleted, nil
+}
+
+// deleteTrafficMirrorResource deletes the given traffic mirroring resource
+// once it was first found more than the grace period ago.
+func (a *Cleaner) deleteTrafficMirrorResource(ctx context.Context, res run.Resource, fn func() error) error {
+	seen, err := a.run.FirstSeen(cleanerTrafficMirroring, res.ID)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	// do not delete recent traffic mirroring resources.
+	if time.Since(seen) < a.gracePeriod {
+		return nil
+	}
+
+	a.logger.Log("level", "info", "message", fmt.Sprintf("found that %s %#q should be deleted", res.Type, res.ID))
+
+	err = a.run.DeleteResource(ctx, cleanerTrafficMirroring, res, fn)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (a *Cleaner) isCITrafficMirrorSession(session *ec2.TrafficMirrorSession) bool {
+	if session.TrafficMirrorSessionId == nil {
+		return false
+	}
+
+	return a.isCITagged(ec2Tags(session.Tags))
+}
@@ -0,0 +1,51 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func TestIsCITrafficMirrorSession(t *testing.T) {
+	tcs := []struct {
+		tags        []*ec2.Tag
+		expected    bool
+		description string
+	}{
+		{
+			description: "session named after ci cluster should be deleted",
+			tags:        []*ec2.Tag{{Key: aws.String("Name"), Value: aws.String("ci-wip-a1b2c-mirror")}},
+			expected:    true,
+		},
+		{
+			description: "session tagged with ci cluster should be deleted",
+			tags:        []*ec2.Tag{{Key: aws.String(clusterTag), Value: aws.String("ci-wip-a1b2c")}},
+			expected:    true,
+		},
+		{
+			description: "general session should not be deleted",
+			tags:        []*ec2.Tag{{Key: aws.String("Name"), Value: aws.String("ids-mirror")}},
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		prefixes: defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			session := &ec2.TrafficMirrorSession{
+				Tags:                   tc.tags,
+				TrafficMirrorSessionId: aws.String("tms-0123456789abcdef0"),
+			}
+
+			actual := a.isCITrafficMirrorSession(session)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *session.TrafficMirrorSessionId, tc.expected, actual)
+			}
+		})
+	}
+}
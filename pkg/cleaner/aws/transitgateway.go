@@ -0,0 +1,198 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanTransitGateways deletes the CI transit gateway VPC and peering
+// attachments tests exercising transit gateway peering leak, followed by the
+// CI transit gateways without any attachments left. Attachments take a while
+// to be deleted, which is why their transit gateways are only deleted by a
+// later run.
+func (a *Cleaner) cleanTransitGateways(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	// attachments counts the attachments of every transit gateway which are
+	// not gone yet.
+	attachments := map[string]int{}
+
+	i := &ec2.DescribeTransitGatewayAttachmentsInput{}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.ec2Client.DescribeTransitGatewayAttachments(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, attachment := range o.TransitGatewayAttachments {
+			if !isTransitGatewayAttachmentGone(attachment) {
+				attachments[aws.StringValue(attachment.TransitGatewayId)]++
+			}
+
+			if !a.transitGatewayAttachmentShouldBeDeleted(attachment) {
+				continue
+			}
+
+			err := a.deleteTransitGatewayAttachment(ctx, attachment)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting transit gateway attachment %#q", *attachment.TransitGatewayAttachmentId), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	{
+		i := &ec2.DescribeTransitGatewaysInput{}
+		err := paginate(ctx, &i.NextToken, func() (*string, error) {
+			o, err := a.ec2Client.DescribeTransitGateways(i)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			for _, tgw := range o.TransitGateways {
+				if !a.transitGatewayShouldBeDeleted(tgw, attachments) {
+					continue
+				}
+
+				a.logger.Log("level", "info", "message", fmt.Sprintf("found that transit gateway %#q should be deleted", *tgw.TransitGatewayId))
+
+				res := run.Resource{
+					ID:        *tgw.TransitGatewayId,
+					Type:      "AWS::EC2::TransitGateway",
+					Tags:      ec2Tags(tgw.Tags),
+					CreatedAt: aws.TimeValue(tgw.CreationTime),
+				}
+				id := tgw.TransitGatewayId
+				err := a.run.DeleteResource(ctx, cleanerTransitGateways, res, func() error {
+					_, err := a.ec2Client.DeleteTransitGateway(&ec2.DeleteTransitGatewayInput{TransitGatewayId: id})
+					if err != nil && !isAWSError(err, "InvalidTransitGatewayID.NotFound") {
+						return microerror.Mask(err)
+					}
+
+					return nil
+				})
+				if err != nil {
+					errors.Append(microerror.Mask(err))
+					a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting transit gateway %#q", *tgw.TransitGatewayId), "stack", fmt.Sprintf("%#v", err))
+				}
+			}
+
+			return o.NextToken, nil
+		})
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			return errors
+		}
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) deleteTransitGatewayAttachment(ctx context.Context, attachment *ec2.TransitGatewayAttachment) error {
+	a.logger.Log("level", "info", "message", fmt.Sprintf("found that transit gateway %s attachment %#q should be deleted", *attachment.ResourceType, *attachment.TransitGatewayAttachmentId))
+
+	res := run.Resource{
+		ID:          *attachment.TransitGatewayAttachmentId,
+		Type:        "AWS::EC2::TransitGatewayAttachment",
+		Tags:        ec2Tags(attachment.Tags),
+		CreatedAt:   aws.TimeValue(attachment.CreationTime),
+		MonthlyCost: transitGatewayAttachmentHourlyCost * hoursPerMonth,
+	}
+	id := attachment.TransitGatewayAttachmentId
+	err := a.run.DeleteResource(ctx, cleanerTransitGateways, res, func() error {
+		var err error
+		if *attachment.ResourceType == ec2.TransitGatewayAttachmentResourceTypePeering {
+			_, err = a.ec2Client.DeleteTransitGatewayPeeringAttachment(&ec2.DeleteTransitGatewayPeeringAttachmentInput{TransitGatewayAttachmentId: id})
+		} else {
+			_, err = a.ec2Client.DeleteTransitGatewayVpcAttachment(&ec2.DeleteTransitGatewayVpcAttachmentInput{TransitGatewayAttachmentId: id})
+		}
+		if err != nil && !isAWSError(err, "InvalidTransitGatewayAttachmentID.NotFound") {
+			return microerror.Mask(err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// isTransitGatewayAttachmentGone returns whether the given attachment does
+// not keep its transit gateway from being deleted anymore.
+func isTransitGatewayAttachmentGone(attachment *ec2.TransitGatewayAttachment) bool {
+	switch aws.StringValue(attachment.State) {
+	case ec2.TransitGatewayAttachmentStateDeleted, ec2.TransitGatewayAttachmentStateFailed, ec2.TransitGatewayAttachmentStateRejected:
+		return true
+	}
+
+	return false
+}
+
+func (a *Cleaner) transitGatewayAttachmentShouldBeDeleted(attachment *ec2.TransitGatewayAttachment) bool {
+	if attachment.TransitGatewayAttachmentId == nil || attachment.ResourceType == nil {
+		return false
+	}
+
+	// only VPC and peering attachments are created by tests.
+	switch *attachment.ResourceType {
+	case ec2.TransitGatewayAttachmentResourceTypeVpc, ec2.TransitGatewayAttachmentResourceTypePeering:
+	default:
+		return false
+	}
+
+	// do not delete attachments which are gone or being deleted already.
+	if isTransitGatewayAttachmentGone(attachment) || aws.StringValue(attachment.State) == ec2.TransitGatewayAttachmentStateDeleting {
+		return false
+	}
+
+	if !a.isCITagged(ec2Tags(attachment.Tags)) {
+		return false
+	}
+
+	// do not delete recent attachments.
+	if isRecent(attachment.CreationTime, a.gracePeriod) {
+		return false
+	}
+
+	return true
+}
+
+func (a *Cleaner) transitGatewayShouldBeDeleted(tgw *ec2.TransitGateway, attachments map[string]int) bool {
+	if tgw.TransitGatewayId == nil || aws.StringValue(tgw.State) != ec2.TransitGatewayStateAvailable {
+		return false
+	}
+
+	if !a.isCITagged(ec2Tags(tgw.Tags)) {
+		return false
+	}
+
+	// do not delete transit gateways which are still attached to anything.
+	if attachments[*tgw.TransitGatewayId] > 0 {
+		return false
+	}
+
+	// do not delete recent transit gateways.
+	if isRecent(tgw.CreationTime, a.gracePeriod) {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,152 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func TestTransitGatewayAttachmentShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		resourceType string
+		state        string
+		name         string
+		created      time.Time
+		expected     bool
+		description  string
+	}{
+		{
+			description:  "old ci vpc attachment should be deleted",
+			resourceType: ec2.TransitGatewayAttachmentResourceTypeVpc,
+			state:        ec2.TransitGatewayAttachmentStateAvailable,
+			name:         "ci-wip-a1b2c",
+			created:      time.Now().Add(-2 * time.Hour),
+			expected:     true,
+		},
+		{
+			description:  "old ci peering attachment pending acceptance should be deleted",
+			resourceType: ec2.TransitGatewayAttachmentResourceTypePeering,
+			state:        ec2.TransitGatewayAttachmentStatePendingAcceptance,
+			name:         "ci-wip-a1b2c",
+			created:      time.Now().Add(-2 * time.Hour),
+			expected:     true,
+		},
+		{
+			description:  "old ci vpn attachment should not be deleted",
+			resourceType: ec2.TransitGatewayAttachmentResourceTypeVpn,
+			state:        ec2.TransitGatewayAttachmentStateAvailable,
+			name:         "ci-wip-a1b2c",
+			created:      time.Now().Add(-2 * time.Hour),
+			expected:     false,
+		},
+		{
+			description:  "old ci vpc attachment being deleted should not be deleted",
+			resourceType: ec2.TransitGatewayAttachmentResourceTypeVpc,
+			state:        ec2.TransitGatewayAttachmentStateDeleting,
+			name:         "ci-wip-a1b2c",
+			created:      time.Now().Add(-2 * time.Hour),
+			expected:     false,
+		},
+		{
+			description:  "recent ci vpc attachment should not be deleted",
+			resourceType: ec2.TransitGatewayAttachmentResourceTypeVpc,
+			state:        ec2.TransitGatewayAttachmentStateAvailable,
+			name:         "ci-wip-a1b2c",
+			created:      time.Now().Add(-time.Hour),
+			expected:     false,
+		},
+		{
+			description:  "old general vpc attachment should not be deleted",
+			resourceType: ec2.TransitGatewayAttachmentResourceTypeVpc,
+			state:        ec2.TransitGatewayAttachmentStateAvailable,
+			name:         "installation",
+			created:      time.Now().Add(-2 * time.Hour),
+			expected:     false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			attachment := &ec2.TransitGatewayAttachment{
+				CreationTime:               aws.Time(tc.created),
+				ResourceType:               aws.String(tc.resourceType),
+				State:                      aws.String(tc.state),
+				Tags:                       []*ec2.Tag{{Key: aws.String("Name"), Value: aws.String(tc.name)}},
+				TransitGatewayAttachmentId: aws.String("tgw-attach-0123456789abcdef0"),
+				TransitGatewayId:           aws.String("tgw-0123456789abcdef0"),
+			}
+
+			actual := a.transitGatewayAttachmentShouldBeDeleted(attachment)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *attachment.TransitGatewayAttachmentId, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestTransitGatewayShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		name        string
+		attachments int
+		created     time.Time
+		expected    bool
+		description string
+	}{
+		{
+			description: "old ci transit gateway without attachments should be deleted",
+			name:        "ci-wip-a1b2c",
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    true,
+		},
+		{
+			description: "old ci transit gateway with attachments should not be deleted",
+			name:        "ci-wip-a1b2c",
+			attachments: 1,
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    false,
+		},
+		{
+			description: "recent ci transit gateway without attachments should not be deleted",
+			name:        "ci-wip-a1b2c",
+			created:     time.Now().Add(-time.Hour),
+			expected:    false,
+		},
+		{
+			description: "old general transit gateway without attachments should not be deleted",
+			name:        "installation",
+			created:     time.Now().Add(-2 * time.Hour),
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			tgw := &ec2.TransitGateway{
+				CreationTime:     aws.Time(tc.created),
+				State:            aws.String(ec2.TransitGatewayStateAvailable),
+				Tags:             []*ec2.Tag{{Key: aws.String("Name"), Value: aws.String(tc.name)}},
+				TransitGatewayId: aws.String("tgw-0123456789abcdef0"),
+			}
+			attachments := map[string]int{*tgw.TransitGatewayId: tc.attachments}
+
+			actual := a.transitGatewayShouldBeDeleted(tgw, attachments)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tgw.TransitGatewayId, tc.expected, actual)
+			}
+		})
+	}
+}
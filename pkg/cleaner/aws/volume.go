@@ -0,0 +1,121 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanVolumes deletes unattached volumes of CI clusters, which dynamic
+// provisioning leaves behind by the hundreds. At most maxVolumesPerRun
+// volumes are deleted per run, so that wrongly tagged volumes cannot all be
+// wiped at once. The remaining volumes are logged and left to later runs.
+func (a *Cleaner) cleanVolumes(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	var deleted int
+	var skipped []string
+
+	var nextToken *string
+	err := paginate(ctx, &nextToken, func() (*string, error) {
+		i := &ec2.DescribeVolumesInput{
+			Filters: []*ec2.Filter{
+				{
+					Name:   aws.String("status"),
+					Values: []*string{aws.String(ec2.VolumeStateAvailable)},
+				},
+			},
+			NextToken: nextToken,
+		}
+
+		o, err := a.ec2Client.DescribeVolumes(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, volume := range o.Volumes {
+			if !a.volumeShouldBeDeleted(volume) {
+				continue
+			}
+
+			if deleted >= a.maxVolumesPerRun {
+				skipped = append(skipped, *volume.VolumeId)
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that volume %#q should be deleted", *volume.VolumeId))
+
+			res := run.Resource{
+				ID:          *volume.VolumeId,
+				Type:        "AWS::EC2::Volume",
+				Tags:        ec2Tags(volume.Tags),
+				CreatedAt:   aws.TimeValue(volume.CreateTime),
+				Cost:        fmt.Sprintf("%d GiB %s volume", aws.Int64Value(volume.Size), aws.StringValue(volume.VolumeType)),
+				MonthlyCost: volumeMonthlyCost(volume),
+			}
+			if volume.AvailabilityZone != nil {
+				az := *volume.AvailabilityZone
+				res.Region = az[:len(az)-1]
+			}
+
+			// Only volumes actually deleted count against the limit, not
+			// the ones which are protected or only reported.
+			err := a.run.DeleteResource(ctx, cleanerVolumes, res, func() error {
+				deleted++
+
+				_, err := a.ec2Client.DeleteVolume(&ec2.DeleteVolumeInput{VolumeId: volume.VolumeId})
+				if err != nil {
+					return microerror.Mask(err)
+				}
+
+				return nil
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting volume %#q", *volume.VolumeId), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	if len(skipped) != 0 {
+		a.logger.Log("level", "warning", "message", fmt.Sprintf("not deleting %d more volumes as at most %d volumes are deleted per run: %s", len(skipped), a.maxVolumesPerRun, strings.Join(skipped, ", ")))
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) volumeShouldBeDeleted(volume *ec2.Volume) bool {
+	if volume.VolumeId == nil {
+		return false
+	}
+
+	// do not delete volumes which are in use.
+	if aws.StringValue(volume.State) != ec2.VolumeStateAvailable || len(volume.Attachments) != 0 {
+		return false
+	}
+
+	// do not delete recent volumes.
+	if isRecent(volume.CreateTime, a.gracePeriod) {
+		return false
+	}
+
+	tags := ec2Tags(volume.Tags)
+
+	return a.isCITagged(tags)
+}
@@ -0,0 +1,150 @@
+package aws
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/giantswarm/micrologger/microloggertest"
+
+	"github.com/giantswarm/ci-cleaner/pkg/protection"
+	"github.com/giantswarm/ci-cleaner/pkg/report"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+	"github.com/giantswarm/ci-cleaner/pkg/state"
+)
+
+type volumeEC2ClientMock struct {
+	EC2Client
+
+	volumes []*ec2.Volume
+	deleted []string
+}
+
+func (c *volumeEC2ClientMock) DescribeVolumes(*ec2.DescribeVolumesInput) (*ec2.DescribeVolumesOutput, error) {
+	return &ec2.DescribeVolumesOutput{Volumes: c.volumes}, nil
+}
+
+func (c *volumeEC2ClientMock) DeleteVolume(i *ec2.DeleteVolumeInput) (*ec2.DeleteVolumeOutput, error) {
+	c.deleted = append(c.deleted, aws.StringValue(i.VolumeId))
+	return &ec2.DeleteVolumeOutput{}, nil
+}
+
+func TestVolumeShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		volume      *ec2.Volume
+		expected    bool
+		description string
+	}{
+		{
+			description: "old volume of ci cluster should be deleted",
+			volume:      newVolume(ec2.VolumeStateAvailable, time.Now().Add(-2*time.Hour), clusterTag, "ci-a1b2c"),
+			expected:    true,
+		},
+		{
+			description: "old dynamically provisioned volume of ci cluster should be deleted",
+			volume:      newVolume(ec2.VolumeStateAvailable, time.Now().Add(-2*time.Hour), "kubernetes.io/cluster/ci-a1b2c", "owned"),
+			expected:    true,
+		},
+		{
+			description: "recent volume of ci cluster should not be deleted",
+			volume:      newVolume(ec2.VolumeStateAvailable, time.Now().Add(-time.Hour), clusterTag, "ci-a1b2c"),
+			expected:    false,
+		},
+		{
+			description: "attached volume of ci cluster should not be deleted",
+			volume:      newVolume(ec2.VolumeStateInUse, time.Now().Add(-2*time.Hour), clusterTag, "ci-a1b2c"),
+			expected:    false,
+		},
+		{
+			description: "old volume of general cluster should not be deleted",
+			volume:      newVolume(ec2.VolumeStateAvailable, time.Now().Add(-2*time.Hour), "kubernetes.io/cluster/gauss", "owned"),
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		gracePeriod: defaultGracePeriod,
+		prefixes:    defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := a.volumeShouldBeDeleted(tc.volume)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.volume.VolumeId, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func newVolume(state string, createTime time.Time, tagKey, tagValue string) *ec2.Volume {
+	return &ec2.Volume{
+		CreateTime: aws.Time(createTime),
+		State:      aws.String(state),
+		Tags: []*ec2.Tag{
+			{Key: aws.String(tagKey), Value: aws.String(tagValue)},
+		},
+		VolumeId: aws.String("vol-0123456789abcdef0"),
+	}
+}
+
+func TestCleanVolumesLimitCountsDeletedVolumesOnly(t *testing.T) {
+	var volumes []*ec2.Volume
+	for _, c := range []struct {
+		id      string
+		cluster string
+	}{
+		{id: "vol-0000000000000000a", cluster: "ci-wip-keep1"},
+		{id: "vol-0000000000000000b", cluster: "ci-wip-keep2"},
+		{id: "vol-0000000000000000c", cluster: "ci-wip-a1b2c"},
+		{id: "vol-0000000000000000d", cluster: "ci-wip-d4e5f"},
+	} {
+		v := newVolume(ec2.VolumeStateAvailable, time.Now().Add(-2*time.Hour), clusterTag, c.cluster)
+		v.VolumeId = aws.String(c.id)
+		volumes = append(volumes, v)
+	}
+	client := &volumeEC2ClientMock{volumes: volumes}
+
+	stateStore, err := state.New(state.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := protection.New(protection.Config{State: stateStore})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.Keep("ci-wip-keep", time.Hour, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := run.New(run.Config{
+		Logger:     microloggertest.New(),
+		Protection: p,
+		Report:     report.New("aws"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := &Cleaner{
+		ec2Client:        client,
+		gracePeriod:      defaultGracePeriod,
+		logger:           microloggertest.New(),
+		maxVolumesPerRun: 1,
+		prefixes:         defaultPrefixes,
+		run:              r,
+	}
+
+	err = a.cleanVolumes(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(client.deleted) != 1 || client.deleted[0] != "vol-0000000000000000c" {
+		t.Errorf("expected protected volumes not to count against the limit, deleted %v", client.deleted)
+	}
+}
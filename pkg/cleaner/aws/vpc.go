@@ -0,0 +1,702 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/graph"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+const (
+	kindEgressOnlyInternetGateway = "AWS::EC2::EgressOnlyInternetGateway"
+	kindInstance                  = "AWS::EC2::Instance"
+	kindInternetGateway           = "AWS::EC2::InternetGateway"
+	kindNATGateway                = "AWS::EC2::NatGateway"
+	kindNetworkInterface          = "AWS::EC2::NetworkInterface"
+	kindRouteTable                = "AWS::EC2::RouteTable"
+	kindSecurityGroup             = "AWS::EC2::SecurityGroup"
+	kindSubnet                    = "AWS::EC2::Subnet"
+	kindVPC                       = "AWS::EC2::VPC"
+	kindVPCCIDRBlock              = "AWS::EC2::VPCCidrBlock"
+	kindVPCEndpoint               = "AWS::EC2::VPCEndpoint"
+)
+
+// amazonIPv6Pool is the pool of IPv6 CIDR blocks provided by Amazon, which
+// are released along with their VPC.
+const amazonIPv6Pool = "Amazon"
+
+// vpcInventory holds the resources inside a single VPC which have to be
+// deleted before the VPC itself.
+type vpcInventory struct {
+	egressOnlyInternetGateways []*ec2.EgressOnlyInternetGateway
+	instances                  []*ec2.Instance
+	internetGateways           []*ec2.InternetGateway
+	// ipv6CIDRBlocks are the IPv6 CIDR blocks the VPC was assigned from our
+	// BYOIP pools, which are only returned to the pool once disassociated.
+	ipv6CIDRBlocks    []*ec2.VpcIpv6CidrBlockAssociation
+	natGateways       []*ec2.NatGateway
+	networkInterfaces []*ec2.NetworkInterface
+	routeTables       []*ec2.RouteTable
+	securityGroups    []*ec2.SecurityGroup
+	subnets           []*ec2.Subnet
+	vpcEndpoints      []*ec2.VpcEndpoint
+}
+
+// cleanVPCs tears down CI VPCs which leaked because their CloudFormation
+// stack failed to be deleted. The resources inside a VPC are deleted in the
+// order given by their dependency graph, which is added to the report, so
+// that humans can see why a VPC refuses to be deleted. Resources which take
+// a while to be deleted, like instances and NAT gateways, block their
+// dependencies until a later run.
+func (a *Cleaner) cleanVPCs(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &ec2.DescribeVpcsInput{}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.ec2Client.DescribeVpcs(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, vpc := range o.Vpcs {
+			if vpc.VpcId == nil || aws.BoolValue(vpc.IsDefault) || !a.isCITagged(ec2Tags(vpc.Tags)) {
+				continue
+			}
+
+			seen, err := a.run.FirstSeen(cleanerVPCs, *vpc.VpcId)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				continue
+			}
+
+			// do not delete recent VPCs.
+			if time.Since(seen) < a.gracePeriod {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that vpc %#q should be deleted", *vpc.VpcId))
+
+			err = a.deleteVPC(ctx, vpc)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting vpc %#q", *vpc.VpcId), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// deleteVPC deletes the resources inside the given VPC in dependency order
+// and the VPC itself.
+func (a *Cleaner) deleteVPC(ctx context.Context, vpc *ec2.Vpc) error {
+	inv, err := a.vpcInventory(ctx, vpc)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	g := vpcGraph(*vpc.VpcId, inv)
+	a.run.AddGraph(g)
+
+	order, err := g.Order()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	fns := a.vpcDeleteFuncs(vpc, inv)
+
+	// blocked holds the resources which cannot be deleted in this run, as
+	// resources depending on them failed to be deleted or are still being
+	// deleted.
+	blocked := map[string]bool{}
+	block := func(id string) {
+		for _, e := range g.Edges {
+			if e.From == id {
+				blocked[e.To] = true
+			}
+		}
+	}
+
+	errors := &errorcollection.ErrorCollection{}
+	for _, n := range order {
+		if blocked[n.ID] {
+			a.logger.Log("level", "debug", "message", fmt.Sprintf("not deleting %#q before the resources depending on it are gone", n.ID))
+			block(n.ID)
+			continue
+		}
+
+		res := run.Resource{
+			ID:   n.ID,
+			Type: n.Kind,
+		}
+		if n.ID == *vpc.VpcId {
+			res.Tags = ec2Tags(vpc.Tags)
+		}
+
+		err := a.run.DeleteResource(ctx, cleanerVPCs, res, fns[n.ID])
+		if err != nil {
+			g.SetError(n.ID, err)
+			errors.Append(microerror.Mask(err))
+			block(n.ID)
+			continue
+		}
+
+		// instances and NAT gateways take minutes to be deleted.
+		if n.Kind == kindInstance || n.Kind == kindNATGateway {
+			block(n.ID)
+		}
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// vpcInventory lists the resources inside the given VPC.
+func (a *Cleaner) vpcInventory(ctx context.Context, vpc *ec2.Vpc) (vpcInventory, error) {
+	var inv vpcInventory
+
+	vpcID := *vpc.VpcId
+
+	for _, association := range vpc.Ipv6CidrBlockAssociationSet {
+		if !isBYOIPCIDRBlockAssociation(association) {
+			continue
+		}
+		inv.ipv6CIDRBlocks = append(inv.ipv6CIDRBlocks, association)
+	}
+
+	{
+		// egress-only internet gateways cannot be filtered by the VPC they
+		// are attached to.
+		i := &ec2.DescribeEgressOnlyInternetGatewaysInput{}
+		err := paginate(ctx, &i.NextToken, func() (*string, error) {
+			o, err := a.ec2Client.DescribeEgressOnlyInternetGateways(i)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			for _, eigw := range o.EgressOnlyInternetGateways {
+				for _, attachment := range eigw.Attachments {
+					if aws.StringValue(attachment.VpcId) == vpcID {
+						inv.egressOnlyInternetGateways = append(inv.egressOnlyInternetGateways, eigw)
+						break
+					}
+				}
+			}
+
+			return o.NextToken, nil
+		})
+		if err != nil {
+			return vpcInventory{}, microerror.Mask(err)
+		}
+	}
+
+	filters := []*ec2.Filter{
+		{
+			Name:   aws.String("vpc-id"),
+			Values: []*string{aws.String(vpcID)},
+		},
+	}
+
+	{
+		i := &ec2.DescribeInstancesInput{Filters: filters}
+		err := paginate(ctx, &i.NextToken, func() (*string, error) {
+			o, err := a.ec2Client.DescribeInstances(i)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			for _, r := range o.Reservations {
+				for _, instance := range r.Instances {
+					if instance.State != nil && aws.StringValue(instance.State.Name) == ec2.InstanceStateNameTerminated {
+						continue
+					}
+					inv.instances = append(inv.instances, instance)
+				}
+			}
+
+			return o.NextToken, nil
+		})
+		if err != nil {
+			return vpcInventory{}, microerror.Mask(err)
+		}
+	}
+
+	{
+		i := &ec2.DescribeInternetGatewaysInput{
+			Filters: []*ec2.Filter{
+				{
+					Name:   aws.String("attachment.vpc-id"),
+					Values: []*string{aws.String(vpcID)},
+				},
+			},
+		}
+		err := paginate(ctx, &i.NextToken, func() (*string, error) {
+			o, err := a.ec2Client.DescribeInternetGateways(i)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			inv.internetGateways = append(inv.internetGateways, o.InternetGateways...)
+
+			return o.NextToken, nil
+		})
+		if err != nil {
+			return vpcInventory{}, microerror.Mask(err)
+		}
+	}
+
+	{
+		i := &ec2.DescribeNatGatewaysInput{Filter: filters}
+		err := paginate(ctx, &i.NextToken, func() (*string, error) {
+			o, err := a.ec2Client.DescribeNatGateways(i)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			for _, n := range o.NatGateways {
+				if aws.StringValue(n.State) == ec2.NatGatewayStateDeleted {
+					continue
+				}
+				inv.natGateways = append(inv.natGateways, n)
+			}
+
+			return o.NextToken, nil
+		})
+		if err != nil {
+			return vpcInventory{}, microerror.Mask(err)
+		}
+	}
+
+	{
+		i := &ec2.DescribeNetworkInterfacesInput{Filters: filters}
+		err := paginate(ctx, &i.NextToken, func() (*string, error) {
+			o, err := a.ec2Client.DescribeNetworkInterfaces(i)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			for _, n := range o.NetworkInterfaces {
+				// network interfaces of NAT gateways and endpoints are
+				// deleted along with them.
+				switch aws.StringValue(n.InterfaceType) {
+				case ec2.NetworkInterfaceTypeNatGateway, ec2.NetworkInterfaceTypeVpcEndpoint:
+					continue
+				}
+				if aws.BoolValue(n.RequesterManaged) {
+					continue
+				}
+				inv.networkInterfaces = append(inv.networkInterfaces, n)
+			}
+
+			return o.NextToken, nil
+		})
+		if err != nil {
+			return vpcInventory{}, microerror.Mask(err)
+		}
+	}
+
+	{
+		i := &ec2.DescribeRouteTablesInput{Filters: filters}
+		err := paginate(ctx, &i.NextToken, func() (*string, error) {
+			o, err := a.ec2Client.DescribeRouteTables(i)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			for _, t := range o.RouteTables {
+				// the main route table is deleted along with the VPC.
+				if isMainRouteTable(t) {
+					continue
+				}
+				inv.routeTables = append(inv.routeTables, t)
+			}
+
+			return o.NextToken, nil
+		})
+		if err != nil {
+			return vpcInventory{}, microerror.Mask(err)
+		}
+	}
+
+	{
+		i := &ec2.DescribeSecurityGroupsInput{Filters: filters}
+		err := paginate(ctx, &i.NextToken, func() (*string, error) {
+			o, err := a.ec2Client.DescribeSecurityGroups(i)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			for _, group := range o.SecurityGroups {
+				// the default security group is deleted along with the VPC.
+				if aws.StringValue(group.GroupName) == "default" {
+					continue
+				}
+				inv.securityGroups = append(inv.securityGroups, group)
+			}
+
+			return o.NextToken, nil
+		})
+		if err != nil {
+			return vpcInventory{}, microerror.Mask(err)
+		}
+	}
+
+	{
+		i := &ec2.DescribeSubnetsInput{Filters: filters}
+		err := paginate(ctx, &i.NextToken, func() (*string, error) {
+			o, err := a.ec2Client.DescribeSubnets(i)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			inv.subnets = append(inv.subnets, o.Subnets...)
+
+			return o.NextToken, nil
+		})
+		if err != nil {
+			return vpcInventory{}, microerror.Mask(err)
+		}
+	}
+
+	{
+		i := &ec2.DescribeVpcEndpointsInput{Filters: filters}
+		err := paginate(ctx, &i.NextToken, func() (*string, error) {
+			o, err := a.ec2Client.DescribeVpcEndpoints(i)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			for _, e := range o.VpcEndpoints {
+				switch aws.StringValue(e.State) {
+				case "deleting", "deleted":
+					continue
+				}
+				inv.vpcEndpoints = append(inv.vpcEndpoints, e)
+			}
+
+			return o.NextToken, nil
+		})
+		if err != nil {
+			return vpcInventory{}, microerror.Mask(err)
+		}
+	}
+
+	return inv, nil
+}
+
+// vpcGraph computes the dependency graph of the VPC with the given ID and
+// the resources inside it.
+func vpcGraph(vpcID string, inv vpcInventory) *graph.Graph {
+	g := graph.New(vpcID)
+
+	// Resources are added from the outside in, so that resources without
+	// dependencies between each other are deleted in a sensible order.
+	for _, instance := range inv.instances {
+		g.AddNode(*instance.InstanceId, kindInstance)
+	}
+	for _, e := range inv.vpcEndpoints {
+		g.AddNode(*e.VpcEndpointId, kindVPCEndpoint)
+	}
+	for _, n := range inv.natGateways {
+		g.AddNode(*n.NatGatewayId, kindNATGateway)
+	}
+	for _, n := range inv.networkInterfaces {
+		g.AddNode(*n.NetworkInterfaceId, kindNetworkInterface)
+	}
+	for _, t := range inv.routeTables {
+		g.AddNode(*t.RouteTableId, kindRouteTable)
+	}
+	for _, igw := range inv.internetGateways {
+		g.AddNode(*igw.InternetGatewayId, kindInternetGateway)
+	}
+	for _, eigw := range inv.egressOnlyInternetGateways {
+		g.AddNode(*eigw.EgressOnlyInternetGatewayId, kindEgressOnlyInternetGateway)
+	}
+	for _, s := range inv.subnets {
+		g.AddNode(*s.SubnetId, kindSubnet)
+	}
+	for _, association := range inv.ipv6CIDRBlocks {
+		g.AddNode(*association.AssociationId, kindVPCCIDRBlock)
+	}
+	for _, group := range inv.securityGroups {
+		g.AddNode(*group.GroupId, kindSecurityGroup)
+	}
+	g.AddNode(vpcID, kindVPC)
+
+	// addEdge only adds edges between resources of the graph, as resources
+	// outside the VPC are not deleted.
+	ids := map[string]bool{}
+	for _, n := range g.Nodes {
+		ids[n.ID] = true
+	}
+	addEdge := func(from string, to *string) {
+		if !ids[from] || to == nil || !ids[*to] {
+			return
+		}
+		g.AddEdge(from, *to)
+	}
+
+	for _, instance := range inv.instances {
+		addEdge(*instance.InstanceId, instance.SubnetId)
+		for _, group := range instance.SecurityGroups {
+			addEdge(*instance.InstanceId, group.GroupId)
+		}
+	}
+	for _, e := range inv.vpcEndpoints {
+		for _, id := range e.SubnetIds {
+			addEdge(*e.VpcEndpointId, id)
+		}
+		for _, group := range e.Groups {
+			addEdge(*e.VpcEndpointId, group.GroupId)
+		}
+	}
+	for _, n := range inv.natGateways {
+		addEdge(*n.NatGatewayId, n.SubnetId)
+		// internet gateways cannot be detached while NAT gateways still
+		// map public addresses.
+		for _, igw := range inv.internetGateways {
+			addEdge(*n.NatGatewayId, igw.InternetGatewayId)
+		}
+	}
+	for _, n := range inv.networkInterfaces {
+		if n.Attachment != nil {
+			addEdge(aws.StringValue(n.Attachment.InstanceId), n.NetworkInterfaceId)
+		}
+		addEdge(*n.NetworkInterfaceId, n.SubnetId)
+		for _, group := range n.Groups {
+			addEdge(*n.NetworkInterfaceId, group.GroupId)
+		}
+	}
+	for _, t := range inv.routeTables {
+		addEdge(*t.RouteTableId, aws.String(vpcID))
+	}
+	for _, igw := range inv.internetGateways {
+		addEdge(*igw.InternetGatewayId, aws.String(vpcID))
+	}
+	for _, eigw := range inv.egressOnlyInternetGateways {
+		addEdge(*eigw.EgressOnlyInternetGatewayId, aws.String(vpcID))
+	}
+	for _, s := range inv.subnets {
+		addEdge(*s.SubnetId, aws.String(vpcID))
+		// IPv6 CIDR blocks cannot be disassociated while subnets use them.
+		for _, association := range inv.ipv6CIDRBlocks {
+			if subnetInIPv6CIDRBlock(s, aws.StringValue(association.Ipv6CidrBlock)) {
+				addEdge(*s.SubnetId, association.AssociationId)
+			}
+		}
+	}
+	for _, association := range inv.ipv6CIDRBlocks {
+		addEdge(*association.AssociationId, aws.String(vpcID))
+	}
+	for _, group := range inv.securityGroups {
+		addEdge(*group.GroupId, aws.String(vpcID))
+	}
+
+	return g
+}
+
+// vpcDeleteFuncs returns the functions deleting the given VPC and the
+// resources inside it by ID.
+func (a *Cleaner) vpcDeleteFuncs(vpc *ec2.Vpc, inv vpcInventory) map[string]func() error {
+	fns := map[string]func() error{}
+
+	for _, instance := range inv.instances {
+		id := instance.InstanceId
+		fns[*id] = func() error {
+			_, err := a.ec2Client.TerminateInstances(&ec2.TerminateInstancesInput{InstanceIds: []*string{id}})
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			return nil
+		}
+	}
+	for _, e := range inv.vpcEndpoints {
+		id := e.VpcEndpointId
+		fns[*id] = func() error {
+			o, err := a.ec2Client.DeleteVpcEndpoints(&ec2.DeleteVpcEndpointsInput{VpcEndpointIds: []*string{id}})
+			if err != nil {
+				return microerror.Mask(err)
+			}
+			for _, item := range o.Unsuccessful {
+				if item.Error != nil {
+					return microerror.Maskf(executionFailedError, "%s: %s", aws.StringValue(item.Error.Code), aws.StringValue(item.Error.Message))
+				}
+			}
+
+			return nil
+		}
+	}
+	for _, n := range inv.natGateways {
+		id := n.NatGatewayId
+		fns[*id] = func() error {
+			_, err := a.ec2Client.DeleteNatGateway(&ec2.DeleteNatGatewayInput{NatGatewayId: id})
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			return nil
+		}
+	}
+	for _, n := range inv.networkInterfaces {
+		id := n.NetworkInterfaceId
+		fns[*id] = func() error {
+			_, err := a.ec2Client.DeleteNetworkInterface(&ec2.DeleteNetworkInterfaceInput{NetworkInterfaceId: id})
+			// network interfaces of instances are usually deleted along
+			// with them.
+			if err != nil && !isAWSError(err, "InvalidNetworkInterfaceID.NotFound") {
+				return microerror.Mask(err)
+			}
+
+			return nil
+		}
+	}
+	for _, t := range inv.routeTables {
+		t := t
+		fns[*t.RouteTableId] = func() error {
+			for _, association := range t.Associations {
+				_, err := a.ec2Client.DisassociateRouteTable(&ec2.DisassociateRouteTableInput{AssociationId: association.RouteTableAssociationId})
+				if err != nil {
+					return microerror.Mask(err)
+				}
+			}
+
+			_, err := a.ec2Client.DeleteRouteTable(&ec2.DeleteRouteTableInput{RouteTableId: t.RouteTableId})
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			return nil
+		}
+	}
+	for _, igw := range inv.internetGateways {
+		id := igw.InternetGatewayId
+		fns[*id] = func() error {
+			_, err := a.ec2Client.DetachInternetGateway(&ec2.DetachInternetGatewayInput{InternetGatewayId: id, VpcId: vpc.VpcId})
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			_, err = a.ec2Client.DeleteInternetGateway(&ec2.DeleteInternetGatewayInput{InternetGatewayId: id})
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			return nil
+		}
+	}
+	for _, eigw := range inv.egressOnlyInternetGateways {
+		id := eigw.EgressOnlyInternetGatewayId
+		fns[*id] = func() error {
+			_, err := a.ec2Client.DeleteEgressOnlyInternetGateway(&ec2.DeleteEgressOnlyInternetGatewayInput{EgressOnlyInternetGatewayId: id})
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			return nil
+		}
+	}
+	for _, s := range inv.subnets {
+		id := s.SubnetId
+		fns[*id] = func() error {
+			_, err := a.ec2Client.DeleteSubnet(&ec2.DeleteSubnetInput{SubnetId: id})
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			return nil
+		}
+	}
+	for _, association := range inv.ipv6CIDRBlocks {
+		id := association.AssociationId
+		fns[*id] = func() error {
+			_, err := a.ec2Client.DisassociateVpcCidrBlock(&ec2.DisassociateVpcCidrBlockInput{AssociationId: id})
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			return nil
+		}
+	}
+	for _, group := range inv.securityGroups {
+		group := group
+		fns[*group.GroupId] = func() error {
+			return a.deleteSecurityGroup(group, inv.securityGroups)
+		}
+	}
+	fns[*vpc.VpcId] = func() error {
+		_, err := a.ec2Client.DeleteVpc(&ec2.DeleteVpcInput{VpcId: vpc.VpcId})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		return nil
+	}
+
+	return fns
+}
+
+// isBYOIPCIDRBlockAssociation checks if the given IPv6 CIDR block was
+// assigned to a VPC from one of our BYOIP pools and is still associated.
+func isBYOIPCIDRBlockAssociation(association *ec2.VpcIpv6CidrBlockAssociation) bool {
+	if association.AssociationId == nil || association.Ipv6CidrBlock == nil {
+		return false
+	}
+	if association.Ipv6CidrBlockState == nil || aws.StringValue(association.Ipv6CidrBlockState.State) != ec2.VpcCidrBlockStateCodeAssociated {
+		return false
+	}
+
+	pool := aws.StringValue(association.Ipv6Pool)
+
+	return pool != "" && pool != amazonIPv6Pool
+}
+
+// subnetInIPv6CIDRBlock checks if any IPv6 CIDR block of the given subnet
+// lies within the given IPv6 CIDR block of its VPC.
+func subnetInIPv6CIDRBlock(s *ec2.Subnet, block string) bool {
+	_, vpcNet, err := net.ParseCIDR(block)
+	if err != nil {
+		return false
+	}
+
+	for _, association := range s.Ipv6CidrBlockAssociationSet {
+		ip, _, err := net.ParseCIDR(aws.StringValue(association.Ipv6CidrBlock))
+		if err != nil {
+			continue
+		}
+		if vpcNet.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isMainRouteTable(t *ec2.RouteTable) bool {
+	for _, association := range t.Associations {
+		if aws.BoolValue(association.Main) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,161 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func TestVPCGraph(t *testing.T) {
+	inv := vpcInventory{
+		egressOnlyInternetGateways: []*ec2.EgressOnlyInternetGateway{
+			{EgressOnlyInternetGatewayId: aws.String("eigw-1")},
+		},
+		ipv6CIDRBlocks: []*ec2.VpcIpv6CidrBlockAssociation{
+			{AssociationId: aws.String("vpc-cidr-assoc-1"), Ipv6CidrBlock: aws.String("2001:db8:1234::/56")},
+		},
+		instances: []*ec2.Instance{
+			{
+				InstanceId:     aws.String("i-1"),
+				SubnetId:       aws.String("subnet-1"),
+				SecurityGroups: []*ec2.GroupIdentifier{{GroupId: aws.String("sg-1")}},
+			},
+		},
+		internetGateways: []*ec2.InternetGateway{
+			{InternetGatewayId: aws.String("igw-1")},
+		},
+		natGateways: []*ec2.NatGateway{
+			{NatGatewayId: aws.String("nat-1"), SubnetId: aws.String("subnet-1")},
+		},
+		networkInterfaces: []*ec2.NetworkInterface{
+			{
+				NetworkInterfaceId: aws.String("eni-1"),
+				SubnetId:           aws.String("subnet-1"),
+				Groups:             []*ec2.GroupIdentifier{{GroupId: aws.String("sg-1")}},
+				Attachment:         &ec2.NetworkInterfaceAttachment{InstanceId: aws.String("i-1")},
+			},
+			{
+				// attached to an instance outside the inventory.
+				NetworkInterfaceId: aws.String("eni-2"),
+				SubnetId:           aws.String("subnet-1"),
+				Attachment:         &ec2.NetworkInterfaceAttachment{InstanceId: aws.String("i-2")},
+			},
+		},
+		routeTables: []*ec2.RouteTable{
+			{RouteTableId: aws.String("rtb-1")},
+		},
+		securityGroups: []*ec2.SecurityGroup{
+			{GroupId: aws.String("sg-1")},
+		},
+		subnets: []*ec2.Subnet{
+			{
+				SubnetId: aws.String("subnet-1"),
+				Ipv6CidrBlockAssociationSet: []*ec2.SubnetIpv6CidrBlockAssociation{
+					{Ipv6CidrBlock: aws.String("2001:db8:1234:1::/64")},
+				},
+			},
+		},
+		vpcEndpoints: []*ec2.VpcEndpoint{
+			{VpcEndpointId: aws.String("vpce-1"), SubnetIds: []*string{aws.String("subnet-1")}},
+		},
+	}
+
+	order, err := vpcGraph("vpc-1", inv).Order()
+	if err != nil {
+		t.Fatalf("expected nil, got %#v", err)
+	}
+
+	if len(order) != 12 {
+		t.Fatalf("want 12 resources, got %d", len(order))
+	}
+
+	position := map[string]int{}
+	for i, n := range order {
+		position[n.ID] = i
+	}
+
+	before := [][2]string{
+		{"i-1", "eni-1"},
+		{"i-1", "subnet-1"},
+		{"i-1", "sg-1"},
+		{"eni-1", "subnet-1"},
+		{"eni-2", "subnet-1"},
+		{"nat-1", "igw-1"},
+		{"nat-1", "subnet-1"},
+		{"vpce-1", "subnet-1"},
+		{"rtb-1", "vpc-1"},
+		{"igw-1", "vpc-1"},
+		{"subnet-1", "vpc-1"},
+		{"subnet-1", "vpc-cidr-assoc-1"},
+		{"vpc-cidr-assoc-1", "vpc-1"},
+		{"eigw-1", "vpc-1"},
+		{"sg-1", "vpc-1"},
+	}
+	for _, b := range before {
+		if position[b[0]] > position[b[1]] {
+			t.Errorf("want %#q deleted before %#q, got order %v", b[0], b[1], order)
+		}
+	}
+}
+
+func TestIsMainRouteTable(t *testing.T) {
+	main := &ec2.RouteTable{
+		Associations: []*ec2.RouteTableAssociation{{Main: aws.Bool(true)}},
+	}
+	if !isMainRouteTable(main) {
+		t.Errorf("want main route table to be detected")
+	}
+
+	custom := &ec2.RouteTable{
+		Associations: []*ec2.RouteTableAssociation{{Main: aws.Bool(false), SubnetId: aws.String("subnet-1")}},
+	}
+	if isMainRouteTable(custom) {
+		t.Errorf("want custom route table not to be detected as main")
+	}
+}
+
+func TestIsBYOIPCIDRBlockAssociation(t *testing.T) {
+	tcs := []struct {
+		pool        string
+		state       string
+		expected    bool
+		description string
+	}{
+		{
+			description: "associated block of byoip pool should be disassociated",
+			pool:        "ipv6pool-ec2-0123456789abcdef0",
+			state:       ec2.VpcCidrBlockStateCodeAssociated,
+			expected:    true,
+		},
+		{
+			description: "block provided by amazon should not be disassociated",
+			pool:        amazonIPv6Pool,
+			state:       ec2.VpcCidrBlockStateCodeAssociated,
+			expected:    false,
+		},
+		{
+			description: "block of byoip pool being disassociated should not be disassociated",
+			pool:        "ipv6pool-ec2-0123456789abcdef0",
+			state:       ec2.VpcCidrBlockStateCodeDisassociating,
+			expected:    false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			association := &ec2.VpcIpv6CidrBlockAssociation{
+				AssociationId:      aws.String("vpc-cidr-assoc-1"),
+				Ipv6CidrBlock:      aws.String("2001:db8:1234::/56"),
+				Ipv6CidrBlockState: &ec2.VpcCidrBlockState{State: aws.String(tc.state)},
+				Ipv6Pool:           aws.String(tc.pool),
+			}
+
+			actual := isBYOIPCIDRBlockAssociation(association)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be disassociated, want %t, got %t", *association.AssociationId, tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanVPCPeeringConnections deletes the VPC peering connections host-peer CI
+// setups leave behind, where either the requester or the accepter VPC is a CI
+// VPC, along with the routes using them.
+func (a *Cleaner) cleanVPCPeeringConnections(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	vpcs, err := a.ciVPCs(ctx)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	if len(vpcs) == 0 {
+		return nil
+	}
+
+	i := &ec2.DescribeVpcPeeringConnectionsInput{}
+	err = paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.ec2Client.DescribeVpcPeeringConnections(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, p := range o.VpcPeeringConnections {
+			if !vpcPeeringConnectionShouldBeDeleted(p, vpcs) {
+				continue
+			}
+
+			seen, err := a.run.FirstSeen(cleanerVPCPeeringConnections, *p.VpcPeeringConnectionId)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				continue
+			}
+
+			// do not delete recent peering connections.
+			if time.Since(seen) < a.gracePeriod {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that vpc peering connection %#q should be deleted", *p.VpcPeeringConnectionId))
+
+			res := run.Resource{
+				ID:     *p.VpcPeeringConnectionId,
+				Type:   "AWS::EC2::VPCPeeringConnection",
+				Tags:   ec2Tags(p.Tags),
+				Reason: run.ReasonDangling,
+				Note:   "routes using the peering connection are deleted along with it",
+			}
+			if vpcPeeringConnectionStatus(p) == ec2.VpcPeeringConnectionStateReasonCodeFailed {
+				res.Note = "failed peering connections expire on their own, only the routes using them are deleted"
+			}
+
+			p := p
+			err = a.run.DeleteResource(ctx, cleanerVPCPeeringConnections, res, func() error {
+				return a.deleteVPCPeeringConnection(ctx, p)
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting vpc peering connection %#q", *p.VpcPeeringConnectionId), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// deleteVPCPeeringConnection deletes the routes using the given peering
+// connection and the peering connection itself. Failed peering connections
+// cannot be deleted and are removed by AWS after a while.
+func (a *Cleaner) deleteVPCPeeringConnection(ctx context.Context, p *ec2.VpcPeeringConnection) error {
+	err := a.deleteVPCPeeringRoutes(ctx, *p.VpcPeeringConnectionId)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if vpcPeeringConnectionStatus(p) == ec2.VpcPeeringConnectionStateReasonCodeFailed {
+		return nil
+	}
+
+	_, err = a.ec2Client.DeleteVpcPeeringConnection(&ec2.DeleteVpcPeeringConnectionInput{VpcPeeringConnectionId: p.VpcPeeringConnectionId})
+	if err != nil && !isAWSError(err, "InvalidVpcPeeringConnectionID.NotFound") {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// deleteVPCPeeringRoutes deletes the routes of all route tables which target
+// the peering connection of the given ID.
+func (a *Cleaner) deleteVPCPeeringRoutes(ctx context.Context, id string) error {
+	i := &ec2.DescribeRouteTablesInput{
+		Filters: []*ec2.Filter{
+			{
+				Name:   aws.String("route.vpc-peering-connection-id"),
+				Values: aws.StringSlice([]string{id}),
+			},
+		},
+	}
+	err := paginate(ctx, &i.NextToken, func() (*string, error) {
+		o, err := a.ec2Client.DescribeRouteTables(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, t := range o.RouteTables {
+			for _, r := range t.Routes {
+				if aws.StringValue(r.VpcPeeringConnectionId) != id {
+					continue
+				}
+
+				di := &ec2.DeleteRouteInput{
+					DestinationCidrBlock:     r.DestinationCidrBlock,
+					DestinationIpv6CidrBlock: r.DestinationIpv6CidrBlock,
+					DestinationPrefixListId:  r.DestinationPrefixListId,
+					RouteTableId:             t.RouteTableId,
+				}
+				_, err := a.ec2Client.DeleteRoute(di)
+				if err != nil && !isAWSError(err, "InvalidRoute.NotFound") {
+					return nil, microerror.Mask(err)
+				}
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// vpcPeeringConnectionShouldBeDeleted checks if the given peering connection
+// is active or failed and either of its VPCs is one of the given CI VPCs.
+// Peering connections which are still being set up are left alone.
+func vpcPeeringConnectionShouldBeDeleted(p *ec2.VpcPeeringConnection, vpcs map[string]bool) bool {
+	if p.VpcPeeringConnectionId == nil {
+		return false
+	}
+
+	switch vpcPeeringConnectionStatus(p) {
+	case ec2.VpcPeeringConnectionStateReasonCodeActive, ec2.VpcPeeringConnectionStateReasonCodeFailed:
+	default:
+		return false
+	}
+
+	for _, info := range []*ec2.VpcPeeringConnectionVpcInfo{p.RequesterVpcInfo, p.AccepterVpcInfo} {
+		if info != nil && vpcs[aws.StringValue(info.VpcId)] {
+			return true
+		}
+	}
+
+	return false
+}
+
+func vpcPeeringConnectionStatus(p *ec2.VpcPeeringConnection) string {
+	if p.Status == nil {
+		return ""
+	}
+
+	return aws.StringValue(p.Status.Code)
+}
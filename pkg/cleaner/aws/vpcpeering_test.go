@@ -0,0 +1,66 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func TestVPCPeeringConnectionShouldBeDeleted(t *testing.T) {
+	vpcs := map[string]bool{"vpc-ci": true}
+
+	tcs := []struct {
+		status      string
+		requester   string
+		accepter    string
+		expected    bool
+		description string
+	}{
+		{
+			description: "active peering connection requested by ci vpc should be deleted",
+			status:      ec2.VpcPeeringConnectionStateReasonCodeActive,
+			requester:   "vpc-ci",
+			accepter:    "vpc-host",
+			expected:    true,
+		},
+		{
+			description: "failed peering connection accepted by ci vpc should be deleted",
+			status:      ec2.VpcPeeringConnectionStateReasonCodeFailed,
+			requester:   "vpc-host",
+			accepter:    "vpc-ci",
+			expected:    true,
+		},
+		{
+			description: "pending peering connection of ci vpc should not be deleted",
+			status:      ec2.VpcPeeringConnectionStateReasonCodePendingAcceptance,
+			requester:   "vpc-ci",
+			accepter:    "vpc-host",
+			expected:    false,
+		},
+		{
+			description: "active peering connection of other vpcs should not be deleted",
+			status:      ec2.VpcPeeringConnectionStateReasonCodeActive,
+			requester:   "vpc-host",
+			accepter:    "vpc-other",
+			expected:    false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			p := &ec2.VpcPeeringConnection{
+				AccepterVpcInfo:        &ec2.VpcPeeringConnectionVpcInfo{VpcId: aws.String(tc.accepter)},
+				RequesterVpcInfo:       &ec2.VpcPeeringConnectionVpcInfo{VpcId: aws.String(tc.requester)},
+				Status:                 &ec2.VpcPeeringConnectionStateReason{Code: aws.String(tc.status)},
+				VpcPeeringConnectionId: aws.String("pcx-1"),
+			}
+
+			actual := vpcPeeringConnectionShouldBeDeleted(p, vpcs)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *p.VpcPeeringConnectionId, tc.expected, actual)
+			}
+		})
+	}
+}
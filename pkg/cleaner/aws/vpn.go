@@ -0,0 +1,237 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanClientVPNEndpoints deletes the Client VPN endpoints created by
+// connectivity tests.
+func (a *Cleaner) cleanClientVPNEndpoints(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	var nextToken *string
+	err := paginate(ctx, &nextToken, func() (*string, error) {
+		i := &ec2.DescribeClientVpnEndpointsInput{
+			NextToken: nextToken,
+		}
+
+		o, err := a.ec2Client.DescribeClientVpnEndpoints(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, endpoint := range o.ClientVpnEndpoints {
+			id := aws.StringValue(endpoint.ClientVpnEndpointId)
+			tags := ec2Tags(endpoint.Tags)
+
+			var status string
+			if endpoint.Status != nil {
+				status = aws.StringValue(endpoint.Status.Code)
+			}
+
+			ok, err := a.vpnResourceShouldBeDeleted(cleanerClientVPNEndpoints, id, tags, status)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				continue
+			}
+			if !ok {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that client vpn endpoint %#q should be deleted", id))
+
+			res := run.Resource{
+				ID:   id,
+				Type: "AWS::EC2::ClientVpnEndpoint",
+				Tags: tags,
+			}
+			err = a.run.DeleteResource(ctx, cleanerClientVPNEndpoints, res, func() error {
+				return a.deleteClientVPNEndpoint(ctx, endpoint.ClientVpnEndpointId)
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting client vpn endpoint %#q", id), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// deleteClientVPNEndpoint disassociates all target networks of the given
+// endpoint first, as endpoints with associated target networks cannot be
+// deleted.
+func (a *Cleaner) deleteClientVPNEndpoint(ctx context.Context, id *string) error {
+	var nextToken *string
+	err := paginate(ctx, &nextToken, func() (*string, error) {
+		i := &ec2.DescribeClientVpnTargetNetworksInput{
+			ClientVpnEndpointId: id,
+			NextToken:           nextToken,
+		}
+
+		o, err := a.ec2Client.DescribeClientVpnTargetNetworks(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, n := range o.ClientVpnTargetNetworks {
+			if n.Status != nil {
+				switch aws.StringValue(n.Status.Code) {
+				case ec2.AssociationStatusCodeDisassociating, ec2.AssociationStatusCodeDisassociated:
+					continue
+				}
+			}
+
+			i := &ec2.DisassociateClientVpnTargetNetworkInput{
+				AssociationId:       n.AssociationId,
+				ClientVpnEndpointId: id,
+			}
+
+			_, err := a.ec2Client.DisassociateClientVpnTargetNetwork(i)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+		}
+
+		return o.NextToken, nil
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	i := &ec2.DeleteClientVpnEndpointInput{
+		ClientVpnEndpointId: id,
+	}
+
+	_, err = a.ec2Client.DeleteClientVpnEndpoint(i)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// cleanVPNConnections deletes the Site-to-Site VPN connections created by
+// connectivity tests and afterwards their customer gateways, which cannot be
+// deleted while connections use them.
+func (a *Cleaner) cleanVPNConnections(ctx context.Context) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	{
+		o, err := a.ec2Client.DescribeVpnConnections(&ec2.DescribeVpnConnectionsInput{})
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			return errors
+		}
+
+		for _, c := range o.VpnConnections {
+			res := run.Resource{
+				ID:   aws.StringValue(c.VpnConnectionId),
+				Type: "AWS::EC2::VPNConnection",
+				Tags: ec2Tags(c.Tags),
+			}
+
+			err := a.deleteVPNResource(ctx, res, aws.StringValue(c.State), func() error {
+				_, err := a.ec2Client.DeleteVpnConnection(&ec2.DeleteVpnConnectionInput{VpnConnectionId: c.VpnConnectionId})
+				return err
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting vpn connection %#q", res.ID), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+	}
+
+	{
+		o, err := a.ec2Client.DescribeCustomerGateways(&ec2.DescribeCustomerGatewaysInput{})
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			return errors
+		}
+
+		for _, g := range o.CustomerGateways {
+			res := run.Resource{
+				ID:   aws.StringValue(g.CustomerGatewayId),
+				Type: "AWS::EC2::CustomerGateway",
+				Tags: ec2Tags(g.Tags),
+			}
+
+			err := a.deleteVPNResource(ctx, res, aws.StringValue(g.State), func() error {
+				_, err := a.ec2Client.DeleteCustomerGateway(&ec2.DeleteCustomerGatewayInput{CustomerGatewayId: g.CustomerGatewayId})
+				return err
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting customer gateway %#q", res.ID), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) deleteVPNResource(ctx context.Context, res run.Resource, state string, fn func() error) error {
+	ok, err := a.vpnResourceShouldBeDeleted(cleanerVPNConnections, res.ID, res.Tags, state)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	if !ok {
+		return nil
+	}
+
+	a.logger.Log("level", "info", "message", fmt.Sprintf("found that %s %#q should be deleted", res.Type, res.ID))
+
+	err = a.run.DeleteResource(ctx, cleanerVPNConnections, res, fn)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// vpnResourceShouldBeDeleted checks if the given VPN resource is tagged for
+// CI and was first seen longer than the grace period ago.
+func (a *Cleaner) vpnResourceShouldBeDeleted(cleaner string, id string, tags map[string]string, state string) (bool, error) {
+	// do not delete resources that are already being deleted.
+	switch state {
+	case "deleting", "deleted":
+		return false, nil
+	}
+
+	if !a.isCITagged(tags) {
+		return false, nil
+	}
+
+	seen, err := a.run.FirstSeen(cleaner, id)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	// do not delete recent resources.
+	if time.Since(seen) < a.gracePeriod {
+		return false, nil
+	}
+
+	return true, nil
+}
@@ -0,0 +1,47 @@
+package aws
+
+import (
+	"testing"
+)
+
+func TestIsCITagged(t *testing.T) {
+	tcs := []struct {
+		tags        map[string]string
+		expected    bool
+		description string
+	}{
+		{
+			description: "resource with ci name should be matched",
+			tags:        map[string]string{"Name": "ci-wip-a1b2c-vpn"},
+			expected:    true,
+		},
+		{
+			description: "resource of ci cluster should be matched",
+			tags:        map[string]string{"Name": "vpn", clusterTag: "e2e-a1b2c"},
+			expected:    true,
+		},
+		{
+			description: "resource of general cluster should not be matched",
+			tags:        map[string]string{"Name": "vpn", clusterTag: "gauss"},
+			expected:    false,
+		},
+		{
+			description: "untagged resource should not be matched",
+			expected:    false,
+		},
+	}
+
+	a := &Cleaner{
+		prefixes: defaultPrefixes,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := a.isCITagged(tc.tags)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %v is tagged for CI, want %t, got %t", tc.tags, tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanApplications deletes the app registrations and service principals
+// every e2e cluster creates in the directory, like `ci-wip-a1b2c-sp`, as
+// directories are limited in the number of objects they hold. Deleted app
+// registrations and service principals are purged right away, as they count
+// against the limit for 30 days otherwise. The expired client secrets of the
+// app registrations of the CI principals, which pile up as pipelines add
+// secrets, are removed as well. Nothing is done unless deleting applications
+// is enabled, as it requires permissions of Microsoft Graph.
+func (c Cleaner) cleanApplications(ctx context.Context) error {
+	if !c.deleteApplications {
+		return nil
+	}
+
+	var lastError error
+
+	filter := graphPrefixFilter(c.prefixes)
+
+	applications, err := c.graphClient.Applications(ctx, filter)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	principals, err := c.graphClient.ServicePrincipals(ctx, filter)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	// Service principals are named after their app registration, but only
+	// deleted along with it.
+	principalsByApp := map[string][]graphServicePrincipal{}
+	for _, p := range principals {
+		principalsByApp[p.AppID] = append(principalsByApp[p.AppID], p)
+	}
+
+	for _, a := range applications {
+		if !c.applicationShouldBeDeleted(a) {
+			continue
+		}
+
+		err := c.deleteApplication(ctx, a, principalsByApp[a.AppID])
+		if err != nil {
+			c.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("failed to delete application %q", a.DisplayName), "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
+			lastError = err
+			continue
+		}
+	}
+
+	deleted, err := c.graphClient.DeletedApplications(ctx, filter)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, a := range deleted {
+		if !c.isCIResource(a.DisplayName) {
+			continue
+		}
+
+		err := c.purgeApplication(ctx, a)
+		if err != nil {
+			c.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("failed to purge soft-deleted application %q", a.DisplayName), "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
+			lastError = err
+			continue
+		}
+	}
+
+	for _, id := range c.ciPrincipals {
+		err := c.removeExpiredCredentials(ctx, id)
+		if err != nil {
+			c.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("failed to remove expired credentials of CI principal %q", id), "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
+			lastError = err
+			continue
+		}
+	}
+
+	if lastError != nil {
+		return microerror.Mask(lastError)
+	}
+
+	return nil
+}
+
+// deleteApplication deletes and purges the given app registration along with
+// the given service principals of it.
+func (c Cleaner) deleteApplication(ctx context.Context, a graphApplication, principals []graphServicePrincipal) error {
+	c.logger.LogCtx(ctx, "level", "info", "message", fmt.Sprintf("application %q has to be deleted", a.DisplayName))
+
+	res := run.Resource{
+		ID:        "/applications/" + a.ID,
+		Type:      "Microsoft.Graph/applications",
+		CreatedAt: a.CreatedDateTime,
+		Note:      fmt.Sprintf("%s, %d service principals", a.DisplayName, len(principals)),
+	}
+	err := c.run.DeleteResource(ctx, cleanerApplications, res, func() error {
+		for _, p := range principals {
+			err := c.graphClient.Delete(ctx, "/servicePrincipals/"+url.PathEscape(p.ID))
+			if err != nil {
+				return microerror.Mask(err)
+			}
+			err = c.graphClient.Purge(ctx, p.ID)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+
+		err := c.graphClient.Delete(ctx, "/applications/"+url.PathEscape(a.ID))
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		err = c.graphClient.Purge(ctx, a.ID)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// purgeApplication purges the given soft-deleted app registration, which was
+// deleted by someone else or by a previous run failing to purge it.
+func (c Cleaner) purgeApplication(ctx context.Context, a graphApplication) error {
+	c.logger.LogCtx(ctx, "level", "info", "message", fmt.Sprintf("soft-deleted application %q has to be purged", a.DisplayName))
+
+	res := run.Resource{
+		ID:        "/directory/deletedItems/" + a.ID,
+		Type:      "Microsoft.Graph/deletedItems",
+		CreatedAt: a.CreatedDateTime,
+		Note:      a.DisplayName,
+	}
+	err := c.run.DeleteResource(ctx, cleanerApplications, res, func() error {
+		return c.graphClient.Purge(ctx, a.ID)
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// removeExpiredCredentials removes the expired client secrets of the app
+// registration of the CI principal with the given client ID.
+func (c Cleaner) removeExpiredCredentials(ctx context.Context, appID string) error {
+	var lastError error
+
+	applications, err := c.graphClient.Applications(ctx, fmt.Sprintf("appId eq '%s'", graphQuote(appID)))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, a := range applications {
+		for _, cred := range a.PasswordCredentials {
+			if cred.EndDateTime.IsZero() || time.Now().Before(cred.EndDateTime) {
+				continue
+			}
+
+			c.logger.LogCtx(ctx, "level", "info", "message", fmt.Sprintf("client secret %q of application %q expired and has to be removed", cred.KeyID, a.DisplayName))
+
+			res := run.Resource{
+				ID:   "/applications/" + a.ID + "/passwordCredentials/" + cred.KeyID,
+				Type: "Microsoft.Graph/applications/passwordCredentials",
+				Note: fmt.Sprintf("%s, expired %s", cred.DisplayName, cred.EndDateTime.Format(time.RFC3339)),
+			}
+			applicationID := a.ID
+			keyID := cred.KeyID
+			err := c.run.DeleteResource(ctx, cleanerApplications, res, func() error {
+				return c.graphClient.RemovePassword(ctx, applicationID, keyID)
+			})
+			if err != nil {
+				c.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("failed to remove client secret %q of application %q", cred.KeyID, a.DisplayName), "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
+				lastError = err
+				continue
+			}
+		}
+	}
+
+	if lastError != nil {
+		return microerror.Mask(lastError)
+	}
+
+	return nil
+}
+
+// applicationShouldBeDeleted checks if the given app registration was created
+// for a CI cluster and is older than the grace period. The app registrations
+// of the CI principals are never deleted, whatever their name.
+func (c Cleaner) applicationShouldBeDeleted(a graphApplication) bool {
+	if !c.isCIResource(a.DisplayName) {
+		return false
+	}
+
+	for _, id := range c.ciPrincipals {
+		if a.AppID == id {
+			return false
+		}
+	}
+
+	// do not delete recent applications.
+	if a.CreatedDateTime.IsZero() || time.Since(a.CreatedDateTime) < c.gracePeriod {
+		return false
+	}
+
+	return true
+}
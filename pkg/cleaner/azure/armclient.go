@@ -0,0 +1,270 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/giantswarm/microerror"
+)
+
+// ARMClient is a thin Azure Resource Manager client used for the REST
+// endpoints which are not covered by the SDK API versions we vendor, e.g. the
+// soft-deleted resource APIs of newer resource providers.
+type ARMClient struct {
+	autorest.Client
+
+	BaseURI        string
+	SubscriptionID string
+}
+
+// NewARMClient creates a ARMClient for the public Azure cloud. The Authorizer
+// of the embedded autorest.Client has to be set by the caller.
+func NewARMClient(subscriptionID string) ARMClient {
+	return ARMClient{
+		Client:         autorest.NewClientWithUserAgent("ci-cleaner"),
+		BaseURI:        azure.PublicCloud.ResourceManagerEndpoint,
+		SubscriptionID: subscriptionID,
+	}
+}
+
+// armResource is the generic shape of a resource returned by Azure Resource
+// Manager.
+type armResource struct {
+	ID         string            `json:"id"`
+	Name       string            `json:"name"`
+	Type       string            `json:"type"`
+	Location   string            `json:"location"`
+	Tags       map[string]string `json:"tags"`
+	Properties json.RawMessage   `json:"properties"`
+	SystemData armSystemData     `json:"systemData"`
+}
+
+// armSystemData is returned by newer API versions only.
+type armSystemData struct {
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type armResourceList struct {
+	Value    []armResource `json:"value"`
+	NextLink string        `json:"nextLink"`
+}
+
+// List returns all resources listed under the given path, following
+// pagination links.
+func (c ARMClient) List(ctx context.Context, path string, apiVersion string) ([]armResource, error) {
+	var resources []armResource
+
+	preparer := autorest.CreatePreparer(
+		autorest.AsGet(),
+		autorest.WithBaseURL(c.BaseURI),
+		autorest.WithPath(path),
+		autorest.WithQueryParameters(map[string]interface{}{
+			"api-version": apiVersion,
+		}),
+	)
+
+	for {
+		req, err := preparer.Prepare((&http.Request{}).WithContext(ctx))
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		resp, err := c.Send(req, azure.DoRetryWithRegistration(c.Client))
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		var page armResourceList
+		err = autorest.Respond(
+			resp,
+			c.ByInspecting(),
+			azure.WithErrorUnlessStatusCode(http.StatusOK),
+			autorest.ByUnmarshallingJSON(&page),
+			autorest.ByClosing(),
+		)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		resources = append(resources, page.Value...)
+
+		if page.NextLink == "" {
+			break
+		}
+
+		preparer = autorest.CreatePreparer(
+			autorest.AsGet(),
+			autorest.WithBaseURL(page.NextLink),
+		)
+	}
+
+	return resources, nil
+}
+
+// Get reads the resource with the given path into v.
+func (c ARMClient) Get(ctx context.Context, path string, apiVersion string, v interface{}) error {
+	preparer := autorest.CreatePreparer(
+		autorest.AsGet(),
+		autorest.WithBaseURL(c.BaseURI),
+		autorest.WithPath(path),
+		autorest.WithQueryParameters(map[string]interface{}{
+			"api-version": apiVersion,
+		}),
+	)
+
+	req, err := preparer.Prepare((&http.Request{}).WithContext(ctx))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	resp, err := c.Send(req, azure.DoRetryWithRegistration(c.Client))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = autorest.Respond(
+		resp,
+		c.ByInspecting(),
+		azure.WithErrorUnlessStatusCode(http.StatusOK),
+		autorest.ByUnmarshallingJSON(v),
+		autorest.ByClosing(),
+	)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// Delete deletes the resource with the given ID. Resources which do not exist
+// anymore are not considered an error.
+func (c ARMClient) Delete(ctx context.Context, id string, apiVersion string) error {
+	_, err := c.do(ctx, autorest.AsDelete(), id, apiVersion)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// Post calls the action with the given path, e.g. the purge action of a
+// soft-deleted resource. Resources which do not exist anymore are not
+// considered an error.
+func (c ARMClient) Post(ctx context.Context, path string, apiVersion string) error {
+	_, err := c.do(ctx, autorest.AsPost(), path, apiVersion)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// DeleteAsync starts deleting the resource with the given ID and returns the
+// URL of the operation tracking the deletion, or an empty URL when the
+// deletion finished right away. See Poll.
+func (c ARMClient) DeleteAsync(ctx context.Context, id string, apiVersion string) (string, error) {
+	resp, err := c.do(ctx, autorest.AsDelete(), id, apiVersion)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	if resp.StatusCode != http.StatusAccepted {
+		return "", nil
+	}
+	if u := resp.Header.Get("Azure-AsyncOperation"); u != "" {
+		return u, nil
+	}
+
+	return resp.Header.Get("Location"), nil
+}
+
+// Poll returns whether the long-running operation with the given URL
+// finished. Operations which are unknown, e.g. because they expired, are
+// considered finished.
+func (c ARMClient) Poll(ctx context.Context, operation string) (bool, error) {
+	preparer := autorest.CreatePreparer(
+		autorest.AsGet(),
+		autorest.WithBaseURL(operation),
+	)
+
+	req, err := preparer.Prepare((&http.Request{}).WithContext(ctx))
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	resp, err := c.Send(req)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	var status struct {
+		Status string `json:"status"`
+		Error  struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	err = autorest.Respond(
+		resp,
+		c.ByInspecting(),
+		azure.WithErrorUnlessStatusCode(http.StatusOK, http.StatusAccepted, http.StatusNoContent, http.StatusNotFound),
+		autorest.ByUnmarshallingJSON(&status),
+		autorest.ByClosing(),
+	)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusAccepted:
+		return false, nil
+	case resp.StatusCode == http.StatusNotFound:
+		return true, nil
+	}
+
+	switch status.Status {
+	case "", "Succeeded":
+		return true, nil
+	case "Failed", "Canceled":
+		return false, microerror.Maskf(operationFailedError, "%s: %s", status.Error.Code, status.Error.Message)
+	default:
+		return false, nil
+	}
+}
+
+func (c ARMClient) do(ctx context.Context, method autorest.PrepareDecorator, id string, apiVersion string) (*http.Response, error) {
+	preparer := autorest.CreatePreparer(
+		method,
+		autorest.WithBaseURL(c.BaseURI),
+		autorest.WithPath(id),
+		autorest.WithQueryParameters(map[string]interface{}{
+			"api-version": apiVersion,
+		}),
+	)
+
+	req, err := preparer.Prepare((&http.Request{}).WithContext(ctx))
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	resp, err := c.Send(req, azure.DoRetryWithRegistration(c.Client))
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	err = autorest.Respond(
+		resp,
+		c.ByInspecting(),
+		azure.WithErrorUnlessStatusCode(http.StatusOK, http.StatusAccepted, http.StatusNoContent, http.StatusNotFound),
+		autorest.ByClosing(),
+	)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return resp, nil
+}
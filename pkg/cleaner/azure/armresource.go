@@ -0,0 +1,94 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanARMResources deletes the CI resources of the given type in the whole
+// subscription, for resources which can be deleted by their ID without
+// further preparation. Deletions are long-running operations for most
+// resource types and tracked by later runs.
+func (c Cleaner) cleanARMResources(ctx context.Context, cleaner string, resourceType string, apiVersion string) error {
+	path := fmt.Sprintf("/subscriptions/%s/providers/%s", c.armClient.SubscriptionID, resourceType)
+	resources, err := c.armClient.List(ctx, path, apiVersion)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = c.deleteARMResources(ctx, cleaner, resourceType, apiVersion, resources)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// deleteARMResources deletes the CI resources among the given resources of
+// the given type, see cleanARMResources.
+func (c Cleaner) deleteARMResources(ctx context.Context, cleaner string, resourceType string, apiVersion string, resources []armResource) error {
+	var lastError error
+
+	for _, r := range resources {
+		if !c.armResourceShouldBeDeleted(r) {
+			continue
+		}
+
+		err := c.deleteARMResource(ctx, cleaner, resourceType, apiVersion, r)
+		if err != nil {
+			c.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("did not ensure deletion of resource %q", r.ID), "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
+			lastError = err
+			continue
+		}
+	}
+
+	if lastError != nil {
+		return microerror.Mask(lastError)
+	}
+
+	return nil
+}
+
+// deleteARMResource starts deleting the given resource of the given type or
+// checks whether a deletion started by a previous run finished.
+func (c Cleaner) deleteARMResource(ctx context.Context, cleaner string, resourceType string, apiVersion string, r armResource) error {
+	c.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("ensuring deletion of resource %q", r.ID))
+
+	res := run.Resource{
+		ID:        r.ID,
+		Type:      resourceType,
+		Region:    r.Location,
+		Tags:      r.Tags,
+		CreatedAt: r.SystemData.CreatedAt,
+	}
+	start := func() (string, error) {
+		return c.armClient.DeleteAsync(ctx, r.ID, apiVersion)
+	}
+	err := c.run.DeleteResourceAsync(ctx, cleaner, res, start, c.armClient.Poll)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// armResourceShouldBeDeleted checks if the given resource was created by a
+// CI pipeline and is older than the grace period. Resources listed with API
+// versions without system data are only matched by name and tags.
+func (c Cleaner) armResourceShouldBeDeleted(r armResource) bool {
+	if !c.isCIResource(r.Name) && !c.isCreatedByCI(r.ID) && !c.isCITagged(r.Tags) {
+		return false
+	}
+
+	// do not delete recent resources.
+	if !r.SystemData.CreatedAt.IsZero() && time.Since(r.SystemData.CreatedAt) < c.gracePeriod {
+		return false
+	}
+
+	return true
+}
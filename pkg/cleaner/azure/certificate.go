@@ -0,0 +1,147 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/domain"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+const (
+	keyVaultAPIVersion = "7.4"
+	keyVaultURLFormat  = "https://%s.vault.azure.net"
+)
+
+// keyVaultCertificate is the part of a Key Vault certificate bundle we care
+// about.
+type keyVaultCertificate struct {
+	ID         string `json:"id"`
+	Attributes struct {
+		Created int64 `json:"created"`
+	} `json:"attributes"`
+	Policy struct {
+		X509Props struct {
+			Subject string `json:"subject"`
+			SANs    struct {
+				DNSNames []string `json:"dns_names"`
+			} `json:"sans"`
+		} `json:"x509_props"`
+	} `json:"policy"`
+	Tags map[string]string `json:"tags"`
+}
+
+// cleanCertificates deletes the wildcard certificates issued for CI clusters,
+// like `*.ci-wip-a1b2c.westeurope.azure.gigantic.io`, which outlived their
+// cluster, from the Key Vaults configured as certificate vaults. A cluster is
+// considered gone once its API hostname does not resolve anymore. Deleted
+// certificates stay soft-deleted for the retention period of their vault.
+func (c Cleaner) cleanCertificates(ctx context.Context) error {
+	var lastError error
+
+	for _, vault := range c.certificateVaults {
+		err := c.cleanVaultCertificates(ctx, vault)
+		if err != nil {
+			c.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("failed to clean certificates of key vault %q", vault), "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
+			lastError = err
+			continue
+		}
+	}
+
+	if lastError != nil {
+		return microerror.Mask(lastError)
+	}
+
+	return nil
+}
+
+func (c Cleaner) cleanVaultCertificates(ctx context.Context, vault string) error {
+	var lastError error
+
+	client := *c.keyVaultClient
+	client.BaseURI = fmt.Sprintf(keyVaultURLFormat, vault)
+
+	items, err := client.List(ctx, "/certificates", keyVaultAPIVersion)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, item := range items {
+		name := path.Base(item.ID)
+
+		var cert keyVaultCertificate
+		err := client.Get(ctx, "/certificates/"+name, keyVaultAPIVersion, &cert)
+		if err != nil {
+			c.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("failed to get certificate %q", item.ID), "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
+			lastError = err
+			continue
+		}
+
+		cluster, ok := c.certificateShouldBeDeleted(cert)
+		if !ok {
+			continue
+		}
+
+		resolves, err := domain.APIResolves(cluster)
+		if err != nil {
+			c.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("failed to resolve API hostname of cluster %q", cluster), "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
+			continue
+		}
+		if resolves {
+			continue
+		}
+
+		c.logger.LogCtx(ctx, "level", "info", "message", fmt.Sprintf("certificate %q of deleted cluster %q has to be deleted", item.ID, cluster))
+
+		res := run.Resource{
+			ID:        item.ID,
+			Type:      "Microsoft.KeyVault/vaults/certificates",
+			Tags:      cert.Tags,
+			CreatedAt: time.Unix(cert.Attributes.Created, 0),
+		}
+		err = c.run.DeleteResource(ctx, cleanerCertificates, res, func() error {
+			return client.Delete(ctx, "/certificates/"+name, keyVaultAPIVersion)
+		})
+		if err != nil {
+			c.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("failed to delete certificate %q", item.ID), "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
+			lastError = err
+			continue
+		}
+	}
+
+	if lastError != nil {
+		return microerror.Mask(lastError)
+	}
+
+	return nil
+}
+
+// certificateShouldBeDeleted checks if the given certificate is a wildcard
+// certificate of a CI cluster and returns the domain of the cluster.
+func (c Cleaner) certificateShouldBeDeleted(cert keyVaultCertificate) (string, bool) {
+	names := append([]string{strings.TrimPrefix(cert.Policy.X509Props.Subject, "CN=")}, cert.Policy.X509Props.SANs.DNSNames...)
+
+	var cluster string
+	for _, n := range names {
+		var ok bool
+		cluster, ok = domain.WildcardCluster(n, c.prefixes)
+		if ok {
+			break
+		}
+	}
+	if cluster == "" {
+		return "", false
+	}
+
+	// do not delete recent certificates.
+	if time.Since(time.Unix(cert.Attributes.Created, 0)) < c.gracePeriod {
+		return "", false
+	}
+
+	return cluster, true
+}
@@ -0,0 +1,100 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/chart"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanCharts deletes the ephemeral Helm chart versions CI pushes to the
+// configured ACR repositories, like `1.2.3-pr42`, once they are older than
+// the chart retention. Manifests which are also tagged with a released
+// version are kept.
+func (c Cleaner) cleanCharts(ctx context.Context) error {
+	var lastError error
+
+	for _, repository := range c.chartRepositories {
+		err := c.cleanRepositoryCharts(ctx, repository)
+		if err != nil {
+			c.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("failed to clean charts of repository %q", repository), "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
+			lastError = err
+			continue
+		}
+	}
+
+	if lastError != nil {
+		return microerror.Mask(lastError)
+	}
+
+	return nil
+}
+
+// cleanRepositoryCharts cleans the given repository, which is named like
+// `<registry>.azurecr.io/<repository>`.
+func (c Cleaner) cleanRepositoryCharts(ctx context.Context, repository string) error {
+	var lastError error
+
+	parts := strings.SplitN(repository, "/", 2)
+	if len(parts) != 2 {
+		return microerror.Maskf(invalidConfigError, "chart repository %q must be named like <registry>/<repository>", repository)
+	}
+
+	repo, err := c.registryClient.Repository(ctx, parts[0], parts[1])
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	manifests, err := repo.Manifests(ctx)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, m := range manifests {
+		if !c.chartShouldBeDeleted(m) {
+			continue
+		}
+
+		c.logger.LogCtx(ctx, "level", "info", "message", fmt.Sprintf("chart versions %s in repository %q have to be deleted", strings.Join(m.Tags, ", "), repository))
+
+		res := run.Resource{
+			ID:        repository + "@" + m.Digest,
+			Type:      "Microsoft.ContainerRegistry/registries/manifests",
+			CreatedAt: m.CreatedTime,
+			Note:      strings.Join(m.Tags, ", "),
+		}
+		digest := m.Digest
+		err = c.run.DeleteResource(ctx, cleanerCharts, res, func() error {
+			return repo.DeleteManifest(ctx, digest)
+		})
+		if err != nil {
+			c.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("failed to delete chart %q", res.ID), "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
+			lastError = err
+			continue
+		}
+	}
+
+	if lastError != nil {
+		return microerror.Mask(lastError)
+	}
+
+	return nil
+}
+
+func (c Cleaner) chartShouldBeDeleted(m registryManifest) bool {
+	if m.Digest == "" || !chart.AllEphemeral(m.Tags, c.chartSuffixes) {
+		return false
+	}
+
+	// do not delete charts within their retention.
+	if time.Since(m.CreatedTime) < c.chartRetention {
+		return false
+	}
+
+	return true
+}
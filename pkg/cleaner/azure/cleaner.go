@@ -3,56 +3,216 @@ package azure
 import (
 	"context"
 	"strings"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/services/dns/mgmt/2017-10-01/dns"
+	"github.com/Azure/azure-sdk-for-go/services/keyvault/mgmt/2018-02-14/keyvault"
 	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-12-01/network"
 	"github.com/Azure/azure-sdk-for-go/services/preview/monitor/mgmt/2018-03-01/insights"
 	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2018-02-01/resources"
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
+
+	"github.com/giantswarm/ci-cleaner/pkg/chart"
+	"github.com/giantswarm/ci-cleaner/pkg/logctx"
+	"github.com/giantswarm/ci-cleaner/pkg/owner"
+	"github.com/giantswarm/ci-cleaner/pkg/report"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+	"github.com/giantswarm/ci-cleaner/pkg/tabledata"
+)
+
+// Cleaner names identify the cleaners in reports and configuration.
+const (
+	cleanerAlertRules             = "alert-rules"
+	cleanerAPIManagementServices  = "api-management-services"
+	cleanerApplications           = "applications"
+	cleanerAppServices            = "app-services"
+	cleanerCertificates           = "certificates"
+	cleanerCharts                 = "charts"
+	cleanerCosmosDBAccounts       = "cosmos-db-accounts"
+	cleanerDNSRecordSets          = "dns-record-sets"
+	cleanerDelegatedDNSRecords    = "delegated-dns-records"
+	cleanerDiagnosticSettings     = "diagnostic-settings"
+	cleanerEventGrid              = "event-grid"
+	cleanerHSMs                   = "hsms"
+	cleanerManagedDisks           = "managed-disks"
+	cleanerPrivateEndpoints       = "private-endpoints"
+	cleanerResourceGroups         = "resource-groups"
+	cleanerRoleAssignments        = "role-assignments"
+	cleanerSoftDeleted            = "soft-deleted"
+	cleanerTableData              = "table-data"
+	cleanerVPNConnections         = "vpn-connections"
+	cleanerVirtualNetworkGateways = "virtual-network-gateways"
+	cleanerVirtualNetworkPeerings = "virtual-network-peerings"
+)
+
+const (
+	// clusterTag is the tag holding the ID of the cluster a resource
+	// belongs to.
+	clusterTag = owner.ClusterTag
+
+	// defaultGracePeriod represents the maximum time the CI resources are
+	// allowed to remain up, unless configured otherwise. CI resources older
+	// than the grace period will be deleted.
+	defaultGracePeriod = 90 * time.Minute
+
+	// defaultChartRetention is how long ephemeral chart versions are kept,
+	// unless configured otherwise.
+	defaultChartRetention = 7 * 24 * time.Hour
+
+	// defaultTableDataRetention is how long the items of test runs are kept
+	// in shared tables, unless configured otherwise.
+	defaultTableDataRetention = 7 * 24 * time.Hour
+)
+
+var (
+	// defaultPrefixes are the name prefixes of resources created by CI,
+	// unless configured otherwise.
+	defaultPrefixes = []string{
+		"ci-last-",
+		"ci-prev-",
+		"ci-cur-",
+		"ci-wip-",
+	}
 )
 
 type CleanerConfig struct {
 	Logger micrologger.Logger
+	Run    *run.Run
 
 	ActivityLogsClient                     *insights.ActivityLogsClient
+	ARMClient                              *ARMClient
+	CosmosClient                           *CosmosClient
 	DNSRecordSetsClient                    *dns.RecordSetsClient
+	GraphClient                            *GraphClient
 	GroupsClient                           *resources.GroupsClient
+	KeyVaultClient                         *ARMClient
+	RegistryClient                         *RegistryClient
+	VaultsClient                           *keyvault.VaultsClient
 	VirtualNetworkGatewayConnectionsClient *network.VirtualNetworkGatewayConnectionsClient
 	VirtualNetworkPeeringsClient           *network.VirtualNetworkPeeringsClient
 	VirtualNetworksClient                  *network.VirtualNetworksClient
 
 	Installations []string
 	AzureLocation string
+
+	// CIPrincipals are the client IDs of the service principals CI runs
+	// as. The resources they created according to the activity log are
+	// treated as CI resources regardless of their name.
+	CIPrincipals []string
+	// CertificateVaults are the names of the Key Vaults leaked wildcard
+	// certificates of CI clusters are deleted from. KeyVaultClient, which is
+	// authorized for the Key Vault data plane, is required when set.
+	CertificateVaults []string
+	// ChartRepositories are the ACR repositories ephemeral chart versions
+	// are deleted from, named like `<registry>.azurecr.io/<repository>`.
+	// RegistryClient is required when set.
+	ChartRepositories []string
+	// ChartRetention is how long ephemeral chart versions are kept after
+	// they were pushed. Defaults to 7 days.
+	ChartRetention time.Duration
+	// ChartSuffixes are the suffixes marking ephemeral chart versions.
+	// Defaults to chart.DefaultSuffixes.
+	ChartSuffixes []string
+	// Tables are the shared Cosmos DB containers the items of test runs are
+	// deleted from, named like `<account>/<database>/<container>`.
+	// CosmosClient is required when set.
+	Tables []tabledata.Table
+	// TableDataRetention is how long the items of a test run are kept after
+	// the last of them was written. Defaults to 7 days.
+	TableDataRetention time.Duration
+
+	// GracePeriod is the maximum time CI resources are allowed to remain up.
+	// Defaults to 90 minutes.
+	GracePeriod time.Duration
+	// Prefixes are the name prefixes identifying CI resources. Defaults to
+	// the prefixes used by our CI pipelines.
+	Prefixes []string
+	// DeleteApplications enables deleting the app registrations and service
+	// principals of CI clusters from the directory, and the expired client
+	// secrets of the CI principals. GraphClient is required when set.
+	DeleteApplications bool
+	// DeleteOrphanedRoleAssignments enables deleting the role assignments
+	// of principals which do not exist anymore. GraphClient, which needs the
+	// Directory.Read.All permission of Microsoft Graph, is required when
+	// set.
+	DeleteOrphanedRoleAssignments bool
+	// DeleteTableData enables deleting the items of test runs from shared
+	// containers, which are only reported otherwise.
+	DeleteTableData bool
+	// PurgeHSMs enables deleting and purging CI HSMs, which are only
+	// reported otherwise.
+	PurgeHSMs bool
+	// RecordRoleAssignmentDrift enables recording the role assignments of
+	// the subscription before and after the cleanup and adding the
+	// difference to the report.
+	RecordRoleAssignmentDrift bool
 }
 
 type Cleaner struct {
 	logger micrologger.Logger
+	run    *run.Run
 
 	activityLogsClient                     *insights.ActivityLogsClient
+	armClient                              *ARMClient
+	cosmosClient                           *CosmosClient
 	dnsRecordSetsClient                    *dns.RecordSetsClient
+	graphClient                            *GraphClient
 	groupsClient                           *resources.GroupsClient
+	keyVaultClient                         *ARMClient
+	registryClient                         *RegistryClient
+	vaultsClient                           *keyvault.VaultsClient
 	virtualNetworkGatewayConnectionsClient *network.VirtualNetworkGatewayConnectionsClient
 	virtualNetworkPeeringsClient           *network.VirtualNetworkPeeringsClient
 	virtualNetworksClient                  *network.VirtualNetworksClient
 
-	installations []string
-	azureLocation string
+	installations     []string
+	azureLocation     string
+	certificateVaults []string
+	chartRepositories []string
+	chartRetention    time.Duration
+	chartSuffixes     []string
+	ciPrincipals      []string
+	gracePeriod       time.Duration
+	prefixes          []string
+	purgeHSMs         bool
+
+	tables             []tabledata.Table
+	tableDataRetention time.Duration
+
+	deleteApplications        bool
+	deleteTableData           bool
+	recordRoleAssignmentDrift bool
+
+	deleteOrphanedRoleAssignments bool
+
+	// createdByCI holds the lowercase IDs of the resources and resource
+	// groups created by the configured CI principals.
+	createdByCI map[string]bool
 }
 
 func NewCleaner(config CleanerConfig) (*Cleaner, error) {
 	if config.Logger == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
 	}
+	if config.Run == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Run must not be empty", config)
+	}
 	if config.ActivityLogsClient == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.ActivityLogsClient must not be empty", config)
 	}
+	if config.ARMClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ARMClient must not be empty", config)
+	}
 	if config.DNSRecordSetsClient == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.DNSRecordSetsClient must not be empty", config)
 	}
 	if config.GroupsClient == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.GroupsClient must not be empty", config)
 	}
+	if config.VaultsClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.VaultsClient must not be empty", config)
+	}
 	if config.VirtualNetworkPeeringsClient == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.VirtualNetworkPeeringsClient must not be empty", config)
 	}
@@ -72,19 +232,83 @@ func NewCleaner(config CleanerConfig) (*Cleaner, error) {
 	if len(config.AzureLocation) == 0 {
 		return nil, microerror.Maskf(invalidConfigError, "%T.AzureLocation must not be empty", config)
 	}
+	if len(config.CertificateVaults) != 0 && config.KeyVaultClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.KeyVaultClient must not be empty when %T.CertificateVaults is set", config, config)
+	}
+	if len(config.ChartRepositories) != 0 && config.RegistryClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.RegistryClient must not be empty when %T.ChartRepositories is set", config, config)
+	}
+	if config.DeleteApplications && config.GraphClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.GraphClient must not be empty when %T.DeleteApplications is set", config, config)
+	}
+	if config.DeleteOrphanedRoleAssignments && config.GraphClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.GraphClient must not be empty when %T.DeleteOrphanedRoleAssignments is set", config, config)
+	}
+	if len(config.Tables) != 0 && config.CosmosClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.CosmosClient must not be empty when %T.Tables is set", config, config)
+	}
+	for i, t := range config.Tables {
+		if t.Name == "" {
+			return nil, microerror.Maskf(invalidConfigError, "%T.Tables[%d].Name must not be empty", config, i)
+		}
+		if len(t.KeyPrefixes) == 0 {
+			return nil, microerror.Maskf(invalidConfigError, "%T.Tables[%d].KeyPrefixes must not be empty", config, i)
+		}
+	}
+
+	if config.ChartRetention == 0 {
+		config.ChartRetention = defaultChartRetention
+	}
+	if len(config.ChartSuffixes) == 0 {
+		config.ChartSuffixes = chart.DefaultSuffixes
+	}
+
+	if config.GracePeriod == 0 {
+		config.GracePeriod = defaultGracePeriod
+	}
+	if len(config.Prefixes) == 0 {
+		config.Prefixes = defaultPrefixes
+	}
+	if config.TableDataRetention == 0 {
+		config.TableDataRetention = defaultTableDataRetention
+	}
 
 	c := &Cleaner{
 		logger: config.Logger,
+		run:    config.Run,
 
 		activityLogsClient:                     config.ActivityLogsClient,
+		armClient:                              config.ARMClient,
+		cosmosClient:                           config.CosmosClient,
 		dnsRecordSetsClient:                    config.DNSRecordSetsClient,
+		graphClient:                            config.GraphClient,
 		groupsClient:                           config.GroupsClient,
+		keyVaultClient:                         config.KeyVaultClient,
+		registryClient:                         config.RegistryClient,
+		vaultsClient:                           config.VaultsClient,
 		virtualNetworkPeeringsClient:           config.VirtualNetworkPeeringsClient,
 		virtualNetworkGatewayConnectionsClient: config.VirtualNetworkGatewayConnectionsClient,
 		virtualNetworksClient:                  config.VirtualNetworksClient,
 
-		installations: config.Installations,
-		azureLocation: config.AzureLocation,
+		installations:     config.Installations,
+		azureLocation:     config.AzureLocation,
+		certificateVaults: config.CertificateVaults,
+		chartRepositories: config.ChartRepositories,
+		chartRetention:    config.ChartRetention,
+		chartSuffixes:     config.ChartSuffixes,
+		ciPrincipals:      config.CIPrincipals,
+		gracePeriod:       config.GracePeriod,
+		prefixes:          config.Prefixes,
+		purgeHSMs:         config.PurgeHSMs,
+
+		tables:             config.Tables,
+		tableDataRetention: config.TableDataRetention,
+
+		deleteApplications:        config.DeleteApplications,
+		deleteTableData:           config.DeleteTableData,
+		recordRoleAssignmentDrift: config.RecordRoleAssignmentDrift,
+
+		deleteOrphanedRoleAssignments: config.DeleteOrphanedRoleAssignments,
 	}
 
 	return c, nil
@@ -93,29 +317,49 @@ func NewCleaner(config CleanerConfig) (*Cleaner, error) {
 func (c *Cleaner) Clean(ctx context.Context) error {
 	c.logger.LogCtx(ctx, "level", "debug", "message", "starting Azure CI cleanup")
 
-	err := c.cleanVirtualNetworkPeering(ctx)
+	// The cleaners are bound to a copy of c, so resources created by CI
+	// principals have to be known before they are listed.
+	err := c.loadCreatedByCI(ctx)
 	if err != nil {
 		return microerror.Mask(err)
 	}
 
-	err = c.cleanResourceGroup(ctx)
-	if err != nil {
-		return microerror.Mask(err)
+	var roleAssignments []report.RoleAssignment
+	if c.recordRoleAssignmentDrift {
+		roleAssignments, err = c.listRoleAssignments(ctx)
+		if err != nil {
+			return microerror.Mask(err)
+		}
 	}
 
-	err = c.cleanVPNConnection(ctx)
-	if err != nil {
-		return microerror.Mask(err)
-	}
+	for _, cleaner := range c.cleaners() {
+		if !c.run.Enabled(cleaner.name) {
+			continue
+		}
 
-	err = c.cleanDNSRecordSet(ctx)
-	if err != nil {
-		return microerror.Mask(err)
+		cleanerCtx := logctx.WithCleaner(ctx, cleaner.name)
+
+		err := cleaner.fn(cleanerCtx)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		// Resources being deleted are not listed by all resource providers,
+		// so the deletions the cleaner started are polled until they finish.
+		// All of them are operations of Azure Resource Manager.
+		err = c.run.PollPending(cleanerCtx, cleaner.name, "", c.armClient.Poll)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		c.run.Complete(cleaner.name)
 	}
 
-	err = c.cleanDelegateDNSRecords(ctx)
-	if err != nil {
-		return microerror.Mask(err)
+	if c.recordRoleAssignmentDrift {
+		err = c.addRoleAssignmentDrift(ctx, roleAssignments)
+		if err != nil {
+			return microerror.Mask(err)
+		}
 	}
 
 	c.logger.LogCtx(ctx, "level", "debug", "message", "finished Azure CI cleanup")
@@ -123,14 +367,80 @@ func (c *Cleaner) Clean(ctx context.Context) error {
 	return nil
 }
 
-func isCIResource(s string) bool {
-	r := false
-	r = r || strings.HasPrefix(s, "ci-last-")
-	r = r || strings.HasPrefix(s, "ci-prev-")
-	r = r || strings.HasPrefix(s, "ci-cur-")
-	r = r || strings.HasPrefix(s, "ci-wip-")
+// namedCleaner is a single cleaner of the provider.
+type namedCleaner struct {
+	name string
+	fn   func(ctx context.Context) error
+}
 
-	return r
+// cleaners returns all cleaners in the order they run.
+func (c Cleaner) cleaners() []namedCleaner {
+	cleaners := []namedCleaner{
+		{name: cleanerVirtualNetworkPeerings, fn: c.cleanVirtualNetworkPeering},
+		{name: cleanerResourceGroups, fn: c.cleanResourceGroup},
+		{name: cleanerVPNConnections, fn: c.cleanVPNConnection},
+		{name: cleanerVirtualNetworkGateways, fn: c.cleanVirtualNetworkGateways},
+		{name: cleanerPrivateEndpoints, fn: c.cleanPrivateEndpoints},
+		{name: cleanerManagedDisks, fn: c.cleanManagedDisks},
+		{name: cleanerDNSRecordSets, fn: c.cleanDNSRecordSet},
+		{name: cleanerDelegatedDNSRecords, fn: c.cleanDelegateDNSRecords},
+		{name: cleanerAPIManagementServices, fn: c.cleanAPIManagementServices},
+		{name: cleanerAppServices, fn: c.cleanAppServices},
+		{name: cleanerCosmosDBAccounts, fn: c.cleanCosmosDBAccounts},
+		{name: cleanerCertificates, fn: c.cleanCertificates},
+		{name: cleanerCharts, fn: c.cleanCharts},
+		{name: cleanerTableData, fn: c.cleanTableData},
+		{name: cleanerEventGrid, fn: c.cleanEventGrid},
+		{name: cleanerDiagnosticSettings, fn: c.cleanDiagnosticSettings},
+		{name: cleanerAlertRules, fn: c.cleanAlertRules},
+		{name: cleanerSoftDeleted, fn: c.cleanSoftDeleted},
+		{name: cleanerHSMs, fn: c.cleanHSMs},
+		{name: cleanerApplications, fn: c.cleanApplications},
+		{name: cleanerRoleAssignments, fn: c.cleanRoleAssignments},
+	}
+
+	return cleaners
+}
+
+// Names returns the names of the cleaners in the order they run.
+func (c Cleaner) Names() []string {
+	var names []string
+	for _, cleaner := range c.cleaners() {
+		names = append(names, cleaner.name)
+	}
+
+	return names
+}
+
+func (c Cleaner) isCIResource(s string) bool {
+	for _, p := range c.prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isCITagged checks if the given tags mark a resource as created by CI by the
+// standard Giant Swarm tags of the installation or cluster it belongs to,
+// including the tags Kubernetes provisioned resources carry.
+func (c Cleaner) isCITagged(tags map[string]string) bool {
+	for k, v := range tags {
+		switch {
+		case k == owner.ClusterTag || k == owner.InstallationTag:
+		case strings.HasPrefix(k, owner.KubernetesClusterTagPrefix):
+			v = strings.TrimPrefix(k, owner.KubernetesClusterTagPrefix)
+		default:
+			continue
+		}
+
+		if v != "" && c.isCIResource(v) {
+			return true
+		}
+	}
+
+	return false
 }
 
 func isAnyEmpty(list []string) bool {
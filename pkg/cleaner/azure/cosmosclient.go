@@ -0,0 +1,272 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/adal"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// CosmosDBResource is the resource Azure AD tokens for the Cosmos DB
+	// data plane are issued for.
+	CosmosDBResource = "https://cosmos.azure.com"
+
+	cosmosDBDataAPIVersion = "2018-12-31"
+)
+
+// CosmosClient is a thin client of the data plane of Cosmos DB SQL API
+// accounts, which is not covered by the SDK API versions we vendor. It
+// authenticates with Azure AD, so the service principal needs a data plane
+// role assignment of the accounts, like Cosmos DB Built-in Data Contributor.
+type CosmosClient struct {
+	autorest.Client
+
+	// Token is the Azure AD token of the service principal, issued for
+	// CosmosDBResource.
+	Token *adal.ServicePrincipalToken
+}
+
+// NewCosmosClient creates a CosmosClient authenticating with the given Azure
+// AD token of a service principal.
+func NewCosmosClient(token *adal.ServicePrincipalToken) CosmosClient {
+	return CosmosClient{
+		Client: autorest.NewClientWithUserAgent("ci-cleaner"),
+		Token:  token,
+	}
+}
+
+// cosmosContainer is a container of a database of a Cosmos DB account.
+type cosmosContainer struct {
+	client   CosmosClient
+	account  string
+	database string
+	name     string
+	// partitionKeyPath is the path of the partition key of the container,
+	// like `/runId`.
+	partitionKeyPath string
+}
+
+// cosmosDocument is a document of a container as returned by the queries of
+// Query.
+type cosmosDocument struct {
+	ID           string      `json:"id"`
+	RunKey       interface{} `json:"runKey"`
+	PartitionKey interface{} `json:"partitionKey"`
+	Written      interface{} `json:"written"`
+}
+
+// Container returns the container with the given name of the given database
+// of the Cosmos DB account with the given name.
+func (c CosmosClient) Container(ctx context.Context, account string, database string, name string) (cosmosContainer, error) {
+	r := cosmosContainer{
+		client:   c,
+		account:  account,
+		database: database,
+		name:     name,
+	}
+
+	req, err := c.prepare(ctx, account, autorest.AsGet(), autorest.WithPath(r.path()))
+	if err != nil {
+		return cosmosContainer{}, microerror.Mask(err)
+	}
+
+	resp, err := c.Send(req)
+	if err != nil {
+		return cosmosContainer{}, microerror.Mask(err)
+	}
+
+	var collection struct {
+		PartitionKey struct {
+			Paths []string `json:"paths"`
+		} `json:"partitionKey"`
+	}
+	err = autorest.Respond(
+		resp,
+		c.ByInspecting(),
+		azure.WithErrorUnlessStatusCode(http.StatusOK),
+		autorest.ByUnmarshallingJSON(&collection),
+		autorest.ByClosing(),
+	)
+	if err != nil {
+		return cosmosContainer{}, microerror.Mask(err)
+	}
+
+	if len(collection.PartitionKey.Paths) != 0 {
+		r.partitionKeyPath = collection.PartitionKey.Paths[0]
+	}
+
+	return r, nil
+}
+
+// Query returns the documents matching the given query across all
+// partitions, following continuation tokens.
+func (r cosmosContainer) Query(ctx context.Context, query string, parameters map[string]string) ([]cosmosDocument, error) {
+	type parameter struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+	body := struct {
+		Query      string      `json:"query"`
+		Parameters []parameter `json:"parameters"`
+	}{
+		Query: query,
+	}
+	for k, v := range parameters {
+		body.Parameters = append(body.Parameters, parameter{Name: k, Value: v})
+	}
+
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var documents []cosmosDocument
+	var continuation string
+	for {
+		decorators := []autorest.PrepareDecorator{
+			autorest.AsPost(),
+			autorest.WithPath(r.path() + "/docs"),
+			autorest.WithHeader("Content-Type", "application/query+json"),
+			autorest.WithHeader("x-ms-documentdb-isquery", "True"),
+			autorest.WithHeader("x-ms-documentdb-query-enablecrosspartition", "True"),
+			autorest.WithString(string(b)),
+		}
+		if continuation != "" {
+			decorators = append(decorators, autorest.WithHeader("x-ms-continuation", continuation))
+		}
+
+		req, err := r.client.prepare(ctx, r.account, decorators...)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		resp, err := r.client.Send(req)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		var page struct {
+			Documents []cosmosDocument `json:"Documents"`
+		}
+		err = autorest.Respond(
+			resp,
+			r.client.ByInspecting(),
+			azure.WithErrorUnlessStatusCode(http.StatusOK),
+			autorest.ByUnmarshallingJSON(&page),
+			autorest.ByClosing(),
+		)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		documents = append(documents, page.Documents...)
+
+		continuation = resp.Header.Get("x-ms-continuation")
+		if continuation == "" {
+			break
+		}
+	}
+
+	return documents, nil
+}
+
+// DeleteDocument deletes the document with the given ID and partition key
+// value. Documents which do not exist anymore are not considered an error.
+func (r cosmosContainer) DeleteDocument(ctx context.Context, id string, partitionKey interface{}) error {
+	// Documents without partition key value are addressed by an empty
+	// object.
+	pk := []byte("[{}]")
+	if partitionKey != nil {
+		var err error
+		pk, err = json.Marshal([]interface{}{partitionKey})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	req, err := r.client.prepare(ctx, r.account,
+		autorest.AsDelete(),
+		autorest.WithPath(r.path()+"/docs/"+url.PathEscape(id)),
+		autorest.WithHeader("x-ms-documentdb-partitionkey", string(pk)),
+	)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	resp, err := r.client.Send(req)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = autorest.Respond(
+		resp,
+		r.client.ByInspecting(),
+		azure.WithErrorUnlessStatusCode(http.StatusNoContent, http.StatusNotFound),
+		autorest.ByClosing(),
+	)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (r cosmosContainer) path() string {
+	return "/dbs/" + url.PathEscape(r.database) + "/colls/" + url.PathEscape(r.name)
+}
+
+// prepare prepares a request to the given account, authorized with the
+// Azure AD token of the client.
+func (c CosmosClient) prepare(ctx context.Context, account string, decorators ...autorest.PrepareDecorator) (*http.Request, error) {
+	err := c.Token.EnsureFreshWithContext(ctx)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	decorators = append([]autorest.PrepareDecorator{
+		autorest.WithBaseURL("https://" + account + ".documents.azure.com"),
+		autorest.WithHeader("Authorization", url.QueryEscape("type=aad&ver=1.0&sig="+c.Token.OAuthToken())),
+		autorest.WithHeader("x-ms-date", time.Now().UTC().Format(http.TimeFormat)),
+		autorest.WithHeader("x-ms-version", cosmosDBDataAPIVersion),
+	}, decorators...)
+
+	req, err := autorest.Prepare((&http.Request{}).WithContext(ctx), decorators...)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return req, nil
+}
+
+// cosmosPath returns the expression of the attribute with the given path of
+// the documents of a query, e.g. `c["status"]["run"]` for `/status/run`.
+func cosmosPath(attr string) string {
+	p := "c"
+	for _, s := range strings.Split(strings.TrimPrefix(attr, "/"), "/") {
+		p += "[" + strconv.Quote(s) + "]"
+	}
+
+	return p
+}
+
+// cosmosValue returns the given scalar value of a document as string, like
+// the attribute values tabledata.ParseTimestamp reads.
+func cosmosValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,23 @@
+package azure
+
+import (
+	"context"
+
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	cosmosDBAPIVersion = "2021-10-15"
+)
+
+// cleanCosmosDBAccounts deletes Cosmos DB accounts created by database e2e
+// tests. Deleting an account takes several minutes, so the deletion is only
+// started and tracked by later runs.
+func (c Cleaner) cleanCosmosDBAccounts(ctx context.Context) error {
+	err := c.cleanARMResources(ctx, cleanerCosmosDBAccounts, "Microsoft.DocumentDB/databaseAccounts", cosmosDBAPIVersion)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
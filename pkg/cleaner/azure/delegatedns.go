@@ -3,18 +3,18 @@ package azure
 import (
 	"context"
 	"fmt"
+	"path"
 	"regexp"
 	"strings"
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/services/dns/mgmt/2017-10-01/dns"
-	"github.com/bogdanovich/dns_resolver"
 	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/domain"
 )
 
 const (
-	dnsFailureError    = "SERVFAIL"
-	dnsServerAddress   = "8.8.8.8"
 	e2eterraformPrefix = "e2eterraform"
 	resourceGroup      = "root_dns_zone_rg"
 	zoneName           = "azure.gigantic.io"
@@ -28,9 +28,9 @@ func (c Cleaner) cleanDelegateDNSRecords(ctx context.Context) error {
 		return microerror.Mask(err)
 	}
 
-	deadLine := time.Now().Add(-gracePeriod).UTC()
+	deadLine := time.Now().Add(-c.gracePeriod).UTC()
 
-	for ; recordsIter.NotDone(); recordsIter.Next() {
+	err = iterate(ctx, &recordsIter, func() error {
 		record := recordsIter.Value()
 
 		del, err := c.dnsRecordShouldBeDeleted(ctx, record, deadLine)
@@ -38,24 +38,28 @@ func (c Cleaner) cleanDelegateDNSRecords(ctx context.Context) error {
 			c.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("failed to check DNS record %q", *record.Name), "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
 			c.logger.LogCtx(ctx, "level", "error", "message", "skipping")
 			lastError = err
-			continue
+			return nil
 		}
 
 		if del {
 			c.logger.LogCtx(ctx, "level", "info", "message", fmt.Sprintf("DNS record %s has to be deleted", *record.Name))
-			err := c.deleteRecord(ctx, record)
+			err := c.run.Delete(ctx, cleanerDelegatedDNSRecords, *record.Name, func() error {
+				return c.deleteRecord(ctx, record)
+			})
 			if err != nil {
 				c.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("failed to delete DNS record %q", *record.Name), "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
 				c.logger.LogCtx(ctx, "level", "error", "message", "skipping")
 				lastError = err
-				continue
+				return nil
 			}
-
-			c.logger.LogCtx(ctx, "level", "debug", "info", fmt.Sprintf("DNS record %s was deleted", *record.Name))
 		} else {
 			c.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("DNS record %s has to be kept", *record.Name))
 		}
 
+		return nil
+	})
+	if err != nil {
+		return microerror.Mask(err)
 	}
 
 	if lastError != nil {
@@ -66,16 +70,34 @@ func (c Cleaner) cleanDelegateDNSRecords(ctx context.Context) error {
 }
 
 func (c Cleaner) deleteRecord(ctx context.Context, dnsRecord dns.RecordSet) error {
-	_, err := c.dnsRecordSetsClient.Delete(ctx, resourceGroup, zoneName, *dnsRecord.Name, dns.NS, *dnsRecord.Etag)
+	_, err := c.dnsRecordSetsClient.Delete(ctx, resourceGroup, zoneName, *dnsRecord.Name, recordType(dnsRecord), *dnsRecord.Etag)
 
 	return err
 }
 
+// recordType returns the type of the given record set, which Azure returns
+// like "Microsoft.Network/dnszones/AAAA".
+func recordType(dnsRecord dns.RecordSet) dns.RecordType {
+	if dnsRecord.Type == nil {
+		return dns.NS
+	}
+
+	return dns.RecordType(path.Base(*dnsRecord.Type))
+}
+
 func (c Cleaner) dnsRecordShouldBeDeleted(ctx context.Context, dnsRecord dns.RecordSet, since time.Time) (bool, error) {
 	if !isCIRecord(*dnsRecord.Name) {
 		return false, nil
 	}
 
+	// delegations and the AAAA records of dual-stack e2e clusters are the
+	// only records created for CI clusters in the root zone.
+	switch recordType(dnsRecord) {
+	case dns.NS, dns.AAAA:
+	default:
+		return false, nil
+	}
+
 	resolves, err := resolvesApiName(*dnsRecord.Name)
 	if err != nil {
 		c.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("Unexpected error when trying to resolve %s: %s", *dnsRecord.Name, err.Error()))
@@ -98,21 +120,13 @@ func isCIRecord(s string) bool {
 	return re.Match([]byte(s))
 }
 
-// Tries to resolve the API hostname on the specified delegated zone.
+// Tries to resolve the API hostname on the specified delegated zone. IPv6-only
+// clusters are found by their AAAA records.
 func resolvesApiName(name string) (bool, error) {
-	full := fmt.Sprintf("api.%s.%s", name, zoneName)
-
-	resolver := dns_resolver.New([]string{dnsServerAddress})
-
-	// In case of i/o timeout
-	resolver.RetryTimes = 5
-
-	addresses, err := resolver.LookupHost(full)
+	resolves, err := domain.APIResolves(fmt.Sprintf("%s.%s", name, zoneName))
 	if err != nil {
-		if !strings.Contains(err.Error(), dnsFailureError) {
-			return false, err
-		}
+		return false, microerror.Mask(err)
 	}
 
-	return len(addresses) > 0, nil
+	return resolves, nil
 }
@@ -0,0 +1,137 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+const (
+	computeAPIVersion = "2022-07-02"
+
+	// pvcTagPrefix prefixes the tags the Azure disk drivers put on the disks
+	// they provision for persistent volume claims, like
+	// `kubernetes.io-created-for-pvc-namespace`.
+	pvcTagPrefix = "kubernetes.io-created-for-pvc-"
+
+	diskStateUnattached = "Unattached"
+)
+
+// diskProperties are the properties of a managed disk we care about.
+type diskProperties struct {
+	DiskSizeGB  int       `json:"diskSizeGB"`
+	DiskState   string    `json:"diskState"`
+	TimeCreated time.Time `json:"timeCreated"`
+}
+
+// cleanManagedDisks deletes the unattached managed disks provisioned for
+// persistent volume claims of CI clusters. They are created in the resource
+// group of the node pools, which is often shared, so they survive the
+// deletion of the resource group of their CI cluster and are billed monthly.
+func (c Cleaner) cleanManagedDisks(ctx context.Context) error {
+	var lastError error
+
+	path := fmt.Sprintf("/subscriptions/%s/providers/Microsoft.Compute/disks", c.armClient.SubscriptionID)
+	disks, err := c.armClient.List(ctx, path, computeAPIVersion)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, r := range disks {
+		var p diskProperties
+		err := json.Unmarshal(r.Properties, &p)
+		if err != nil {
+			c.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("failed to decode managed disk %q", r.ID), "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
+			lastError = err
+			continue
+		}
+
+		if !c.managedDiskShouldBeDeleted(r, p) {
+			continue
+		}
+
+		err = c.deleteManagedDisk(ctx, r, p)
+		if err != nil {
+			c.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("did not ensure deletion of managed disk %q", r.ID), "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
+			lastError = err
+			continue
+		}
+	}
+
+	if lastError != nil {
+		return microerror.Mask(lastError)
+	}
+
+	return nil
+}
+
+// deleteManagedDisk starts deleting the given managed disk or checks whether
+// a deletion started by a previous run finished.
+func (c Cleaner) deleteManagedDisk(ctx context.Context, r armResource, p diskProperties) error {
+	c.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("ensuring deletion of managed disk %q", r.ID))
+
+	res := run.Resource{
+		ID:        r.ID,
+		Type:      "Microsoft.Compute/disks",
+		Region:    r.Location,
+		Tags:      r.Tags,
+		CreatedAt: p.TimeCreated,
+		Note:      fmt.Sprintf("%d GiB", p.DiskSizeGB),
+		Reason:    run.ReasonUnused,
+	}
+	start := func() (string, error) {
+		return c.armClient.DeleteAsync(ctx, r.ID, computeAPIVersion)
+	}
+	err := c.run.DeleteResourceAsync(ctx, cleanerManagedDisks, res, start, c.armClient.Poll)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// managedDiskShouldBeDeleted checks if the given managed disk was provisioned
+// for a persistent volume claim of a CI cluster, is not attached to any
+// virtual machine and is older than the grace period. The CI cluster is told
+// by the name of the disk, its cluster tags or the values of its persistent
+// volume claim tags, like the namespace of the claim.
+func (c Cleaner) managedDiskShouldBeDeleted(r armResource, p diskProperties) bool {
+	if p.DiskState != diskStateUnattached {
+		return false
+	}
+
+	var pvc bool
+	var ciPVC bool
+	for k, v := range r.Tags {
+		if !strings.HasPrefix(k, pvcTagPrefix) {
+			continue
+		}
+		pvc = true
+		if c.isCIResource(v) {
+			ciPVC = true
+		}
+	}
+	if !pvc {
+		return false
+	}
+	if !ciPVC && !c.isCIResource(r.Name) && !c.isCreatedByCI(r.ID) && !c.isCITagged(r.Tags) {
+		return false
+	}
+
+	// do not delete recent disks, which may be attached any moment.
+	createdAt := p.TimeCreated
+	if createdAt.IsZero() {
+		createdAt = r.SystemData.CreatedAt
+	}
+	if createdAt.IsZero() || time.Since(createdAt) < c.gracePeriod {
+		return false
+	}
+
+	return true
+}
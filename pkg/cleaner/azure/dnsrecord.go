@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/Azure/azure-sdk-for-go/services/dns/mgmt/2017-10-01/dns"
+	"github.com/Azure/go-autorest/autorest/to"
 	"github.com/giantswarm/microerror"
 )
 
@@ -25,12 +26,17 @@ func (c Cleaner) cleanDNSRecordSet(ctx context.Context) error {
 		return microerror.Mask(err)
 	}
 
-	for ; groupIter.NotDone(); groupIter.Next() {
+	err = iterate(ctx, &groupIter, func() error {
 		group := groupIter.Value()
 
-		if isCIResource(*group.Name) {
+		if c.isCIResource(*group.Name) || c.isCreatedByCI(to.String(group.ID)) {
 			groupMap[*group.Name] = true
 		}
+
+		return nil
+	})
+	if err != nil {
+		return microerror.Mask(err)
 	}
 
 	// Clean dns record set in every installation.
@@ -43,12 +49,12 @@ func (c Cleaner) cleanDNSRecordSet(ctx context.Context) error {
 			return microerror.Mask(err)
 		}
 
-		for ; iter.NotDone(); iter.Next() {
+		err = iterate(ctx, &iter, func() error {
 			recordSet := iter.Value()
 
-			if !isCIResource(*recordSet.Name) {
+			if !c.isCIResource(*recordSet.Name) && !c.isCreatedByCI(to.String(recordSet.ID)) {
 				// Skip non CI dns record set.
-				continue
+				return nil
 			}
 
 			// Delete dns record set which do not have a corresponding resource group.
@@ -57,17 +63,29 @@ func (c Cleaner) cleanDNSRecordSet(ctx context.Context) error {
 			if !exist {
 				c.logger.Log("level", "error", "message", fmt.Sprintf("ensuring deletion of record set %q", *recordSet.Name))
 
-				res, err := c.dnsRecordSetsClient.Delete(ctx, i, zoneName, *recordSet.Name, dns.NS, "")
-				if res.Response != nil && res.StatusCode == http.StatusNotFound {
-					// fall through
-				} else if err != nil {
+				err := c.run.Delete(ctx, cleanerDNSRecordSets, *recordSet.Name, func() error {
+					res, err := c.dnsRecordSetsClient.Delete(ctx, i, zoneName, *recordSet.Name, dns.NS, "")
+					if res.Response != nil && res.StatusCode == http.StatusNotFound {
+						// fall through
+					} else if err != nil {
+						return microerror.Mask(err)
+					}
+
+					c.logger.Log("level", "error", "message", fmt.Sprintf("ensured deletion of record set %q", *recordSet.Name))
+
+					return nil
+				})
+				if err != nil {
 					c.logger.Log("level", "error", "message", fmt.Sprintf("did not ensure deletion of record set %q", *recordSet.Name), "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
 					lastError = err
-					continue
+					return nil
 				}
-
-				c.logger.Log("level", "error", "message", fmt.Sprintf("ensured deletion of record set %q", *recordSet.Name))
 			}
+
+			return nil
+		})
+		if err != nil {
+			return microerror.Mask(err)
 		}
 	}
 
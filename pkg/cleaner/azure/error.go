@@ -41,3 +41,12 @@ var invalidConfigError = &microerror.Error{
 func IsInvalidConfig(err error) bool {
 	return microerror.Cause(err) == invalidConfigError
 }
+
+var operationFailedError = &microerror.Error{
+	Kind: "operationFailedError",
+}
+
+// IsOperationFailed asserts operationFailedError.
+func IsOperationFailed(err error) bool {
+	return microerror.Cause(err) == operationFailedError
+}
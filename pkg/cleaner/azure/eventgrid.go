@@ -0,0 +1,53 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	eventGridAPIVersion = "2022-06-15"
+)
+
+// cleanEventGrid deletes the Event Grid custom topics and the event
+// subscriptions of system topics created per CI cluster. They are created in
+// shared resource groups and accumulate otherwise, which slows down queries
+// against ARM and the portal. The system topics themselves are shared and
+// kept.
+func (c Cleaner) cleanEventGrid(ctx context.Context) error {
+	var lastError error
+
+	err := c.cleanARMResources(ctx, cleanerEventGrid, "Microsoft.EventGrid/topics", eventGridAPIVersion)
+	if err != nil {
+		c.logger.LogCtx(ctx, "level", "error", "message", "failed to clean event grid topics", "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
+		lastError = err
+	}
+
+	path := fmt.Sprintf("/subscriptions/%s/providers/Microsoft.EventGrid/systemTopics", c.armClient.SubscriptionID)
+	systemTopics, err := c.armClient.List(ctx, path, eventGridAPIVersion)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, t := range systemTopics {
+		subscriptions, err := c.armClient.List(ctx, t.ID+"/eventSubscriptions", eventGridAPIVersion)
+		if err != nil {
+			c.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("failed to list event subscriptions of system topic %q", t.ID), "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
+			lastError = err
+			continue
+		}
+
+		err = c.deleteARMResources(ctx, cleanerEventGrid, "Microsoft.EventGrid/systemTopics/eventSubscriptions", eventGridAPIVersion, subscriptions)
+		if err != nil {
+			lastError = err
+		}
+	}
+
+	if lastError != nil {
+		return microerror.Mask(lastError)
+	}
+
+	return nil
+}
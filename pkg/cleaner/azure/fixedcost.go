@@ -0,0 +1,45 @@
+package azure
+
+import (
+	"context"
+
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	appServiceAPIVersion = "2022-03-01"
+)
+
+// cleanAPIManagementServices deletes API Management services created by CI
+// integration tests, which are billed at a fixed cost no matter whether they
+// are used. Deleted services are soft-deleted and purged by the soft-deleted
+// cleaner afterwards.
+func (c Cleaner) cleanAPIManagementServices(ctx context.Context) error {
+	err := c.cleanARMResources(ctx, cleanerAPIManagementServices, "Microsoft.ApiManagement/service", apiManagementAPIVersion)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// cleanAppServices deletes web apps and App Service plans created by CI
+// integration tests. Plans are billed at a fixed cost no matter whether apps
+// run on them. Web apps are deleted first, as plans cannot be deleted while
+// apps run on them.
+func (c Cleaner) cleanAppServices(ctx context.Context) error {
+	var lastError error
+
+	for _, t := range []string{"Microsoft.Web/sites", "Microsoft.Web/serverfarms"} {
+		err := c.cleanARMResources(ctx, cleanerAppServices, t, appServiceAPIVersion)
+		if err != nil {
+			lastError = err
+		}
+	}
+
+	if lastError != nil {
+		return microerror.Mask(lastError)
+	}
+
+	return nil
+}
@@ -0,0 +1,340 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// GraphResource is the resource Azure AD tokens for Microsoft Graph are
+	// issued for.
+	GraphResource = "https://graph.microsoft.com/"
+
+	graphBaseURI = "https://graph.microsoft.com/v1.0"
+
+	// graphGetByIDsLimit is the maximum number of IDs of a single request of
+	// directory objects by their IDs.
+	graphGetByIDsLimit = 1000
+)
+
+// GraphClient is a thin Microsoft Graph client for the app registrations and
+// service principals of the directory, which are not covered by the SDK
+// packages we vendor. The Authorizer of the embedded autorest.Client has to
+// be set by the caller. The service principal needs the
+// Application.ReadWrite.OwnedBy permission of Microsoft Graph, which covers
+// the app registrations it created.
+type GraphClient struct {
+	autorest.Client
+
+	BaseURI string
+}
+
+// NewGraphClient creates a GraphClient for the public Azure cloud.
+func NewGraphClient() GraphClient {
+	return GraphClient{
+		Client:  autorest.NewClientWithUserAgent("ci-cleaner"),
+		BaseURI: graphBaseURI,
+	}
+}
+
+// graphApplication is the part of an app registration we care about.
+type graphApplication struct {
+	ID                  string            `json:"id"`
+	AppID               string            `json:"appId"`
+	DisplayName         string            `json:"displayName"`
+	CreatedDateTime     time.Time         `json:"createdDateTime"`
+	DeletedDateTime     time.Time         `json:"deletedDateTime"`
+	PasswordCredentials []graphCredential `json:"passwordCredentials"`
+}
+
+// graphServicePrincipal is the part of a service principal we care about.
+type graphServicePrincipal struct {
+	ID          string `json:"id"`
+	AppID       string `json:"appId"`
+	DisplayName string `json:"displayName"`
+}
+
+// graphCredential is a client secret of an app registration.
+type graphCredential struct {
+	KeyID       string    `json:"keyId"`
+	DisplayName string    `json:"displayName"`
+	EndDateTime time.Time `json:"endDateTime"`
+}
+
+type graphList struct {
+	Value    json.RawMessage `json:"value"`
+	NextLink string          `json:"@odata.nextLink"`
+}
+
+// Applications returns the app registrations matching the given OData
+// filter, or all of them when it is empty.
+func (c GraphClient) Applications(ctx context.Context, filter string) ([]graphApplication, error) {
+	var applications []graphApplication
+	err := c.list(ctx, "/applications", filter, func(value json.RawMessage) error {
+		var page []graphApplication
+		err := json.Unmarshal(value, &page)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		applications = append(applications, page...)
+
+		return nil
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return applications, nil
+}
+
+// DeletedApplications returns the soft-deleted app registrations matching the
+// given OData filter. They are kept for 30 days and count against the
+// directory object quota until they are purged.
+func (c GraphClient) DeletedApplications(ctx context.Context, filter string) ([]graphApplication, error) {
+	var applications []graphApplication
+	err := c.list(ctx, "/directory/deletedItems/microsoft.graph.application", filter, func(value json.RawMessage) error {
+		var page []graphApplication
+		err := json.Unmarshal(value, &page)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		applications = append(applications, page...)
+
+		return nil
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return applications, nil
+}
+
+// ServicePrincipals returns the service principals matching the given OData
+// filter, or all of them when it is empty.
+func (c GraphClient) ServicePrincipals(ctx context.Context, filter string) ([]graphServicePrincipal, error) {
+	var principals []graphServicePrincipal
+	err := c.list(ctx, "/servicePrincipals", filter, func(value json.RawMessage) error {
+		var page []graphServicePrincipal
+		err := json.Unmarshal(value, &page)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		principals = append(principals, page...)
+
+		return nil
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return principals, nil
+}
+
+// ExistingObjects returns which of the directory objects with the given IDs,
+// like the object IDs of service principals, users and groups, exist. It
+// requires the Directory.Read.All permission of Microsoft Graph.
+func (c GraphClient) ExistingObjects(ctx context.Context, ids []string) (map[string]bool, error) {
+	existing := map[string]bool{}
+
+	for start := 0; start < len(ids); start += graphGetByIDsLimit {
+		end := start + graphGetByIDsLimit
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		preparer := autorest.CreatePreparer(
+			autorest.AsPost(),
+			autorest.AsJSON(),
+			autorest.WithBaseURL(c.BaseURI),
+			autorest.WithPath("/directoryObjects/getByIds"),
+			autorest.WithJSON(map[string][]string{"ids": ids[start:end]}),
+		)
+
+		req, err := preparer.Prepare((&http.Request{}).WithContext(ctx))
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		resp, err := c.Send(req)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		var objects struct {
+			Value []struct {
+				ID string `json:"id"`
+			} `json:"value"`
+		}
+		err = autorest.Respond(
+			resp,
+			c.ByInspecting(),
+			azure.WithErrorUnlessStatusCode(http.StatusOK),
+			autorest.ByUnmarshallingJSON(&objects),
+			autorest.ByClosing(),
+		)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, o := range objects.Value {
+			existing[o.ID] = true
+		}
+	}
+
+	return existing, nil
+}
+
+// Delete deletes the directory object with the given path, e.g.
+// `/applications/<id>`. Objects which do not exist anymore are not considered
+// an error.
+func (c GraphClient) Delete(ctx context.Context, path string) error {
+	preparer := autorest.CreatePreparer(
+		autorest.AsDelete(),
+		autorest.WithBaseURL(c.BaseURI),
+		autorest.WithPath(path),
+	)
+
+	err := c.send(ctx, preparer, http.StatusNoContent, http.StatusNotFound)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// Purge permanently deletes the soft-deleted directory object with the given
+// ID, so that it does not count against the directory object quota anymore.
+func (c GraphClient) Purge(ctx context.Context, id string) error {
+	err := c.Delete(ctx, "/directory/deletedItems/"+url.PathEscape(id))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// RemovePassword removes the client secret with the given key ID from the
+// app registration with the given object ID.
+func (c GraphClient) RemovePassword(ctx context.Context, applicationID string, keyID string) error {
+	preparer := autorest.CreatePreparer(
+		autorest.AsPost(),
+		autorest.AsJSON(),
+		autorest.WithBaseURL(c.BaseURI),
+		autorest.WithPath("/applications/"+url.PathEscape(applicationID)+"/removePassword"),
+		autorest.WithJSON(map[string]string{"keyId": keyID}),
+	)
+
+	err := c.send(ctx, preparer, http.StatusOK, http.StatusNoContent)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// list calls add with the values of all pages listed under the given path,
+// following pagination links.
+func (c GraphClient) list(ctx context.Context, path string, filter string, add func(value json.RawMessage) error) error {
+	decorators := []autorest.PrepareDecorator{
+		autorest.AsGet(),
+		autorest.WithBaseURL(c.BaseURI),
+		autorest.WithPath(path),
+	}
+	if filter != "" {
+		decorators = append(decorators, autorest.WithQueryParameters(map[string]interface{}{
+			"$filter": filter,
+		}))
+	}
+	preparer := autorest.CreatePreparer(decorators...)
+
+	for {
+		req, err := preparer.Prepare((&http.Request{}).WithContext(ctx))
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		resp, err := c.Send(req)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		var page graphList
+		err = autorest.Respond(
+			resp,
+			c.ByInspecting(),
+			azure.WithErrorUnlessStatusCode(http.StatusOK),
+			autorest.ByUnmarshallingJSON(&page),
+			autorest.ByClosing(),
+		)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		if len(page.Value) != 0 {
+			err = add(page.Value)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+
+		if page.NextLink == "" {
+			break
+		}
+
+		preparer = autorest.CreatePreparer(
+			autorest.AsGet(),
+			autorest.WithBaseURL(page.NextLink),
+		)
+	}
+
+	return nil
+}
+
+func (c GraphClient) send(ctx context.Context, preparer autorest.Preparer, codes ...int) error {
+	req, err := preparer.Prepare((&http.Request{}).WithContext(ctx))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	resp, err := c.Send(req)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = autorest.Respond(
+		resp,
+		c.ByInspecting(),
+		azure.WithErrorUnlessStatusCode(codes...),
+		autorest.ByClosing(),
+	)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// graphPrefixFilter returns the OData filter of the directory objects whose
+// display name starts with one of the given prefixes.
+func graphPrefixFilter(prefixes []string) string {
+	var conditions []string
+	for _, p := range prefixes {
+		conditions = append(conditions, "startswith(displayName,'"+graphQuote(p)+"')")
+	}
+
+	return strings.Join(conditions, " or ")
+}
+
+// graphQuote escapes the given value for a string literal of an OData
+// filter.
+func graphQuote(s string) string {
+	return strings.Replace(s, "'", "''", -1)
+}
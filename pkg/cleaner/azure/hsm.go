@@ -0,0 +1,141 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+const (
+	dedicatedHSMAPIVersion = "2021-11-30"
+	managedHSMAPIVersion   = "2021-10-01"
+)
+
+// cleanHSMs finds CI managed HSM pools, including soft-deleted ones, and
+// dedicated HSMs. A single leaked pool costs as much as a month of CI in a
+// few days, which is why they are always reported loudly, but only deleted
+// and purged when configured explicitly.
+func (c Cleaner) cleanHSMs(ctx context.Context) error {
+	var lastError error
+
+	path := fmt.Sprintf("/subscriptions/%s/providers/Microsoft.KeyVault/managedHSMs", c.armClient.SubscriptionID)
+	err := c.cleanHSMCollection(ctx, path, managedHSMAPIVersion, "Microsoft.KeyVault/managedHSMs", c.deleteManagedHSM)
+	if err != nil {
+		c.logger.LogCtx(ctx, "level", "error", "message", "failed to clean managed hsms", "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
+		lastError = err
+	}
+
+	path = fmt.Sprintf("/subscriptions/%s/providers/Microsoft.KeyVault/deletedManagedHSMs", c.armClient.SubscriptionID)
+	err = c.cleanHSMCollection(ctx, path, managedHSMAPIVersion, "Microsoft.KeyVault/deletedManagedHSMs", c.purgeDeletedManagedHSM)
+	if err != nil {
+		c.logger.LogCtx(ctx, "level", "error", "message", "failed to purge soft-deleted managed hsms", "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
+		lastError = err
+	}
+
+	path = fmt.Sprintf("/subscriptions/%s/providers/Microsoft.HardwareSecurityModules/dedicatedHSMs", c.armClient.SubscriptionID)
+	err = c.cleanHSMCollection(ctx, path, dedicatedHSMAPIVersion, "Microsoft.HardwareSecurityModules/dedicatedHSMs", func(ctx context.Context, r armResource) error {
+		return c.armClient.Delete(ctx, r.ID, dedicatedHSMAPIVersion)
+	})
+	if err != nil {
+		c.logger.LogCtx(ctx, "level", "error", "message", "failed to clean dedicated hsms", "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
+		lastError = err
+	}
+
+	if lastError != nil {
+		return microerror.Mask(lastError)
+	}
+
+	return nil
+}
+
+func (c Cleaner) cleanHSMCollection(ctx context.Context, path string, apiVersion string, resourceType string, deleteFn func(ctx context.Context, r armResource) error) error {
+	var lastError error
+
+	hsms, err := c.armClient.List(ctx, path, apiVersion)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, r := range hsms {
+		if !c.hsmShouldBeDeleted(r) {
+			continue
+		}
+
+		res := run.Resource{
+			ID:        r.ID,
+			Type:      resourceType,
+			Region:    r.Location,
+			Tags:      r.Tags,
+			CreatedAt: r.SystemData.CreatedAt,
+			Cost:      "HSM pool, billed hourly until deleted and purged",
+		}
+
+		if !c.purgeHSMs {
+			c.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("found CI HSM %q, which is billed hourly and has to be deleted manually", r.ID))
+			c.run.Report(ctx, cleanerHSMs, res)
+			continue
+		}
+
+		c.logger.LogCtx(ctx, "level", "info", "message", fmt.Sprintf("HSM %q has to be deleted", r.ID))
+
+		r := r
+		err := c.run.DeleteResource(ctx, cleanerHSMs, res, func() error {
+			return deleteFn(ctx, r)
+		})
+		if err != nil {
+			c.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("failed to delete HSM %q", r.ID), "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
+			lastError = err
+			continue
+		}
+	}
+
+	if lastError != nil {
+		return microerror.Mask(lastError)
+	}
+
+	return nil
+}
+
+// deleteManagedHSM deletes the given managed HSM pool and purges it right
+// away, as soft-deleted pools are still billed.
+func (c Cleaner) deleteManagedHSM(ctx context.Context, r armResource) error {
+	err := c.armClient.Delete(ctx, r.ID, managedHSMAPIVersion)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	path := fmt.Sprintf("/subscriptions/%s/providers/Microsoft.KeyVault/locations/%s/deletedManagedHSMs/%s", c.armClient.SubscriptionID, r.Location, r.Name)
+	err = c.purgeDeletedManagedHSM(ctx, armResource{ID: path})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (c Cleaner) purgeDeletedManagedHSM(ctx context.Context, r armResource) error {
+	err := c.armClient.Post(ctx, r.ID+"/purge", managedHSMAPIVersion)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (c Cleaner) hsmShouldBeDeleted(r armResource) bool {
+	if !c.isCISoftDeletedResource(r.Name) {
+		return false
+	}
+
+	// do not delete recent HSMs. Soft-deleted HSMs do not carry a creation
+	// time and are never recent.
+	if !r.SystemData.CreatedAt.IsZero() && time.Since(r.SystemData.CreatedAt) < c.gracePeriod {
+		return false
+	}
+
+	return true
+}
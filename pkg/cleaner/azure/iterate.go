@@ -0,0 +1,51 @@
+package azure
+
+import (
+	"context"
+
+	"github.com/giantswarm/microerror"
+)
+
+// iterator is implemented by the iterators the ListComplete methods of the
+// Azure SDK clients return, which request the next page of values when
+// moving past the last value of the current page.
+type iterator interface {
+	NotDone() bool
+	NextWithContext(ctx context.Context) error
+}
+
+// iterate calls visit for every value of iter, which visit gets with
+// iter.Value(). Unlike Next, failing to request the next page is returned
+// instead of silently ending the iteration, and iterating stops as soon as
+// ctx is canceled. Throttled requests are retried by the Azure SDK itself,
+// honouring the Retry-After header of the API.
+//
+//	iter, err := c.groupsClient.ListComplete(ctx, "", nil)
+//	if err != nil {
+//		return microerror.Mask(err)
+//	}
+//	err = iterate(ctx, &iter, func() error {
+//		group := iter.Value()
+//		...
+//		return nil
+//	})
+func iterate(ctx context.Context, iter iterator, visit func() error) error {
+	for iter.NotDone() {
+		err := ctx.Err()
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		err = visit()
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		err = iter.NextWithContext(ctx)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
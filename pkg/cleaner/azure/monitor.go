@@ -0,0 +1,216 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/resource"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+const (
+	activityLogAlertsAPIVersion   = "2020-10-01"
+	diagnosticSettingsAPIVersion  = "2021-05-01-preview"
+	metricAlertsAPIVersion        = "2018-03-01"
+	scheduledQueryRulesAPIVersion = "2021-08-01"
+)
+
+// diagnosticSettingProperties holds the destinations of a diagnostic setting.
+type diagnosticSettingProperties struct {
+	EventHubAuthorizationRuleID string `json:"eventHubAuthorizationRuleId"`
+	StorageAccountID            string `json:"storageAccountId"`
+	WorkspaceID                 string `json:"workspaceId"`
+}
+
+// alertRuleProperties holds the resources an alert rule watches.
+type alertRuleProperties struct {
+	Scopes []string `json:"scopes"`
+}
+
+// cleanDiagnosticSettings deletes the subscription diagnostic settings CI
+// clusters create to export the activity log into their Log Analytics
+// workspace, storage account or event hub. Once the cluster resource group is
+// gone they keep failing, as none of their destinations exists anymore.
+func (c Cleaner) cleanDiagnosticSettings(ctx context.Context) error {
+	var lastError error
+
+	groups, err := c.resourceGroupNames(ctx)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	path := fmt.Sprintf("/subscriptions/%s/providers/Microsoft.Insights/diagnosticSettings", c.armClient.SubscriptionID)
+	settings, err := c.armClient.List(ctx, path, diagnosticSettingsAPIVersion)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, r := range settings {
+		var p diagnosticSettingProperties
+		err := json.Unmarshal(r.Properties, &p)
+		if err != nil {
+			c.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("failed to decode diagnostic setting %q", r.ID), "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
+			lastError = err
+			continue
+		}
+
+		dangling := allGone([]string{p.EventHubAuthorizationRuleID, p.StorageAccountID, p.WorkspaceID}, groups)
+		err = c.deleteMonitorResource(ctx, cleanerDiagnosticSettings, "Microsoft.Insights/diagnosticSettings", diagnosticSettingsAPIVersion, r, dangling)
+		if err != nil {
+			c.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("did not ensure deletion of diagnostic setting %q", r.ID), "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
+			lastError = err
+			continue
+		}
+	}
+
+	if lastError != nil {
+		return microerror.Mask(lastError)
+	}
+
+	return nil
+}
+
+// cleanAlertRules deletes the metric alerts, activity log alerts and log
+// search alerts of CI clusters, and the alert rules none of whose scopes
+// exists anymore. They are created in shared resource groups and outlive the
+// clusters they watch.
+func (c Cleaner) cleanAlertRules(ctx context.Context) error {
+	var lastError error
+
+	groups, err := c.resourceGroupNames(ctx)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	ruleTypes := []struct {
+		resourceType string
+		apiVersion   string
+	}{
+		{resourceType: "Microsoft.Insights/metricAlerts", apiVersion: metricAlertsAPIVersion},
+		{resourceType: "Microsoft.Insights/activityLogAlerts", apiVersion: activityLogAlertsAPIVersion},
+		{resourceType: "Microsoft.Insights/scheduledQueryRules", apiVersion: scheduledQueryRulesAPIVersion},
+	}
+
+	for _, t := range ruleTypes {
+		path := fmt.Sprintf("/subscriptions/%s/providers/%s", c.armClient.SubscriptionID, t.resourceType)
+		rules, err := c.armClient.List(ctx, path, t.apiVersion)
+		if err != nil {
+			c.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("failed to list %s", t.resourceType), "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
+			lastError = err
+			continue
+		}
+
+		for _, r := range rules {
+			var p alertRuleProperties
+			err := json.Unmarshal(r.Properties, &p)
+			if err != nil {
+				c.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("failed to decode alert rule %q", r.ID), "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
+				lastError = err
+				continue
+			}
+
+			err = c.deleteMonitorResource(ctx, cleanerAlertRules, t.resourceType, t.apiVersion, r, allGone(p.Scopes, groups))
+			if err != nil {
+				c.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("did not ensure deletion of alert rule %q", r.ID), "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
+				lastError = err
+				continue
+			}
+		}
+	}
+
+	if lastError != nil {
+		return microerror.Mask(lastError)
+	}
+
+	return nil
+}
+
+// deleteMonitorResource deletes the given diagnostic setting or alert rule if
+// it belongs to CI or all resources it refers to are gone, once it was first
+// found more than the grace period ago.
+func (c Cleaner) deleteMonitorResource(ctx context.Context, cleaner string, resourceType string, apiVersion string, r armResource, dangling bool) error {
+	if !dangling && !c.isCIResource(r.Name) && !c.isCreatedByCI(r.ID) && !c.isCITagged(r.Tags) {
+		return nil
+	}
+
+	seen, err := c.run.FirstSeen(cleaner, r.ID)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	// do not delete recent diagnostic settings and alert rules.
+	if time.Since(seen) < c.gracePeriod {
+		return nil
+	}
+
+	c.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("ensuring deletion of %s %q", resourceType, r.ID))
+
+	res := run.Resource{
+		ID:     r.ID,
+		Type:   resourceType,
+		Region: r.Location,
+		Tags:   r.Tags,
+	}
+	if dangling {
+		res.Reason = run.ReasonDangling
+	}
+	err = c.run.DeleteResource(ctx, cleaner, res, func() error {
+		return c.armClient.Delete(ctx, r.ID, apiVersion)
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// resourceGroupNames returns the lower case names of all resource groups of
+// the subscription, as resource IDs do not preserve their case reliably.
+func (c Cleaner) resourceGroupNames(ctx context.Context) (map[string]bool, error) {
+	groups := map[string]bool{}
+
+	iter, err := c.groupsClient.ListComplete(ctx, "", nil)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	err = iterate(ctx, &iter, func() error {
+		group := iter.Value()
+
+		if group.Name != nil {
+			groups[strings.ToLower(*group.Name)] = true
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return groups, nil
+}
+
+// allGone checks if all of the given resource IDs refer to resources whose
+// resource group does not exist anymore. Empty IDs are ignored, and IDs
+// outside of resource groups, like subscriptions, are considered to exist.
+func allGone(ids []string, groups map[string]bool) bool {
+	gone := false
+	for _, id := range ids {
+		if id == "" {
+			continue
+		}
+
+		parsed, err := resource.ParseARMID(id)
+		if err != nil || parsed.ResourceGroup == "" || groups[strings.ToLower(parsed.ResourceGroup)] {
+			return false
+		}
+		gone = true
+	}
+
+	return gone
+}
@@ -0,0 +1,89 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/preview/monitor/mgmt/2018-03-01/insights"
+	"github.com/giantswarm/microerror"
+)
+
+// principalLookback is how far back the activity log is searched for
+// resources created by CI principals.
+const principalLookback = 7 * 24 * time.Hour
+
+// loadCreatedByCI looks up the IDs of the resources and resource groups the
+// configured CI principals created according to the activity log, so that
+// they are treated as CI resources regardless of their name. Writing to an
+// existing resource, or to a resource in an existing group, does not make it
+// a CI resource.
+func (c *Cleaner) loadCreatedByCI(ctx context.Context) error {
+	if len(c.ciPrincipals) == 0 {
+		return nil
+	}
+
+	principals := map[string]bool{}
+	for _, p := range c.ciPrincipals {
+		principals[p] = true
+	}
+
+	createdByCI := map[string]bool{}
+
+	filter := fmt.Sprintf("eventTimestamp ge '%s'", time.Now().Add(-principalLookback).Format(time.RFC3339Nano))
+	eventIter, err := c.activityLogsClient.ListComplete(ctx, filter, "caller,operationName,resourceId,status,subStatus")
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = iterate(ctx, &eventIter, func() error {
+		event := eventIter.Value()
+		if event.Caller == nil || !principals[*event.Caller] || !isCreateEvent(event) {
+			return nil
+		}
+
+		createdByCI[strings.ToLower(*event.ResourceID)] = true
+
+		return nil
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	c.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("found %d resources created by CI principals", len(createdByCI)))
+
+	c.createdByCI = createdByCI
+
+	return nil
+}
+
+// isCreateEvent checks if the given activity log event is a write operation
+// which created its resource, as opposed to updating an existing one.
+func isCreateEvent(event insights.EventData) bool {
+	if event.ResourceID == nil {
+		return false
+	}
+	if !strings.HasSuffix(strings.ToLower(localizedValue(event.OperationName)), "/write") {
+		return false
+	}
+	if localizedValue(event.Status) == "Failed" {
+		return false
+	}
+
+	return localizedValue(event.SubStatus) == "Created"
+}
+
+// isCreatedByCI checks if the resource with the given ID was created by one
+// of the configured CI principals.
+func (c Cleaner) isCreatedByCI(id string) bool {
+	return c.createdByCI[strings.ToLower(id)]
+}
+
+func localizedValue(s *insights.LocalizableString) string {
+	if s == nil || s.Value == nil {
+		return ""
+	}
+
+	return *s.Value
+}
@@ -0,0 +1,107 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// cleanPrivateEndpoints deletes the private endpoints and private link
+// services CI clusters create in the shared virtual networks of the
+// installations, once the resource group of their CI cluster does not exist
+// anymore. They keep the subnets from being deleted and are billed hourly.
+// The private DNS zone groups of private endpoints are deleted first, which
+// removes their records from the private DNS zones.
+func (c Cleaner) cleanPrivateEndpoints(ctx context.Context) error {
+	groups, err := c.ciResourceGroups(ctx)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	var lastError error
+
+	path := fmt.Sprintf("/subscriptions/%s/providers/Microsoft.Network/privateEndpoints", c.armClient.SubscriptionID)
+	endpoints, err := c.armClient.List(ctx, path, networkAPIVersion)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, r := range endpoints {
+		if !c.networkResourceShouldBeDeleted(r, groups) {
+			continue
+		}
+
+		err := c.deletePrivateEndpoint(ctx, r)
+		if err != nil {
+			c.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("did not ensure deletion of private endpoint %q", r.ID), "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
+			lastError = err
+			continue
+		}
+	}
+
+	path = fmt.Sprintf("/subscriptions/%s/providers/Microsoft.Network/privateLinkServices", c.armClient.SubscriptionID)
+	services, err := c.armClient.List(ctx, path, networkAPIVersion)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, r := range services {
+		if !c.networkResourceShouldBeDeleted(r, groups) {
+			continue
+		}
+
+		err := c.deleteARMResource(ctx, cleanerPrivateEndpoints, "Microsoft.Network/privateLinkServices", networkAPIVersion, r)
+		if err != nil {
+			c.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("did not ensure deletion of private link service %q", r.ID), "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
+			lastError = err
+			continue
+		}
+	}
+
+	if lastError != nil {
+		return microerror.Mask(lastError)
+	}
+
+	return nil
+}
+
+// deletePrivateEndpoint deletes the private DNS zone groups of the given
+// private endpoint and starts deleting the private endpoint itself, or checks
+// whether a deletion started by a previous run finished.
+func (c Cleaner) deletePrivateEndpoint(ctx context.Context, r armResource) error {
+	c.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("ensuring deletion of private endpoint %q", r.ID))
+
+	res := run.Resource{
+		ID:        r.ID,
+		Type:      "Microsoft.Network/privateEndpoints",
+		Region:    r.Location,
+		Tags:      r.Tags,
+		CreatedAt: r.SystemData.CreatedAt,
+	}
+	start := func() (string, error) {
+		zoneGroups, err := c.armClient.List(ctx, r.ID+"/privateDnsZoneGroups", networkAPIVersion)
+		if err != nil {
+			return "", microerror.Mask(err)
+		}
+
+		for _, g := range zoneGroups {
+			c.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("deleting private dns zone group %q and its records", g.ID))
+
+			err := c.armClient.Delete(ctx, g.ID, networkAPIVersion)
+			if err != nil {
+				return "", microerror.Mask(err)
+			}
+		}
+
+		return c.armClient.DeleteAsync(ctx, r.ID, networkAPIVersion)
+	}
+	err := c.run.DeleteResourceAsync(ctx, cleanerPrivateEndpoints, res, start, c.armClient.Poll)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,236 @@
+package azure
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/adal"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/giantswarm/microerror"
+)
+
+// RegistryClient is a thin client of the data plane of Azure Container
+// Registries, which is not covered by the SDK API versions we vendor. Azure
+// AD tokens are not accepted by the data plane, but exchanged for registry
+// tokens scoped to a single repository, see Repository.
+type RegistryClient struct {
+	autorest.Client
+
+	TenantID string
+	// Token is the Azure AD token of the service principal, issued for the
+	// resource manager.
+	Token *adal.ServicePrincipalToken
+}
+
+// NewRegistryClient creates a RegistryClient authenticating with the given
+// Azure AD token of a service principal of the given tenant.
+func NewRegistryClient(tenantID string, token *adal.ServicePrincipalToken) RegistryClient {
+	return RegistryClient{
+		Client:   autorest.NewClientWithUserAgent("ci-cleaner"),
+		TenantID: tenantID,
+		Token:    token,
+	}
+}
+
+// registryRepository is a repository of a registry along with a token
+// allowing to read and delete its manifests.
+type registryRepository struct {
+	client   RegistryClient
+	registry string
+	name     string
+	token    string
+}
+
+// registryManifest is the part of the attributes of a manifest we care
+// about.
+type registryManifest struct {
+	Digest      string    `json:"digest"`
+	CreatedTime time.Time `json:"createdTime"`
+	Tags        []string  `json:"tags"`
+}
+
+// Repository returns the repository with the given name of the registry with
+// the given login server, e.g. `gsoci.azurecr.io`, authorized to read and
+// delete its manifests.
+func (c RegistryClient) Repository(ctx context.Context, registry string, name string) (registryRepository, error) {
+	err := c.Token.EnsureFreshWithContext(ctx)
+	if err != nil {
+		return registryRepository{}, microerror.Mask(err)
+	}
+
+	var refresh struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	err = c.postForm(ctx, registry, "/oauth2/exchange", url.Values{
+		"access_token": {c.Token.OAuthToken()},
+		"grant_type":   {"access_token"},
+		"service":      {registry},
+		"tenant":       {c.TenantID},
+	}, &refresh)
+	if err != nil {
+		return registryRepository{}, microerror.Mask(err)
+	}
+
+	var access struct {
+		AccessToken string `json:"access_token"`
+	}
+	err = c.postForm(ctx, registry, "/oauth2/token", url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refresh.RefreshToken},
+		"scope":         {"repository:" + name + ":metadata_read,delete"},
+		"service":       {registry},
+	}, &access)
+	if err != nil {
+		return registryRepository{}, microerror.Mask(err)
+	}
+
+	r := registryRepository{
+		client:   c,
+		registry: registry,
+		name:     name,
+		token:    access.AccessToken,
+	}
+
+	return r, nil
+}
+
+// Manifests returns the attributes of all manifests of the repository,
+// following pagination links.
+func (r registryRepository) Manifests(ctx context.Context) ([]registryManifest, error) {
+	var manifests []registryManifest
+
+	preparer := autorest.CreatePreparer(
+		autorest.AsGet(),
+		autorest.WithBaseURL("https://"+r.registry),
+		autorest.WithPath("/acr/v1/"+r.name+"/_manifests"),
+		autorest.WithBearerAuthorization(r.token),
+	)
+
+	for {
+		req, err := preparer.Prepare((&http.Request{}).WithContext(ctx))
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		resp, err := r.client.Send(req)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		var page struct {
+			Manifests []registryManifest `json:"manifests"`
+		}
+		err = autorest.Respond(
+			resp,
+			r.client.ByInspecting(),
+			azure.WithErrorUnlessStatusCode(http.StatusOK),
+			autorest.ByUnmarshallingJSON(&page),
+			autorest.ByClosing(),
+		)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		manifests = append(manifests, page.Manifests...)
+
+		next := nextLink(resp.Header.Get("Link"))
+		if next == "" {
+			break
+		}
+
+		preparer = autorest.CreatePreparer(
+			autorest.AsGet(),
+			autorest.WithBaseURL("https://"+r.registry+next),
+			autorest.WithBearerAuthorization(r.token),
+		)
+	}
+
+	return manifests, nil
+}
+
+// DeleteManifest deletes the manifest with the given digest along with all
+// its tags. Manifests which do not exist anymore are not considered an
+// error.
+func (r registryRepository) DeleteManifest(ctx context.Context, digest string) error {
+	preparer := autorest.CreatePreparer(
+		autorest.AsDelete(),
+		autorest.WithBaseURL("https://"+r.registry),
+		autorest.WithPath("/v2/"+r.name+"/manifests/"+digest),
+		autorest.WithBearerAuthorization(r.token),
+	)
+
+	req, err := preparer.Prepare((&http.Request{}).WithContext(ctx))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	resp, err := r.client.Send(req)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = autorest.Respond(
+		resp,
+		r.client.ByInspecting(),
+		azure.WithErrorUnlessStatusCode(http.StatusOK, http.StatusAccepted, http.StatusNotFound),
+		autorest.ByClosing(),
+	)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (c RegistryClient) postForm(ctx context.Context, registry string, path string, form url.Values, v interface{}) error {
+	preparer := autorest.CreatePreparer(
+		autorest.AsPost(),
+		autorest.WithBaseURL("https://"+registry),
+		autorest.WithPath(path),
+		autorest.WithFormData(form),
+	)
+
+	req, err := preparer.Prepare((&http.Request{}).WithContext(ctx))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	resp, err := c.Send(req)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = autorest.Respond(
+		resp,
+		c.ByInspecting(),
+		azure.WithErrorUnlessStatusCode(http.StatusOK),
+		autorest.ByUnmarshallingJSON(v),
+		autorest.ByClosing(),
+	)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// nextLink returns the path of the next page of the given Link header, like
+// `</acr/v1/charts/app/_manifests?last=sha256:...&n=100>; rel="next"`, or an
+// empty string when there is none.
+func nextLink(header string) string {
+	if !strings.Contains(header, `rel="next"`) {
+		return ""
+	}
+
+	start := strings.Index(header, "<")
+	end := strings.Index(header, ">")
+	if start < 0 || end < start {
+		return ""
+	}
+
+	return header[start+1 : end]
+}
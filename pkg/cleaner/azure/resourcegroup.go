@@ -8,13 +8,10 @@ import (
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2018-02-01/resources"
+	"github.com/Azure/go-autorest/autorest/to"
 	"github.com/giantswarm/microerror"
-)
 
-const (
-	// gracePeriod represents the maximum time the CI resources are allowed to
-	// remain up. CI resources older than gracePeriod will be deleted.
-	gracePeriod = 90 * time.Minute
+	"github.com/giantswarm/ci-cleaner/pkg/run"
 )
 
 func (c Cleaner) cleanResourceGroup(ctx context.Context) error {
@@ -27,9 +24,9 @@ func (c Cleaner) cleanResourceGroup(ctx context.Context) error {
 		return microerror.Mask(err)
 	}
 
-	deadLine := time.Now().Add(-gracePeriod).UTC()
+	deadLine := time.Now().Add(-c.gracePeriod).UTC()
 
-	for ; groupIter.NotDone(); groupIter.Next() {
+	err = iterate(ctx, &groupIter, func() error {
 		group := groupIter.Value()
 
 		c.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("check resource group %q", *group.Name))
@@ -39,30 +36,32 @@ func (c Cleaner) cleanResourceGroup(ctx context.Context) error {
 			c.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("failed to check resource group %q", *group.Name), "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
 			c.logger.LogCtx(ctx, "level", "debug", "message", "skipping")
 			lastError = err
-			continue
+			return nil
 		}
 
 		if shouldBeDeleted {
 			c.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("ensuring deletion of resource group %q", *group.Name))
 
-			respFuture, err := c.groupsClient.Delete(ctx, *group.Name)
-			if err != nil {
-				c.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("did not ensure deletion for resource group %q ", *group.Name), "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
-				lastError = err
-				continue
+			res := run.Resource{
+				ID:     *group.Name,
+				Type:   "Microsoft.Resources/resourceGroups",
+				Region: to.String(group.Location),
+				Tags:   to.StringMap(group.Tags),
 			}
-
-			res, err := c.groupsClient.DeleteResponder(respFuture.Response())
-			if res.Response != nil && res.StatusCode == http.StatusNotFound {
-				// fall through
-			} else if err != nil {
+			err := c.run.DeleteResource(ctx, cleanerResourceGroups, res, func() error {
+				return c.deleteResourceGroup(ctx, *group.Name)
+			})
+			if err != nil {
 				c.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("did not ensure deletion for resource group %q ", *group.Name), "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
 				lastError = err
-				continue
+				return nil
 			}
-
-			c.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("ensured deletion of resource group %q", *group.Name))
 		}
+
+		return nil
+	})
+	if err != nil {
+		return microerror.Mask(err)
 	}
 
 	if lastError != nil {
@@ -72,8 +71,26 @@ func (c Cleaner) cleanResourceGroup(ctx context.Context) error {
 	return nil
 }
 
+func (c Cleaner) deleteResourceGroup(ctx context.Context, name string) error {
+	respFuture, err := c.groupsClient.Delete(ctx, name)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	res, err := c.groupsClient.DeleteResponder(respFuture.Response())
+	if res.Response != nil && res.StatusCode == http.StatusNotFound {
+		// fall through
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	c.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("ensured deletion of resource group %q", name))
+
+	return nil
+}
+
 func (c Cleaner) groupShouldBeDeleted(ctx context.Context, group resources.Group, since time.Time) (bool, error) {
-	if !isCIResource(*group.Name) && !isTerraformCIResourceGroup(*group.Name) {
+	if !c.isCIResource(*group.Name) && !c.isCreatedByCI(to.String(group.ID)) && !isTerraformCIResourceGroup(*group.Name) {
 		return false, nil
 	}
 
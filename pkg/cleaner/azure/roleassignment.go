@@ -0,0 +1,219 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/report"
+	"github.com/giantswarm/ci-cleaner/pkg/resource"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+const roleAssignmentsAPIVersion = "2022-04-01"
+
+// roleAssignmentProperties are the properties of a role assignment we care
+// about.
+type roleAssignmentProperties struct {
+	PrincipalID      string `json:"principalId"`
+	RoleDefinitionID string `json:"roleDefinitionId"`
+	Scope            string `json:"scope"`
+
+	CreatedOn     time.Time `json:"createdOn"`
+	PrincipalType string    `json:"principalType"`
+}
+
+// listRoleAssignments records the role assignments of the subscription for
+// the role assignment drift of the run.
+func (c Cleaner) listRoleAssignments(ctx context.Context) ([]report.RoleAssignment, error) {
+	p := fmt.Sprintf("/subscriptions/%s/providers/Microsoft.Authorization/roleAssignments", c.armClient.SubscriptionID)
+	resources, err := c.armClient.List(ctx, p, roleAssignmentsAPIVersion)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var assignments []report.RoleAssignment
+	for _, r := range resources {
+		var props roleAssignmentProperties
+		err := json.Unmarshal(r.Properties, &props)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		a := report.RoleAssignment{
+			ID:               r.ID,
+			PrincipalID:      props.PrincipalID,
+			RoleDefinitionID: props.RoleDefinitionID,
+			Scope:            props.Scope,
+			CIScoped:         c.isCIScope(props.Scope),
+		}
+		assignments = append(assignments, a)
+	}
+
+	return assignments, nil
+}
+
+// isCIScope checks if the given role assignment scope is a CI resource group
+// or a resource in one, or a CI resource itself.
+func (c Cleaner) isCIScope(scope string) bool {
+	id, err := resource.ParseARMID(scope)
+	if err != nil || id.ResourceGroup == "" {
+		return false
+	}
+	if c.isCIResource(id.ResourceGroup) || c.isCreatedByCI(fmt.Sprintf("/subscriptions/%s/resourceGroups/%s", id.Account, id.ResourceGroup)) {
+		return true
+	}
+
+	return c.isCIResource(id.Name) || c.isCreatedByCI(scope)
+}
+
+// addRoleAssignmentDrift adds the difference between the given role
+// assignments recorded before the cleanup and the current ones to the report.
+// Removed assignments which are not scoped to CI resources are called out,
+// as the cleaner is not supposed to touch them.
+func (c Cleaner) addRoleAssignmentDrift(ctx context.Context, before []report.RoleAssignment) error {
+	after, err := c.listRoleAssignments(ctx)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	d := report.NewRoleAssignmentDrift(before, after)
+	c.run.SetRoleAssignmentDrift(d)
+
+	c.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("found %d removed and %d added role assignments", len(d.Removed), len(d.Added)))
+
+	for _, a := range d.Removed {
+		if a.CIScoped {
+			continue
+		}
+
+		c.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("found that role assignment %q outside of CI scope was removed during cleanup", a.ID))
+	}
+
+	return nil
+}
+
+// cleanRoleAssignments deletes the role assignments of the subscription whose
+// scope is a CI resource group, or a resource in one, which does not exist
+// anymore. When deleting orphaned role assignments is enabled, the role
+// assignments of principals which do not exist anymore, shown as "Identity
+// not found", are deleted as well, as long as they are not inherited from
+// outside of the subscription. Assignments of principals of other tenants
+// cannot be looked up and are kept.
+func (c Cleaner) cleanRoleAssignments(ctx context.Context) error {
+	var lastError error
+
+	groups, err := c.resourceGroupNames(ctx)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	p := fmt.Sprintf("/subscriptions/%s/providers/Microsoft.Authorization/roleAssignments", c.armClient.SubscriptionID)
+	resources, err := c.armClient.List(ctx, p, roleAssignmentsAPIVersion)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	properties := map[string]roleAssignmentProperties{}
+	for _, r := range resources {
+		var props roleAssignmentProperties
+		err := json.Unmarshal(r.Properties, &props)
+		if err != nil {
+			c.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("failed to decode role assignment %q", r.ID), "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
+			lastError = err
+			continue
+		}
+		properties[r.ID] = props
+	}
+
+	// existing holds the principals which exist, when orphaned role
+	// assignments are deleted.
+	var existing map[string]bool
+	if c.deleteOrphanedRoleAssignments {
+		seen := map[string]bool{}
+		var ids []string
+		for _, props := range properties {
+			if props.PrincipalID != "" && !seen[props.PrincipalID] {
+				seen[props.PrincipalID] = true
+				ids = append(ids, props.PrincipalID)
+			}
+		}
+
+		existing, err = c.graphClient.ExistingObjects(ctx, ids)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	for _, r := range resources {
+		props, ok := properties[r.ID]
+		if !ok {
+			continue
+		}
+
+		why, ok := c.roleAssignmentShouldBeDeleted(props, groups, existing)
+		if !ok {
+			continue
+		}
+
+		c.logger.LogCtx(ctx, "level", "info", "message", fmt.Sprintf("role assignment %q has to be deleted as %s", r.ID, why))
+
+		res := run.Resource{
+			ID:        r.ID,
+			Type:      "Microsoft.Authorization/roleAssignments",
+			CreatedAt: props.CreatedOn,
+			Note:      why,
+			Reason:    run.ReasonDangling,
+		}
+		id := r.ID
+		err := c.run.DeleteResource(ctx, cleanerRoleAssignments, res, func() error {
+			return c.armClient.Delete(ctx, id, roleAssignmentsAPIVersion)
+		})
+		if err != nil {
+			c.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("failed to delete role assignment %q", r.ID), "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
+			lastError = err
+			continue
+		}
+	}
+
+	if lastError != nil {
+		return microerror.Mask(lastError)
+	}
+
+	return nil
+}
+
+// roleAssignmentShouldBeDeleted checks if the role assignment with the given
+// properties is older than the grace period and dangling, and tells why. The
+// given existing principals are nil unless orphaned role assignments are
+// deleted.
+func (c Cleaner) roleAssignmentShouldBeDeleted(props roleAssignmentProperties, groups map[string]bool, existing map[string]bool) (string, bool) {
+	// do not delete recent role assignments, whose principals may not be
+	// replicated yet.
+	if props.CreatedOn.IsZero() || time.Since(props.CreatedOn) < c.gracePeriod {
+		return "", false
+	}
+
+	if c.isCIScope(props.Scope) && allGone([]string{props.Scope}, groups) {
+		return "its CI resource group does not exist anymore", true
+	}
+
+	if existing == nil || props.PrincipalID == "" || existing[props.PrincipalID] {
+		return "", false
+	}
+	if props.PrincipalType == "ForeignGroup" {
+		return "", false
+	}
+	// role assignments inherited from management groups are listed as
+	// well, but cannot be deleted here.
+	subscription := "/subscriptions/" + strings.ToLower(c.armClient.SubscriptionID)
+	if !strings.HasPrefix(strings.ToLower(props.Scope), subscription) {
+		return "", false
+	}
+
+	return fmt.Sprintf("its %s principal %s does not exist anymore", strings.ToLower(props.PrincipalType), props.PrincipalID), true
+}
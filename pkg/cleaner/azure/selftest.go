@@ -0,0 +1,59 @@
+package azure
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2018-02-01/resources"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/owner"
+	"github.com/giantswarm/ci-cleaner/pkg/selftest"
+)
+
+// SelfTestProbes returns the probes of the self-test, which create canary
+// resources the cleaners are expected to delete: an empty resource group
+// tagged like the resource groups of CI clusters.
+func (c *Cleaner) SelfTestProbes() []selftest.Probe {
+	probes := []selftest.Probe{
+		&resourceGroupProbe{cleaner: c},
+	}
+
+	return probes
+}
+
+type resourceGroupProbe struct {
+	cleaner *Cleaner
+}
+
+func (p *resourceGroupProbe) Name() string {
+	return "resource-group"
+}
+
+func (p *resourceGroupProbe) Create(ctx context.Context, name string) (string, error) {
+	group := resources.Group{
+		Location: to.StringPtr(p.cleaner.azureLocation),
+		Tags: map[string]*string{
+			owner.ClusterTag: to.StringPtr(name),
+		},
+	}
+
+	_, err := p.cleaner.groupsClient.CreateOrUpdate(ctx, name, group)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return name, nil
+}
+
+func (p *resourceGroupProbe) Exists(ctx context.Context, id string) (bool, error) {
+	res, err := p.cleaner.groupsClient.CheckExistence(ctx, id)
+	if res.Response != nil && res.StatusCode == http.StatusNotFound {
+		return false, nil
+	} else if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	return true, nil
+}
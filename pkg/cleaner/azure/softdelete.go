@@ -0,0 +1,145 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	apiManagementAPIVersion     = "2021-08-01"
+	cognitiveServicesAPIVersion = "2021-10-01"
+)
+
+// cleanSoftDeleted purges soft-deleted CI resources. Key Vaults, API
+// Management services and Cognitive Services accounts are kept in a deleted
+// state after their resource group is gone and block the reuse of their
+// globally unique names, which breaks reruns of the same CI pipeline.
+func (c Cleaner) cleanSoftDeleted(ctx context.Context) error {
+	var lastError error
+
+	err := c.purgeDeletedVaults(ctx)
+	if err != nil {
+		c.logger.LogCtx(ctx, "level", "error", "message", "failed to purge soft-deleted key vaults", "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
+		lastError = err
+	}
+
+	path := fmt.Sprintf("/subscriptions/%s/providers/Microsoft.ApiManagement/deletedservices", c.armClient.SubscriptionID)
+	err = c.purgeDeletedResources(ctx, path, apiManagementAPIVersion)
+	if err != nil {
+		c.logger.LogCtx(ctx, "level", "error", "message", "failed to purge soft-deleted api management services", "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
+		lastError = err
+	}
+
+	path = fmt.Sprintf("/subscriptions/%s/providers/Microsoft.CognitiveServices/deletedAccounts", c.armClient.SubscriptionID)
+	err = c.purgeDeletedResources(ctx, path, cognitiveServicesAPIVersion)
+	if err != nil {
+		c.logger.LogCtx(ctx, "level", "error", "message", "failed to purge soft-deleted cognitive services accounts", "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
+		lastError = err
+	}
+
+	if lastError != nil {
+		return microerror.Mask(lastError)
+	}
+
+	return nil
+}
+
+func (c Cleaner) purgeDeletedVaults(ctx context.Context) error {
+	var lastError error
+
+	iter, err := c.vaultsClient.ListDeletedComplete(ctx)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = iterate(ctx, &iter, func() error {
+		vault := iter.Value()
+
+		if vault.ID == nil || vault.Name == nil || vault.Properties == nil || vault.Properties.Location == nil {
+			return nil
+		}
+		if !c.isCISoftDeletedResource(*vault.Name) {
+			return nil
+		}
+
+		c.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("ensuring purge of soft-deleted key vault %q", *vault.Name))
+
+		err := c.run.Delete(ctx, cleanerSoftDeleted, *vault.ID, func() error {
+			_, err := c.vaultsClient.PurgeDeleted(ctx, *vault.Name, *vault.Properties.Location)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			c.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("ensured purge of soft-deleted key vault %q", *vault.Name))
+
+			return nil
+		})
+		if err != nil {
+			c.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("did not ensure purge of soft-deleted key vault %q", *vault.Name), "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
+			lastError = err
+			return nil
+		}
+
+		return nil
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if lastError != nil {
+		return microerror.Mask(lastError)
+	}
+
+	return nil
+}
+
+// purgeDeletedResources purges the CI resources of a soft-deleted resource
+// collection. The IDs of soft-deleted resources point to the deleted resource
+// itself, so deleting them purges the resource.
+func (c Cleaner) purgeDeletedResources(ctx context.Context, path string, apiVersion string) error {
+	var lastError error
+
+	deleted, err := c.armClient.List(ctx, path, apiVersion)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, r := range deleted {
+		if !c.isCISoftDeletedResource(r.Name) {
+			continue
+		}
+
+		c.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("ensuring purge of soft-deleted resource %q", r.ID))
+
+		err := c.run.Delete(ctx, cleanerSoftDeleted, r.ID, func() error {
+			err := c.armClient.Delete(ctx, r.ID, apiVersion)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			c.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("ensured purge of soft-deleted resource %q", r.ID))
+
+			return nil
+		})
+		if err != nil {
+			c.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("did not ensure purge of soft-deleted resource %q", r.ID), "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
+			lastError = err
+			continue
+		}
+	}
+
+	if lastError != nil {
+		return microerror.Mask(lastError)
+	}
+
+	return nil
+}
+
+// isCISoftDeletedResource checks if the name of a soft-deleted resource
+// belongs to a resource created by a CI pipeline.
+func (c Cleaner) isCISoftDeletedResource(s string) bool {
+	return c.isCIResource(s) || strings.HasPrefix(s, "e2e")
+}
@@ -0,0 +1,188 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+	"github.com/giantswarm/ci-cleaner/pkg/tabledata"
+)
+
+// cosmosRun is the data a single test run left in a shared Cosmos DB
+// container along with its documents.
+type cosmosRun struct {
+	tabledata.Run
+
+	documents []cosmosDocument
+}
+
+// cleanTableData deletes the documents tests write into the configured
+// shared Cosmos DB containers, keyed by the ID of their run, once the run is
+// past the table data retention. Unless deleting table data is enabled, the
+// runs are only reported.
+func (c Cleaner) cleanTableData(ctx context.Context) error {
+	var lastError error
+
+	for _, t := range c.tables {
+		err := c.cleanContainer(ctx, t)
+		if err != nil {
+			c.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("failed to clean data of container %q", t.Name), "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
+			lastError = err
+			continue
+		}
+	}
+
+	if lastError != nil {
+		return microerror.Mask(lastError)
+	}
+
+	return nil
+}
+
+// cleanContainer cleans the given container, which is named like
+// `<account>/<database>/<container>`.
+func (c Cleaner) cleanContainer(ctx context.Context, t tabledata.Table) error {
+	var lastError error
+
+	parts := strings.Split(t.Name, "/")
+	if len(parts) != 3 {
+		return microerror.Maskf(invalidConfigError, "table %q must be named like <account>/<database>/<container>", t.Name)
+	}
+
+	container, err := c.cosmosClient.Container(ctx, parts[0], parts[1], parts[2])
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	query, parameters := cosmosTableDataQuery(t, container.partitionKeyPath)
+	documents, err := container.Query(ctx, query, parameters)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	runs := cosmosRuns(t, documents)
+
+	var keys []string
+	for k := range runs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		r := runs[k]
+
+		id := t.Name + "/" + r.Key
+		seen, err := c.run.FirstSeen(cleanerTableData, id)
+		if err != nil {
+			lastError = err
+			continue
+		}
+		if !r.Expired(c.tableDataRetention, seen) {
+			continue
+		}
+
+		res := run.Resource{
+			ID:        id,
+			Type:      "Microsoft.DocumentDB/databaseAccounts/sqlDatabases/containers/documents",
+			CreatedAt: r.LastWrite,
+			Note:      fmt.Sprintf("%d items", r.Items),
+		}
+
+		if !c.deleteTableData {
+			c.logger.LogCtx(ctx, "level", "info", "message", fmt.Sprintf("found %d documents of run %q in container %q past their retention, which are only reported as deleting table data is disabled", r.Items, r.Key, t.Name))
+			c.run.Report(ctx, cleanerTableData, res)
+			continue
+		}
+
+		c.logger.LogCtx(ctx, "level", "info", "message", fmt.Sprintf("%d documents of run %q in container %q have to be deleted", r.Items, r.Key, t.Name))
+
+		docs := r.documents
+		err = c.run.DeleteResource(ctx, cleanerTableData, res, func() error {
+			for _, d := range docs {
+				err := container.DeleteDocument(ctx, d.ID, d.PartitionKey)
+				if err != nil {
+					return microerror.Mask(err)
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			c.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("failed to delete documents of run %q in container %q", r.Key, t.Name), "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
+			lastError = err
+			continue
+		}
+	}
+
+	if lastError != nil {
+		return microerror.Mask(lastError)
+	}
+
+	return nil
+}
+
+// cosmosTableDataQuery returns the query of the documents of the given table
+// whose key attribute has one of its key prefixes along with its parameters.
+// The key attribute defaults to the given partition key path. Documents
+// always tell when they were last modified in `_ts`, which is used unless a
+// timestamp attribute is configured.
+func cosmosTableDataQuery(t tabledata.Table, partitionKeyPath string) (string, map[string]string) {
+	key := t.KeyAttribute
+	if key == "" {
+		key = partitionKeyPath
+	}
+
+	written := "c._ts"
+	if t.TimestampAttribute != "" {
+		written = cosmosPath(t.TimestampAttribute)
+	}
+
+	partitionKey := "null"
+	if partitionKeyPath != "" {
+		partitionKey = cosmosPath(partitionKeyPath)
+	}
+
+	parameters := map[string]string{}
+	var conditions []string
+	for i, prefix := range t.KeyPrefixes {
+		p := fmt.Sprintf("@p%d", i)
+		parameters[p] = prefix
+		conditions = append(conditions, fmt.Sprintf("STARTSWITH(%s, %s)", cosmosPath(key), p))
+	}
+
+	query := fmt.Sprintf(
+		"SELECT c.id, %s AS runKey, %s AS partitionKey, %s AS written FROM c WHERE %s",
+		cosmosPath(key), partitionKey, written, strings.Join(conditions, " OR "),
+	)
+
+	return query, parameters
+}
+
+// cosmosRuns groups the given documents by their run ID. Documents whose run
+// ID does not have one of the key prefixes of the table are skipped, even
+// though the query filters them already.
+func cosmosRuns(t tabledata.Table, documents []cosmosDocument) map[string]*cosmosRun {
+	runs := map[string]*cosmosRun{}
+	for _, d := range documents {
+		key, ok := d.RunKey.(string)
+		if !ok || d.ID == "" || !t.HasKeyPrefix(key) {
+			continue
+		}
+
+		r, ok := runs[key]
+		if !ok {
+			r = &cosmosRun{Run: tabledata.Run{Key: key}}
+			runs[key] = r
+		}
+		r.documents = append(r.documents, d)
+
+		written, _ := tabledata.ParseTimestamp(cosmosValue(d.Written))
+		r.Add(written)
+	}
+
+	return runs
+}
@@ -0,0 +1,142 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	networkAPIVersion = "2022-07-01"
+)
+
+// cleanVirtualNetworkGateways deletes the virtual network gateways,
+// ExpressRoute circuits and public IP addresses VPN e2e tests leak in the
+// resource groups of the installations. They are deleted once the resource
+// group of their CI cluster does not exist anymore. Deleting a gateway takes
+// 30 minutes and more and is tracked by later runs. The public IP addresses
+// of gateways can only be deleted once the gateway is gone.
+func (c Cleaner) cleanVirtualNetworkGateways(ctx context.Context) error {
+	groups, err := c.ciResourceGroups(ctx)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	var lastError error
+
+	var gateways []armResource
+	for _, i := range c.installations {
+		path := fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Network/virtualNetworkGateways", c.armClient.SubscriptionID, i)
+		l, err := c.armClient.List(ctx, path, networkAPIVersion)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		gateways = append(gateways, l...)
+	}
+
+	for _, r := range gateways {
+		if !c.networkResourceShouldBeDeleted(r, groups) {
+			continue
+		}
+
+		c.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("deleting virtual network gateway %q, which takes 30 minutes and more", r.ID))
+
+		err := c.deleteARMResource(ctx, cleanerVirtualNetworkGateways, "Microsoft.Network/virtualNetworkGateways", networkAPIVersion, r)
+		if err != nil {
+			c.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("did not ensure deletion of virtual network gateway %q", r.ID), "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
+			lastError = err
+			continue
+		}
+	}
+
+	for _, t := range []string{"Microsoft.Network/expressRouteCircuits", "Microsoft.Network/publicIPAddresses"} {
+		path := fmt.Sprintf("/subscriptions/%s/providers/%s", c.armClient.SubscriptionID, t)
+		resources, err := c.armClient.List(ctx, path, networkAPIVersion)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		for _, r := range resources {
+			if !c.networkResourceShouldBeDeleted(r, groups) {
+				continue
+			}
+
+			err := c.deleteARMResource(ctx, cleanerVirtualNetworkGateways, t, networkAPIVersion, r)
+			if err != nil {
+				c.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("did not ensure deletion of resource %q", r.ID), "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
+				lastError = err
+				continue
+			}
+		}
+	}
+
+	if lastError != nil {
+		return microerror.Mask(lastError)
+	}
+
+	return nil
+}
+
+// ciResourceGroups returns the names of the existing resource groups of CI
+// clusters.
+func (c Cleaner) ciResourceGroups(ctx context.Context) ([]string, error) {
+	var groups []string
+
+	iter, err := c.groupsClient.ListComplete(ctx, "", nil)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	err = iterate(ctx, &iter, func() error {
+		group := iter.Value()
+
+		if group.Name != nil && (c.isCIResource(*group.Name) || c.isCreatedByCI(to.String(group.ID))) {
+			groups = append(groups, *group.Name)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return groups, nil
+}
+
+// networkResourceShouldBeDeleted checks if the given network resource belongs
+// to a CI cluster whose resource group is gone. Public IP addresses are only
+// deleted when they are not associated anymore.
+func (c Cleaner) networkResourceShouldBeDeleted(r armResource, groups []string) bool {
+	cluster := r.Tags[clusterTag]
+	if !c.isCIResource(r.Name) && !c.isCreatedByCI(r.ID) && !c.isCITagged(r.Tags) {
+		return false
+	}
+
+	for _, g := range groups {
+		if strings.HasPrefix(r.Name, g) || cluster == g {
+			return false
+		}
+	}
+
+	// do not delete recent resources.
+	if !r.SystemData.CreatedAt.IsZero() && time.Since(r.SystemData.CreatedAt) < c.gracePeriod {
+		return false
+	}
+
+	if len(r.Properties) != 0 {
+		var properties struct {
+			IPConfiguration *json.RawMessage `json:"ipConfiguration"`
+		}
+		err := json.Unmarshal(r.Properties, &properties)
+		if err != nil || properties.IPConfiguration != nil {
+			return false
+		}
+	}
+
+	return true
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-12-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
 	"github.com/giantswarm/microerror"
 )
 
@@ -21,7 +22,7 @@ func (c Cleaner) cleanVirtualNetworkPeering(ctx context.Context) error {
 		for {
 			for _, v := range r.Values() {
 				for _, p := range *v.VirtualNetworkPeerings {
-					if !isCIResource(*p.Name) {
+					if !c.isCIResource(*p.Name) && !c.isCreatedByCI(to.String(p.ID)) {
 						continue
 					}
 
@@ -29,14 +30,22 @@ func (c Cleaner) cleanVirtualNetworkPeering(ctx context.Context) error {
 					if IsResourceGroupNotFound(err) && p.PeeringState == network.VirtualNetworkPeeringStateDisconnected {
 						c.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("deleting vnet peering '%s'", *p.Name))
 
-						_, err := c.virtualNetworkPeeringsClient.Delete(ctx, i, *v.Name, *p.Name)
+						err := c.run.Delete(ctx, cleanerVirtualNetworkPeerings, *p.Name, func() error {
+							_, err := c.virtualNetworkPeeringsClient.Delete(ctx, i, *v.Name, *p.Name)
+							if err != nil {
+								return microerror.Mask(err)
+							}
+
+							c.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("deleted vnet peering '%s'", *p.Name))
+
+							time.Sleep(1 * time.Second)
+
+							return nil
+						})
 						if err != nil {
 							return microerror.Mask(err)
 						}
 
-						c.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("deleted vnet peering '%s'", *p.Name))
-
-						time.Sleep(1 * time.Second)
 						continue
 					} else if err != nil {
 						return microerror.Mask(err)
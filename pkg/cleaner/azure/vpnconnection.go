@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/Azure/go-autorest/autorest/to"
 	"github.com/giantswarm/microerror"
 )
 
@@ -18,12 +19,17 @@ func (c Cleaner) cleanVPNConnection(ctx context.Context) error {
 		return microerror.Mask(err)
 	}
 
-	for ; groupIter.NotDone(); groupIter.Next() {
+	err = iterate(ctx, &groupIter, func() error {
 		group := groupIter.Value()
 
-		if isCIResource(*group.Name) {
+		if c.isCIResource(*group.Name) || c.isCreatedByCI(to.String(group.ID)) {
 			groupMap[*group.Name] = true
 		}
+
+		return nil
+	})
+	if err != nil {
+		return microerror.Mask(err)
 	}
 
 	// Clean vpn connections in every installation.
@@ -34,12 +40,12 @@ func (c Cleaner) cleanVPNConnection(ctx context.Context) error {
 			return microerror.Mask(err)
 		}
 
-		for ; iter.NotDone(); iter.Next() {
+		err = iterate(ctx, &iter, func() error {
 			connection := iter.Value()
 
-			if !isCIResource(*connection.Name) {
+			if !c.isCIResource(*connection.Name) && !c.isCreatedByCI(to.String(connection.ID)) {
 				// Skip non CI vpn connections.
-				continue
+				return nil
 			}
 
 			// Delete vpn connection which do not have a corresponding resource group.
@@ -47,24 +53,20 @@ func (c Cleaner) cleanVPNConnection(ctx context.Context) error {
 			if !exist {
 				c.logger.Log("level", "error", "message", fmt.Sprintf("ensuring deletion of vpn connection %q", *connection.Name))
 
-				resFuture, err := c.virtualNetworkGatewayConnectionsClient.Delete(ctx, i, *connection.Name)
+				err := c.run.Delete(ctx, cleanerVPNConnections, *connection.Name, func() error {
+					return c.deleteVPNConnection(ctx, i, *connection.Name)
+				})
 				if err != nil {
 					c.logger.Log("level", "error", "message", fmt.Sprintf("did not ensure deletion of vpn connection %q", *connection.Name), "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
 					lastError = err
-					continue
+					return nil
 				}
-
-				res, err := c.virtualNetworkGatewayConnectionsClient.DeleteResponder(resFuture.Response())
-				if res.Response != nil && res.StatusCode == http.StatusNotFound {
-					// fall through
-				} else if err != nil {
-					c.logger.Log("level", "error", "message", fmt.Sprintf("did not ensure deletion of vpn connection %q", *connection.Name), "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
-					lastError = err
-					continue
-				}
-
-				c.logger.Log("level", "error", "message", fmt.Sprintf("ensured deletion of vpn connection %q", *connection.Name))
 			}
+
+			return nil
+		})
+		if err != nil {
+			return microerror.Mask(err)
 		}
 	}
 
@@ -74,3 +76,21 @@ func (c Cleaner) cleanVPNConnection(ctx context.Context) error {
 
 	return nil
 }
+
+func (c Cleaner) deleteVPNConnection(ctx context.Context, resourceGroup string, name string) error {
+	resFuture, err := c.virtualNetworkGatewayConnectionsClient.Delete(ctx, resourceGroup, name)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	res, err := c.virtualNetworkGatewayConnectionsClient.DeleteResponder(resFuture.Response())
+	if res.Response != nil && res.StatusCode == http.StatusNotFound {
+		// fall through
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	c.logger.Log("level", "error", "message", fmt.Sprintf("ensured deletion of vpn connection %q", name))
+
+	return nil
+}
@@ -0,0 +1,50 @@
+// Package cleaner is the entry point for tools embedding the ci-cleaner, like
+// standalone teardown jobs of e2e frameworks, which want to invoke cleaners
+// programmatically instead of running the ci-cleaner binary.
+//
+// The cleaners of each provider live in a sub-package, see pkg/cleaner/aws
+// and pkg/cleaner/azure. They are created from a config holding the clients,
+// the logger and the policies, like grace period and name prefixes, and a
+// *run.Run, which decides what happens to the resources they find. Embedding
+// tools inject their own clients, e.g. the ones of a test account, and can
+// restrict a run to individual cleaners with run.Scope, using the names
+// returned by Interface.Names. What happens to every resource found, e.g.
+// that it was deleted, skipped as it is protected or failed to be deleted,
+// is passed to run.Config.OnResult as it happens.
+//
+//	c := aws.ConfigFromSession(s)
+//	c.Logger = logger
+//	c.Run = r
+//	c.Prefixes = []string{"e2e-"}
+//
+//	a, err := aws.New(c)
+//	...
+//	err = a.Clean(ctx)
+//
+// The exported identifiers of this package, the provider packages and
+// pkg/run form the embedding API. It is versioned by APIVersion following
+// semantic versioning. Other packages of this module are internal to the
+// ci-cleaner and may change at any time.
+package cleaner
+
+import (
+	"context"
+)
+
+// APIVersion is the version of the embedding API. The major version is only
+// increased together with the major version of this module for changes
+// breaking embedding tools, the minor version for additions like new
+// cleaners or config fields.
+const APIVersion = "1.1.0"
+
+// Interface is implemented by the cleaners of all providers.
+type Interface interface {
+	// Clean runs all cleaners of the provider enabled by the run. Errors of
+	// single cleaners do not stop the others, unless noted otherwise by the
+	// provider.
+	Clean(ctx context.Context) error
+	// Names returns the names of the cleaners of the provider in the order
+	// they run. The names identify the cleaners in run.Scope, reports and
+	// configuration.
+	Names() []string
+}
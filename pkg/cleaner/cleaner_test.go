@@ -0,0 +1,12 @@
+package cleaner
+
+import (
+	"github.com/giantswarm/ci-cleaner/pkg/cleaner/aws"
+	"github.com/giantswarm/ci-cleaner/pkg/cleaner/azure"
+)
+
+// The cleaners of all providers must implement the embedding API.
+var (
+	_ Interface = &aws.Cleaner{}
+	_ Interface = &azure.Cleaner{}
+)
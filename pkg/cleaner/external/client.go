@@ -0,0 +1,107 @@
+// Package external implements cleaners for artifacts e2e tests register in
+// external systems, like test repositories in container registries. The
+// cleaners implement plugin.Cleaner, so that they run like external plugins.
+package external
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	defaultHTTPTimeout = 30 * time.Second
+)
+
+type ClientConfig struct {
+	// BaseURL is prepended to the paths of all requests.
+	BaseURL string
+	// Token is sent as bearer token with every request.
+	Token string
+	// HTTPClient defaults to a client with a 30 seconds timeout.
+	HTTPClient *http.Client
+}
+
+// Client is a small JSON API client shared by all external cleaners.
+type Client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+func NewClient(config ClientConfig) (*Client, error) {
+	if config.BaseURL == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.BaseURL must not be empty", config)
+	}
+
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: defaultHTTPTimeout}
+	}
+
+	c := &Client{
+		baseURL:    strings.TrimSuffix(config.BaseURL, "/"),
+		token:      config.Token,
+		httpClient: config.HTTPClient,
+	}
+
+	return c, nil
+}
+
+// Do sends a request with in as JSON body, unless in is nil, and decodes the
+// JSON response into out, unless out is nil. Responses with status 404 return
+// notFoundError, other unsuccessful responses executionFailedError.
+func (c *Client) Do(ctx context.Context, method string, path string, in interface{}, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, body)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return microerror.Maskf(notFoundError, "%s %s", method, path)
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		b, _ := ioutil.ReadAll(io.LimitReader(res.Body, 512))
+		return microerror.Maskf(executionFailedError, "%s %s returned status %d: %s", method, path, res.StatusCode, strings.TrimSpace(string(b)))
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	err = json.NewDecoder(res.Body).Decode(out)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
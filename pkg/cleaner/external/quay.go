@@ -0,0 +1,192 @@
+package external
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+const (
+	// CleanerQuayRepositories identifies the Quay cleaner in reports and
+	// configuration.
+	CleanerQuayRepositories = "quay-repositories"
+
+	defaultQuayURL       = "https://quay.io"
+	defaultQuayRetention = 24 * time.Hour
+)
+
+var (
+	// defaultQuayPrefixes are the name prefixes of test repositories pushed
+	// by e2e tests, unless configured otherwise.
+	defaultQuayPrefixes = []string{
+		"ci-",
+		"e2e-",
+	}
+)
+
+type QuayConfig struct {
+	Logger micrologger.Logger
+
+	// Namespace is the organization or user whose repositories are cleaned.
+	Namespace string
+	// Token is an OAuth token allowed to administer the repositories of the
+	// namespace.
+	Token string
+	// URL defaults to https://quay.io.
+	URL string
+
+	// Retention is the time repositories are kept after they were last
+	// modified. Defaults to 24 hours.
+	Retention time.Duration
+	// Prefixes are the name prefixes identifying test repositories.
+	Prefixes []string
+}
+
+// Quay deletes test repositories from Quay.
+type Quay struct {
+	client *Client
+	logger micrologger.Logger
+
+	namespace string
+	prefixes  []string
+	retention time.Duration
+}
+
+type quayRepository struct {
+	Namespace    string `json:"namespace"`
+	Name         string `json:"name"`
+	LastModified int64  `json:"last_modified"`
+}
+
+type quayRepositoryList struct {
+	Repositories []quayRepository `json:"repositories"`
+	NextPage     string           `json:"next_page"`
+}
+
+func NewQuay(config QuayConfig) (*Quay, error) {
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.Namespace == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Namespace must not be empty", config)
+	}
+	if config.Token == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Token must not be empty", config)
+	}
+
+	if config.URL == "" {
+		config.URL = defaultQuayURL
+	}
+	if config.Retention == 0 {
+		config.Retention = defaultQuayRetention
+	}
+	if len(config.Prefixes) == 0 {
+		config.Prefixes = defaultQuayPrefixes
+	}
+
+	client, err := NewClient(ClientConfig{BaseURL: config.URL + "/api/v1", Token: config.Token})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	q := &Quay{
+		client: client,
+		logger: config.Logger,
+
+		namespace: config.Namespace,
+		prefixes:  config.Prefixes,
+		retention: config.Retention,
+	}
+
+	return q, nil
+}
+
+// Name implements plugin.Cleaner.
+func (q *Quay) Name() string {
+	return CleanerQuayRepositories
+}
+
+// Candidates implements plugin.Cleaner.
+func (q *Quay) Candidates(ctx context.Context) ([]run.Resource, error) {
+	deadline := time.Now().Add(-q.retention)
+
+	var candidates []run.Resource
+	var nextPage string
+	for {
+		query := url.Values{}
+		query.Set("namespace", q.namespace)
+		query.Set("last_modified", "true")
+		if nextPage != "" {
+			query.Set("next_page", nextPage)
+		}
+
+		var list quayRepositoryList
+		err := q.client.Do(ctx, http.MethodGet, "/repository?"+query.Encode(), nil, &list)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, r := range list.Repositories {
+			if !q.repositoryShouldBeDeleted(r, deadline) {
+				continue
+			}
+
+			candidates = append(candidates, run.Resource{
+				ID:   r.Namespace + "/" + r.Name,
+				Type: "quay.io/repository",
+			})
+		}
+
+		if list.NextPage == "" {
+			break
+		}
+		nextPage = list.NextPage
+	}
+
+	return candidates, nil
+}
+
+// Delete implements plugin.Cleaner. Repositories which are gone already are
+// considered deleted.
+func (q *Quay) Delete(ctx context.Context, resource run.Resource) error {
+	err := q.client.Do(ctx, http.MethodDelete, "/repository/"+resource.ID, nil, nil)
+	if IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	q.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("deleted quay repository %#q", resource.ID))
+
+	return nil
+}
+
+// repositoryShouldBeDeleted returns whether the repository was pushed by
+// tests and was not modified since the given deadline. Repositories Quay does
+// not report a modification time for are never deleted.
+func (q *Quay) repositoryShouldBeDeleted(r quayRepository, deadline time.Time) bool {
+	if r.LastModified == 0 {
+		return false
+	}
+
+	var hasPrefix bool
+	for _, p := range q.prefixes {
+		if strings.HasPrefix(r.Name, p) {
+			hasPrefix = true
+			break
+		}
+	}
+	if !hasPrefix {
+		return false
+	}
+
+	return time.Unix(r.LastModified, 0).Before(deadline)
+}
@@ -0,0 +1,78 @@
+package external
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+func TestQuay(t *testing.T) {
+	old := time.Now().Add(-48 * time.Hour).Unix()
+	recent := time.Now().Add(-time.Hour).Unix()
+
+	var deleted []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/repository" && r.URL.Query().Get("next_page") == "":
+			fmt.Fprintf(w, `{"repositories": [{"namespace": "giantswarm", "name": "ci-a", "last_modified": %d}, {"namespace": "giantswarm", "name": "ci-b", "last_modified": %d}], "next_page": "p2"}`, old, recent)
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/repository":
+			fmt.Fprintf(w, `{"repositories": [{"namespace": "giantswarm", "name": "app-operator", "last_modified": %d}, {"namespace": "giantswarm", "name": "e2e-c", "last_modified": %d}]}`, old, old)
+		case r.Method == http.MethodDelete && r.URL.Path == "/api/v1/repository/giantswarm/gone":
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodDelete:
+			deleted = append(deleted, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer ts.Close()
+
+	q, err := NewQuay(QuayConfig{
+		Logger:    microloggertest.New(),
+		Namespace: "giantswarm",
+		Token:     "secret",
+		URL:       ts.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	candidates, err := q.Candidates(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []string
+	for _, c := range candidates {
+		ids = append(ids, c.ID)
+	}
+	if fmt.Sprint(ids) != "[giantswarm/ci-a giantswarm/e2e-c]" {
+		t.Errorf("expected old test repositories of all pages, got %v", ids)
+	}
+
+	err = q.Delete(context.Background(), candidates[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = q.Delete(context.Background(), run.Resource{ID: "giantswarm/gone"})
+	if err != nil {
+		t.Errorf("expected missing repository to be considered deleted, got %#v", err)
+	}
+
+	if len(deleted) != 1 || deleted[0] != "/api/v1/repository/giantswarm/ci-a" {
+		t.Errorf("unexpected deletions %v", deleted)
+	}
+}
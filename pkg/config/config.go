@@ -0,0 +1,369 @@
+// Package config implements the configuration file of the ci-cleaner. A
+// configuration file holds named profiles, so that a single deployment of the
+// ci-cleaner can serve several CI environments with different credentials and
+// cleanup rules.
+package config
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"time"
+
+	"github.com/giantswarm/microerror"
+)
+
+// Config is the content of a configuration file.
+type Config struct {
+	Profiles map[string]Profile `json:"profiles"`
+	// Schedules select the profile by the day of the week, see
+	// ScheduledProfile.
+	Schedules []Schedule `json:"schedules"`
+}
+
+// Schedule selects Profile on the given days of the week in the given time
+// zone, e.g. a profile which also runs expensive cleaners on weekends.
+type Schedule struct {
+	Profile string `json:"profile"`
+	// Days are the English names of the days of the week, e.g. "Saturday".
+	Days []string `json:"days"`
+	// TimeZone is the name of the time zone the days are in, e.g.
+	// "Europe/Berlin". Defaults to UTC.
+	TimeZone string `json:"timeZone"`
+}
+
+// Profile describes a single CI environment.
+type Profile struct {
+	AWS      AWS      `json:"aws"`
+	Azure    Azure    `json:"azure"`
+	External External `json:"external"`
+
+	// GracePeriod overrides the maximum time CI resources are allowed to
+	// remain up, e.g. "2h".
+	GracePeriod Duration `json:"gracePeriod"`
+	// Prefixes overrides the name prefixes identifying CI resources.
+	Prefixes []string `json:"prefixes"`
+	// Plugins are the paths of Go plugins implementing external cleaners,
+	// which run along with the cleaners for external systems.
+	Plugins []string `json:"plugins"`
+	// SkipCleaners are the names of the cleaners which do not run with this
+	// profile, e.g. expensive cleaners of rarely leaked resources which
+	// only run with a weekend profile.
+	SkipCleaners []string `json:"skipCleaners"`
+	// MaxMonthlyCostPerRun is the maximum estimated monthly cost in USD of
+	// the resources deleted per run. Once it is exceeded, the run stops and
+	// the remaining resources are reported for human review. Not limited
+	// when zero.
+	MaxMonthlyCostPerRun float64 `json:"maxMonthlyCostPerRun"`
+	// Rules maps cleaner names to conditions deciding whether the resources
+	// they found are deleted. The rules of "*" apply to all cleaners.
+	Rules map[string]Rules `json:"rules"`
+
+	Canary      Canary      `json:"canary"`
+	Escalation  Escalation  `json:"escalation"`
+	Freeze      Freeze      `json:"freeze"`
+	Inventory   Inventory   `json:"inventory"`
+	Notify      Notify      `json:"notify"`
+	Retention   Retention   `json:"retention"`
+	SelfTest    SelfTest    `json:"selfTest"`
+	TimeToClean TimeToClean `json:"timeToClean"`
+}
+
+// Rules are the conditions of a single cleaner, which are expressions like
+// `age > 4h && tags["team"] != "release"`. Resources matching any Skip
+// condition are kept. When Delete conditions are set, resources matching
+// none of them are kept as well.
+type Rules struct {
+	Skip   []string `json:"skip"`
+	Delete []string `json:"delete"`
+}
+
+// External holds the settings of the cleaners for external systems.
+type External struct {
+	Quay Quay `json:"quay"`
+}
+
+// Quay configures the cleanup of test repositories in Quay. The cleaner is
+// disabled when Namespace is empty.
+type Quay struct {
+	Namespace string   `json:"namespace"`
+	Token     string   `json:"token"`
+	URL       string   `json:"url"`
+	Retention Duration `json:"retention"`
+	Prefixes  []string `json:"prefixes"`
+}
+
+// Canary configures the rollout of newly enabled cleaners, which run in
+// report-only mode until their candidate sets were stable for Runs runs.
+type Canary struct {
+	Cleaners  []string `json:"cleaners"`
+	Runs      int      `json:"runs"`
+	Tolerance float64  `json:"tolerance"`
+}
+
+// SelfTest configures the end to end test of the cleanup, which creates
+// canary resources at the start of a run and alerts when later runs find
+// that the cleaners did not delete them within Deadline. Deadline must be
+// longer than the grace period and defaults to 6h.
+type SelfTest struct {
+	Enabled  bool     `json:"enabled"`
+	Deadline Duration `json:"deadline"`
+}
+
+// TimeToClean configures the objective for how long leaked resources live
+// before they are deleted. SLO applies to the 95th percentile of every
+// resource type and Types overrides it for single types, e.g.
+// "AWS::EC2::Instance". Window defaults to 7 days.
+type TimeToClean struct {
+	SLO    Duration            `json:"slo"`
+	Types  map[string]Duration `json:"types"`
+	Window Duration            `json:"window"`
+}
+
+// Freeze configures the calendar of freeze windows, e.g. of releases or
+// audits, during which runs only report what they would delete.
+type Freeze struct {
+	// CalendarURL is the address of an iCalendar feed, e.g. the secret iCal
+	// address of a Google Calendar. Every event is a freeze window.
+	CalendarURL string `json:"calendarURL"`
+}
+
+// Escalation configures how resources which survive several runs are
+// handled. Zero values fall back to the defaults.
+type Escalation struct {
+	DiagnoseAfter int      `json:"diagnoseAfter"`
+	ManualAfter   int      `json:"manualAfter"`
+	MaxRetries    int      `json:"maxRetries"`
+	RetryDelay    Duration `json:"retryDelay"`
+}
+
+// Inventory configures where the inventory of the resources found by every
+// run is uploaded to for compliance ingestion.
+type Inventory struct {
+	// S3Location looks like s3://bucket/prefix. The AWS credentials of the
+	// profile are used for uploading.
+	S3Location string `json:"s3Location"`
+	// BlobContainerURL is the URL of an Azure blob storage container
+	// including a SAS token allowing to create blobs.
+	BlobContainerURL string `json:"blobContainerURL"`
+}
+
+// Notify configures where the outcome of runs is sent to.
+type Notify struct {
+	SlackWebhookURL string `json:"slackWebhookURL"`
+	WebhookURL      string `json:"webhookURL"`
+	// MaxResources is the number of resources listed per action and
+	// cleaner in a single message.
+	MaxResources int `json:"maxResources"`
+}
+
+// Retention configures how long the ci-cleaner keeps its own data. Zero
+// durations keep the data forever.
+type Retention struct {
+	Audit   Duration `json:"audit"`
+	Reports Duration `json:"reports"`
+	State   Duration `json:"state"`
+}
+
+// AWS holds the AWS account settings of a profile.
+type AWS struct {
+	AccessKeyID     string `json:"accessKeyID"`
+	Region          string `json:"region"`
+	SecretAccessKey string `json:"secretAccessKey"`
+
+	// AcceleratorGracePeriod overrides the stricter grace period of CI
+	// instances with GPUs or other accelerators.
+	AcceleratorGracePeriod Duration `json:"acceleratorGracePeriod"`
+	// AMIRetention overrides how long CI AMIs are kept.
+	AMIRetention Duration `json:"amiRetention"`
+	// Charts configures the retention of ephemeral chart versions in ECR
+	// repositories of the account.
+	Charts Charts `json:"charts"`
+	// TableData configures the cleanup of test data in shared DynamoDB
+	// tables.
+	TableData TableData `json:"tableData"`
+	// AccountRetention overrides how long member accounts created by
+	// account-vending tests are kept.
+	AccountRetention Duration `json:"accountRetention"`
+	// MaxVolumesPerRun limits the number of unattached volumes deleted per
+	// run.
+	MaxVolumesPerRun int `json:"maxVolumesPerRun"`
+	// LogGroupRetentionDays is set as retention on CI log groups which are
+	// kept for now and have no retention policy, e.g. 7.
+	LogGroupRetentionDays int64 `json:"logGroupRetentionDays"`
+	// CloseAccounts enables closing CI member accounts of the organization,
+	// which are only reported otherwise.
+	CloseAccounts bool `json:"closeAccounts"`
+	// DeleteCloudHSMClusters enables deleting CI CloudHSM clusters, which
+	// are only reported otherwise.
+	DeleteCloudHSMClusters bool `json:"deleteCloudHSMClusters"`
+	// DeprovisionBYOIPCIDRs enables withdrawing and deprovisioning the
+	// BYOIP CIDRs of CI IPv6 pools, which are only reported otherwise.
+	DeprovisionBYOIPCIDRs bool `json:"deprovisionByoipCidrs"`
+	// DisableMacie enables disabling Macie in accounts where it is not
+	// managed by an organization. Macie sessions cannot be tagged, so this is
+	// meant for accounts used by CI only.
+	DisableMacie bool `json:"disableMacie"`
+	// TerminateProtectedEMRClusters enables terminating CI EMR clusters
+	// with termination protection, which are only reported otherwise.
+	TerminateProtectedEMRClusters bool `json:"terminateProtectedEMRClusters"`
+	// Queries maps cleaner names to the names of Resource Explorer views
+	// replacing the in-code matching of the cleaner.
+	Queries map[string]string `json:"queries"`
+	// CIPrincipals are the ARNs of the IAM roles and users CI runs as,
+	// whose resources are cleaned up regardless of their name.
+	CIPrincipals []string `json:"ciPrincipals"`
+}
+
+// Azure holds the Azure subscription settings of a profile.
+type Azure struct {
+	ClientID       string   `json:"clientID"`
+	ClientSecret   string   `json:"clientSecret"`
+	Installations  []string `json:"installations"`
+	Location       string   `json:"location"`
+	SubscriptionID string   `json:"subscriptionID"`
+	TenantID       string   `json:"tenantID"`
+
+	// CertificateVaults are the names of the Key Vaults leaked wildcard
+	// certificates of CI clusters are deleted from.
+	CertificateVaults []string `json:"certificateVaults"`
+	// Charts configures the retention of ephemeral chart versions in ACR
+	// repositories, which are named like `<registry>.azurecr.io/<repository>`.
+	Charts Charts `json:"charts"`
+	// TableData configures the cleanup of test data in shared Cosmos DB
+	// containers, which are named like `<account>/<database>/<container>`.
+	TableData TableData `json:"tableData"`
+	// CIPrincipals are the client IDs of the service principals CI runs
+	// as, whose resources are cleaned up regardless of their name.
+	CIPrincipals []string `json:"ciPrincipals"`
+	// DeleteApplications enables deleting the app registrations and service
+	// principals of CI clusters from the directory, and the expired client
+	// secrets of the CI principals. It requires the
+	// Application.ReadWrite.OwnedBy permission of Microsoft Graph.
+	DeleteApplications bool `json:"deleteApplications"`
+	// DeleteOrphanedRoleAssignments enables deleting the role assignments
+	// of principals which do not exist anymore. It requires the
+	// Directory.Read.All permission of Microsoft Graph.
+	DeleteOrphanedRoleAssignments bool `json:"deleteOrphanedRoleAssignments"`
+	// PurgeHSMs enables deleting and purging CI managed and dedicated
+	// HSMs, which are only reported otherwise.
+	PurgeHSMs bool `json:"purgeHSMs"`
+	// RecordRoleAssignmentDrift enables recording the role assignments of
+	// the subscription before and after the cleanup, so that the report
+	// proves only CI scoped role assignments were removed.
+	RecordRoleAssignmentDrift bool `json:"recordRoleAssignmentDrift"`
+}
+
+// Charts configures the retention of the ephemeral Helm chart versions CI
+// pushes to OCI registries, see package chart. The cleaner is disabled when
+// Repositories is empty. Retention defaults to 7 days.
+type Charts struct {
+	// Repositories are the repositories whose ephemeral chart versions are
+	// deleted.
+	Repositories []string `json:"repositories"`
+	// Retention is how long ephemeral chart versions are kept after they
+	// were pushed.
+	Retention Duration `json:"retention"`
+	// Suffixes override the suffixes marking ephemeral chart versions, e.g.
+	// "-pr".
+	Suffixes []string `json:"suffixes"`
+}
+
+// TableData configures the cleanup of the items tests write into shared
+// tables keyed by the ID of their run, see package tabledata. The cleaner is
+// disabled when Tables is empty, and only reports the runs past their
+// retention unless Delete is set. Retention defaults to 7 days.
+type TableData struct {
+	Tables []Table `json:"tables"`
+	// Retention is how long the items of a run are kept after the last of
+	// them was written.
+	Retention Duration `json:"retention"`
+	// Delete enables deleting the items of runs past their retention.
+	Delete bool `json:"delete"`
+}
+
+// Table is a shared table holding test data. KeyPrefixes must not be empty.
+type Table struct {
+	Name string `json:"name"`
+	// KeyAttribute is the attribute holding the run ID. Defaults to the
+	// partition key.
+	KeyAttribute string `json:"keyAttribute"`
+	// KeyPrefixes are the prefixes of the run IDs whose items are cleaned
+	// up, e.g. "ci-run-".
+	KeyPrefixes []string `json:"keyPrefixes"`
+	// TimestampAttribute is the attribute holding when an item was written,
+	// as RFC 3339 string or Unix seconds.
+	TimestampAttribute string `json:"timestampAttribute"`
+}
+
+// Duration is a time.Duration which is read from a duration string like
+// "90m".
+type Duration struct {
+	time.Duration
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	err := json.Unmarshal(b, &s)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	d.Duration, err = time.ParseDuration(s)
+	if err != nil {
+		return microerror.Maskf(invalidConfigError, "invalid duration %q: %s", s, err.Error())
+	}
+
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// Read reads the configuration file at the given path.
+func Read(path string) (Config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Config{}, microerror.Mask(err)
+	}
+
+	var c Config
+	err = json.Unmarshal(b, &c)
+	if err != nil {
+		return Config{}, microerror.Maskf(invalidConfigError, "parsing %#q: %s", path, err.Error())
+	}
+
+	return c, nil
+}
+
+// ScheduledProfile returns the name of the profile of the first schedule
+// covering the given time.
+func (c Config) ScheduledProfile(now time.Time) (string, error) {
+	for _, s := range c.Schedules {
+		location, err := time.LoadLocation(s.TimeZone)
+		if err != nil {
+			return "", microerror.Maskf(invalidConfigError, "time zone of schedule of profile %#q: %s", s.Profile, err.Error())
+		}
+
+		day := now.In(location).Weekday().String()
+		for _, d := range s.Days {
+			if d == day {
+				return s.Profile, nil
+			}
+		}
+	}
+
+	return "", microerror.Maskf(profileNotFoundError, "no schedule covers %s", now.Format(time.RFC3339))
+}
+
+// Profile returns the profile with the given name.
+func (c Config) Profile(name string) (Profile, error) {
+	p, ok := c.Profiles[name]
+	if !ok {
+		return Profile{}, microerror.Maskf(profileNotFoundError, "profile %#q", name)
+	}
+
+	return p, nil
+}
@@ -0,0 +1,150 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRead(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ci-cleaner-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.json")
+	content := `{
+  "profiles": {
+    "host": {
+      "aws": {"region": "eu-central-1"},
+      "gracePeriod": "2h",
+      "prefixes": ["ci-", "e2e-"]
+    },
+    "azure": {
+      "azure": {"installations": ["ghost"], "location": "westeurope"}
+    }
+  }
+}`
+	err = ioutil.WriteFile(path, []byte(content), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := c.Profile("host")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.AWS.Region != "eu-central-1" {
+		t.Errorf("expected region %q, got %q", "eu-central-1", p.AWS.Region)
+	}
+	if p.GracePeriod.Duration != 2*time.Hour {
+		t.Errorf("expected grace period %s, got %s", 2*time.Hour, p.GracePeriod.Duration)
+	}
+	if len(p.Prefixes) != 2 {
+		t.Errorf("expected 2 prefixes, got %d", len(p.Prefixes))
+	}
+
+	p, err = c.Profile("azure")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.GracePeriod.Duration != 0 {
+		t.Errorf("expected no grace period, got %s", p.GracePeriod.Duration)
+	}
+
+	_, err = c.Profile("guest")
+	if !IsProfileNotFound(err) {
+		t.Errorf("expected profileNotFoundError, got %#v", err)
+	}
+}
+
+func TestReadInvalidDuration(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ci-cleaner-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.json")
+	err = ioutil.WriteFile(path, []byte(`{"profiles": {"host": {"gracePeriod": "soon"}}}`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = Read(path)
+	if !IsInvalidConfig(err) {
+		t.Errorf("expected invalidConfigError, got %#v", err)
+	}
+}
+
+func TestScheduledProfile(t *testing.T) {
+	c := Config{
+		Schedules: []Schedule{
+			{Profile: "weekend", Days: []string{"Saturday", "Sunday"}, TimeZone: "Europe/Berlin"},
+			{Profile: "weekday", Days: []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday"}, TimeZone: "Europe/Berlin"},
+		},
+	}
+
+	tcs := []struct {
+		now         time.Time
+		expected    string
+		description string
+	}{
+		{
+			description: "saturday noon is covered by the weekend profile",
+			now:         time.Date(2020, 2, 8, 12, 0, 0, 0, time.UTC),
+			expected:    "weekend",
+		},
+		{
+			description: "friday night in utc is saturday in berlin",
+			now:         time.Date(2020, 2, 7, 23, 30, 0, 0, time.UTC),
+			expected:    "weekend",
+		},
+		{
+			description: "sunday night in utc is monday in berlin",
+			now:         time.Date(2020, 2, 9, 23, 30, 0, 0, time.UTC),
+			expected:    "weekday",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual, err := c.ScheduledProfile(tc.now)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if actual != tc.expected {
+				t.Errorf("want %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestScheduledProfileInvalid(t *testing.T) {
+	c := Config{
+		Schedules: []Schedule{
+			{Profile: "weekday", Days: []string{"Monday"}},
+		},
+	}
+
+	_, err := c.ScheduledProfile(time.Date(2020, 2, 8, 12, 0, 0, 0, time.UTC))
+	if !IsProfileNotFound(err) {
+		t.Errorf("expected profileNotFoundError, got %#v", err)
+	}
+
+	c.Schedules[0].TimeZone = "Europe/Nowhere"
+
+	_, err = c.ScheduledProfile(time.Date(2020, 2, 10, 12, 0, 0, 0, time.UTC))
+	if !IsInvalidConfig(err) {
+		t.Errorf("expected invalidConfigError, got %#v", err)
+	}
+}
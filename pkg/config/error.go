@@ -0,0 +1,23 @@
+package config
+
+import (
+	"github.com/giantswarm/microerror"
+)
+
+var invalidConfigError = &microerror.Error{
+	Kind: "invalidConfigError",
+}
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
+
+var profileNotFoundError = &microerror.Error{
+	Kind: "profileNotFoundError",
+}
+
+// IsProfileNotFound asserts profileNotFoundError.
+func IsProfileNotFound(err error) bool {
+	return microerror.Cause(err) == profileNotFoundError
+}
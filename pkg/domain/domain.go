@@ -0,0 +1,111 @@
+// Package domain knows about the ephemeral DNS domains of CI clusters, so
+// that the cleaners of all providers detect leftovers issued for clusters
+// which are gone, e.g. wildcard certificates, the same way.
+package domain
+
+import (
+	"net"
+	"strings"
+
+	"github.com/bogdanovich/dns_resolver"
+	"github.com/giantswarm/microerror"
+	"github.com/miekg/dns"
+)
+
+const (
+	// Base is the domain the domains of CI clusters are created in, e.g.
+	// `ci-wip-a1b2c.k8s.gigantic.io`.
+	Base = "gigantic.io"
+
+	dnsFailureError  = "SERVFAIL"
+	dnsRetries       = 5
+	dnsServerAddress = "8.8.8.8"
+)
+
+// WildcardCluster returns the domain of the CI cluster the given wildcard
+// domain, e.g. `*.ci-wip-a1b2c.k8s.gigantic.io`, was issued for. The given
+// prefixes identify CI clusters. false is returned for any other domain.
+func WildcardCluster(name string, prefixes []string) (string, bool) {
+	name = strings.TrimSuffix(name, ".")
+
+	if !strings.HasPrefix(name, "*.") {
+		return "", false
+	}
+	cluster := strings.TrimPrefix(name, "*.")
+
+	if !strings.HasSuffix(cluster, "."+Base) {
+		return "", false
+	}
+
+	for _, p := range prefixes {
+		if strings.HasPrefix(cluster, p) {
+			return cluster, true
+		}
+	}
+
+	return "", false
+}
+
+// APIResolves checks whether the API hostname of the CI cluster with the given
+// domain still resolves, which is the case as long as the cluster exists.
+func APIResolves(cluster string) (bool, error) {
+	full := "api." + strings.TrimSuffix(cluster, ".")
+
+	resolver := dns_resolver.New([]string{dnsServerAddress})
+
+	// In case of i/o timeout
+	resolver.RetryTimes = dnsRetries
+
+	addresses, err := resolver.LookupHost(full)
+	if err != nil && !strings.Contains(err.Error(), dnsFailureError) {
+		return false, microerror.Mask(err)
+	}
+	if len(addresses) > 0 {
+		return true, nil
+	}
+
+	// the API of IPv6-only clusters only has AAAA records, which the
+	// resolver does not look up.
+	resolves, err := resolvesIPv6(full)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	return resolves, nil
+}
+
+// resolvesIPv6 checks whether the given hostname has IPv6 addresses. Like for
+// IPv4 addresses, lookups are retried on timeouts and server failures are
+// treated as the hostname not resolving.
+func resolvesIPv6(host string) (bool, error) {
+	m := &dns.Msg{}
+	m.SetQuestion(dns.Fqdn(host), dns.TypeAAAA)
+
+	var in *dns.Msg
+	var err error
+	for tries := 0; tries <= dnsRetries; tries++ {
+		in, err = dns.Exchange(m, net.JoinHostPort(dnsServerAddress, "53"))
+		if err == nil || !strings.HasSuffix(err.Error(), "i/o timeout") {
+			break
+		}
+	}
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	switch in.Rcode {
+	case dns.RcodeSuccess:
+	case dns.RcodeServerFailure:
+		return false, nil
+	default:
+		return false, microerror.Maskf(lookupFailedError, "%s", dns.RcodeToString[in.Rcode])
+	}
+
+	for _, record := range in.Answer {
+		if _, ok := record.(*dns.AAAA); ok {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
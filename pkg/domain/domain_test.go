@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"testing"
+)
+
+func TestWildcardCluster(t *testing.T) {
+	prefixes := []string{"ci-", "e2e-"}
+
+	tcs := []struct {
+		name            string
+		expectedCluster string
+		expected        bool
+		description     string
+	}{
+		{
+			description:     "wildcard domain of ci cluster",
+			name:            "*.ci-wip-a1b2c.k8s.gigantic.io",
+			expectedCluster: "ci-wip-a1b2c.k8s.gigantic.io",
+			expected:        true,
+		},
+		{
+			description:     "fully qualified wildcard domain of ci cluster",
+			name:            "*.e2e-a1b2c.westeurope.azure.gigantic.io.",
+			expectedCluster: "e2e-a1b2c.westeurope.azure.gigantic.io",
+			expected:        true,
+		},
+		{
+			description: "domain of ci cluster without wildcard",
+			name:        "ci-wip-a1b2c.k8s.gigantic.io",
+			expected:    false,
+		},
+		{
+			description: "wildcard domain of installation",
+			name:        "*.gauss.eu-central-1.aws.gigantic.io",
+			expected:    false,
+		},
+		{
+			description: "wildcard domain of ci cluster in another domain",
+			name:        "*.ci-wip-a1b2c.example.com",
+			expected:    false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			cluster, ok := WildcardCluster(tc.name, prefixes)
+
+			if ok != tc.expected {
+				t.Fatalf("checking %q, want %t, got %t", tc.name, tc.expected, ok)
+			}
+			if cluster != tc.expectedCluster {
+				t.Errorf("checking %q, want cluster %q, got %q", tc.name, tc.expectedCluster, cluster)
+			}
+		})
+	}
+}
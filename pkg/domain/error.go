@@ -0,0 +1,14 @@
+package domain
+
+import (
+	"github.com/giantswarm/microerror"
+)
+
+var lookupFailedError = &microerror.Error{
+	Kind: "lookupFailedError",
+}
+
+// IsLookupFailed asserts lookupFailedError.
+func IsLookupFailed(err error) bool {
+	return microerror.Cause(err) == lookupFailedError
+}
@@ -0,0 +1,32 @@
+package freeze
+
+import (
+	"github.com/giantswarm/microerror"
+)
+
+var invalidConfigError = &microerror.Error{
+	Kind: "invalidConfigError",
+}
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
+
+var invalidCalendarError = &microerror.Error{
+	Kind: "invalidCalendarError",
+}
+
+// IsInvalidCalendar asserts invalidCalendarError.
+func IsInvalidCalendar(err error) bool {
+	return microerror.Cause(err) == invalidCalendarError
+}
+
+var executionFailedError = &microerror.Error{
+	Kind: "executionFailedError",
+}
+
+// IsExecutionFailed asserts executionFailedError.
+func IsExecutionFailed(err error) bool {
+	return microerror.Cause(err) == executionFailedError
+}
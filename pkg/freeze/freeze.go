@@ -0,0 +1,305 @@
+// Package freeze reads the freeze windows of releases, audits and the like
+// from an iCalendar feed, e.g. the secret iCal address of a Google Calendar,
+// so that runs during a freeze only report what they would delete.
+//
+// Every event of the calendar is a freeze window. Recurring events are not
+// expanded, only their first occurrence counts.
+package freeze
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	defaultHTTPTimeout = 30 * time.Second
+)
+
+// Window is a single freeze.
+type Window struct {
+	Summary string
+	Start   time.Time
+	End     time.Time
+}
+
+// Contains checks if the given time is within the window.
+func (w Window) Contains(t time.Time) bool {
+	return !t.Before(w.Start) && t.Before(w.End)
+}
+
+type Config struct {
+	// HTTPClient fetches the calendar. Defaults to a client with a timeout
+	// of 30 seconds.
+	HTTPClient *http.Client
+
+	// URL is the address of the iCalendar feed.
+	URL string
+}
+
+// Calendar is an iCalendar feed of freeze windows.
+type Calendar struct {
+	httpClient *http.Client
+
+	url string
+}
+
+func New(config Config) (*Calendar, error) {
+	if config.URL == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.URL must not be empty", config)
+	}
+
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: defaultHTTPTimeout}
+	}
+
+	c := &Calendar{
+		httpClient: config.HTTPClient,
+
+		url: config.URL,
+	}
+
+	return c, nil
+}
+
+// Active returns the freeze window containing the given time. When windows
+// overlap, the one ending last is returned.
+func (c *Calendar) Active(ctx context.Context, now time.Time) (Window, bool, error) {
+	windows, err := c.Windows(ctx)
+	if err != nil {
+		return Window{}, false, microerror.Mask(err)
+	}
+
+	w, ok := Active(windows, now)
+	return w, ok, nil
+}
+
+// Windows fetches the calendar and returns its freeze windows ordered by
+// their start.
+func (c *Calendar) Windows(ctx context.Context) ([]Window, error) {
+	req, err := http.NewRequest(http.MethodGet, c.url, nil)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	resp, err := c.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, microerror.Maskf(executionFailedError, "fetching freeze calendar responded with status %d", resp.StatusCode)
+	}
+
+	windows, err := Parse(resp.Body)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return windows, nil
+}
+
+// Active returns the window of the given ones containing the given time. When
+// windows overlap, the one ending last is returned.
+func Active(windows []Window, now time.Time) (Window, bool) {
+	var active Window
+	var ok bool
+	for _, w := range windows {
+		if !w.Contains(now) {
+			continue
+		}
+		if !ok || w.End.After(active.End) {
+			active = w
+			ok = true
+		}
+	}
+
+	return active, ok
+}
+
+// Parse reads the events of the given iCalendar data as freeze windows
+// ordered by their start. Cancelled events are skipped.
+func Parse(r io.Reader) ([]Window, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var windows []Window
+	var event map[string]property
+	for _, line := range lines {
+		p, ok := parseProperty(line)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case p.name == "BEGIN" && strings.EqualFold(p.value, "VEVENT"):
+			event = map[string]property{}
+		case p.name == "END" && strings.EqualFold(p.value, "VEVENT"):
+			if event == nil {
+				continue
+			}
+
+			w, ok, err := window(event)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+			if ok {
+				windows = append(windows, w)
+			}
+			event = nil
+		case event != nil:
+			event[p.name] = p
+		}
+	}
+
+	sort.SliceStable(windows, func(i, j int) bool {
+		return windows[i].Start.Before(windows[j].Start)
+	})
+
+	return windows, nil
+}
+
+// property is a content line like `DTSTART;TZID=Europe/Berlin:20240517T120000`.
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+func parseProperty(line string) (property, bool) {
+	i := strings.Index(line, ":")
+	if i < 0 {
+		return property{}, false
+	}
+
+	parts := strings.Split(line[:i], ";")
+	p := property{
+		name:   strings.ToUpper(parts[0]),
+		params: map[string]string{},
+		value:  line[i+1:],
+	}
+	for _, param := range parts[1:] {
+		kv := strings.SplitN(param, "=", 2)
+		if len(kv) == 2 {
+			p.params[strings.ToUpper(kv[0])] = strings.Trim(kv[1], `"`)
+		}
+	}
+
+	return p, true
+}
+
+// window returns the freeze window of the event with the given properties.
+// Events without end are all-day events when they start on a date, and take
+// no time otherwise.
+func window(event map[string]property) (Window, bool, error) {
+	if strings.EqualFold(event["STATUS"].value, "CANCELLED") {
+		return Window{}, false, nil
+	}
+
+	start, ok := event["DTSTART"]
+	if !ok {
+		return Window{}, false, nil
+	}
+
+	w := Window{
+		Summary: unescape(event["SUMMARY"].value),
+	}
+
+	var err error
+	w.Start, err = parseTime(start)
+	if err != nil {
+		return Window{}, false, microerror.Mask(err)
+	}
+
+	if end, ok := event["DTEND"]; ok {
+		w.End, err = parseTime(end)
+		if err != nil {
+			return Window{}, false, microerror.Mask(err)
+		}
+	} else if isDate(start) {
+		w.End = w.Start.AddDate(0, 0, 1)
+	} else {
+		w.End = w.Start
+	}
+
+	return w, true, nil
+}
+
+// parseTime parses the given date or date-time property. Floating times and
+// dates are taken as UTC.
+func parseTime(p property) (time.Time, error) {
+	if isDate(p) {
+		t, err := time.Parse("20060102", p.value)
+		if err != nil {
+			return time.Time{}, microerror.Maskf(invalidCalendarError, "%s: %s", p.name, err.Error())
+		}
+
+		return t, nil
+	}
+
+	if strings.HasSuffix(p.value, "Z") {
+		t, err := time.Parse("20060102T150405Z", p.value)
+		if err != nil {
+			return time.Time{}, microerror.Maskf(invalidCalendarError, "%s: %s", p.name, err.Error())
+		}
+
+		return t, nil
+	}
+
+	location := time.UTC
+	if tzid := p.params["TZID"]; tzid != "" {
+		l, err := time.LoadLocation(tzid)
+		if err != nil {
+			return time.Time{}, microerror.Maskf(invalidCalendarError, "%s: %s", p.name, err.Error())
+		}
+		location = l
+	}
+
+	t, err := time.ParseInLocation("20060102T150405", p.value, location)
+	if err != nil {
+		return time.Time{}, microerror.Maskf(invalidCalendarError, "%s: %s", p.name, err.Error())
+	}
+
+	return t, nil
+}
+
+func isDate(p property) bool {
+	return strings.EqualFold(p.params["VALUE"], "DATE") || len(p.value) == len("20060102")
+}
+
+// unfold reads the content lines of the given iCalendar data, joining lines
+// which continue the previous one by starting with a space or a tab.
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), 1024*1024)
+	for s.Scan() {
+		line := strings.TrimRight(s.Text(), "\r")
+		if len(lines) != 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := s.Err(); err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return lines, nil
+}
+
+// unescape unescapes the given text value.
+func unescape(s string) string {
+	r := strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`)
+	return r.Replace(s)
+}
@@ -0,0 +1,117 @@
+package freeze
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const calendar = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"PRODID:-//Google Inc//Google Calendar 70.9054//EN\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"DTSTART;VALUE=DATE:20240520\r\n" +
+	"DTEND;VALUE=DATE:20240522\r\n" +
+	"SUMMARY:Release freeze\\, v20\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"DTSTART;TZID=Europe/Berlin:20240517T090000\r\n" +
+	"DTEND;TZID=Europe/Berlin:20240517T170000\r\n" +
+	"SUMMARY:SOC 2 audit of the\r\n" +
+	"  CI accounts\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"DTSTART:20240524T080000Z\r\n" +
+	"DTEND:20240524T100000Z\r\n" +
+	"SUMMARY:Cancelled freeze\r\n" +
+	"STATUS:CANCELLED\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"DTSTART;VALUE=DATE:20240603\r\n" +
+	"SUMMARY:Single day freeze\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestActive(t *testing.T) {
+	windows, err := Parse(strings.NewReader(calendar))
+	if err != nil {
+		t.Fatalf("expected nil, got %#v", err)
+	}
+	if len(windows) != 3 {
+		t.Fatalf("expected 3 windows, got %d", len(windows))
+	}
+
+	tcs := []struct {
+		now         time.Time
+		expected    string
+		description string
+	}{
+		{
+			description: "within timed event in other time zone",
+			now:         time.Date(2024, 5, 17, 14, 0, 0, 0, time.UTC),
+			expected:    "SOC 2 audit of the CI accounts",
+		},
+		{
+			description: "after timed event in other time zone",
+			now:         time.Date(2024, 5, 17, 15, 30, 0, 0, time.UTC),
+			expected:    "",
+		},
+		{
+			description: "on last day of all-day event",
+			now:         time.Date(2024, 5, 21, 23, 0, 0, 0, time.UTC),
+			expected:    "Release freeze, v20",
+		},
+		{
+			description: "on day after all-day event",
+			now:         time.Date(2024, 5, 22, 0, 0, 0, 0, time.UTC),
+			expected:    "",
+		},
+		{
+			description: "within cancelled event",
+			now:         time.Date(2024, 5, 24, 9, 0, 0, 0, time.UTC),
+			expected:    "",
+		},
+		{
+			description: "on single day event without end",
+			now:         time.Date(2024, 6, 3, 12, 0, 0, 0, time.UTC),
+			expected:    "Single day freeze",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			w, ok := Active(windows, tc.now)
+
+			if ok != (tc.expected != "") {
+				t.Fatalf("checking if %s is frozen, want %t, got %t", tc.now, tc.expected != "", ok)
+			}
+			if w.Summary != tc.expected {
+				t.Errorf("want freeze %q, got %q", tc.expected, w.Summary)
+			}
+		})
+	}
+}
+
+func TestCalendarActive(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/calendar")
+		_, _ = w.Write([]byte(calendar))
+	}))
+	defer s.Close()
+
+	c, err := New(Config{URL: s.URL})
+	if err != nil {
+		t.Fatalf("expected nil, got %#v", err)
+	}
+
+	w, ok, err := c.Active(context.Background(), time.Date(2024, 5, 20, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("expected nil, got %#v", err)
+	}
+	if !ok || w.Summary != "Release freeze, v20" {
+		t.Errorf("want active freeze %q, got %q (%t)", "Release freeze, v20", w.Summary, ok)
+	}
+}
@@ -0,0 +1,14 @@
+package graph
+
+import (
+	"github.com/giantswarm/microerror"
+)
+
+var cycleError = &microerror.Error{
+	Kind: "cycleError",
+}
+
+// IsCycle asserts cycleError.
+func IsCycle(err error) bool {
+	return microerror.Cause(err) == cycleError
+}
@@ -0,0 +1,151 @@
+// Package graph implements the dependency graph of the resources torn down
+// together, e.g. a VPC and everything inside it. The graph determines the
+// order resources are deleted in and is exported for humans to understand why
+// a resource refuses to be deleted.
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+)
+
+// Node is a single resource of the graph.
+type Node struct {
+	ID   string `json:"id"`
+	Kind string `json:"kind"`
+	// Error is the reason deleting the resource failed, if it did.
+	Error string `json:"error,omitempty"`
+}
+
+// Edge expresses that From depends on To, so From has to be deleted before
+// To can be deleted.
+type Edge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Graph is the dependency graph of the resources of a single doomed cluster
+// or VPC. It is not safe for concurrent use.
+type Graph struct {
+	Name  string `json:"name"`
+	Nodes []Node `json:"nodes"`
+	Edges []Edge `json:"edges"`
+}
+
+// New creates an empty graph with the given name.
+func New(name string) *Graph {
+	return &Graph{Name: name}
+}
+
+// AddNode adds a resource to the graph. Adding a resource twice is a no-op.
+func (g *Graph) AddNode(id string, kind string) {
+	if g.index(id) != -1 {
+		return
+	}
+
+	g.Nodes = append(g.Nodes, Node{ID: id, Kind: kind})
+}
+
+// AddEdge records that from depends on to. Both resources have to be added
+// before.
+func (g *Graph) AddEdge(from string, to string) {
+	for _, e := range g.Edges {
+		if e.From == from && e.To == to {
+			return
+		}
+	}
+
+	g.Edges = append(g.Edges, Edge{From: from, To: to})
+}
+
+// SetError records why deleting the given resource failed.
+func (g *Graph) SetError(id string, err error) {
+	i := g.index(id)
+	if i == -1 {
+		return
+	}
+
+	g.Nodes[i].Error = err.Error()
+}
+
+// Order returns the resources in the order they have to be deleted in, i.e.
+// every resource comes before the resources it depends on. Resources without
+// dependencies between each other keep the order they were added in.
+func (g *Graph) Order() ([]Node, error) {
+	dependents := map[string]int{}
+	for _, e := range g.Edges {
+		dependents[e.To]++
+	}
+
+	var order []Node
+	done := map[string]bool{}
+	for len(order) < len(g.Nodes) {
+		var progress bool
+		for _, n := range g.Nodes {
+			if done[n.ID] || dependents[n.ID] != 0 {
+				continue
+			}
+
+			order = append(order, n)
+			done[n.ID] = true
+			progress = true
+
+			for _, e := range g.Edges {
+				if e.From == n.ID {
+					dependents[e.To]--
+				}
+			}
+		}
+
+		if !progress {
+			var cycle []string
+			for _, n := range g.Nodes {
+				if !done[n.ID] {
+					cycle = append(cycle, n.ID)
+				}
+			}
+			sort.Strings(cycle)
+
+			return nil, microerror.Maskf(cycleError, "resources %s depend on each other", strings.Join(cycle, ", "))
+		}
+	}
+
+	return order, nil
+}
+
+// DOT renders the graph in the Graphviz DOT language. Resources which failed
+// to be deleted are drawn red and labelled with the error.
+func (g *Graph) DOT() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "digraph %q {\n", g.Name)
+	b.WriteString("  rankdir=LR;\n")
+	for _, n := range g.Nodes {
+		label := n.Kind + "\n" + n.ID
+		attrs := ""
+		if n.Error != "" {
+			label += "\n" + n.Error
+			attrs = ", color=red, fontcolor=red"
+		}
+		fmt.Fprintf(&b, "  %q [label=%q%s];\n", n.ID, label, attrs)
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&b, "  %q -> %q;\n", e.From, e.To)
+	}
+	b.WriteString("}\n")
+
+	return b.String()
+}
+
+func (g *Graph) index(id string) int {
+	for i, n := range g.Nodes {
+		if n.ID == id {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,93 @@
+package graph
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestOrder(t *testing.T) {
+	testCases := []struct {
+		name          string
+		nodes         []string
+		edges         []Edge
+		expectedOrder []string
+		expectedCycle bool
+	}{
+		{
+			name:          "case 0: no dependencies keep their order",
+			nodes:         []string{"a", "b"},
+			expectedOrder: []string{"a", "b"},
+		},
+		{
+			name:  "case 1: dependents are deleted first",
+			nodes: []string{"vpc", "subnet", "eni", "igw"},
+			edges: []Edge{
+				{From: "subnet", To: "vpc"},
+				{From: "eni", To: "subnet"},
+				{From: "igw", To: "vpc"},
+			},
+			expectedOrder: []string{"eni", "igw", "subnet", "vpc"},
+		},
+		{
+			name:  "case 2: cycles are detected",
+			nodes: []string{"sg-a", "sg-b", "vpc"},
+			edges: []Edge{
+				{From: "sg-a", To: "sg-b"},
+				{From: "sg-b", To: "sg-a"},
+				{From: "sg-a", To: "vpc"},
+			},
+			expectedCycle: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := New("test")
+			for _, n := range tc.nodes {
+				g.AddNode(n, "resource")
+			}
+			for _, e := range tc.edges {
+				g.AddEdge(e.From, e.To)
+			}
+
+			order, err := g.Order()
+			if tc.expectedCycle {
+				if !IsCycle(err) {
+					t.Fatalf("expected cycle error, got %#v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var ids []string
+			for _, n := range order {
+				ids = append(ids, n.ID)
+			}
+			if strings.Join(ids, ",") != strings.Join(tc.expectedOrder, ",") {
+				t.Errorf("expected order %v, got %v", tc.expectedOrder, ids)
+			}
+		})
+	}
+}
+
+func TestDOT(t *testing.T) {
+	g := New("vpc-1")
+	g.AddNode("vpc-1", "vpc")
+	g.AddNode("subnet-1", "subnet")
+	g.AddEdge("subnet-1", "vpc-1")
+	g.SetError("vpc-1", errors.New("DependencyViolation"))
+
+	expected := `digraph "vpc-1" {
+  rankdir=LR;
+  "vpc-1" [label="vpc\nvpc-1\nDependencyViolation", color=red, fontcolor=red];
+  "subnet-1" [label="subnet\nsubnet-1"];
+  "subnet-1" -> "vpc-1";
+}
+`
+	if dot := g.DOT(); dot != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, dot)
+	}
+}
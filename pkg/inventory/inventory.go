@@ -0,0 +1,140 @@
+// Package inventory exports the resources found by a ci-cleaner run for
+// compliance ingestion. Unlike the run report, which is meant for humans, the
+// inventory is a machine readable list of JSON lines following the field
+// names of AWS Config configuration items.
+package inventory
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+
+	"github.com/giantswarm/ci-cleaner/pkg/report"
+)
+
+// Record is a single resource of the inventory.
+type Record struct {
+	Provider     string            `json:"provider"`
+	ResourceID   string            `json:"resourceId"`
+	ResourceType string            `json:"resourceType,omitempty"`
+	Region       string            `json:"awsRegion,omitempty"`
+	Tags         map[string]string `json:"tags,omitempty"`
+	// ResourceCreationTime and AgeSeconds are only set when the creation
+	// time of the resource is known.
+	ResourceCreationTime *time.Time `json:"resourceCreationTime,omitempty"`
+	AgeSeconds           int64      `json:"ageSeconds,omitempty"`
+	CaptureTime          time.Time  `json:"configurationItemCaptureTime"`
+	Cleaner              string     `json:"cleaner"`
+	Action               string     `json:"action"`
+}
+
+// Uploader stores an inventory under the given name.
+type Uploader interface {
+	Upload(ctx context.Context, name string, body []byte) error
+}
+
+type Config struct {
+	Logger    micrologger.Logger
+	Uploaders []Uploader
+
+	// Region is the region or location of the run. It is used for
+	// resources whose cleaner did not set a region.
+	Region string
+}
+
+type Exporter struct {
+	logger    micrologger.Logger
+	uploaders []Uploader
+
+	region string
+}
+
+func New(config Config) (*Exporter, error) {
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+
+	e := &Exporter{
+		logger:    config.Logger,
+		uploaders: config.Uploaders,
+
+		region: config.Region,
+	}
+
+	return e, nil
+}
+
+// Export uploads the inventory of the given report to all uploaders.
+func (e *Exporter) Export(ctx context.Context, r *report.Report) error {
+	if len(e.uploaders) == 0 {
+		return nil
+	}
+
+	b, err := Encode(e.Records(r, time.Now().UTC()))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	name := fmt.Sprintf("inventory-%s-%s.jsonl", r.Provider, r.Started.Format("20060102T150405Z"))
+	for _, u := range e.uploaders {
+		err := u.Upload(ctx, name, b)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	e.logger.LogCtx(ctx, "level", "info", "message", fmt.Sprintf("exported inventory %#q", name))
+
+	return nil
+}
+
+// Records converts the items of the given report into inventory records
+// captured at the given time.
+func (e *Exporter) Records(r *report.Report, now time.Time) []Record {
+	var records []Record
+	for _, items := range r.ByCleaner() {
+		for _, i := range items {
+			rec := Record{
+				Provider:     r.Provider,
+				ResourceID:   i.Resource,
+				ResourceType: i.Type,
+				Region:       i.Region,
+				Tags:         i.Tags,
+				CaptureTime:  now,
+				Cleaner:      i.Cleaner,
+				Action:       string(i.Action),
+			}
+			if rec.Region == "" {
+				rec.Region = e.region
+			}
+			if i.CreatedAt != nil {
+				rec.ResourceCreationTime = i.CreatedAt
+				rec.AgeSeconds = int64(now.Sub(*i.CreatedAt).Seconds())
+			}
+
+			records = append(records, rec)
+		}
+	}
+
+	return records
+}
+
+// Encode renders the records as JSON lines.
+func Encode(records []Record) ([]byte, error) {
+	var b bytes.Buffer
+
+	enc := json.NewEncoder(&b)
+	for _, r := range records {
+		err := enc.Encode(r)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	return b.Bytes(), nil
+}
@@ -0,0 +1,89 @@
+package inventory
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+
+	"github.com/giantswarm/ci-cleaner/pkg/report"
+)
+
+func TestExport(t *testing.T) {
+	var uploadedPath string
+	var uploaded string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.Header.Get("x-ms-blob-type") != "BlockBlob" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		b, _ := ioutil.ReadAll(r.Body)
+		uploadedPath = r.URL.Path
+		uploaded = string(b)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer ts.Close()
+
+	e, err := New(Config{
+		Logger:    microloggertest.New(),
+		Uploaders: []Uploader{&BlobUploader{ContainerURL: ts.URL + "/inventory?sig=secret"}},
+
+		Region: "eu-central-1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	createdAt := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	r := report.New("aws")
+	r.Started = time.Date(2020, 1, 1, 2, 0, 0, 0, time.UTC)
+	r.Add(report.Item{
+		Cleaner:   "stacks",
+		Resource:  "cluster-ci-a",
+		Action:    report.ActionDeleted,
+		Type:      "AWS::CloudFormation::Stack",
+		Tags:      map[string]string{"giantswarm.io/cluster": "a"},
+		CreatedAt: &createdAt,
+	})
+
+	records := e.Records(r, createdAt.Add(2*time.Hour))
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(records))
+	}
+	if records[0].AgeSeconds != 7200 || records[0].Region != "eu-central-1" {
+		t.Errorf("expected age and default region to be set, got %#v", records[0])
+	}
+
+	err = e.Export(context.Background(), r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if uploadedPath != "/inventory/inventory-aws-20200101T020000Z.jsonl" {
+		t.Errorf("unexpected upload path %#q", uploadedPath)
+	}
+	if !strings.Contains(uploaded, `"resourceId":"cluster-ci-a"`) || !strings.Contains(uploaded, `"resourceType":"AWS::CloudFormation::Stack"`) {
+		t.Errorf("unexpected inventory %s", uploaded)
+	}
+}
+
+func TestNewS3Uploader(t *testing.T) {
+	u, err := NewS3Uploader(nil, "s3://compliance/ci-cleaner/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Bucket != "compliance" || u.Prefix != "ci-cleaner" {
+		t.Errorf("unexpected uploader %#v", u)
+	}
+
+	_, err = NewS3Uploader(nil, "compliance/ci-cleaner")
+	if !IsInvalidConfig(err) {
+		t.Errorf("expected invalid config error, got %#v", err)
+	}
+}
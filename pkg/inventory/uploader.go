@@ -0,0 +1,101 @@
+package inventory
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/giantswarm/microerror"
+)
+
+// S3Client describes the methods required to be implemented by a S3 AWS
+// client.
+type S3Client interface {
+	PutObjectWithContext(aws.Context, *s3.PutObjectInput, ...request.Option) (*s3.PutObjectOutput, error)
+}
+
+// S3Uploader uploads inventories into a S3 bucket.
+type S3Uploader struct {
+	Client S3Client
+	Bucket string
+	Prefix string
+}
+
+// NewS3Uploader creates an uploader for a location like
+// s3://bucket/prefix.
+func NewS3Uploader(client S3Client, location string) (*S3Uploader, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	if u.Scheme != "s3" || u.Host == "" {
+		return nil, microerror.Maskf(invalidConfigError, "S3 location must look like s3://bucket/prefix, got %#q", location)
+	}
+
+	s := &S3Uploader{
+		Client: client,
+		Bucket: u.Host,
+		Prefix: strings.Trim(u.Path, "/"),
+	}
+
+	return s, nil
+}
+
+// Upload implements Uploader.
+func (s *S3Uploader) Upload(ctx context.Context, name string, body []byte) error {
+	i := &s3.PutObjectInput{
+		Body:        bytes.NewReader(body),
+		Bucket:      aws.String(s.Bucket),
+		ContentType: aws.String("application/x-ndjson"),
+		Key:         aws.String(path.Join(s.Prefix, name)),
+	}
+
+	_, err := s.Client.PutObjectWithContext(ctx, i)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// BlobUploader uploads inventories into an Azure blob storage container.
+type BlobUploader struct {
+	// ContainerURL is the URL of the container including a SAS token
+	// allowing to create blobs, e.g.
+	// https://account.blob.core.windows.net/inventory?sv=...
+	ContainerURL string
+}
+
+// Upload implements Uploader.
+func (b *BlobUploader) Upload(ctx context.Context, name string, body []byte) error {
+	u, err := url.Parse(b.ContainerURL)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	u.Path = path.Join(u.Path, name)
+
+	req, err := http.NewRequest(http.MethodPut, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.Header.Set("x-ms-blob-type", "BlockBlob")
+
+	res, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusCreated {
+		return microerror.Maskf(executionFailedError, "uploading blob %#q returned status %d", name, res.StatusCode)
+	}
+
+	return nil
+}
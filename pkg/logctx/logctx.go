@@ -0,0 +1,168 @@
+// Package logctx carries what a log entry is about, like the run and the
+// cleaner, in context.Context, and provides a logger adding it to the entries
+// logged with that context. This keeps interleaved logs of cleaners running
+// in parallel attributable.
+//
+// Unlike loggermeta, whose key-value map is shared by all derived contexts,
+// the fields are copied whenever they change, so that contexts can be
+// derived concurrently.
+package logctx
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/go-stack/stack"
+)
+
+const (
+	KeyCleaner  = "cleaner"
+	KeyProvider = "provider"
+	KeyResource = "resource"
+	KeyRun      = "run"
+)
+
+type key struct{}
+
+// fields are the fields of a context, in the order they are logged.
+type fields struct {
+	run      string
+	provider string
+	cleaner  string
+	resource string
+}
+
+func fromContext(ctx context.Context) fields {
+	f, _ := ctx.Value(key{}).(fields)
+	return f
+}
+
+// WithRun returns a copy of ctx carrying the given run ID.
+func WithRun(ctx context.Context, id string) context.Context {
+	f := fromContext(ctx)
+	f.run = id
+	return context.WithValue(ctx, key{}, f)
+}
+
+// WithProvider returns a copy of ctx carrying the given provider.
+func WithProvider(ctx context.Context, provider string) context.Context {
+	f := fromContext(ctx)
+	f.provider = provider
+	return context.WithValue(ctx, key{}, f)
+}
+
+// WithCleaner returns a copy of ctx carrying the given cleaner name. The
+// resource is reset, as it belongs to the previous cleaner.
+func WithCleaner(ctx context.Context, name string) context.Context {
+	f := fromContext(ctx)
+	f.cleaner = name
+	f.resource = ""
+	return context.WithValue(ctx, key{}, f)
+}
+
+// WithResource returns a copy of ctx carrying the given resource ID.
+func WithResource(ctx context.Context, id string) context.Context {
+	f := fromContext(ctx)
+	f.resource = id
+	return context.WithValue(ctx, key{}, f)
+}
+
+// KeyVals returns the fields the given context carries as alternating
+// key-value pairs. Fields which are not set are omitted.
+func KeyVals(ctx context.Context) []interface{} {
+	f := fromContext(ctx)
+
+	var keyVals []interface{}
+	for _, kv := range [][2]string{
+		{KeyRun, f.run},
+		{KeyProvider, f.provider},
+		{KeyCleaner, f.cleaner},
+		{KeyResource, f.resource},
+	} {
+		if kv[1] != "" {
+			keyVals = append(keyVals, kv[0], kv[1])
+		}
+	}
+
+	return keyVals
+}
+
+// Caller is the micrologger.Config.Caller of the loggers wrapped by Logger.
+// It reports the caller of Logger instead of Logger itself, as
+// micrologger.DefaultCaller would.
+var Caller = func() interface{} {
+	return fmt.Sprintf("%+v", stack.Caller(5))
+}
+
+type Config struct {
+	// Logger is the logger entries are written to. It should be created with
+	// Caller.
+	Logger micrologger.Logger
+}
+
+// Logger is a micrologger.Logger which can be used across goroutines. It adds
+// the fields of the context to the entries logged with LogCtx, unless the
+// entries set them themselves.
+type Logger struct {
+	logger micrologger.Logger
+
+	// mutex serializes the entries of the logger and all loggers derived
+	// with With, so that loggers writing without synchronization of their
+	// own can be wrapped too.
+	mutex *sync.Mutex
+}
+
+func New(config Config) (*Logger, error) {
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+
+	l := &Logger{
+		logger: config.Logger,
+
+		mutex: &sync.Mutex{},
+	}
+
+	return l, nil
+}
+
+func (l *Logger) Log(keyVals ...interface{}) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.logger.Log(keyVals...)
+}
+
+func (l *Logger) LogCtx(ctx context.Context, keyVals ...interface{}) {
+	// keyVals is copied, so that the slice of the caller is not written to.
+	keyVals = append([]interface{}{}, keyVals...)
+
+	set := map[interface{}]bool{}
+	for i := 0; i < len(keyVals); i += 2 {
+		set[keyVals[i]] = true
+	}
+
+	fields := KeyVals(ctx)
+	for i := 0; i+1 < len(fields); i += 2 {
+		if set[fields[i]] {
+			continue
+		}
+		keyVals = append(keyVals, fields[i], fields[i+1])
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.logger.LogCtx(ctx, keyVals...)
+}
+
+func (l *Logger) With(keyVals ...interface{}) micrologger.Logger {
+	return &Logger{
+		logger: l.logger.With(keyVals...),
+
+		mutex: l.mutex,
+	}
+}
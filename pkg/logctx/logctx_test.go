@@ -0,0 +1,124 @@
+package logctx
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/giantswarm/micrologger"
+)
+
+func newTestLogger(t *testing.T, buf *bytes.Buffer) *Logger {
+	microLogger, err := micrologger.New(micrologger.Config{Caller: Caller, IOWriter: buf})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := New(Config{Logger: microLogger})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return l
+}
+
+func entries(t *testing.T, buf *bytes.Buffer) []map[string]string {
+	var entries []map[string]string
+
+	s := bufio.NewScanner(buf)
+	for s.Scan() {
+		var e map[string]string
+		err := json.Unmarshal(s.Bytes(), &e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, e)
+	}
+
+	return entries
+}
+
+func TestLogCtx(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := newTestLogger(t, buf)
+
+	ctx := WithRun(context.Background(), "aws-20240517T120000Z")
+	ctx = WithProvider(ctx, "aws")
+	ctx = WithCleaner(WithResource(ctx, "stale"), "stacks")
+	l.LogCtx(ctx, "message", "running")
+	l.LogCtx(WithResource(ctx, "cluster-ci-1"), "message", "deleting")
+	l.LogCtx(ctx, "message", "overridden", KeyCleaner, "buckets")
+	l.With("component", "test").LogCtx(ctx, "message", "derived")
+	l.Log("message", "plain")
+
+	testCases := []map[string]string{
+		{"message": "running", KeyRun: "aws-20240517T120000Z", KeyProvider: "aws", KeyCleaner: "stacks"},
+		{"message": "deleting", KeyRun: "aws-20240517T120000Z", KeyProvider: "aws", KeyCleaner: "stacks", KeyResource: "cluster-ci-1"},
+		{"message": "overridden", KeyRun: "aws-20240517T120000Z", KeyProvider: "aws", KeyCleaner: "buckets"},
+		{"message": "derived", KeyRun: "aws-20240517T120000Z", KeyProvider: "aws", KeyCleaner: "stacks", "component": "test"},
+		{"message": "plain"},
+	}
+
+	got := entries(t, buf)
+	if len(got) != len(testCases) {
+		t.Fatalf("expected %d entries, got %d", len(testCases), len(got))
+	}
+
+	for i, expected := range testCases {
+		for _, k := range []string{"message", KeyRun, KeyProvider, KeyCleaner, KeyResource, "component"} {
+			if got[i][k] != expected[k] {
+				t.Errorf("entry %d: expected %s %#q, got %#q", i, k, expected[k], got[i][k])
+			}
+		}
+
+		if !strings.Contains(got[i]["caller"], "logctx_test.go") {
+			t.Errorf("entry %d: expected caller in logctx_test.go, got %#q", i, got[i]["caller"])
+		}
+	}
+}
+
+func TestLogCtxConcurrent(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := newTestLogger(t, buf)
+
+	ctx := WithRun(context.Background(), "azure-20240517T120000Z")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			ctx := WithCleaner(ctx, fmt.Sprintf("cleaner-%d", i))
+			for j := 0; j < 20; j++ {
+				l.LogCtx(WithResource(ctx, fmt.Sprintf("resource-%d", j)), "message", fmt.Sprintf("%d/%d", i, j))
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	got := entries(t, buf)
+	if len(got) != 200 {
+		t.Fatalf("expected 200 entries, got %d", len(got))
+	}
+
+	for _, e := range got {
+		var i, j int
+		_, err := fmt.Sscanf(e["message"], "%d/%d", &i, &j)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if e[KeyCleaner] != fmt.Sprintf("cleaner-%d", i) || e[KeyResource] != fmt.Sprintf("resource-%d", j) {
+			t.Errorf("entry %#q attributed to cleaner %#q and resource %#q", e["message"], e[KeyCleaner], e[KeyResource])
+		}
+		if e[KeyRun] != "azure-20240517T120000Z" {
+			t.Errorf("entry %#q attributed to run %#q", e["message"], e[KeyRun])
+		}
+	}
+}
@@ -0,0 +1,235 @@
+// Package metrics counts the resources ci-cleaner runs found in the
+// Prometheus text exposition format, so that dashboards can break deletions
+// and skips down by cleaner and reason without scraping logs.
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/report"
+)
+
+const (
+	// quotaUtilizationName is the name of the gauge of the utilization of
+	// service quotas.
+	quotaUtilizationName = "ci_cleaner_quota_utilization_ratio"
+	// resourcesName is the name of the counter of the resources found by
+	// the cleaners.
+	resourcesName = "ci_cleaner_resources_total"
+	// timeToCleanName is the name of the gauge of the time-to-clean
+	// percentiles by resource type.
+	timeToCleanName = "ci_cleaner_time_to_clean_seconds"
+	// timeToCleanSLOName is the name of the gauge of the time-to-clean
+	// objectives by resource type.
+	timeToCleanSLOName = "ci_cleaner_time_to_clean_slo_seconds"
+	// unknownReason is the reason of items of reports which do not carry a
+	// reason.
+	unknownReason = "unknown"
+)
+
+// labels identify a single series of the resources counter.
+type labels struct {
+	provider string
+	cleaner  string
+	region   string
+	action   report.Action
+	reason   string
+	// originRepo is the repository which created the resources. The label
+	// is left out for resources which are not tagged with it.
+	originRepo string
+}
+
+// quotaLabels identify a single series of the quota utilization gauge.
+type quotaLabels struct {
+	provider string
+	region   string
+	quota    string
+}
+
+// Metrics holds the counters of all runs observed so far. It is safe for
+// concurrent use.
+type Metrics struct {
+	mutex     sync.Mutex
+	resources map[labels]int
+	// quotas holds the latest utilization of every service quota.
+	quotas map[quotaLabels]float64
+	// timeToClean holds the latest time-to-clean of every provider and
+	// resource type.
+	timeToClean map[string]report.TimeToClean
+}
+
+// New creates metrics without any observations.
+func New() *Metrics {
+	m := &Metrics{
+		quotas:      map[quotaLabels]float64{},
+		resources:   map[labels]int{},
+		timeToClean: map[string]report.TimeToClean{},
+	}
+
+	return m
+}
+
+// Observe counts the items of the given report and keeps its time-to-clean
+// and quota utilization. region is used for items whose cleaner did not set
+// a region and for the quotas.
+func (m *Metrics) Observe(r *report.Report, region string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, q := range r.Quotas {
+		m.quotas[quotaLabels{provider: r.Provider, region: region, quota: q.Name}] = q.Utilization()
+	}
+
+	for _, t := range r.TimeToClean {
+		m.timeToClean[t.Provider+"/"+t.Type] = t
+	}
+
+	for _, items := range r.ByCleaner() {
+		for _, item := range items {
+			l := labels{
+				provider: r.Provider,
+				cleaner:  item.Cleaner,
+				region:   item.Region,
+				action:   item.Action,
+				reason:   item.Reason,
+
+				originRepo: item.OriginRepo,
+			}
+			if l.region == "" {
+				l.region = region
+			}
+			if l.reason == "" {
+				l.reason = unknownReason
+			}
+
+			m.resources[l]++
+		}
+	}
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mutex.Lock()
+	var lines []string
+	for l, v := range m.resources {
+		var originRepo string
+		if l.originRepo != "" {
+			originRepo = ",origin_repo=" + quote(l.originRepo)
+		}
+		lines = append(lines, fmt.Sprintf("%s{provider=%s,cleaner=%s,region=%s,action=%s,reason=%s%s} %d\n", resourcesName, quote(l.provider), quote(l.cleaner), quote(l.region), quote(string(l.action)), quote(l.reason), originRepo, v))
+	}
+	var quotaLines []string
+	for l, v := range m.quotas {
+		quotaLines = append(quotaLines, fmt.Sprintf("%s{provider=%s,region=%s,quota=%s} %g\n", quotaUtilizationName, quote(l.provider), quote(l.region), quote(l.quota), v))
+	}
+	var ttcLines, sloLines []string
+	for _, t := range m.timeToClean {
+		for _, q := range []struct {
+			quantile string
+			value    float64
+		}{
+			{quantile: "0.5", value: t.P50.Seconds()},
+			{quantile: "0.95", value: t.P95.Seconds()},
+		} {
+			ttcLines = append(ttcLines, fmt.Sprintf("%s{provider=%s,type=%s,quantile=%s} %g\n", timeToCleanName, quote(t.Provider), quote(t.Type), quote(q.quantile), q.value))
+		}
+		if t.Threshold != 0 {
+			sloLines = append(sloLines, fmt.Sprintf("%s{provider=%s,type=%s} %g\n", timeToCleanSLOName, quote(t.Provider), quote(t.Type), t.Threshold.Seconds()))
+		}
+	}
+	m.mutex.Unlock()
+
+	sort.Strings(lines)
+	sort.Strings(quotaLines)
+	sort.Strings(ttcLines)
+	sort.Strings(sloLines)
+
+	b := &bytes.Buffer{}
+	fmt.Fprintf(b, "# HELP %s Resources found by the cleaners by what happened to them and why they were candidates.\n", resourcesName)
+	fmt.Fprintf(b, "# TYPE %s counter\n", resourcesName)
+	for _, l := range lines {
+		b.WriteString(l)
+	}
+	if len(quotaLines) != 0 {
+		fmt.Fprintf(b, "# HELP %s Share of the service quotas leaked resources count against in use after the last run.\n", quotaUtilizationName)
+		fmt.Fprintf(b, "# TYPE %s gauge\n", quotaUtilizationName)
+		for _, l := range quotaLines {
+			b.WriteString(l)
+		}
+	}
+	if len(ttcLines) != 0 {
+		fmt.Fprintf(b, "# HELP %s How long deleted resources lived before they were deleted by type, over the SLO window.\n", timeToCleanName)
+		fmt.Fprintf(b, "# TYPE %s gauge\n", timeToCleanName)
+		for _, l := range ttcLines {
+			b.WriteString(l)
+		}
+	}
+	if len(sloLines) != 0 {
+		fmt.Fprintf(b, "# HELP %s Objective for the 95th percentile of the time-to-clean by type.\n", timeToCleanSLOName)
+		fmt.Fprintf(b, "# TYPE %s gauge\n", timeToCleanSLOName)
+		for _, l := range sloLines {
+			b.WriteString(l)
+		}
+	}
+
+	n, err := w.Write(b.Bytes())
+	if err != nil {
+		return int64(n), microerror.Mask(err)
+	}
+
+	return int64(n), nil
+}
+
+// WriteFile writes the metrics to the file at the given path, e.g. for the
+// textfile collector of the node exporter. The file is replaced atomically,
+// so that it is never collected half-written.
+func (m *Metrics) WriteFile(path string) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	defer os.Remove(f.Name())
+
+	_, err = m.WriteTo(f)
+	if err != nil {
+		f.Close()
+		return microerror.Mask(err)
+	}
+
+	err = f.Close()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = os.Rename(f.Name(), path)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// ServeHTTP serves the metrics to Prometheus.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	_, _ = m.WriteTo(w)
+}
+
+// quote quotes the given label value as the text exposition format requires.
+func quote(v string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+	return `"` + r.Replace(v) + `"`
+}
@@ -0,0 +1,171 @@
+package metrics
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/ci-cleaner/pkg/report"
+)
+
+func TestObserve(t *testing.T) {
+	m := New()
+
+	for i := 0; i < 2; i++ {
+		r := report.New("aws")
+		r.Add(report.Item{Cleaner: "delegation-records", Resource: "a", Action: report.ActionDeleted, Reason: "dns-stale"})
+		r.Add(report.Item{Cleaner: "stacks", Resource: "b", Action: report.ActionProtected, Reason: "age-expired", Region: "eu-west-1"})
+		r.Add(report.Item{Cleaner: "stacks", Resource: "c", Action: report.ActionDeleted})
+		m.Observe(r, "eu-central-1")
+	}
+
+	b := &bytes.Buffer{}
+	_, err := m.WriteTo(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `# HELP ci_cleaner_resources_total Resources found by the cleaners by what happened to them and why they were candidates.
+# TYPE ci_cleaner_resources_total counter
+ci_cleaner_resources_total{provider="aws",cleaner="delegation-records",region="eu-central-1",action="deleted",reason="dns-stale"} 2
+ci_cleaner_resources_total{provider="aws",cleaner="stacks",region="eu-central-1",action="deleted",reason="unknown"} 2
+ci_cleaner_resources_total{provider="aws",cleaner="stacks",region="eu-west-1",action="protected",reason="age-expired"} 2
+`
+	if b.String() != expected {
+		t.Errorf("want\n%s\ngot\n%s", expected, b.String())
+	}
+}
+
+func TestObserveOriginRepo(t *testing.T) {
+	m := New()
+
+	r := report.New("aws")
+	r.Add(report.Item{Cleaner: "stacks", Resource: "a", Action: report.ActionDeleted, Reason: "age-expired", OriginRepo: "giantswarm/cluster-aws"})
+	r.Add(report.Item{Cleaner: "stacks", Resource: "b", Action: report.ActionDeleted, Reason: "age-expired", OriginRepo: "giantswarm/cluster-aws"})
+	r.Add(report.Item{Cleaner: "stacks", Resource: "c", Action: report.ActionDeleted, Reason: "age-expired"})
+	m.Observe(r, "eu-central-1")
+
+	b := &bytes.Buffer{}
+	_, err := m.WriteTo(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `# HELP ci_cleaner_resources_total Resources found by the cleaners by what happened to them and why they were candidates.
+# TYPE ci_cleaner_resources_total counter
+ci_cleaner_resources_total{provider="aws",cleaner="stacks",region="eu-central-1",action="deleted",reason="age-expired",origin_repo="giantswarm/cluster-aws"} 2
+ci_cleaner_resources_total{provider="aws",cleaner="stacks",region="eu-central-1",action="deleted",reason="age-expired"} 1
+`
+	if b.String() != expected {
+		t.Errorf("want\n%s\ngot\n%s", expected, b.String())
+	}
+}
+
+func TestObserveTimeToClean(t *testing.T) {
+	m := New()
+
+	r := report.New("aws")
+	r.SetTimeToClean([]report.TimeToClean{
+		{Provider: "aws", Type: "AWS::EC2::Instance", Count: 3, P50: time.Hour, P95: 2 * time.Hour, Threshold: 6 * time.Hour},
+		{Provider: "aws", Type: "AWS::EC2::Volume", Count: 1, P50: 90 * time.Minute, P95: 90 * time.Minute},
+	})
+	m.Observe(r, "eu-central-1")
+
+	b := &bytes.Buffer{}
+	_, err := m.WriteTo(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `# HELP ci_cleaner_resources_total Resources found by the cleaners by what happened to them and why they were candidates.
+# TYPE ci_cleaner_resources_total counter
+# HELP ci_cleaner_time_to_clean_seconds How long deleted resources lived before they were deleted by type, over the SLO window.
+# TYPE ci_cleaner_time_to_clean_seconds gauge
+ci_cleaner_time_to_clean_seconds{provider="aws",type="AWS::EC2::Instance",quantile="0.5"} 3600
+ci_cleaner_time_to_clean_seconds{provider="aws",type="AWS::EC2::Instance",quantile="0.95"} 7200
+ci_cleaner_time_to_clean_seconds{provider="aws",type="AWS::EC2::Volume",quantile="0.5"} 5400
+ci_cleaner_time_to_clean_seconds{provider="aws",type="AWS::EC2::Volume",quantile="0.95"} 5400
+# HELP ci_cleaner_time_to_clean_slo_seconds Objective for the 95th percentile of the time-to-clean by type.
+# TYPE ci_cleaner_time_to_clean_slo_seconds gauge
+ci_cleaner_time_to_clean_slo_seconds{provider="aws",type="AWS::EC2::Instance"} 21600
+`
+	if b.String() != expected {
+		t.Errorf("want\n%s\ngot\n%s", expected, b.String())
+	}
+}
+
+func TestObserveQuotas(t *testing.T) {
+	m := New()
+
+	r := report.New("aws")
+	r.SetQuotas([]report.Quota{
+		{Name: "vpcs-per-region", Usage: 4, Limit: 5},
+		{Name: "iam-roles", Usage: 100, Limit: 1000},
+	})
+	m.Observe(r, "eu-central-1")
+
+	b := &bytes.Buffer{}
+	_, err := m.WriteTo(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `# HELP ci_cleaner_resources_total Resources found by the cleaners by what happened to them and why they were candidates.
+# TYPE ci_cleaner_resources_total counter
+# HELP ci_cleaner_quota_utilization_ratio Share of the service quotas leaked resources count against in use after the last run.
+# TYPE ci_cleaner_quota_utilization_ratio gauge
+ci_cleaner_quota_utilization_ratio{provider="aws",region="eu-central-1",quota="iam-roles"} 0.1
+ci_cleaner_quota_utilization_ratio{provider="aws",region="eu-central-1",quota="vpcs-per-region"} 0.8
+`
+	if b.String() != expected {
+		t.Errorf("want\n%s\ngot\n%s", expected, b.String())
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ci-cleaner-metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	m := New()
+	r := report.New("azure")
+	r.Add(report.Item{Cleaner: "resource-groups", Resource: "ci-\"quoted\"", Action: report.ActionReported, Reason: "age-expired"})
+	m.Observe(r, "westeurope")
+
+	path := filepath.Join(dir, "ci-cleaner.prom")
+	err = m.WriteFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `cleaner="resource-groups",region="westeurope",action="reported"`) {
+		t.Errorf("unexpected metrics %s", b)
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("expected temporary files to be removed, got %d files", len(files))
+	}
+}
+
+func TestQuote(t *testing.T) {
+	actual := quote("a\\b\"c\nd")
+	expected := `"a\\b\"c\nd"`
+
+	if actual != expected {
+		t.Errorf("want %s, got %s", expected, actual)
+	}
+}
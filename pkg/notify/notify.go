@@ -0,0 +1,464 @@
+// Package notify informs humans about the outcome of a ci-cleaner run. Items
+// are batched into one message per cleaner and failures which already
+// occurred in previous runs are collapsed into an occurrence counter instead
+// of being reported again, so that large runs do not flood channels.
+package notify
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+
+	"github.com/giantswarm/ci-cleaner/pkg/owner"
+	"github.com/giantswarm/ci-cleaner/pkg/report"
+	"github.com/giantswarm/ci-cleaner/pkg/selftest"
+	"github.com/giantswarm/ci-cleaner/pkg/state"
+)
+
+const (
+	// defaultMaxResources is the default number of resources listed per
+	// action in a single message.
+	defaultMaxResources = 10
+
+	// timeToCleanCleaner is the cleaner name of messages about resource
+	// types exceeding their time-to-clean objective.
+	timeToCleanCleaner = "time-to-clean"
+
+	keyPrefix            = "notify/failure/"
+	manualKeyPrefix      = "notify/manual/"
+	timeToCleanKeyPrefix = "notify/time-to-clean/"
+)
+
+type Config struct {
+	Logger micrologger.Logger
+	Sinks  []Sink
+	State  *state.Store
+
+	// MaxResources is the number of resources listed per action in a single
+	// message. Further resources are only counted.
+	MaxResources int
+}
+
+type Notifier struct {
+	logger micrologger.Logger
+	sinks  []Sink
+	state  *state.Store
+
+	maxResources int
+}
+
+// Message summarizes what a single cleaner did during a run.
+type Message struct {
+	Provider string    `json:"provider"`
+	Cleaner  string    `json:"cleaner"`
+	Deleted  []string  `json:"deleted,omitempty"`
+	Reported []string  `json:"reported,omitempty"`
+	Failures []Failure `json:"failures,omitempty"`
+	// Expensive are the resources which are billed heavily and were not
+	// deleted. Messages listing them are of high severity.
+	Expensive []Expensive `json:"expensive,omitempty"`
+	// ManualIntervention are the resources the ci-cleaner gave up on in
+	// this run.
+	ManualIntervention []Escalation `json:"manualIntervention,omitempty"`
+	// BudgetExceeded are the resources which were held back for human review
+	// as deleting them exceeded the cost budget of the run. Messages listing
+	// them are of high severity.
+	BudgetExceeded []string `json:"budgetExceeded,omitempty"`
+	// SelfTestFailed are the canary resources of the self-test which were
+	// not deleted in time, meaning that the cleanup is broken. Messages
+	// listing them are of high severity.
+	SelfTestFailed []string `json:"selfTestFailed,omitempty"`
+	// TimeToCleanExceeded are the resource types whose 95th percentile of
+	// the time-to-clean newly exceeds its objective. Messages listing them
+	// are of high severity.
+	TimeToCleanExceeded []string `json:"timeToCleanExceeded,omitempty"`
+	// Freeze describes the freeze window the run fell into, which made it
+	// only report what it would delete.
+	Freeze string `json:"freeze,omitempty"`
+	// Owners counts the resources of the message by the installation and
+	// cluster they belong to according to their tags, e.g.
+	// "gauss/ci-wip-a1b2c". Resources without such tags are not counted.
+	Owners map[string]int `json:"owners,omitempty"`
+
+	maxResources int
+}
+
+// Escalation is a resource which requires manual intervention.
+type Escalation struct {
+	Resource    string   `json:"resource"`
+	Survived    int      `json:"survived"`
+	Diagnostics []string `json:"diagnostics,omitempty"`
+}
+
+// Expensive is a resource which keeps being billed heavily.
+type Expensive struct {
+	Resource string        `json:"resource"`
+	Action   report.Action `json:"action"`
+	Cost     string        `json:"cost"`
+}
+
+// Failure is a distinct error hit by a cleaner on one or more resources.
+type Failure struct {
+	Error     string   `json:"error"`
+	Resources []string `json:"resources"`
+	// Occurrences is the number of runs the failure was seen in. It is 1
+	// for failures which are new in this run.
+	Occurrences int `json:"occurrences"`
+}
+
+func New(config Config) (*Notifier, error) {
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.State == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.State must not be empty", config)
+	}
+
+	if config.MaxResources == 0 {
+		config.MaxResources = defaultMaxResources
+	}
+
+	n := &Notifier{
+		logger: config.Logger,
+		sinks:  config.Sinks,
+		state:  config.State,
+
+		maxResources: config.MaxResources,
+	}
+
+	return n, nil
+}
+
+// Notify sends one message per cleaner of the given report to all sinks.
+// Cleaners which neither deleted nor reported anything and only hit failures
+// which were already notified about are skipped.
+func (n *Notifier) Notify(ctx context.Context, r *report.Report) error {
+	if len(n.sinks) == 0 {
+		return nil
+	}
+
+	messages, err := n.messages(r)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, m := range messages {
+		for _, s := range n.sinks {
+			err := s.Send(ctx, m)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+
+		n.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("sent notification for cleaner %#q", m.Cleaner))
+	}
+
+	return nil
+}
+
+// messages batches the items of the given report into messages and updates
+// the occurrence counters of the failures.
+func (n *Notifier) messages(r *report.Report) ([]Message, error) {
+	byCleaner := r.ByCleaner()
+
+	var cleaners []string
+	for c := range byCleaner {
+		cleaners = append(cleaners, c)
+	}
+	sort.Strings(cleaners)
+
+	var messages []Message
+	for _, c := range cleaners {
+		m := Message{
+			Provider: r.Provider,
+			Cleaner:  c,
+
+			maxResources: n.maxResources,
+		}
+		if r.Freeze != nil {
+			m.Freeze = r.Freeze.String()
+		}
+
+		failures := map[string]*Failure{}
+		var isNew bool
+		for _, i := range byCleaner[c] {
+			o := owner.Owner{Installation: i.Installation, Cluster: i.Cluster}
+			if !o.IsZero() {
+				if m.Owners == nil {
+					m.Owners = map[string]int{}
+				}
+				m.Owners[o.String()]++
+			}
+
+			if i.Cost != "" && i.Action != report.ActionDeleted && i.Action != report.ActionDeleting {
+				m.Expensive = append(m.Expensive, Expensive{Resource: i.Resource, Action: i.Action, Cost: i.Cost})
+			}
+
+			switch i.Action {
+			case report.ActionDeleted:
+				m.Deleted = append(m.Deleted, i.Resource)
+			case report.ActionReported:
+				m.Reported = append(m.Reported, i.Resource)
+			case report.ActionBudgetExceeded:
+				m.BudgetExceeded = append(m.BudgetExceeded, i.Resource)
+			case report.ActionFailed:
+				occurrences, err := n.countFailure(i)
+				if err != nil {
+					return nil, microerror.Mask(err)
+				}
+				if occurrences == 1 {
+					isNew = true
+				}
+
+				f, ok := failures[i.Error]
+				if !ok {
+					f = &Failure{Error: i.Error}
+					failures[i.Error] = f
+				}
+				f.Resources = append(f.Resources, i.Resource)
+				if occurrences > f.Occurrences {
+					f.Occurrences = occurrences
+				}
+
+				if i.ManualIntervention {
+					isNewEscalation, err := n.isNewEscalation(i)
+					if err != nil {
+						return nil, microerror.Mask(err)
+					}
+					if isNewEscalation {
+						isNew = true
+						m.ManualIntervention = append(m.ManualIntervention, Escalation{
+							Resource:    i.Resource,
+							Survived:    i.Survived,
+							Diagnostics: i.Diagnostics,
+						})
+					}
+				}
+			}
+		}
+
+		for _, f := range failures {
+			sort.Strings(f.Resources)
+			m.Failures = append(m.Failures, *f)
+		}
+		sort.Slice(m.Failures, func(i, j int) bool { return m.Failures[i].Error < m.Failures[j].Error })
+		sort.Strings(m.Deleted)
+		sort.Strings(m.Reported)
+		sort.Strings(m.BudgetExceeded)
+		sort.Slice(m.Expensive, func(i, j int) bool { return m.Expensive[i].Resource < m.Expensive[j].Resource })
+
+		if len(m.Deleted) == 0 && len(m.Reported) == 0 && len(m.Expensive) == 0 && len(m.BudgetExceeded) == 0 && !isNew {
+			continue
+		}
+
+		messages = append(messages, m)
+	}
+
+	// Failed self-tests are notified about in every run until they pass,
+	// as resources are most likely not cleaned up anymore meanwhile.
+	var selfTestFailed []string
+	for _, res := range r.SelfTest {
+		if res.Status == selftest.StatusFailed {
+			selfTestFailed = append(selfTestFailed, fmt.Sprintf("%s (%s)", res.Resource, res.Probe))
+		}
+	}
+	if len(selfTestFailed) != 0 {
+		sort.Strings(selfTestFailed)
+		m := Message{
+			Provider:       r.Provider,
+			Cleaner:        selftest.Cleaner,
+			SelfTestFailed: selfTestFailed,
+
+			maxResources: n.maxResources,
+		}
+		messages = append(messages, m)
+	}
+
+	timeToCleanExceeded, err := n.timeToCleanExceeded(r)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	if len(timeToCleanExceeded) != 0 {
+		m := Message{
+			Provider:            r.Provider,
+			Cleaner:             timeToCleanCleaner,
+			TimeToCleanExceeded: timeToCleanExceeded,
+
+			maxResources: n.maxResources,
+		}
+		messages = append(messages, m)
+	}
+
+	return messages, nil
+}
+
+// timeToCleanExceeded returns the resource types of the given report whose
+// time-to-clean newly exceeds the objective. Types are notified about again
+// only after they were within the objective in between.
+func (n *Notifier) timeToCleanExceeded(r *report.Report) ([]string, error) {
+	var exceeded []string
+	for _, t := range r.TimeToClean {
+		key := timeToCleanKeyPrefix + t.Provider + "/" + t.Type
+
+		if !t.Exceeded() {
+			n.state.Delete(key)
+			continue
+		}
+
+		ok, err := n.state.Get(key, new(int))
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		// The entry is written in every run to keep it from expiring while
+		// the objective is still exceeded.
+		err = n.state.Put(key, t.Count)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		if !ok {
+			exceeded = append(exceeded, fmt.Sprintf("%s: p95 %s exceeds %s", t.Type, t.P95, t.Threshold))
+		}
+	}
+
+	return exceeded, nil
+}
+
+// countFailure increments and returns the number of runs the failure of the
+// given item was seen in. Failures are identical when cleaner, resource and
+// error are equal.
+func (n *Notifier) countFailure(i report.Item) (int, error) {
+	sum := sha256.Sum256([]byte(i.Cleaner + "\x00" + i.Resource + "\x00" + i.Error))
+	key := keyPrefix + hex.EncodeToString(sum[:8])
+
+	var occurrences int
+	_, err := n.state.Get(key, &occurrences)
+	if err != nil {
+		return 0, microerror.Mask(err)
+	}
+
+	occurrences++
+
+	err = n.state.Put(key, occurrences)
+	if err != nil {
+		return 0, microerror.Mask(err)
+	}
+
+	return occurrences, nil
+}
+
+// isNewEscalation returns whether no notification was sent yet about the
+// resource of the given item requiring manual intervention.
+func (n *Notifier) isNewEscalation(i report.Item) (bool, error) {
+	sum := sha256.Sum256([]byte(i.Cleaner + "\x00" + i.Resource))
+	key := manualKeyPrefix + hex.EncodeToString(sum[:8])
+
+	ok, err := n.state.Get(key, new(int))
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	// The entry is written in every run to keep it from expiring while the
+	// resource is still around.
+	err = n.state.Put(key, i.Survived)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	return !ok, nil
+}
+
+// Text renders the message for humans.
+func (m Message) Text() string {
+	var lines []string
+
+	header := fmt.Sprintf("%s cleaner `%s`: %d deleted, %d reported, %d failed", m.Provider, m.Cleaner, len(m.Deleted), len(m.Reported), m.failed())
+	if m.HighSeverity() {
+		header = ":rotating_light: " + header
+	}
+	lines = append(lines, header)
+
+	if m.Freeze != "" {
+		lines = append(lines, "report-only during freeze: "+m.Freeze)
+	}
+	for _, e := range m.Expensive {
+		lines = append(lines, fmt.Sprintf("still billed (%s): %s: %s", e.Action, e.Resource, e.Cost))
+	}
+
+	if len(m.Deleted) != 0 {
+		lines = append(lines, "deleted: "+m.list(m.Deleted))
+	}
+	if len(m.Reported) != 0 {
+		lines = append(lines, "would delete: "+m.list(m.Reported))
+	}
+	if len(m.BudgetExceeded) != 0 {
+		lines = append(lines, "held back for review, cost budget exceeded: "+m.list(m.BudgetExceeded))
+	}
+	if len(m.SelfTestFailed) != 0 {
+		lines = append(lines, "self-test failed, canaries not deleted in time: "+m.list(m.SelfTestFailed))
+	}
+	if len(m.TimeToCleanExceeded) != 0 {
+		lines = append(lines, "time-to-clean objective exceeded: "+m.list(m.TimeToCleanExceeded))
+	}
+	if len(m.Owners) != 0 {
+		var owners []string
+		for o, n := range m.Owners {
+			owners = append(owners, fmt.Sprintf("%s (%d)", o, n))
+		}
+		sort.Strings(owners)
+		lines = append(lines, "by owner: "+strings.Join(owners, ", "))
+	}
+	for _, f := range m.Failures {
+		seen := "new"
+		if f.Occurrences > 1 {
+			seen = fmt.Sprintf("seen in %d runs", f.Occurrences)
+		}
+		lines = append(lines, fmt.Sprintf("failed (%s): %s: %s", seen, m.list(f.Resources), f.Error))
+	}
+	for _, e := range m.ManualIntervention {
+		line := fmt.Sprintf("manual intervention required: %s survived %d runs", e.Resource, e.Survived)
+		for _, d := range e.Diagnostics {
+			line += "\n  " + d
+		}
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// HighSeverity returns whether the message calls out expensive resources
+// which keep being billed, resources held back as the cost budget of the
+// run was exceeded, a failed self-test or resource types exceeding their
+// time-to-clean objective.
+func (m Message) HighSeverity() bool {
+	return len(m.Expensive) != 0 || len(m.BudgetExceeded) != 0 || len(m.SelfTestFailed) != 0 || len(m.TimeToCleanExceeded) != 0
+}
+
+func (m Message) failed() int {
+	var n int
+	for _, f := range m.Failures {
+		n += len(f.Resources)
+	}
+
+	return n
+}
+
+func (m Message) list(resources []string) string {
+	max := m.maxResources
+	if max == 0 {
+		max = defaultMaxResources
+	}
+
+	if len(resources) <= max {
+		return strings.Join(resources, ", ")
+	}
+
+	return fmt.Sprintf("%s and %d more", strings.Join(resources[:max], ", "), len(resources)-max)
+}
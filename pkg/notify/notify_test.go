@@ -0,0 +1,370 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+
+	"github.com/giantswarm/ci-cleaner/pkg/report"
+	"github.com/giantswarm/ci-cleaner/pkg/selftest"
+	"github.com/giantswarm/ci-cleaner/pkg/state"
+)
+
+type sinkMock struct {
+	messages []Message
+}
+
+func (s *sinkMock) Send(ctx context.Context, m Message) error {
+	s.messages = append(s.messages, m)
+	return nil
+}
+
+func TestNotify(t *testing.T) {
+	stateStore, err := state.New(state.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sink := &sinkMock{}
+
+	n, err := New(Config{
+		Logger: microloggertest.New(),
+		Sinks:  []Sink{sink},
+		State:  stateStore,
+
+		MaxResources: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first run deletes many stacks and fails to delete a bucket.
+	{
+		r := report.New("aws")
+		for i := 0; i < 5; i++ {
+			r.Add(report.Item{Cleaner: "stacks", Resource: fmt.Sprintf("cluster-ci-%d", i), Action: report.ActionDeleted})
+		}
+		r.Add(report.Item{Cleaner: "buckets", Resource: "ci-a", Action: report.ActionFailed, Error: "access denied"})
+		r.Add(report.Item{Cleaner: "buckets", Resource: "ci-b", Action: report.ActionFailed, Error: "access denied"})
+
+		err = n.Notify(context.Background(), r)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(sink.messages) != 2 {
+			t.Fatalf("expected one message per cleaner, got %d", len(sink.messages))
+		}
+
+		buckets := sink.messages[0]
+		if len(buckets.Failures) != 1 || len(buckets.Failures[0].Resources) != 2 || buckets.Failures[0].Occurrences != 1 {
+			t.Errorf("expected identical failures to be batched, got %#v", buckets.Failures)
+		}
+
+		expected := "aws cleaner `stacks`: 5 deleted, 0 reported, 0 failed\ndeleted: cluster-ci-0, cluster-ci-1 and 3 more"
+		if text := sink.messages[1].Text(); text != expected {
+			t.Errorf("expected text %q, got %q", expected, text)
+		}
+	}
+
+	// The second run only hits the same failures again, which were already
+	// notified about.
+	{
+		sink.messages = nil
+
+		r := report.New("aws")
+		r.Add(report.Item{Cleaner: "buckets", Resource: "ci-a", Action: report.ActionFailed, Error: "access denied"})
+
+		err = n.Notify(context.Background(), r)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(sink.messages) != 0 {
+			t.Fatalf("expected repeated failures to be deduplicated, got %#v", sink.messages)
+		}
+	}
+
+	// The third run hits a new failure, so the known one is sent along with
+	// its occurrence counter.
+	{
+		sink.messages = nil
+
+		r := report.New("aws")
+		r.Add(report.Item{Cleaner: "buckets", Resource: "ci-a", Action: report.ActionFailed, Error: "access denied"})
+		r.Add(report.Item{Cleaner: "buckets", Resource: "ci-c", Action: report.ActionFailed, Error: "bucket not empty"})
+
+		err = n.Notify(context.Background(), r)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(sink.messages) != 1 {
+			t.Fatalf("expected one message, got %d", len(sink.messages))
+		}
+
+		expected := "aws cleaner `buckets`: 0 deleted, 0 reported, 2 failed\nfailed (seen in 3 runs): ci-a: access denied\nfailed (new): ci-c: bucket not empty"
+		if text := sink.messages[0].Text(); text != expected {
+			t.Errorf("expected text %q, got %q", expected, text)
+		}
+	}
+}
+
+func TestNotifyManualIntervention(t *testing.T) {
+	stateStore, err := state.New(state.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sink := &sinkMock{}
+
+	n, err := New(Config{
+		Logger: microloggertest.New(),
+		Sinks:  []Sink{sink},
+		State:  stateStore,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		r := report.New("aws")
+		r.Add(report.Item{
+			Cleaner:            "stacks",
+			Resource:           "cluster-ci-a",
+			Action:             report.ActionFailed,
+			Error:              fmt.Sprintf("attempt %d failed", i),
+			Survived:           6 + i,
+			Diagnostics:        []string{"VPC: has dependencies"},
+			ManualIntervention: true,
+		})
+
+		err = n.Notify(context.Background(), r)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(sink.messages) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(sink.messages))
+	}
+	if len(sink.messages[0].ManualIntervention) != 1 {
+		t.Errorf("expected first message to require manual intervention, got %#v", sink.messages[0])
+	}
+	if len(sink.messages[1].ManualIntervention) != 0 {
+		t.Errorf("expected manual intervention to be notified only once, got %#v", sink.messages[1])
+	}
+}
+
+func TestNotifyExpensive(t *testing.T) {
+	stateStore, err := state.New(state.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sink := &sinkMock{}
+
+	n, err := New(Config{
+		Logger: microloggertest.New(),
+		Sinks:  []Sink{sink},
+		State:  stateStore,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := report.New("aws")
+	r.Add(report.Item{Cleaner: "cloudhsm-clusters", Resource: "cluster-a", Action: report.ActionReported, Cost: "CloudHSM cluster with 2 HSMs"})
+	r.Add(report.Item{Cleaner: "cloudhsm-clusters", Resource: "cluster-b", Action: report.ActionDeleted, Cost: "CloudHSM cluster with 1 HSMs"})
+
+	err = n.Notify(context.Background(), r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sink.messages) != 1 {
+		t.Fatalf("expected one message, got %d", len(sink.messages))
+	}
+
+	m := sink.messages[0]
+	if !m.HighSeverity() {
+		t.Errorf("expected message about expensive resources to be of high severity")
+	}
+
+	expected := ":rotating_light: aws cleaner `cloudhsm-clusters`: 1 deleted, 1 reported, 0 failed\nstill billed (reported): cluster-a: CloudHSM cluster with 2 HSMs\ndeleted: cluster-b\nwould delete: cluster-a"
+	if text := m.Text(); text != expected {
+		t.Errorf("expected text %q, got %q", expected, text)
+	}
+}
+
+func TestNotifyOwners(t *testing.T) {
+	stateStore, err := state.New(state.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sink := &sinkMock{}
+
+	n, err := New(Config{
+		Logger: microloggertest.New(),
+		Sinks:  []Sink{sink},
+		State:  stateStore,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := report.New("aws")
+	r.Add(report.Item{Cleaner: "volumes", Resource: "vol-a", Action: report.ActionDeleted, Installation: "gauss", Cluster: "ci-wip-a1b2c"})
+	r.Add(report.Item{Cleaner: "volumes", Resource: "vol-b", Action: report.ActionDeleted, Installation: "gauss", Cluster: "ci-wip-a1b2c"})
+	r.Add(report.Item{Cleaner: "volumes", Resource: "vol-c", Action: report.ActionDeleted})
+
+	err = n.Notify(context.Background(), r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sink.messages) != 1 {
+		t.Fatalf("expected one message, got %d", len(sink.messages))
+	}
+
+	expected := "aws cleaner `volumes`: 3 deleted, 0 reported, 0 failed\ndeleted: vol-a, vol-b, vol-c\nby owner: gauss/ci-wip-a1b2c (2)"
+	if text := sink.messages[0].Text(); text != expected {
+		t.Errorf("expected text %q, got %q", expected, text)
+	}
+}
+
+func TestNotifyFreeze(t *testing.T) {
+	stateStore, err := state.New(state.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sink := &sinkMock{}
+
+	n, err := New(Config{
+		Logger: microloggertest.New(),
+		Sinks:  []Sink{sink},
+		State:  stateStore,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := report.New("aws")
+	r.SetFreeze(report.Freeze{Summary: "Release freeze", End: time.Date(2024, 5, 22, 0, 0, 0, 0, time.UTC)})
+	r.Add(report.Item{Cleaner: "volumes", Resource: "vol-a", Action: report.ActionReported})
+
+	err = n.Notify(context.Background(), r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sink.messages) != 1 {
+		t.Fatalf("expected one message, got %d", len(sink.messages))
+	}
+
+	expected := "aws cleaner `volumes`: 0 deleted, 1 reported, 0 failed\nreport-only during freeze: Release freeze until 2024-05-22T00:00:00Z\nwould delete: vol-a"
+	if text := sink.messages[0].Text(); text != expected {
+		t.Errorf("expected text %q, got %q", expected, text)
+	}
+}
+
+func TestNotifySelfTestFailed(t *testing.T) {
+	stateStore, err := state.New(state.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sink := &sinkMock{}
+
+	n, err := New(Config{
+		Logger: microloggertest.New(),
+		Sinks:  []Sink{sink},
+		State:  stateStore,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := report.New("aws")
+	r.SetSelfTest([]selftest.Result{
+		{Probe: "s3-bucket", Resource: "ci-wip-canary-a1b2c3d4", Status: selftest.StatusFailed},
+		{Probe: "delegation-record", Resource: "Z123/ci-wip-canary-e5f6a7b8.gigantic.io.", Status: selftest.StatusPending},
+	})
+
+	// failed self-tests are notified about in every run.
+	for i := 0; i < 2; i++ {
+		err = n.Notify(context.Background(), r)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(sink.messages) != 2 {
+		t.Fatalf("expected two messages, got %d", len(sink.messages))
+	}
+
+	m := sink.messages[1]
+	if !m.HighSeverity() {
+		t.Errorf("expected message about failed self-test to be of high severity")
+	}
+
+	expected := ":rotating_light: aws cleaner `self-test`: 0 deleted, 0 reported, 0 failed\nself-test failed, canaries not deleted in time: ci-wip-canary-a1b2c3d4 (s3-bucket)"
+	if text := m.Text(); text != expected {
+		t.Errorf("expected text %q, got %q", expected, text)
+	}
+}
+
+func TestNotifyTimeToCleanExceeded(t *testing.T) {
+	stateStore, err := state.New(state.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sink := &sinkMock{}
+
+	n, err := New(Config{
+		Logger: microloggertest.New(),
+		Sinks:  []Sink{sink},
+		State:  stateStore,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exceeded := report.New("aws")
+	exceeded.SetTimeToClean([]report.TimeToClean{
+		{Provider: "aws", Type: "AWS::EC2::Instance", Count: 20, P50: time.Hour, P95: 7 * time.Hour, Threshold: 6 * time.Hour},
+		{Provider: "aws", Type: "AWS::EC2::Volume", Count: 20, P50: time.Hour, P95: 2 * time.Hour, Threshold: 6 * time.Hour},
+	})
+	recovered := report.New("aws")
+	recovered.SetTimeToClean([]report.TimeToClean{
+		{Provider: "aws", Type: "AWS::EC2::Instance", Count: 20, P50: time.Hour, P95: 5 * time.Hour, Threshold: 6 * time.Hour},
+	})
+
+	// the breach is notified about once until the type recovers.
+	for _, r := range []*report.Report{exceeded, exceeded, recovered, exceeded} {
+		err = n.Notify(context.Background(), r)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(sink.messages) != 2 {
+		t.Fatalf("expected two messages, got %d", len(sink.messages))
+	}
+
+	m := sink.messages[1]
+	if !m.HighSeverity() {
+		t.Errorf("expected message about exceeded time-to-clean to be of high severity")
+	}
+
+	expected := ":rotating_light: aws cleaner `time-to-clean`: 0 deleted, 0 reported, 0 failed\ntime-to-clean objective exceeded: AWS::EC2::Instance: p95 7h0m0s exceeds 6h0m0s"
+	if text := m.Text(); text != expected {
+		t.Errorf("expected text %q, got %q", expected, text)
+	}
+}
@@ -0,0 +1,75 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	httpTimeout = 30 * time.Second
+)
+
+// Sink delivers messages to humans or other systems.
+type Sink interface {
+	Send(ctx context.Context, m Message) error
+}
+
+// SlackSink posts messages to a Slack incoming webhook.
+type SlackSink struct {
+	URL string
+}
+
+// Send implements Sink.
+func (s SlackSink) Send(ctx context.Context, m Message) error {
+	body := struct {
+		Text string `json:"text"`
+	}{
+		Text: m.Text(),
+	}
+
+	return post(ctx, s.URL, body)
+}
+
+// WebhookSink posts messages as JSON to an arbitrary HTTP endpoint.
+type WebhookSink struct {
+	URL string
+}
+
+// Send implements Sink.
+func (s WebhookSink) Send(ctx context.Context, m Message) error {
+	return post(ctx, s.URL, m)
+}
+
+func post(ctx context.Context, url string, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, httpTimeout)
+	defer cancel()
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return microerror.Maskf(executionFailedError, "posting to %#q returned status %d", url, res.StatusCode)
+	}
+
+	return nil
+}
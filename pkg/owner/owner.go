@@ -0,0 +1,98 @@
+// Package owner tells which Giant Swarm installation and cluster a resource
+// belongs to according to the standard tags of Giant Swarm installations.
+package owner
+
+import (
+	"strings"
+)
+
+const (
+	// ClusterTag is the tag holding the ID of the cluster a resource
+	// belongs to.
+	ClusterTag = "giantswarm.io/cluster"
+	// InstallationTag is the tag holding the name of the installation a
+	// resource belongs to.
+	InstallationTag = "giantswarm.io/installation"
+	// KubernetesClusterTagPrefix is the prefix of the tag keys Kubernetes
+	// marks the resources it provisions for a cluster with, e.g.
+	// `kubernetes.io/cluster/ci-wip-a1b2c`.
+	KubernetesClusterTagPrefix = "kubernetes.io/cluster/"
+	// OriginRepoTag is the tag holding the repository whose pipeline created
+	// a resource, e.g. `giantswarm/cluster-aws`.
+	OriginRepoTag = "giantswarm.io/origin-repo"
+)
+
+// Owner is the installation and cluster a resource belongs to. Either may be
+// empty when the resource is not tagged with it.
+type Owner struct {
+	Installation string
+	Cluster      string
+}
+
+// Of returns the owner of a resource with the given tags. The cluster is
+// taken from the Kubernetes cluster tag when the resource lacks the cluster
+// tag, e.g. for volumes and load balancers Kubernetes provisioned.
+func Of(tags map[string]string) Owner {
+	o := Owner{
+		Installation: tags[InstallationTag],
+		Cluster:      tags[ClusterTag],
+	}
+
+	if o.Cluster == "" {
+		o.Cluster = KubernetesCluster(tags)
+	}
+
+	return o
+}
+
+// KubernetesCluster returns the cluster the resource with the given tags was
+// provisioned for by Kubernetes, if any. When tagged with several clusters,
+// the first in alphabetical order is returned.
+func KubernetesCluster(tags map[string]string) string {
+	var cluster string
+	for k := range tags {
+		if !strings.HasPrefix(k, KubernetesClusterTagPrefix) {
+			continue
+		}
+
+		c := strings.TrimPrefix(k, KubernetesClusterTagPrefix)
+		if cluster == "" || c < cluster {
+			cluster = c
+		}
+	}
+
+	return cluster
+}
+
+// OriginRepo returns the repository whose pipeline created the resource with
+// the given tags, if any. Repositories tagged by their URL, like
+// `https://github.com/giantswarm/cluster-aws.git`, are returned as
+// `giantswarm/cluster-aws`.
+func OriginRepo(tags map[string]string) string {
+	repo := strings.TrimSpace(tags[OriginRepoTag])
+	for _, p := range []string{"https://", "http://", "git@", "github.com/", "github.com:"} {
+		repo = strings.TrimPrefix(repo, p)
+	}
+	repo = strings.TrimSuffix(repo, ".git")
+	repo = strings.TrimSuffix(repo, "/")
+
+	return repo
+}
+
+// IsZero returns whether the owner is unknown.
+func (o Owner) IsZero() bool {
+	return o.Installation == "" && o.Cluster == ""
+}
+
+// String returns the owner as `<installation>/<cluster>`, leaving out what is
+// unknown.
+func (o Owner) String() string {
+	switch {
+	case o.Installation == "":
+		return o.Cluster
+	case o.Cluster == "":
+		return o.Installation
+	default:
+		return o.Installation + "/" + o.Cluster
+	}
+}
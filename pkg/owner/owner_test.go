@@ -0,0 +1,87 @@
+package owner
+
+import (
+	"testing"
+)
+
+func TestOf(t *testing.T) {
+	tcs := []struct {
+		tags        map[string]string
+		expected    string
+		description string
+	}{
+		{
+			description: "installation and cluster tags",
+			tags:        map[string]string{InstallationTag: "gauss", ClusterTag: "ci-wip-a1b2c"},
+			expected:    "gauss/ci-wip-a1b2c",
+		},
+		{
+			description: "kubernetes cluster tag",
+			tags:        map[string]string{"kubernetes.io/cluster/ci-wip-a1b2c": "owned"},
+			expected:    "ci-wip-a1b2c",
+		},
+		{
+			description: "cluster tag takes precedence over kubernetes cluster tag",
+			tags:        map[string]string{ClusterTag: "ci-wip-a1b2c", "kubernetes.io/cluster/other": "shared"},
+			expected:    "ci-wip-a1b2c",
+		},
+		{
+			description: "installation tag only",
+			tags:        map[string]string{InstallationTag: "gauss"},
+			expected:    "gauss",
+		},
+		{
+			description: "no tags",
+			expected:    "",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := Of(tc.tags).String()
+
+			if actual != tc.expected {
+				t.Errorf("want %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestOriginRepo(t *testing.T) {
+	tcs := []struct {
+		tags        map[string]string
+		expected    string
+		description string
+	}{
+		{
+			description: "repository",
+			tags:        map[string]string{OriginRepoTag: "giantswarm/cluster-aws"},
+			expected:    "giantswarm/cluster-aws",
+		},
+		{
+			description: "https url",
+			tags:        map[string]string{OriginRepoTag: "https://github.com/giantswarm/cluster-aws.git"},
+			expected:    "giantswarm/cluster-aws",
+		},
+		{
+			description: "ssh url",
+			tags:        map[string]string{OriginRepoTag: "git@github.com:giantswarm/cluster-aws.git"},
+			expected:    "giantswarm/cluster-aws",
+		},
+		{
+			description: "no tag",
+			tags:        map[string]string{ClusterTag: "ci-wip-a1b2c"},
+			expected:    "",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := OriginRepo(tc.tags)
+
+			if actual != tc.expected {
+				t.Errorf("want %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
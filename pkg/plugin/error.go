@@ -0,0 +1,23 @@
+package plugin
+
+import (
+	"github.com/giantswarm/microerror"
+)
+
+var invalidConfigError = &microerror.Error{
+	Kind: "invalidConfigError",
+}
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
+
+var invalidPluginError = &microerror.Error{
+	Kind: "invalidPluginError",
+}
+
+// IsInvalidPlugin asserts invalidPluginError.
+func IsInvalidPlugin(err error) bool {
+	return microerror.Cause(err) == invalidPluginError
+}
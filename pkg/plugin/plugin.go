@@ -0,0 +1,135 @@
+// Package plugin is the extension point for cleaners maintained outside of
+// this repository, e.g. for marketplace appliances or SaaS registrations
+// created by e2e tests. External cleaners only list their candidates and
+// delete single resources. Everything else, like report-only mode, scoping,
+// escalation and reporting, is applied by the ci-cleaner as for its own
+// cleaners.
+//
+// External cleaners are built as Go plugins exporting a function
+//
+//	func NewCleaner(config plugin.Config) (plugin.Cleaner, error)
+//
+// with
+//
+//	go build -buildmode=plugin
+//
+// against the same version of this module the ci-cleaner was built with.
+package plugin
+
+import (
+	"context"
+	"fmt"
+	goplugin "plugin"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/logctx"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+// Symbol is the name of the function every plugin has to export.
+const Symbol = "NewCleaner"
+
+// Config is passed to external cleaners when they are created.
+type Config struct {
+	Logger micrologger.Logger
+
+	// GracePeriod is the maximum time CI resources are allowed to remain
+	// up. Zero means the cleaner should apply its own default.
+	GracePeriod time.Duration
+	// Prefixes are the name prefixes identifying CI resources. Empty means
+	// the cleaner should apply its own defaults.
+	Prefixes []string
+}
+
+// Cleaner is implemented by external cleaners.
+type Cleaner interface {
+	// Name identifies the cleaner in reports and configuration.
+	Name() string
+	// Candidates returns the resources which should be deleted.
+	Candidates(ctx context.Context) ([]run.Resource, error)
+	// Delete deletes a single resource returned by Candidates.
+	Delete(ctx context.Context, resource run.Resource) error
+}
+
+// NewCleanerFunc is the signature of the function exported by plugins.
+type NewCleanerFunc func(config Config) (Cleaner, error)
+
+// Open loads the plugin at the given path and creates its cleaner.
+func Open(path string, config Config) (Cleaner, error) {
+	p, err := goplugin.Open(path)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	sym, err := p.Lookup(Symbol)
+	if err != nil {
+		return nil, microerror.Maskf(invalidPluginError, "plugin %#q does not export %s", path, Symbol)
+	}
+
+	var newCleaner NewCleanerFunc
+	switch f := sym.(type) {
+	case func(Config) (Cleaner, error):
+		newCleaner = f
+	case *NewCleanerFunc:
+		newCleaner = *f
+	default:
+		return nil, microerror.Maskf(invalidPluginError, "plugin %#q exports %s with unexpected type %T", path, Symbol, sym)
+	}
+
+	c, err := newCleaner(config)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return c, nil
+}
+
+// Clean runs the given external cleaners. Every deletion goes through r, so
+// that external cleaners are subject to the same rules as the built-in ones.
+func Clean(ctx context.Context, logger micrologger.Logger, r *run.Run, cleaners []Cleaner) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	for _, c := range cleaners {
+		if !r.Enabled(c.Name()) {
+			continue
+		}
+
+		ctx := logctx.WithCleaner(ctx, c.Name())
+
+		logger.LogCtx(ctx, "level", "info", "message", fmt.Sprintf("running cleaner %s", c.Name()))
+
+		candidates, err := c.Candidates(ctx)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("running cleaner %s", c.Name()), "stack", fmt.Sprintf("%#v", err))
+			continue
+		}
+
+		failed := false
+		for _, res := range candidates {
+			res := res
+			err := r.DeleteResource(ctx, c.Name(), res, func() error {
+				return c.Delete(ctx, res)
+			})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("cleaner %s failed deleting %#q", c.Name(), res.ID), "stack", fmt.Sprintf("%#v", err))
+				failed = true
+			}
+		}
+
+		if !failed {
+			r.Complete(c.Name())
+		}
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+
+	"github.com/giantswarm/ci-cleaner/pkg/report"
+	"github.com/giantswarm/ci-cleaner/pkg/run"
+)
+
+type cleanerMock struct {
+	deleted []string
+}
+
+func (c *cleanerMock) Name() string {
+	return "quay-repositories"
+}
+
+func (c *cleanerMock) Candidates(ctx context.Context) ([]run.Resource, error) {
+	return []run.Resource{{ID: "ci-a"}, {ID: "ci-b"}}, nil
+}
+
+func (c *cleanerMock) Delete(ctx context.Context, resource run.Resource) error {
+	if resource.ID == "ci-b" {
+		return errors.New("forbidden")
+	}
+
+	c.deleted = append(c.deleted, resource.ID)
+
+	return nil
+}
+
+func TestClean(t *testing.T) {
+	rep := report.New("aws")
+
+	r, err := run.New(run.Config{
+		Logger: microloggertest.New(),
+		Report: rep,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &cleanerMock{}
+
+	err = Clean(context.Background(), microloggertest.New(), r, []Cleaner{c})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	if len(c.deleted) != 1 || c.deleted[0] != "ci-a" {
+		t.Errorf("expected %q to be deleted, got %v", "ci-a", c.deleted)
+	}
+
+	candidates := rep.Candidates()["quay-repositories"]
+	if len(candidates) != 2 {
+		t.Errorf("expected both candidates to be reported, got %v", candidates)
+	}
+}
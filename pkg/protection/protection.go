@@ -0,0 +1,137 @@
+// Package protection implements temporary protection of resources from being
+// deleted, e.g. to keep a failed CI cluster around for debugging. Protections
+// are kept in the state store and expire on their own.
+package protection
+
+import (
+	"strings"
+	"time"
+
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/resource"
+	"github.com/giantswarm/ci-cleaner/pkg/state"
+)
+
+const (
+	defaultMaxDuration = 7 * 24 * time.Hour
+
+	// KeyPrefix prefixes the state entries holding protections. They expire
+	// by their own end time and not by the time of their last update.
+	KeyPrefix = "protection/"
+)
+
+type Config struct {
+	State *state.Store
+
+	// MaxDuration is the maximum time a resource can be protected for at
+	// once. Defaults to 7 days.
+	MaxDuration time.Duration
+}
+
+// Protection keeps track of protected resources.
+type Protection struct {
+	state *state.Store
+
+	maxDuration time.Duration
+}
+
+// Entry protects all resources whose ID contains Pattern, e.g. a cluster ID,
+// or which belong to a cluster or installation whose name contains it, until
+// the given time.
+type Entry struct {
+	Pattern string    `json:"pattern"`
+	Until   time.Time `json:"until"`
+	By      string    `json:"by"`
+}
+
+func New(config Config) (*Protection, error) {
+	if config.State == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.State must not be empty", config)
+	}
+
+	if config.MaxDuration == 0 {
+		config.MaxDuration = defaultMaxDuration
+	}
+
+	p := &Protection{
+		state: config.State,
+
+		maxDuration: config.MaxDuration,
+	}
+
+	return p, nil
+}
+
+// Keep protects the resources matching pattern for the given duration.
+// Protecting a pattern again replaces the previous protection.
+func (p *Protection) Keep(pattern string, d time.Duration, by string) (Entry, error) {
+	if len(pattern) < 3 {
+		return Entry{}, microerror.Maskf(invalidRequestError, "pattern %#q must be at least 3 characters long", pattern)
+	}
+	if d <= 0 || d > p.maxDuration {
+		return Entry{}, microerror.Maskf(invalidRequestError, "duration must be between 0 and %s", p.maxDuration)
+	}
+
+	e := Entry{
+		Pattern: pattern,
+		Until:   time.Now().Add(d).UTC(),
+		By:      by,
+	}
+
+	err := p.state.Put(KeyPrefix+pattern, e)
+	if err != nil {
+		return Entry{}, microerror.Mask(err)
+	}
+
+	return e, nil
+}
+
+// Release removes the protection of the given pattern.
+func (p *Protection) Release(pattern string) {
+	p.state.Delete(KeyPrefix + pattern)
+}
+
+// List returns the active protections. Expired protections are removed.
+func (p *Protection) List() ([]Entry, error) {
+	now := time.Now()
+
+	var entries []Entry
+	for _, k := range p.state.Keys(KeyPrefix) {
+		var e Entry
+		_, err := p.state.Get(k, &e)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		if !e.Until.After(now) {
+			p.state.Delete(k)
+			continue
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries, nil
+}
+
+// Protected returns the protection of the given resource, if any. Resources
+// whose ID does not tell their cluster, like instances and volumes, are
+// matched by the cluster and installation tags they carry.
+func (p *Protection) Protected(res resource.Resource) (Entry, bool, error) {
+	entries, err := p.List()
+	if err != nil {
+		return Entry{}, false, microerror.Mask(err)
+	}
+
+	o := res.Owner()
+	for _, e := range entries {
+		for _, s := range []string{res.ID, o.Cluster, o.Installation} {
+			if s != "" && strings.Contains(s, e.Pattern) {
+				return e, true, nil
+			}
+		}
+	}
+
+	return Entry{}, false, nil
+}
@@ -0,0 +1,102 @@
+package protection
+
+import (
+	"testing"
+	"time"
+
+	"github.com/giantswarm/ci-cleaner/pkg/owner"
+	"github.com/giantswarm/ci-cleaner/pkg/resource"
+	"github.com/giantswarm/ci-cleaner/pkg/state"
+)
+
+func TestProtection(t *testing.T) {
+	s, err := state.New(state.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := New(Config{State: s, MaxDuration: 48 * time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = p.Keep("ci-a1b2c", 72*time.Hour, "alice")
+	if !IsInvalidRequest(err) {
+		t.Errorf("expected durations above the maximum to be rejected, got %#v", err)
+	}
+	_, err = p.Keep("ci", time.Hour, "alice")
+	if !IsInvalidRequest(err) {
+		t.Errorf("expected short patterns to be rejected, got %#v", err)
+	}
+
+	_, err = p.Keep("ci-a1b2c", 24*time.Hour, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e, ok, err := p.Protected(resource.Resource{ID: "cluster-ci-a1b2c-guest-main"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok || e.By != "alice" {
+		t.Errorf("expected resource to be protected by alice, got %#v", e)
+	}
+
+	_, ok, err = p.Protected(resource.Resource{ID: "cluster-ci-d3e4f-guest-main"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok {
+		t.Errorf("expected resource not to be protected")
+	}
+
+	// Resources whose ID does not tell their cluster are protected by their
+	// cluster tags.
+	instance := resource.Resource{
+		ID:   "i-0123456789abcdef0",
+		Type: "AWS::EC2::Instance",
+		Tags: map[string]string{owner.ClusterTag: "ci-a1b2c"},
+	}
+	e, ok, err = p.Protected(instance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok || e.Pattern != "ci-a1b2c" {
+		t.Errorf("expected instance tagged with the cluster to be protected, got %#v", e)
+	}
+
+	volume := resource.Resource{
+		ID:   "vol-0123456789abcdef0",
+		Type: "AWS::EC2::Volume",
+		Tags: map[string]string{"kubernetes.io/cluster/ci-d3e4f": "owned"},
+	}
+	_, ok, err = p.Protected(volume)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok {
+		t.Errorf("expected volume of another cluster not to be protected")
+	}
+
+	// Expired protections are removed.
+	err = s.Put(KeyPrefix+"ci-d3e4f", Entry{Pattern: "ci-d3e4f", Until: time.Now().Add(-time.Minute)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := p.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || len(s.Keys(KeyPrefix)) != 1 {
+		t.Errorf("expected expired protection to be removed, got %#v", entries)
+	}
+
+	p.Release("ci-a1b2c")
+	_, ok, err = p.Protected(instance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok {
+		t.Errorf("expected released resource not to be protected")
+	}
+}
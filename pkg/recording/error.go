@@ -0,0 +1,14 @@
+package recording
+
+import (
+	"github.com/giantswarm/microerror"
+)
+
+var notRecordedError = &microerror.Error{
+	Kind: "notRecordedError",
+}
+
+// IsNotRecorded asserts notRecordedError.
+func IsNotRecorded(err error) bool {
+	return microerror.Cause(err) == notRecordedError
+}
@@ -0,0 +1,374 @@
+// Package recording records the responses of the read operations of AWS API
+// calls made during a run and replays them, so that the decisions of the
+// cleaners can be debugged against the data of a past run without cloud
+// access.
+package recording
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/giantswarm/microerror"
+)
+
+// manifestFile is the name of the file the Manifest is recorded in.
+const manifestFile = "run.json"
+
+// readPrefixes are the name prefixes of the operations which only read, and
+// whose responses are recorded.
+var readPrefixes = []string{
+	"Describe",
+	"Get",
+	"Head",
+	"List",
+	"Lookup",
+	"Scan",
+	"Search",
+}
+
+// sensitiveFields are the names of the fields which are removed from
+// recorded responses, as recordings are meant to be shared for debugging.
+var sensitiveFields = map[string]bool{
+	"KeyMaterial":     true,
+	"Password":        true,
+	"PrivateKey":      true,
+	"SecretAccessKey": true,
+	"SecretBinary":    true,
+	"SecretString":    true,
+	"SessionToken":    true,
+	"UserData":        true,
+}
+
+// response is what is recorded of a single call.
+type response struct {
+	Operation string          `json:"operation"`
+	Output    json.RawMessage `json:"output,omitempty"`
+	Error     *responseError  `json:"error,omitempty"`
+}
+
+// responseError is an AWS error returned by a call.
+type responseError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Manifest is what is recorded of the run itself, so that replays decide as
+// of the time of the recording.
+type Manifest struct {
+	// Started is when the recorded run started.
+	Started time.Time `json:"started"`
+	// Seen are the state entries of the recorded run telling when resources
+	// were found for the first time, by key.
+	Seen map[string]time.Time `json:"seen,omitempty"`
+}
+
+// WriteManifest writes the given manifest of the run recorded in dir.
+func WriteManifest(dir string, m Manifest) error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = ioutil.WriteFile(filepath.Join(dir, manifestFile), b, 0640)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// ReadManifest reads the manifest of the run recorded in dir. Recordings
+// without manifest fail with notRecordedError.
+func ReadManifest(dir string) (Manifest, error) {
+	b, err := ioutil.ReadFile(filepath.Join(dir, manifestFile))
+	if os.IsNotExist(err) {
+		return Manifest{}, microerror.Maskf(notRecordedError, "manifest of %#q", dir)
+	} else if err != nil {
+		return Manifest{}, microerror.Mask(err)
+	}
+
+	var m Manifest
+	err = json.Unmarshal(b, &m)
+	if err != nil {
+		return Manifest{}, microerror.Mask(err)
+	}
+
+	return m, nil
+}
+
+// Record adds handlers to the given handlers of an AWS session which write
+// the responses of all read operations into dir, one file per call. Errors
+// returned by the calls are recorded as well, as cleaners decide on them,
+// e.g. on resources which are not found anymore. Sensitive fields like
+// secret values are removed.
+func Record(h *request.Handlers, dir string) error {
+	err := os.MkdirAll(dir, 0750)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	h.Complete.PushBackNamed(request.NamedHandler{
+		Name: "recording.Record",
+		Fn: func(r *request.Request) {
+			if !isRead(r.Operation.Name) {
+				return
+			}
+
+			err := record(r, dir)
+			if err != nil {
+				r.Error = microerror.Mask(err)
+			}
+		},
+	})
+
+	return nil
+}
+
+// Replay replaces the handlers of an AWS session sending requests with
+// handlers returning the responses recorded in dir. Read operations which
+// were not recorded fail with notRecordedError. Other operations succeed
+// without doing anything, so that nothing is changed when replaying a run.
+// shift is added to all times of the recorded responses, so that resources
+// are as old as they were during the recording when shift is the time passed
+// since the recorded run started.
+func Replay(h *request.Handlers, dir string, shift time.Duration) {
+	h.Sign.Clear()
+	h.Send.Clear()
+	h.Send.PushBackNamed(request.NamedHandler{
+		Name: "recording.Send",
+		Fn: func(r *request.Request) {
+			r.HTTPResponse = &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{},
+				Body:       ioutil.NopCloser(&bytes.Buffer{}),
+			}
+		},
+	})
+	h.ValidateResponse.Clear()
+	h.UnmarshalMeta.Clear()
+	h.UnmarshalError.Clear()
+	h.Unmarshal.Clear()
+	h.Unmarshal.PushBackNamed(request.NamedHandler{
+		Name: "recording.Replay",
+		Fn: func(r *request.Request) {
+			if !isRead(r.Operation.Name) {
+				return
+			}
+
+			err := replay(r, dir, shift)
+			if err != nil {
+				r.Error = err
+			}
+		},
+	})
+}
+
+func record(r *request.Request, dir string) error {
+	name, err := fileName(r)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	res := response{
+		Operation: r.Operation.Name,
+	}
+	if r.Error != nil {
+		aerr, ok := r.Error.(awserr.Error)
+		if !ok {
+			// do not record failures to reach the API.
+			return nil
+		}
+		res.Error = &responseError{Code: aerr.Code(), Message: aerr.Message()}
+	} else {
+		res.Output, err = sanitize(r.Data)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	b, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = ioutil.WriteFile(filepath.Join(dir, name), b, 0640)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func replay(r *request.Request, dir string, shift time.Duration) error {
+	name, err := fileName(r)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, name))
+	if os.IsNotExist(err) {
+		return microerror.Maskf(notRecordedError, "%s.%s with %s", r.ClientInfo.ServiceName, r.Operation.Name, name)
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	var res response
+	err = json.Unmarshal(b, &res)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if res.Error != nil {
+		return awserr.New(res.Error.Code, res.Error.Message, nil)
+	}
+
+	output := res.Output
+	if shift != 0 && len(output) != 0 {
+		var v interface{}
+		err = json.Unmarshal(output, &v)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		output, err = json.Marshal(shiftTimes(v, shift))
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	err = json.Unmarshal(output, r.Data)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// fileName returns the name of the file the response of the given request is
+// recorded in. It is derived from service, operation and parameters of the
+// request, so that every page of a listing is recorded on its own.
+// Parameters holding times, like the start of a lookup window, are left out,
+// as they change between runs.
+func fileName(r *request.Request) (string, error) {
+	b, err := json.Marshal(r.Params)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	var params interface{}
+	err = json.Unmarshal(b, &params)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	b, err = json.Marshal(withoutTimes(params))
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	sum := sha256.Sum256(b)
+
+	return fmt.Sprintf("%s.%s-%s.json", r.ClientInfo.ServiceName, r.Operation.Name, hex.EncodeToString(sum[:8])), nil
+}
+
+// sanitize encodes the given output without its sensitive and empty fields.
+func sanitize(output interface{}) (json.RawMessage, error) {
+	b, err := json.Marshal(output)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var v interface{}
+	err = json.Unmarshal(b, &v)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	b, err = json.Marshal(withoutSensitiveFields(v))
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return b, nil
+}
+
+func withoutSensitiveFields(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, value := range v {
+			if sensitiveFields[k] || value == nil {
+				delete(v, k)
+				continue
+			}
+			v[k] = withoutSensitiveFields(value)
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = withoutSensitiveFields(value)
+		}
+	}
+
+	return v
+}
+
+func withoutTimes(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, value := range v {
+			if s, ok := value.(string); ok {
+				if _, err := time.Parse(time.RFC3339Nano, s); err == nil {
+					delete(v, k)
+					continue
+				}
+			}
+			v[k] = withoutTimes(value)
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = withoutTimes(value)
+		}
+	}
+
+	return v
+}
+
+// shiftTimes adds d to all times in v.
+func shiftTimes(v interface{}, d time.Duration) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, value := range v {
+			v[k] = shiftTimes(value, d)
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = shiftTimes(value, d)
+		}
+	case string:
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return t.Add(d).Format(time.RFC3339Nano)
+		}
+	}
+
+	return v
+}
+
+func isRead(operation string) bool {
+	for _, p := range readPrefixes {
+		if strings.HasPrefix(operation, p) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,142 @@
+package recording
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+)
+
+func TestRecordReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "recording")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, `<DescribeKeyPairsResponse><keySet><item><keyName>ci-wip-a1b2c</keyName><createTime>2024-05-17T12:00:00Z</createTime></item></keySet></DescribeKeyPairsResponse>`)
+	}))
+	defer server.Close()
+
+	{
+		s := newSession(t, server.URL)
+		err := Record(&s.Handlers, dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = ec2.New(s).DescribeKeyPairs(&ec2.DescribeKeyPairsInput{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = ec2.New(s).DeleteKeyPair(&ec2.DeleteKeyPairInput{KeyName: aws.String("ci-wip-a1b2c")})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("expected only the read operation to be recorded, got %d files", len(files))
+	}
+
+	started := time.Date(2024, 5, 17, 13, 0, 0, 0, time.UTC)
+	err = WriteManifest(dir, Manifest{Started: started, Seen: map[string]time.Time{"seen/stacks/ci-wip-a1b2c": started}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	{
+		m, err := ReadManifest(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !m.Started.Equal(started) || !m.Seen["seen/stacks/ci-wip-a1b2c"].Equal(started) {
+			t.Errorf("expected recorded manifest, got %#v", m)
+		}
+
+		s := newSession(t, server.URL)
+		Replay(&s.Handlers, dir, 24*time.Hour)
+
+		o, err := ec2.New(s).DescribeKeyPairs(&ec2.DescribeKeyPairsInput{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(o.KeyPairs) != 1 || aws.StringValue(o.KeyPairs[0].KeyName) != "ci-wip-a1b2c" {
+			t.Errorf("expected recorded key pair, got %v", o.KeyPairs)
+		}
+		if created := aws.TimeValue(o.KeyPairs[0].CreateTime); !created.Equal(time.Date(2024, 5, 18, 12, 0, 0, 0, time.UTC)) {
+			t.Errorf("expected times of the recording to be shifted, got %s", created)
+		}
+
+		_, err = ec2.New(s).DeleteKeyPair(&ec2.DeleteKeyPairInput{KeyName: aws.String("ci-wip-a1b2c")})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = ec2.New(s).DescribeVolumes(&ec2.DescribeVolumesInput{})
+		if !IsNotRecorded(err) {
+			t.Errorf("expected not recorded error, got %v", err)
+		}
+	}
+
+	empty, err := ioutil.TempDir("", "recording")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(empty)
+
+	_, err = ReadManifest(empty)
+	if !IsNotRecorded(err) {
+		t.Errorf("expected missing manifest to be not recorded, got %v", err)
+	}
+
+	if requests != 2 {
+		t.Errorf("expected 2 requests while recording and none while replaying, got %d", requests)
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	o := &secretsmanager.GetSecretValueOutput{
+		Name:         aws.String("ci-wip-a1b2c"),
+		SecretString: aws.String("hunter2"),
+	}
+
+	b, err := sanitize(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"Name":"ci-wip-a1b2c"}`
+	if string(b) != expected {
+		t.Errorf("expected %s, got %s", expected, b)
+	}
+}
+
+func newSession(t *testing.T, endpoint string) *session.Session {
+	s, err := session.NewSession(&aws.Config{
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+		Endpoint:    aws.String(endpoint),
+		Region:      aws.String("eu-west-1"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
@@ -0,0 +1,71 @@
+package report
+
+import (
+	"sort"
+)
+
+// RoleAssignment is a single role assignment of an Azure subscription as
+// recorded for the role assignment drift.
+type RoleAssignment struct {
+	ID               string `json:"id"`
+	PrincipalID      string `json:"principalID"`
+	RoleDefinitionID string `json:"roleDefinitionID"`
+	Scope            string `json:"scope"`
+	// CIScoped marks assignments scoped to CI resources, e.g. to the
+	// resource group of a CI cluster.
+	CIScoped bool `json:"ciScoped,omitempty"`
+}
+
+// RoleAssignmentDrift is the difference between the role assignments of a
+// subscription before and after a cleanup. It proves to the security team
+// that a run only removed assignments scoped to CI resources.
+type RoleAssignmentDrift struct {
+	Before  int              `json:"before"`
+	After   int              `json:"after"`
+	Added   []RoleAssignment `json:"added,omitempty"`
+	Removed []RoleAssignment `json:"removed,omitempty"`
+	// OnlyCIScoped is true when all removed assignments were scoped to CI
+	// resources.
+	OnlyCIScoped bool `json:"onlyCIScoped"`
+}
+
+// NewRoleAssignmentDrift computes the drift between the given role
+// assignments recorded before and after a cleanup. Assignments are matched
+// by their ID.
+func NewRoleAssignmentDrift(before []RoleAssignment, after []RoleAssignment) RoleAssignmentDrift {
+	d := RoleAssignmentDrift{
+		Before:       len(before),
+		After:        len(after),
+		OnlyCIScoped: true,
+	}
+
+	beforeIDs := map[string]bool{}
+	for _, a := range before {
+		beforeIDs[a.ID] = true
+	}
+	afterIDs := map[string]bool{}
+	for _, a := range after {
+		afterIDs[a.ID] = true
+	}
+
+	for _, a := range before {
+		if afterIDs[a.ID] {
+			continue
+		}
+
+		d.Removed = append(d.Removed, a)
+		if !a.CIScoped {
+			d.OnlyCIScoped = false
+		}
+	}
+	for _, a := range after {
+		if !beforeIDs[a.ID] {
+			d.Added = append(d.Added, a)
+		}
+	}
+
+	sort.Slice(d.Added, func(i, j int) bool { return d.Added[i].ID < d.Added[j].ID })
+	sort.Slice(d.Removed, func(i, j int) bool { return d.Removed[i].ID < d.Removed[j].ID })
+
+	return d
+}
@@ -0,0 +1,84 @@
+package report
+
+import (
+	"testing"
+)
+
+func TestNewRoleAssignmentDrift(t *testing.T) {
+	tcs := []struct {
+		before               []RoleAssignment
+		after                []RoleAssignment
+		expectedAdded        int
+		expectedRemoved      int
+		expectedOnlyCIScoped bool
+		description          string
+	}{
+		{
+			description: "unchanged role assignments",
+			before: []RoleAssignment{
+				{ID: "a"},
+				{ID: "b"},
+			},
+			after: []RoleAssignment{
+				{ID: "b"},
+				{ID: "a"},
+			},
+			expectedOnlyCIScoped: true,
+		},
+		{
+			description: "removed ci scoped role assignment",
+			before: []RoleAssignment{
+				{ID: "a"},
+				{ID: "b", CIScoped: true},
+			},
+			after: []RoleAssignment{
+				{ID: "a"},
+			},
+			expectedRemoved:      1,
+			expectedOnlyCIScoped: true,
+		},
+		{
+			description: "removed other role assignment",
+			before: []RoleAssignment{
+				{ID: "a"},
+				{ID: "b", CIScoped: true},
+			},
+			after: []RoleAssignment{
+				{ID: "b", CIScoped: true},
+			},
+			expectedRemoved:      1,
+			expectedOnlyCIScoped: false,
+		},
+		{
+			description: "added role assignment",
+			before: []RoleAssignment{
+				{ID: "a"},
+			},
+			after: []RoleAssignment{
+				{ID: "a"},
+				{ID: "c"},
+			},
+			expectedAdded:        1,
+			expectedOnlyCIScoped: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			d := NewRoleAssignmentDrift(tc.before, tc.after)
+
+			if len(d.Added) != tc.expectedAdded {
+				t.Errorf("expected %d added role assignments, got %d", tc.expectedAdded, len(d.Added))
+			}
+			if len(d.Removed) != tc.expectedRemoved {
+				t.Errorf("expected %d removed role assignments, got %d", tc.expectedRemoved, len(d.Removed))
+			}
+			if d.OnlyCIScoped != tc.expectedOnlyCIScoped {
+				t.Errorf("expected only ci scoped %t, got %t", tc.expectedOnlyCIScoped, d.OnlyCIScoped)
+			}
+			if d.Before != len(tc.before) || d.After != len(tc.after) {
+				t.Errorf("expected %d role assignments before and %d after, got %d and %d", len(tc.before), len(tc.after), d.Before, d.After)
+			}
+		})
+	}
+}
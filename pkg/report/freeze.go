@@ -0,0 +1,25 @@
+package report
+
+import (
+	"time"
+)
+
+// Freeze is the freeze window of releases, audits and the like a run fell
+// into, which made all its cleaners run in report-only mode.
+type Freeze struct {
+	Summary string    `json:"summary,omitempty"`
+	Start   time.Time `json:"start,omitempty"`
+	End     time.Time `json:"end,omitempty"`
+	// Error tells why the freeze calendar could not be read. Runs which
+	// cannot tell whether a freeze is active do not delete anything either.
+	Error string `json:"error,omitempty"`
+}
+
+// String describes the freeze for humans.
+func (f Freeze) String() string {
+	if f.Error != "" {
+		return "freeze calendar unavailable: " + f.Error
+	}
+
+	return f.Summary + " until " + f.End.UTC().Format(time.RFC3339)
+}
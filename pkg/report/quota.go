@@ -0,0 +1,21 @@
+package report
+
+// Quota is the utilization of a service quota after a run, e.g. of the VPCs
+// per region. Leaked resources exhausting quotas block CI long before their
+// cost is noticed.
+type Quota struct {
+	// Name identifies the quota, e.g. "vpcs-per-region".
+	Name  string  `json:"name"`
+	Usage int     `json:"usage"`
+	Limit float64 `json:"limit"`
+}
+
+// Utilization returns the share of the quota in use, e.g. 0.8 for 80%. It is
+// zero for quotas without limit.
+func (q Quota) Utilization() float64 {
+	if q.Limit <= 0 {
+		return 0
+	}
+
+	return float64(q.Usage) / q.Limit
+}
@@ -0,0 +1,293 @@
+// Package report collects the outcome of a ci-cleaner run for humans.
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/graph"
+	"github.com/giantswarm/ci-cleaner/pkg/selftest"
+)
+
+// Action is what happened to a resource found by a cleaner.
+type Action string
+
+const (
+	// ActionDeleted means the resource was deleted.
+	ActionDeleted Action = "deleted"
+	// ActionDeleting means deleting the resource was started, but did not
+	// finish yet. Later runs keep track of the deletion.
+	ActionDeleting Action = "deleting"
+	// ActionFailed means deleting the resource failed.
+	ActionFailed Action = "failed"
+	// ActionProtected means the resource would have been deleted, but it
+	// was protected temporarily.
+	ActionProtected Action = "protected"
+	// ActionReported means the resource would have been deleted, but the
+	// cleaner runs in report-only mode.
+	ActionReported Action = "reported"
+	// ActionSkipped means the resource would have been deleted, but a rule
+	// configured by operators kept it.
+	ActionSkipped Action = "skipped"
+	// ActionBudgetExceeded means the resource would have been deleted, but
+	// the estimated monthly cost of the resources deleted by the run
+	// exceeded the budget. It is held back for human review.
+	ActionBudgetExceeded Action = "budget-exceeded"
+)
+
+// Item is a single resource found by a cleaner.
+type Item struct {
+	Cleaner  string `json:"cleaner"`
+	Resource string `json:"resource"`
+	Action   Action `json:"action"`
+	// Reason is the code of the reason the cleaner found the resource to be
+	// deleted, e.g. "age-expired".
+	Reason string `json:"reason,omitempty"`
+	Error  string `json:"error,omitempty"`
+
+	// Type, Name, Account, Region, Tags and CreatedAt describe the
+	// resource further, as far as the cleaner knows them or they are
+	// contained in its ARN or Azure Resource Manager ID.
+	Type      string            `json:"type,omitempty"`
+	Name      string            `json:"name,omitempty"`
+	Account   string            `json:"account,omitempty"`
+	Region    string            `json:"region,omitempty"`
+	Tags      map[string]string `json:"tags,omitempty"`
+	CreatedAt *time.Time        `json:"createdAt,omitempty"`
+	// Installation and Cluster are the Giant Swarm installation and
+	// cluster the resource belongs to according to its tags.
+	Installation string `json:"installation,omitempty"`
+	Cluster      string `json:"cluster,omitempty"`
+	// OriginRepo is the repository whose pipeline created the resource
+	// according to its tags.
+	OriginRepo string `json:"originRepo,omitempty"`
+	// Cost calls out what an expensive resource is billed for.
+	Cost string `json:"cost,omitempty"`
+	// MonthlyCost is the estimated monthly cost of the resource in USD.
+	MonthlyCost float64 `json:"monthlyCost,omitempty"`
+	// Note tells humans what is deleted along with the resource.
+	Note string `json:"note,omitempty"`
+	// Rule tells which rule kept a skipped resource.
+	Rule string `json:"rule,omitempty"`
+
+	// Survived is the number of previous runs which found the resource
+	// already.
+	Survived int `json:"survived,omitempty"`
+	// Diagnostics are collected for resources which survived several runs
+	// to help humans figure out why they cannot be deleted.
+	Diagnostics []string `json:"diagnostics,omitempty"`
+	// ManualIntervention marks resources the ci-cleaner gave up on.
+	ManualIntervention bool `json:"manualIntervention,omitempty"`
+}
+
+// Report collects the items of a single run. It is safe for concurrent use.
+type Report struct {
+	mutex sync.Mutex
+
+	Provider string    `json:"provider"`
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+	// ReportOnly are the cleaners which ran in report-only mode.
+	ReportOnly []string `json:"reportOnly,omitempty"`
+	// Completed are the cleaners which ran to completion without errors.
+	Completed []string `json:"completed,omitempty"`
+	Items     []Item   `json:"items"`
+	// Freeze is the freeze window the run fell into, when all its cleaners
+	// ran in report-only mode because of it.
+	Freeze *Freeze `json:"freeze,omitempty"`
+	// Quotas is the utilization of the service quotas leaked resources
+	// count against after the run.
+	Quotas []Quota `json:"quotas,omitempty"`
+	// RoleAssignmentDrift is the difference of the role assignments before
+	// and after the run, when recorded.
+	RoleAssignmentDrift *RoleAssignmentDrift `json:"roleAssignmentDrift,omitempty"`
+	// SelfTest are the results of the self-test probes, when enabled.
+	SelfTest []selftest.Result `json:"selfTest,omitempty"`
+	// TimeToClean is how long the resources of each type lived before
+	// they were deleted, computed over the runs within the SLO window.
+	TimeToClean []TimeToClean `json:"timeToClean,omitempty"`
+
+	// graphs are the dependency graphs computed during the run. They are
+	// written to separate files next to the report.
+	graphs []*graph.Graph
+}
+
+// New creates a report for a run against the given provider which starts now.
+func New(provider string) *Report {
+	r := &Report{
+		Provider: provider,
+		Started:  time.Now().UTC(),
+	}
+
+	return r
+}
+
+// Add adds an item to the report.
+func (r *Report) Add(item Item) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.Items = append(r.Items, item)
+}
+
+// AddCompleted records that the given cleaner ran to completion.
+func (r *Report) AddCompleted(cleaner string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.Completed = append(r.Completed, cleaner)
+}
+
+// AddGraph adds the dependency graph of resources torn down together to the
+// report.
+func (r *Report) AddGraph(g *graph.Graph) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.graphs = append(r.graphs, g)
+}
+
+// SetFreeze sets the freeze window the run fell into.
+func (r *Report) SetFreeze(f Freeze) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.Freeze = &f
+}
+
+// SetQuotas sets the utilization of the service quotas after the run.
+func (r *Report) SetQuotas(q []Quota) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.Quotas = q
+}
+
+// SetRoleAssignmentDrift sets the role assignment drift of the run.
+func (r *Report) SetRoleAssignmentDrift(d RoleAssignmentDrift) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.RoleAssignmentDrift = &d
+}
+
+// SetSelfTest sets the results of the self-test of the run.
+func (r *Report) SetSelfTest(results []selftest.Result) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.SelfTest = results
+}
+
+// SetTimeToClean sets the time-to-clean of the resource types.
+func (r *Report) SetTimeToClean(t []TimeToClean) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.TimeToClean = t
+}
+
+// ByCleaner returns the items of the report grouped by cleaner.
+func (r *Report) ByCleaner() map[string][]Item {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	items := map[string][]Item{}
+	for _, i := range r.Items {
+		items[i.Cleaner] = append(items[i.Cleaner], i)
+	}
+
+	return items
+}
+
+// Candidates returns the sorted resources found by each cleaner, regardless
+// of what happened to them.
+func (r *Report) Candidates() map[string][]string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	candidates := map[string][]string{}
+	for _, i := range r.Items {
+		candidates[i.Cleaner] = append(candidates[i.Cleaner], i.Resource)
+	}
+	for _, c := range candidates {
+		sort.Strings(c)
+	}
+
+	return candidates
+}
+
+// FilePattern matches the file names of all reports and the files written
+// along with them, see Name.
+const FilePattern = "report-*"
+
+// ID returns the ID of the run, which tells its provider and when it
+// started, like `aws-20240517T120000Z`.
+func (r *Report) ID() string {
+	return fmt.Sprintf("%s-%s", r.Provider, r.Started.Format("20060102T150405Z"))
+}
+
+// Name returns the file name the report is written to.
+func (r *Report) Name() string {
+	return fmt.Sprintf("report-%s.json", r.ID())
+}
+
+// Write finishes the report and writes it as JSON file into dir. The path of
+// the written file is returned.
+func (r *Report) Write(dir string) (string, error) {
+	r.mutex.Lock()
+	r.Finished = time.Now().UTC()
+	b, err := json.MarshalIndent(r, "", "  ")
+	r.mutex.Unlock()
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	path := filepath.Join(dir, r.Name())
+	err = ioutil.WriteFile(path, b, 0600)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	err = r.writeGraphs(dir)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return path, nil
+}
+
+// writeGraphs writes every dependency graph as DOT and JSON file into dir.
+func (r *Report) writeGraphs(dir string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	base := strings.TrimSuffix(r.Name(), ".json")
+	for _, g := range r.graphs {
+		name := filepath.Join(dir, fmt.Sprintf("%s-graph-%s", base, g.Name))
+
+		err := ioutil.WriteFile(name+".dot", []byte(g.DOT()), 0600)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		b, err := json.MarshalIndent(g, "", "  ")
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		err = ioutil.WriteFile(name+".json", b, 0600)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
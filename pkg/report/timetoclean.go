@@ -0,0 +1,57 @@
+package report
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/giantswarm/microerror"
+)
+
+// TimeToClean is how long the resources of a single type lived before runs
+// deleted them, see package slo.
+type TimeToClean struct {
+	Provider string
+	Type     string
+	// Count is the number of deleted resources the percentiles are
+	// computed of.
+	Count int
+	P50   time.Duration
+	P95   time.Duration
+	// Threshold is the objective for P95. Zero means there is none.
+	Threshold time.Duration
+}
+
+// Exceeded returns whether the 95th percentile of the time-to-clean exceeds
+// the objective.
+func (t TimeToClean) Exceeded() bool {
+	return t.Threshold != 0 && t.P95 > t.Threshold
+}
+
+// MarshalJSON renders the durations for humans reading the report, like
+// "1h30m0s".
+func (t TimeToClean) MarshalJSON() ([]byte, error) {
+	v := struct {
+		Type      string `json:"type"`
+		Count     int    `json:"count"`
+		P50       string `json:"p50"`
+		P95       string `json:"p95"`
+		Threshold string `json:"threshold,omitempty"`
+		Exceeded  bool   `json:"exceeded,omitempty"`
+	}{
+		Type:     t.Type,
+		Count:    t.Count,
+		P50:      t.P50.String(),
+		P95:      t.P95.String(),
+		Exceeded: t.Exceeded(),
+	}
+	if t.Threshold != 0 {
+		v.Threshold = t.Threshold.String()
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return b, nil
+}
@@ -0,0 +1,148 @@
+package resource
+
+import (
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// ProviderAWS is the provider of resources identified by ARNs.
+	ProviderAWS = "aws"
+	// ProviderAzure is the provider of resources identified by Azure
+	// Resource Manager IDs.
+	ProviderAzure = "azure"
+)
+
+// ID is a parsed ARN or Azure Resource Manager ID.
+type ID struct {
+	Provider string
+	// Account is the AWS account or the Azure subscription of the
+	// resource.
+	Account string
+	Region  string
+	// ResourceGroup is the Azure resource group of the resource. It is
+	// empty for AWS resources and subscription level Azure resources.
+	ResourceGroup string
+	// Service is the AWS service or the Azure resource provider namespace,
+	// e.g. `ec2` or `Microsoft.Network`.
+	Service string
+	// Type is the resource type within the service, e.g. `vpc` or
+	// `virtualNetworks/subnets`.
+	Type string
+	// Name is the last segment of Azure IDs, e.g. the name of a subnet, and
+	// the resource part of ARNs following the type, e.g. the ID of a VPC.
+	Name string
+}
+
+// Parse parses the given ARN or Azure Resource Manager ID. false is returned
+// for anything else, e.g. the plain IDs most AWS resources are listed by.
+func Parse(s string) (ID, bool) {
+	var id ID
+	var err error
+	switch {
+	case arn.IsARN(s):
+		id, err = ParseARN(s)
+	case strings.HasPrefix(strings.ToLower(s), "/subscriptions/"):
+		id, err = ParseARMID(s)
+	default:
+		return ID{}, false
+	}
+	if err != nil {
+		return ID{}, false
+	}
+
+	return id, true
+}
+
+// ParseARN parses the given ARN, like
+// `arn:aws:ec2:eu-central-1:123456789012:vpc/vpc-a1b2c3d4`. The resource part
+// is split into type and name at the first slash or colon, as AWS services do
+// not agree on either.
+func ParseARN(s string) (ID, error) {
+	a, err := arn.Parse(s)
+	if err != nil {
+		return ID{}, microerror.Maskf(invalidIDError, "%#q is not an ARN", s)
+	}
+
+	id := ID{
+		Provider: ProviderAWS,
+		Account:  a.AccountID,
+		Region:   a.Region,
+		Service:  a.Service,
+		Name:     a.Resource,
+	}
+
+	if i := strings.IndexAny(a.Resource, "/:"); i >= 0 {
+		id.Type = a.Resource[:i]
+		id.Name = a.Resource[i+1:]
+	}
+	// log group ARNs may end with a wildcard matching their streams.
+	id.Name = strings.TrimSuffix(id.Name, ":*")
+
+	return id, nil
+}
+
+// ParseARMID parses the given Azure Resource Manager ID, like
+// `/subscriptions/<id>/resourceGroups/<group>/providers/Microsoft.Network/virtualNetworks/<name>`.
+// IDs of resource groups and of subscription level resources are supported
+// as well. Azure IDs do not contain the location of a resource.
+func ParseARMID(s string) (ID, error) {
+	segments := strings.Split(strings.Trim(s, "/"), "/")
+	if len(segments) < 2 || !strings.EqualFold(segments[0], "subscriptions") || segments[1] == "" {
+		return ID{}, microerror.Maskf(invalidIDError, "%#q is not an Azure Resource Manager ID", s)
+	}
+
+	id := ID{
+		Provider: ProviderAzure,
+		Account:  segments[1],
+		Name:     segments[1],
+		Service:  "Microsoft.Resources",
+		Type:     "subscriptions",
+	}
+	segments = segments[2:]
+
+	if len(segments) >= 2 && strings.EqualFold(segments[0], "resourceGroups") {
+		id.ResourceGroup = segments[1]
+		id.Name = segments[1]
+		id.Type = "resourceGroups"
+		segments = segments[2:]
+	}
+
+	// the last providers segment wins for extension resources, e.g. the
+	// diagnostic settings of a resource.
+	for i := len(segments) - 2; i >= 0; i-- {
+		if !strings.EqualFold(segments[i], "providers") {
+			continue
+		}
+
+		rest := segments[i+1:]
+		if len(rest) < 3 {
+			return ID{}, microerror.Maskf(invalidIDError, "%#q is not an Azure Resource Manager ID", s)
+		}
+
+		id.Service = rest[0]
+		id.Name = rest[len(rest)-1]
+
+		var types []string
+		for j := 1; j < len(rest); j += 2 {
+			types = append(types, rest[j])
+		}
+		id.Type = strings.Join(types, "/")
+
+		break
+	}
+
+	return id, nil
+}
+
+// FullType returns the type of the resource including its service, e.g.
+// `Microsoft.Network/virtualNetworks` or `ec2/vpc`.
+func (id ID) FullType() string {
+	if id.Type == "" {
+		return id.Service
+	}
+
+	return id.Service + "/" + id.Type
+}
@@ -0,0 +1,70 @@
+// Package resource implements the provider agnostic model of the resources
+// cleaners find, so that cross-cutting features like reports, metrics and
+// protections do not need to know how each provider identifies resources.
+package resource
+
+import (
+	"time"
+
+	"github.com/giantswarm/ci-cleaner/pkg/owner"
+)
+
+// Resource is a resource of any provider. Only the ID is required. The other
+// fields are derived from the ID by Normalize as far as possible.
+type Resource struct {
+	Provider string
+	// Type is the type of the resource as the cleaner calls it, e.g.
+	// `AWS::EC2::VPC` or `Microsoft.Network/virtualNetworks`.
+	Type string
+	// ID is the ARN, Azure Resource Manager ID or any other ID the
+	// provider identifies the resource by.
+	ID        string
+	Name      string
+	Account   string
+	Region    string
+	Tags      map[string]string
+	CreatedAt time.Time
+
+	// Cost calls out what the resource is billed for, for resources which
+	// are expensive to leave behind.
+	Cost string
+	// MonthlyCost is the estimated monthly cost of the resource in USD.
+	MonthlyCost float64
+}
+
+// Normalize returns a copy of the resource with the fields the cleaner did
+// not set derived from its ID, when the ID is an ARN or Azure Resource
+// Manager ID. The name defaults to the ID otherwise.
+func (r Resource) Normalize() Resource {
+	id, ok := Parse(r.ID)
+	if !ok {
+		if r.Name == "" {
+			r.Name = r.ID
+		}
+		return r
+	}
+
+	if r.Provider == "" {
+		r.Provider = id.Provider
+	}
+	if r.Type == "" {
+		r.Type = id.FullType()
+	}
+	if r.Name == "" {
+		r.Name = id.Name
+	}
+	if r.Account == "" {
+		r.Account = id.Account
+	}
+	if r.Region == "" {
+		r.Region = id.Region
+	}
+
+	return r
+}
+
+// Owner returns the Giant Swarm installation and cluster the resource
+// belongs to according to its tags.
+func (r Resource) Owner() owner.Owner {
+	return owner.Of(r.Tags)
+}
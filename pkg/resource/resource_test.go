@@ -0,0 +1,142 @@
+package resource
+
+import (
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tcs := []struct {
+		id          string
+		expected    ID
+		expectedOK  bool
+		description string
+	}{
+		{
+			description: "arn of vpc",
+			id:          "arn:aws:ec2:eu-central-1:123456789012:vpc/vpc-a1b2c3d4",
+			expected: ID{
+				Provider: ProviderAWS,
+				Account:  "123456789012",
+				Region:   "eu-central-1",
+				Service:  "ec2",
+				Type:     "vpc",
+				Name:     "vpc-a1b2c3d4",
+			},
+			expectedOK: true,
+		},
+		{
+			description: "arn of log group",
+			id:          "arn:aws:logs:eu-central-1:123456789012:log-group:/aws/eks/ci-wip-a1b2c/cluster:*",
+			expected: ID{
+				Provider: ProviderAWS,
+				Account:  "123456789012",
+				Region:   "eu-central-1",
+				Service:  "logs",
+				Type:     "log-group",
+				Name:     "/aws/eks/ci-wip-a1b2c/cluster",
+			},
+			expectedOK: true,
+		},
+		{
+			description: "arn of bucket",
+			id:          "arn:aws:s3:::ci-wip-a1b2c-logs",
+			expected: ID{
+				Provider: ProviderAWS,
+				Service:  "s3",
+				Name:     "ci-wip-a1b2c-logs",
+			},
+			expectedOK: true,
+		},
+		{
+			description: "azure id of subnet",
+			id:          "/subscriptions/a1b2c3d4/resourceGroups/ci-wip-a1b2c/providers/Microsoft.Network/virtualNetworks/ci-wip-a1b2c-vnet/subnets/worker",
+			expected: ID{
+				Provider:      ProviderAzure,
+				Account:       "a1b2c3d4",
+				ResourceGroup: "ci-wip-a1b2c",
+				Service:       "Microsoft.Network",
+				Type:          "virtualNetworks/subnets",
+				Name:          "worker",
+			},
+			expectedOK: true,
+		},
+		{
+			description: "azure id of resource group",
+			id:          "/subscriptions/a1b2c3d4/resourceGroups/ci-wip-a1b2c",
+			expected: ID{
+				Provider:      ProviderAzure,
+				Account:       "a1b2c3d4",
+				ResourceGroup: "ci-wip-a1b2c",
+				Service:       "Microsoft.Resources",
+				Type:          "resourceGroups",
+				Name:          "ci-wip-a1b2c",
+			},
+			expectedOK: true,
+		},
+		{
+			description: "azure id of subscription level resource",
+			id:          "/subscriptions/a1b2c3d4/providers/Microsoft.Insights/diagnosticSettings/ci-wip-a1b2c",
+			expected: ID{
+				Provider: ProviderAzure,
+				Account:  "a1b2c3d4",
+				Service:  "Microsoft.Insights",
+				Type:     "diagnosticSettings",
+				Name:     "ci-wip-a1b2c",
+			},
+			expectedOK: true,
+		},
+		{
+			description: "plain id",
+			id:          "vpc-a1b2c3d4",
+			expectedOK:  false,
+		},
+		{
+			description: "malformed azure id",
+			id:          "/subscriptions/a1b2c3d4/providers/Microsoft.Network",
+			expectedOK:  false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual, ok := Parse(tc.id)
+
+			if ok != tc.expectedOK {
+				t.Fatalf("parsing %q, want ok %t, got %t", tc.id, tc.expectedOK, ok)
+			}
+			if actual != tc.expected {
+				t.Errorf("parsing %q, want %#v, got %#v", tc.id, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	r := Resource{
+		ID:     "arn:aws:ec2:eu-central-1:123456789012:vpc/vpc-a1b2c3d4",
+		Type:   "AWS::EC2::VPC",
+		Region: "eu-west-1",
+	}.Normalize()
+
+	if r.Provider != ProviderAWS {
+		t.Errorf("expected provider %q, got %q", ProviderAWS, r.Provider)
+	}
+	if r.Type != "AWS::EC2::VPC" {
+		t.Errorf("expected type set by cleaner to be kept, got %q", r.Type)
+	}
+	if r.Name != "vpc-a1b2c3d4" {
+		t.Errorf("expected name %q, got %q", "vpc-a1b2c3d4", r.Name)
+	}
+	if r.Account != "123456789012" {
+		t.Errorf("expected account %q, got %q", "123456789012", r.Account)
+	}
+	if r.Region != "eu-west-1" {
+		t.Errorf("expected region set by cleaner to be kept, got %q", r.Region)
+	}
+
+	r = Resource{ID: "vpc-a1b2c3d4"}.Normalize()
+
+	if r.Name != "vpc-a1b2c3d4" {
+		t.Errorf("expected name to default to id, got %q", r.Name)
+	}
+}
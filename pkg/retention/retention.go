@@ -0,0 +1,150 @@
+// Package retention implements the garbage collection of the data the
+// ci-cleaner produces itself. State entries, audit records and run reports
+// are removed once they are older than their configured retention, so that
+// long running deployments do not grow their volumes without bounds.
+package retention
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+
+	"github.com/giantswarm/ci-cleaner/pkg/audit"
+	"github.com/giantswarm/ci-cleaner/pkg/protection"
+	"github.com/giantswarm/ci-cleaner/pkg/report"
+	"github.com/giantswarm/ci-cleaner/pkg/state"
+)
+
+type Config struct {
+	Audit  *audit.Log
+	Logger micrologger.Logger
+	State  *state.Store
+
+	// ReportDir is the directory run reports are written to. Reports are
+	// not pruned when empty.
+	ReportDir string
+
+	// AuditRetention is the time audit records are kept. Zero keeps them forever.
+	AuditRetention time.Duration
+	// ReportRetention is the time run reports are kept. Zero keeps them
+	// forever.
+	ReportRetention time.Duration
+	// StateRetention is the time state entries are kept after their last
+	// update. Zero keeps them forever. Protections are kept until they
+	// expire.
+	StateRetention time.Duration
+}
+
+type Retention struct {
+	audit  *audit.Log
+	logger micrologger.Logger
+	state  *state.Store
+
+	reportDir string
+
+	auditRetention  time.Duration
+	reportRetention time.Duration
+	stateRetention  time.Duration
+}
+
+func New(config Config) (*Retention, error) {
+	if config.Audit == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Audit must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.State == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.State must not be empty", config)
+	}
+
+	if config.AuditRetention < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.AuditRetention must not be negative", config)
+	}
+	if config.ReportRetention < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ReportRetention must not be negative", config)
+	}
+	if config.StateRetention < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.StateRetention must not be negative", config)
+	}
+
+	r := &Retention{
+		audit:  config.Audit,
+		logger: config.Logger,
+		state:  config.State,
+
+		reportDir: config.ReportDir,
+
+		auditRetention:  config.AuditRetention,
+		reportRetention: config.ReportRetention,
+		stateRetention:  config.StateRetention,
+	}
+
+	return r, nil
+}
+
+// Prune removes all data which is older than its retention. The state store
+// is only pruned in memory and has to be flushed by the caller.
+func (r *Retention) Prune() error {
+	now := time.Now()
+
+	if r.stateRetention != 0 {
+		// Protections can last longer than the state retention. They are
+		// removed by the protection package once they expired.
+		n := r.state.DeleteUpdatedBefore(now.Add(-r.stateRetention), protection.KeyPrefix)
+		r.logger.Log("level", "debug", "message", fmt.Sprintf("pruned %d state entries older than %s", n, r.stateRetention))
+	}
+
+	if r.auditRetention != 0 {
+		n, err := r.audit.DeleteBefore(now.Add(-r.auditRetention))
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		r.logger.Log("level", "debug", "message", fmt.Sprintf("pruned %d audit records older than %s", n, r.auditRetention))
+	}
+
+	if r.reportRetention != 0 && r.reportDir != "" {
+		n, err := pruneReports(r.reportDir, now.Add(-r.reportRetention))
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		r.logger.Log("level", "debug", "message", fmt.Sprintf("pruned %d reports older than %s", n, r.reportRetention))
+	}
+
+	return nil
+}
+
+// pruneReports removes the reports in dir which were last modified before t
+// and returns the number of removed reports.
+func pruneReports(dir string, t time.Time) (int, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, report.FilePattern))
+	if err != nil {
+		return 0, microerror.Mask(err)
+	}
+
+	var n int
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return n, microerror.Mask(err)
+		}
+
+		if !info.ModTime().Before(t) {
+			continue
+		}
+
+		err = os.Remove(p)
+		if err != nil && !os.IsNotExist(err) {
+			return n, microerror.Mask(err)
+		}
+		n++
+	}
+
+	return n, nil
+}
@@ -0,0 +1,164 @@
+package retention
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+
+	"github.com/giantswarm/ci-cleaner/pkg/audit"
+	"github.com/giantswarm/ci-cleaner/pkg/protection"
+	"github.com/giantswarm/ci-cleaner/pkg/resource"
+	"github.com/giantswarm/ci-cleaner/pkg/state"
+)
+
+func TestPrune(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ci-cleaner-retention")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	auditLog, err := audit.New(audit.Config{Path: filepath.Join(dir, "audit.log")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stateStore, err := state.New(state.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+
+	for _, r := range []audit.Record{
+		{Time: now.Add(-48 * time.Hour), Action: "promoted", Cleaner: "stacks"},
+		{Time: now.Add(-time.Hour), Action: "promoted", Cleaner: "buckets"},
+	} {
+		err = auditLog.Record(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = stateStore.Put("rollout/stacks", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldReport := filepath.Join(dir, "report-aws-20200101T000000Z.json")
+	newReport := filepath.Join(dir, "report-aws-20200102T000000Z.json")
+	for _, p := range []string{oldReport, newReport} {
+		err = ioutil.WriteFile(p, []byte("{}"), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = os.Chtimes(oldReport, now.Add(-48*time.Hour), now.Add(-48*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := New(Config{
+		Audit:  auditLog,
+		Logger: microloggertest.New(),
+		State:  stateStore,
+
+		ReportDir: dir,
+
+		AuditRetention:  24 * time.Hour,
+		ReportRetention: 24 * time.Hour,
+		StateRetention:  24 * time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = r.Prune()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := auditLog.Records()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Cleaner != "buckets" {
+		t.Errorf("expected only the recent audit record to be kept, got %v", records)
+	}
+
+	if keys := stateStore.Keys(""); len(keys) != 1 {
+		t.Errorf("expected recently updated state entry to be kept, got %v", keys)
+	}
+
+	_, err = os.Stat(oldReport)
+	if !os.IsNotExist(err) {
+		t.Errorf("expected %s to be removed", oldReport)
+	}
+	_, err = os.Stat(newReport)
+	if err != nil {
+		t.Errorf("expected %s to be kept, got %v", newReport, err)
+	}
+}
+
+func TestPruneKeepsActiveProtections(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ci-cleaner-retention")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	auditLog, err := audit.New(audit.Config{Path: filepath.Join(dir, "audit.log")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stateStore, err := state.New(state.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := protection.New(protection.Config{State: stateStore})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = p.Keep("ci-a1b2c", 7*24*time.Hour, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = stateStore.Put("rollout/stacks", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := New(Config{
+		Audit:  auditLog,
+		Logger: microloggertest.New(),
+		State:  stateStore,
+
+		StateRetention: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	err = r.Prune()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if keys := stateStore.Keys("rollout/"); len(keys) != 0 {
+		t.Errorf("expected state entry past its retention to be removed, got %v", keys)
+	}
+
+	_, protected, err := p.Protected(resource.Resource{ID: "ci-a1b2c-master"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !protected {
+		t.Errorf("expected protection to outlive the state retention")
+	}
+}
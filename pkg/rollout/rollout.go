@@ -0,0 +1,229 @@
+// Package rollout implements the canary rollout of newly enabled cleaners. A
+// cleaner under rollout runs in report-only mode until its candidate sets were
+// stable for a configured number of runs and is then promoted to delete
+// resources. Cleaners are rolled out separately for every provider.
+package rollout
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+
+	"github.com/giantswarm/ci-cleaner/pkg/audit"
+	"github.com/giantswarm/ci-cleaner/pkg/state"
+)
+
+const (
+	// ActionPromoted is the audit log action recorded when a cleaner is
+	// promoted to destructive mode.
+	ActionPromoted = "promoted"
+
+	keyPrefix = "rollout/"
+)
+
+type Config struct {
+	Audit  *audit.Log
+	Logger micrologger.Logger
+	State  *state.Store
+
+	// Provider is the provider the cleaners under rollout belong to.
+	Provider string
+
+	// Cleaners are the names of the cleaners under rollout.
+	Cleaners []string
+	// Runs is the number of report-only runs a cleaner has to complete
+	// before it can be promoted.
+	Runs int
+	// Tolerance is the maximum distance between the candidate sets of
+	// consecutive runs for them to be considered stable. The distance is the
+	// share of candidates found by only one of both runs, between 0 and 1.
+	Tolerance float64
+}
+
+type Rollout struct {
+	audit  *audit.Log
+	logger micrologger.Logger
+	state  *state.Store
+
+	provider  string
+	cleaners  []string
+	runs      int
+	tolerance float64
+}
+
+// cleanerState is the rollout state of a single cleaner.
+type cleanerState struct {
+	Promoted   bool       `json:"promoted"`
+	PromotedAt time.Time  `json:"promotedAt,omitempty"`
+	Runs       [][]string `json:"runs"`
+}
+
+func New(config Config) (*Rollout, error) {
+	if config.Audit == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Audit must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.State == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.State must not be empty", config)
+	}
+
+	if len(config.Cleaners) != 0 && config.Provider == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Provider must not be empty", config)
+	}
+	if len(config.Cleaners) != 0 && config.Runs < 1 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Runs must be greater than 0", config)
+	}
+	if config.Tolerance < 0 || config.Tolerance > 1 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Tolerance must be between 0 and 1", config)
+	}
+
+	r := &Rollout{
+		audit:  config.Audit,
+		logger: config.Logger,
+		state:  config.State,
+
+		provider:  config.Provider,
+		cleaners:  config.Cleaners,
+		runs:      config.Runs,
+		tolerance: config.Tolerance,
+	}
+
+	return r, nil
+}
+
+// ReportOnly returns the cleaners under rollout which were not promoted yet
+// and therefore have to run in report-only mode.
+func (r *Rollout) ReportOnly() ([]string, error) {
+	var reportOnly []string
+
+	for _, c := range r.cleaners {
+		s, err := r.get(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		if !s.Promoted {
+			reportOnly = append(reportOnly, c)
+		}
+	}
+
+	return reportOnly, nil
+}
+
+// Observe records the candidates found by the cleaners under rollout during
+// a report-only run and promotes the cleaners whose candidate sets were
+// stable. Only the given completed cleaners are observed, as skipped or
+// failed cleaners did not find all their candidates.
+func (r *Rollout) Observe(candidates map[string][]string, completed []string) error {
+	isCompleted := map[string]bool{}
+	for _, c := range completed {
+		isCompleted[c] = true
+	}
+
+	for _, c := range r.cleaners {
+		s, err := r.get(c)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		// The state of promoted cleaners is written anyway to keep it from
+		// expiring, which would put the cleaner back into report-only mode.
+		if !s.Promoted && isCompleted[c] {
+			s.Runs = append(s.Runs, candidates[c])
+			if len(s.Runs) > r.runs {
+				s.Runs = s.Runs[len(s.Runs)-r.runs:]
+			}
+		}
+
+		if !s.Promoted && len(s.Runs) == r.runs && r.isStable(s.Runs) {
+			s.Promoted = true
+			s.PromotedAt = time.Now().UTC()
+
+			message := fmt.Sprintf("promoted %s cleaner %#q to destructive mode after %d stable report-only runs", r.provider, c, r.runs)
+			r.logger.Log("level", "info", "message", message)
+
+			err = r.audit.Record(audit.Record{
+				Action:  ActionPromoted,
+				Cleaner: c,
+				Message: message,
+			})
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+
+		err = r.state.Put(r.key(c), s)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+func (r *Rollout) get(cleaner string) (cleanerState, error) {
+	var s cleanerState
+
+	_, err := r.state.Get(r.key(cleaner), &s)
+	if err != nil {
+		return cleanerState{}, microerror.Mask(err)
+	}
+
+	return s, nil
+}
+
+func (r *Rollout) key(cleaner string) string {
+	return keyPrefix + r.provider + "/" + cleaner
+}
+
+// isStable returns whether the candidate sets of the given runs were stable.
+// Runs which found no candidates at all tell nothing about the cleaner, so
+// they are not considered stable.
+func (r *Rollout) isStable(runs [][]string) bool {
+	var found bool
+	for _, candidates := range runs {
+		if len(candidates) != 0 {
+			found = true
+		}
+	}
+	if !found {
+		return false
+	}
+
+	for i := 1; i < len(runs); i++ {
+		if distance(runs[i-1], runs[i]) > r.tolerance {
+			return false
+		}
+	}
+
+	return true
+}
+
+// distance returns the share of elements contained in only one of the given
+// sets, which is 0 for equal sets and 1 for disjoint sets.
+func distance(a, b []string) float64 {
+	union := map[string]int{}
+	for _, e := range a {
+		union[e] |= 1
+	}
+	for _, e := range b {
+		union[e] |= 2
+	}
+
+	if len(union) == 0 {
+		return 0
+	}
+
+	var diff int
+	for _, v := range union {
+		if v != 3 {
+			diff++
+		}
+	}
+
+	return float64(diff) / float64(len(union))
+}
@@ -0,0 +1,237 @@
+package rollout
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+
+	"github.com/giantswarm/ci-cleaner/pkg/audit"
+	"github.com/giantswarm/ci-cleaner/pkg/state"
+)
+
+func TestDistance(t *testing.T) {
+	tcs := []struct {
+		a, b     []string
+		expected float64
+	}{
+		{a: nil, b: nil, expected: 0},
+		{a: []string{"a", "b"}, b: []string{"b", "a"}, expected: 0},
+		{a: []string{"a"}, b: []string{"b"}, expected: 1},
+		{a: []string{"a", "b", "c"}, b: []string{"a", "b", "c", "d"}, expected: 0.25},
+	}
+
+	for _, tc := range tcs {
+		actual := distance(tc.a, tc.b)
+		if actual != tc.expected {
+			t.Errorf("distance(%v, %v): want %f, got %f", tc.a, tc.b, tc.expected, actual)
+		}
+	}
+}
+
+func TestRollout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ci-cleaner-rollout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	auditLog, err := audit.New(audit.Config{Path: filepath.Join(dir, "audit.log")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := state.New(state.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := New(Config{
+		Audit:  auditLog,
+		Logger: microloggertest.New(),
+		State:  store,
+
+		Provider:  "aws",
+		Cleaners:  []string{"stacks", "buckets"},
+		Runs:      2,
+		Tolerance: 0.3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runs := []map[string][]string{
+		{"stacks": {"a", "b", "c"}, "buckets": {"x"}},
+		{"stacks": {"a", "b", "c", "d"}, "buckets": {"y"}},
+		{"stacks": {"a", "b", "c", "d"}, "buckets": {"z"}},
+	}
+	expectedReportOnly := [][]string{
+		{"stacks", "buckets"},
+		{"stacks", "buckets"},
+		{"buckets"},
+	}
+
+	for i, candidates := range runs {
+		reportOnly, err := r.ReportOnly()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(reportOnly, expectedReportOnly[i]) {
+			t.Errorf("run %d: expected report-only cleaners %v, got %v", i, expectedReportOnly[i], reportOnly)
+		}
+
+		err = r.Observe(candidates, []string{"stacks", "buckets"})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	records, err := auditLog.Records()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Action != ActionPromoted || records[0].Cleaner != "stacks" {
+		t.Errorf("expected a single promotion of %q, got %v", "stacks", records)
+	}
+}
+
+func TestRolloutObserve(t *testing.T) {
+	tcs := []struct {
+		candidates  []map[string][]string
+		completed   [][]string
+		expected    bool
+		description string
+	}{
+		{
+			description: "stable cleaner is promoted",
+			candidates:  []map[string][]string{{"stacks": {"a"}}, {"stacks": {"a"}}},
+			completed:   [][]string{{"stacks"}, {"stacks"}},
+			expected:    true,
+		},
+		{
+			description: "cleaner without candidates is not promoted",
+			candidates:  []map[string][]string{{}, {}, {}},
+			completed:   [][]string{{"stacks"}, {"stacks"}, {"stacks"}},
+			expected:    false,
+		},
+		{
+			description: "failed run is not observed",
+			candidates:  []map[string][]string{{"stacks": {"a"}}, {}, {"stacks": {"a"}}},
+			completed:   [][]string{{"stacks"}, nil, {"stacks"}},
+			expected:    true,
+		},
+		{
+			description: "runs of other cleaners are not observed",
+			candidates:  []map[string][]string{{"stacks": {"a"}}, {"buckets": {"x"}}},
+			completed:   [][]string{{"stacks"}, {"buckets"}},
+			expected:    false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "ci-cleaner-rollout")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+
+			auditLog, err := audit.New(audit.Config{Path: filepath.Join(dir, "audit.log")})
+			if err != nil {
+				t.Fatal(err)
+			}
+			store, err := state.New(state.Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r, err := New(Config{
+				Audit:  auditLog,
+				Logger: microloggertest.New(),
+				State:  store,
+
+				Provider:  "aws",
+				Cleaners:  []string{"stacks"},
+				Runs:      2,
+				Tolerance: 0,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i := range tc.candidates {
+				err = r.Observe(tc.candidates[i], tc.completed[i])
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			reportOnly, err := r.ReportOnly()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if promoted := len(reportOnly) == 0; promoted != tc.expected {
+				t.Errorf("want promoted %t, got %t", tc.expected, promoted)
+			}
+		})
+	}
+}
+
+func TestRolloutIsPerProvider(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ci-cleaner-rollout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	auditLog, err := audit.New(audit.Config{Path: filepath.Join(dir, "audit.log")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := state.New(state.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rollouts := map[string]*Rollout{}
+	for _, p := range []string{"aws", "azure"} {
+		rollouts[p], err = New(Config{
+			Audit:  auditLog,
+			Logger: microloggertest.New(),
+			State:  store,
+
+			Provider:  p,
+			Cleaners:  []string{"charts"},
+			Runs:      2,
+			Tolerance: 0,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i := 0; i < 2; i++ {
+		err = rollouts["aws"].Observe(map[string][]string{"charts": {"a"}}, []string{"charts"})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reportOnly, err := rollouts["aws"].ReportOnly()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reportOnly) != 0 {
+		t.Errorf("expected aws cleaner to be promoted, got report-only cleaners %v", reportOnly)
+	}
+
+	reportOnly, err = rollouts["azure"].ReportOnly()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(reportOnly, []string{"charts"}) {
+		t.Errorf("expected azure cleaner to stay in report-only mode, got %v", reportOnly)
+	}
+}
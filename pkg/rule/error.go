@@ -0,0 +1,23 @@
+package rule
+
+import (
+	"github.com/giantswarm/microerror"
+)
+
+var invalidConfigError = &microerror.Error{
+	Kind: "invalidConfigError",
+}
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
+
+var invalidExpressionError = &microerror.Error{
+	Kind: "invalidExpressionError",
+}
+
+// IsInvalidExpression asserts invalidExpressionError.
+func IsInvalidExpression(err error) bool {
+	return microerror.Cause(err) == invalidExpressionError
+}
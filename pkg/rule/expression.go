@@ -0,0 +1,546 @@
+package rule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/giantswarm/microerror"
+)
+
+// Expression is a compiled condition like
+// `age > 4h && tags["team"] != "release"`. Expressions support string,
+// number, duration and boolean literals, the variables of Env, indexing tags
+// by key, comparisons, the logical operators `&&`, `||` and `!`, parentheses
+// and the string methods `contains`, `startsWith` and `endsWith`.
+type Expression struct {
+	source string
+	eval   evalFunc
+}
+
+// evalFunc evaluates a node of an expression against the given variables.
+type evalFunc func(vars map[string]interface{}) (interface{}, error)
+
+// Compile parses the given expression.
+func Compile(source string) (*Expression, error) {
+	tokens, err := lex(source)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	p := &parser{tokens: tokens}
+	eval, err := p.parseOr()
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	if p.peek().kind != tokenEOF {
+		return nil, microerror.Maskf(invalidExpressionError, "unexpected %q in %#q", p.peek().text, source)
+	}
+
+	e := &Expression{
+		source: source,
+		eval:   eval,
+	}
+
+	return e, nil
+}
+
+// String returns the source of the expression.
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Matches evaluates the expression against the given variables. Expressions
+// which do not evaluate to a boolean are an error.
+func (e *Expression) Matches(env Env) (bool, error) {
+	v, err := e.eval(env.vars())
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	b, ok := v.(bool)
+	if !ok {
+		return false, microerror.Maskf(invalidExpressionError, "%#q does not evaluate to a boolean", e.source)
+	}
+
+	return b, nil
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenDuration
+	tokenOperator
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	// value is the parsed value of literals.
+	value interface{}
+}
+
+// operators are all operators and punctuation, longest first.
+var operators = []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "(", ")", "[", "]", ".", ","}
+
+func lex(source string) ([]token, error) {
+	var tokens []token
+
+	s := source
+	for {
+		s = strings.TrimLeftFunc(s, unicode.IsSpace)
+		if s == "" {
+			break
+		}
+
+		c := rune(s[0])
+		switch {
+		case c == '"' || c == '\'':
+			end := strings.IndexRune(s[1:], c)
+			if end < 0 {
+				return nil, microerror.Maskf(invalidExpressionError, "unterminated string in %#q", source)
+			}
+			text := s[:end+2]
+			tokens = append(tokens, token{kind: tokenString, text: text, value: s[1 : end+1]})
+			s = s[end+2:]
+
+		case unicode.IsDigit(c):
+			n := strings.IndexFunc(s, func(r rune) bool {
+				return !unicode.IsDigit(r) && !unicode.IsLetter(r) && r != '.'
+			})
+			if n < 0 {
+				n = len(s)
+			}
+			text := s[:n]
+			s = s[n:]
+
+			if f, err := strconv.ParseFloat(text, 64); err == nil {
+				tokens = append(tokens, token{kind: tokenNumber, text: text, value: f})
+				continue
+			}
+			d, err := time.ParseDuration(text)
+			if err != nil {
+				return nil, microerror.Maskf(invalidExpressionError, "invalid number or duration %q in %#q", text, source)
+			}
+			tokens = append(tokens, token{kind: tokenDuration, text: text, value: d})
+
+		case unicode.IsLetter(c) || c == '_':
+			n := strings.IndexFunc(s, func(r rune) bool {
+				return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+			})
+			if n < 0 {
+				n = len(s)
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: s[:n]})
+			s = s[n:]
+
+		default:
+			var op string
+			for _, o := range operators {
+				if strings.HasPrefix(s, o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, microerror.Maskf(invalidExpressionError, "unexpected %q in %#q", string(c), source)
+			}
+			tokens = append(tokens, token{kind: tokenOperator, text: op})
+			s = s[len(op):]
+		}
+	}
+
+	tokens = append(tokens, token{kind: tokenEOF, text: "end of expression"})
+
+	return tokens, nil
+}
+
+// parser is a recursive descent parser compiling tokens into evalFuncs.
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) accept(op string) bool {
+	t := p.peek()
+	if t.kind == tokenOperator && t.text == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(op string) error {
+	if !p.accept(op) {
+		return microerror.Maskf(invalidExpressionError, "expected %q, got %q", op, p.peek().text)
+	}
+	return nil
+}
+
+func (p *parser) parseOr() (evalFunc, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	for p.accept("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		left = logical(left, right, true)
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd() (evalFunc, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	for p.accept("&&") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		left = logical(left, right, false)
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseUnary() (evalFunc, error) {
+	if !p.accept("!") {
+		return p.parseComparison()
+	}
+
+	operand, err := p.parseUnary()
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	f := func(vars map[string]interface{}) (interface{}, error) {
+		v, err := operand(vars)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		b, ok := v.(bool)
+		if !ok {
+			return nil, microerror.Maskf(invalidExpressionError, "cannot negate %v", v)
+		}
+		return !b, nil
+	}
+
+	return f, nil
+}
+
+func (p *parser) parseComparison() (evalFunc, error) {
+	left, err := p.parsePostfix()
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	t := p.peek()
+	if t.kind != tokenOperator {
+		return left, nil
+	}
+	switch t.text {
+	case "==", "!=", "<", "<=", ">", ">=":
+	default:
+		return left, nil
+	}
+	p.next()
+
+	right, err := p.parsePostfix()
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	f := func(vars map[string]interface{}) (interface{}, error) {
+		l, err := left(vars)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		r, err := right(vars)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		return compare(t.text, l, r)
+	}
+
+	return f, nil
+}
+
+func (p *parser) parsePostfix() (evalFunc, error) {
+	operand, err := p.parsePrimary()
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	for {
+		switch {
+		case p.accept("["):
+			key, err := p.parseOr()
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+			err = p.expect("]")
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+			operand = index(operand, key)
+
+		case p.accept("."):
+			name := p.next()
+			if name.kind != tokenIdent {
+				return nil, microerror.Maskf(invalidExpressionError, "expected method name, got %q", name.text)
+			}
+			err = p.expect("(")
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+			arg, err := p.parseOr()
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+			err = p.expect(")")
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+			operand, err = method(operand, name.text, arg)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+		default:
+			return operand, nil
+		}
+	}
+}
+
+func (p *parser) parsePrimary() (evalFunc, error) {
+	t := p.next()
+
+	switch t.kind {
+	case tokenString, tokenNumber, tokenDuration:
+		return constant(t.value), nil
+
+	case tokenIdent:
+		switch t.text {
+		case "true":
+			return constant(true), nil
+		case "false":
+			return constant(false), nil
+		}
+		if !isVariable(t.text) {
+			return nil, microerror.Maskf(invalidExpressionError, "unknown variable %q", t.text)
+		}
+		name := t.text
+		f := func(vars map[string]interface{}) (interface{}, error) {
+			return vars[name], nil
+		}
+		return f, nil
+
+	case tokenOperator:
+		if t.text == "(" {
+			inner, err := p.parseOr()
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+			err = p.expect(")")
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+			return inner, nil
+		}
+	}
+
+	return nil, microerror.Maskf(invalidExpressionError, "unexpected %q", t.text)
+}
+
+func constant(v interface{}) evalFunc {
+	return func(map[string]interface{}) (interface{}, error) {
+		return v, nil
+	}
+}
+
+// logical combines two operands with `||` when or is set, with `&&`
+// otherwise. The right operand is only evaluated when needed.
+func logical(left, right evalFunc, or bool) evalFunc {
+	return func(vars map[string]interface{}) (interface{}, error) {
+		for _, operand := range []evalFunc{left, right} {
+			v, err := operand(vars)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+			b, ok := v.(bool)
+			if !ok {
+				return nil, microerror.Maskf(invalidExpressionError, "%v is not a boolean", v)
+			}
+			if b == or {
+				return or, nil
+			}
+		}
+
+		return !or, nil
+	}
+}
+
+// index looks up a key of a map, e.g. a tag. Missing keys are empty strings.
+func index(operand, key evalFunc) evalFunc {
+	return func(vars map[string]interface{}) (interface{}, error) {
+		v, err := operand(vars)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		m, ok := v.(map[string]string)
+		if !ok {
+			return nil, microerror.Maskf(invalidExpressionError, "cannot index %v", v)
+		}
+		k, err := key(vars)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		s, ok := k.(string)
+		if !ok {
+			return nil, microerror.Maskf(invalidExpressionError, "key %v is not a string", k)
+		}
+		return m[s], nil
+	}
+}
+
+// method implements the string methods.
+func method(operand evalFunc, name string, arg evalFunc) (evalFunc, error) {
+	var fn func(s, arg string) bool
+	switch name {
+	case "contains":
+		fn = strings.Contains
+	case "startsWith":
+		fn = strings.HasPrefix
+	case "endsWith":
+		fn = strings.HasSuffix
+	default:
+		return nil, microerror.Maskf(invalidExpressionError, "unknown method %q", name)
+	}
+
+	f := func(vars map[string]interface{}) (interface{}, error) {
+		v, err := operand(vars)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		a, err := arg(vars)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		s, ok := v.(string)
+		if !ok {
+			return nil, microerror.Maskf(invalidExpressionError, "%s called on %v, which is not a string", name, v)
+		}
+		t, ok := a.(string)
+		if !ok {
+			return nil, microerror.Maskf(invalidExpressionError, "%s called with %v, which is not a string", name, a)
+		}
+		return fn(s, t), nil
+	}
+
+	return f, nil
+}
+
+// compare compares two values of the same type.
+func compare(op string, l, r interface{}) (interface{}, error) {
+	var c int
+	switch l := l.(type) {
+	case string:
+		r, ok := r.(string)
+		if !ok {
+			break
+		}
+		c = strings.Compare(l, r)
+		return result(op, c), nil
+	case float64:
+		r, ok := r.(float64)
+		if !ok {
+			break
+		}
+		c = compareFloat(l, r)
+		return result(op, c), nil
+	case time.Duration:
+		r, ok := r.(time.Duration)
+		if !ok {
+			break
+		}
+		c = compareFloat(float64(l), float64(r))
+		return result(op, c), nil
+	case bool:
+		r, ok := r.(bool)
+		if !ok || (op != "==" && op != "!=") {
+			break
+		}
+		return (l == r) == (op == "=="), nil
+	}
+
+	return nil, microerror.Maskf(invalidExpressionError, "cannot compare %s with %s using %q", describe(l), describe(r), op)
+}
+
+func compareFloat(l, r float64) int {
+	switch {
+	case l < r:
+		return -1
+	case l > r:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func result(op string, c int) bool {
+	switch op {
+	case "==":
+		return c == 0
+	case "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	default:
+		return c >= 0
+	}
+}
+
+func describe(v interface{}) string {
+	switch v.(type) {
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case time.Duration:
+		return "duration"
+	case bool:
+		return "boolean"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
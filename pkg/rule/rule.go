@@ -0,0 +1,172 @@
+// Package rule implements operator defined conditions deciding whether the
+// resources found by a cleaner are deleted, so that exceptions like keeping
+// the resources of a team do not need code changes. Conditions are
+// expressions evaluated against the provider agnostic resource model, e.g.
+// `age > 4h && tags["team"] != "release"`.
+package rule
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/resource"
+)
+
+// AllCleaners is the cleaner name of rules which apply to all cleaners.
+const AllCleaners = "*"
+
+// Rules are the conditions of a single cleaner.
+type Rules struct {
+	// Skip are the conditions of resources which are kept. Any matching
+	// condition keeps a resource.
+	Skip []string
+	// Delete are the conditions of resources which are deleted. When set,
+	// resources matching none of them are kept.
+	Delete []string
+}
+
+type Config struct {
+	// Rules maps cleaner names to their rules. The rules of AllCleaners
+	// apply to every cleaner in addition to its own.
+	Rules map[string]Rules
+}
+
+// Engine decides about resources according to the configured rules.
+type Engine struct {
+	skip   map[string][]*Expression
+	delete map[string][]*Expression
+}
+
+func New(config Config) (*Engine, error) {
+	e := &Engine{
+		skip:   map[string][]*Expression{},
+		delete: map[string][]*Expression{},
+	}
+
+	for cleaner, rules := range config.Rules {
+		for _, s := range rules.Skip {
+			expr, err := Compile(s)
+			if err != nil {
+				return nil, microerror.Maskf(invalidConfigError, "skip rule %#q of cleaner %#q: %s", s, cleaner, err)
+			}
+			e.skip[cleaner] = append(e.skip[cleaner], expr)
+		}
+		for _, s := range rules.Delete {
+			expr, err := Compile(s)
+			if err != nil {
+				return nil, microerror.Maskf(invalidConfigError, "delete rule %#q of cleaner %#q: %s", s, cleaner, err)
+			}
+			e.delete[cleaner] = append(e.delete[cleaner], expr)
+		}
+	}
+
+	return e, nil
+}
+
+// Skip returns whether the given resource found by the given cleaner is kept
+// according to the rules, and why.
+func (e *Engine) Skip(cleaner string, r resource.Resource, now time.Time) (string, bool, error) {
+	env := Env{
+		Cleaner:  cleaner,
+		Resource: r,
+		Now:      now,
+	}
+
+	for _, c := range []string{AllCleaners, cleaner} {
+		for _, expr := range e.skip[c] {
+			ok, err := expr.Matches(env)
+			if err != nil {
+				return "", false, microerror.Mask(err)
+			}
+			if ok {
+				return fmt.Sprintf("skip rule %#q matched", expr), true, nil
+			}
+		}
+	}
+
+	var deleteRules bool
+	for _, c := range []string{AllCleaners, cleaner} {
+		for _, expr := range e.delete[c] {
+			deleteRules = true
+
+			ok, err := expr.Matches(env)
+			if err != nil {
+				return "", false, microerror.Mask(err)
+			}
+			if ok {
+				return "", false, nil
+			}
+		}
+	}
+
+	if deleteRules {
+		return "no delete rule matched", true, nil
+	}
+
+	return "", false, nil
+}
+
+// Env is what expressions are evaluated against. The variables `cleaner`,
+// `provider`, `type`, `id`, `name`, `account`, `region`, `installation` and
+// `cluster` are strings. `tags` is indexed by tag keys, e.g. `tags["team"]`.
+// `age` is a duration like `4h`, which is zero when the creation time of the
+// resource is unknown. `monthlyCost` is the estimated monthly cost in USD.
+type Env struct {
+	Cleaner  string
+	Resource resource.Resource
+	Now      time.Time
+}
+
+// variables are the names of the variables of Env.
+var variables = map[string]bool{
+	"account":      true,
+	"age":          true,
+	"cleaner":      true,
+	"cluster":      true,
+	"id":           true,
+	"installation": true,
+	"monthlyCost":  true,
+	"name":         true,
+	"provider":     true,
+	"region":       true,
+	"tags":         true,
+	"type":         true,
+}
+
+func isVariable(name string) bool {
+	return variables[name]
+}
+
+func (e Env) vars() map[string]interface{} {
+	r := e.Resource
+	o := r.Owner()
+
+	var age time.Duration
+	if !r.CreatedAt.IsZero() {
+		age = e.Now.Sub(r.CreatedAt)
+	}
+
+	tags := r.Tags
+	if tags == nil {
+		tags = map[string]string{}
+	}
+
+	vars := map[string]interface{}{
+		"account":      r.Account,
+		"age":          age,
+		"cleaner":      e.Cleaner,
+		"cluster":      o.Cluster,
+		"id":           r.ID,
+		"installation": o.Installation,
+		"monthlyCost":  r.MonthlyCost,
+		"name":         r.Name,
+		"provider":     r.Provider,
+		"region":       r.Region,
+		"tags":         tags,
+		"type":         r.Type,
+	}
+
+	return vars
+}
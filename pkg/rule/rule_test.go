@@ -0,0 +1,172 @@
+package rule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/giantswarm/ci-cleaner/pkg/resource"
+)
+
+func TestExpression(t *testing.T) {
+	now := time.Now()
+	env := Env{
+		Cleaner: "stacks",
+		Resource: resource.Resource{
+			Provider:    resource.ProviderAWS,
+			ID:          "arn:aws:cloudformation:eu-central-1:123456789012:stack/cluster-ci-a1b2c-guest-main/a1b2c3d4",
+			Name:        "cluster-ci-a1b2c-guest-main",
+			Region:      "eu-central-1",
+			CreatedAt:   now.Add(-5 * time.Hour),
+			MonthlyCost: 120,
+			Tags: map[string]string{
+				"giantswarm.io/cluster": "ci-a1b2c",
+				"team":                  "phoenix",
+			},
+		},
+		Now: now,
+	}
+
+	tcs := []struct {
+		expression  string
+		expected    bool
+		description string
+	}{
+		{
+			description: "age and tag",
+			expression:  `age > 4h && tags["team"] != "release"`,
+			expected:    true,
+		},
+		{
+			description: "age below threshold",
+			expression:  `age > 6h`,
+			expected:    false,
+		},
+		{
+			description: "missing tag is empty",
+			expression:  `tags["owner"] == ""`,
+			expected:    true,
+		},
+		{
+			description: "or with parentheses and negation",
+			expression:  `!(region == "eu-west-1") && (monthlyCost < 100 || tags["team"] == "phoenix")`,
+			expected:    true,
+		},
+		{
+			description: "string methods",
+			expression:  `name.startsWith("cluster-ci-") && id.contains(':stack/') && !name.endsWith("-main")`,
+			expected:    false,
+		},
+		{
+			description: "owner and cleaner",
+			expression:  `cluster == "ci-a1b2c" && cleaner == "stacks" && provider == "aws"`,
+			expected:    true,
+		},
+		{
+			description: "compound duration",
+			expression:  `age >= 4h30m || false`,
+			expected:    true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			expr, err := Compile(tc.expression)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actual, err := expr.Matches(env)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if actual != tc.expected {
+				t.Errorf("evaluating %#q, want %t, got %t", tc.expression, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestCompileInvalid(t *testing.T) {
+	for _, expression := range []string{
+		`age >`,
+		`owner == "phoenix"`,
+		`name.matches("ci-")`,
+		`tags["team" == "phoenix"`,
+		`name == 'ci-`,
+		`age > 4x`,
+	} {
+		_, err := Compile(expression)
+		if !IsInvalidExpression(err) {
+			t.Errorf("compiling %#q, expected invalid expression error, got %v", expression, err)
+		}
+	}
+}
+
+func TestSkip(t *testing.T) {
+	e, err := New(Config{
+		Rules: map[string]Rules{
+			AllCleaners: {
+				Skip: []string{`tags["team"] == "release"`},
+			},
+			"stacks": {
+				Delete: []string{`age > 4h`},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+
+	tcs := []struct {
+		cleaner     string
+		r           resource.Resource
+		expected    bool
+		description string
+	}{
+		{
+			description: "old stack should be deleted",
+			cleaner:     "stacks",
+			r:           resource.Resource{ID: "cluster-ci-a1b2c", CreatedAt: now.Add(-5 * time.Hour)},
+			expected:    false,
+		},
+		{
+			description: "recent stack matching no delete rule should be skipped",
+			cleaner:     "stacks",
+			r:           resource.Resource{ID: "cluster-ci-a1b2c", CreatedAt: now.Add(-2 * time.Hour)},
+			expected:    true,
+		},
+		{
+			description: "old stack of release team should be skipped",
+			cleaner:     "stacks",
+			r:           resource.Resource{ID: "cluster-ci-a1b2c", CreatedAt: now.Add(-5 * time.Hour), Tags: map[string]string{"team": "release"}},
+			expected:    true,
+		},
+		{
+			description: "recent bucket should be deleted",
+			cleaner:     "buckets",
+			r:           resource.Resource{ID: "ci-a1b2c-logs", CreatedAt: now.Add(-2 * time.Hour)},
+			expected:    false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			_, actual, err := e.Skip(tc.cleaner, tc.r, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be skipped, want %t, got %t", tc.r.ID, tc.expected, actual)
+			}
+		})
+	}
+
+	_, err = New(Config{Rules: map[string]Rules{"stacks": {Skip: []string{`age >`}}}})
+	if !IsInvalidConfig(err) {
+		t.Errorf("expected invalid config error, got %v", err)
+	}
+}
@@ -0,0 +1,32 @@
+package run
+
+import (
+	"github.com/giantswarm/microerror"
+)
+
+var invalidConfigError = &microerror.Error{
+	Kind: "invalidConfigError",
+}
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
+
+var budgetExceededError = &microerror.Error{
+	Kind: "budgetExceededError",
+}
+
+// IsBudgetExceeded asserts budgetExceededError.
+func IsBudgetExceeded(err error) bool {
+	return microerror.Cause(err) == budgetExceededError
+}
+
+var faultInjectedError = &microerror.Error{
+	Kind: "faultInjectedError",
+}
+
+// IsFaultInjected asserts faultInjectedError.
+func IsFaultInjected(err error) bool {
+	return microerror.Cause(err) == faultInjectedError
+}
@@ -0,0 +1,140 @@
+package run
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	escalationKeyPrefix = "escalation/"
+
+	defaultDiagnoseAfter = 3
+	defaultManualAfter   = 6
+	defaultMaxRetries    = 8
+	defaultRetryDelay    = 5 * time.Second
+)
+
+// Escalation configures how resources which survive several runs are
+// handled. The number of delete attempts doubles with every run a resource
+// survived, diagnostics are collected once it survived DiagnoseAfter runs and
+// it is marked for manual intervention once it survived ManualAfter runs.
+type Escalation struct {
+	DiagnoseAfter int
+	ManualAfter   int
+	MaxRetries    int
+	RetryDelay    time.Duration
+}
+
+// DiagnoseFunc collects diagnostics explaining why a resource cannot be
+// deleted, e.g. the resources depending on it.
+type DiagnoseFunc func(ctx context.Context, resource string) ([]string, error)
+
+// escalationState is the escalation state of a single resource.
+type escalationState struct {
+	// Survived is the number of previous runs which found the resource.
+	Survived int `json:"survived"`
+	// LastRun is the start of the last run which found the resource, so
+	// that a resource found twice in one run is only counted once.
+	LastRun time.Time `json:"lastRun"`
+}
+
+// RegisterDiagnoser registers fn to collect diagnostics for the resources of
+// the given cleaner which survived several runs.
+func (r *Run) RegisterDiagnoser(cleaner string, fn DiagnoseFunc) {
+	r.diagnosers[cleaner] = fn
+}
+
+// survived records that resource was found by the current run and returns
+// the number of previous runs which found it.
+func (r *Run) survived(cleaner string, resource string) (int, error) {
+	if r.state == nil {
+		return 0, nil
+	}
+
+	key := escalationKeyPrefix + cleaner + "/" + resource
+
+	var s escalationState
+	ok, err := r.state.Get(key, &s)
+	if err != nil {
+		return 0, microerror.Mask(err)
+	}
+
+	if ok && s.LastRun.Before(r.report.Started) {
+		s.Survived++
+	}
+	s.LastRun = r.report.Started
+
+	err = r.state.Put(key, s)
+	if err != nil {
+		return 0, microerror.Mask(err)
+	}
+
+	return s.Survived, nil
+}
+
+// forgetEscalation removes the escalation state of the given resource once
+// it is deleted, so that a resource reusing its ID, like a stack or bucket
+// name, starts over.
+func (r *Run) forgetEscalation(cleaner string, resource string) {
+	if r.state == nil {
+		return
+	}
+
+	r.state.Delete(escalationKeyPrefix + cleaner + "/" + resource)
+}
+
+// attempts returns the number of delete attempts for a resource which
+// survived the given number of runs.
+func (r *Run) attempts(survived int) int {
+	if survived == 0 {
+		return 1
+	}
+
+	retries := r.escalation.MaxRetries
+	if survived < 31 && 1<<uint(survived)-1 < retries {
+		retries = 1<<uint(survived) - 1
+	}
+
+	return retries + 1
+}
+
+// deleteWithRetries calls fn up to the given number of attempts.
+func (r *Run) deleteWithRetries(ctx context.Context, attempts int, fn func() error) error {
+	var err error
+	for i := 0; i < attempts; i++ {
+		if i != 0 {
+			select {
+			case <-ctx.Done():
+				return microerror.Mask(ctx.Err())
+			case <-time.After(r.escalation.RetryDelay):
+			}
+		}
+
+		err = fn()
+		if err == nil {
+			return nil
+		}
+	}
+
+	return microerror.Mask(err)
+}
+
+// diagnose collects diagnostics for a resource which survived several runs.
+// Failing to collect diagnostics is reported as diagnostic itself, as it must
+// not prevent the escalation.
+func (r *Run) diagnose(ctx context.Context, cleaner string, resource string) []string {
+	fn, ok := r.diagnosers[cleaner]
+	if !ok {
+		return nil
+	}
+
+	diagnostics, err := fn(ctx, resource)
+	if err != nil {
+		return []string{fmt.Sprintf("collecting diagnostics failed: %s", err.Error())}
+	}
+
+	return diagnostics
+}
@@ -0,0 +1,184 @@
+package run
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/logctx"
+	"github.com/giantswarm/ci-cleaner/pkg/report"
+)
+
+const (
+	pendingKeyPrefix = "pending/"
+)
+
+// StartFunc starts deleting a resource and returns a handle of the operation
+// tracking the deletion, e.g. its status URL. An empty handle means the
+// resource was deleted right away.
+type StartFunc func() (string, error)
+
+// PollFunc returns whether the operation with the given handle finished. An
+// error means the operation failed.
+type PollFunc func(ctx context.Context, operation string) (bool, error)
+
+// pendingDeletion is a deletion started by a previous run which did not
+// finish yet.
+type pendingDeletion struct {
+	Operation string    `json:"operation"`
+	Started   time.Time `json:"started"`
+	// Resource is the resource being deleted, so that PollPending can poll
+	// the deletion when the cleaner does not list the resource anymore.
+	Resource *Resource `json:"resource,omitempty"`
+}
+
+// DeleteResourceAsync is like DeleteResource for resources whose deletion
+// takes longer than a run should wait for. start is called to start the
+// deletion, which is then tracked in the state, so that later runs poll it
+// instead of starting it again. Deletions are tracked within a run only when
+// the run has no State. Cleaners usually do not list resources which are
+// being deleted anymore, which is why they have to call PollPending once
+// they listed all their resources.
+func (r *Run) DeleteResourceAsync(ctx context.Context, cleaner string, res Resource, start StartFunc, poll PollFunc) error {
+	if !r.scope.Includes(res) {
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("not deleting %#q as it does not belong to cluster %#q", res.ID, r.scope.ClusterID))
+		return nil
+	}
+
+	key := pendingKeyPrefix + cleaner + "/" + res.ID
+	r.polled[key] = true
+
+	if r.state != nil {
+		var p pendingDeletion
+		ok, err := r.state.Get(key, &p)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		if ok {
+			err := r.pollDeletion(ctx, cleaner, res, key, p, poll)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			return nil
+		}
+	}
+
+	return r.deleteResource(ctx, cleaner, res, func() (report.Action, error) {
+		operation, err := start()
+		if err != nil {
+			return "", microerror.Mask(err)
+		}
+
+		if operation == "" {
+			return report.ActionDeleted, nil
+		}
+
+		if r.state != nil {
+			p := pendingDeletion{
+				Operation: operation,
+				Started:   r.report.Started,
+				Resource:  &res,
+			}
+
+			err = r.state.Put(key, p)
+			if err != nil {
+				return "", microerror.Mask(err)
+			}
+		}
+
+		return report.ActionDeleting, nil
+	})
+}
+
+// PollPending polls the pending deletions of resources of the given type the
+// given cleaner started, which DeleteResourceAsync did not poll during the
+// run, as the cleaner did not list the resource anymore. An empty
+// resourceType polls the deletions of all types. Deletions tracked by
+// versions which did not record the resource are left to DeleteResourceAsync.
+func (r *Run) PollPending(ctx context.Context, cleaner string, resourceType string, poll PollFunc) error {
+	if r.state == nil {
+		return nil
+	}
+
+	var lastError error
+	for _, key := range r.state.Keys(pendingKeyPrefix + cleaner + "/") {
+		if r.polled[key] {
+			continue
+		}
+
+		var p pendingDeletion
+		ok, err := r.state.Get(key, &p)
+		if err != nil {
+			lastError = err
+			continue
+		}
+		if !ok || p.Resource == nil || (resourceType != "" && p.Resource.Type != resourceType) {
+			continue
+		}
+		if !r.scope.Includes(*p.Resource) {
+			continue
+		}
+
+		r.polled[key] = true
+
+		err = r.pollDeletion(logctx.WithResource(ctx, p.Resource.ID), cleaner, *p.Resource, key, p, poll)
+		if err != nil {
+			r.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("failed deleting %#q", p.Resource.ID), "stack", fmt.Sprintf("%#v", err))
+			lastError = err
+			continue
+		}
+	}
+
+	if lastError != nil {
+		return microerror.Mask(lastError)
+	}
+
+	return nil
+}
+
+// pollDeletion checks the pending deletion of the given resource. Failed
+// deletions are forgotten, so that the next run starts them again.
+func (r *Run) pollDeletion(ctx context.Context, cleaner string, res Resource, key string, p pendingDeletion, poll PollFunc) error {
+	item := newItem(cleaner, res)
+
+	done, err := poll(ctx, p.Operation)
+	if err != nil {
+		r.state.Delete(key)
+
+		item.Action = report.ActionFailed
+		item.Error = err.Error()
+		r.add(item)
+
+		return microerror.Mask(err)
+	}
+
+	if done {
+		r.state.Delete(key)
+		r.forgetEscalation(cleaner, res.ID)
+
+		r.logger.LogCtx(ctx, "level", "info", "message", fmt.Sprintf("cleaner %#q deleted %#q", cleaner, res.ID))
+
+		item.Action = report.ActionDeleted
+		r.add(item)
+
+		return nil
+	}
+
+	// The entry is written again to keep it from expiring while the
+	// deletion is still running.
+	err = r.state.Put(key, p)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "info", "message", fmt.Sprintf("cleaner %#q is still deleting %#q since %s", cleaner, res.ID, p.Started.Format(time.RFC3339)))
+
+	item.Action = report.ActionDeleting
+	r.add(item)
+
+	return nil
+}
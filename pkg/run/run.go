@@ -0,0 +1,516 @@
+// Package run implements the decisions shared by all cleaners of a single
+// ci-cleaner run. Every deletion goes through Run.Delete, so that cross-cutting
+// behaviour like report-only mode applies to all cleaners alike.
+package run
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+
+	"github.com/giantswarm/ci-cleaner/pkg/graph"
+	"github.com/giantswarm/ci-cleaner/pkg/logctx"
+	"github.com/giantswarm/ci-cleaner/pkg/owner"
+	"github.com/giantswarm/ci-cleaner/pkg/protection"
+	"github.com/giantswarm/ci-cleaner/pkg/report"
+	"github.com/giantswarm/ci-cleaner/pkg/resource"
+	"github.com/giantswarm/ci-cleaner/pkg/rule"
+	"github.com/giantswarm/ci-cleaner/pkg/state"
+)
+
+type Config struct {
+	Logger micrologger.Logger
+	// Protection keeps resources from being deleted temporarily. No
+	// resources are protected when Protection is nil.
+	Protection *protection.Protection
+	Report     *report.Report
+	// Rules keep resources from being deleted according to conditions
+	// configured by operators. No rules apply when Rules is nil.
+	Rules *rule.Engine
+	// State persists how many runs resources survived. Resources are not
+	// escalated when State is nil.
+	State *state.Store
+
+	Escalation Escalation
+
+	// FaultRate is the share of delete calls, between 0 and 1, which fail
+	// with an injected error instead of deleting anything. It is meant for
+	// testing how retries, escalation, pending deletions and reports behave
+	// under partial failure and must not be set in production.
+	FaultRate float64
+	// MaxMonthlyCost is the maximum estimated monthly cost in USD of the
+	// resources deleted per run, see Resource.MonthlyCost. Once deleting a
+	// resource would exceed it, the run stops deleting and holds back the
+	// remaining candidates for human review. Not limited when zero.
+	MaxMonthlyCost float64
+
+	// OnResult is called with every resource added to the report as soon as
+	// it is known what happened to it, e.g. for tools embedding the cleaners
+	// to stream the progress of a cleanup into their own logs. It is called
+	// from the goroutine of the cleaner and must not block.
+	OnResult func(item report.Item)
+
+	// ReportOnly are the names of the cleaners which must not delete
+	// anything but only report their candidates.
+	ReportOnly []string
+	// DryRun makes all cleaners report the resources they found instead of
+	// deleting them, e.g. when replaying a recorded run.
+	DryRun bool
+	// Skip are the names of the cleaners which do not run at all, even
+	// when in scope.
+	Skip []string
+	// Scope restricts the run to a subset of cleaners and resources. The
+	// zero value does not restrict anything.
+	Scope Scope
+}
+
+// Scope restricts a targeted run, e.g. one requested by a CI pipeline to
+// clean up after itself.
+type Scope struct {
+	// Cleaners are the names of the cleaners to run. All cleaners run when
+	// empty.
+	Cleaners []string
+	// ClusterID restricts the run to resources whose ID contains the given
+	// cluster ID as a whole segment, or which are tagged with it.
+	ClusterID string
+}
+
+// Reason codes tell why a cleaner found a resource to be deleted. They label
+// the metrics of the run.
+const (
+	// ReasonAgeExpired is the reason of resources which are older than the
+	// grace period. It is the default.
+	ReasonAgeExpired = "age-expired"
+	// ReasonDangling is the reason of resources pointing to resources which
+	// do not exist anymore, like subscription filters of deleted streams.
+	ReasonDangling = "dangling"
+	// ReasonDNSStale is the reason of DNS records pointing to clusters which
+	// do not resolve anymore.
+	ReasonDNSStale = "dns-stale"
+	// ReasonUnused is the reason of resources nothing uses anymore, like
+	// unassociated addresses or keys without alias.
+	ReasonUnused = "unused"
+)
+
+// Resource describes a resource found by a cleaner. Only the ID is required,
+// the other fields are added to reports and inventories when known.
+type Resource struct {
+	ID        string
+	Type      string
+	Region    string
+	Tags      map[string]string
+	CreatedAt time.Time
+	// Cost calls out what the resource is billed for, for resources which
+	// are expensive to leave behind.
+	Cost string
+	// MonthlyCost is the estimated monthly cost of the resource in USD, as
+	// far as the cleaner can tell. It counts against the cost budget of the
+	// run.
+	MonthlyCost float64
+	// Note tells humans what is deleted along with the resource, e.g. the
+	// number of images of a repository.
+	Note string
+	// Reason is the code of the reason the resource is to be deleted.
+	// Defaults to ReasonAgeExpired.
+	Reason string
+}
+
+// Model returns the provider agnostic model of the resource, with the fields
+// the cleaner did not set derived from its ID.
+func (res Resource) Model() resource.Resource {
+	m := resource.Resource{
+		Type:        res.Type,
+		ID:          res.ID,
+		Region:      res.Region,
+		Tags:        res.Tags,
+		CreatedAt:   res.CreatedAt,
+		Cost:        res.Cost,
+		MonthlyCost: res.MonthlyCost,
+	}
+
+	return m.Normalize()
+}
+
+// IsZero returns whether the scope does not restrict anything.
+func (s Scope) IsZero() bool {
+	return len(s.Cleaners) == 0 && s.ClusterID == ""
+}
+
+// Includes returns whether the given resource is in the scope. Resources
+// belong to the cluster of the scope when their ID contains the cluster ID as
+// a whole segment, like `abc12` in `cluster-ci-abc12-guest-main` but not in
+// `cluster-ci-abc123-guest-main`, or when they are tagged with it.
+func (s Scope) Includes(res Resource) bool {
+	if s.ClusterID == "" {
+		return true
+	}
+
+	if containsSegment(res.ID, s.ClusterID) {
+		return true
+	}
+
+	return res.Model().Owner().Cluster == s.ClusterID
+}
+
+// containsSegment returns whether s contains segment delimited by anything
+// but letters and digits, or the start or end of s.
+func containsSegment(s string, segment string) bool {
+	for i := 0; ; {
+		j := strings.Index(s[i:], segment)
+		if j < 0 {
+			return false
+		}
+		start := i + j
+		end := start + len(segment)
+
+		if (start == 0 || !isAlphanumeric(s[start-1])) && (end == len(s) || !isAlphanumeric(s[end])) {
+			return true
+		}
+
+		i = start + 1
+	}
+}
+
+func isAlphanumeric(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+type Run struct {
+	logger     micrologger.Logger
+	protection *protection.Protection
+	report     *report.Report
+	rules      *rule.Engine
+	state      *state.Store
+
+	diagnosers map[string]DiagnoseFunc
+	escalation Escalation
+	onResult   func(item report.Item)
+	dryRun     bool
+	reportOnly map[string]bool
+	scope      Scope
+	skip       map[string]bool
+
+	// budgetExceeded is set once deleting a resource would exceed the cost
+	// budget of the run. deletedCost is the estimated monthly cost of the
+	// resources deleted so far.
+	budgetExceeded bool
+	deletedCost    float64
+	maxMonthlyCost float64
+
+	faultRate float64
+
+	// polled holds the keys of the pending deletions polled or started
+	// during the run, so that PollPending does not poll them again.
+	polled map[string]bool
+}
+
+func New(config Config) (*Run, error) {
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.Report == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Report must not be empty", config)
+	}
+
+	if config.FaultRate < 0 || config.FaultRate > 1 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.FaultRate must be between 0 and 1", config)
+	}
+
+	if config.Escalation.DiagnoseAfter == 0 {
+		config.Escalation.DiagnoseAfter = defaultDiagnoseAfter
+	}
+	if config.Escalation.ManualAfter == 0 {
+		config.Escalation.ManualAfter = defaultManualAfter
+	}
+	if config.Escalation.MaxRetries == 0 {
+		config.Escalation.MaxRetries = defaultMaxRetries
+	}
+	if config.Escalation.RetryDelay == 0 {
+		config.Escalation.RetryDelay = defaultRetryDelay
+	}
+
+	r := &Run{
+		logger:     config.Logger,
+		protection: config.Protection,
+		report:     config.Report,
+		rules:      config.Rules,
+		state:      config.State,
+
+		diagnosers: map[string]DiagnoseFunc{},
+		escalation: config.Escalation,
+		onResult:   config.OnResult,
+		dryRun:     config.DryRun,
+		reportOnly: map[string]bool{},
+		scope:      config.Scope,
+		skip:       map[string]bool{},
+
+		maxMonthlyCost: config.MaxMonthlyCost,
+
+		faultRate: config.FaultRate,
+
+		polled: map[string]bool{},
+	}
+
+	for _, c := range config.ReportOnly {
+		r.reportOnly[c] = true
+	}
+	for _, c := range config.Skip {
+		r.skip[c] = true
+	}
+
+	return r, nil
+}
+
+// Enabled returns whether the given cleaner is in the scope of the run and
+// not skipped. No cleaner is enabled anymore once the cost budget of the run
+// is exceeded.
+func (r *Run) Enabled(cleaner string) bool {
+	if r.skip[cleaner] || r.budgetExceeded {
+		return false
+	}
+
+	if len(r.scope.Cleaners) == 0 {
+		return true
+	}
+
+	for _, c := range r.scope.Cleaners {
+		if c == cleaner {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Complete records that the given cleaner ran to completion without errors.
+// Cleaners cut short by the cost budget of the run are not recorded, as they
+// did not find all their candidates.
+func (r *Run) Complete(cleaner string) {
+	if r.budgetExceeded {
+		return
+	}
+
+	r.report.AddCompleted(cleaner)
+}
+
+// Delete records resource as candidate of the given cleaner and calls fn to
+// delete it, unless the cleaner runs in report-only mode. Resources out of
+// the scope of the run are ignored.
+func (r *Run) Delete(ctx context.Context, cleaner string, resource string, fn func() error) error {
+	return r.DeleteResource(ctx, cleaner, Resource{ID: resource}, fn)
+}
+
+// DeleteResource is like Delete for cleaners which know more about the
+// resource than its ID.
+func (r *Run) DeleteResource(ctx context.Context, cleaner string, res Resource, fn func() error) error {
+	return r.deleteResource(ctx, cleaner, res, func() (report.Action, error) {
+		return report.ActionDeleted, fn()
+	})
+}
+
+// deleteResource implements DeleteResource for deletions which either finish
+// right away or are tracked by later runs, see DeleteResourceAsync. fn
+// returns the action to report for the resource.
+func (r *Run) deleteResource(ctx context.Context, cleaner string, res Resource, fn func() (report.Action, error)) error {
+	ctx = logctx.WithResource(ctx, res.ID)
+	resource := res.ID
+
+	if !r.scope.Includes(res) {
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("not deleting %#q as it does not belong to cluster %#q", resource, r.scope.ClusterID))
+		return nil
+	}
+
+	item := newItem(cleaner, res)
+
+	if r.protection != nil {
+		e, ok, err := r.protection.Protected(res.Model())
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		if ok {
+			r.logger.LogCtx(ctx, "level", "info", "message", fmt.Sprintf("not deleting %#q as %s protected it until %s", resource, e.By, e.Until.Format(time.RFC3339)))
+
+			item.Action = report.ActionProtected
+			r.add(item)
+
+			return nil
+		}
+	}
+
+	if r.rules != nil {
+		why, ok, err := r.rules.Skip(cleaner, res.Model(), time.Now())
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		if ok {
+			r.logger.LogCtx(ctx, "level", "info", "message", fmt.Sprintf("not deleting %#q as %s", resource, why))
+
+			item.Action = report.ActionSkipped
+			item.Rule = why
+			r.add(item)
+
+			return nil
+		}
+	}
+
+	if r.dryRun || r.reportOnly[cleaner] {
+		r.logger.LogCtx(ctx, "level", "info", "message", fmt.Sprintf("not deleting %#q as cleaner %#q runs in report-only mode", resource, cleaner))
+
+		item.Action = report.ActionReported
+		r.add(item)
+
+		return nil
+	}
+
+	if r.exceedsBudget(res) {
+		r.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("not deleting %#q as the cost budget of the run is exceeded", resource))
+
+		item.Action = report.ActionBudgetExceeded
+		r.add(item)
+
+		return microerror.Maskf(budgetExceededError, "deleting %#q exceeds %.2f USD per month", resource, r.maxMonthlyCost)
+	}
+
+	survived, err := r.survived(cleaner, resource)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	item.Survived = survived
+
+	if survived >= r.escalation.DiagnoseAfter {
+		item.Diagnostics = r.diagnose(ctx, cleaner, resource)
+	}
+	if survived >= r.escalation.ManualAfter {
+		r.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("resource %#q survived %d runs of cleaner %#q and requires manual intervention", resource, survived, cleaner))
+		item.ManualIntervention = true
+	}
+
+	err = r.deleteWithRetries(ctx, r.attempts(survived), func() error {
+		if r.faultRate != 0 && rand.Float64() < r.faultRate {
+			r.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("injecting fault deleting %#q", resource))
+			return microerror.Maskf(faultInjectedError, "deleting %#q", resource)
+		}
+
+		action, err := fn()
+		item.Action = action
+		return err
+	})
+	if err != nil {
+		item.Action = report.ActionFailed
+		item.Error = err.Error()
+		r.add(item)
+
+		return microerror.Mask(err)
+	}
+
+	r.deletedCost += res.MonthlyCost
+
+	if item.Action == report.ActionDeleting {
+		r.logger.LogCtx(ctx, "level", "info", "message", fmt.Sprintf("cleaner %#q started deleting %#q", cleaner, resource))
+	} else {
+		r.forgetEscalation(cleaner, resource)
+		r.logger.LogCtx(ctx, "level", "info", "message", fmt.Sprintf("cleaner %#q deleted %#q", cleaner, resource))
+	}
+
+	r.add(item)
+
+	return nil
+}
+
+// Report records resource as candidate of the given cleaner without deleting
+// it. It is meant for resources which are only deleted when configured
+// explicitly, but are too expensive to go unnoticed. Resources out of the
+// scope of the run are ignored.
+func (r *Run) Report(ctx context.Context, cleaner string, res Resource) {
+	if !r.scope.Includes(res) {
+		return
+	}
+
+	ctx = logctx.WithResource(ctx, res.ID)
+	r.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("cleaner %#q found %#q, which has to be deleted manually", cleaner, res.ID))
+
+	item := newItem(cleaner, res)
+	item.Action = report.ActionReported
+	r.add(item)
+}
+
+// SetQuotas adds the utilization of the service quotas after the run to the
+// report.
+func (r *Run) SetQuotas(q []report.Quota) {
+	r.report.SetQuotas(q)
+}
+
+// SetRoleAssignmentDrift adds the difference of the role assignments before
+// and after the run to the report.
+func (r *Run) SetRoleAssignmentDrift(d report.RoleAssignmentDrift) {
+	r.report.SetRoleAssignmentDrift(d)
+}
+
+// AddGraph adds the dependency graph of resources a cleaner tears down
+// together to the report of the run.
+func (r *Run) AddGraph(g *graph.Graph) {
+	r.report.AddGraph(g)
+}
+
+// exceedsBudget returns whether deleting the given resource exceeds the cost
+// budget of the run. Once it is exceeded, no resources are deleted anymore.
+func (r *Run) exceedsBudget(res Resource) bool {
+	if r.maxMonthlyCost == 0 {
+		return false
+	}
+
+	if r.deletedCost+res.MonthlyCost > r.maxMonthlyCost {
+		r.budgetExceeded = true
+	}
+
+	return r.budgetExceeded
+}
+
+// add adds the given item to the report and passes it to the result callback.
+func (r *Run) add(item report.Item) {
+	r.report.Add(item)
+
+	if r.onResult != nil {
+		r.onResult(item)
+	}
+}
+
+func newItem(cleaner string, res Resource) report.Item {
+	m := res.Model()
+	o := m.Owner()
+
+	item := report.Item{
+		Cleaner:  cleaner,
+		Resource: m.ID,
+
+		Type:    m.Type,
+		Name:    m.Name,
+		Account: m.Account,
+		Region:  m.Region,
+		Tags:    m.Tags,
+		Cost:    m.Cost,
+		Reason:  res.Reason,
+
+		MonthlyCost: m.MonthlyCost,
+		Note:        res.Note,
+
+		Installation: o.Installation,
+		Cluster:      o.Cluster,
+		OriginRepo:   owner.OriginRepo(m.Tags),
+	}
+	if item.Reason == "" {
+		item.Reason = ReasonAgeExpired
+	}
+	if !m.CreatedAt.IsZero() {
+		item.CreatedAt = &m.CreatedAt
+	}
+
+	return item
+}
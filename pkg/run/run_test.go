@@ -0,0 +1,572 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+
+	"github.com/giantswarm/ci-cleaner/pkg/report"
+	"github.com/giantswarm/ci-cleaner/pkg/rule"
+	"github.com/giantswarm/ci-cleaner/pkg/state"
+)
+
+func TestDelete(t *testing.T) {
+	rep := report.New("aws")
+
+	r, err := New(Config{
+		Logger:     microloggertest.New(),
+		Report:     rep,
+		ReportOnly: []string{"buckets"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var deleted []string
+	deleteFn := func(resource string) func() error {
+		return func() error {
+			deleted = append(deleted, resource)
+			return nil
+		}
+	}
+
+	err = r.Delete(context.Background(), "stacks", "cluster-ci-a", deleteFn("cluster-ci-a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.Delete(context.Background(), "buckets", "ci-wip-a", deleteFn("ci-wip-a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.Delete(context.Background(), "stacks", "cluster-ci-b", func() error { return errors.New("in use") })
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	if len(deleted) != 1 || deleted[0] != "cluster-ci-a" {
+		t.Errorf("expected only %q to be deleted, got %v", "cluster-ci-a", deleted)
+	}
+
+	expected := []report.Action{report.ActionDeleted, report.ActionReported, report.ActionFailed}
+	if len(rep.Items) != len(expected) {
+		t.Fatalf("expected %d report items, got %d", len(expected), len(rep.Items))
+	}
+	for i, a := range expected {
+		if rep.Items[i].Action != a {
+			t.Errorf("expected item %d to have action %q, got %q", i, a, rep.Items[i].Action)
+		}
+	}
+
+	candidates := rep.Candidates()
+	if len(candidates["stacks"]) != 2 || len(candidates["buckets"]) != 1 {
+		t.Errorf("unexpected candidates %v", candidates)
+	}
+}
+
+func TestScope(t *testing.T) {
+	rep := report.New("aws")
+
+	r, err := New(Config{
+		Logger: microloggertest.New(),
+		Report: rep,
+		Scope: Scope{
+			Cleaners:  []string{"stacks"},
+			ClusterID: "abc12",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !r.Enabled("stacks") || r.Enabled("buckets") {
+		t.Errorf("expected only cleaner %q to be enabled", "stacks")
+	}
+
+	var deleted []string
+	for _, resource := range []string{"cluster-ci-abc12-guest-main", "cluster-ci-xyz34-guest-main", "cluster-ci-abc123-guest-main", "xabc12"} {
+		resource := resource
+		err = r.Delete(context.Background(), "stacks", resource, func() error {
+			deleted = append(deleted, resource)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tagged := Resource{
+		ID:   "vol-a1b2c3d4",
+		Tags: map[string]string{"kubernetes.io/cluster/abc12": "owned"},
+	}
+	err = r.DeleteResource(context.Background(), "stacks", tagged, func() error {
+		deleted = append(deleted, tagged.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.Report(context.Background(), "stacks", Resource{ID: "cluster-ci-abc12-hsm"})
+	r.Report(context.Background(), "stacks", Resource{ID: "cluster-ci-xyz34-hsm"})
+
+	if len(deleted) != 2 || deleted[0] != "cluster-ci-abc12-guest-main" || deleted[1] != "vol-a1b2c3d4" {
+		t.Errorf("expected only resources of the cluster to be deleted, got %v", deleted)
+	}
+	if len(rep.Items) != 3 {
+		t.Errorf("expected resources out of scope not to be reported, got %v", rep.Items)
+	}
+}
+
+func TestSkip(t *testing.T) {
+	r, err := New(Config{
+		Logger: microloggertest.New(),
+		Report: report.New("aws"),
+		Scope: Scope{
+			Cleaners: []string{"stacks", "emr"},
+		},
+		Skip: []string{"emr", "sagemaker"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !r.Enabled("stacks") {
+		t.Errorf("expected cleaner %q to be enabled", "stacks")
+	}
+	if r.Enabled("emr") || r.Enabled("sagemaker") {
+		t.Errorf("expected skipped cleaners to be disabled")
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	rep := report.New("aws")
+
+	r, err := New(Config{
+		DryRun: true,
+		Logger: microloggertest.New(),
+		Report: rep,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = r.Delete(context.Background(), "stacks", "cluster-ci-a", func() error {
+		t.Errorf("expected nothing to be deleted")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rep.Items) != 1 || rep.Items[0].Action != report.ActionReported {
+		t.Errorf("expected resource to be reported, got %v", rep.Items)
+	}
+}
+
+func TestRules(t *testing.T) {
+	rep := report.New("aws")
+
+	rules, err := rule.New(rule.Config{
+		Rules: map[string]rule.Rules{
+			"stacks": {Skip: []string{`tags["team"] == "release"`}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := New(Config{
+		Logger: microloggertest.New(),
+		Report: rep,
+		Rules:  rules,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var deleted []string
+	for _, team := range []string{"release", "phoenix"} {
+		res := Resource{
+			ID:   "cluster-ci-" + team,
+			Tags: map[string]string{"team": team},
+		}
+		err = r.DeleteResource(context.Background(), "stacks", res, func() error {
+			deleted = append(deleted, res.ID)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(deleted) != 1 || deleted[0] != "cluster-ci-phoenix" {
+		t.Errorf("expected only resources matching no skip rule to be deleted, got %v", deleted)
+	}
+	if len(rep.Items) != 2 || rep.Items[0].Action != report.ActionSkipped || rep.Items[0].Rule == "" {
+		t.Errorf("expected skipped resource to be reported with its rule, got %v", rep.Items)
+	}
+}
+
+func TestOnResult(t *testing.T) {
+	var results []report.Item
+	r, err := New(Config{
+		Logger:     microloggertest.New(),
+		Report:     report.New("aws"),
+		ReportOnly: []string{"buckets"},
+		OnResult: func(item report.Item) {
+			results = append(results, item)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = r.Delete(context.Background(), "stacks", "cluster-ci-a", func() error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.Delete(context.Background(), "buckets", "ci-b", func() error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.Delete(context.Background(), "stacks", "cluster-ci-c", func() error { return errors.New("stack in use") })
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	expected := []report.Action{report.ActionDeleted, report.ActionReported, report.ActionFailed}
+	if len(results) != len(expected) {
+		t.Fatalf("expected %d results, got %v", len(expected), results)
+	}
+	for i, a := range expected {
+		if results[i].Action != a {
+			t.Errorf("result %d: expected action %q, got %q", i, a, results[i].Action)
+		}
+	}
+	if results[0].Reason != ReasonAgeExpired {
+		t.Errorf("expected reason %q, got %q", ReasonAgeExpired, results[0].Reason)
+	}
+}
+
+func TestCostBudget(t *testing.T) {
+	rep := report.New("aws")
+
+	r, err := New(Config{
+		Logger:         microloggertest.New(),
+		MaxMonthlyCost: 100,
+		Report:         rep,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var deleted []string
+	deleteFn := func(resource string) func() error {
+		return func() error {
+			deleted = append(deleted, resource)
+			return nil
+		}
+	}
+
+	resources := []Resource{
+		{ID: "ci-a", MonthlyCost: 60},
+		{ID: "ci-b", MonthlyCost: 60},
+		{ID: "ci-c"},
+	}
+	for _, res := range resources {
+		err = r.DeleteResource(context.Background(), "nat-gateways", res, deleteFn(res.ID))
+		if res.ID == "ci-a" && err != nil {
+			t.Fatal(err)
+		}
+		if res.ID != "ci-a" && !IsBudgetExceeded(err) {
+			t.Errorf("expected budget exceeded error deleting %q, got %v", res.ID, err)
+		}
+	}
+
+	if len(deleted) != 1 || deleted[0] != "ci-a" {
+		t.Errorf("expected only %q to be deleted, got %v", "ci-a", deleted)
+	}
+
+	expected := []report.Action{report.ActionDeleted, report.ActionBudgetExceeded, report.ActionBudgetExceeded}
+	if len(rep.Items) != len(expected) {
+		t.Fatalf("expected %d report items, got %d", len(expected), len(rep.Items))
+	}
+	for i, a := range expected {
+		if rep.Items[i].Action != a {
+			t.Errorf("expected item %d to have action %q, got %q", i, a, rep.Items[i].Action)
+		}
+	}
+
+	if r.Enabled("stacks") {
+		t.Errorf("expected cleaners to be disabled once the budget is exceeded")
+	}
+}
+
+func TestEscalation(t *testing.T) {
+	stateStore, err := state.New(state.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var attempts int
+	deleteFn := func() error {
+		attempts++
+		return errors.New("in use")
+	}
+
+	expected := []struct {
+		attempts           int
+		diagnostics        bool
+		manualIntervention bool
+	}{
+		{attempts: 1},
+		{attempts: 2},
+		{attempts: 4},
+		{attempts: 5, diagnostics: true},
+		{attempts: 5, diagnostics: true, manualIntervention: true},
+	}
+
+	for i, e := range expected {
+		rep := &report.Report{Started: time.Unix(int64(i), 0)}
+
+		r, err := New(Config{
+			Logger: microloggertest.New(),
+			Report: rep,
+			State:  stateStore,
+
+			Escalation: Escalation{
+				DiagnoseAfter: 3,
+				ManualAfter:   4,
+				MaxRetries:    4,
+				RetryDelay:    time.Nanosecond,
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.RegisterDiagnoser("stacks", func(ctx context.Context, resource string) ([]string, error) {
+			return []string{"VPC: has dependencies"}, nil
+		})
+
+		attempts = 0
+		err = r.Delete(context.Background(), "stacks", "cluster-ci-a", deleteFn)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+
+		item := rep.Items[0]
+		if attempts != e.attempts {
+			t.Errorf("run %d: expected %d attempts, got %d", i, e.attempts, attempts)
+		}
+		if item.Survived != i {
+			t.Errorf("run %d: expected resource to have survived %d runs, got %d", i, i, item.Survived)
+		}
+		if (len(item.Diagnostics) != 0) != e.diagnostics {
+			t.Errorf("run %d: expected diagnostics %t, got %v", i, e.diagnostics, item.Diagnostics)
+		}
+		if item.ManualIntervention != e.manualIntervention {
+			t.Errorf("run %d: expected manual intervention %t, got %t", i, e.manualIntervention, item.ManualIntervention)
+		}
+	}
+
+	// Once the resource is deleted, a resource reusing its ID starts over.
+	for i, fn := range []func() error{func() error { return nil }, deleteFn} {
+		rep := &report.Report{Started: time.Unix(int64(len(expected)+i), 0)}
+
+		r, err := New(Config{
+			Logger: microloggertest.New(),
+			Report: rep,
+			State:  stateStore,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = r.Delete(context.Background(), "stacks", "cluster-ci-a", fn)
+		if i == 0 && err != nil {
+			t.Fatal(err)
+		}
+
+		if i == 1 && rep.Items[0].Survived != 0 {
+			t.Errorf("expected reused resource to start over, got %d survived runs", rep.Items[0].Survived)
+		}
+	}
+}
+
+func TestDeleteAsync(t *testing.T) {
+	stateStore, err := state.New(state.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var started int
+	start := func() (string, error) {
+		started++
+		return "https://management.azure.com/operations/1", nil
+	}
+
+	expected := []struct {
+		done   bool
+		action report.Action
+	}{
+		{action: report.ActionDeleting},
+		{action: report.ActionDeleting},
+		{done: true, action: report.ActionDeleted},
+	}
+
+	for i, e := range expected {
+		rep := &report.Report{Started: time.Unix(int64(i), 0)}
+
+		r, err := New(Config{
+			Logger: microloggertest.New(),
+			Report: rep,
+			State:  stateStore,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		poll := func(ctx context.Context, operation string) (bool, error) {
+			return e.done, nil
+		}
+
+		err = r.DeleteResourceAsync(context.Background(), "cosmos-db-accounts", Resource{ID: "ci-wip-a"}, start, poll)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if rep.Items[0].Action != e.action {
+			t.Errorf("run %d: expected action %q, got %q", i, e.action, rep.Items[0].Action)
+		}
+	}
+
+	if started != 1 {
+		t.Errorf("expected deletion to be started once, got %d", started)
+	}
+	if keys := stateStore.Keys(pendingKeyPrefix); len(keys) != 0 {
+		t.Errorf("expected finished deletion to be forgotten, got %v", keys)
+	}
+}
+
+func TestPollPending(t *testing.T) {
+	stateStore, err := state.New(state.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := func() (string, error) {
+		return "nat-0123456789abcdef0", nil
+	}
+
+	var polled []string
+	poll := func(ctx context.Context, operation string) (bool, error) {
+		polled = append(polled, operation)
+		return true, nil
+	}
+
+	res := Resource{ID: "nat-0123456789abcdef0", Type: "AWS::EC2::NatGateway"}
+
+	// The first run starts the deletion and polls nothing, as it just
+	// started it.
+	{
+		r, err := New(Config{
+			Logger: microloggertest.New(),
+			Report: &report.Report{Started: time.Unix(0, 0)},
+			State:  stateStore,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = r.DeleteResourceAsync(context.Background(), "nat-gateways", res, start, poll)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = r.PollPending(context.Background(), "nat-gateways", res.Type, poll)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(polled) != 0 {
+			t.Fatalf("expected deletion not to be polled by the run starting it, got %v", polled)
+		}
+	}
+
+	// The second run does not list the resource anymore, as it is being
+	// deleted, so only PollPending polls it.
+	{
+		rep := &report.Report{Started: time.Unix(1, 0)}
+		r, err := New(Config{
+			Logger: microloggertest.New(),
+			Report: rep,
+			State:  stateStore,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = r.PollPending(context.Background(), "nat-gateways", "AWS::EC2::EIP", poll)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(polled) != 0 {
+			t.Fatalf("expected deletions of other types not to be polled, got %v", polled)
+		}
+
+		err = r.PollPending(context.Background(), "nat-gateways", res.Type, poll)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(polled) != 1 || polled[0] != "nat-0123456789abcdef0" {
+			t.Errorf("expected pending deletion to be polled once, got %v", polled)
+		}
+		if len(rep.Items) != 1 || rep.Items[0].Action != report.ActionDeleted || rep.Items[0].Resource != res.ID {
+			t.Errorf("expected resource to be reported as deleted, got %v", rep.Items)
+		}
+	}
+
+	if keys := stateStore.Keys(pendingKeyPrefix); len(keys) != 0 {
+		t.Errorf("expected finished deletion to be forgotten, got %v", keys)
+	}
+}
+
+func TestFaultInjection(t *testing.T) {
+	_, err := New(Config{
+		Logger:    microloggertest.New(),
+		Report:    report.New("aws"),
+		FaultRate: 1.5,
+	})
+	if !IsInvalidConfig(err) {
+		t.Fatalf("expected invalid config error, got %#v", err)
+	}
+
+	rep := report.New("aws")
+	r, err := New(Config{
+		Logger:    microloggertest.New(),
+		Report:    rep,
+		FaultRate: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var deleted bool
+	err = r.Delete(context.Background(), "stacks", "cluster-ci-a", func() error {
+		deleted = true
+		return nil
+	})
+	if !IsFaultInjected(err) {
+		t.Fatalf("expected injected fault, got %#v", err)
+	}
+
+	if deleted {
+		t.Errorf("expected resource not to be deleted when a fault is injected")
+	}
+	if len(rep.Items) != 1 || rep.Items[0].Action != report.ActionFailed {
+		t.Errorf("expected resource to be reported as failed, got %v", rep.Items)
+	}
+}
@@ -0,0 +1,43 @@
+package run
+
+import (
+	"time"
+
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// SeenKeyPrefix prefixes the state entries telling when resources were
+	// found for the first time.
+	SeenKeyPrefix = "seen/"
+)
+
+// FirstSeen returns when a run of the given cleaner found the given resource
+// for the first time. It is meant for resources which do not tell when they
+// were created, so that they still get a grace period. Without State, the
+// start of the current run is returned.
+func (r *Run) FirstSeen(cleaner string, resource string) (time.Time, error) {
+	if r.state == nil {
+		return r.report.Started, nil
+	}
+
+	key := SeenKeyPrefix + cleaner + "/" + resource
+
+	var seen time.Time
+	ok, err := r.state.Get(key, &seen)
+	if err != nil {
+		return time.Time{}, microerror.Mask(err)
+	}
+	if !ok {
+		seen = r.report.Started
+	}
+
+	// The entry is written in every run to keep it from expiring while the
+	// resource is still around.
+	err = r.state.Put(key, seen)
+	if err != nil {
+		return time.Time{}, microerror.Mask(err)
+	}
+
+	return seen, nil
+}
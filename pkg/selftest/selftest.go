@@ -0,0 +1,236 @@
+// Package selftest verifies the cleanup end to end. It creates small canary
+// resources the cleaners are expected to delete, like a tagged resource group
+// or a tiny S3 bucket, and checks on later runs whether they are gone.
+package selftest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/ci-cleaner/pkg/state"
+)
+
+const (
+	// Cleaner is the name the results of the self-test are reported under.
+	Cleaner = "self-test"
+	// NamePrefix is the prefix of the names of canary resources. It is one
+	// of the default CI prefixes, so that the cleaners pick them up.
+	NamePrefix = "ci-wip-canary-"
+
+	defaultDeadline = 6 * time.Hour
+
+	keyPrefix = "selftest/"
+)
+
+// Status is the outcome of the self-test of a single probe.
+type Status string
+
+const (
+	// StatusCreated means a new canary resource was created.
+	StatusCreated Status = "created"
+	// StatusPending means the canary resource still exists, but the
+	// deadline for deleting it did not pass yet.
+	StatusPending Status = "pending"
+	// StatusPassed means the canary resource was deleted by the cleaners.
+	StatusPassed Status = "passed"
+	// StatusFailed means the canary resource still exists after the
+	// deadline, so the cleanup is broken somewhere on the way.
+	StatusFailed Status = "failed"
+)
+
+// Probe creates canary resources of a single kind and checks whether they
+// still exist.
+type Probe interface {
+	// Name identifies the kind of canary resources, e.g. "s3-bucket".
+	Name() string
+	// Create creates a canary resource with the given name and returns its
+	// ID.
+	Create(ctx context.Context, name string) (string, error)
+	// Exists checks whether the canary resource with the given ID still
+	// exists.
+	Exists(ctx context.Context, id string) (bool, error)
+}
+
+type Config struct {
+	Logger micrologger.Logger
+	State  *state.Store
+
+	Probes []Probe
+
+	// Deadline is the time the cleaners are given to delete a canary
+	// resource, which has to be longer than their grace period. Defaults to
+	// 6h.
+	Deadline time.Duration
+}
+
+type SelfTest struct {
+	logger micrologger.Logger
+	state  *state.Store
+
+	probes []Probe
+
+	deadline time.Duration
+}
+
+// Result is the outcome of the self-test of a single probe.
+type Result struct {
+	Probe    string    `json:"probe"`
+	Resource string    `json:"resource"`
+	Status   Status    `json:"status"`
+	Created  time.Time `json:"created"`
+}
+
+// canary is the state of the canary resource of a single probe.
+type canary struct {
+	ID      string    `json:"id"`
+	Created time.Time `json:"created"`
+}
+
+func New(config Config) (*SelfTest, error) {
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.State == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.State must not be empty", config)
+	}
+
+	if config.Deadline == 0 {
+		config.Deadline = defaultDeadline
+	}
+
+	s := &SelfTest{
+		logger: config.Logger,
+		state:  config.State,
+
+		probes: config.Probes,
+
+		deadline: config.Deadline,
+	}
+
+	return s, nil
+}
+
+// Run checks the canary resources created by previous runs and creates new
+// ones for the probes whose canary resources were deleted. It is meant to be
+// called before the cleaners run, so that canary resources are given the
+// full grace period.
+func (s *SelfTest) Run(ctx context.Context) ([]Result, error) {
+	errors := &errorcollection.ErrorCollection{}
+
+	var results []Result
+	for _, p := range s.probes {
+		r, err := s.runProbe(ctx, p)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			s.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("failed self-test of probe %#q", p.Name()), "stack", fmt.Sprintf("%#v", err))
+			continue
+		}
+
+		results = append(results, r)
+	}
+
+	if errors.HasErrors() {
+		return results, errors
+	}
+	return results, nil
+}
+
+func (s *SelfTest) runProbe(ctx context.Context, p Probe) (Result, error) {
+	key := keyPrefix + p.Name()
+
+	var c canary
+	ok, err := s.state.Get(key, &c)
+	if err != nil {
+		return Result{}, microerror.Mask(err)
+	}
+
+	if ok {
+		exists, err := p.Exists(ctx, c.ID)
+		if err != nil {
+			return Result{}, microerror.Mask(err)
+		}
+
+		if exists {
+			// The entry is written again to keep it from expiring while
+			// the canary resource is still around.
+			err = s.state.Put(key, c)
+			if err != nil {
+				return Result{}, microerror.Mask(err)
+			}
+
+			r := Result{
+				Probe:    p.Name(),
+				Resource: c.ID,
+				Status:   StatusPending,
+				Created:  c.Created,
+			}
+			if time.Since(c.Created) > s.deadline {
+				r.Status = StatusFailed
+				s.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("canary %#q of probe %#q was not deleted within %s", c.ID, p.Name(), s.deadline))
+			}
+
+			return r, nil
+		}
+
+		s.logger.LogCtx(ctx, "level", "info", "message", fmt.Sprintf("canary %#q of probe %#q was deleted", c.ID, p.Name()))
+	}
+
+	name, err := canaryName()
+	if err != nil {
+		return Result{}, microerror.Mask(err)
+	}
+
+	id, err := p.Create(ctx, name)
+	if err != nil {
+		return Result{}, microerror.Mask(err)
+	}
+
+	created := canary{
+		ID:      id,
+		Created: time.Now().UTC(),
+	}
+	err = s.state.Put(key, created)
+	if err != nil {
+		return Result{}, microerror.Mask(err)
+	}
+
+	s.logger.LogCtx(ctx, "level", "info", "message", fmt.Sprintf("created canary %#q of probe %#q", id, p.Name()))
+
+	r := Result{
+		Probe:    p.Name(),
+		Resource: id,
+		Status:   StatusCreated,
+		Created:  created.Created,
+	}
+	// The canary resource of the previous run was deleted, which is what
+	// is tested for.
+	if ok {
+		r = Result{
+			Probe:    p.Name(),
+			Resource: c.ID,
+			Status:   StatusPassed,
+			Created:  c.Created,
+		}
+	}
+
+	return r, nil
+}
+
+// canaryName returns a unique name for a canary resource, which is valid for
+// S3 buckets, DNS records and resource groups alike.
+func canaryName() (string, error) {
+	b := make([]byte, 4)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return NamePrefix + hex.EncodeToString(b), nil
+}
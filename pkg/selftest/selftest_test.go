@@ -0,0 +1,100 @@
+package selftest
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+
+	"github.com/giantswarm/ci-cleaner/pkg/state"
+)
+
+type fakeProbe struct {
+	existing map[string]bool
+}
+
+func (p *fakeProbe) Name() string {
+	return "fake"
+}
+
+func (p *fakeProbe) Create(ctx context.Context, name string) (string, error) {
+	p.existing[name] = true
+	return name, nil
+}
+
+func (p *fakeProbe) Exists(ctx context.Context, id string) (bool, error) {
+	return p.existing[id], nil
+}
+
+func TestSelfTest(t *testing.T) {
+	store, err := state.New(state.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	probe := &fakeProbe{existing: map[string]bool{}}
+
+	newSelfTest := func(deadline time.Duration) *SelfTest {
+		c := Config{
+			Logger: microloggertest.New(),
+			State:  store,
+
+			Probes: []Probe{probe},
+
+			Deadline: deadline,
+		}
+
+		s, err := New(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return s
+	}
+
+	run := func(s *SelfTest) Result {
+		results, err := s.Run(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != 1 {
+			t.Fatalf("want 1 result, got %d", len(results))
+		}
+
+		return results[0]
+	}
+
+	// The first run creates a canary.
+	r := run(newSelfTest(time.Hour))
+	if r.Status != StatusCreated {
+		t.Fatalf("want status %q, got %q", StatusCreated, r.Status)
+	}
+	if !strings.HasPrefix(r.Resource, NamePrefix) {
+		t.Fatalf("want canary name with prefix %q, got %q", NamePrefix, r.Resource)
+	}
+	first := r.Resource
+
+	// The canary is still around within the deadline.
+	r = run(newSelfTest(time.Hour))
+	if r.Status != StatusPending || r.Resource != first {
+		t.Fatalf("want status %q of %q, got %q of %q", StatusPending, first, r.Status, r.Resource)
+	}
+
+	// The canary is still around after the deadline.
+	r = run(newSelfTest(time.Nanosecond))
+	if r.Status != StatusFailed || r.Resource != first {
+		t.Fatalf("want status %q of %q, got %q of %q", StatusFailed, first, r.Status, r.Resource)
+	}
+
+	// Once the cleaners deleted the canary, a new one is created.
+	delete(probe.existing, first)
+	r = run(newSelfTest(time.Hour))
+	if r.Status != StatusPassed || r.Resource != first {
+		t.Fatalf("want status %q of %q, got %q of %q", StatusPassed, first, r.Status, r.Resource)
+	}
+	if len(probe.existing) != 1 || probe.existing[first] {
+		t.Fatalf("want new canary to be created, got %v", probe.existing)
+	}
+}
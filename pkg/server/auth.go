@@ -0,0 +1,58 @@
+package server
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+)
+
+// Authenticator decides whether a request may trigger a sweep.
+type Authenticator interface {
+	Authenticate(r *http.Request) error
+}
+
+// TokenAuthenticator accepts requests carrying a static bearer token.
+type TokenAuthenticator struct {
+	Token string
+}
+
+// Authenticate implements Authenticator.
+func (a TokenAuthenticator) Authenticate(r *http.Request) error {
+	token, err := bearerToken(r)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if a.Token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(a.Token)) != 1 {
+		return microerror.Maskf(unauthorizedError, "invalid token")
+	}
+
+	return nil
+}
+
+// Authenticators accepts requests accepted by any of its authenticators.
+type Authenticators []Authenticator
+
+// Authenticate implements Authenticator.
+func (as Authenticators) Authenticate(r *http.Request) error {
+	err := microerror.Maskf(unauthorizedError, "no authenticator configured")
+	for _, a := range as {
+		err = a.Authenticate(r)
+		if err == nil {
+			return nil
+		}
+	}
+
+	return microerror.Mask(err)
+}
+
+func bearerToken(r *http.Request) (string, error) {
+	h := r.Header.Get("Authorization")
+	if !strings.HasPrefix(h, "Bearer ") {
+		return "", microerror.Maskf(unauthorizedError, "missing bearer token")
+	}
+
+	return strings.TrimPrefix(h, "Bearer "), nil
+}
@@ -0,0 +1,321 @@
+package server
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// keysRefreshInterval limits how often the signing keys of the issuer
+	// are fetched when a token is signed with an unknown key.
+	keysRefreshInterval = time.Minute
+)
+
+type OIDCAuthenticatorConfig struct {
+	// Issuer is the URL of the OIDC issuer, e.g.
+	// https://token.actions.githubusercontent.com.
+	Issuer string
+	// Audience is the audience tokens have to be issued for.
+	Audience string
+
+	// Repositories are the repositories, e.g. giantswarm/cluster-test-suites,
+	// whose workflows are accepted, as told by the repository claim of
+	// tokens of GitHub Actions. Subjects are the accepted subjects, e.g.
+	// repo:giantswarm/cluster-test-suites:ref:refs/heads/main, where a
+	// trailing * matches any suffix. Either of them must be given, as every
+	// workflow can get tokens of public issuers.
+	Repositories []string
+	Subjects     []string
+}
+
+// OIDCAuthenticator accepts requests carrying an ID token of the configured
+// issuer. Only RS256 signed tokens are supported, which all common CI
+// systems issue.
+type OIDCAuthenticator struct {
+	issuer   string
+	audience string
+
+	repositories []string
+	subjects     []string
+
+	mutex       sync.Mutex
+	keys        map[string]*rsa.PublicKey
+	refreshedAt time.Time
+}
+
+func NewOIDCAuthenticator(config OIDCAuthenticatorConfig) (*OIDCAuthenticator, error) {
+	if config.Issuer == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Issuer must not be empty", config)
+	}
+	if config.Audience == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Audience must not be empty", config)
+	}
+	if len(config.Repositories) == 0 && len(config.Subjects) == 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Repositories or %T.Subjects must not be empty", config, config)
+	}
+
+	a := &OIDCAuthenticator{
+		issuer:   strings.TrimSuffix(config.Issuer, "/"),
+		audience: config.Audience,
+
+		repositories: config.Repositories,
+		subjects:     config.Subjects,
+	}
+
+	return a, nil
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type jwtClaims struct {
+	Issuer    string   `json:"iss"`
+	Audience  audience `json:"aud"`
+	ExpiresAt int64    `json:"exp"`
+	NotBefore int64    `json:"nbf"`
+
+	Subject    string `json:"sub"`
+	Repository string `json:"repository"`
+}
+
+// audience is either a single string or a list of strings.
+type audience []string
+
+func (a *audience) UnmarshalJSON(b []byte) error {
+	var s string
+	err := json.Unmarshal(b, &s)
+	if err == nil {
+		*a = audience{s}
+		return nil
+	}
+
+	var l []string
+	err = json.Unmarshal(b, &l)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	*a = l
+
+	return nil
+}
+
+// Authenticate implements Authenticator.
+func (a *OIDCAuthenticator) Authenticate(r *http.Request) error {
+	token, err := bearerToken(r)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return microerror.Maskf(unauthorizedError, "malformed token")
+	}
+
+	var header jwtHeader
+	err = decodeSegment(parts[0], &header)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	if header.Alg != "RS256" {
+		return microerror.Maskf(unauthorizedError, "unsupported signing algorithm %#q", header.Alg)
+	}
+
+	key, err := a.key(r.Context(), header.Kid)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return microerror.Maskf(unauthorizedError, "malformed signature")
+	}
+	hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	err = rsa.VerifyPKCS1v15(key, crypto.SHA256, hash[:], signature)
+	if err != nil {
+		return microerror.Maskf(unauthorizedError, "invalid signature")
+	}
+
+	var claims jwtClaims
+	err = decodeSegment(parts[1], &claims)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	now := time.Now().Unix()
+	if strings.TrimSuffix(claims.Issuer, "/") != a.issuer {
+		return microerror.Maskf(unauthorizedError, "unexpected issuer %#q", claims.Issuer)
+	}
+	if !contains(claims.Audience, a.audience) {
+		return microerror.Maskf(unauthorizedError, "token not issued for audience %#q", a.audience)
+	}
+	if claims.ExpiresAt <= now {
+		return microerror.Maskf(unauthorizedError, "token expired")
+	}
+	if claims.NotBefore > now {
+		return microerror.Maskf(unauthorizedError, "token not valid yet")
+	}
+	if !a.allowed(claims) {
+		return microerror.Maskf(unauthorizedError, "token of subject %#q not allowed", claims.Subject)
+	}
+
+	return nil
+}
+
+// allowed returns whether the token with the given claims was issued for one
+// of the accepted repositories or subjects.
+func (a *OIDCAuthenticator) allowed(claims jwtClaims) bool {
+	if claims.Repository != "" && contains(a.repositories, claims.Repository) {
+		return true
+	}
+
+	if claims.Subject == "" {
+		return false
+	}
+	for _, s := range a.subjects {
+		if strings.HasSuffix(s, "*") && strings.HasPrefix(claims.Subject, strings.TrimSuffix(s, "*")) {
+			return true
+		}
+		if s == claims.Subject {
+			return true
+		}
+	}
+
+	return false
+}
+
+// key returns the signing key with the given ID. The keys of the issuer are
+// fetched again when the key is unknown, as issuers rotate their keys.
+func (a *OIDCAuthenticator) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	key, ok := a.keys[kid]
+	if ok {
+		return key, nil
+	}
+
+	if time.Since(a.refreshedAt) < keysRefreshInterval {
+		return nil, microerror.Maskf(unauthorizedError, "unknown signing key %#q", kid)
+	}
+
+	keys, err := a.fetchKeys(ctx)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	a.keys = keys
+	a.refreshedAt = time.Now()
+
+	key, ok = a.keys[kid]
+	if !ok {
+		return nil, microerror.Maskf(unauthorizedError, "unknown signing key %#q", kid)
+	}
+
+	return key, nil
+}
+
+func (a *OIDCAuthenticator) fetchKeys(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	var discovery struct {
+		JWKSURI string `json:"jwks_uri"`
+	}
+	err := getJSON(ctx, a.issuer+"/.well-known/openid-configuration", &discovery)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var jwks struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	err = getJSON(ctx, discovery.JWKSURI, &jwks)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+	for _, k := range jwks.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			continue
+		}
+
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	return keys, nil
+}
+
+func getJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	res, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return microerror.Maskf(unauthorizedError, "fetching %#q returned status %d", url, res.StatusCode)
+	}
+
+	err = json.NewDecoder(res.Body).Decode(v)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func decodeSegment(s string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return microerror.Maskf(unauthorizedError, "malformed token")
+	}
+
+	err = json.Unmarshal(b, v)
+	if err != nil {
+		return microerror.Maskf(unauthorizedError, "malformed token")
+	}
+
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,188 @@
+// Package server implements the HTTP API of the ci-cleaner daemon. CI
+// pipelines use it to request the cleanup of their own resources right after
+// they failed, instead of waiting for the next scheduled sweep.
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+
+	"github.com/giantswarm/ci-cleaner/pkg/protection"
+)
+
+// clusterIDPattern matches cluster IDs like `abc12` or `ci-wip-a1b2c`. The
+// minimum length keeps requests from scoping sweeps to something as broad as
+// `ci`.
+var clusterIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{3,38}[a-z0-9]$`)
+
+// TriggerRequest is the body of a request to the /trigger endpoint.
+type TriggerRequest struct {
+	// Provider is the provider to sweep, e.g. "aws".
+	Provider string `json:"provider"`
+	// ClusterID restricts the sweep to the resources of a single cluster.
+	ClusterID string `json:"clusterID"`
+	// Cleaners restricts the sweep to the given cleaners. All cleaners run
+	// when empty.
+	Cleaners []string `json:"cleaners,omitempty"`
+}
+
+type Config struct {
+	Authenticator Authenticator
+	Logger        micrologger.Logger
+	// Trigger schedules a sweep for the given request. It must not block
+	// until the sweep finished.
+	Trigger func(r TriggerRequest) error
+
+	// Metrics is served on /metrics when given.
+	Metrics http.Handler
+
+	// Providers are the providers sweeps can be triggered for. Cleaners are
+	// the names of the cleaners of each provider triggered sweeps can be
+	// restricted to.
+	Providers []string
+	Cleaners  map[string][]string
+
+	// Protection and SlackSigningSecret enable the /slack/command endpoint
+	// handling the /cleaner Slack slash-command to protect resources
+	// temporarily. Persist is called after protections changed.
+	Protection         *protection.Protection
+	Persist            func() error
+	SlackSigningSecret string
+}
+
+type Server struct {
+	authenticator Authenticator
+	logger        micrologger.Logger
+	trigger       func(r TriggerRequest) error
+
+	metrics   http.Handler
+	providers []string
+	cleaners  map[string][]string
+
+	protection         *protection.Protection
+	persist            func() error
+	slackSigningSecret string
+}
+
+func New(config Config) (*Server, error) {
+	if config.Authenticator == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Authenticator must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.Trigger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Trigger must not be empty", config)
+	}
+
+	if len(config.Providers) == 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Providers must not be empty", config)
+	}
+
+	if config.SlackSigningSecret != "" && config.Protection == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Protection must not be empty when %T.SlackSigningSecret is given", config, config)
+	}
+	if config.Persist == nil {
+		config.Persist = func() error { return nil }
+	}
+
+	s := &Server{
+		authenticator: config.Authenticator,
+		logger:        config.Logger,
+		trigger:       config.Trigger,
+
+		metrics:   config.Metrics,
+		providers: config.Providers,
+		cleaners:  config.Cleaners,
+
+		protection:         config.Protection,
+		persist:            config.Persist,
+		slackSigningSecret: config.SlackSigningSecret,
+	}
+
+	return s, nil
+}
+
+// Handler returns the HTTP handler serving the API.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", s.healthz)
+	mux.HandleFunc("/trigger", s.triggerHandler)
+	if s.metrics != nil {
+		mux.Handle("/metrics", s.metrics)
+	}
+	if s.slackSigningSecret != "" {
+		mux.HandleFunc("/slack/command", s.slackCommand)
+	}
+
+	return mux
+}
+
+func (s *Server) healthz(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) triggerHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	err := s.authenticator.Authenticate(r)
+	if err != nil {
+		s.logger.LogCtx(r.Context(), "level", "warning", "message", "rejected unauthenticated trigger request", "stack", fmt.Sprintf("%#v", err))
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req TriggerRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid request body: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	err = s.validate(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = s.trigger(req)
+	if err != nil {
+		s.logger.LogCtx(r.Context(), "level", "error", "message", "failed to trigger sweep", "stack", fmt.Sprintf("%#v", err))
+		http.Error(w, "failed to trigger sweep", http.StatusServiceUnavailable)
+		return
+	}
+
+	s.logger.LogCtx(r.Context(), "level", "info", "message", fmt.Sprintf("triggered %s sweep for cluster %#q", req.Provider, req.ClusterID))
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// validate ensures triggered sweeps are scoped to a single cluster, so that
+// a pipeline can only request the cleanup of its own resources, and only run
+// cleaners of the provider.
+func (s *Server) validate(req TriggerRequest) error {
+	if !contains(s.providers, req.Provider) {
+		return fmt.Errorf("provider must be one of %v", s.providers)
+	}
+	if req.ClusterID == "" {
+		return fmt.Errorf("clusterID must not be empty")
+	}
+	if !clusterIDPattern.MatchString(req.ClusterID) {
+		return fmt.Errorf("clusterID %#q must consist of 5 to 40 lowercase alphanumeric characters or dashes", req.ClusterID)
+	}
+	for _, c := range req.Cleaners {
+		if !contains(s.cleaners[req.Provider], c) {
+			return fmt.Errorf("cleaner %#q is not a cleaner of provider %#q", c, req.Provider)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,228 @@
+package server
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+)
+
+func TestTrigger(t *testing.T) {
+	var triggered []TriggerRequest
+
+	s, err := New(Config{
+		Authenticator: TokenAuthenticator{Token: "secret"},
+		Logger:        microloggertest.New(),
+		Trigger: func(r TriggerRequest) error {
+			triggered = append(triggered, r)
+			return nil
+		},
+
+		Providers: []string{"aws"},
+		Cleaners:  map[string][]string{"aws": {"stacks", "buckets"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name           string
+		method         string
+		token          string
+		body           string
+		expectedStatus int
+	}{
+		{
+			name:           "case 0: valid request",
+			method:         http.MethodPost,
+			token:          "secret",
+			body:           `{"provider": "aws", "clusterID": "abc12", "cleaners": ["stacks"]}`,
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			name:           "case 1: invalid token",
+			method:         http.MethodPost,
+			token:          "guess",
+			body:           `{"provider": "aws", "clusterID": "abc12"}`,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "case 2: missing cluster ID",
+			method:         http.MethodPost,
+			token:          "secret",
+			body:           `{"provider": "aws"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "case 3: unknown provider",
+			method:         http.MethodPost,
+			token:          "secret",
+			body:           `{"provider": "gcp", "clusterID": "abc12"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "case 4: unknown cleaner",
+			method:         http.MethodPost,
+			token:          "secret",
+			body:           `{"provider": "aws", "clusterID": "abc12", "cleaners": ["stacks", "stack"]}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "case 5: too short cluster ID",
+			method:         http.MethodPost,
+			token:          "secret",
+			body:           `{"provider": "aws", "clusterID": "ci"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "case 6: malformed cluster ID",
+			method:         http.MethodPost,
+			token:          "secret",
+			body:           `{"provider": "aws", "clusterID": "abc12*"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "case 7: wrong method",
+			method:         http.MethodGet,
+			token:          "secret",
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/trigger", strings.NewReader(tc.body))
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+			w := httptest.NewRecorder()
+
+			s.Handler().ServeHTTP(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("expected status %d, got %d", tc.expectedStatus, w.Code)
+			}
+		})
+	}
+
+	if len(triggered) != 1 || triggered[0].ClusterID != "abc12" || triggered[0].Cleaners[0] != "stacks" {
+		t.Errorf("expected exactly the valid request to trigger a sweep, got %#v", triggered)
+	}
+}
+
+func TestOIDCAuthenticator(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var issuer string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"jwks_uri": %q}`, issuer+"/keys")
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"keys": [{"kty": "RSA", "kid": "k1", "n": %q, "e": %q}]}`,
+			base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		)
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+	issuer = ts.URL
+
+	_, err = NewOIDCAuthenticator(OIDCAuthenticatorConfig{Issuer: issuer, Audience: "ci-cleaner"})
+	if !IsInvalidConfig(err) {
+		t.Fatalf("expected tokens of any repository to be rejected, got %#v", err)
+	}
+
+	c := OIDCAuthenticatorConfig{
+		Issuer:   issuer,
+		Audience: "ci-cleaner",
+
+		Repositories: []string{"giantswarm/cluster-test-suites"},
+		Subjects:     []string{"repo:giantswarm/cluster-api-app:*"},
+	}
+	a, err := NewOIDCAuthenticator(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sign := func(claims map[string]interface{}) string {
+		header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "k1"})
+		payload, _ := json.Marshal(claims)
+		unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+		hash := sha256.Sum256([]byte(unsigned))
+		signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hash[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature)
+	}
+
+	exp := time.Now().Add(time.Hour).Unix()
+
+	testCases := []struct {
+		name          string
+		token         string
+		expectedError bool
+	}{
+		{
+			name:  "case 0: valid token",
+			token: sign(map[string]interface{}{"iss": issuer, "aud": "ci-cleaner", "exp": exp, "repository": "giantswarm/cluster-test-suites"}),
+		},
+		{
+			name:          "case 1: wrong audience",
+			token:         sign(map[string]interface{}{"iss": issuer, "aud": []string{"other"}, "exp": exp, "repository": "giantswarm/cluster-test-suites"}),
+			expectedError: true,
+		},
+		{
+			name:          "case 2: expired",
+			token:         sign(map[string]interface{}{"iss": issuer, "aud": "ci-cleaner", "exp": time.Now().Add(-time.Hour).Unix(), "repository": "giantswarm/cluster-test-suites"}),
+			expectedError: true,
+		},
+		{
+			name:          "case 3: tampered signature",
+			token:         sign(map[string]interface{}{"iss": issuer, "aud": "ci-cleaner", "exp": exp, "repository": "giantswarm/cluster-test-suites"}) + "x",
+			expectedError: true,
+		},
+		{
+			name:  "case 4: allowed subject",
+			token: sign(map[string]interface{}{"iss": issuer, "aud": "ci-cleaner", "exp": exp, "sub": "repo:giantswarm/cluster-api-app:ref:refs/heads/main"}),
+		},
+		{
+			name:          "case 5: other repository",
+			token:         sign(map[string]interface{}{"iss": issuer, "aud": "ci-cleaner", "exp": exp, "sub": "repo:someone/fork:ref:refs/heads/main", "repository": "someone/fork"}),
+			expectedError: true,
+		},
+		{
+			name:          "case 6: no subject",
+			token:         sign(map[string]interface{}{"iss": issuer, "aud": "ci-cleaner", "exp": exp}),
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/trigger", nil)
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+
+			err := a.Authenticate(req)
+			if tc.expectedError && !IsUnauthorized(err) {
+				t.Errorf("expected unauthorized error, got %#v", err)
+			}
+			if !tc.expectedError && err != nil {
+				t.Errorf("expected no error, got %#v", err)
+			}
+		})
+	}
+}
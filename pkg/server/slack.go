@@ -0,0 +1,176 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// slackMaxAge is the maximum age of slash-command requests, which
+	// protects against replayed requests.
+	slackMaxAge = 5 * time.Minute
+
+	slackUsage = "Usage: `/cleaner keep <cluster ID or name> <duration, e.g. 24h or 2d>`, `/cleaner release <cluster ID or name>` or `/cleaner list`"
+)
+
+type slackResponse struct {
+	ResponseType string `json:"response_type"`
+	Text         string `json:"text"`
+}
+
+// slackCommand handles the /cleaner Slack slash-command, which protects
+// resources from being deleted temporarily.
+func (s *Server) slackCommand(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, 1<<16))
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	err = verifySlackSignature(s.slackSigningSecret, r.Header, body, time.Now())
+	if err != nil {
+		s.logger.LogCtx(r.Context(), "level", "warning", "message", "rejected unauthenticated slack command", "stack", fmt.Sprintf("%#v", err))
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	res := s.runSlackCommand(form.Get("user_name"), strings.Fields(form.Get("text")))
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(res)
+	if err != nil {
+		s.logger.LogCtx(r.Context(), "level", "error", "message", "failed to respond to slack command", "stack", fmt.Sprintf("%#v", err))
+	}
+}
+
+func (s *Server) runSlackCommand(user string, args []string) slackResponse {
+	ephemeral := func(text string) slackResponse {
+		return slackResponse{ResponseType: "ephemeral", Text: text}
+	}
+
+	if len(args) == 0 {
+		return ephemeral(slackUsage)
+	}
+
+	switch {
+	case args[0] == "keep" && len(args) == 3:
+		d, err := parseDuration(args[2])
+		if err != nil {
+			return ephemeral(fmt.Sprintf("Invalid duration %q. %s", args[2], slackUsage))
+		}
+
+		e, err := s.protection.Keep(args[1], d, user)
+		if err != nil {
+			return ephemeral(fmt.Sprintf("Cannot keep `%s`: %s", args[1], microerror.Cause(err).Error()))
+		}
+
+		err = s.persist()
+		if err != nil {
+			return ephemeral(fmt.Sprintf("Cannot keep `%s`: %s", args[1], err.Error()))
+		}
+
+		return slackResponse{
+			ResponseType: "in_channel",
+			Text:         fmt.Sprintf("Keeping resources matching `%s` until %s, as requested by %s.", e.Pattern, e.Until.Format(time.RFC1123), user),
+		}
+
+	case args[0] == "release" && len(args) == 2:
+		s.protection.Release(args[1])
+
+		err := s.persist()
+		if err != nil {
+			return ephemeral(fmt.Sprintf("Cannot release `%s`: %s", args[1], err.Error()))
+		}
+
+		return slackResponse{
+			ResponseType: "in_channel",
+			Text:         fmt.Sprintf("Resources matching `%s` are no longer kept, as requested by %s.", args[1], user),
+		}
+
+	case args[0] == "list" && len(args) == 1:
+		entries, err := s.protection.List()
+		if err != nil {
+			return ephemeral(fmt.Sprintf("Cannot list kept resources: %s", err.Error()))
+		}
+		if len(entries) == 0 {
+			return ephemeral("No resources are kept.")
+		}
+
+		var lines []string
+		for _, e := range entries {
+			lines = append(lines, fmt.Sprintf("`%s` until %s, requested by %s", e.Pattern, e.Until.Format(time.RFC1123), e.By))
+		}
+
+		return ephemeral(strings.Join(lines, "\n"))
+	}
+
+	return ephemeral(slackUsage)
+}
+
+// verifySlackSignature verifies the signature Slack sends along with every
+// request, see https://api.slack.com/authentication/verifying-requests-from-slack.
+func verifySlackSignature(secret string, header http.Header, body []byte, now time.Time) error {
+	timestamp := header.Get("X-Slack-Request-Timestamp")
+	signature := header.Get("X-Slack-Signature")
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return microerror.Maskf(unauthorizedError, "invalid timestamp")
+	}
+	age := now.Sub(time.Unix(ts, 0))
+	if age > slackMaxAge || age < -slackMaxAge {
+		return microerror.Maskf(unauthorizedError, "request too old")
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "v0:%s:%s", timestamp, body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return microerror.Maskf(unauthorizedError, "invalid signature")
+	}
+
+	return nil
+}
+
+// parseDuration parses durations like "24h" and additionally supports days
+// like "2d".
+func parseDuration(s string) (time.Duration, error) {
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if err != nil {
+			return 0, microerror.Mask(err)
+		}
+
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, microerror.Mask(err)
+	}
+
+	return d, nil
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+
+	"github.com/giantswarm/ci-cleaner/pkg/protection"
+	"github.com/giantswarm/ci-cleaner/pkg/resource"
+	"github.com/giantswarm/ci-cleaner/pkg/state"
+)
+
+func TestSlackCommand(t *testing.T) {
+	stateStore, err := state.New(state.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := protection.New(protection.Config{State: stateStore})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var persisted int
+	s, err := New(Config{
+		Authenticator: TokenAuthenticator{Token: "secret"},
+		Logger:        microloggertest.New(),
+		Trigger:       func(r TriggerRequest) error { return nil },
+
+		Providers: []string{"aws"},
+
+		Protection: p,
+		Persist: func() error {
+			persisted++
+			return nil
+		},
+		SlackSigningSecret: "signing-secret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	send := func(text string, secret string, timestamp time.Time) *httptest.ResponseRecorder {
+		body := url.Values{"text": {text}, "user_name": {"alice"}}.Encode()
+		ts := strconv.FormatInt(timestamp.Unix(), 10)
+		mac := hmac.New(sha256.New, []byte(secret))
+		fmt.Fprintf(mac, "v0:%s:%s", ts, body)
+
+		req := httptest.NewRequest(http.MethodPost, "/slack/command", strings.NewReader(body))
+		req.Header.Set("X-Slack-Request-Timestamp", ts)
+		req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+		w := httptest.NewRecorder()
+
+		s.Handler().ServeHTTP(w, req)
+
+		return w
+	}
+
+	if w := send("keep ci-a1b2c 24h", "guess", time.Now()); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected invalid signature to be rejected, got %d", w.Code)
+	}
+	if w := send("keep ci-a1b2c 24h", "signing-secret", time.Now().Add(-time.Hour)); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected replayed request to be rejected, got %d", w.Code)
+	}
+
+	w := send("keep ci-a1b2c 2d", "signing-secret", time.Now())
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	var res slackResponse
+	err = json.NewDecoder(w.Body).Decode(&res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ResponseType != "in_channel" || !strings.Contains(res.Text, "ci-a1b2c") {
+		t.Errorf("unexpected response %#v", res)
+	}
+
+	e, ok, err := p.Protected(resource.Resource{ID: "cluster-ci-a1b2c-guest-main"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok || e.By != "alice" || e.Until.Before(time.Now().Add(47*time.Hour)) {
+		t.Errorf("expected resource to be protected for 2 days by alice, got %#v", e)
+	}
+	if persisted != 1 {
+		t.Errorf("expected protection to be persisted once, got %d", persisted)
+	}
+
+	send("release ci-a1b2c", "signing-secret", time.Now())
+	_, ok, err = p.Protected(resource.Resource{ID: "cluster-ci-a1b2c-guest-main"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok {
+		t.Errorf("expected protection to be released")
+	}
+}
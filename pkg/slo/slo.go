@@ -0,0 +1,154 @@
+// Package slo tracks the time-to-clean of leaked resources, i.e. how long
+// they lived before a run deleted them, per resource type. Deletions are
+// recorded in the audit log, so that the percentiles span all runs within a
+// window and can be compared against a service level objective.
+package slo
+
+import (
+	"sort"
+	"time"
+
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/ci-cleaner/pkg/audit"
+	"github.com/giantswarm/ci-cleaner/pkg/report"
+)
+
+const (
+	// ActionDeleted is the audit log action recorded for every resource a
+	// run deleted which tells when it was created.
+	ActionDeleted = "deleted"
+
+	defaultWindow = 7 * 24 * time.Hour
+)
+
+type Config struct {
+	Audit *audit.Log
+
+	// Threshold is the objective for the 95th percentile of the
+	// time-to-clean of all resource types. Types are not alerted on when
+	// zero.
+	Threshold time.Duration
+	// Thresholds overrides Threshold for single resource types, e.g.
+	// "AWS::EC2::Instance".
+	Thresholds map[string]time.Duration
+	// Window is how far back deletions are considered. Defaults to 7 days.
+	Window time.Duration
+}
+
+type SLO struct {
+	audit *audit.Log
+
+	threshold  time.Duration
+	thresholds map[string]time.Duration
+	window     time.Duration
+}
+
+func New(config Config) (*SLO, error) {
+	if config.Audit == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Audit must not be empty", config)
+	}
+
+	if config.Threshold < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Threshold must not be negative", config)
+	}
+	if config.Window == 0 {
+		config.Window = defaultWindow
+	}
+
+	s := &SLO{
+		audit: config.Audit,
+
+		threshold:  config.Threshold,
+		thresholds: config.Thresholds,
+		window:     config.Window,
+	}
+
+	return s, nil
+}
+
+// Record appends the resources the given report lists as deleted to the
+// audit log. Resources which do not tell when they were created are left
+// out, as their time-to-clean is unknown.
+func (s *SLO) Record(r *report.Report) error {
+	for _, items := range r.ByCleaner() {
+		for _, i := range items {
+			if i.Action != report.ActionDeleted || i.CreatedAt == nil {
+				continue
+			}
+
+			record := audit.Record{
+				Action:   ActionDeleted,
+				Cleaner:  i.Cleaner,
+				Resource: i.Resource,
+
+				Provider:  r.Provider,
+				Type:      i.Type,
+				CreatedAt: i.CreatedAt,
+			}
+			err := s.audit.Record(record)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Compute returns the time-to-clean of the resource types the given provider
+// deleted within the window before now, sorted by type.
+func (s *SLO) Compute(provider string, now time.Time) ([]report.TimeToClean, error) {
+	records, err := s.audit.Records()
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	since := now.Add(-s.window)
+
+	lifetimes := map[string][]time.Duration{}
+	for _, r := range records {
+		if r.Action != ActionDeleted || r.Provider != provider || r.CreatedAt == nil || r.Time.Before(since) {
+			continue
+		}
+
+		lifetimes[r.Type] = append(lifetimes[r.Type], r.Time.Sub(*r.CreatedAt))
+	}
+
+	var stats []report.TimeToClean
+	for t, l := range lifetimes {
+		sort.Slice(l, func(i, j int) bool { return l[i] < l[j] })
+
+		threshold := s.threshold
+		if d, ok := s.thresholds[t]; ok {
+			threshold = d
+		}
+
+		stats = append(stats, report.TimeToClean{
+			Provider:  provider,
+			Type:      t,
+			Count:     len(l),
+			P50:       percentile(l, 50),
+			P95:       percentile(l, 95),
+			Threshold: threshold,
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Type < stats[j].Type })
+
+	return stats, nil
+}
+
+// percentile returns the p-th percentile of the given sorted durations by the
+// nearest-rank method.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+
+	return sorted[rank-1]
+}
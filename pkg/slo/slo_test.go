@@ -0,0 +1,102 @@
+package slo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/ci-cleaner/pkg/audit"
+	"github.com/giantswarm/ci-cleaner/pkg/report"
+)
+
+func TestPercentile(t *testing.T) {
+	tcs := []struct {
+		durations []time.Duration
+		p         int
+		expected  time.Duration
+	}{
+		{durations: nil, p: 95, expected: 0},
+		{durations: []time.Duration{time.Hour}, p: 95, expected: time.Hour},
+		{durations: []time.Duration{1, 2, 3, 4}, p: 50, expected: 2},
+		{durations: []time.Duration{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20}, p: 95, expected: 19},
+	}
+
+	for _, tc := range tcs {
+		actual := percentile(tc.durations, tc.p)
+		if actual != tc.expected {
+			t.Errorf("percentile(%v, %d): want %s, got %s", tc.durations, tc.p, tc.expected, actual)
+		}
+	}
+}
+
+func TestSLO(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ci-cleaner-slo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	auditLog, err := audit.New(audit.Config{Path: filepath.Join(dir, "audit.log")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := New(Config{
+		Audit: auditLog,
+
+		Threshold: 3 * time.Hour,
+		Thresholds: map[string]time.Duration{
+			"AWS::S3::Bucket": 12 * time.Hour,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	created := func(d time.Duration) *time.Time {
+		t := time.Now().Add(-d)
+		return &t
+	}
+
+	r := report.New("aws")
+	r.Add(report.Item{Cleaner: "instances", Resource: "i-1", Action: report.ActionDeleted, Type: "AWS::EC2::Instance", CreatedAt: created(2 * time.Hour)})
+	r.Add(report.Item{Cleaner: "instances", Resource: "i-2", Action: report.ActionDeleted, Type: "AWS::EC2::Instance", CreatedAt: created(4 * time.Hour)})
+	r.Add(report.Item{Cleaner: "instances", Resource: "i-3", Action: report.ActionFailed, Type: "AWS::EC2::Instance", CreatedAt: created(48 * time.Hour)})
+	r.Add(report.Item{Cleaner: "buckets", Resource: "ci-wip-a", Action: report.ActionDeleted, Type: "AWS::S3::Bucket", CreatedAt: created(4 * time.Hour)})
+	r.Add(report.Item{Cleaner: "route-tables", Resource: "rtb-1", Action: report.ActionDeleted, Type: "AWS::EC2::RouteTable"})
+
+	err = s.Record(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := s.Compute("aws", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(stats) != 2 {
+		t.Fatalf("want stats of 2 types, got %v", stats)
+	}
+
+	instance, bucket := stats[0], stats[1]
+	if bucket.Type != "AWS::S3::Bucket" || bucket.Exceeded() {
+		t.Errorf("want bucket to meet its own threshold, got %#v", bucket)
+	}
+	if instance.Type != "AWS::EC2::Instance" || instance.Count != 2 || !instance.Exceeded() {
+		t.Errorf("want instances to exceed the threshold, got %#v", instance)
+	}
+	if instance.P95 < 4*time.Hour || instance.P50 >= 4*time.Hour {
+		t.Errorf("want p50 of about 2h and p95 of about 4h, got %s and %s", instance.P50, instance.P95)
+	}
+
+	stats, err = s.Compute("azure", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 0 {
+		t.Errorf("want no stats of other providers, got %v", stats)
+	}
+}
@@ -0,0 +1,14 @@
+package state
+
+import (
+	"github.com/giantswarm/microerror"
+)
+
+var invalidStateError = &microerror.Error{
+	Kind: "invalidStateError",
+}
+
+// IsInvalidState asserts invalidStateError.
+func IsInvalidState(err error) bool {
+	return microerror.Cause(err) == invalidStateError
+}
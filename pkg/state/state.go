@@ -0,0 +1,191 @@
+// Package state implements a small key value store persisting the state of the
+// ci-cleaner between runs as a JSON file.
+package state
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/giantswarm/microerror"
+)
+
+type Config struct {
+	// Path is the file the state is persisted to. The state is kept in memory
+	// only when Path is empty.
+	Path string
+}
+
+// Entry is a single value of the store.
+type Entry struct {
+	Value     json.RawMessage `json:"value"`
+	UpdatedAt time.Time       `json:"updatedAt"`
+}
+
+// Store is a key value store safe for concurrent use. Changes are only
+// persisted when calling Flush.
+type Store struct {
+	mutex   sync.Mutex
+	path    string
+	entries map[string]Entry
+}
+
+func New(config Config) (*Store, error) {
+	s := &Store{
+		path:    config.Path,
+		entries: map[string]Entry{},
+	}
+
+	if s.path == "" {
+		return s, nil
+	}
+
+	b, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return s, nil
+	} else if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	err = json.Unmarshal(b, &s.entries)
+	if err != nil {
+		return nil, microerror.Maskf(invalidStateError, "parsing %#q: %s", s.path, err.Error())
+	}
+
+	return s, nil
+}
+
+// Get decodes the value stored under key into v. The returned bool is false
+// when no value is stored under key.
+func (s *Store) Get(key string, v interface{}) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	e, ok := s.entries[key]
+	if !ok {
+		return false, nil
+	}
+
+	err := json.Unmarshal(e.Value, v)
+	if err != nil {
+		return false, microerror.Maskf(invalidStateError, "decoding %#q: %s", key, err.Error())
+	}
+
+	return true, nil
+}
+
+// Put stores v under key.
+func (s *Store) Put(key string, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.entries[key] = Entry{
+		Value:     b,
+		UpdatedAt: time.Now().UTC(),
+	}
+
+	return nil
+}
+
+// Delete removes the value stored under key.
+func (s *Store) Delete(key string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.entries, key)
+}
+
+// DeleteUpdatedBefore removes all values which were not updated since t and
+// returns the number of removed values. Values whose key starts with one of
+// the given prefixes are kept.
+func (s *Store) DeleteUpdatedBefore(t time.Time, exceptPrefixes ...string) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var n int
+	for k, e := range s.entries {
+		if hasAnyPrefix(k, exceptPrefixes) {
+			continue
+		}
+		if e.UpdatedAt.Before(t) {
+			delete(s.entries, k)
+			n++
+		}
+	}
+
+	return n
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Keys returns the sorted keys starting with prefix.
+func (s *Store) Keys(prefix string) []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var keys []string
+	for k := range s.entries {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// Flush persists the state. The file is replaced atomically so a crashing run
+// does not leave a corrupted state behind.
+func (s *Store) Flush() error {
+	if s.path == "" {
+		return nil
+	}
+
+	s.mutex.Lock()
+	b, err := json.MarshalIndent(s.entries, "", "  ")
+	s.mutex.Unlock()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(b)
+	if err != nil {
+		f.Close()
+		return microerror.Mask(err)
+	}
+	err = f.Close()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = os.Rename(f.Name(), s.path)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package state
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ci-cleaner-state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "state.json")
+
+	{
+		s, err := New(Config{Path: path})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = s.Put("rollout/stacks", []string{"a", "b"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = s.Put("rollout/buckets", []string{"c"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = s.Put("other", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.Delete("other")
+
+		err = s.Flush()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	{
+		s, err := New(Config{Path: path})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		keys := s.Keys("rollout/")
+		expected := []string{"rollout/buckets", "rollout/stacks"}
+		if !reflect.DeepEqual(keys, expected) {
+			t.Errorf("expected keys %v, got %v", expected, keys)
+		}
+
+		var v []string
+		ok, err := s.Get("rollout/stacks", &v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok || !reflect.DeepEqual(v, []string{"a", "b"}) {
+			t.Errorf("expected value %v, got %v", []string{"a", "b"}, v)
+		}
+
+		ok, err = s.Get("other", &v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok {
+			t.Errorf("expected deleted key to be missing")
+		}
+	}
+}
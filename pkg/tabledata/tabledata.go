@@ -0,0 +1,99 @@
+// Package tabledata knows about the items tests write into shared tables
+// instead of creating their own, keyed by the ID of their run, e.g.
+// `ci-run-1234`, so that the cleaners of DynamoDB tables and Cosmos DB
+// containers decide the same way which runs expired.
+package tabledata
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Table is a shared table tests write items into.
+type Table struct {
+	// Name is the name of a DynamoDB table, or of a Cosmos DB container like
+	// `<account>/<database>/<container>`.
+	Name string
+	// KeyAttribute is the attribute holding the run ID. Defaults to the
+	// partition key of the table.
+	KeyAttribute string
+	// KeyPrefixes are the prefixes of the run IDs whose items are cleaned
+	// up. There is no default, so that items are never deleted by accident.
+	KeyPrefixes []string
+	// TimestampAttribute is the attribute holding when an item was written,
+	// see ParseTimestamp. Runs with items without it expire once they were
+	// first seen longer ago than the retention.
+	TimestampAttribute string
+}
+
+// HasKeyPrefix checks if the given run ID has one of the key prefixes of the
+// table.
+func (t Table) HasKeyPrefix(key string) bool {
+	for _, p := range t.KeyPrefixes {
+		if p != "" && strings.HasPrefix(key, p) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Run is the data a single test run left in a table.
+type Run struct {
+	Key   string
+	Items int
+	// LastWrite is when the latest item of the run was written. It is zero
+	// when any item does not tell.
+	LastWrite time.Time
+
+	unknown bool
+}
+
+// Add records an item of the run written at the given time, which is zero
+// when the item does not tell.
+func (r *Run) Add(written time.Time) {
+	r.Items++
+
+	if written.IsZero() {
+		r.unknown = true
+		r.LastWrite = time.Time{}
+		return
+	}
+	if !r.unknown && written.After(r.LastWrite) {
+		r.LastWrite = written
+	}
+}
+
+// Expired checks if the run was last written longer ago than the given
+// retention. Runs whose items do not all tell when they were written expire
+// relative to when they were first seen.
+func (r Run) Expired(retention time.Duration, firstSeen time.Time) bool {
+	t := r.LastWrite
+	if t.IsZero() {
+		t = firstSeen
+	}
+
+	return !t.IsZero() && time.Since(t) >= retention
+}
+
+// ParseTimestamp reads the time an item was written from the given attribute
+// value, which is either an RFC 3339 string or a number of Unix seconds or
+// milliseconds.
+func ParseTimestamp(v string) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+		return t, true
+	}
+
+	n, err := strconv.ParseFloat(v, 64)
+	if err != nil || n <= 0 {
+		return time.Time{}, false
+	}
+
+	// Unix milliseconds have 13 digits until the year 2286.
+	if n >= 1e12 {
+		return time.Unix(0, int64(n*float64(time.Millisecond))), true
+	}
+
+	return time.Unix(0, int64(n*float64(time.Second))), true
+}
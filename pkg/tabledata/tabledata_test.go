@@ -0,0 +1,106 @@
+package tabledata
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRunExpired(t *testing.T) {
+	retention := 24 * time.Hour
+	old := time.Now().Add(-48 * time.Hour)
+	recent := time.Now().Add(-time.Hour)
+
+	tcs := []struct {
+		written     []time.Time
+		firstSeen   time.Time
+		expected    bool
+		description string
+	}{
+		{
+			description: "run with old items only",
+			written:     []time.Time{old, old},
+			expected:    true,
+		},
+		{
+			description: "run with a recent item",
+			written:     []time.Time{old, recent},
+			expected:    false,
+		},
+		{
+			description: "run with an item without timestamp first seen long ago",
+			written:     []time.Time{recent, {}},
+			firstSeen:   old,
+			expected:    true,
+		},
+		{
+			description: "run with an item without timestamp first seen recently",
+			written:     []time.Time{old, {}},
+			firstSeen:   recent,
+			expected:    false,
+		},
+		{
+			description: "run with an item without timestamp never seen",
+			written:     []time.Time{{}},
+			expected:    false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			r := Run{Key: "ci-run-1234"}
+			for _, w := range tc.written {
+				r.Add(w)
+			}
+
+			actual := r.Expired(retention, tc.firstSeen)
+
+			if actual != tc.expected {
+				t.Errorf("checking if run expired, want %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	expected := time.Date(2024, 5, 17, 12, 30, 0, 0, time.UTC)
+
+	tcs := []struct {
+		value       string
+		ok          bool
+		description string
+	}{
+		{
+			description: "RFC 3339 string",
+			value:       "2024-05-17T12:30:00Z",
+			ok:          true,
+		},
+		{
+			description: "Unix seconds",
+			value:       "1715949000",
+			ok:          true,
+		},
+		{
+			description: "Unix milliseconds",
+			value:       "1715949000000",
+			ok:          true,
+		},
+		{
+			description: "no timestamp",
+			value:       "yesterday",
+			ok:          false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual, ok := ParseTimestamp(tc.value)
+
+			if ok != tc.ok {
+				t.Fatalf("parsing %q, want ok %t, got %t", tc.value, tc.ok, ok)
+			}
+			if ok && !actual.Equal(expected) {
+				t.Errorf("parsing %q, want %s, got %s", tc.value, expected, actual)
+			}
+		})
+	}
+}